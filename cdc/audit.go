@@ -0,0 +1,329 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/ticdc/cdc/kv"
+	"github.com/pingcap/ticdc/cdc/model"
+	cdcContext "github.com/pingcap/ticdc/pkg/context"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	// auditPruneInterval is the min interval of removing the expired audit
+	// records from etcd
+	auditPruneInterval = time.Hour
+	// auditWriteTimeout bounds the time of writing an audit record to etcd
+	auditWriteTimeout = 5 * time.Second
+	// auditMaxErrorLen is the max length of the error response recorded
+	auditMaxErrorLen = 1024
+
+	// apiForwardedForHeader and apiForwardedPrincipalHeader are the source
+	// and the principal of a request forwarded to the owner, which are set by
+	// the capture forwarding it.
+	apiForwardedForHeader       = "X-TiCDC-Forwarded-For"
+	apiForwardedPrincipalHeader = "X-TiCDC-Forwarded-Principal"
+)
+
+// auditLogger appends the administrative actions to the audit log in etcd, and
+// the audit log file if it's set. The records in etcd older than the retention
+// are removed.
+type auditLogger struct {
+	retention time.Duration
+
+	mu        sync.Mutex
+	file      *os.File
+	lastPrune time.Time
+}
+
+func newAuditLogger(path string, retention time.Duration) (*auditLogger, error) {
+	a := &auditLogger{retention: retention}
+	if path != "" {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+		if err != nil {
+			return nil, cerror.WrapError(cerror.ErrInvalidServerOption, err)
+		}
+		a.file = file
+	}
+	return a, nil
+}
+
+// record appends the record to the audit log, the failures are logged instead
+// of failing the action, which has been done.
+func (a *auditLogger) record(ctx context.Context, etcdCli *kv.CDCEtcdClient, record *model.AuditRecord) {
+	log.Info("audit", zap.String("action", string(record.Action)),
+		zap.String("principal", record.Principal), zap.String("source", record.Source),
+		zap.String("changefeed", record.Changefeed), zap.String("result", string(record.Result)))
+	if etcdCli != nil {
+		ctx, cancel := context.WithTimeout(ctx, auditWriteTimeout)
+		defer cancel()
+		if err := etcdCli.PutAuditRecord(ctx, record); err != nil {
+			log.Warn("write audit record to etcd failed", zap.Reflect("record", record), zap.Error(err))
+		}
+		a.prune(ctx, etcdCli, record.Time)
+	}
+	if err := a.writeFile(record); err != nil {
+		log.Warn("write audit record to file failed", zap.Reflect("record", record), zap.Error(err))
+	}
+}
+
+func (a *auditLogger) writeFile(record *model.AuditRecord) error {
+	if a.file == nil {
+		return nil
+	}
+	data, err := record.Marshal()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_, err = a.file.WriteString(data + "\n")
+	return errors.Trace(err)
+}
+
+// prune removes the expired records at most once per auditPruneInterval
+func (a *auditLogger) prune(ctx context.Context, etcdCli *kv.CDCEtcdClient, now time.Time) {
+	if a.retention == 0 {
+		return
+	}
+	a.mu.Lock()
+	if now.Sub(a.lastPrune) < auditPruneInterval {
+		a.mu.Unlock()
+		return
+	}
+	a.lastPrune = now
+	a.mu.Unlock()
+	if err := etcdCli.RemoveAuditRecordsBefore(ctx, now.Add(-a.retention)); err != nil {
+		log.Warn("remove expired audit records failed", zap.Error(err))
+	}
+}
+
+func (a *auditLogger) close() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.file == nil {
+		return
+	}
+	if err := a.file.Close(); err != nil {
+		log.Warn("close audit log file failed", zap.Error(err))
+	}
+	a.file = nil
+}
+
+// recordAudit records the action handled by the capture
+func (s *Server) recordAudit(ctx context.Context, record *model.AuditRecord) {
+	if s.auditLog == nil {
+		return
+	}
+	var etcdCli *kv.CDCEtcdClient
+	if s.capture != nil {
+		record.Capture = s.capture.info.ID
+		etcdCli = &s.capture.etcdClient
+	}
+	s.auditLog.record(ctx, etcdCli, record)
+}
+
+// auditAction returns the administrative action of the request, which is empty
+// if the request is not audited, e.g. a query. form is the parameters of the
+// request.
+func auditAction(req *http.Request, form url.Values) model.AuditAction {
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return ""
+	}
+	path := req.URL.Path
+	switch path {
+	case apiV1Prefix + "/changefeeds":
+		if req.Method == http.MethodPost {
+			return model.AuditCreateChangefeed
+		}
+	case apiV1Prefix + "/import":
+		return model.AuditImportChangefeeds
+	case apiV1Prefix + "/batch/changefeeds":
+		return model.AuditBatchCreateChangefeeds
+	case "/capture/owner/admin":
+		typ, err := strconv.Atoi(form.Get(APIOpVarAdminJob))
+		if err != nil {
+			return model.AuditAdminJob
+		}
+		return model.AuditActionOfAdminJob(model.AdminJobType(typ))
+	case "/capture/owner/resign":
+		return model.AuditResignOwner
+	case "/capture/owner/rebalance_trigger":
+		return model.AuditRebalanceTables
+	case "/capture/owner/move_table":
+		return model.AuditMoveTable
+	}
+	if !strings.HasPrefix(path, apiV1Prefix+"/changefeeds/") {
+		return ""
+	}
+	parts := strings.SplitN(strings.TrimPrefix(path, apiV1Prefix+"/changefeeds/"), "/", 2)
+	action := ""
+	if len(parts) > 1 {
+		action = parts[1]
+	}
+	switch {
+	case action == "" && req.Method == http.MethodPut:
+		return model.AuditUpdateChangefeed
+	case action == "" && req.Method == http.MethodDelete:
+		return model.AuditRemoveChangefeed
+	case action == "pause":
+		return model.AuditPauseChangefeed
+	case action == "resume":
+		return model.AuditResumeChangefeed
+	case action == "tables/move":
+		return model.AuditMoveTable
+	}
+	return ""
+}
+
+// newAuditRecord returns the audit record of the request, whose body is
+// buffered and restored to be read by the handler. The record is nil if the
+// request is not audited.
+func newAuditRecord(req *http.Request) (*model.AuditRecord, error) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, cerror.ErrAPIInvalidParam.GenWithStack("invalid request body: %s", err)
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	params := make(map[string]interface{})
+	form := req.URL.Query()
+	var jsonBody interface{}
+	if strings.HasPrefix(req.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		bodyForm, err := url.ParseQuery(string(body))
+		if err == nil {
+			for key, values := range bodyForm {
+				form[key] = append(form[key], values...)
+			}
+		}
+	} else if len(body) > 0 && json.Unmarshal(body, &jsonBody) == nil {
+		params["body"] = model.RedactAuditParam("", jsonBody)
+	}
+	action := auditAction(req, form)
+	if action == "" {
+		return nil, nil
+	}
+	for key := range form {
+		params[key] = model.RedactAuditParam(key, form.Get(key))
+	}
+
+	record := &model.AuditRecord{
+		Time:   time.Now(),
+		Action: action,
+		Source: req.RemoteAddr,
+		Params: params,
+	}
+	if req.TLS != nil && len(req.TLS.PeerCertificates) > 0 {
+		record.Principal = req.TLS.PeerCertificates[0].Subject.CommonName
+	}
+	if req.Header.Get(apiForwardedHeader) != "" {
+		record.Source = req.Header.Get(apiForwardedForHeader)
+		record.Principal = req.Header.Get(apiForwardedPrincipalHeader)
+	}
+
+	// the changefeed is in the path of the API, or the parameters
+	switch {
+	case strings.HasPrefix(req.URL.Path, apiV1Prefix+"/changefeeds/"):
+		record.Changefeed = strings.SplitN(strings.TrimPrefix(req.URL.Path, apiV1Prefix+"/changefeeds/"), "/", 2)[0]
+	case form.Get(APIOpVarChangefeedID) != "":
+		record.Changefeed = form.Get(APIOpVarChangefeedID)
+	default:
+		if m, ok := jsonBody.(map[string]interface{}); ok {
+			if id, ok := m["changefeed_id"].(string); ok {
+				record.Changefeed = id
+			}
+		}
+	}
+	return record, nil
+}
+
+type auditRecordKey struct{}
+
+// setAuditChangefeed sets the changefeed of the audit record of the request,
+// if it's decided by the handler, e.g. the generated ID of a new changefeed.
+func setAuditChangefeed(req *http.Request, changefeedID model.ChangeFeedID) {
+	if record, ok := req.Context().Value(auditRecordKey{}).(*model.AuditRecord); ok {
+		record.Changefeed = changefeedID
+	}
+}
+
+// auditResponseWriter records the status code and the error of a response
+type auditResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (w *auditResponseWriter) WriteHeader(statusCode int) {
+	w.statusCode = statusCode
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *auditResponseWriter) Write(data []byte) (int, error) {
+	if w.statusCode >= http.StatusBadRequest && w.body.Len() < auditMaxErrorLen {
+		w.body.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// audited records the administrative actions handled by the handler into the
+// audit log.
+func (s *Server) audited(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		record, err := newAuditRecord(req)
+		if err != nil {
+			writeAPIError(w, err)
+			return
+		}
+		if record == nil {
+			handler(w, req)
+			return
+		}
+		aw := &auditResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		handler(aw, req.WithContext(context.WithValue(req.Context(), auditRecordKey{}, record)))
+		if aw.statusCode >= http.StatusBadRequest {
+			msg := strings.TrimSpace(aw.body.String())
+			apiErr := &APIError{}
+			if json.Unmarshal(aw.body.Bytes(), apiErr) == nil && apiErr.Code != "" {
+				msg = apiErr.Code + ": " + apiErr.Message
+			}
+			if len(msg) > auditMaxErrorLen {
+				msg = msg[:auditMaxErrorLen]
+			}
+			if msg == "" {
+				msg = http.StatusText(aw.statusCode)
+			}
+			record.SetResult(errors.New(msg))
+		} else {
+			record.SetResult(nil)
+		}
+		// the action is done even if the request is canceled
+		s.recordAudit(cdcContext.Detach(req.Context()), record)
+	}
+}
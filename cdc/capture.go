@@ -16,6 +16,7 @@ package cdc
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -24,6 +25,7 @@ import (
 	"github.com/pingcap/log"
 	"github.com/pingcap/ticdc/cdc/kv"
 	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/pkg/buckets"
 	cerror "github.com/pingcap/ticdc/pkg/errors"
 	"github.com/pingcap/ticdc/pkg/security"
 	"go.etcd.io/etcd/clientv3"
@@ -36,11 +38,15 @@ import (
 
 const (
 	captureSessionTTL = 3
+	// drainCheckInterval is the interval of checking whether the tables of a
+	// draining capture are all moved
+	drainCheckInterval = 500 * time.Millisecond
 )
 
 // processorOpts records options for processor
 type processorOpts struct {
 	flushCheckpointInterval time.Duration
+	sorterMemQuota          *buckets.Bucket
 }
 
 // Capture represents a Capture server, it monitors the changefeed information in etcd and schedules Task on it.
@@ -52,6 +58,8 @@ type Capture struct {
 	procLock   sync.Mutex
 
 	info *model.CaptureInfo
+	// draining is set to 1 when the capture starts to exit gracefully
+	draining int32
 
 	// session keeps alive between the capture and etcd
 	session  *concurrency.Session
@@ -177,6 +185,39 @@ func (c *Capture) Campaign(ctx context.Context) error {
 	return cerror.WrapError(cerror.ErrCaptureCampaignOwner, c.election.Campaign(ctx, c.info.ID))
 }
 
+// ShouldCampaign returns whether the capture campaigns to be the owner by its
+// owner preference, a normal capture campaigns only if there is no preferred
+// capture alive, so that the owner fails over to it only when needed.
+func (c *Capture) ShouldCampaign(ctx context.Context) (bool, error) {
+	switch c.info.OwnerPreference {
+	case model.OwnerNever:
+		return false, nil
+	case model.OwnerPreferred:
+		return true, nil
+	}
+	preferred, err := c.hasPreferredOwner(ctx)
+	return !preferred, errors.Trace(err)
+}
+
+// hasPreferredOwner returns whether there is a preferred capture alive other
+// than the capture.
+func (c *Capture) hasPreferredOwner(ctx context.Context) (bool, error) {
+	_, captures, err := c.etcdClient.GetCaptures(ctx)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	return hasPreferredOwner(captures, c.info.ID), nil
+}
+
+func hasPreferredOwner(captures []*model.CaptureInfo, self model.CaptureID) bool {
+	for _, capture := range captures {
+		if capture.ID != self && capture.IsPreferredOwner() {
+			return true
+		}
+	}
+	return false
+}
+
 // Resign lets a owner start a new election.
 func (c *Capture) Resign(ctx context.Context) error {
 	failpoint.Inject("capture-resign-failed", func() {
@@ -185,6 +226,72 @@ func (c *Capture) Resign(ctx context.Context) error {
 	return cerror.WrapError(cerror.ErrCaptureResignOwner, c.election.Resign(ctx))
 }
 
+// Drain marks the capture as draining, so that the owner moves all of its
+// tables to the other captures, and waits until the tables are moved or the
+// ctx is done. A table is removed from the capture after its sink is flushed to
+// the checkpoint ts the table is moved at, so the replication is continued by
+// the other captures without regressing the checkpoint.
+func (c *Capture) Drain(ctx context.Context) error {
+	atomic.StoreInt32(&c.draining, 1)
+	info := *c.info
+	info.Draining = true
+	err := c.etcdClient.PutCaptureInfo(ctx, &info, c.session.Lease())
+	if err != nil {
+		return cerror.WrapError(cerror.ErrCaptureRegister, err)
+	}
+	log.Info("capture is draining", zap.String("capture", c.info.ID))
+
+	ticker := time.NewTicker(drainCheckInterval)
+	defer ticker.Stop()
+	for {
+		tableCount, err := c.countTables(ctx)
+		if err != nil {
+			log.Warn("check the tables of the draining capture failed", zap.String("capture", c.info.ID), zap.Error(err))
+		} else if tableCount == 0 {
+			log.Info("all the tables are moved out of the draining capture", zap.String("capture", c.info.ID))
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			log.Warn("drain capture is interrupted",
+				zap.String("capture", c.info.ID), zap.Int("tables", tableCount), zap.Error(ctx.Err()))
+			return errors.Trace(ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// IsDraining returns true if the capture is exiting gracefully.
+func (c *Capture) IsDraining() bool {
+	return atomic.LoadInt32(&c.draining) == 1
+}
+
+// countTables returns the number of the tables in the capture, including the
+// ones being removed.
+func (c *Capture) countTables(ctx context.Context) (int, error) {
+	_, changefeeds, err := c.etcdClient.GetChangeFeeds(ctx)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	count := 0
+	for changefeedID := range changefeeds {
+		_, status, err := c.etcdClient.GetTaskStatus(ctx, changefeedID, c.info.ID)
+		if cerror.ErrTaskStatusNotExists.Equal(err) {
+			continue
+		}
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+		count += len(status.Tables)
+		for _, op := range status.Operation {
+			if !op.TableProcessed() {
+				count++
+			}
+		}
+	}
+	return count, nil
+}
+
 // Cleanup cleans all dynamic resources
 func (c *Capture) Cleanup() {
 	c.procLock.Lock()
@@ -238,7 +345,7 @@ func (c *Capture) assignTask(ctx context.Context, task *Task) (*processor, error
 		zap.String("changefeedid", task.ChangeFeedID))
 
 	p, err := runProcessor(
-		ctx, c.credential, c.session, *cf, task.ChangeFeedID, *c.info, task.CheckpointTS, c.opts.flushCheckpointInterval)
+		ctx, c.credential, c.session, *cf, task.ChangeFeedID, *c.info, task.CheckpointTS, c.opts.flushCheckpointInterval, c.opts.sorterMemQuota)
 	if err != nil {
 		log.Error("run processor failed",
 			zap.String("changefeedid", task.ChangeFeedID),
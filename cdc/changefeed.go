@@ -30,6 +30,7 @@ import (
 	"github.com/pingcap/ticdc/pkg/cyclic/mark"
 	cerror "github.com/pingcap/ticdc/pkg/errors"
 	"github.com/pingcap/ticdc/pkg/filter"
+	"github.com/pingcap/ticdc/pkg/logutil"
 	"github.com/pingcap/ticdc/pkg/scheduler"
 	"github.com/pingcap/tidb/sessionctx/binloginfo"
 	"go.etcd.io/etcd/mvcc/mvccpb"
@@ -38,6 +39,10 @@ import (
 
 type tableIDMap = map[model.TableID]struct{}
 
+// maxTableFailures is the number of the failures after which a failed table
+// is quarantined instead of rescheduled.
+const maxTableFailures = 3
+
 // OwnerDDLHandler defines the ddl handler for Owner
 // which can pull ddl jobs and execute ddl jobs
 type OwnerDDLHandler interface {
@@ -68,6 +73,10 @@ type ChangeFeedRWriter interface {
 	// RemoveAllTaskPositions removes all task partitions of a changefeed
 	RemoveAllTaskPositions(ctx context.Context, changefeedID string) error
 
+	// GetAllTableProgress queries the table progress of all processors of a
+	// changefeed, and returns a map mapping from captureID to TableProgress
+	GetAllTableProgress(ctx context.Context, changefeedID string) (map[string]*model.TableProgress, error)
+
 	// GetChangeFeedStatus queries the checkpointTs and resovledTs of a given changefeed
 	GetChangeFeedStatus(ctx context.Context, id string) (*model.ChangeFeedStatus, int64, error)
 	// PutAllChangeFeedStatus the changefeed info to storage such as etcd.
@@ -110,18 +119,26 @@ type changeFeed struct {
 	moveTableJobs      map[model.TableID]*model.MoveTableJob
 	manualMoveCommands []*model.MoveTableJob
 	rebalanceNextTick  bool
+	// tableFailures counts the failures of the tables reported by the
+	// processors, quarantinedTables are the tables failed too many times,
+	// which are not replicated until the changefeed is restarted.
+	tableFailures     map[model.TableID]int
+	quarantinedTables map[model.TableID]model.Ts
 
 	lastRebalanceTime time.Time
+	// configReloading is set once the config is reloaded by the owner, until
+	// all the processors reload it as well, see reloadConfig.
+	configReloading bool
 
 	etcdCli kv.CDCEtcdClient
 }
 
 // String implements fmt.Stringer interface.
 func (c *changeFeed) String() string {
-	format := "{\n ID: %s\n info: %+v\n status: %+v\n State: %v\n ProcessorInfos: %+v\n tables: %+v\n orphanTables: %+v\n toCleanTables: %v\n ddlResolvedTs: %d\n ddlJobHistory: %+v\n}\n\n"
+	format := "{\n ID: %s\n info: %+v\n status: %+v\n State: %v\n ProcessorInfos: %+v\n tables: %+v\n orphanTables: %+v\n toCleanTables: %v\n quarantinedTables: %v\n ddlResolvedTs: %d\n ddlJobHistory: %+v\n}\n\n"
 	s := fmt.Sprintf(format,
 		c.id, c.info, c.status, c.ddlState, c.taskStatus, c.tables,
-		c.orphanTables, c.toCleanTables, c.ddlResolvedTs, c.ddlJobHistory)
+		c.orphanTables, c.toCleanTables, c.quarantinedTables, c.ddlResolvedTs, c.ddlJobHistory)
 
 	if len(c.ddlJobHistory) > 0 {
 		job := c.ddlJobHistory[0]
@@ -250,12 +267,93 @@ func (c *changeFeed) updatePartition(tblInfo *timodel.TableInfo, startTs uint64)
 	}
 }
 
+// reloadConfig applies the updated config to the running changefeed. The sink
+// and the filter of the owner are recreated, and the tables no longer matching
+// the filter rules are removed at the checkpoint ts. The processors reload the
+// config by themselves, and the tables newly matching the filter rules are not
+// added until all the processors reload it, otherwise their events may be
+// ignored by a processor still using the old filter rules.
+func (c *changeFeed) reloadConfig(ctx context.Context, info *model.ChangeFeedInfo) error {
+	if err := c.info.VerifyHotReload(info); err != nil {
+		return errors.Trace(err)
+	}
+	filter, err := filter.NewFilter(info.Config)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	credential, err := info.GetCredential()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	errCh := make(chan error, 1)
+	newSink, err := sink.NewSink(ctx, c.id, info.SinkURI, filter, info.Config, credential, info.Opts, errCh)
+	if err != nil {
+		cancel()
+		return errors.Trace(err)
+	}
+	go func() {
+		err := <-errCh
+		if errors.Cause(err) != context.Canceled {
+			log.Error("error on running owner", zap.Error(err))
+		}
+		cancel()
+	}()
+	if err := c.sink.Close(); err != nil {
+		log.Warn("failed to close the sink", zap.String("changefeed", c.id), zap.Error(err))
+	}
+	c.sink = newSink
+	c.filter = filter
+	c.info = info
+
+	for sid, tables := range c.schemas {
+		for tid := range tables {
+			name := c.tables[tid]
+			if filter.ShouldIgnoreTable(name.Schema, name.Table) {
+				log.Info("remove the table ignored by the reloaded config",
+					zap.String("changefeed", c.id), zap.Int64("tableID", tid), zap.Stringer("table", name))
+				c.removeTable(sid, tid, c.status.CheckpointTs)
+			}
+		}
+	}
+	c.configReloading = true
+	log.Info("changefeed config reloaded by owner",
+		zap.String("changefeed", c.id), zap.Uint64("configVersion", info.ConfigVersion))
+	return nil
+}
+
+// addReloadedTables adds the tables newly matching the filter rules once all
+// the processors reload the config.
+func (c *changeFeed) addReloadedTables() {
+	if !c.configReloading || len(c.taskPositions) < len(c.taskStatus) {
+		return
+	}
+	for _, position := range c.taskPositions {
+		if position.ConfigVersion < c.info.ConfigVersion {
+			return
+		}
+	}
+	c.configReloading = false
+	for tid := range c.schema.CloneTables() {
+		if _, ok := c.tables[tid]; ok {
+			continue
+		}
+		tblInfo, ok := c.schema.TableByID(tid)
+		if !ok {
+			continue
+		}
+		// addTable ignores the tables not matching the filter rules
+		c.addTable(tblInfo, c.status.CheckpointTs)
+	}
+}
+
 func (c *changeFeed) tryBalance(ctx context.Context, captures map[string]*model.CaptureInfo, rebalanceNow bool,
 	manualMoveCommands []*model.MoveTableJob) error {
 	err := c.balanceOrphanTables(ctx, captures)
 	if err != nil {
 		return errors.Trace(err)
 	}
+	c.handleFailedTables(captures)
 	c.manualMoveCommands = append(c.manualMoveCommands, manualMoveCommands...)
 	if rebalanceNow {
 		c.rebalanceNextTick = true
@@ -432,6 +530,112 @@ func (c *changeFeed) handleManualMoveTableJobs(ctx context.Context, captures map
 	return nil
 }
 
+// handleFailedTables moves the tables failed in the processors out of their
+// captures, a failed table is added back at the checkpoint of the changefeed
+// to the capture with the fewest tables. The table is quarantined instead
+// once it's failed maxTableFailures times.
+func (c *changeFeed) handleFailedTables(captures map[model.CaptureID]*model.CaptureInfo) {
+	if len(captures) == 0 {
+		return
+	}
+	for captureID, status := range c.taskStatus {
+		for tableID, failure := range status.FailedTables {
+			if _, exist := status.Tables[tableID]; !exist {
+				// the table is being removed
+				continue
+			}
+			if _, exist := c.moveTableJobs[tableID]; exist {
+				continue
+			}
+			if c.tableFailures == nil {
+				c.tableFailures = make(map[model.TableID]int)
+			}
+			c.tableFailures[tableID]++
+			job := &model.MoveTableJob{TableID: tableID, From: captureID}
+			if c.tableFailures[tableID] >= maxTableFailures {
+				if c.quarantinedTables == nil {
+					c.quarantinedTables = make(map[model.TableID]model.Ts)
+				}
+				c.quarantinedTables[tableID] = c.status.CheckpointTs
+				log.Error("the table is failed too many times, quarantine it, "+
+					"it's not replicated until the changefeed is restarted",
+					zap.String("changefeed", c.id), zap.String("capture", captureID),
+					zap.Int64("tableID", tableID), zap.Uint64("checkpointTs", c.status.CheckpointTs),
+					zap.Int("failures", c.tableFailures[tableID]), zap.Reflect("failure", failure))
+			} else {
+				job.To = c.pickCaptureForFailedTable(captures, captureID)
+				log.Warn("the table is failed, reschedule it",
+					zap.String("changefeed", c.id), zap.Reflect("job", job),
+					zap.Int("failures", c.tableFailures[tableID]), zap.Reflect("failure", failure))
+			}
+			if c.moveTableJobs == nil {
+				c.moveTableJobs = make(map[model.TableID]*model.MoveTableJob)
+			}
+			c.moveTableJobs[tableID] = job
+		}
+	}
+}
+
+// pickCaptureForFailedTable returns the capture with the fewest tables except
+// the one the table is failed in, which is returned if it's the only capture.
+func (c *changeFeed) pickCaptureForFailedTable(captures map[model.CaptureID]*model.CaptureInfo, from model.CaptureID) model.CaptureID {
+	target := from
+	targetCount := 0
+	for captureID := range captures {
+		if captureID == from {
+			continue
+		}
+		count := 0
+		if status, exist := c.taskStatus[captureID]; exist {
+			count = len(status.Tables)
+		}
+		if target == from || count < targetCount || (count == targetCount && captureID < target) {
+			target = captureID
+			targetCount = count
+		}
+	}
+	return target
+}
+
+// drainCaptures moves the tables of the draining captures to the other
+// captures, each table is moved to the capture with the fewest tables. The
+// jobs are generated when the previous move jobs are all finished.
+func (c *changeFeed) drainCaptures(captures, draining map[model.CaptureID]*model.CaptureInfo) {
+	if len(captures) == 0 || len(draining) == 0 {
+		return
+	}
+	if len(c.moveTableJobs) != 0 || len(c.manualMoveCommands) != 0 {
+		return
+	}
+	tableCounts := make(map[model.CaptureID]int, len(captures))
+	for captureID := range captures {
+		if status, exist := c.taskStatus[captureID]; exist {
+			tableCounts[captureID] = len(status.Tables)
+		} else {
+			tableCounts[captureID] = 0
+		}
+	}
+	for captureID := range draining {
+		status, exist := c.taskStatus[captureID]
+		if !exist {
+			continue
+		}
+		for tableID := range status.Tables {
+			var target model.CaptureID
+			for cid, count := range tableCounts {
+				if target == "" || count < tableCounts[target] || (count == tableCounts[target] && cid < target) {
+					target = cid
+				}
+			}
+			tableCounts[target]++
+			job := &model.MoveTableJob{TableID: tableID, To: target}
+			c.manualMoveCommands = append(c.manualMoveCommands, job)
+			log.Info("move the table out of the draining capture",
+				zap.String("changefeed", c.id), zap.String("capture", captureID), zap.Reflect("job", job))
+		}
+	}
+}
+
 func (c *changeFeed) rebalanceTables(ctx context.Context, captures map[model.CaptureID]*model.CaptureInfo) error {
 	if len(captures) == 0 {
 		return nil
@@ -520,6 +724,11 @@ func (c *changeFeed) handleMoveTableJobs(ctx context.Context, captures map[model
 			job.Status = model.MoveTableStatusDeleted
 			log.Info("handle the move job, remove table from the source capture", zap.Reflect("job", job))
 		case model.MoveTableStatusDeleted:
+			if _, quarantined := c.quarantinedTables[tableID]; quarantined {
+				delete(c.moveTableJobs, tableID)
+				log.Info("handle the move job, the table is quarantined", zap.Reflect("job", job))
+				continue
+			}
 			// add table to target capture
 			status, exist := cloneStatus(job.To)
 			if !exist {
@@ -615,13 +824,13 @@ func (c *changeFeed) handleDDL(ctx context.Context, captures map[string]*model.C
 		log.Debug("wait checkpoint ts",
 			zap.Uint64("checkpoint ts", c.status.CheckpointTs),
 			zap.Uint64("finish ts", todoDDLJob.BinlogInfo.FinishedTS),
-			zap.String("ddl query", todoDDLJob.Query))
+			logutil.ZapRedactString("ddl query", todoDDLJob.Query))
 		return nil
 	}
 
 	log.Info("apply job", zap.Stringer("job", todoDDLJob),
 		zap.String("schema", todoDDLJob.SchemaName),
-		zap.String("query", todoDDLJob.Query),
+		logutil.ZapRedactString("query", todoDDLJob.Query),
 		zap.Uint64("ts", todoDDLJob.BinlogInfo.FinishedTS))
 
 	ddlEvent := new(model.DDLEvent)
@@ -663,7 +872,7 @@ func (c *changeFeed) handleDDL(ctx context.Context, captures map[string]*model.C
 	executed := false
 	if !c.cyclicEnabled || c.info.Config.Cyclic.SyncDDL {
 		ddlEvent.Query = binloginfo.AddSpecialComment(ddlEvent.Query)
-		log.Debug("DDL processed to make special features mysql-compatible", zap.String("query", ddlEvent.Query))
+		log.Debug("DDL processed to make special features mysql-compatible", logutil.ZapRedactString("query", ddlEvent.Query))
 		err = c.sink.EmitDDLEvent(ctx, ddlEvent)
 		// If DDL executing failed, pause the changefeed and print log, rather
 		// than return an error and break the running of this owner.
@@ -895,7 +1104,7 @@ func (c *changeFeed) pullDDLJob() error {
 	c.ddlResolvedTs = ddlResolvedTs
 	for _, ddl := range ddlJobs {
 		if c.filter.ShouldDiscardDDL(ddl.Type) {
-			log.Info("discard the ddl job", zap.Int64("jobID", ddl.ID), zap.String("query", ddl.Query))
+			log.Info("discard the ddl job", zap.Int64("jobID", ddl.ID), logutil.ZapRedactString("query", ddl.Query))
 			continue
 		}
 		c.ddlJobHistory = append(c.ddlJobHistory, ddl)
@@ -0,0 +1,183 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdc
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pingcap/log"
+	"github.com/pingcap/ticdc/cdc/kv"
+	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/pkg/config"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+	"github.com/pingcap/ticdc/pkg/util"
+	"github.com/pingcap/tidb/store/tikv/oracle"
+	"go.uber.org/zap"
+)
+
+// ChangefeedManifest is the manifest of the changefeeds created in a batch
+type ChangefeedManifest struct {
+	// Defaults are shared by all the changefeeds, the non-zero fields of each
+	// changefeed override them. The opts and the replica configs are merged.
+	Defaults    *ChangefeedConfig   `json:"defaults"`
+	Changefeeds []*ChangefeedConfig `json:"changefeeds"`
+}
+
+// BatchCreateResult is the result of creating a changefeed of the manifest
+type BatchCreateResult struct {
+	ID      model.ChangeFeedID `json:"changefeed_id"`
+	Created bool               `json:"created"`
+	Error   *APIError          `json:"error,omitempty"`
+}
+
+// merge returns the config of a changefeed with the defaults applied
+func (m *ChangefeedManifest) merge(cfg *ChangefeedConfig) *ChangefeedConfig {
+	merged := *cfg
+	defaults := m.Defaults
+	if defaults == nil {
+		return &merged
+	}
+	if merged.SinkURI == "" {
+		merged.SinkURI = defaults.SinkURI
+	}
+	if merged.StartTs == 0 {
+		merged.StartTs = defaults.StartTs
+	}
+	if merged.TargetTs == 0 {
+		merged.TargetTs = defaults.TargetTs
+	}
+	if merged.SortEngine == "" {
+		merged.SortEngine = defaults.SortEngine
+	}
+	if merged.SortDir == "" {
+		merged.SortDir = defaults.SortDir
+	}
+	if merged.GCTTL == 0 {
+		merged.GCTTL = defaults.GCTTL
+	}
+	if merged.Credential == nil {
+		merged.Credential = defaults.Credential
+	}
+	if merged.Upstream == nil {
+		merged.Upstream = defaults.Upstream
+	}
+	merged.Opts = make(map[string]string, len(defaults.Opts)+len(cfg.Opts))
+	for key, value := range defaults.Opts {
+		merged.Opts[key] = value
+	}
+	for key, value := range cfg.Opts {
+		merged.Opts[key] = value
+	}
+	return &merged
+}
+
+// CreateChangefeeds creates the changefeeds of the manifest. All the
+// changefeeds are validated before any of them is created, and none is created
+// if any of them is invalid. The changefeeds of the same upstream without the
+// start ts start from the same current ts. The result of each changefeed is
+// returned in the order of the manifest.
+func CreateChangefeeds(
+	ctx context.Context, cli kv.CDCEtcdClient, upstreams UpstreamClients, manifest *ChangefeedManifest, timezone *time.Location,
+) ([]*BatchCreateResult, error) {
+	ctx = util.PutTimezoneInCtx(ctx, timezone)
+	currentTs := make(map[string]uint64)
+	var defaultReplicaConfig json.RawMessage
+	if manifest.Defaults != nil {
+		defaultReplicaConfig = manifest.Defaults.ReplicaConfig
+	}
+
+	results := make([]*BatchCreateResult, len(manifest.Changefeeds))
+	infos := make([]*model.ChangeFeedInfo, len(manifest.Changefeeds))
+	ids := make(map[model.ChangeFeedID]struct{}, len(manifest.Changefeeds))
+	invalid := 0
+	for i, entry := range manifest.Changefeeds {
+		cfg := manifest.merge(entry)
+		if cfg.ID == "" {
+			cfg.ID = uuid.New().String()
+		}
+		results[i] = &BatchCreateResult{ID: cfg.ID}
+		info, err := verifyManifestEntry(ctx, cli, upstreams, cfg, defaultReplicaConfig, ids, currentTs)
+		if err != nil {
+			results[i].Error, _ = newAPIError(err)
+			invalid++
+			continue
+		}
+		ids[cfg.ID] = struct{}{}
+		infos[i] = info
+	}
+	if invalid != 0 {
+		return results, cerror.ErrInvalidChangefeedManifest.GenWithStackByArgs(invalid, len(manifest.Changefeeds))
+	}
+
+	for i, info := range infos {
+		id := results[i].ID
+		if err := cli.CreateChangefeedInfo(ctx, info, id); err != nil {
+			log.Warn("create changefeed failed", zap.String("changefeed", id), zap.Error(err))
+			results[i].Error, _ = newAPIError(err)
+			continue
+		}
+		log.Info("changefeed is created in a batch", zap.String("changefeed", id), zap.Stringer("info", info))
+		results[i].Created = true
+	}
+	return results, nil
+}
+
+// verifyManifestEntry verifies a changefeed of the manifest, the start ts is
+// the current ts of the upstream if it's not set, which is recorded in
+// currentTs by the ID of the upstream.
+func verifyManifestEntry(
+	ctx context.Context, cli kv.CDCEtcdClient, upstreams UpstreamClients, cfg *ChangefeedConfig,
+	defaultReplicaConfig json.RawMessage, ids map[model.ChangeFeedID]struct{}, currentTs map[string]uint64,
+) (*model.ChangeFeedInfo, error) {
+	if _, ok := ids[cfg.ID]; ok {
+		return nil, cerror.ErrAPIInvalidParam.GenWithStack("duplicate changefeed_id %s in the manifest", cfg.ID)
+	}
+	_, err := cli.GetChangeFeedInfo(ctx, cfg.ID)
+	if err == nil {
+		return nil, cerror.ErrChangeFeedAlreadyExists.GenWithStackByArgs(cfg.ID)
+	}
+	if cerror.ErrChangeFeedNotExists.NotEqual(err) {
+		return nil, err
+	}
+	if cfg.Upstream != nil {
+		if err := cfg.Upstream.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	pdCli, gcCli, err := upstreams(ctx, cfg.Upstream)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.StartTs == 0 {
+		upstreamID := cfg.Upstream.ID()
+		if currentTs[upstreamID] == 0 {
+			ts, logical, err := pdCli.GetTS(ctx)
+			if err != nil {
+				return nil, cerror.WrapError(cerror.ErrPDEtcdAPIError, err)
+			}
+			currentTs[upstreamID] = oracle.ComposeTS(ts, logical)
+		}
+		cfg.StartTs = currentTs[upstreamID]
+	}
+	replicaConfig := config.GetDefaultReplicaConfig()
+	if len(defaultReplicaConfig) > 0 {
+		if err := replicaConfig.Unmarshal(defaultReplicaConfig); err != nil {
+			return nil, cerror.ErrAPIInvalidParam.GenWithStack("invalid default replica_config: %s", err)
+		}
+	}
+	return newChangefeedInfo(ctx, gcCli, cfg, replicaConfig)
+}
@@ -0,0 +1,158 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdc
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/ticdc/cdc/kv"
+	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/cdc/sink"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+	"github.com/pingcap/ticdc/pkg/util"
+	"go.uber.org/zap"
+)
+
+// ExportChangefeeds exports the definitions of all the changefeeds in the
+// cluster, except the removed and finished ones.
+func ExportChangefeeds(ctx context.Context, cli kv.CDCEtcdClient) (*model.ChangefeedExport, error) {
+	_, raw, err := cli.GetChangeFeeds(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]model.ChangeFeedID, 0, len(raw))
+	for id := range raw {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	export := &model.ChangefeedExport{
+		Version:     model.ChangefeedExportVersion,
+		ExportTime:  time.Now(),
+		Changefeeds: make([]*model.ChangefeedDefinition, 0, len(ids)),
+	}
+	for _, id := range ids {
+		info, err := cli.GetChangeFeedInfo(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		status, _, err := cli.GetChangeFeedStatus(ctx, id)
+		if err != nil && cerror.ErrChangeFeedNotExists.NotEqual(err) {
+			return nil, err
+		}
+		if status != nil && (status.AdminJobType == model.AdminRemove || status.AdminJobType == model.AdminFinish) {
+			continue
+		}
+		def, err := model.NewChangefeedDefinition(id, info, status)
+		if err != nil {
+			return nil, errors.Annotatef(err, "export changefeed %s", id)
+		}
+		export.Changefeeds = append(export.Changefeeds, def)
+	}
+	return export, nil
+}
+
+// ImportOptions is the options to import the changefeeds
+type ImportOptions struct {
+	// StartTs is the start ts of all the imported changefeeds, the changefeeds
+	// start from their exported checkpoints if it's 0.
+	StartTs uint64
+	// GetSecret looks up the secrets referenced by the changefeed definitions
+	GetSecret func(name string) (string, bool)
+	// Timezone is the timezone to validate the sinks
+	Timezone *time.Location
+	// Upstreams returns the clients of the upstreams the changefeeds replicate
+	// from, the start ts are checked against their GC safepoints.
+	Upstreams UpstreamClients
+}
+
+// ImportChangefeeds creates the changefeeds of the exported definitions. All
+// the changefeeds are validated before any of them is created, the IDs of the
+// created changefeeds are returned.
+func ImportChangefeeds(
+	ctx context.Context, cli kv.CDCEtcdClient, export *model.ChangefeedExport, opts ImportOptions,
+) ([]model.ChangeFeedID, error) {
+	if export.Version != model.ChangefeedExportVersion {
+		return nil, cerror.ErrInvalidChangefeedExport.GenWithStack(
+			"unsupported version %d, %d is expected", export.Version, model.ChangefeedExportVersion)
+	}
+	ctx = util.PutTimezoneInCtx(ctx, opts.Timezone)
+	infos := make([]*model.ChangeFeedInfo, 0, len(export.Changefeeds))
+	for _, def := range export.Changefeeds {
+		info, err := verifyChangefeedDefinition(ctx, cli, def, opts)
+		if err != nil {
+			return nil, errors.Annotatef(err, "import changefeed %s", def.ID)
+		}
+		infos = append(infos, info)
+	}
+
+	created := make([]model.ChangeFeedID, 0, len(infos))
+	for i, info := range infos {
+		id := export.Changefeeds[i].ID
+		if err := cli.CreateChangefeedInfo(ctx, info, id); err != nil {
+			return created, errors.Annotatef(err, "import changefeed %s", id)
+		}
+		log.Info("changefeed is imported", zap.String("changefeed", id), zap.Stringer("info", info))
+		created = append(created, id)
+	}
+	return created, nil
+}
+
+func verifyChangefeedDefinition(
+	ctx context.Context, cli kv.CDCEtcdClient, def *model.ChangefeedDefinition, opts ImportOptions,
+) (*model.ChangeFeedInfo, error) {
+	if err := model.ValidateChangefeedID(def.ID); err != nil {
+		return nil, err
+	}
+	_, err := cli.GetChangeFeedInfo(ctx, def.ID)
+	if err == nil {
+		return nil, cerror.ErrChangeFeedAlreadyExists.GenWithStackByArgs(def.ID)
+	}
+	if cerror.ErrChangeFeedNotExists.NotEqual(err) {
+		return nil, err
+	}
+	info, credential, err := def.ToChangeFeedInfo(opts.GetSecret, opts.StartTs)
+	if err != nil {
+		return nil, err
+	}
+	if info.Upstream != nil {
+		if err := info.Upstream.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	_, gcCli, err := opts.Upstreams(ctx, info.Upstream)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyStartTs(ctx, gcCli, info.StartTs); err != nil {
+		return nil, err
+	}
+	if info.TargetTs > 0 && info.TargetTs <= info.StartTs {
+		return nil, cerror.ErrInvalidChangefeedExport.GenWithStack(
+			"target ts %d must be larger than start ts %d", info.TargetTs, info.StartTs)
+	}
+	if err := info.VerifyAndFix(); err != nil {
+		return nil, err
+	}
+	if err := credential.Validate(); err != nil {
+		return nil, err
+	}
+	if err := sink.Validate(ctx, info.SinkURI, info.Config, credential, info.Opts); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/pingcap/ticdc/cdc/model"
 	cerror "github.com/pingcap/ticdc/pkg/errors"
 	"github.com/pingcap/ticdc/pkg/filter"
+	"github.com/pingcap/ticdc/pkg/logutil"
 	"github.com/pingcap/ticdc/pkg/retry"
 	timeta "github.com/pingcap/tidb/meta"
 	"go.uber.org/zap"
@@ -509,7 +510,7 @@ func (s *schemaSnapshot) handleDDL(job *timodel.Job) error {
 	if err := s.FillSchemaName(job); err != nil {
 		return errors.Trace(err)
 	}
-	log.Debug("handle job: ", zap.String("sql query", job.Query), zap.Stringer("job", job))
+	log.Debug("handle job: ", logutil.ZapRedactString("sql query", job.Query), zap.Stringer("job", job))
 	getWrapTableInfo := func(job *timodel.Job) *model.TableInfo {
 		return model.WrapTableInfo(job.SchemaID, job.SchemaName,
 			job.BinlogInfo.FinishedTS,
@@ -756,7 +757,7 @@ func (s *SchemaStorage) DoGC(ts uint64) {
 // At state *done*, it will be always and only changed to *synced*.
 func (s *SchemaStorage) skipJob(job *timodel.Job) bool {
 	if s.filter != nil && s.filter.ShouldDiscardDDL(job.Type) {
-		log.Info("discard the ddl job", zap.Int64("jobID", job.ID), zap.String("query", job.Query))
+		log.Info("discard the ddl job", zap.Int64("jobID", job.ID), logutil.ZapRedactString("query", job.Query))
 		return true
 	}
 	return !job.IsSynced() && !job.IsDone()
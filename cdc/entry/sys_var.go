@@ -0,0 +1,114 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entry
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/ticdc/cdc/kv"
+	tidbkv "github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/tablecodec"
+)
+
+const (
+	mysqlSchema = "mysql"
+	// the global system variables are stored in mysql.GLOBAL_VARIABLES, and
+	// the time zone of the system TiDB is bootstrapped in is stored in
+	// mysql.tidb, the names are the ones the tables are created with
+	globalVariablesTable = "GLOBAL_VARIABLES"
+	tidbTable            = "tidb"
+	variableNameColumn   = "variable_name"
+	variableValueColumn  = "variable_value"
+
+	timeZoneVariable   = "time_zone"
+	systemTZVariable   = "system_tz"
+	systemTimeZoneName = "SYSTEM"
+)
+
+// GetUpstreamTimezone returns the global time_zone of the upstream TiDB cluster
+// at ts, "SYSTEM" is resolved to the system time zone of the cluster. An empty
+// name is returned if the time zone is not stored, e.g. it's never changed.
+func GetUpstreamTimezone(store tidbkv.Storage, ts uint64) (string, error) {
+	meta, err := kv.GetSnapshotMeta(store, ts)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	snap, err := newSchemaSnapshotFromMeta(meta, ts)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	snapshot, err := store.GetSnapshot(tidbkv.NewVersion(ts))
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	name, err := readSysVar(snapshot, snap, globalVariablesTable, timeZoneVariable)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	if name == "" || strings.EqualFold(name, systemTimeZoneName) {
+		name, err = readSysVar(snapshot, snap, tidbTable, systemTZVariable)
+		if err != nil {
+			return "", errors.Trace(err)
+		}
+	}
+	return name, nil
+}
+
+// readSysVar reads the value of a variable from a table of the mysql schema,
+// which has the variable_name and variable_value columns.
+func readSysVar(snapshot tidbkv.Snapshot, snap *schemaSnapshot, table, name string) (string, error) {
+	tableInfo, ok := snap.GetTableByName(mysqlSchema, table)
+	if !ok {
+		return "", nil
+	}
+	var nameColID, valueColID int64
+	for _, col := range tableInfo.Columns {
+		switch col.Name.L {
+		case variableNameColumn:
+			nameColID = col.ID
+		case variableValueColumn:
+			valueColID = col.ID
+		}
+	}
+	if nameColID == 0 || valueColID == 0 {
+		return "", errors.NotFoundf("the variable columns of %s.%s", mysqlSchema, table)
+	}
+
+	prefix := tablecodec.GenTableRecordPrefix(tableInfo.ID)
+	iter, err := snapshot.Iter(prefix, prefix.PrefixNext())
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	defer iter.Close()
+	for ; iter.Valid(); err = iter.Next() {
+		if err != nil {
+			return "", errors.Trace(err)
+		}
+		handle, err := tablecodec.DecodeRowKey(iter.Key())
+		if err != nil {
+			return "", errors.Trace(err)
+		}
+		row, err := decodeRow(iter.Value(), handle, tableInfo, time.UTC)
+		if err != nil {
+			return "", errors.Trace(err)
+		}
+		if nameDatum, ok := row[nameColID]; ok && strings.EqualFold(nameDatum.GetString(), name) {
+			valueDatum := row[valueColID]
+			return valueDatum.GetString(), nil
+		}
+	}
+	return "", errors.Trace(err)
+}
@@ -0,0 +1,55 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entry
+
+import (
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/session"
+	"github.com/pingcap/tidb/store/mockstore"
+	"github.com/pingcap/tidb/util/testkit"
+)
+
+type sysVarSuite struct{}
+
+var _ = Suite(&sysVarSuite{})
+
+func (s *sysVarSuite) TestGetUpstreamTimezone(c *C) {
+	store, err := mockstore.NewMockTikvStore()
+	c.Assert(err, IsNil)
+	defer store.Close() //nolint:errcheck
+
+	session.SetSchemaLease(0)
+	session.DisableStats4Test()
+	domain, err := session.BootstrapSession(store)
+	c.Assert(err, IsNil)
+	defer domain.Close()
+	domain.SetStatsUpdating(true)
+	tk := testkit.NewTestKit(c, store)
+
+	// the system time zone of the cluster is used by default
+	rows := tk.MustQuery("select variable_value from mysql.tidb where variable_name = 'system_tz'").Rows()
+	c.Assert(rows, HasLen, 1)
+	ver, err := store.CurrentVersion()
+	c.Assert(err, IsNil)
+	tz, err := GetUpstreamTimezone(store, ver.Ver)
+	c.Assert(err, IsNil)
+	c.Assert(tz, Equals, rows[0][0])
+
+	tk.MustExec("set @@global.time_zone = '+08:00'")
+	ver, err = store.CurrentVersion()
+	c.Assert(err, IsNil)
+	tz, err = GetUpstreamTimezone(store, ver.Ver)
+	c.Assert(err, IsNil)
+	c.Assert(tz, Equals, "+08:00")
+}
@@ -0,0 +1,1001 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/ticdc/cdc/kv"
+	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/cdc/sink"
+	"github.com/pingcap/ticdc/pkg/config"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+	"github.com/pingcap/ticdc/pkg/httputil"
+	"github.com/pingcap/ticdc/pkg/security"
+	"github.com/pingcap/ticdc/pkg/util"
+	"github.com/pingcap/tidb/store/tikv/oracle"
+	"go.uber.org/zap"
+)
+
+// The HTTP API to manage the changefeeds, which is documented by the OpenAPI
+// spec in docs/api/openapi.yaml. The API can be requested on any capture, the
+// requests are forwarded to the owner.
+const (
+	apiV1Prefix = "/api/v1"
+	// apiForwardedHeader is set to the ID of the capture which forwards the
+	// request, a forwarded request is never forwarded again.
+	apiForwardedHeader = "X-TiCDC-Forwarded-By"
+
+	apiTimeFormat = "2006-01-02 15:04:05.000"
+
+	// defaultAuditQueryLimit is the default number of the audit records
+	// returned by the API
+	defaultAuditQueryLimit = 100
+)
+
+// APIError is the error body of the HTTP API
+type APIError struct {
+	Code    string `json:"error_code"`
+	Message string `json:"error_msg"`
+}
+
+// CaptureDetail is the information of a capture in the HTTP API
+type CaptureDetail struct {
+	ID              string                `json:"id"`
+	AdvertiseAddr   string                `json:"address"`
+	IsOwner         bool                  `json:"is_owner"`
+	OwnerPreference model.OwnerPreference `json:"owner_preference,omitempty"`
+	FeatureGates    config.FeatureGates   `json:"feature_gates,omitempty"`
+}
+
+// ChangefeedConfig is the body of the requests to create or update a changefeed,
+// the zero fields are left unchanged when a changefeed is updated.
+type ChangefeedConfig struct {
+	ID         string            `json:"changefeed_id"`
+	SinkURI    string            `json:"sink_uri"`
+	StartTs    uint64            `json:"start_ts"`
+	TargetTs   uint64            `json:"target_ts"`
+	SortEngine model.SortEngine  `json:"sort_engine"`
+	SortDir    string            `json:"sort_dir"`
+	Opts       map[string]string `json:"opts"`
+	// ReplicaConfig is merged into the default replica config on creation, or
+	// the current one on update.
+	ReplicaConfig json.RawMessage `json:"replica_config"`
+	// Credential is the credential to connect to the sink, which is encrypted
+	// when stored. It replaces the current one on update.
+	Credential *security.SinkCredential `json:"credential"`
+	// GCTTL is the seconds the changefeed holds the GC safepoint after it's
+	// paused or failed, 0 is the gc ttl of the server.
+	GCTTL int64 `json:"gc_ttl"`
+	// Upstream is the cluster the changefeed replicates from, it's the
+	// cluster of the captures if it's not set. It can't be updated.
+	Upstream *model.UpstreamInfo `json:"upstream"`
+}
+
+// ChangefeedDetail is the information of a changefeed in the HTTP API
+type ChangefeedDetail struct {
+	ID             string                `json:"changefeed_id"`
+	State          model.FeedState       `json:"state"`
+	SinkURI        string                `json:"sink_uri"`
+	CreateTime     string                `json:"create_time"`
+	StartTs        uint64                `json:"start_ts"`
+	TargetTs       uint64                `json:"target_ts"`
+	CheckpointTSO  uint64                `json:"checkpoint_tso"`
+	CheckpointTime string                `json:"checkpoint_time"`
+	ResolvedTs     uint64                `json:"resolved_ts"`
+	SortEngine     model.SortEngine      `json:"sort_engine"`
+	SortDir        string                `json:"sort_dir"`
+	Opts           map[string]string     `json:"opts"`
+	ReplicaConfig  *config.ReplicaConfig `json:"replica_config"`
+	GCTTL          int64                 `json:"gc_ttl"`
+	Upstream       *model.UpstreamInfo   `json:"upstream,omitempty"`
+	Error          *model.RunningError   `json:"error"`
+}
+
+// MoveTableRequest is the body of the request to move a table to a capture
+type MoveTableRequest struct {
+	TableID   model.TableID   `json:"table_id"`
+	CaptureID model.CaptureID `json:"capture_id"`
+}
+
+// LaggingTableDetail is the progress of a lagging table in the HTTP API, the
+// lags are in seconds.
+type LaggingTableDetail struct {
+	TableID       model.TableID   `json:"table_id"`
+	TableName     string          `json:"table_name"`
+	CaptureID     model.CaptureID `json:"capture_id"`
+	CheckpointTs  uint64          `json:"checkpoint_ts"`
+	CheckpointLag float64         `json:"checkpoint_lag"`
+	ResolvedTs    uint64          `json:"resolved_ts"`
+	ResolvedLag   float64         `json:"resolved_lag"`
+}
+
+// ImportRequest is the body of the request to import the exported changefeeds
+type ImportRequest struct {
+	Export *model.ChangefeedExport `json:"export"`
+	// Secrets are the secrets referenced by the changefeed definitions
+	Secrets map[string]string `json:"secrets"`
+	// StartTs is the start ts of all the changefeeds, the exported checkpoints
+	// are used if it's 0.
+	StartTs uint64 `json:"start_ts"`
+}
+
+// ImportResult is the result of importing the changefeeds
+type ImportResult struct {
+	Changefeeds []model.ChangeFeedID `json:"changefeed_ids"`
+}
+
+// ResumeRequest is the optional body of the request to resume a changefeed
+type ResumeRequest struct {
+	// ResumeTs is the ts the changefeed is resumed from, the checkpoint of the
+	// changefeed is used if it's 0.
+	ResumeTs uint64 `json:"resume_ts"`
+	// SafeMode makes the MySQL sink write the events replicated again in safe
+	// mode, when the resume ts is earlier than the checkpoint.
+	SafeMode bool `json:"safe_mode"`
+}
+
+// ResumeResult is the result of resuming a changefeed
+type ResumeResult struct {
+	// Warnings are about the events replicated again or skipped
+	Warnings []string `json:"warnings"`
+}
+
+type apiChangefeedHandler func(w http.ResponseWriter, req *http.Request, changefeedID model.ChangeFeedID)
+
+func (s *Server) registerAPIv1(serverMux *http.ServeMux) {
+	serverMux.HandleFunc(apiV1Prefix+"/", s.forwardToOwner(handleAPINotFound))
+	serverMux.HandleFunc(apiV1Prefix+"/captures", s.forwardToOwner(s.handleAPICaptures))
+	serverMux.HandleFunc(apiV1Prefix+"/changefeeds", s.forwardToOwner(s.audited(s.handleAPIChangefeeds)))
+	serverMux.HandleFunc(apiV1Prefix+"/changefeeds/", s.forwardToOwner(s.audited(s.handleAPIChangefeed)))
+	serverMux.HandleFunc(apiV1Prefix+"/export", s.forwardToOwner(s.handleAPIExport))
+	serverMux.HandleFunc(apiV1Prefix+"/import", s.forwardToOwner(s.audited(s.handleAPIImport)))
+	serverMux.HandleFunc(apiV1Prefix+"/batch/changefeeds", s.forwardToOwner(s.audited(s.handleAPIBatchCreate)))
+	serverMux.HandleFunc(apiV1Prefix+"/audit", s.forwardToOwner(s.handleAPIAudit))
+}
+
+// apiOwner returns the owner for an API handler, it writes ErrAPIOwnerNotFound
+// if the owner is closed after the request is accepted by forwardToOwner.
+func (s *Server) apiOwner(w http.ResponseWriter) (*Owner, bool) {
+	s.ownerLock.RLock()
+	owner := s.owner
+	s.ownerLock.RUnlock()
+	if owner == nil {
+		writeAPIError(w, cerror.ErrAPIOwnerNotFound.GenWithStackByArgs())
+		return nil, false
+	}
+	return owner, true
+}
+
+// forwardToOwner calls the handler if the capture is the owner, otherwise the
+// request is forwarded to the owner.
+func (s *Server) forwardToOwner(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		// the lock isn't held by the handler, which gets the owner again by
+		// apiOwner since the owner may be closed in the meantime
+		s.ownerLock.RLock()
+		owner := s.owner
+		capture := s.capture
+		s.ownerLock.RUnlock()
+		if owner != nil {
+			handler(w, req)
+			return
+		}
+
+		if capture == nil || req.Header.Get(apiForwardedHeader) != "" {
+			writeAPIError(w, cerror.ErrAPIOwnerNotFound.GenWithStackByArgs())
+			return
+		}
+		ctx := req.Context()
+		ownerID, err := capture.etcdClient.GetOwnerID(ctx, kv.CaptureOwnerKey)
+		if err != nil {
+			writeAPIError(w, cerror.WrapError(cerror.ErrAPIOwnerNotFound, err))
+			return
+		}
+		if ownerID == capture.info.ID {
+			// the capture is elected, but the owner isn't started yet
+			writeAPIError(w, cerror.ErrAPIOwnerNotFound.GenWithStackByArgs())
+			return
+		}
+		ownerInfo, err := capture.etcdClient.GetCaptureInfo(ctx, ownerID)
+		if err != nil {
+			writeAPIError(w, cerror.WrapError(cerror.ErrAPIOwnerNotFound, err))
+			return
+		}
+		s.forwardRequest(w, req, capture.info.ID, ownerInfo.AdvertiseAddr)
+	}
+}
+
+func (s *Server) forwardRequest(w http.ResponseWriter, req *http.Request, captureID, ownerAddr string) {
+	scheme := "http"
+	if s.opts.credential != nil && s.opts.credential.IsTLSEnabled() {
+		scheme = "https"
+	}
+	uri := fmt.Sprintf("%s://%s%s", scheme, ownerAddr, req.URL.RequestURI())
+	forwarded, err := http.NewRequestWithContext(req.Context(), req.Method, uri, req.Body)
+	if err != nil {
+		writeAPIError(w, errors.Annotate(cerror.ErrAPIForwardFailed.GenWithStackByArgs(ownerAddr), err.Error()))
+		return
+	}
+	forwarded.Header = req.Header.Clone()
+	forwarded.Header.Set(apiForwardedHeader, captureID)
+	// the owner audits the forwarded request by its source and principal
+	forwarded.Header.Set(apiForwardedForHeader, req.RemoteAddr)
+	principal := ""
+	if req.TLS != nil && len(req.TLS.PeerCertificates) > 0 {
+		principal = req.TLS.PeerCertificates[0].Subject.CommonName
+	}
+	forwarded.Header.Set(apiForwardedPrincipalHeader, principal)
+	cli, err := httputil.NewClient(s.opts.credential)
+	if err != nil {
+		writeAPIError(w, errors.Annotate(cerror.ErrAPIForwardFailed.GenWithStackByArgs(ownerAddr), err.Error()))
+		return
+	}
+	resp, err := cli.Do(forwarded)
+	if err != nil {
+		writeAPIError(w, errors.Annotate(cerror.ErrAPIForwardFailed.GenWithStackByArgs(ownerAddr), err.Error()))
+		return
+	}
+	defer resp.Body.Close()
+	for key, values := range resp.Header {
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		log.Warn("failed to forward the response of owner",
+			zap.String("owner", ownerAddr), zap.Error(err))
+	}
+}
+
+func handleAPINotFound(w http.ResponseWriter, req *http.Request) {
+	writeAPIError(w, cerror.ErrAPIRouteNotFound.GenWithStackByArgs(req.URL.Path))
+}
+
+// GET /api/v1/captures
+func (s *Server) handleAPICaptures(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		writeAPIError(w, cerror.ErrAPIMethodNotAllowed.GenWithStackByArgs(req.Method))
+		return
+	}
+	owner, ok := s.apiOwner(w)
+	if !ok {
+		return
+	}
+	_, captures, err := owner.etcdClient.GetCaptures(req.Context())
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	details := make([]*CaptureDetail, 0, len(captures))
+	for _, c := range captures {
+		details = append(details, &CaptureDetail{
+			ID:              c.ID,
+			AdvertiseAddr:   c.AdvertiseAddr,
+			IsOwner:         s.capture != nil && c.ID == s.capture.info.ID,
+			OwnerPreference: c.OwnerPreference,
+			FeatureGates:    c.FeatureGates,
+		})
+	}
+	writeData(w, details)
+}
+
+// GET, POST /api/v1/changefeeds
+func (s *Server) handleAPIChangefeeds(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet:
+		s.listChangefeeds(w, req)
+	case http.MethodPost:
+		s.createChangefeed(w, req)
+	default:
+		writeAPIError(w, cerror.ErrAPIMethodNotAllowed.GenWithStackByArgs(req.Method))
+	}
+}
+
+// /api/v1/changefeeds/{changefeed_id}[/{action}]
+func (s *Server) handleAPIChangefeed(w http.ResponseWriter, req *http.Request) {
+	path := strings.TrimPrefix(req.URL.Path, apiV1Prefix+"/changefeeds/")
+	parts := strings.SplitN(path, "/", 2)
+	changefeedID := parts[0]
+	action := ""
+	if len(parts) > 1 {
+		action = parts[1]
+	}
+	routes := map[string]map[string]apiChangefeedHandler{
+		"": {
+			http.MethodGet:    s.getChangefeed,
+			http.MethodPut:    s.updateChangefeed,
+			http.MethodDelete: s.removeChangefeed,
+		},
+		"pause":          {http.MethodPost: s.pauseChangefeed},
+		"resume":         {http.MethodPost: s.resumeChangefeed},
+		"tables/move":    {http.MethodPost: s.moveTable},
+		"tables/lagging": {http.MethodGet: s.listLaggingTables},
+	}
+	methods, ok := routes[action]
+	if !ok {
+		handleAPINotFound(w, req)
+		return
+	}
+	handler, ok := methods[req.Method]
+	if !ok {
+		writeAPIError(w, cerror.ErrAPIMethodNotAllowed.GenWithStackByArgs(req.Method))
+		return
+	}
+	if err := model.ValidateChangefeedID(changefeedID); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	handler(w, req, changefeedID)
+}
+
+func (s *Server) listChangefeeds(w http.ResponseWriter, req *http.Request) {
+	owner, ok := s.apiOwner(w)
+	if !ok {
+		return
+	}
+	_, raw, err := owner.etcdClient.GetChangeFeeds(req.Context())
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	details := make([]*ChangefeedDetail, 0, len(raw))
+	for changefeedID := range raw {
+		detail, err := s.collectChangefeedDetail(req.Context(), owner, changefeedID)
+		if err != nil {
+			writeAPIError(w, err)
+			return
+		}
+		details = append(details, detail)
+	}
+	writeData(w, details)
+}
+
+func (s *Server) getChangefeed(w http.ResponseWriter, req *http.Request, changefeedID model.ChangeFeedID) {
+	owner, ok := s.apiOwner(w)
+	if !ok {
+		return
+	}
+	detail, err := s.collectChangefeedDetail(req.Context(), owner, changefeedID)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	writeData(w, detail)
+}
+
+func (s *Server) collectChangefeedDetail(ctx context.Context, owner *Owner, changefeedID model.ChangeFeedID) (*ChangefeedDetail, error) {
+	info, err := owner.etcdClient.GetChangeFeedInfo(ctx, changefeedID)
+	if err != nil {
+		return nil, err
+	}
+	cf, status, feedState, err := owner.collectChangefeedInfo(ctx, changefeedID)
+	if err != nil && cerror.ErrChangeFeedNotExists.NotEqual(err) {
+		return nil, err
+	}
+	detail := &ChangefeedDetail{
+		ID:            changefeedID,
+		State:         feedState,
+		SinkURI:       info.SinkURI,
+		CreateTime:    info.CreateTime.Format(apiTimeFormat),
+		StartTs:       info.StartTs,
+		TargetTs:      info.TargetTs,
+		SortEngine:    info.Engine,
+		SortDir:       info.SortDir,
+		Opts:          info.Opts,
+		ReplicaConfig: info.Config,
+		GCTTL:         info.GCTTL,
+		Upstream:      info.Upstream,
+		Error:         info.Error,
+	}
+	if cf != nil {
+		detail.Error = cf.info.Error
+	}
+	if status != nil {
+		detail.CheckpointTSO = status.CheckpointTs
+		detail.CheckpointTime = oracle.GetTimeFromTS(status.CheckpointTs).Format(apiTimeFormat)
+		detail.ResolvedTs = status.ResolvedTs
+	}
+	return detail, nil
+}
+
+func (s *Server) createChangefeed(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	cfg := &ChangefeedConfig{}
+	if err := decodeAPIBody(req, cfg); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	if cfg.ID == "" {
+		cfg.ID = uuid.New().String()
+	}
+	setAuditChangefeed(req, cfg.ID)
+	if cfg.Upstream != nil {
+		if err := cfg.Upstream.Validate(); err != nil {
+			writeAPIError(w, err)
+			return
+		}
+	}
+	owner, ok := s.apiOwner(w)
+	if !ok {
+		return
+	}
+	pdClient, gcCli, err := owner.upstreamClients(ctx, cfg.Upstream)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	if cfg.StartTs == 0 {
+		ts, logical, err := pdClient.GetTS(ctx)
+		if err != nil {
+			writeAPIError(w, cerror.WrapError(cerror.ErrPDEtcdAPIError, err))
+			return
+		}
+		cfg.StartTs = oracle.ComposeTS(ts, logical)
+	}
+	ctx = util.PutTimezoneInCtx(ctx, s.opts.timezone)
+	info, err := newChangefeedInfo(ctx, gcCli, cfg, config.GetDefaultReplicaConfig())
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	if err := owner.etcdClient.CreateChangefeedInfo(ctx, info, cfg.ID); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	log.Info("changefeed is created by http api", zap.String("changefeed", cfg.ID), zap.Stringer("info", info))
+	s.getChangefeed(w, req, cfg.ID)
+}
+
+// newChangefeedInfo validates the config of a new changefeed, whose start ts
+// must be set, and returns the changefeed info. The start ts is checked
+// against the GC safepoint read by gcCli, the etcd client of the upstream. The
+// replica config of cfg is merged into replicaConfig.
+func newChangefeedInfo(
+	ctx context.Context, gcCli kv.CDCEtcdClient, cfg *ChangefeedConfig, replicaConfig *config.ReplicaConfig,
+) (*model.ChangeFeedInfo, error) {
+	if err := model.ValidateChangefeedID(cfg.ID); err != nil {
+		return nil, err
+	}
+	if cfg.SinkURI == "" {
+		return nil, cerror.ErrAPIInvalidParam.GenWithStack("sink_uri is required")
+	}
+	if cfg.Upstream != nil {
+		if err := cfg.Upstream.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if err := verifyStartTs(ctx, gcCli, cfg.StartTs); err != nil {
+		return nil, err
+	}
+	info := &model.ChangeFeedInfo{
+		SinkURI:    cfg.SinkURI,
+		Opts:       make(map[string]string),
+		CreateTime: time.Now(),
+		StartTs:    cfg.StartTs,
+		TargetTs:   cfg.TargetTs,
+		Config:     replicaConfig,
+		Engine:     cfg.SortEngine,
+		SortDir:    cfg.SortDir,
+		State:      model.StateNormal,
+		GCTTL:      cfg.GCTTL,
+		Upstream:   cfg.Upstream,
+	}
+	for key, value := range cfg.Opts {
+		info.Opts[key] = value
+	}
+	if err := verifyChangefeedInfo(ctx, info, cfg.ReplicaConfig, cfg.Credential); err != nil {
+		return nil, err
+	}
+	if err := info.SetCredential(cfg.Credential); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+func (s *Server) updateChangefeed(w http.ResponseWriter, req *http.Request, changefeedID model.ChangeFeedID) {
+	ctx := req.Context()
+	cfg := &ChangefeedConfig{}
+	if err := decodeAPIBody(req, cfg); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	if (cfg.ID != "" && cfg.ID != changefeedID) || cfg.StartTs != 0 || cfg.Upstream != nil {
+		writeAPIError(w, cerror.ErrAPIInvalidParam.GenWithStack("changefeed_id, start_ts and upstream can't be updated"))
+		return
+	}
+	owner, ok := s.apiOwner(w)
+	if !ok {
+		return
+	}
+	oldInfo, err := owner.etcdClient.GetChangeFeedInfo(ctx, changefeedID)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	_, _, feedState, err := owner.collectChangefeedInfo(ctx, changefeedID)
+	if err != nil && cerror.ErrChangeFeedNotExists.NotEqual(err) {
+		writeAPIError(w, err)
+		return
+	}
+	if err := oldInfo.VerifyAndFix(); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	info, err := oldInfo.Clone()
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	if cfg.SinkURI != "" {
+		info.SinkURI = cfg.SinkURI
+	}
+	if cfg.TargetTs != 0 {
+		info.TargetTs = cfg.TargetTs
+	}
+	if cfg.SortEngine != "" {
+		info.Engine = cfg.SortEngine
+	}
+	if cfg.SortDir != "" {
+		info.SortDir = cfg.SortDir
+	}
+	if cfg.GCTTL != 0 {
+		info.GCTTL = cfg.GCTTL
+	}
+	if info.Opts == nil {
+		info.Opts = make(map[string]string)
+	}
+	for key, value := range cfg.Opts {
+		info.Opts[key] = value
+	}
+	credential := cfg.Credential
+	if credential != nil {
+		if err := info.SetCredential(credential); err != nil {
+			writeAPIError(w, err)
+			return
+		}
+	} else if credential, err = info.GetCredential(); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	ctx = util.PutTimezoneInCtx(ctx, s.opts.timezone)
+	if err := verifyChangefeedInfo(ctx, info, cfg.ReplicaConfig, credential); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	// the running changefeed reloads the config by itself, see
+	// changeFeed.reloadConfig and processor.configWorker.
+	if feedState == model.StateNormal {
+		if err := oldInfo.VerifyHotReload(info); err != nil {
+			writeAPIError(w, err)
+			return
+		}
+	}
+	info.ConfigVersion++
+	if err := owner.etcdClient.SaveChangeFeedInfo(ctx, info, changefeedID); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	log.Info("changefeed is updated by http api", zap.String("changefeed", changefeedID), zap.Stringer("info", info))
+	s.getChangefeed(w, req, changefeedID)
+}
+
+// verifyChangefeedInfo merges the replica config into the changefeed info, and
+// checks the changefeed can be run with the sink, whose timezone is in ctx.
+func verifyChangefeedInfo(ctx context.Context, info *model.ChangeFeedInfo, replicaConfig json.RawMessage, credential *security.SinkCredential) error {
+	if info.TargetTs > 0 && info.TargetTs <= info.StartTs {
+		return cerror.ErrAPIInvalidParam.GenWithStack("target_ts %d must be larger than start_ts %d", info.TargetTs, info.StartTs)
+	}
+	switch info.Engine {
+	case "", model.SortInMemory, model.SortInFile:
+	default:
+		return cerror.ErrAPIInvalidParam.GenWithStack("invalid sort_engine: %s", info.Engine)
+	}
+	if info.GCTTL < 0 {
+		return cerror.ErrAPIInvalidParam.GenWithStack("invalid gc_ttl: %d", info.GCTTL)
+	}
+	if len(replicaConfig) > 0 {
+		if err := info.Config.Unmarshal(replicaConfig); err != nil {
+			return cerror.ErrAPIInvalidParam.GenWithStack("invalid replica_config: %s", err)
+		}
+	}
+	if err := info.VerifyAndFix(); err != nil {
+		return err
+	}
+	diags := info.Validate()
+	for _, d := range diags.Filter(config.DiagnosticWarning) {
+		log.Warn("changefeed config diagnostic", zap.String("changefeed", info.String()), zap.Stringer("diagnostic", d))
+	}
+	if err := diags.Err(); err != nil {
+		return cerror.ErrAPIInvalidParam.GenWithStack("invalid replica_config: %s", err)
+	}
+	// the gates of the captures are checked by the owner once the changefeed
+	// is started
+	if err := info.CheckFeatures(); err != nil {
+		return err
+	}
+	if err := credential.Validate(); err != nil {
+		return cerror.ErrAPIInvalidParam.GenWithStack("invalid credential: %s", err)
+	}
+	sinkURI, err := url.Parse(info.SinkURI)
+	if err != nil {
+		return cerror.WrapError(cerror.ErrSinkURIInvalid, err)
+	}
+	if !info.Config.EnableOldValue && sink.RequireOldValue(sinkURI, info.Config) {
+		log.Info("enable old value required by the sink or the filters", zap.String("sink", sinkURI.Scheme))
+		info.Config.EnableOldValue = true
+	}
+	return sink.Validate(ctx, info.SinkURI, info.Config, credential, info.Opts)
+}
+
+func (s *Server) pauseChangefeed(w http.ResponseWriter, req *http.Request, changefeedID model.ChangeFeedID) {
+	s.enqueueAdminJob(w, req, model.AdminJob{CfID: changefeedID, Type: model.AdminStop})
+}
+
+func (s *Server) resumeChangefeed(w http.ResponseWriter, req *http.Request, changefeedID model.ChangeFeedID) {
+	resumeReq := &ResumeRequest{}
+	if req.ContentLength != 0 {
+		if err := decodeAPIBody(req, resumeReq); err != nil {
+			writeAPIError(w, err)
+			return
+		}
+	}
+	if resumeReq.ResumeTs == 0 {
+		s.enqueueAdminJob(w, req, model.AdminJob{CfID: changefeedID, Type: model.AdminResume})
+		return
+	}
+	owner, ok := s.apiOwner(w)
+	if !ok {
+		return
+	}
+	warnings, err := VerifyResumeTs(req.Context(), owner.etcdClient, owner.upstreamClients, changefeedID, resumeReq.ResumeTs, resumeReq.SafeMode)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	err = owner.EnqueueJob(model.AdminJob{
+		CfID: changefeedID,
+		Type: model.AdminResume,
+		Opts: &model.AdminJobOption{ResumeTs: resumeReq.ResumeTs, SafeMode: resumeReq.SafeMode},
+	})
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	if warnings == nil {
+		warnings = []string{}
+	}
+	writeDataWithStatus(w, http.StatusAccepted, &ResumeResult{Warnings: warnings})
+}
+
+// DELETE /api/v1/changefeeds/{changefeed_id}?force=true
+func (s *Server) removeChangefeed(w http.ResponseWriter, req *http.Request, changefeedID model.ChangeFeedID) {
+	opts := &model.AdminJobOption{}
+	if forceStr := req.URL.Query().Get("force"); forceStr != "" {
+		force, err := strconv.ParseBool(forceStr)
+		if err != nil {
+			writeAPIError(w, cerror.ErrAPIInvalidParam.GenWithStack("invalid force remove option: %s", forceStr))
+			return
+		}
+		opts.ForceRemove = force
+	}
+	s.enqueueAdminJob(w, req, model.AdminJob{CfID: changefeedID, Type: model.AdminRemove, Opts: opts})
+}
+
+// enqueueAdminJob enqueues the job of an existing changefeed to the owner, the
+// job is handled asynchronously.
+func (s *Server) enqueueAdminJob(w http.ResponseWriter, req *http.Request, job model.AdminJob) {
+	// the status of a changefeed is created after the owner initializes it,
+	// and the info is deleted once it's removed.
+	ctx := req.Context()
+	owner, ok := s.apiOwner(w)
+	if !ok {
+		return
+	}
+	_, err := owner.etcdClient.GetChangeFeedInfo(ctx, job.CfID)
+	if cerror.ErrChangeFeedNotExists.Equal(err) {
+		_, _, err = owner.etcdClient.GetChangeFeedStatus(ctx, job.CfID)
+	}
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	if err := owner.EnqueueJob(job); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *Server) moveTable(w http.ResponseWriter, req *http.Request, changefeedID model.ChangeFeedID) {
+	moveReq := &MoveTableRequest{}
+	if err := decodeAPIBody(req, moveReq); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	owner, ok := s.apiOwner(w)
+	if !ok {
+		return
+	}
+	owner.l.RLock()
+	_, cfExists := owner.changeFeeds[changefeedID]
+	_, captureExists := owner.captures[moveReq.CaptureID]
+	owner.l.RUnlock()
+	if !cfExists {
+		writeAPIError(w, cerror.ErrChangeFeedNotExists.GenWithStackByArgs(changefeedID))
+		return
+	}
+	if !captureExists {
+		writeAPIError(w, cerror.ErrCaptureNotExist.GenWithStackByArgs(moveReq.CaptureID))
+		return
+	}
+	owner.ManualSchedule(changefeedID, moveReq.CaptureID, moveReq.TableID)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// listLaggingTables lists the tables with the least resolved ts of a changefeed,
+// which are merged from the lagging tables reported by the processors.
+func (s *Server) listLaggingTables(w http.ResponseWriter, req *http.Request, changefeedID model.ChangeFeedID) {
+	limit := model.MaxLaggingTables
+	if limitStr := req.URL.Query().Get("limit"); limitStr != "" {
+		n, err := strconv.Atoi(limitStr)
+		if err != nil || n <= 0 {
+			writeAPIError(w, cerror.ErrAPIInvalidParam.GenWithStack("invalid limit: %s", limitStr))
+			return
+		}
+		if n < limit {
+			limit = n
+		}
+	}
+	tables := make([]*LaggingTableDetail, 0)
+	owner, ok := s.apiOwner(w)
+	if !ok {
+		return
+	}
+	owner.l.RLock()
+	cf, exists := owner.changeFeeds[changefeedID]
+	if exists {
+		for captureID, position := range cf.taskPositions {
+			for _, lag := range position.LaggingTables {
+				tables = append(tables, &LaggingTableDetail{
+					TableID:      lag.TableID,
+					TableName:    lag.TableName,
+					CaptureID:    captureID,
+					CheckpointTs: lag.CheckPointTs,
+					ResolvedTs:   lag.ResolvedTs,
+				})
+			}
+		}
+	}
+	owner.l.RUnlock()
+	if !exists {
+		writeAPIError(w, cerror.ErrChangeFeedNotExists.GenWithStackByArgs(changefeedID))
+		return
+	}
+	sort.Slice(tables, func(i, j int) bool {
+		if tables[i].ResolvedTs != tables[j].ResolvedTs {
+			return tables[i].ResolvedTs < tables[j].ResolvedTs
+		}
+		return tables[i].TableID < tables[j].TableID
+	})
+	if len(tables) > limit {
+		tables = tables[:limit]
+	}
+	nowPhysical := oracle.GetPhysical(time.Now())
+	for _, table := range tables {
+		table.CheckpointLag = float64(nowPhysical-oracle.ExtractPhysical(table.CheckpointTs)) / 1e3
+		table.ResolvedLag = float64(nowPhysical-oracle.ExtractPhysical(table.ResolvedTs)) / 1e3
+	}
+	writeData(w, tables)
+}
+
+// GET /api/v1/export
+func (s *Server) handleAPIExport(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		writeAPIError(w, cerror.ErrAPIMethodNotAllowed.GenWithStackByArgs(req.Method))
+		return
+	}
+	owner, ok := s.apiOwner(w)
+	if !ok {
+		return
+	}
+	export, err := ExportChangefeeds(req.Context(), owner.etcdClient)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	writeData(w, export)
+}
+
+// POST /api/v1/import
+func (s *Server) handleAPIImport(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		writeAPIError(w, cerror.ErrAPIMethodNotAllowed.GenWithStackByArgs(req.Method))
+		return
+	}
+	importReq := &ImportRequest{}
+	if err := decodeAPIBody(req, importReq); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	if importReq.Export == nil {
+		writeAPIError(w, cerror.ErrAPIInvalidParam.GenWithStack("export is required"))
+		return
+	}
+	owner, ok := s.apiOwner(w)
+	if !ok {
+		return
+	}
+	created, err := ImportChangefeeds(req.Context(), owner.etcdClient, importReq.Export, ImportOptions{
+		StartTs: importReq.StartTs,
+		GetSecret: func(name string) (string, bool) {
+			secret, ok := importReq.Secrets[name]
+			return secret, ok
+		},
+		Timezone:  s.opts.timezone,
+		Upstreams: owner.upstreamClients,
+	})
+	if err != nil {
+		log.Warn("import changefeeds failed", zap.Strings("created", created), zap.Error(err))
+		writeAPIError(w, err)
+		return
+	}
+	writeData(w, &ImportResult{Changefeeds: created})
+}
+
+// POST /api/v1/batch/changefeeds
+func (s *Server) handleAPIBatchCreate(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		writeAPIError(w, cerror.ErrAPIMethodNotAllowed.GenWithStackByArgs(req.Method))
+		return
+	}
+	manifest := &ChangefeedManifest{}
+	if err := decodeAPIBody(req, manifest); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	if len(manifest.Changefeeds) == 0 {
+		writeAPIError(w, cerror.ErrAPIInvalidParam.GenWithStack("changefeeds is required"))
+		return
+	}
+	owner, ok := s.apiOwner(w)
+	if !ok {
+		return
+	}
+	results, err := CreateChangefeeds(req.Context(), owner.etcdClient, owner.upstreamClients, manifest, s.opts.timezone)
+	if cerror.ErrInvalidChangefeedManifest.Equal(err) {
+		writeDataWithStatus(w, http.StatusBadRequest, results)
+		return
+	}
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	writeData(w, results)
+}
+
+// GET /api/v1/audit
+func (s *Server) handleAPIAudit(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		writeAPIError(w, cerror.ErrAPIMethodNotAllowed.GenWithStackByArgs(req.Method))
+		return
+	}
+	query := req.URL.Query()
+	since := time.Time{}
+	if sinceStr := query.Get("since"); sinceStr != "" {
+		t, err := time.Parse(time.RFC3339, sinceStr)
+		if err != nil {
+			writeAPIError(w, cerror.ErrAPIInvalidParam.GenWithStack("invalid since: %s", sinceStr))
+			return
+		}
+		since = t
+	}
+	limit := defaultAuditQueryLimit
+	if limitStr := query.Get("limit"); limitStr != "" {
+		n, err := strconv.Atoi(limitStr)
+		if err != nil || n <= 0 {
+			writeAPIError(w, cerror.ErrAPIInvalidParam.GenWithStack("invalid limit: %s", limitStr))
+			return
+		}
+		limit = n
+	}
+	owner, ok := s.apiOwner(w)
+	if !ok {
+		return
+	}
+	records, err := owner.etcdClient.GetAuditRecords(req.Context(), since)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	writeData(w, model.FilterAuditRecords(
+		records, query.Get("changefeed_id"), model.AuditAction(query.Get("action")), limit))
+}
+
+// decodeAPIBody decodes the json body strictly, the unknown fields are rejected.
+func decodeAPIBody(req *http.Request, v interface{}) error {
+	decoder := json.NewDecoder(req.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return cerror.ErrAPIInvalidParam.GenWithStack("invalid request body: %s", err)
+	}
+	return nil
+}
+
+// apiErrorStatus maps the RFC codes of the errors to the HTTP status codes,
+// the errors not listed are internal server errors.
+var apiErrorStatus = map[errors.RFCErrorCode]int{
+	cerror.ErrAPIInvalidParam.RFCCode():          http.StatusBadRequest,
+	cerror.ErrInvalidChangefeedID.RFCCode():      http.StatusBadRequest,
+	cerror.ErrSinkURIInvalid.RFCCode():           http.StatusBadRequest,
+	cerror.ErrCaptureNotExist.RFCCode():          http.StatusBadRequest,
+	cerror.ErrConfigNotReloadable.RFCCode():      http.StatusBadRequest,
+	cerror.ErrStartTsBeforeGC.RFCCode():          http.StatusBadRequest,
+	cerror.ErrChangefeedSecretNotFound.RFCCode(): http.StatusBadRequest,
+	cerror.ErrInvalidChangefeedExport.RFCCode():  http.StatusBadRequest,
+	cerror.ErrInvalidAdminJobType.RFCCode():      http.StatusBadRequest,
+	cerror.ErrInvalidResumeTs.RFCCode():          http.StatusBadRequest,
+	cerror.ErrUnknownFeature.RFCCode():           http.StatusBadRequest,
+	cerror.ErrFeatureDisabled.RFCCode():          http.StatusBadRequest,
+	cerror.ErrChangeFeedNotExists.RFCCode():      http.StatusNotFound,
+	cerror.ErrAPIRouteNotFound.RFCCode():         http.StatusNotFound,
+	cerror.ErrAPIMethodNotAllowed.RFCCode():      http.StatusMethodNotAllowed,
+	cerror.ErrChangeFeedAlreadyExists.RFCCode():  http.StatusConflict,
+	cerror.ErrAPIForwardFailed.RFCCode():         http.StatusBadGateway,
+	cerror.ErrAPIOwnerNotFound.RFCCode():         http.StatusServiceUnavailable,
+}
+
+// newAPIError returns the API error of err and its HTTP status code
+func newAPIError(err error) (*APIError, int) {
+	statusCode := http.StatusInternalServerError
+	apiErr := &APIError{
+		Code:    string(cerror.ErrInternalServerError.RFCCode()),
+		Message: err.Error(),
+	}
+	if code, ok := cerror.RFCCode(err); ok {
+		apiErr.Code = string(code)
+		if s, ok := apiErrorStatus[code]; ok {
+			statusCode = s
+		}
+	}
+	return apiErr, statusCode
+}
+
+func writeAPIError(w http.ResponseWriter, err error) {
+	apiErr, statusCode := newAPIError(err)
+	data, err := json.Marshal(apiErr)
+	if err != nil {
+		log.Error("invalid json data", zap.Reflect("data", apiErr), zap.Error(err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if _, err := w.Write(data); err != nil {
+		log.Error("fail to write error", zap.Error(err))
+	}
+}
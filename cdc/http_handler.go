@@ -41,6 +41,11 @@ const (
 	APIOpVarTableID = "table-id"
 	// APIOpForceRemoveChangefeed is used when remove a changefeed
 	APIOpForceRemoveChangefeed = "force-remove"
+	// APIOpVarResumeTs is the key of the ts a changefeed is resumed from
+	APIOpVarResumeTs = "resume-ts"
+	// APIOpVarSafeMode is the key of the safe mode option when a changefeed
+	// is resumed from a ts earlier than its checkpoint
+	APIOpVarSafeMode = "safe-mode"
 )
 
 type commonResp struct {
@@ -132,6 +137,24 @@ func (s *Server) handleChangefeedAdmin(w http.ResponseWriter, req *http.Request)
 		}
 		opts.ForceRemove = forceRemoveOpt
 	}
+	if resumeTsStr := req.Form.Get(APIOpVarResumeTs); resumeTsStr != "" {
+		resumeTs, err := strconv.ParseUint(resumeTsStr, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest,
+				cerror.ErrAPIInvalidParam.GenWithStack("invalid resume ts: %s", resumeTsStr))
+			return
+		}
+		opts.ResumeTs = resumeTs
+	}
+	if safeModeStr := req.Form.Get(APIOpVarSafeMode); safeModeStr != "" {
+		safeMode, err := strconv.ParseBool(safeModeStr)
+		if err != nil {
+			writeError(w, http.StatusBadRequest,
+				cerror.ErrAPIInvalidParam.GenWithStack("invalid safe mode option: %s", safeModeStr))
+			return
+		}
+		opts.SafeMode = safeMode
+	}
 	job := model.AdminJob{
 		CfID: req.Form.Get(APIOpVarChangefeedID),
 		Type: model.AdminJobType(typ),
@@ -26,6 +26,9 @@ import (
 	"github.com/pingcap/errors"
 	"github.com/pingcap/log"
 	"github.com/pingcap/ticdc/cdc/kv"
+	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/cdc/puller"
+	"github.com/pingcap/ticdc/pkg/buckets"
 	cerror "github.com/pingcap/ticdc/pkg/errors"
 	"github.com/pingcap/ticdc/pkg/security"
 	"github.com/pingcap/ticdc/pkg/version"
@@ -46,14 +49,18 @@ func (s *Server) startStatusHTTP() error {
 
 	serverMux.HandleFunc("/status", s.handleStatus)
 	serverMux.HandleFunc("/debug/info", s.handleDebugInfo)
-	serverMux.HandleFunc("/capture/owner/resign", s.handleResignOwner)
-	serverMux.HandleFunc("/capture/owner/admin", s.handleChangefeedAdmin)
-	serverMux.HandleFunc("/capture/owner/rebalance_trigger", s.handleRebalanceTrigger)
-	serverMux.HandleFunc("/capture/owner/move_table", s.handleMoveTable)
+	serverMux.HandleFunc("/debug/buckets", s.handleDebugBuckets)
+	serverMux.HandleFunc("/debug/resolved-ts", s.handleDebugResolvedTs)
+	serverMux.HandleFunc("/capture/owner/resign", s.audited(s.handleResignOwner))
+	serverMux.HandleFunc("/capture/owner/admin", s.audited(s.handleChangefeedAdmin))
+	serverMux.HandleFunc("/capture/owner/rebalance_trigger", s.audited(s.handleRebalanceTrigger))
+	serverMux.HandleFunc("/capture/owner/move_table", s.audited(s.handleMoveTable))
 	serverMux.HandleFunc("/capture/owner/changefeed/query", s.handleChangefeedQuery)
 
 	serverMux.HandleFunc("/admin/log", handleAdminLogLevel)
 
+	s.registerAPIv1(serverMux)
+
 	prometheus.DefaultGatherer = registry
 	serverMux.Handle("/metrics", promhttp.Handler())
 
@@ -76,7 +83,8 @@ func (s *Server) startStatusHTTP() error {
 	go func() {
 		log.Info("status http server is running", zap.String("addr", addr))
 		if tlsConfig != nil {
-			err = s.statusServer.ServeTLS(ln, credential.CertPath, credential.KeyPath)
+			// the key pair is loaded by the tls config, so it's reloaded once rotated
+			err = s.statusServer.ServeTLS(ln, "", "")
 		} else {
 			err = s.statusServer.Serve(ln)
 		}
@@ -124,6 +132,52 @@ func (s *Server) handleDebugInfo(w http.ResponseWriter, req *http.Request) {
 	s.writeEtcdInfo(req.Context(), s.capture.etcdClient, w)
 }
 
+// bucketsInfo is the state of the buckets used for throttling in a capture
+type bucketsInfo struct {
+	Memory      *buckets.GroupSnapshot           `json:"memory,omitempty"`
+	Changefeeds map[string]buckets.GroupSnapshot `json:"changefeeds"`
+}
+
+func (s *Server) handleDebugBuckets(w http.ResponseWriter, req *http.Request) {
+	info := bucketsInfo{Changefeeds: make(map[string]buckets.GroupSnapshot)}
+	if s.memoryManager != nil {
+		snap := s.memoryManager.Snapshot()
+		info.Memory = &snap
+	}
+	if s.capture != nil {
+		s.capture.procLock.Lock()
+		for changefeedID, p := range s.capture.processors {
+			info.Changefeeds[changefeedID] = p.memQuota.Snapshot()
+		}
+		s.capture.procLock.Unlock()
+	}
+	writeData(w, info)
+}
+
+// handleDebugResolvedTs reports the table pullers whose resolved ts is stuck
+// and the regions holding them back, or all the table pullers if the `all`
+// parameter is set.
+func (s *Server) handleDebugResolvedTs(w http.ResponseWriter, req *http.Request) {
+	all := req.URL.Query().Get("all") != ""
+	info := make(map[string]map[model.TableID]puller.ResolvedTsDiagnosis)
+	if s.capture != nil {
+		s.capture.procLock.Lock()
+		for changefeedID, p := range s.capture.processors {
+			diagnoses := p.diagnoseResolvedTs()
+			for tableID, d := range diagnoses {
+				if !all && !d.Stuck {
+					delete(diagnoses, tableID)
+				}
+			}
+			if len(diagnoses) > 0 {
+				info[changefeedID] = diagnoses
+			}
+		}
+		s.capture.procLock.Unlock()
+	}
+	writeData(w, info)
+}
+
 func (s *Server) handleStatus(w http.ResponseWriter, req *http.Request) {
 	s.ownerLock.RLock()
 	defer s.ownerLock.RUnlock()
@@ -152,6 +206,10 @@ func writeError(w http.ResponseWriter, statusCode int, err error) {
 }
 
 func writeData(w http.ResponseWriter, data interface{}) {
+	writeDataWithStatus(w, http.StatusOK, data)
+}
+
+func writeDataWithStatus(w http.ResponseWriter, statusCode int, data interface{}) {
 	js, err := json.MarshalIndent(data, "", " ")
 	if err != nil {
 		log.Error("invalid json data", zap.Reflect("data", data), zap.Error(err))
@@ -159,7 +217,7 @@ func writeData(w http.ResponseWriter, data interface{}) {
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	w.WriteHeader(statusCode)
 	_, err = w.Write(js)
 	if err != nil {
 		log.Error("fail to write data", zap.Error(err))
@@ -17,7 +17,6 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"math/rand"
 	"strconv"
 	"sync"
 	"sync/atomic"
@@ -30,9 +29,11 @@ import (
 	"github.com/pingcap/log"
 	"github.com/pingcap/ticdc/cdc/model"
 	cerror "github.com/pingcap/ticdc/pkg/errors"
+	"github.com/pingcap/ticdc/pkg/logutil"
 	"github.com/pingcap/ticdc/pkg/regionspan"
 	"github.com/pingcap/ticdc/pkg/retry"
 	"github.com/pingcap/ticdc/pkg/security"
+	"github.com/pingcap/ticdc/pkg/tracing"
 	"github.com/pingcap/ticdc/pkg/txnutil"
 	"github.com/pingcap/ticdc/pkg/util"
 	"github.com/pingcap/ticdc/pkg/version"
@@ -42,12 +43,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	pd "github.com/tikv/pd/client"
 	"go.uber.org/zap"
-	"golang.org/x/sync/errgroup"
-	"golang.org/x/time/rate"
-	"google.golang.org/grpc"
-	gbackoff "google.golang.org/grpc/backoff"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/status"
 )
 
@@ -166,111 +162,6 @@ func (m *syncRegionFeedStateMap) takeAll() map[uint64]*regionFeedState {
 	return state
 }
 
-type connArray struct {
-	credential *security.Credential
-	target     string
-	index      uint32
-	v          []*grpc.ClientConn
-}
-
-func newConnArray(ctx context.Context, maxSize uint, addr string, credential *security.Credential) (*connArray, error) {
-	a := &connArray{
-		target:     addr,
-		credential: credential,
-		index:      0,
-		v:          make([]*grpc.ClientConn, maxSize),
-	}
-	err := a.Init(ctx)
-	if err != nil {
-		return nil, errors.Trace(err)
-	}
-	return a, nil
-}
-
-func (a *connArray) Init(ctx context.Context) error {
-	grpcTLSOption, err := a.credential.ToGRPCDialOption()
-	if err != nil {
-		return errors.Trace(err)
-	}
-	for i := range a.v {
-		ctx, cancel := context.WithTimeout(ctx, dialTimeout)
-
-		conn, err := grpc.DialContext(
-			ctx,
-			a.target,
-			grpcTLSOption,
-			grpc.WithInitialWindowSize(grpcInitialWindowSize),
-			grpc.WithInitialConnWindowSize(grpcInitialConnWindowSize),
-			grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(grpcMaxCallRecvMsgSize)),
-			grpc.WithConnectParams(grpc.ConnectParams{
-				Backoff: gbackoff.Config{
-					BaseDelay:  time.Second,
-					Multiplier: 1.1,
-					Jitter:     0.1,
-					MaxDelay:   3 * time.Second,
-				},
-				MinConnectTimeout: 3 * time.Second,
-			}),
-			grpc.WithKeepaliveParams(keepalive.ClientParameters{
-				Time:                10 * time.Second,
-				Timeout:             3 * time.Second,
-				PermitWithoutStream: true,
-			}),
-		)
-		cancel()
-
-		if err != nil {
-			a.Close()
-			return cerror.WrapError(cerror.ErrGRPCDialFailed, err)
-		}
-		a.v[i] = conn
-	}
-	return nil
-}
-
-func (a *connArray) Get() *grpc.ClientConn {
-	next := atomic.AddUint32(&a.index, 1) % uint32(len(a.v))
-	return a.v[next]
-}
-
-func (a *connArray) Close() {
-	for i, c := range a.v {
-		if c != nil {
-			err := c.Close()
-			if err != nil {
-				log.Warn("close grpc conn", zap.Error(err))
-			}
-		}
-		a.v[i] = nil
-	}
-}
-
-type regionEventFeedLimiters struct {
-	sync.Mutex
-	// TODO replace with a LRU cache.
-	limiters map[uint64]*rate.Limiter
-}
-
-var defaultRegionEventFeedLimiters *regionEventFeedLimiters = &regionEventFeedLimiters{
-	limiters: make(map[uint64]*rate.Limiter),
-}
-
-func (rl *regionEventFeedLimiters) getLimiter(regionID uint64) *rate.Limiter {
-	var limiter *rate.Limiter
-	var ok bool
-
-	rl.Lock()
-	limiter, ok = rl.limiters[regionID]
-	if !ok {
-		// In most cases, region replica count is 3.
-		replicaCount := 3
-		limiter = rate.NewLimiter(rate.Every(100*time.Millisecond), replicaCount)
-		rl.limiters[regionID] = limiter
-	}
-	rl.Unlock()
-	return limiter
-}
-
 // CDCClient to get events from TiKV
 type CDCClient struct {
 	pd         pd.Client
@@ -278,15 +169,18 @@ type CDCClient struct {
 
 	clusterID uint64
 
-	mu struct {
-		sync.Mutex
-		conns map[string]*connArray
-	}
+	// pool is shared by all the kv clients to the cluster with the same credential
+	pool *connPool
 
 	regionCache *tikv.RegionCache
 	kvStorage   tikv.Storage
 
-	regionLimiters *regionEventFeedLimiters
+	// scanLimiter is nil if the incremental scans are not limited
+	scanLimiter *ScanLimiter
+	// matcherCache limits the prewrites cached by the region feeds
+	matcherCache MatcherCacheConfig
+	// breakers are shared by all the kv clients in the process
+	breakers *storeBreakers
 }
 
 // NewCDCClient creates a CDCClient instance
@@ -300,63 +194,46 @@ func NewCDCClient(ctx context.Context, pd pd.Client, kvStorage tikv.Storage, cre
 		credential:  credential,
 		kvStorage:   kvStorage,
 		regionCache: tikv.NewRegionCache(pd),
-		mu: struct {
-			sync.Mutex
-			conns map[string]*connArray
-		}{
-			conns: make(map[string]*connArray),
-		},
-		regionLimiters: defaultRegionEventFeedLimiters,
+		pool:        getConnPool(clusterID, credential),
+		scanLimiter: getGlobalScanLimiter(),
+
+		matcherCache: getMatcherCacheConfig(),
+		breakers:     defaultStoreBreakers,
 	}
 	return
 }
 
-// Close CDCClient
+// Close CDCClient. The connections to the stores are kept in the pool for the
+// other kv clients, they're closed once the last kv client is closed.
 func (c *CDCClient) Close() error {
-	c.mu.Lock()
-	for _, conn := range c.mu.conns {
-		conn.Close()
-	}
-	c.mu.Unlock()
 	c.regionCache.Close()
-
-	return nil
-}
-
-func (c *CDCClient) getConn(ctx context.Context, addr string) (*grpc.ClientConn, error) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	if conns, ok := c.mu.conns[addr]; ok {
-		return conns.Get(), nil
-	}
-	ca, err := newConnArray(ctx, grpcConnCount, addr, c.credential)
-	if err != nil {
-		return nil, errors.Trace(err)
+	if c.pool != nil {
+		putConnPool(c.pool)
+		c.pool = nil
 	}
-	c.mu.conns[addr] = ca
-	return ca.Get(), nil
-}
 
-func (c *CDCClient) getRegionLimiter(regionID uint64) *rate.Limiter {
-	return c.regionLimiters.getLimiter(regionID)
+	return nil
 }
 
-func (c *CDCClient) newStream(ctx context.Context, addr string, storeID uint64) (stream cdcpb.ChangeData_EventFeedClient, err error) {
+// newStream creates an event feed stream to the store on a connection in the pool,
+// the connection must be released to the pool when the stream is closed.
+func (c *CDCClient) newStream(ctx context.Context, addr string, storeID uint64) (stream cdcpb.ChangeData_EventFeedClient, conn *pooledConn, err error) {
 	err = retry.Run(50*time.Millisecond, 3, func() error {
-		conn, err := c.getConn(ctx, addr)
+		err := version.CheckStoreVersion(ctx, c.pd, storeID)
 		if err != nil {
-			log.Info("get connection to store failed, retry later", zap.String("addr", addr), zap.Error(err))
+			log.Error("check tikv version failed", zap.Error(err), zap.Uint64("storeID", storeID))
 			return errors.Trace(err)
 		}
-		err = version.CheckStoreVersion(ctx, c.pd, storeID)
+		conn, err = c.pool.acquire(ctx, addr)
 		if err != nil {
-			log.Error("check tikv version failed", zap.Error(err), zap.Uint64("storeID", storeID))
+			log.Info("get connection to store failed, retry later", zap.String("addr", addr), zap.Error(err))
 			return errors.Trace(err)
 		}
-		client := cdcpb.NewChangeDataClient(conn)
+		client := cdcpb.NewChangeDataClient(conn.ClientConn)
 		stream, err = client.EventFeed(ctx)
 		if err != nil {
+			c.pool.release(conn)
+			conn = nil
 			err = cerror.WrapError(cerror.ErrTiKVEventFeed, err)
 			log.Info("establish stream to store failed, retry later", zap.String("addr", addr), zap.Error(err))
 			return err
@@ -418,6 +295,8 @@ type eventFeedSession struct {
 
 	rangeLock      *regionspan.RegionRangeLock
 	enableOldValue bool
+	// retries counts the successive retries of the regions for the backoff
+	retries *regionRetryTracker
 
 	// To identify metrics of different eventFeedSession
 	id                string
@@ -454,6 +333,7 @@ func newEventFeedSession(
 		requestRangeCh:    make(chan rangeRequestTask, 16),
 		rangeLock:         regionspan.NewRegionRangeLock(totalSpan.Start, totalSpan.End, startTs),
 		enableOldValue:    enableOldValue,
+		retries:           newRegionRetryTracker(),
 		lockResolver:      lockResolver,
 		isPullerInit:      isPullerInit,
 		id:                strconv.FormatUint(allocID(), 10),
@@ -467,9 +347,9 @@ func (s *eventFeedSession) eventFeed(ctx context.Context, ts uint64) error {
 	eventFeedGauge.Inc()
 	defer eventFeedGauge.Dec()
 
-	log.Debug("event feed started", zap.Stringer("span", s.totalSpan), zap.Uint64("ts", ts))
+	log.Debug("event feed started", logutil.ZapRedactStringer("span", s.totalSpan), zap.Uint64("ts", ts))
 
-	g, ctx := errgroup.WithContext(ctx)
+	g, ctx := util.SafeGroupWithContext(ctx)
 
 	g.Go(func() error {
 		return s.dispatchRequest(ctx, g)
@@ -553,7 +433,7 @@ func (s *eventFeedSession) scheduleRegionRequest(ctx context.Context, sri single
 		case regionspan.LockRangeStatusStale:
 			log.Info("request expired",
 				zap.Uint64("regionID", sri.verID.GetID()),
-				zap.Stringer("span", sri.span),
+				logutil.ZapRedactStringer("span", sri.span),
 				zap.Reflect("retrySpans", res.RetryRanges))
 			for _, r := range res.RetryRanges {
 				// This call can be always blocking because if `blocking` is set to false, this will in a new goroutine,
@@ -621,13 +501,14 @@ func (s *eventFeedSession) onRegionFail(ctx context.Context, errorInfo regionErr
 // responsible for handling the error.
 func (s *eventFeedSession) dispatchRequest(
 	ctx context.Context,
-	g *errgroup.Group,
+	g *util.SafeGroup,
 ) error {
 	streams := make(map[string]cdcpb.ChangeData_EventFeedClient)
 	// Stores pending regions info for each stream. After sending a new request, the region info wil be put to the map,
 	// and it will be loaded by the receiver thread when it receives the first response from that region. We need this
 	// to pass the region info to the receiver since the region info cannot be inferred from the response from TiKV.
 	storePendingRegions := make(map[string]*syncRegionFeedStateMap)
+	metricScanWaiting := scanBacklogGauge.WithLabelValues(util.CaptureAddrFromCtx(ctx), "waiting")
 
 MainLoop:
 	for {
@@ -642,6 +523,12 @@ MainLoop:
 
 		log.Debug("dispatching region", zap.Uint64("regionID", sri.verID.GetID()))
 
+		// Every request makes TiKV scan the region incrementally from the checkpoint ts.
+		err := s.client.scanLimiter.acquireRegion(ctx, metricScanWaiting)
+		if err != nil {
+			return errors.Trace(err)
+		}
+
 		// Loop for retrying in case the stream has disconnected.
 		// TODO: Should we break if retries and fails too many times?
 		for {
@@ -653,7 +540,11 @@ MainLoop:
 				// The region info is invalid. Retry the span.
 				log.Info("cannot get rpcCtx, retry span",
 					zap.Uint64("regionID", sri.verID.GetID()),
-					zap.Stringer("span", sri.span))
+					logutil.ZapRedactStringer("span", sri.span))
+				err = backoffRegionRetry(ctx, regionRetryRPCCtxUnavailable, s.retries.next(sri.verID.GetID()))
+				if err != nil {
+					return errors.Trace(err)
+				}
 				err = s.onRegionFail(ctx, regionErrorInfo{
 					singleRegionInfo: sri,
 					err: &rpcCtxUnavailableErr{
@@ -708,6 +599,23 @@ MainLoop:
 
 			stream, ok := streams[rpcCtx.Addr]
 			// Establish the stream if it has not been connected yet.
+			if !ok && !s.client.breakers.allow(rpcCtx.Addr) {
+				// The store keeps failing, mark it failed in the region cache, so
+				// the region is requested from the other peers. The leader may
+				// be transferred to them, or they reply the leader is not changed.
+				log.Debug("store circuit breaker is open, try other peers",
+					zap.Uint64("regionID", sri.verID.GetID()),
+					zap.String("addr", rpcCtx.Addr))
+				bo := tikv.NewBackoffer(ctx, tikvRequestMaxBackoff)
+				s.client.regionCache.OnSendFail(bo, rpcCtx, needReloadRegion(sri.failStoreIDs, rpcCtx),
+					cerror.ErrStoreBreakerOpen.GenWithStackByArgs(rpcCtx.Addr))
+				pendingRegions.take(requestID)
+				err = backoffRegionRetry(ctx, regionRetryStoreBreakerOpen, s.retries.next(regionID))
+				if err != nil {
+					return errors.Trace(err)
+				}
+				continue
+			}
 			if !ok {
 				storeID := rpcCtx.Peer.GetStoreId()
 				log.Info("creating new stream to store to send request",
@@ -715,7 +623,11 @@ MainLoop:
 					zap.Uint64("requestID", requestID),
 					zap.Uint64("storeID", storeID),
 					zap.String("addr", rpcCtx.Addr))
-				stream, err = s.client.newStream(ctx, rpcCtx.Addr, storeID)
+				var conn *pooledConn
+				stream, conn, err = s.client.newStream(ctx, rpcCtx.Addr, storeID)
+				if err != nil && errors.Cause(err) != context.Canceled {
+					s.client.breakers.onFailure(rpcCtx.Addr)
+				}
 				if err != nil {
 					// if get stream failed, maybe the store is down permanently, we should try to relocate the active store
 					log.Warn("get grpc stream client failed",
@@ -731,13 +643,17 @@ MainLoop:
 					s.client.regionCache.OnSendFail(bo, rpcCtx, needReloadRegion(sri.failStoreIDs, rpcCtx), err)
 					// Delete the pendingRegion info from `pendingRegions` and retry connecting and sending the request.
 					pendingRegions.take(requestID)
+					err = backoffRegionRetry(ctx, regionRetryStoreUnreachable, s.retries.next(regionID))
+					if err != nil {
+						return errors.Trace(err)
+					}
 					continue
 				}
 				streams[rpcCtx.Addr] = stream
 
-				limiter := s.client.getRegionLimiter(regionID)
 				g.Go(func() error {
-					return s.receiveFromStream(ctx, g, rpcCtx.Addr, getStoreID(rpcCtx), stream, pendingRegions, limiter)
+					defer s.client.pool.release(conn)
+					return s.receiveFromStream(ctx, g, rpcCtx.Addr, getStoreID(rpcCtx), stream, pendingRegions)
 				})
 			}
 
@@ -752,7 +668,7 @@ MainLoop:
 			// If Send error, the receiver should have received error too or will receive error soon. So we doesn't need
 			// to do extra work here.
 			if err != nil {
-
+				s.client.breakers.onFailure(rpcCtx.Addr)
 				log.Error("send request to stream failed",
 					zap.String("addr", rpcCtx.Addr),
 					zap.Uint64("storeID", getStoreID(rpcCtx)),
@@ -780,14 +696,10 @@ MainLoop:
 				}
 
 				// Wait for a while and retry sending the request
-				time.Sleep(time.Millisecond * time.Duration(rand.Intn(100)))
-				// Break if ctx has been canceled.
-				select {
-				case <-ctx.Done():
-					return ctx.Err()
-				default:
+				err = backoffRegionRetry(ctx, regionRetryStoreUnreachable, s.retries.next(regionID))
+				if err != nil {
+					return errors.Trace(err)
 				}
-
 				continue
 			}
 
@@ -815,7 +727,6 @@ func needReloadRegion(failStoreIDs map[uint64]struct{}, rpcCtx *tikv.RPCContext)
 func (s *eventFeedSession) partialRegionFeed(
 	ctx context.Context,
 	state *regionFeedState,
-	limiter *rate.Limiter,
 ) error {
 	receiver := state.regionEventCh
 	defer func() {
@@ -833,22 +744,24 @@ func (s *eventFeedSession) partialRegionFeed(
 	}()
 
 	ts := state.sri.ts
-	maxTs, err := s.singleEventFeed(ctx, state.sri.verID.GetID(), state.sri.span, state.sri.ts, receiver)
+	maxTs, err := s.singleEventFeed(ctx, state.sri.verID.GetID(), state.sri.rpcCtx.Addr, state.sri.span, state.sri.ts, receiver)
 	log.Debug("singleEventFeed quit")
 
 	if err == nil || errors.Cause(err) == context.Canceled {
 		return nil
 	}
 
+	regionID := state.sri.verID.GetID()
 	if maxTs > ts {
 		ts = maxTs
+		// The backoff starts over as the region has made progress.
+		s.retries.reset(regionID)
 	}
 
-	regionID := state.sri.verID.GetID()
 	log.Info("EventFeed disconnected",
 		zap.Uint64("regionID", regionID),
 		zap.Uint64("requestID", state.requestID),
-		zap.Stringer("span", state.sri.span),
+		logutil.ZapRedactStringer("span", state.sri.span),
 		zap.Uint64("checkpoint", ts),
 		zap.String("error", err.Error()))
 
@@ -857,19 +770,12 @@ func (s *eventFeedSession) partialRegionFeed(
 	// We need to ensure when the error is handled, `isStopped` must be set. So set it before sending the error.
 	state.markStopped()
 
-	now := time.Now()
-	delay := limiter.ReserveN(now, 1).Delay()
-	if delay != 0 {
-		log.Info("EventFeed retry rate limited",
-			zap.Duration("delay", delay), zap.Reflect("regionID", regionID))
-		t := time.NewTimer(delay)
-		defer t.Stop()
-		select {
-		case <-t.C:
-			// We can proceed.
-		case <-ctx.Done():
-			return ctx.Err()
-		}
+	reason := classifyRegionError(err)
+	attempt := s.retries.next(regionID)
+	log.Info("EventFeed retry backoff",
+		zap.Uint64("regionID", regionID), zap.String("reason", string(reason)), zap.Int("attempt", attempt))
+	if err := backoffRegionRetry(ctx, reason, attempt); err != nil {
+		return errors.Trace(err)
 	}
 
 	return s.onRegionFail(ctx, regionErrorInfo{
@@ -912,17 +818,17 @@ func (s *eventFeedSession) divideAndSendEventFeedToRegions(
 				for _, region := range regions {
 					if region.GetMeta() == nil {
 						err = cerror.ErrMetaNotInRegion.GenWithStackByArgs()
-						log.Warn("batch load region", zap.Stringer("span", nextSpan), zap.Error(err))
+						log.Warn("batch load region", logutil.ZapRedactStringer("span", nextSpan), zap.Error(err))
 						return err
 					}
 					metas = append(metas, region.GetMeta())
 				}
 				if !regionspan.CheckRegionsLeftCover(metas, nextSpan) {
 					err = cerror.ErrRegionsNotCoverSpan.GenWithStackByArgs(nextSpan, metas)
-					log.Warn("ScanRegions", zap.Stringer("span", nextSpan), zap.Reflect("regions", metas), zap.Error(err))
+					log.Warn("ScanRegions", logutil.ZapRedactStringer("span", nextSpan), logutil.ZapRedactReflect("regions", metas), zap.Error(err))
 					return err
 				}
-				log.Debug("ScanRegions", zap.Stringer("span", nextSpan), zap.Reflect("regions", metas))
+				log.Debug("ScanRegions", logutil.ZapRedactStringer("span", nextSpan), logutil.ZapRedactReflect("regions", metas))
 				return nil
 			})
 
@@ -936,13 +842,13 @@ func (s *eventFeedSession) divideAndSendEventFeedToRegions(
 			if err != nil {
 				return errors.Trace(err)
 			}
-			log.Debug("get partialSpan", zap.Stringer("span", partialSpan), zap.Uint64("regionID", region.Id))
+			log.Debug("get partialSpan", logutil.ZapRedactStringer("span", partialSpan), zap.Uint64("regionID", region.Id))
 
 			nextSpan.Start = region.EndKey
 
 			sri := newSingleRegionInfo(tiRegion.VerID(), partialSpan, ts, nil)
 			s.scheduleRegionRequest(ctx, sri, true)
-			log.Debug("partialSpan scheduled", zap.Stringer("span", partialSpan), zap.Uint64("regionID", region.Id))
+			log.Debug("partialSpan scheduled", logutil.ZapRedactStringer("span", partialSpan), zap.Uint64("regionID", region.Id))
 
 			// return if no more regions
 			if regionspan.EndCompare(nextSpan.Start, span.End) >= 0 {
@@ -1014,12 +920,11 @@ func (s *eventFeedSession) getRPCContextForRegion(ctx context.Context, id tikv.R
 
 func (s *eventFeedSession) receiveFromStream(
 	ctx context.Context,
-	g *errgroup.Group,
+	g *util.SafeGroup,
 	addr string,
 	storeID uint64,
 	stream cdcpb.ChangeData_EventFeedClient,
 	pendingRegions *syncRegionFeedStateMap,
-	limiter *rate.Limiter,
 ) error {
 	// Cancel the pending regions if the stream failed. Otherwise it will remain unhandled in the pendingRegions list
 	// however not registered in the new reconnected stream.
@@ -1046,6 +951,9 @@ func (s *eventFeedSession) receiveFromStream(
 
 	// Each region has it's own goroutine to handle its messages. `regionStates` stores states of these regions.
 	regionStates := make(map[uint64]*regionFeedState)
+	// received is set once the stream receives a response, which means the
+	// store is healthy.
+	received := false
 
 	for {
 		cevent, err := stream.Recv()
@@ -1065,6 +973,7 @@ func (s *eventFeedSession) receiveFromStream(
 					zap.Uint64("storeID", storeID),
 				)
 			} else {
+				s.client.breakers.onFailure(addr)
 				log.Error(
 					"failed to receive from stream",
 					zap.String("addr", addr),
@@ -1086,6 +995,11 @@ func (s *eventFeedSession) receiveFromStream(
 			return nil
 		}
 
+		if !received {
+			received = true
+			s.client.breakers.onSuccess(addr)
+		}
+
 		size := cevent.Size()
 		if size > warnRecvMsgSizeThreshold {
 			regionCount := 0
@@ -1098,7 +1012,7 @@ func (s *eventFeedSession) receiveFromStream(
 		}
 
 		for _, event := range cevent.Events {
-			err = s.sendRegionChangeEvent(ctx, g, event, regionStates, pendingRegions, addr, limiter)
+			err = s.sendRegionChangeEvent(ctx, g, event, regionStates, pendingRegions, addr)
 			if err != nil {
 				return err
 			}
@@ -1115,12 +1029,11 @@ func (s *eventFeedSession) receiveFromStream(
 
 func (s *eventFeedSession) sendRegionChangeEvent(
 	ctx context.Context,
-	g *errgroup.Group,
+	g *util.SafeGroup,
 	event *cdcpb.Event,
 	regionStates map[uint64]*regionFeedState,
 	pendingRegions *syncRegionFeedStateMap,
 	addr string,
-	limiter *rate.Limiter,
 ) error {
 	state, ok := regionStates[event.RegionId]
 	// Every region's range is locked before sending requests and unlocked after exiting, and the requestID
@@ -1163,7 +1076,7 @@ func (s *eventFeedSession) sendRegionChangeEvent(
 		regionStates[event.RegionId] = state
 
 		g.Go(func() error {
-			return s.partialRegionFeed(ctx, state, limiter)
+			return s.partialRegionFeed(ctx, state)
 		})
 	} else if state.isStopped() {
 		log.Warn("drop event due to region feed stopped",
@@ -1185,7 +1098,7 @@ func (s *eventFeedSession) sendRegionChangeEvent(
 
 func (s *eventFeedSession) sendResolvedTs(
 	ctx context.Context,
-	g *errgroup.Group,
+	g *util.SafeGroup,
 	resolvedTs *cdcpb.ResolvedTs,
 	regionStates map[uint64]*regionFeedState,
 	pendingRegions *syncRegionFeedStateMap,
@@ -1221,6 +1134,7 @@ func (s *eventFeedSession) sendResolvedTs(
 func (s *eventFeedSession) singleEventFeed(
 	ctx context.Context,
 	regionID uint64,
+	storeAddr string,
 	span regionspan.ComparableSpan,
 	startTs uint64,
 	receiverCh <-chan *regionEvent,
@@ -1236,10 +1150,36 @@ func (s *eventFeedSession) singleEventFeed(
 	metricSendEventResolvedCounter := sendEventCounter.WithLabelValues("native-resolved", captureAddr, changefeedID)
 	metricSendEventCommitCounter := sendEventCounter.WithLabelValues("commit", captureAddr, changefeedID)
 	metricSendEventCommittedCounter := sendEventCounter.WithLabelValues("committed", captureAddr, changefeedID)
+	metricScanning := scanBacklogGauge.WithLabelValues(captureAddr, "scanning")
+	metricMatcherRefetch := matcherRefetchCounter.WithLabelValues(captureAddr)
+	tableID, tableName := util.TableIDFromCtx(ctx)
+	traceTags := tracing.Tags{
+		"changefeed": changefeedID,
+		"table-id":   tableID,
+		"table":      tableName,
+		"region-id":  regionID,
+	}
+	// startTrace starts tracing the sampled row change once it's received
+	startTrace := func(entry *model.RawKVEntry) {
+		trace := tracing.StartEventTrace(traceTags)
+		if trace == nil {
+			return
+		}
+		trace.SetTag("commit-ts", entry.CRTs)
+		trace.StartStage(tracing.StagePuller)
+		entry.Trace = trace
+	}
 
 	initialized := false
+	metricScanning.Inc()
+	defer func() {
+		if !initialized {
+			metricScanning.Dec()
+		}
+	}()
 
-	matcher := newMatcher()
+	matcher := newMatcherWithCache(s.client.matcherCache, matcherCacheGauge.WithLabelValues(captureAddr, changefeedID))
+	defer matcher.close()
 	advanceCheckTicker := time.NewTicker(time.Second * 5)
 	defer advanceCheckTicker.Stop()
 	lastReceivedEventTime := time.Now()
@@ -1259,13 +1199,15 @@ func (s *eventFeedSession) singleEventFeed(
 		}
 		// emit a checkpointTs
 		revent := &model.RegionFeedEvent{
-			RegionID: regionID,
+			RegionID:  regionID,
+			StoreAddr: storeAddr,
 			Resolved: &model.ResolvedSpan{
 				Span:       span,
 				ResolvedTs: resolvedTs,
 			},
 		}
 		lastResolvedTs = resolvedTs
+		matcher.forgetResolved(resolvedTs)
 
 		select {
 		case s.eventCh <- revent:
@@ -1278,7 +1220,8 @@ func (s *eventFeedSession) singleEventFeed(
 
 	select {
 	case s.eventCh <- &model.RegionFeedEvent{
-		RegionID: regionID,
+		RegionID:  regionID,
+		StoreAddr: storeAddr,
 		Resolved: &model.ResolvedSpan{
 			Span:       span,
 			ResolvedTs: startTs,
@@ -1294,6 +1237,7 @@ func (s *eventFeedSession) singleEventFeed(
 		case <-ctx.Done():
 			return lastResolvedTs, ctx.Err()
 		case <-advanceCheckTicker.C:
+			matcher.evictExpired(time.Now())
 			if time.Since(startFeedTime) < 20*time.Second {
 				continue
 			}
@@ -1304,7 +1248,7 @@ func (s *eventFeedSession) singleEventFeed(
 			sinceLastEvent := time.Since(lastReceivedEventTime)
 			if sinceLastEvent > time.Second*20 {
 				log.Warn("region not receiving event from tikv for too long time",
-					zap.Uint64("regionID", regionID), zap.Stringer("span", span), zap.Duration("duration", sinceLastEvent))
+					zap.Uint64("regionID", regionID), logutil.ZapRedactStringer("span", span), zap.Duration("duration", sinceLastEvent))
 			}
 			version, err := s.kvStorage.(*StorageWithCurVersionCache).GetCachedCurrentVersion()
 			if err != nil {
@@ -1315,7 +1259,7 @@ func (s *eventFeedSession) singleEventFeed(
 			sinceLastResolvedTs := currentTimeFromPD.Sub(oracle.GetTimeFromTS(lastResolvedTs))
 			if sinceLastResolvedTs > time.Second*20 && initialized {
 				log.Warn("region not receiving resolved event from tikv or resolved ts is not pushing for too long time, try to resolve lock",
-					zap.Uint64("regionID", regionID), zap.Stringer("span", span),
+					zap.Uint64("regionID", regionID), logutil.ZapRedactStringer("span", span),
 					zap.Duration("duration", sinceLastResolvedTs),
 					zap.Uint64("resolvedTs", lastResolvedTs))
 				maxVersion := oracle.ComposeTS(oracle.GetPhysical(currentTimeFromPD.Add(-10*time.Second)), 0)
@@ -1342,6 +1286,16 @@ func (s *eventFeedSession) singleEventFeed(
 			metricEventSize.Observe(float64(event.changeEvent.Event.Size()))
 			switch x := event.changeEvent.Event.(type) {
 			case *cdcpb.Event_Entries_:
+				if !initialized {
+					// the entries before the region is initialized are the result of the incremental scan
+					err := s.client.scanLimiter.acquireBytes(ctx, int64(x.Entries.Size()))
+					if err != nil {
+						return lastResolvedTs, errors.Trace(err)
+					}
+				}
+				// the commits whose prewrites are evicted, their values are
+				// fetched in a batch after the other entries are handled
+				var refetch []*cdcpb.Event_Row
 				for _, entry := range x.Entries.GetEntries() {
 					switch entry.Type {
 					case cdcpb.Event_INITIALIZED:
@@ -1351,15 +1305,22 @@ func (s *eventFeedSession) singleEventFeed(
 								zap.Uint64("regionID", regionID))
 						}
 						metricPullEventInitializedCounter.Inc()
+						if !initialized {
+							metricScanning.Dec()
+						}
 						initialized = true
 						for _, cacheEntry := range matcher.cachedCommit {
 							value, ok := matcher.matchRow(cacheEntry)
+							if !ok && matcher.isEvicted(cacheEntry) {
+								refetch = append(refetch, cacheEntry)
+								continue
+							}
 							if !ok {
 								// when cdc receives a commit log without a corresponding
 								// prewrite log before initialized, a committed log  with
 								// the same key and start-ts must have been received.
 								log.Info("ignore commit event without prewrite",
-									zap.Binary("key", cacheEntry.GetKey()),
+									logutil.ZapRedactBinary("key", cacheEntry.GetKey()),
 									zap.Uint64("ts", cacheEntry.GetStartTs()))
 								continue
 							}
@@ -1368,6 +1329,7 @@ func (s *eventFeedSession) singleEventFeed(
 							if err != nil {
 								return lastResolvedTs, errors.Trace(err)
 							}
+							startTrace(revent.Val)
 							select {
 							case s.eventCh <- revent:
 								metricSendEventCommitCounter.Inc()
@@ -1400,6 +1362,7 @@ func (s *eventFeedSession) singleEventFeed(
 								RegionID: regionID,
 							},
 						}
+						startTrace(revent.Val)
 
 						if entry.CommitTs <= lastResolvedTs {
 							log.Fatal("The CommitTs must be greater than the resolvedTs",
@@ -1428,6 +1391,10 @@ func (s *eventFeedSession) singleEventFeed(
 						}
 						// emit a value
 						value, ok := matcher.matchRow(entry)
+						if !ok && matcher.isEvicted(entry) {
+							refetch = append(refetch, entry)
+							continue
+						}
 						if !ok {
 							if !initialized {
 								matcher.cacheCommitRow(entry)
@@ -1440,6 +1407,7 @@ func (s *eventFeedSession) singleEventFeed(
 						if err != nil {
 							return lastResolvedTs, errors.Trace(err)
 						}
+						startTrace(revent.Val)
 
 						select {
 						case s.eventCh <- revent:
@@ -1452,6 +1420,26 @@ func (s *eventFeedSession) singleEventFeed(
 						matcher.rollbackRow(entry)
 					}
 				}
+				if len(refetch) > 0 {
+					metricMatcherRefetch.Add(float64(len(refetch)))
+					values, err := s.fetchCommittedValues(ctx, refetch)
+					if err != nil {
+						return lastResolvedTs, errors.Trace(err)
+					}
+					for i, entry := range refetch {
+						revent, err := assembleCommitEvent(regionID, entry, values[i])
+						if err != nil {
+							return lastResolvedTs, errors.Trace(err)
+						}
+						startTrace(revent.Val)
+						select {
+						case s.eventCh <- revent:
+							metricSendEventCommitCounter.Inc()
+						case <-ctx.Done():
+							return lastResolvedTs, errors.Trace(ctx.Err())
+						}
+					}
+				}
 			case *cdcpb.Event_Admin_:
 				log.Info("receive admin event", zap.Stringer("event", event.changeEvent))
 			case *cdcpb.Event_Error:
@@ -1471,6 +1459,69 @@ func (s *eventFeedSession) singleEventFeed(
 	}
 }
 
+// fetchCommittedValues reads the values written by the committed rows and
+// their old values from TiKV, for the rows whose prewrites are evicted from
+// the matcher. The keys read at the same ts are read in one batch, the rows
+// of a transaction share the commit ts and the start ts. The versions read are
+// not GCed as they're newer than the checkpoint of the changefeed.
+func (s *eventFeedSession) fetchCommittedValues(ctx context.Context, rows []*cdcpb.Event_Row) ([]*pendingValue, error) {
+	values := make([]*pendingValue, len(rows))
+	valueKeys := make(map[uint64][]tidbkv.Key)
+	oldValueKeys := make(map[uint64][]tidbkv.Key)
+	for i, row := range rows {
+		values[i] = &pendingValue{}
+		if row.GetOpType() == cdcpb.Event_Row_PUT {
+			valueKeys[row.GetCommitTs()] = append(valueKeys[row.GetCommitTs()], row.GetKey())
+		}
+		if s.enableOldValue {
+			// A transaction can't be committed if the key is written after its
+			// start ts, so the old value is the one read at the start ts.
+			oldValueKeys[row.GetStartTs()] = append(oldValueKeys[row.GetStartTs()], row.GetKey())
+		}
+	}
+	fetched, err := s.batchGetSnapshotValues(ctx, valueKeys)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	fetchedOld, err := s.batchGetSnapshotValues(ctx, oldValueKeys)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for i, row := range rows {
+		if row.GetOpType() == cdcpb.Event_Row_PUT {
+			values[i].value = fetched[row.GetCommitTs()][string(row.GetKey())]
+		}
+		if s.enableOldValue {
+			values[i].oldValue = fetchedOld[row.GetStartTs()][string(row.GetKey())]
+		}
+	}
+	log.Debug("fetch the committed values of the evicted prewrites",
+		zap.Int("rows", len(rows)),
+		zap.Int("valueSnapshots", len(valueKeys)),
+		zap.Int("oldValueSnapshots", len(oldValueKeys)))
+	return values, nil
+}
+
+// batchGetSnapshotValues reads the keys at each ts, the keys not found are
+// absent from the result.
+func (s *eventFeedSession) batchGetSnapshotValues(
+	ctx context.Context, keys map[uint64][]tidbkv.Key,
+) (map[uint64]map[string][]byte, error) {
+	result := make(map[uint64]map[string][]byte, len(keys))
+	for ts, tsKeys := range keys {
+		snap, err := s.kvStorage.GetSnapshot(tidbkv.NewVersion(ts))
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		values, err := snap.BatchGet(ctx, tsKeys)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		result[ts] = values
+	}
+	return result, nil
+}
+
 func assembleCommitEvent(regionID uint64, entry *cdcpb.Event_Row, value *pendingValue) (*model.RegionFeedEvent, error) {
 	var opType model.OpType
 	switch entry.GetOpType() {
@@ -33,6 +33,7 @@ import (
 	"github.com/pingcap/tidb/store/tikv"
 	pd "github.com/tikv/pd/client"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
 )
 
 func Test(t *testing.T) { check.TestingT(t) }
@@ -300,17 +301,81 @@ func (s *etcdSuite) TodoTestIncompatibleTiKV(c *check.C) {
 // logger and in the logger initializtion it also initializes the grpclog/loggerv2, which
 // is not a thread-safe operation and it must be called before any gRPC functions
 // ref: https://github.com/grpc/grpc-go/blob/master/grpclog/loggerv2.go#L67-L72
-func (s *etcdSuite) TestConnArray(c *check.C) {
+func (s *etcdSuite) TestConnPool(c *check.C) {
 	addr := "127.0.0.1:2379"
-	ca, err := newConnArray(context.TODO(), 2, addr, &security.Credential{})
-	c.Assert(err, check.IsNil)
+	pool := newConnPool(&security.Credential{}, 2)
+	ctx := context.TODO()
 
-	conn1 := ca.Get()
-	conn2 := ca.Get()
+	conn1, err := pool.acquire(ctx, addr)
+	c.Assert(err, check.IsNil)
+	// a new connection is dialed while the existing ones have streams
+	conn2, err := pool.acquire(ctx, addr)
+	c.Assert(err, check.IsNil)
 	c.Assert(conn1, check.Not(check.Equals), conn2)
+	// the streams are multiplexed on the connections when the pool is full
+	conn3, err := pool.acquire(ctx, addr)
+	c.Assert(err, check.IsNil)
+	c.Assert(conn3 == conn1 || conn3 == conn2, check.IsTrue)
+	pool.release(conn3)
+	pool.release(conn2)
+	// the connection with the fewest streams is chosen
+	conn4, err := pool.acquire(ctx, addr)
+	c.Assert(err, check.IsNil)
+	c.Assert(conn4, check.Equals, conn2)
+
+	// the connection shut down is evicted and replaced
+	c.Assert(conn1.Close(), check.IsNil)
+	conn5, err := pool.acquire(ctx, addr)
+	c.Assert(err, check.IsNil)
+	c.Assert(conn5, check.Not(check.Equals), conn1)
+	c.Assert(conn1.evicted, check.IsTrue)
+	c.Assert(pool.stores[addr], check.HasLen, 2)
 
-	conn3 := ca.Get()
-	c.Assert(conn1, check.Equals, conn3)
+	// all the connections are evicted once the certificates are rotated
+	security.ReloadCertificates()
+	conn6, err := pool.acquire(ctx, addr)
+	c.Assert(err, check.IsNil)
+	c.Assert(conn4.evicted, check.IsTrue)
+	c.Assert(conn5.evicted, check.IsTrue)
+	c.Assert(pool.stores[addr], check.DeepEquals, []*pooledConn{conn6})
+
+	pool.release(conn1)
+	pool.release(conn4)
+	pool.release(conn5)
+	pool.release(conn6)
+	for _, conn := range pool.stores[addr] {
+		c.Assert(conn.streams, check.Equals, 0)
+		c.Assert(conn.Close(), check.IsNil)
+	}
+}
+
+func (s *etcdSuite) TestConnPoolRefs(c *check.C) {
+	addr := "127.0.0.1:2379"
+	credential := &security.Credential{}
+	pool := getConnPool(1, credential)
+	// the credentials are compared by their contents
+	c.Assert(getConnPool(1, &security.Credential{}), check.Equals, pool)
+	c.Assert(pool.refs, check.Equals, 2)
+	for _, other := range []*connPool{
+		getConnPool(2, credential),
+		getConnPool(1, &security.Credential{CAPath: "ca.pem"}),
+	} {
+		c.Assert(other, check.Not(check.Equals), pool)
+		putConnPool(other)
+	}
+	conn, err := pool.acquire(context.TODO(), addr)
+	c.Assert(err, check.IsNil)
 
-	ca.Close()
+	putConnPool(pool)
+	c.Assert(conn.evicted, check.IsFalse)
+	// the connections are evicted once the last kv client releases the pool,
+	// the ones with streams are closed when the streams are released
+	putConnPool(pool)
+	c.Assert(conn.evicted, check.IsTrue)
+	c.Assert(pool.stores, check.HasLen, 0)
+	newPool := getConnPool(1, credential)
+	c.Assert(newPool, check.Not(check.Equals), pool)
+	putConnPool(newPool)
+	pool.release(conn)
+	c.Assert(conn.GetState(), check.Equals, connectivity.Shutdown)
 }
@@ -0,0 +1,297 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+	"github.com/pingcap/ticdc/pkg/security"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	gbackoff "google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/keepalive"
+)
+
+// pooledConn is a connection in a connPool, the event feed streams of all the
+// kv clients to the same store are multiplexed on the connections in the pool.
+// All the fields except ClientConn are protected by the mutex of the pool.
+type pooledConn struct {
+	*grpc.ClientConn
+	addr string
+	// streams is the number of the active streams on the connection
+	streams int
+	// evicted is set when the connection is removed from the pool, it's closed
+	// when the last stream on it is released.
+	evicted bool
+}
+
+// connPool is a bounded pool of gRPC connections per store, which is shared by
+// all the kv clients to the same cluster with the same credential in the process, so that the tables
+// don't dial their own connections to every store.
+// A new stream is put on the connection with the fewest streams, and a new
+// connection is dialed only if all the connections of the store have streams.
+// The connections are health checked when a stream is acquired, the ones failed
+// or shut down are evicted, and new connections are dialed instead. All the
+// connections are evicted once the certificates are rotated, so that the new
+// streams are put on the connections dialed with the new certificates, while
+// the existing streams keep running until they're closed.
+type connPool struct {
+	key        connPoolKey
+	credential *security.Credential
+	// maxConns is the max number of connections to a store
+	maxConns int
+	// dial is replaced in tests
+	dial func(ctx context.Context, addr string, credential *security.Credential) (*grpc.ClientConn, error)
+
+	// refs is the number of the kv clients using the pool, it's protected by
+	// the mutex of defaultConnPools
+	refs int
+
+	mu     sync.Mutex
+	stores map[string][]*pooledConn
+	// rotated is closed when the certificates are rotated
+	rotated <-chan struct{}
+}
+
+func newConnPool(credential *security.Credential, maxConns int) *connPool {
+	return &connPool{
+		key:        connPoolKey{credential: credentialKey(credential)},
+		credential: credential,
+		maxConns:   maxConns,
+		dial:       dialStore,
+		stores:     make(map[string][]*pooledConn),
+		rotated:    security.CertificatesRotated(),
+	}
+}
+
+// connPoolKey identifies the pool of the kv clients to the same cluster with
+// the same credential. The credential is compared by its contents, as the
+// credentials of the upstreams are built for every processor.
+type connPoolKey struct {
+	clusterID  uint64
+	credential string
+}
+
+func credentialKey(credential *security.Credential) string {
+	return strings.Join([]string{
+		credential.CAPath, credential.CertPath, credential.KeyPath,
+		string(credential.CA), string(credential.Cert), string(credential.Key),
+	}, "\x00")
+}
+
+var defaultConnPools = struct {
+	sync.Mutex
+	pools map[connPoolKey]*connPool
+}{
+	pools: make(map[connPoolKey]*connPool),
+}
+
+// getConnPool returns the connPool shared by the kv clients to the cluster with
+// the credential, the caller must call putConnPool once it no longer uses the pool.
+func getConnPool(clusterID uint64, credential *security.Credential) *connPool {
+	defaultConnPools.Lock()
+	defer defaultConnPools.Unlock()
+	key := connPoolKey{clusterID: clusterID, credential: credentialKey(credential)}
+	pool, ok := defaultConnPools.pools[key]
+	if !ok {
+		pool = newConnPool(credential, grpcConnCount)
+		pool.key = key
+		defaultConnPools.pools[key] = pool
+	}
+	pool.refs++
+	return pool
+}
+
+// putConnPool releases the pool got by getConnPool, the pool is removed and
+// its connections are closed once the last kv client releases it.
+func putConnPool(pool *connPool) {
+	defaultConnPools.Lock()
+	defer defaultConnPools.Unlock()
+	pool.refs--
+	if pool.refs > 0 {
+		return
+	}
+	delete(defaultConnPools.pools, pool.key)
+	pool.close()
+}
+
+// close evicts all the connections, the ones with streams are closed once
+// their last streams are released.
+func (p *connPool) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.evictAllLocked("pool closed")
+}
+
+// acquire returns a connection to the store for a new stream, the caller must
+// call release when the stream is closed.
+func (p *connPool) acquire(ctx context.Context, addr string) (*pooledConn, error) {
+	p.mu.Lock()
+	select {
+	case <-p.rotated:
+		p.rotated = security.CertificatesRotated()
+		p.evictAllLocked("certificates rotated")
+	default:
+	}
+	p.evictUnhealthyLocked(addr)
+	var best *pooledConn
+	for _, conn := range p.stores[addr] {
+		if best == nil || conn.streams < best.streams {
+			best = conn
+		}
+	}
+	if best != nil && (best.streams == 0 || len(p.stores[addr]) >= p.maxConns) {
+		best.streams++
+		p.updateMetricsLocked(addr)
+		p.mu.Unlock()
+		return best, nil
+	}
+	p.mu.Unlock()
+
+	// dial without holding the lock, as it may take a while
+	clientConn, err := p.dial(ctx, addr, p.credential)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	conn := &pooledConn{ClientConn: clientConn, addr: addr, streams: 1}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.stores[addr]) >= p.maxConns {
+		// other streams have dialed the store concurrently, the new connection
+		// is used by this stream only
+		conn.evicted = true
+	} else {
+		p.stores[addr] = append(p.stores[addr], conn)
+	}
+	p.updateMetricsLocked(addr)
+	log.Info("dialed a new connection to store", zap.String("addr", addr),
+		zap.Int("conns", len(p.stores[addr])), zap.Bool("pooled", !conn.evicted))
+	return conn, nil
+}
+
+// release is called when a stream on the connection is closed
+func (p *connPool) release(conn *pooledConn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	conn.streams--
+	if conn.evicted && conn.streams == 0 {
+		closeConn(conn)
+	}
+	p.updateMetricsLocked(conn.addr)
+}
+
+// evictUnhealthyLocked removes the connections to the store which are failed or
+// shut down. The caller must hold p.mu.
+func (p *connPool) evictUnhealthyLocked(addr string) {
+	conns := p.stores[addr]
+	healthy := conns[:0]
+	for _, conn := range conns {
+		if isConnHealthy(conn.ClientConn) {
+			healthy = append(healthy, conn)
+			continue
+		}
+		log.Warn("evict unhealthy connection to store", zap.String("addr", addr),
+			zap.Stringer("state", conn.GetState()), zap.Int("streams", conn.streams))
+		conn.evicted = true
+		if conn.streams == 0 {
+			closeConn(conn)
+		}
+	}
+	if len(healthy) == 0 {
+		delete(p.stores, addr)
+	} else {
+		p.stores[addr] = healthy
+	}
+}
+
+// evictAllLocked removes all the connections. The caller must hold p.mu.
+func (p *connPool) evictAllLocked(reason string) {
+	for addr, conns := range p.stores {
+		log.Info("evict connections to store", zap.String("reason", reason),
+			zap.String("addr", addr), zap.Int("conns", len(conns)))
+		for _, conn := range conns {
+			conn.evicted = true
+			if conn.streams == 0 {
+				closeConn(conn)
+			}
+		}
+		delete(p.stores, addr)
+		p.updateMetricsLocked(addr)
+	}
+}
+
+// updateMetricsLocked refreshes the gauges of the store. The caller must hold p.mu.
+func (p *connPool) updateMetricsLocked(addr string) {
+	streams := 0
+	for _, conn := range p.stores[addr] {
+		streams += conn.streams
+	}
+	grpcPoolConnGauge.WithLabelValues(addr).Set(float64(len(p.stores[addr])))
+	grpcPoolStreamGauge.WithLabelValues(addr).Set(float64(streams))
+}
+
+func isConnHealthy(conn *grpc.ClientConn) bool {
+	state := conn.GetState()
+	return state != connectivity.TransientFailure && state != connectivity.Shutdown
+}
+
+func closeConn(conn *pooledConn) {
+	err := conn.Close()
+	if err != nil {
+		log.Warn("close grpc conn", zap.String("addr", conn.addr), zap.Error(err))
+	}
+}
+
+// dialStore dials a connection to the store
+func dialStore(ctx context.Context, addr string, credential *security.Credential) (*grpc.ClientConn, error) {
+	grpcTLSOption, err := credential.ToGRPCDialOption()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, dialTimeout)
+	defer cancel()
+	conn, err := grpc.DialContext(
+		ctx,
+		addr,
+		grpcTLSOption,
+		grpc.WithInitialWindowSize(grpcInitialWindowSize),
+		grpc.WithInitialConnWindowSize(grpcInitialConnWindowSize),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(grpcMaxCallRecvMsgSize)),
+		grpc.WithConnectParams(grpc.ConnectParams{
+			Backoff: gbackoff.Config{
+				BaseDelay:  time.Second,
+				Multiplier: 1.1,
+				Jitter:     0.1,
+				MaxDelay:   3 * time.Second,
+			},
+			MinConnectTimeout: 3 * time.Second,
+		}),
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                10 * time.Second,
+			Timeout:             3 * time.Second,
+			PermitWithoutStream: true,
+		}),
+	)
+	if err != nil {
+		return nil, cerror.WrapError(cerror.ErrGRPCDialFailed, err)
+	}
+	return conn, nil
+}
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/pingcap/errors"
 	"github.com/pingcap/log"
 	"github.com/pingcap/ticdc/cdc/model"
@@ -55,6 +56,13 @@ const (
 
 	// JobKeyPrefix is the prefix of job keys
 	JobKeyPrefix = EtcdKeyBase + "/job"
+
+	// TableProgressKeyPrefix is the prefix of table progress keys
+	TableProgressKeyPrefix = EtcdKeyBase + "/table-progress"
+
+	// AuditKeyPrefix is the prefix of the audit record keys, which are
+	// ordered by the time of the records
+	AuditKeyPrefix = EtcdKeyBase + "/audit"
 )
 
 // GetEtcdKeyChangeFeedList returns the prefix key of all changefeed config
@@ -102,11 +110,30 @@ func GetEtcdKeyTaskWorkload(changeFeedID, captureID string) string {
 	return TaskWorkloadKeyPrefix + "/" + captureID + "/" + changeFeedID
 }
 
+// GetEtcdKeyTableProgressList returns the prefix key of the table progress of a changefeed
+func GetEtcdKeyTableProgressList(changefeedID string) string {
+	return TableProgressKeyPrefix + "/" + changefeedID + "/"
+}
+
+// GetEtcdKeyTableProgress returns the key for the table progress of a processor
+func GetEtcdKeyTableProgress(changefeedID, captureID string) string {
+	return GetEtcdKeyTableProgressList(changefeedID) + captureID
+}
+
 // GetEtcdKeyJob returns the key for a job status
 func GetEtcdKeyJob(changeFeedID string) string {
 	return JobKeyPrefix + "/" + changeFeedID
 }
 
+// GetEtcdKeyAuditRecords returns the key the audit records at or after t start from
+func GetEtcdKeyAuditRecords(t time.Time) string {
+	ns := int64(0)
+	if t.After(time.Unix(0, 0)) {
+		ns = t.UnixNano()
+	}
+	return fmt.Sprintf("%s/%020d", AuditKeyPrefix, ns)
+}
+
 // CDCEtcdClient is a wrap of etcd client
 type CDCEtcdClient struct {
 	Client *etcd.Client
@@ -701,6 +728,49 @@ func (c CDCEtcdClient) DeleteTaskPosition(ctx context.Context, changefeedID stri
 	return cerror.WrapError(cerror.ErrPDEtcdAPIError, err)
 }
 
+// PutTableProgress puts the table progress of a processor into etcd
+func (c CDCEtcdClient) PutTableProgress(
+	ctx context.Context,
+	changefeedID string,
+	captureID string,
+	progress *model.TableProgress,
+) error {
+	data, err := progress.Marshal()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	key := GetEtcdKeyTableProgress(changefeedID, captureID)
+	_, err = c.Client.Put(ctx, key, data)
+	return cerror.WrapError(cerror.ErrPDEtcdAPIError, err)
+}
+
+// GetAllTableProgress queries the table progress of all processors of a
+// changefeed, and returns a map mapping from captureID to TableProgress
+func (c CDCEtcdClient) GetAllTableProgress(ctx context.Context, changefeedID string) (map[string]*model.TableProgress, error) {
+	prefix := GetEtcdKeyTableProgressList(changefeedID)
+	resp, err := c.Client.Get(ctx, prefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, cerror.WrapError(cerror.ErrPDEtcdAPIError, err)
+	}
+	progress := make(map[string]*model.TableProgress, resp.Count)
+	for _, rawKv := range resp.Kvs {
+		captureID := string(rawKv.Key[len(prefix):])
+		info := &model.TableProgress{}
+		err = info.Unmarshal(rawKv.Value)
+		if err != nil {
+			return nil, cerror.ErrDecodeFailed.GenWithStackByArgs("failed to unmarshal table progress: %s", err)
+		}
+		progress[captureID] = info
+	}
+	return progress, nil
+}
+
+// RemoveAllTableProgress removes the table progress of all processors of a changefeed
+func (c CDCEtcdClient) RemoveAllTableProgress(ctx context.Context, changefeedID string) error {
+	_, err := c.Client.Delete(ctx, GetEtcdKeyTableProgressList(changefeedID), clientv3.WithPrefix())
+	return cerror.WrapError(cerror.ErrPDEtcdAPIError, err)
+}
+
 // RemoveChangeFeedStatus removes changefeed job status from etcd
 func (c CDCEtcdClient) RemoveChangeFeedStatus(
 	ctx context.Context,
@@ -844,3 +914,51 @@ func (c CDCEtcdClient) GetOwnerID(ctx context.Context, key string) (string, erro
 	}
 	return string(resp.Kvs[0].Value), nil
 }
+
+// PutAuditRecord appends an audit record into etcd, the records are never
+// modified once they're put.
+func (c CDCEtcdClient) PutAuditRecord(ctx context.Context, record *model.AuditRecord) error {
+	value, err := record.Marshal()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// the suffix distinguishes the records at the same time
+	key := GetEtcdKeyAuditRecords(record.Time) + "/" + uuid.New().String()
+	resp, err := c.Client.Txn(ctx).If(
+		clientv3.Compare(clientv3.CreateRevision(key), "=", 0),
+	).Then(
+		clientv3.OpPut(key, value),
+	).Commit()
+	if err != nil {
+		return cerror.WrapError(cerror.ErrPDEtcdAPIError, err)
+	}
+	if !resp.Succeeded {
+		return cerror.ErrPDEtcdAPIError.GenWithStack("audit record %s already exists", key)
+	}
+	return nil
+}
+
+// GetAuditRecords queries the audit records at or after since, in the order of
+// their time.
+func (c CDCEtcdClient) GetAuditRecords(ctx context.Context, since time.Time) ([]*model.AuditRecord, error) {
+	resp, err := c.Client.Get(ctx, GetEtcdKeyAuditRecords(since),
+		clientv3.WithRange(clientv3.GetPrefixRangeEnd(AuditKeyPrefix+"/")))
+	if err != nil {
+		return nil, cerror.WrapError(cerror.ErrPDEtcdAPIError, err)
+	}
+	records := make([]*model.AuditRecord, 0, resp.Count)
+	for _, rawKv := range resp.Kvs {
+		record := &model.AuditRecord{}
+		if err := record.Unmarshal(rawKv.Value); err != nil {
+			return nil, errors.Trace(err)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// RemoveAuditRecordsBefore removes the audit records before t
+func (c CDCEtcdClient) RemoveAuditRecordsBefore(ctx context.Context, t time.Time) error {
+	_, err := c.Client.Delete(ctx, AuditKeyPrefix+"/", clientv3.WithRange(GetEtcdKeyAuditRecords(t)))
+	return cerror.WrapError(cerror.ErrPDEtcdAPIError, err)
+}
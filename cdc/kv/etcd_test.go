@@ -385,6 +385,41 @@ func (s *etcdSuite) TestGetAllTaskWorkload(c *check.C) {
 	}
 }
 
+func (s *etcdSuite) TestTableProgress(c *check.C) {
+	ctx := context.Background()
+	info := &model.ChangeFeedInfo{
+		SinkURI:    "blackhole://",
+		CreateTime: time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC),
+	}
+	feeds := []string{"feed", "feed2"}
+	for i, feed := range feeds {
+		for j, capture := range []string{"capture1", "capture2"} {
+			progress := model.NewTableProgress(info)
+			progress.Tables[int64(1000*(i+1)+j)] = uint64(100 * (j + 1))
+			err := s.client.PutTableProgress(ctx, feed, capture, progress)
+			c.Assert(err, check.IsNil)
+		}
+	}
+
+	progress, err := s.client.GetAllTableProgress(ctx, "feed")
+	c.Assert(err, check.IsNil)
+	c.Assert(progress, check.HasLen, 2)
+	c.Assert(progress["capture1"].Tables, check.DeepEquals, map[model.TableID]model.Ts{1000: 100})
+	c.Assert(progress["capture2"].Tables, check.DeepEquals, map[model.TableID]model.Ts{1001: 200})
+	c.Assert(progress["capture1"].Match(info), check.IsTrue)
+	info.SinkURI = "blackhole://?changed"
+	c.Assert(progress["capture1"].Match(info), check.IsFalse)
+
+	err = s.client.RemoveAllTableProgress(ctx, "feed")
+	c.Assert(err, check.IsNil)
+	progress, err = s.client.GetAllTableProgress(ctx, "feed")
+	c.Assert(err, check.IsNil)
+	c.Assert(progress, check.HasLen, 0)
+	progress, err = s.client.GetAllTableProgress(ctx, "feed2")
+	c.Assert(err, check.IsNil)
+	c.Assert(progress, check.HasLen, 2)
+}
+
 func (s *etcdSuite) TestCreateChangefeed(c *check.C) {
 	ctx := context.Background()
 	detail := &model.ChangeFeedInfo{
@@ -449,3 +484,35 @@ func (s *etcdSuite) TestGetAllCaptureLeases(c *check.C) {
 	c.Assert(err, check.IsNil)
 	c.Check(queryLeases, check.DeepEquals, map[string]int64{})
 }
+
+func (s *etcdSuite) TestAuditRecords(c *check.C) {
+	ctx := context.Background()
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		record := &model.AuditRecord{
+			Time:       start.Add(time.Duration(i) * time.Minute),
+			Action:     model.AuditPauseChangefeed,
+			Changefeed: fmt.Sprintf("cf-%d", i),
+			Result:     model.AuditSuccess,
+		}
+		c.Assert(s.client.PutAuditRecord(ctx, record), check.IsNil)
+		// the records at the same time are kept
+		c.Assert(s.client.PutAuditRecord(ctx, record), check.IsNil)
+	}
+	records, err := s.client.GetAuditRecords(ctx, time.Time{})
+	c.Assert(err, check.IsNil)
+	c.Assert(records, check.HasLen, 6)
+	for i, record := range records {
+		c.Assert(record.Changefeed, check.Equals, fmt.Sprintf("cf-%d", i/2))
+		c.Assert(record.Time.Equal(start.Add(time.Duration(i/2)*time.Minute)), check.IsTrue)
+	}
+	records, err = s.client.GetAuditRecords(ctx, start.Add(time.Minute))
+	c.Assert(err, check.IsNil)
+	c.Assert(records, check.HasLen, 4)
+
+	c.Assert(s.client.RemoveAuditRecordsBefore(ctx, start.Add(2*time.Minute)), check.IsNil)
+	records, err = s.client.GetAuditRecords(ctx, time.Time{})
+	c.Assert(err, check.IsNil)
+	c.Assert(records, check.HasLen, 2)
+	c.Assert(records[0].Changefeed, check.Equals, "cf-2")
+}
@@ -14,18 +14,76 @@
 package kv
 
 import (
+	"container/list"
+	"sync"
+	"time"
+
 	"github.com/pingcap/kvproto/pkg/cdcpb"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// MatcherCacheConfig limits the prewrites cached by the matcher of a region
+// feed while waiting for their commits. The prewrites of a long-running
+// transaction are evicted once the limits are reached, and their values are
+// fetched from TiKV when the commits arrive.
+type MatcherCacheConfig struct {
+	// MaxEntries is the max number of the cached prewrites of a region, 0 is unlimited
+	MaxEntries int
+	// MaxAge is the max duration a prewrite is cached, 0 is unlimited
+	MaxAge time.Duration
+}
+
+var globalMatcherCacheConfig struct {
+	sync.RWMutex
+	cfg MatcherCacheConfig
+}
+
+// SetMatcherCacheConfig sets the matcher cache limits of the kv clients created
+// afterwards, the cache is unlimited if it's never set.
+func SetMatcherCacheConfig(cfg MatcherCacheConfig) {
+	globalMatcherCacheConfig.Lock()
+	defer globalMatcherCacheConfig.Unlock()
+	globalMatcherCacheConfig.cfg = cfg
+}
+
+func getMatcherCacheConfig() MatcherCacheConfig {
+	globalMatcherCacheConfig.RLock()
+	defer globalMatcherCacheConfig.RUnlock()
+	return globalMatcherCacheConfig.cfg
+}
+
+// the reasons of evicting a prewrite from the matcher cache
+const (
+	matcherEvictSize = "size"
+	matcherEvictAge  = "age"
 )
 
 type pendingValue struct {
 	value    []byte
 	oldValue []byte
+
+	key     matchKey
+	putTime time.Time
+	// elem is the element of the value in the eviction queue
+	elem *list.Element
 }
 
 type matcher struct {
+	cfg MatcherCacheConfig
 	// TODO : clear the single prewrite
 	unmatchedValue map[matchKey]*pendingValue
-	cachedCommit   []*cdcpb.Event_Row
+	// queue holds the unmatched values, the oldest first
+	queue *list.List
+	// evicted holds the keys of the prewrites evicted from the cache, whose
+	// values must be fetched from TiKV when they're committed. The keys are
+	// forgotten once the resolved ts passes their start ts, see forgetResolved.
+	evicted map[matchKey]struct{}
+	// minEvictedTs is the min start ts in evicted, 0 if evicted is empty
+	minEvictedTs uint64
+	cachedCommit []*cdcpb.Event_Row
+
+	// cacheSize is nil if the occupancy isn't reported
+	cacheSize prometheus.Gauge
 }
 
 type matchKey struct {
@@ -38,8 +96,16 @@ func newMatchKey(row *cdcpb.Event_Row) matchKey {
 }
 
 func newMatcher() *matcher {
+	return newMatcherWithCache(MatcherCacheConfig{}, nil)
+}
+
+func newMatcherWithCache(cfg MatcherCacheConfig, cacheSize prometheus.Gauge) *matcher {
 	return &matcher{
+		cfg:            cfg,
 		unmatchedValue: make(map[matchKey]*pendingValue),
+		queue:          list.New(),
+		evicted:        make(map[matchKey]struct{}),
+		cacheSize:      cacheSize,
 	}
 }
 
@@ -52,20 +118,53 @@ func (m *matcher) putPrewriteRow(row *cdcpb.Event_Row) {
 	if _, exist := m.unmatchedValue[key]; exist && len(value) == 0 {
 		return
 	}
-	m.unmatchedValue[key] = &pendingValue{
+	// the value of an evicted prewrite is fetched when it's committed
+	if _, exist := m.evicted[key]; exist {
+		if len(value) == 0 {
+			return
+		}
+		m.forgetEviction(key)
+	}
+	if v, exist := m.unmatchedValue[key]; exist {
+		v.value = value
+		v.oldValue = oldvalue
+		return
+	}
+	v := &pendingValue{
 		value:    value,
 		oldValue: oldvalue,
+		key:      key,
+		putTime:  time.Now(),
+	}
+	v.elem = m.queue.PushBack(v)
+	m.unmatchedValue[key] = v
+	m.addCacheSize(1)
+	if m.cfg.MaxEntries > 0 {
+		for len(m.unmatchedValue) > m.cfg.MaxEntries {
+			m.evict(m.queue.Front().Value.(*pendingValue), matcherEvictSize)
+		}
 	}
 }
 
 func (m *matcher) matchRow(row *cdcpb.Event_Row) (*pendingValue, bool) {
 	if value, exist := m.unmatchedValue[newMatchKey(row)]; exist {
-		delete(m.unmatchedValue, newMatchKey(row))
+		m.remove(value)
 		return value, true
 	}
 	return nil, false
 }
 
+// isEvicted returns whether the prewrite of a commit row is evicted, and
+// forgets the eviction, the caller must fetch the value of the row from TiKV.
+func (m *matcher) isEvicted(row *cdcpb.Event_Row) bool {
+	key := newMatchKey(row)
+	if _, exist := m.evicted[key]; exist {
+		m.forgetEviction(key)
+		return true
+	}
+	return false
+}
+
 func (m *matcher) cacheCommitRow(row *cdcpb.Event_Row) {
 	m.cachedCommit = append(m.cachedCommit, row)
 }
@@ -75,5 +174,77 @@ func (m *matcher) clearCacheCommit() {
 }
 
 func (m *matcher) rollbackRow(row *cdcpb.Event_Row) {
-	delete(m.unmatchedValue, newMatchKey(row))
+	key := newMatchKey(row)
+	if value, exist := m.unmatchedValue[key]; exist {
+		m.remove(value)
+	}
+	m.forgetEviction(key)
+}
+
+// forgetResolved forgets the evicted prewrites whose start ts is less than
+// the resolved ts. TiKV doesn't advance the resolved ts past the start ts of a
+// lock, so these transactions are already committed or rolled back, the keys
+// left are the ones whose commits or rollbacks are never seen, e.g. the
+// rollbacks cleaned up by the GC.
+func (m *matcher) forgetResolved(resolvedTs uint64) {
+	if len(m.evicted) == 0 || resolvedTs <= m.minEvictedTs {
+		return
+	}
+	m.minEvictedTs = 0
+	for key := range m.evicted {
+		if key.startTs < resolvedTs {
+			delete(m.evicted, key)
+		} else if m.minEvictedTs == 0 || key.startTs < m.minEvictedTs {
+			m.minEvictedTs = key.startTs
+		}
+	}
+}
+
+// forgetEviction forgets an evicted prewrite, the min start ts is left as is
+// since it's only a lower bound for forgetResolved.
+func (m *matcher) forgetEviction(key matchKey) {
+	delete(m.evicted, key)
+	if len(m.evicted) == 0 {
+		m.minEvictedTs = 0
+	}
+}
+
+// evictExpired evicts the prewrites cached longer than the max age.
+func (m *matcher) evictExpired(now time.Time) {
+	if m.cfg.MaxAge <= 0 {
+		return
+	}
+	for m.queue.Len() > 0 {
+		v := m.queue.Front().Value.(*pendingValue)
+		if now.Sub(v.putTime) <= m.cfg.MaxAge {
+			return
+		}
+		m.evict(v, matcherEvictAge)
+	}
+}
+
+// close stops reporting the occupancy of the matcher.
+func (m *matcher) close() {
+	m.addCacheSize(-len(m.unmatchedValue))
+}
+
+func (m *matcher) evict(v *pendingValue, reason string) {
+	m.remove(v)
+	m.evicted[v.key] = struct{}{}
+	if m.minEvictedTs == 0 || v.key.startTs < m.minEvictedTs {
+		m.minEvictedTs = v.key.startTs
+	}
+	matcherEvictCounter.WithLabelValues(reason).Inc()
+}
+
+func (m *matcher) remove(v *pendingValue) {
+	delete(m.unmatchedValue, v.key)
+	m.queue.Remove(v.elem)
+	m.addCacheSize(-1)
+}
+
+func (m *matcher) addCacheSize(n int) {
+	if m.cacheSize != nil {
+		m.cacheSize.Add(float64(n))
+	}
 }
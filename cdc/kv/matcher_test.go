@@ -14,6 +14,8 @@
 package kv
 
 import (
+	"time"
+
 	"github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/cdcpb"
 )
@@ -52,3 +54,57 @@ func (s *MatcherSuite) TestMatcher(c *check.C) {
 	c.Assert(ok, check.IsTrue)
 	c.Assert(value2.value, check.BytesEquals, []byte("v2"))
 }
+
+func (s *MatcherSuite) TestMatcherEviction(c *check.C) {
+	matcher := newMatcherWithCache(MatcherCacheConfig{MaxEntries: 2, MaxAge: time.Minute}, nil)
+	for i := 1; i <= 3; i++ {
+		matcher.putPrewriteRow(&cdcpb.Event_Row{
+			StartTs: uint64(i),
+			Key:     []byte("k1"),
+			Value:   []byte("v"),
+		})
+	}
+	// the oldest prewrite is evicted by size
+	c.Assert(matcher.unmatchedValue, check.HasLen, 2)
+	commitRow1 := &cdcpb.Event_Row{StartTs: 1, Key: []byte("k1")}
+	_, ok := matcher.matchRow(commitRow1)
+	c.Assert(ok, check.IsFalse)
+	c.Assert(matcher.isEvicted(commitRow1), check.IsTrue)
+	c.Assert(matcher.isEvicted(commitRow1), check.IsFalse)
+
+	// a heartbeat doesn't bring an evicted prewrite back
+	matcher.evictExpired(time.Now().Add(2 * time.Minute))
+	c.Assert(matcher.unmatchedValue, check.HasLen, 0)
+	c.Assert(matcher.queue.Len(), check.Equals, 0)
+	matcher.putPrewriteRow(&cdcpb.Event_Row{StartTs: 2, Key: []byte("k1")})
+	c.Assert(matcher.unmatchedValue, check.HasLen, 0)
+	c.Assert(matcher.isEvicted(&cdcpb.Event_Row{StartTs: 2, Key: []byte("k1")}), check.IsTrue)
+
+	// a rollback forgets the eviction
+	matcher.rollbackRow(&cdcpb.Event_Row{StartTs: 3, Key: []byte("k1")})
+	c.Assert(matcher.evicted, check.HasLen, 0)
+}
+
+func (s *MatcherSuite) TestMatcherForgetResolved(c *check.C) {
+	matcher := newMatcherWithCache(MatcherCacheConfig{MaxEntries: 1}, nil)
+	for i := 1; i <= 4; i++ {
+		matcher.putPrewriteRow(&cdcpb.Event_Row{
+			StartTs: uint64(i * 10),
+			Key:     []byte("k1"),
+			Value:   []byte("v"),
+		})
+	}
+	c.Assert(matcher.evicted, check.HasLen, 3)
+	c.Assert(matcher.minEvictedTs, check.Equals, uint64(10))
+
+	// the evictions before the resolved ts are forgotten
+	matcher.forgetResolved(10)
+	c.Assert(matcher.evicted, check.HasLen, 3)
+	matcher.forgetResolved(21)
+	c.Assert(matcher.evicted, check.HasLen, 1)
+	c.Assert(matcher.minEvictedTs, check.Equals, uint64(30))
+	c.Assert(matcher.isEvicted(&cdcpb.Event_Row{StartTs: 30, Key: []byte("k1")}), check.IsTrue)
+	c.Assert(matcher.minEvictedTs, check.Equals, uint64(0))
+	// the cached prewrite is kept
+	c.Assert(matcher.unmatchedValue, check.HasLen, 1)
+}
@@ -75,6 +75,70 @@ var (
 			Help:      "The number of region in one batch resolved ts event",
 			Buckets:   prometheus.ExponentialBuckets(2, 2, 16),
 		}, []string{"capture", "changefeed"})
+	scanBacklogGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "ticdc",
+			Subsystem: "kvclient",
+			Name:      "scan_backlog_regions",
+			Help:      "The number of regions waiting for the scan rate limit or being scanned incrementally",
+		}, []string{"capture", "type"})
+	grpcPoolConnGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "ticdc",
+			Subsystem: "kvclient",
+			Name:      "grpc_pool_conn_count",
+			Help:      "The number of pooled gRPC connections to a store",
+		}, []string{"store"})
+	grpcPoolStreamGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "ticdc",
+			Subsystem: "kvclient",
+			Name:      "grpc_pool_stream_count",
+			Help:      "The number of event feed streams on the pooled gRPC connections to a store",
+		}, []string{"store"})
+	regionRetryCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "ticdc",
+			Subsystem: "kvclient",
+			Name:      "region_retry_count",
+			Help:      "The number of region retries by reason",
+		}, []string{"reason"})
+	regionRetryBackoffHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "ticdc",
+			Subsystem: "kvclient",
+			Name:      "region_retry_backoff_seconds",
+			Help:      "The backoff duration before a region is retried",
+			Buckets:   prometheus.ExponentialBuckets(0.005, 2, 12),
+		}, []string{"reason"})
+	storeBreakerStateGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "ticdc",
+			Subsystem: "kvclient",
+			Name:      "store_breaker_state",
+			Help:      "The circuit breaker state of a store, 0 is closed, 1 is open, 2 is half-open",
+		}, []string{"store"})
+	matcherCacheGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "ticdc",
+			Subsystem: "kvclient",
+			Name:      "matcher_cache_entries",
+			Help:      "The number of prewrites cached by the matchers waiting for commits",
+		}, []string{"capture", "changefeed"})
+	matcherEvictCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "ticdc",
+			Subsystem: "kvclient",
+			Name:      "matcher_evict_count",
+			Help:      "The number of prewrites evicted from the matcher cache by reason",
+		}, []string{"reason"})
+	matcherRefetchCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "ticdc",
+			Subsystem: "kvclient",
+			Name:      "matcher_refetch_count",
+			Help:      "The number of committed values fetched from TiKV as their prewrites are evicted",
+		}, []string{"capture"})
 	etcdRequestCounter = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: "ticdc",
@@ -94,5 +158,14 @@ func InitMetrics(registry *prometheus.Registry) {
 	registry.MustRegister(sendEventCounter)
 	registry.MustRegister(clientChannelSize)
 	registry.MustRegister(batchResolvedEventSize)
+	registry.MustRegister(scanBacklogGauge)
+	registry.MustRegister(grpcPoolConnGauge)
+	registry.MustRegister(grpcPoolStreamGauge)
+	registry.MustRegister(regionRetryCounter)
+	registry.MustRegister(regionRetryBackoffHistogram)
+	registry.MustRegister(storeBreakerStateGauge)
+	registry.MustRegister(matcherCacheGauge)
+	registry.MustRegister(matcherEvictCounter)
+	registry.MustRegister(matcherRefetchCounter)
 	registry.MustRegister(etcdRequestCounter)
 }
@@ -0,0 +1,140 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/pingcap/errors"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+)
+
+// regionRetryReason is the class of an error which makes a region be requested again
+type regionRetryReason string
+
+const (
+	regionRetryNotLeader         regionRetryReason = "not-leader"
+	regionRetryEpochNotMatch     regionRetryReason = "epoch-not-match"
+	regionRetryRegionNotFound    regionRetryReason = "region-not-found"
+	regionRetryStoreUnreachable  regionRetryReason = "store-unreachable"
+	regionRetryStoreBreakerOpen  regionRetryReason = "store-breaker-open"
+	regionRetryRPCCtxUnavailable regionRetryReason = "rpc-ctx-unavailable"
+	regionRetryUnknown           regionRetryReason = "unknown"
+)
+
+// regionRetryPolicy is an exponential backoff with jitter. The n-th retry waits
+// for baseDelay * multiplier^n, which is capped by maxDelay and then reduced by
+// a random fraction up to jitter, so the regions failed at the same time don't
+// retry at the same time.
+type regionRetryPolicy struct {
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+	multiplier float64
+	jitter     float64
+}
+
+// regionRetryPolicies are the policies of the reasons. A region whose leader or
+// epoch is changed is retried quickly, as the region cache is updated by the
+// error, while a store which can't be reached usually takes a while to recover.
+var regionRetryPolicies = map[regionRetryReason]regionRetryPolicy{
+	regionRetryNotLeader:         {baseDelay: 10 * time.Millisecond, maxDelay: time.Second, multiplier: 2, jitter: 0.2},
+	regionRetryEpochNotMatch:     {baseDelay: 10 * time.Millisecond, maxDelay: time.Second, multiplier: 2, jitter: 0.2},
+	regionRetryRegionNotFound:    {baseDelay: 50 * time.Millisecond, maxDelay: 2 * time.Second, multiplier: 2, jitter: 0.2},
+	regionRetryStoreUnreachable:  {baseDelay: 500 * time.Millisecond, maxDelay: 10 * time.Second, multiplier: 2, jitter: 0.5},
+	regionRetryStoreBreakerOpen:  {baseDelay: 100 * time.Millisecond, maxDelay: 5 * time.Second, multiplier: 2, jitter: 0.5},
+	regionRetryRPCCtxUnavailable: {baseDelay: 100 * time.Millisecond, maxDelay: 5 * time.Second, multiplier: 2, jitter: 0.2},
+	regionRetryUnknown:           {baseDelay: 100 * time.Millisecond, maxDelay: 3 * time.Second, multiplier: 2, jitter: 0.2},
+}
+
+// backoff returns how long to wait before the retry after `attempt` retries
+func (p regionRetryPolicy) backoff(attempt int) time.Duration {
+	delay := float64(p.baseDelay) * math.Pow(p.multiplier, float64(attempt))
+	if delay > float64(p.maxDelay) {
+		delay = float64(p.maxDelay)
+	}
+	delay -= delay * p.jitter * rand.Float64()
+	return time.Duration(delay)
+}
+
+// classifyRegionError returns the reason to retry a region failed with err
+func classifyRegionError(err error) regionRetryReason {
+	switch eerr := errors.Cause(err).(type) {
+	case *eventError:
+		switch {
+		case eerr.err.GetNotLeader() != nil:
+			return regionRetryNotLeader
+		case eerr.err.GetEpochNotMatch() != nil:
+			return regionRetryEpochNotMatch
+		case eerr.err.GetRegionNotFound() != nil:
+			return regionRetryRegionNotFound
+		}
+	case *rpcCtxUnavailableErr:
+		return regionRetryRPCCtxUnavailable
+	}
+	if cerror.ErrEventFeedAborted.Equal(err) || cerror.ErrPendingRegionCancel.Equal(err) ||
+		cerror.ErrTiKVEventFeed.Equal(err) || cerror.ErrGRPCDialFailed.Equal(err) {
+		return regionRetryStoreUnreachable
+	}
+	return regionRetryUnknown
+}
+
+// backoffRegionRetry counts the retry and waits for the backoff of the reason
+// after `attempt` retries, it returns an error only if ctx is done.
+func backoffRegionRetry(ctx context.Context, reason regionRetryReason, attempt int) error {
+	regionRetryCounter.WithLabelValues(string(reason)).Inc()
+	delay := regionRetryPolicies[reason].backoff(attempt)
+	regionRetryBackoffHistogram.WithLabelValues(string(reason)).Observe(delay.Seconds())
+	if delay <= 0 {
+		return nil
+	}
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return errors.Trace(ctx.Err())
+	}
+}
+
+// regionRetryTracker counts the successive retries of the regions in a session,
+// the count of a region is reset once the region makes progress.
+type regionRetryTracker struct {
+	mu       sync.Mutex
+	attempts map[uint64]int
+}
+
+func newRegionRetryTracker() *regionRetryTracker {
+	return &regionRetryTracker{attempts: make(map[uint64]int)}
+}
+
+// next returns the number of the previous retries of the region and counts a new one
+func (t *regionRetryTracker) next(regionID uint64) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	attempt := t.attempts[regionID]
+	t.attempts[regionID] = attempt + 1
+	return attempt
+}
+
+// reset is called when the region makes progress
+func (t *regionRetryTracker) reset(regionID uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.attempts, regionID)
+}
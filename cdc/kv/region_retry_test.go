@@ -0,0 +1,84 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"context"
+	"time"
+
+	"github.com/pingcap/check"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/kvproto/pkg/cdcpb"
+	"github.com/pingcap/kvproto/pkg/errorpb"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+)
+
+type regionRetrySuite struct{}
+
+var _ = check.Suite(&regionRetrySuite{})
+
+func (s *regionRetrySuite) TestBackoff(c *check.C) {
+	p := regionRetryPolicy{baseDelay: 10 * time.Millisecond, maxDelay: time.Second, multiplier: 2, jitter: 0.2}
+	for i := 0; i < 100; i++ {
+		d := p.backoff(0)
+		c.Assert(d >= 8*time.Millisecond && d <= 10*time.Millisecond, check.IsTrue, check.Commentf("%s", d))
+		d = p.backoff(3)
+		c.Assert(d >= 64*time.Millisecond && d <= 80*time.Millisecond, check.IsTrue, check.Commentf("%s", d))
+		d = p.backoff(100)
+		c.Assert(d >= 800*time.Millisecond && d <= time.Second, check.IsTrue, check.Commentf("%s", d))
+	}
+
+	p.jitter = 0
+	c.Assert(p.backoff(1), check.Equals, 20*time.Millisecond)
+	c.Assert(p.backoff(10), check.Equals, time.Second)
+	for reason, p := range regionRetryPolicies {
+		c.Assert(p.backoff(0) <= p.maxDelay, check.IsTrue, check.Commentf("%s", reason))
+	}
+}
+
+func (s *regionRetrySuite) TestClassifyRegionError(c *check.C) {
+	newEventError := func(err *cdcpb.Error) error {
+		return cerror.WrapError(cerror.ErrEventFeedEventError, &eventError{err: err})
+	}
+	testCases := []struct {
+		err    error
+		reason regionRetryReason
+	}{
+		{newEventError(&cdcpb.Error{NotLeader: &errorpb.NotLeader{}}), regionRetryNotLeader},
+		{newEventError(&cdcpb.Error{EpochNotMatch: &errorpb.EpochNotMatch{}}), regionRetryEpochNotMatch},
+		{newEventError(&cdcpb.Error{RegionNotFound: &errorpb.RegionNotFound{}}), regionRetryRegionNotFound},
+		{newEventError(&cdcpb.Error{}), regionRetryUnknown},
+		{&rpcCtxUnavailableErr{}, regionRetryRPCCtxUnavailable},
+		{cerror.ErrEventFeedAborted.GenWithStackByArgs(), regionRetryStoreUnreachable},
+		{cerror.ErrPendingRegionCancel.GenWithStackByArgs(), regionRetryStoreUnreachable},
+		{errors.Trace(cerror.ErrPendingRegionCancel.GenWithStackByArgs()), regionRetryStoreUnreachable},
+		{errors.New("unknown"), regionRetryUnknown},
+	}
+	for _, tc := range testCases {
+		c.Assert(classifyRegionError(tc.err), check.Equals, tc.reason, check.Commentf("%v", tc.err))
+	}
+}
+
+func (s *regionRetrySuite) TestRetryTracker(c *check.C) {
+	t := newRegionRetryTracker()
+	c.Assert(t.next(1), check.Equals, 0)
+	c.Assert(t.next(1), check.Equals, 1)
+	c.Assert(t.next(2), check.Equals, 0)
+	t.reset(1)
+	c.Assert(t.next(1), check.Equals, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c.Assert(backoffRegionRetry(ctx, regionRetryStoreUnreachable, 10), check.NotNil)
+}
@@ -0,0 +1,115 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/ticdc/pkg/buckets"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const scanLimiterRefillInterval = time.Second
+
+// ScanLimiter limits the rate of the incremental scans of all the kv clients in
+// a capture. TiKV scans a region from the checkpoint ts when a region is
+// requested, so a changefeed starting far behind makes TiKV scan a lot of data.
+// The limiter throttles both the number of regions requested per second and the
+// bytes received from the regions which are not initialized yet per second.
+// The limits are throughput buckets of a bucket group, which is refilled by Run.
+type ScanLimiter struct {
+	group *buckets.BucketGroup
+	// regions and bytes are nil if the corresponding rate is unlimited
+	regions *buckets.Bucket
+	bytes   *buckets.Bucket
+}
+
+// NewScanLimiter creates a ScanLimiter, a rate of 0 is unlimited.
+func NewScanLimiter(regionsPerSecond, bytesPerSecond int64) (*ScanLimiter, error) {
+	l := &ScanLimiter{
+		group: buckets.NewBucketGroup(regionsPerSecond+bytesPerSecond, scanLimiterRefillInterval),
+	}
+	l.group.SetName("kv-scan")
+	var err error
+	if regionsPerSecond > 0 {
+		l.regions, err = l.group.CreateBucket(0, regionsPerSecond, 0)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	if bytesPerSecond > 0 {
+		l.bytes, err = l.group.CreateBucket(0, bytesPerSecond, 0)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return l, nil
+}
+
+// Run refills the limits every second until ctx is done.
+func (l *ScanLimiter) Run(ctx context.Context) error {
+	return l.group.Run(ctx)
+}
+
+// acquireRegion blocks until a region can be requested.
+func (l *ScanLimiter) acquireRegion(ctx context.Context, backlog prometheus.Gauge) error {
+	if l == nil || l.regions == nil {
+		return nil
+	}
+	backlog.Inc()
+	defer backlog.Dec()
+	return errors.Trace(l.regions.Acquire(ctx, 1))
+}
+
+// acquireBytes blocks until n bytes of scanned data can be received. A message
+// larger than the limit waits for several refill rounds.
+func (l *ScanLimiter) acquireBytes(ctx context.Context, n int64) error {
+	if l == nil || l.bytes == nil {
+		return nil
+	}
+	limit := l.bytes.Quota()
+	for n > 0 {
+		chunk := n
+		if chunk > limit {
+			chunk = limit
+		}
+		if err := l.bytes.Acquire(ctx, chunk); err != nil {
+			return errors.Trace(err)
+		}
+		n -= chunk
+	}
+	return nil
+}
+
+var globalScanLimiter struct {
+	sync.RWMutex
+	l *ScanLimiter
+}
+
+// SetGlobalScanLimiter sets the ScanLimiter of the kv clients created afterwards,
+// the incremental scans are not limited if it's never set.
+func SetGlobalScanLimiter(l *ScanLimiter) {
+	globalScanLimiter.Lock()
+	defer globalScanLimiter.Unlock()
+	globalScanLimiter.l = l
+}
+
+func getGlobalScanLimiter() *ScanLimiter {
+	globalScanLimiter.RLock()
+	defer globalScanLimiter.RUnlock()
+	return globalScanLimiter.l
+}
@@ -0,0 +1,92 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"context"
+	"time"
+
+	"github.com/pingcap/check"
+)
+
+type scanLimiterSuite struct{}
+
+var _ = check.Suite(&scanLimiterSuite{})
+
+func (s *scanLimiterSuite) TestUnlimited(c *check.C) {
+	ctx := context.Background()
+	var l *ScanLimiter
+	gauge := scanBacklogGauge.WithLabelValues("test", "waiting")
+	c.Assert(l.acquireRegion(ctx, gauge), check.IsNil)
+	c.Assert(l.acquireBytes(ctx, 1<<30), check.IsNil)
+
+	l, err := NewScanLimiter(0, 0)
+	c.Assert(err, check.IsNil)
+	for i := 0; i < 100; i++ {
+		c.Assert(l.acquireRegion(ctx, gauge), check.IsNil)
+	}
+	c.Assert(l.acquireBytes(ctx, 1<<30), check.IsNil)
+}
+
+func (s *scanLimiterSuite) TestLimitRegions(c *check.C) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	l, err := NewScanLimiter(2, 0)
+	c.Assert(err, check.IsNil)
+	gauge := scanBacklogGauge.WithLabelValues("test", "waiting")
+	c.Assert(l.acquireRegion(ctx, gauge), check.IsNil)
+	c.Assert(l.acquireRegion(ctx, gauge), check.IsNil)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- l.acquireRegion(ctx, gauge)
+	}()
+	select {
+	case <-done:
+		c.Fatal("the third region is not limited")
+	case <-time.After(100 * time.Millisecond):
+	}
+	l.group.Refill()
+	c.Assert(<-done, check.IsNil)
+	// the bytes are not limited
+	c.Assert(l.acquireBytes(ctx, 1<<30), check.IsNil)
+
+	c.Assert(l.acquireRegion(ctx, gauge), check.IsNil)
+	canceledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	c.Assert(l.acquireRegion(canceledCtx, gauge), check.NotNil)
+}
+
+func (s *scanLimiterSuite) TestLimitBytes(c *check.C) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	l, err := NewScanLimiter(0, 10)
+	c.Assert(err, check.IsNil)
+
+	// a message larger than the limit takes several refill rounds
+	done := make(chan error, 1)
+	go func() {
+		done <- l.acquireBytes(ctx, 25)
+	}()
+	for i := 0; i < 2; i++ {
+		select {
+		case <-done:
+			c.Fatalf("25 bytes are received after %d refill rounds", i)
+		case <-time.After(100 * time.Millisecond):
+		}
+		l.group.Refill()
+	}
+	c.Assert(<-done, check.IsNil)
+	c.Assert(l.bytes.Available(), check.Equals, int64(5))
+}
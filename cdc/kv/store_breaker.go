@@ -0,0 +1,162 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pingcap/log"
+	"go.uber.org/zap"
+)
+
+const (
+	// storeBreakerFailureThreshold is the number of the consecutive failures
+	// of a store which open its breaker
+	storeBreakerFailureThreshold = 3
+	// storeBreakerBaseCooldown is how long the breaker of a store is open
+	// before the store is probed, it's doubled every time the probe fails.
+	storeBreakerBaseCooldown = 5 * time.Second
+	storeBreakerMaxCooldown  = time.Minute
+)
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerClosed:
+		return "closed"
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+type storeBreaker struct {
+	state    breakerState
+	failures int
+	cooldown time.Duration
+	// openUntil is when the store can be probed
+	openUntil time.Time
+	// probeStart is when the probe started in the half-open state
+	probeStart time.Time
+}
+
+// storeBreakers are the circuit breakers of the stores, which are shared by
+// all the kv clients in the process. The breaker of a store is opened after
+// the consecutive failures of the streams to the store, then the regions on
+// the store are not requested from it but retried on the other peers, until
+// the cooldown passes and a probe request to the store succeeds.
+type storeBreakers struct {
+	mu     sync.Mutex
+	stores map[string]*storeBreaker
+	// now is replaced in tests
+	now func() time.Time
+}
+
+func newStoreBreakers() *storeBreakers {
+	return &storeBreakers{
+		stores: make(map[string]*storeBreaker),
+		now:    time.Now,
+	}
+}
+
+var defaultStoreBreakers = newStoreBreakers()
+
+func (b *storeBreakers) getLocked(addr string) *storeBreaker {
+	breaker, ok := b.stores[addr]
+	if !ok {
+		breaker = &storeBreaker{cooldown: storeBreakerBaseCooldown}
+		b.stores[addr] = breaker
+	}
+	return breaker
+}
+
+// allow returns whether a new stream can be established to the store. Once the
+// cooldown of an open breaker passes, only one stream is allowed as the probe,
+// another probe is allowed if the result isn't reported within a cooldown.
+func (b *storeBreakers) allow(addr string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	breaker := b.getLocked(addr)
+	now := b.now()
+	switch breaker.state {
+	case breakerOpen:
+		if now.Before(breaker.openUntil) {
+			return false
+		}
+		b.setStateLocked(addr, breaker, breakerHalfOpen)
+		breaker.probeStart = now
+		return true
+	case breakerHalfOpen:
+		if now.Sub(breaker.probeStart) < breaker.cooldown {
+			return false
+		}
+		breaker.probeStart = now
+		return true
+	}
+	return true
+}
+
+// onSuccess is called when a stream to the store receives a response
+func (b *storeBreakers) onSuccess(addr string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	breaker := b.getLocked(addr)
+	breaker.failures = 0
+	breaker.cooldown = storeBreakerBaseCooldown
+	if breaker.state != breakerClosed {
+		b.setStateLocked(addr, breaker, breakerClosed)
+	}
+}
+
+// onFailure is called when a stream to the store can't be established, or
+// fails to send or receive.
+func (b *storeBreakers) onFailure(addr string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	breaker := b.getLocked(addr)
+	breaker.failures++
+	switch breaker.state {
+	case breakerClosed:
+		if breaker.failures < storeBreakerFailureThreshold {
+			return
+		}
+	case breakerHalfOpen:
+		breaker.cooldown *= 2
+		if breaker.cooldown > storeBreakerMaxCooldown {
+			breaker.cooldown = storeBreakerMaxCooldown
+		}
+	case breakerOpen:
+		return
+	}
+	breaker.openUntil = b.now().Add(breaker.cooldown)
+	b.setStateLocked(addr, breaker, breakerOpen)
+}
+
+func (b *storeBreakers) setStateLocked(addr string, breaker *storeBreaker, state breakerState) {
+	log.Info("store circuit breaker state changed", zap.String("addr", addr),
+		zap.Stringer("from", breaker.state), zap.Stringer("to", state),
+		zap.Int("failures", breaker.failures), zap.Duration("cooldown", breaker.cooldown))
+	breaker.state = state
+	storeBreakerStateGauge.WithLabelValues(addr).Set(float64(state))
+}
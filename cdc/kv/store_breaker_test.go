@@ -0,0 +1,64 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"time"
+
+	"github.com/pingcap/check"
+)
+
+type storeBreakerSuite struct{}
+
+var _ = check.Suite(&storeBreakerSuite{})
+
+func (s *storeBreakerSuite) TestStoreBreaker(c *check.C) {
+	now := time.Now()
+	b := newStoreBreakers()
+	b.now = func() time.Time { return now }
+	addr := "store1:20160"
+
+	// the breaker opens after the consecutive failures
+	for i := 0; i < storeBreakerFailureThreshold-1; i++ {
+		b.onFailure(addr)
+		c.Assert(b.allow(addr), check.IsTrue)
+	}
+	b.onSuccess(addr)
+	for i := 0; i < storeBreakerFailureThreshold; i++ {
+		c.Assert(b.allow(addr), check.IsTrue)
+		b.onFailure(addr)
+	}
+	c.Assert(b.allow(addr), check.IsFalse)
+	c.Assert(b.allow("store2:20160"), check.IsTrue)
+
+	// only one probe is allowed after the cooldown
+	now = now.Add(storeBreakerBaseCooldown)
+	c.Assert(b.allow(addr), check.IsTrue)
+	c.Assert(b.allow(addr), check.IsFalse)
+	// the cooldown is doubled if the probe fails
+	b.onFailure(addr)
+	now = now.Add(storeBreakerBaseCooldown)
+	c.Assert(b.allow(addr), check.IsFalse)
+	now = now.Add(storeBreakerBaseCooldown)
+	c.Assert(b.allow(addr), check.IsTrue)
+	// another probe is allowed if the probe isn't reported in time
+	now = now.Add(2 * storeBreakerBaseCooldown)
+	c.Assert(b.allow(addr), check.IsTrue)
+
+	// the breaker closes once the probe succeeds
+	b.onSuccess(addr)
+	c.Assert(b.allow(addr), check.IsTrue)
+	c.Assert(b.stores[addr].state, check.Equals, breakerClosed)
+	c.Assert(b.stores[addr].cooldown, check.Equals, storeBreakerBaseCooldown)
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/pingcap/ticdc/cdc/kv"
 	"github.com/pingcap/ticdc/cdc/puller"
 	"github.com/pingcap/ticdc/cdc/sink"
+	"github.com/pingcap/ticdc/pkg/buckets"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -37,5 +38,6 @@ func init() {
 	puller.InitMetrics(registry)
 	sink.InitMetrics(registry)
 	entry.InitMetrics(registry)
+	buckets.InitMetrics(registry)
 	initProcessorMetrics(registry)
 }
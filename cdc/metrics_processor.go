@@ -39,6 +39,27 @@ var (
 			Name:      "table_resolved_ts",
 			Help:      "local resolved ts of processor",
 		}, []string{"changefeed", "capture", "table"})
+	tableResolvedTsLagGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "ticdc",
+			Subsystem: "processor",
+			Name:      "table_resolved_ts_lag",
+			Help:      "resolved ts lag of the table in processor",
+		}, []string{"changefeed", "capture", "table"})
+	tableCheckpointTsGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "ticdc",
+			Subsystem: "processor",
+			Name:      "table_checkpoint_ts",
+			Help:      "checkpoint ts of the table in processor",
+		}, []string{"changefeed", "capture", "table"})
+	tableCheckpointTsLagGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "ticdc",
+			Subsystem: "processor",
+			Name:      "table_checkpoint_ts_lag",
+			Help:      "checkpoint ts lag of the table in processor",
+		}, []string{"changefeed", "capture", "table"})
 	checkpointTsGauge = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "ticdc",
@@ -97,6 +118,13 @@ var (
 			Name:      "exit_with_error_count",
 			Help:      "counter for processor exits with error",
 		}, []string{"changefeed", "capture"})
+	tableFailedCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "ticdc",
+			Subsystem: "processor",
+			Name:      "table_failed_count",
+			Help:      "counter for tables stopped by a panic in processor",
+		}, []string{"changefeed", "capture"})
 	sinkFlushRowChangedDuration = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Namespace: "ticdc",
@@ -112,6 +140,9 @@ func initProcessorMetrics(registry *prometheus.Registry) {
 	registry.MustRegister(resolvedTsGauge)
 	registry.MustRegister(resolvedTsLagGauge)
 	registry.MustRegister(tableResolvedTsGauge)
+	registry.MustRegister(tableResolvedTsLagGauge)
+	registry.MustRegister(tableCheckpointTsGauge)
+	registry.MustRegister(tableCheckpointTsLagGauge)
 	registry.MustRegister(checkpointTsGauge)
 	registry.MustRegister(checkpointTsLagGauge)
 	registry.MustRegister(syncTableNumGauge)
@@ -120,5 +151,6 @@ func initProcessorMetrics(registry *prometheus.Registry) {
 	registry.MustRegister(tableOutputChanSizeGauge)
 	registry.MustRegister(waitEventPrepareDuration)
 	registry.MustRegister(processorErrorCounter)
+	registry.MustRegister(tableFailedCounter)
 	registry.MustRegister(sinkFlushRowChangedDuration)
 }
@@ -0,0 +1,200 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pingcap/errors"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+)
+
+// AuditAction is an administrative action recorded in the audit log
+type AuditAction string
+
+// All the audited actions
+const (
+	AuditCreateChangefeed       AuditAction = "create-changefeed"
+	AuditUpdateChangefeed       AuditAction = "update-changefeed"
+	AuditPauseChangefeed        AuditAction = "pause-changefeed"
+	AuditResumeChangefeed       AuditAction = "resume-changefeed"
+	AuditRemoveChangefeed       AuditAction = "remove-changefeed"
+	AuditImportChangefeeds      AuditAction = "import-changefeeds"
+	AuditBatchCreateChangefeeds AuditAction = "batch-create-changefeeds"
+	AuditMoveTable              AuditAction = "move-table"
+	AuditRebalanceTables        AuditAction = "rebalance-tables"
+	AuditResignOwner            AuditAction = "resign-owner"
+	AuditDrainCapture           AuditAction = "drain-capture"
+	// AuditAdminJob is an admin job of an unknown type
+	AuditAdminJob AuditAction = "admin-job"
+)
+
+// AuditActionOfAdminJob returns the audited action of an admin job type
+func AuditActionOfAdminJob(typ AdminJobType) AuditAction {
+	switch typ {
+	case AdminStop:
+		return AuditPauseChangefeed
+	case AdminResume:
+		return AuditResumeChangefeed
+	case AdminRemove, AdminFinish:
+		return AuditRemoveChangefeed
+	}
+	return AuditAdminJob
+}
+
+// AuditResult is the result of an audited action
+type AuditResult string
+
+// All the results of the audited actions
+const (
+	AuditSuccess AuditResult = "success"
+	AuditFailure AuditResult = "failure"
+)
+
+// auditRedacted replaces the secrets in the parameters of the audit records
+const auditRedacted = "******"
+
+// AuditRecord is an administrative action in the audit log
+type AuditRecord struct {
+	Time   time.Time   `json:"time"`
+	Action AuditAction `json:"action"`
+	// Principal is the common name of the client certificate the action is
+	// requested with, it's empty if TLS is not enabled.
+	Principal string `json:"principal"`
+	// User is the user claimed by the client, e.g. the OS user running the
+	// cli, which is not authenticated.
+	User string `json:"user,omitempty"`
+	// Source is the address or the host the action is requested from
+	Source string `json:"source"`
+	// Capture is the capture which handles the action, it's empty if the
+	// action is applied by the cli directly.
+	Capture    CaptureID    `json:"capture,omitempty"`
+	Changefeed ChangeFeedID `json:"changefeed,omitempty"`
+	// Params are the parameters of the action, the secrets are redacted
+	Params map[string]interface{} `json:"params,omitempty"`
+	Result AuditResult            `json:"result"`
+	Error  string                 `json:"error,omitempty"`
+}
+
+// SetResult sets the result of the action by its error
+func (r *AuditRecord) SetResult(err error) {
+	if err != nil {
+		r.Result = AuditFailure
+		r.Error = err.Error()
+		return
+	}
+	r.Result = AuditSuccess
+	r.Error = ""
+}
+
+// Marshal returns the json marshal format of an AuditRecord
+func (r *AuditRecord) Marshal() (string, error) {
+	data, err := json.Marshal(r)
+	return string(data), cerror.WrapError(cerror.ErrMarshalFailed, err)
+}
+
+// Unmarshal unmarshals into *AuditRecord from json marshal byte slice
+func (r *AuditRecord) Unmarshal(data []byte) error {
+	err := json.Unmarshal(data, r)
+	return errors.Annotatef(
+		cerror.WrapError(cerror.ErrUnmarshalFailed, err), "Unmarshal data: %v", data)
+}
+
+// FilterAuditRecords returns the last limit records of the changefeed and the
+// action in the order of their time, the empty changefeed or action matches
+// all, and limit 0 is unlimited.
+func FilterAuditRecords(records []*AuditRecord, changefeedID ChangeFeedID, action AuditAction, limit int) []*AuditRecord {
+	filtered := make([]*AuditRecord, 0)
+	for _, record := range records {
+		if changefeedID != "" && record.Changefeed != changefeedID {
+			continue
+		}
+		if action != "" && record.Action != action {
+			continue
+		}
+		filtered = append(filtered, record)
+	}
+	if limit > 0 && len(filtered) > limit {
+		filtered = filtered[len(filtered)-limit:]
+	}
+	return filtered
+}
+
+// RedactAuditParam replaces the secrets in a parameter of an audited action,
+// which is decoded from JSON or a form. The values of the sensitive keys, and
+// the passwords and the sensitive query parameters of the URIs are redacted.
+func RedactAuditParam(key string, value interface{}) interface{} {
+	if sensitiveAuditParam(key) {
+		return auditRedacted
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		redacted := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			redacted[key] = RedactAuditParam(key, value)
+		}
+		return redacted
+	case []interface{}:
+		redacted := make([]interface{}, len(v))
+		for i, value := range v {
+			redacted[i] = RedactAuditParam("", value)
+		}
+		return redacted
+	case string:
+		return redactURI(v)
+	}
+	return value
+}
+
+func sensitiveAuditParam(key string) bool {
+	return sensitiveSinkParam(key) || strings.Contains(strings.ToLower(key), "credential")
+}
+
+// redactURI redacts the password and the sensitive query parameters of an
+// URI, s is returned as it is if it's not an URI.
+func redactURI(s string) string {
+	if !strings.Contains(s, "://") {
+		return s
+	}
+	uri, err := url.Parse(s)
+	if err != nil {
+		return s
+	}
+	redacted := false
+	if uri.User != nil {
+		if _, ok := uri.User.Password(); ok {
+			uri.User = url.UserPassword(uri.User.Username(), auditRedacted)
+			redacted = true
+		} else if strings.HasPrefix(strings.ToLower(uri.Scheme), "pulsar") {
+			// the user of the pulsar sink URI is the authentication token
+			uri.User = url.User(auditRedacted)
+			redacted = true
+		}
+	}
+	query := uri.Query()
+	for key := range query {
+		if sensitiveSinkParam(key) {
+			query.Set(key, auditRedacted)
+			redacted = true
+		}
+	}
+	if !redacted {
+		return s
+	}
+	uri.RawQuery = query.Encode()
+	return uri.String()
+}
@@ -17,13 +17,54 @@ import (
 	"encoding/json"
 
 	"github.com/pingcap/errors"
+	"github.com/pingcap/ticdc/pkg/config"
 	cerror "github.com/pingcap/ticdc/pkg/errors"
 )
 
+// OwnerPreference is the preference of a capture to be elected as the owner
+type OwnerPreference string
+
+// All OwnerPreferences
+const (
+	// OwnerPreferred captures are elected as the owner prior to the others
+	OwnerPreferred OwnerPreference = "preferred"
+	// OwnerNormal captures are elected as the owner only if there is no
+	// preferred capture alive
+	OwnerNormal OwnerPreference = "normal"
+	// OwnerNever captures are never elected as the owner
+	OwnerNever OwnerPreference = "never"
+)
+
+// ParseOwnerPreference parses the owner preference, an empty one is normal.
+func ParseOwnerPreference(s string) (OwnerPreference, error) {
+	switch p := OwnerPreference(s); p {
+	case "":
+		return OwnerNormal, nil
+	case OwnerPreferred, OwnerNormal, OwnerNever:
+		return p, nil
+	}
+	return "", cerror.ErrInvalidOwnerPreference.GenWithStackByArgs(s)
+}
+
 // CaptureInfo store in etcd.
 type CaptureInfo struct {
 	ID            CaptureID `json:"id"`
 	AdvertiseAddr string    `json:"address"`
+	// Draining is set when the capture is exiting gracefully, the owner moves
+	// its tables to the other captures and assigns no more tables to it.
+	Draining bool `json:"draining,omitempty"`
+	// OwnerPreference is empty for the captures of the older versions, which
+	// is the same as OwnerNormal.
+	OwnerPreference OwnerPreference `json:"owner-preference,omitempty"`
+	// FeatureGates is the states of the experimental features on the capture,
+	// it's empty for the captures of the older versions.
+	FeatureGates config.FeatureGates `json:"feature-gates,omitempty"`
+}
+
+// IsPreferredOwner returns whether the capture is preferred to be the owner,
+// a draining capture is never preferred.
+func (c *CaptureInfo) IsPreferredOwner() bool {
+	return c.OwnerPreference == OwnerPreferred && !c.Draining
 }
 
 // Marshal using json.Marshal.
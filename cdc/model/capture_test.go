@@ -0,0 +1,51 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"github.com/pingcap/check"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+)
+
+type captureSuite struct{}
+
+var _ = check.Suite(&captureSuite{})
+
+func (s *captureSuite) TestOwnerPreference(c *check.C) {
+	for str, expected := range map[string]OwnerPreference{
+		"":          OwnerNormal,
+		"normal":    OwnerNormal,
+		"preferred": OwnerPreferred,
+		"never":     OwnerNever,
+	} {
+		p, err := ParseOwnerPreference(str)
+		c.Assert(err, check.IsNil)
+		c.Assert(p, check.Equals, expected)
+	}
+	_, err := ParseOwnerPreference("always")
+	c.Assert(cerror.ErrInvalidOwnerPreference.Equal(err), check.IsTrue)
+
+	info := &CaptureInfo{ID: "capture-1", OwnerPreference: OwnerPreferred}
+	c.Assert(info.IsPreferredOwner(), check.IsTrue)
+	info.Draining = true
+	c.Assert(info.IsPreferredOwner(), check.IsFalse)
+
+	// the captures of the older versions have no owner preference
+	info = &CaptureInfo{}
+	c.Assert(info.Unmarshal([]byte(`{"id":"capture-2","address":"127.0.0.1:8300"}`)), check.IsNil)
+	c.Assert(info.IsPreferredOwner(), check.IsFalse)
+	data, err := info.Marshal()
+	c.Assert(err, check.IsNil)
+	c.Assert(string(data), check.Equals, `{"id":"capture-2","address":"127.0.0.1:8300"}`)
+}
@@ -14,10 +14,14 @@
 package model
 
 import (
+	"bytes"
 	"encoding/json"
 	"math"
+	"net/url"
+	"reflect"
 	"regexp"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/pingcap/errors"
@@ -25,6 +29,7 @@ import (
 	"github.com/pingcap/ticdc/pkg/config"
 	"github.com/pingcap/ticdc/pkg/cyclic/mark"
 	cerror "github.com/pingcap/ticdc/pkg/errors"
+	"github.com/pingcap/ticdc/pkg/security"
 	"github.com/pingcap/tidb/store/tikv/oracle"
 	"go.uber.org/zap"
 )
@@ -50,19 +55,6 @@ const (
 	StateFinished FeedState = "finished"
 )
 
-const (
-	// errorHistoryGCInterval represents how long we keep error record in changefeed info
-	errorHistoryGCInterval = time.Minute * 10
-
-	// errorHistoryCheckInterval represents time window for failure check
-	errorHistoryCheckInterval = time.Minute * 2
-
-	// errorHistoryThreshold represents failure upper limit in time window.
-	// Before a changefeed is initialized, check the the failure count of this
-	// changefeed, if it is less than errorHistoryThreshold, then initialize it.
-	errorHistoryThreshold = 5
-)
-
 // ChangeFeedInfo describes the detail of a ChangeFeed
 type ChangeFeedInfo struct {
 	SinkURI    string            `json:"sink-uri"`
@@ -84,6 +76,76 @@ type ChangeFeedInfo struct {
 
 	SyncPointEnabled  bool          `json:"sync-point-enabled"`
 	SyncPointInterval time.Duration `json:"sync-point-interval"`
+
+	// ConfigVersion is increased every time the changefeed is updated, the
+	// running owner and processors reload the config once it's increased.
+	ConfigVersion uint64 `json:"config-version"`
+
+	// Credential is the encrypted credential of the sink, see SetCredential
+	Credential []byte `json:"credential,omitempty"`
+
+	// GCTTL is the seconds the checkpoint of the changefeed holds the GC
+	// safepoint after it's paused or failed, 0 is the gc ttl of the server.
+	GCTTL int64 `json:"gc-ttl,omitempty"`
+
+	// Upstream is the cluster the changefeed replicates from, it's the
+	// cluster of the captures if it's nil.
+	Upstream *UpstreamInfo `json:"upstream,omitempty"`
+}
+
+// UpstreamInfo is an upstream cluster other than the one of the captures, the
+// certificates are read from the same paths on all the captures.
+type UpstreamInfo struct {
+	PDAddrs  []string `json:"pd-addrs"`
+	CAPath   string   `json:"ca-path,omitempty"`
+	CertPath string   `json:"cert-path,omitempty"`
+	KeyPath  string   `json:"key-path,omitempty"`
+}
+
+// ID returns the identity of the upstream, which is the sorted PD addresses,
+// it's empty for the cluster of the captures.
+func (u *UpstreamInfo) ID() string {
+	if u == nil {
+		return ""
+	}
+	addrs := make([]string, len(u.PDAddrs))
+	copy(addrs, u.PDAddrs)
+	sort.Strings(addrs)
+	return strings.Join(addrs, ",")
+}
+
+// GetCredential returns the credential to connect to the upstream
+func (u *UpstreamInfo) GetCredential() *security.Credential {
+	return &security.Credential{
+		CAPath:   u.CAPath,
+		CertPath: u.CertPath,
+		KeyPath:  u.KeyPath,
+	}
+}
+
+// Validate checks the PD addresses and the certificates of the upstream
+func (u *UpstreamInfo) Validate() error {
+	if len(u.PDAddrs) == 0 {
+		return cerror.ErrInvalidUpstream.GenWithStackByArgs("no PD address")
+	}
+	credential := u.GetCredential()
+	if _, err := credential.ToTLSConfig(); err != nil {
+		return cerror.ErrInvalidUpstream.GenWithStackByArgs(err.Error())
+	}
+	scheme := "http"
+	if credential.IsTLSEnabled() {
+		scheme = "https"
+	}
+	for _, addr := range u.PDAddrs {
+		parsed, err := url.Parse(addr)
+		if err != nil || parsed.Host == "" {
+			return cerror.ErrInvalidUpstream.GenWithStackByArgs("invalid PD address " + addr)
+		}
+		if parsed.Scheme != scheme {
+			return cerror.ErrInvalidUpstream.GenWithStackByArgs("the scheme of the PD address " + addr + " should be " + scheme)
+		}
+	}
+	return nil
 }
 
 var changeFeedIDRe *regexp.Regexp = regexp.MustCompile(`^[a-zA-Z0-9]+(\-[a-zA-Z0-9]+)*$`)
@@ -112,6 +174,7 @@ func (info *ChangeFeedInfo) String() (str string) {
 		return
 	}
 	clone.SinkURI = "***"
+	clone.Credential = nil
 	str, err = clone.Marshal()
 	if err != nil {
 		log.Error("failed to marshal changefeed info", zap.Error(err))
@@ -169,6 +232,79 @@ func (info *ChangeFeedInfo) Unmarshal(data []byte) error {
 	return nil
 }
 
+// SetCredential encrypts the sink credential into the changefeed info, a nil
+// credential removes the current one.
+func (info *ChangeFeedInfo) SetCredential(credential *security.SinkCredential) error {
+	if credential == nil {
+		info.Credential = nil
+		return nil
+	}
+	data, err := credential.Encrypt()
+	if err != nil {
+		return err
+	}
+	info.Credential = data
+	return nil
+}
+
+// GetCredential decrypts the sink credential, nil is returned if the changefeed
+// doesn't have one.
+func (info *ChangeFeedInfo) GetCredential() (*security.SinkCredential, error) {
+	if len(info.Credential) == 0 {
+		return nil, nil
+	}
+	return security.DecryptSinkCredential(info.Credential)
+}
+
+// Clone returns a deep copy of the changefeed info
+func (info *ChangeFeedInfo) Clone() (*ChangeFeedInfo, error) {
+	s, err := info.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	cloned := new(ChangeFeedInfo)
+	err = cloned.Unmarshal([]byte(s))
+	return cloned, err
+}
+
+// VerifyHotReload checks whether the changefeed can be updated to newInfo while
+// it's running. Only the sink URI, the sink credential, the sink options, the
+// table filter rules, the sink config and the retry policy can be reloaded
+// without restarting the changefeed.
+func (info *ChangeFeedInfo) VerifyHotReload(newInfo *ChangeFeedInfo) error {
+	oldConfig, newConfig := info.Config.Clone(), newInfo.Config.Clone()
+	// the reloadable configs are excluded from the comparison
+	oldConfig.Sink, newConfig.Sink = nil, nil
+	oldConfig.Retry, newConfig.Retry = nil, nil
+	for _, cfg := range []*config.ReplicaConfig{oldConfig, newConfig} {
+		if cfg.Filter != nil {
+			cfg.Filter = &config.FilterConfig{DDLAllowlist: cfg.Filter.DDLAllowlist}
+		}
+	}
+	changes := []struct {
+		field   string
+		changed bool
+	}{
+		{"start ts", info.StartTs != newInfo.StartTs},
+		{"target ts", info.TargetTs != newInfo.TargetTs},
+		{"sort engine", info.Engine != newInfo.Engine},
+		{"sort dir", info.SortDir != newInfo.SortDir},
+		{"upstream", !reflect.DeepEqual(info.Upstream, newInfo.Upstream)},
+		{"sync point", info.SyncPointEnabled != newInfo.SyncPointEnabled ||
+			info.SyncPointInterval != newInfo.SyncPointInterval},
+		// the sync points are written to the sink by the owner only
+		{"sink uri with sync point enabled", info.SyncPointEnabled && info.SinkURI != newInfo.SinkURI},
+		{"sink credential with sync point enabled", info.SyncPointEnabled && !bytes.Equal(info.Credential, newInfo.Credential)},
+		{"replica config except the filter rules, the sink config and the retry policy", !reflect.DeepEqual(oldConfig, newConfig)},
+	}
+	for _, change := range changes {
+		if change.changed {
+			return cerror.ErrConfigNotReloadable.GenWithStackByArgs(change.field)
+		}
+	}
+	return nil
+}
+
 // VerifyAndFix verifies changefeed info and may fillin some fields.
 // If a must field is not provided, return an error.
 // If some necessary filed is missing but can use a default value, fillin it.
@@ -192,30 +328,106 @@ func (info *ChangeFeedInfo) VerifyAndFix() error {
 	if info.Config.Scheduler == nil {
 		info.Config.Scheduler = defaultConfig.Scheduler
 	}
+	if info.Config.Retry == nil {
+		info.Config.Retry = defaultConfig.Retry
+	}
 	return nil
 }
 
-// CheckErrorHistory checks error history of a changefeed
-// if having error record older than GC interval, set needSave to true.
-// if error counts reach threshold, set canInit to false.
+// SorterConfig returns the sorter of the tables of the changefeed
+func (info *ChangeFeedInfo) SorterConfig() *config.SorterConfig {
+	return &config.SorterConfig{Engine: string(info.Engine), Dir: info.SortDir}
+}
+
+// Validate checks the replica config and the sorter of the changefeed, and the
+// constraints between them and the sink. The sort dir is not checked, which is
+// on the captures.
+func (info *ChangeFeedInfo) Validate() config.Diagnostics {
+	diags := info.SorterConfig().Validate(false)
+	if info.Config == nil {
+		return diags
+	}
+	cfg := info.Config
+	var scheme string
+	if sinkURI, err := url.Parse(info.SinkURI); err == nil {
+		scheme = strings.ToLower(sinkURI.Scheme)
+		// the protocol in the sink URI overrides the one in the config
+		if p := sinkURI.Query().Get("protocol"); p != "" && cfg.Sink != nil {
+			cfg = cfg.Clone()
+			cfg.Sink.Protocol = p
+		}
+	}
+	diags = append(diags, cfg.Validate()...)
+	switch scheme {
+	case "kafka", "kafka+ssl", "pulsar", "pulsar+ssl", "":
+	default:
+		if cfg.Sink == nil {
+			break
+		}
+		hint := "remove it, which is used by the kafka and pulsar sinks only"
+		if len(cfg.Sink.DispatchRules) > 0 {
+			diags.Warnf("sink.dispatchers", hint, "the dispatchers are ignored by the %s sink", scheme)
+		}
+		if p := cfg.Sink.Protocol; p != "" && !strings.EqualFold(p, "default") {
+			diags.Warnf("sink.protocol", hint, "the protocol %s is ignored by the %s sink", p, scheme)
+		}
+	}
+	return diags
+}
+
+// GetRetryConfig returns the retry policy of the changefeed, the default one
+// is used if it's not set, e.g. the changefeed is created by an older version.
+func (info *ChangeFeedInfo) GetRetryConfig() *config.RetryConfig {
+	if info.Config == nil || info.Config.Retry == nil {
+		return config.GetDefaultReplicaConfig().Retry
+	}
+	return info.Config.Retry
+}
+
+// RecordError records the error of the changefeed in its error history, the
+// changefeed is marked failed if the error isn't retried by its retry policy.
+func (info *ChangeFeedInfo) RecordError(err *RunningError) {
+	err.Retryable = info.GetRetryConfig().ShouldRetry(err.Code, err.Retryable)
+	info.Error = err
+	info.ErrorHis = append(info.ErrorHis, time.Now().UnixNano()/1e6)
+	if !err.Retryable {
+		info.State = StateFailed
+	}
+}
+
+// CheckErrorHistory checks error history of a changefeed by its retry policy.
+// if having error record older than the history window, set needSave to true.
+// if the backoff of the last error isn't over, set canInit to false.
+// if the max restarts are exceeded, mark the changefeed failed, and set both
+// needSave to true and canInit to false.
 func (info *ChangeFeedInfo) CheckErrorHistory() (needSave bool, canInit bool) {
+	policy := info.GetRetryConfig()
 	i := sort.Search(len(info.ErrorHis), func(i int) bool {
 		ts := info.ErrorHis[i]
-		return time.Since(time.Unix(ts/1e3, (ts%1e3)*1e6)) < errorHistoryGCInterval
+		return time.Since(time.Unix(ts/1e3, (ts%1e3)*1e6)) < policy.GetHistoryWindow()
 	})
-	if i == len(info.ErrorHis) {
-		info.ErrorHis = info.ErrorHis[:]
-	} else {
-		info.ErrorHis = info.ErrorHis[i:]
-	}
+	info.ErrorHis = info.ErrorHis[i:]
 	if i > 0 {
 		needSave = true
 	}
 
-	i = sort.Search(len(info.ErrorHis), func(i int) bool {
-		ts := info.ErrorHis[i]
-		return time.Since(time.Unix(ts/1e3, (ts%1e3)*1e6)) < errorHistoryCheckInterval
-	})
-	canInit = len(info.ErrorHis)-i < errorHistoryThreshold
+	n := len(info.ErrorHis)
+	if n == 0 {
+		return needSave, true
+	}
+	if policy.MaxRestarts > 0 && n > policy.MaxRestarts {
+		if info.State != StateFailed {
+			log.Warn("changefeed exceeds the max restarts, mark it as failed",
+				zap.Int("max-restarts", policy.MaxRestarts), zap.Int64s("history", info.ErrorHis))
+			info.State = StateFailed
+			if info.Error != nil {
+				info.Error.Retryable = false
+			}
+			needSave = true
+		}
+		return needSave, false
+	}
+	ts := info.ErrorHis[n-1]
+	canInit = time.Since(time.Unix(ts/1e3, (ts%1e3)*1e6)) >= policy.GetBackoff(n)
 	return
 }
@@ -14,11 +14,17 @@
 package model
 
 import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/pingcap/check"
 	"github.com/pingcap/parser/model"
 	"github.com/pingcap/ticdc/pkg/config"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+	"github.com/pingcap/ticdc/pkg/security"
 	filter "github.com/pingcap/tidb-tools/pkg/table-filter"
 )
 
@@ -187,25 +193,144 @@ func (s *changefeedSuite) TestCheckErrorHistory(c *check.C) {
 	info := &ChangeFeedInfo{
 		ErrorHis: []int64{},
 	}
+	policy := info.GetRetryConfig()
 	for i := 0; i < 5; i++ {
-		tm := now.Add(-errorHistoryGCInterval)
+		tm := now.Add(-policy.GetHistoryWindow())
 		info.ErrorHis = append(info.ErrorHis, tm.UnixNano()/1e6)
 		time.Sleep(time.Millisecond)
 	}
-	for i := 0; i < errorHistoryThreshold-1; i++ {
-		info.ErrorHis = append(info.ErrorHis, time.Now().UnixNano()/1e6)
-		time.Sleep(time.Millisecond)
-	}
-	time.Sleep(time.Millisecond)
 	needSave, canInit := info.CheckErrorHistory()
 	c.Assert(needSave, check.IsTrue)
 	c.Assert(canInit, check.IsTrue)
-	c.Assert(info.ErrorHis, check.HasLen, errorHistoryThreshold-1)
+	c.Assert(info.ErrorHis, check.HasLen, 0)
 
+	// the changefeed waits for the backoff of the last error
 	info.ErrorHis = append(info.ErrorHis, time.Now().UnixNano()/1e6)
 	needSave, canInit = info.CheckErrorHistory()
 	c.Assert(needSave, check.IsFalse)
 	c.Assert(canInit, check.IsFalse)
+	info.ErrorHis[0] = now.Add(-policy.GetBackoff(1)).UnixNano() / 1e6
+	_, canInit = info.CheckErrorHistory()
+	c.Assert(canInit, check.IsTrue)
+
+	info.Config = &config.ReplicaConfig{Retry: &config.RetryConfig{
+		MaxRestarts:   2,
+		HistoryWindow: 60,
+		Backoff:       []int{0, 30},
+	}}
+	info.ErrorHis = []int64{now.Add(-time.Second).UnixNano() / 1e6}
+	_, canInit = info.CheckErrorHistory()
+	c.Assert(canInit, check.IsTrue)
+	// the last backoff is used for the subsequent errors
+	info.ErrorHis = append(info.ErrorHis, now.Add(-40*time.Second).UnixNano()/1e6)
+	_, canInit = info.CheckErrorHistory()
+	c.Assert(canInit, check.IsTrue)
+	info.ErrorHis[1] = now.Add(-time.Second).UnixNano() / 1e6
+	_, canInit = info.CheckErrorHistory()
+	c.Assert(canInit, check.IsFalse)
+
+	// the changefeed is failed once the max restarts are exceeded
+	info.Error = &RunningError{Code: "CDC:ErrProcessorUnknown", Retryable: true}
+	info.ErrorHis = append(info.ErrorHis, now.UnixNano()/1e6)
+	needSave, canInit = info.CheckErrorHistory()
+	c.Assert(needSave, check.IsTrue)
+	c.Assert(canInit, check.IsFalse)
+	c.Assert(info.State, check.Equals, StateFailed)
+	c.Assert(info.Error.Retryable, check.IsFalse)
+	needSave, canInit = info.CheckErrorHistory()
+	c.Assert(needSave, check.IsFalse)
+	c.Assert(canInit, check.IsFalse)
+}
+
+func (s *changefeedSuite) TestRecordError(c *check.C) {
+	info := &ChangeFeedInfo{Config: config.GetDefaultReplicaConfig()}
+	info.RecordError(&RunningError{Code: "CDC:ErrMySQLTxnError", Retryable: true})
+	c.Assert(info.Error.Retryable, check.IsTrue)
+	c.Assert(info.State, check.Equals, FeedState(""))
+	c.Assert(info.ErrorHis, check.HasLen, 1)
+
+	info.Config.Retry.AlwaysRetry = []string{"CDC:ErrSinkURIInvalid"}
+	info.Config.Retry.NeverRetry = []string{"CDC:ErrMySQLTxnError"}
+	info.RecordError(&RunningError{Code: "CDC:ErrSinkURIInvalid", Retryable: false})
+	c.Assert(info.Error.Retryable, check.IsTrue)
+	c.Assert(info.State, check.Equals, FeedState(""))
+	info.RecordError(&RunningError{Code: "CDC:ErrMySQLTxnError", Retryable: true})
+	c.Assert(info.Error.Retryable, check.IsFalse)
+	c.Assert(info.State, check.Equals, StateFailed)
+	c.Assert(info.ErrorHis, check.HasLen, 3)
+}
+
+func (s *changefeedSuite) TestRetryConfig(c *check.C) {
+	policy := config.GetDefaultReplicaConfig().Retry
+	c.Assert(policy.Validate(), check.IsNil)
+	c.Assert(policy.GetBackoff(0), check.Equals, time.Duration(0))
+	c.Assert(policy.GetBackoff(1), check.Equals, time.Second)
+	c.Assert(policy.GetBackoff(100), check.Equals, 120*time.Second)
+
+	policy.HistoryWindow = 0
+	c.Assert(policy.Validate(), check.ErrorMatches, ".*history-window must be positive.*")
+	policy.HistoryWindow = 60
+	policy.Backoff = []int{1, -1}
+	c.Assert(policy.Validate(), check.ErrorMatches, ".*backoff must not be negative.*")
+	policy.Backoff = nil
+	c.Assert(policy.GetBackoff(1), check.Equals, time.Duration(0))
+	policy.AlwaysRetry = []string{"CDC:ErrSinkURIInvalid"}
+	policy.NeverRetry = []string{"CDC:ErrSinkURIInvalid"}
+	c.Assert(policy.Validate(), check.ErrorMatches, ".*is both always and never retried.*")
+}
+
+func (s *changefeedSuite) TestValidate(c *check.C) {
+	info := &ChangeFeedInfo{
+		SinkURI: "kafka://127.0.0.1:9092/topic",
+		Engine:  SortInMemory,
+		Config:  config.GetDefaultReplicaConfig(),
+	}
+	c.Assert(info.Validate(), check.HasLen, 0)
+
+	info.Config.Mounter.WorkerNum = 1024
+	info.Config.Sink.DispatchRules = []*config.DispatchRule{{Matcher: []string{"test.*"}, Dispatcher: "rowid"}}
+	diags := info.Validate()
+	c.Assert(diags.Err(), check.IsNil)
+	c.Assert(diags, check.HasLen, 1)
+	c.Assert(diags[0].Field, check.Equals, "mounter.worker-num")
+	// the protocol in the sink URI requires the old value
+	info.SinkURI = "kafka://127.0.0.1:9092/topic?protocol=canal"
+	diags = info.Validate()
+	c.Assert(diags.Err(), check.IsNil)
+	c.Assert(diags, check.HasLen, 2)
+	c.Assert(diags[1].String(), check.Matches, "\\[warning\\] sink.dispatchers\\[0\\].dispatcher: .*")
+	c.Assert(info.Config.Sink.Protocol, check.Equals, "default")
+
+	info.SinkURI = "mysql://127.0.0.1:3306/"
+	diags = info.Validate()
+	c.Assert(diags.Err(), check.IsNil)
+	c.Assert(diags.Filter(config.DiagnosticWarning), check.HasLen, 2)
+	c.Assert(diags[1].Message, check.Equals, "the dispatchers are ignored by the mysql sink")
+
+	info.Config.Mounter.WorkerNum = 0
+	info.Config.Sink.DispatchRules[0].Dispatcher = "unknown"
+	info.Config.TimeZone = "Unknown/Zone"
+	info.Engine = SortInFile
+	diags = info.Validate()
+	c.Assert(diags.Filter(config.DiagnosticError), check.HasLen, 4)
+	err := diags.Err()
+	c.Assert(cerror.ErrInvalidConfig.Equal(err), check.IsTrue)
+	c.Assert(err, check.ErrorMatches, ".*sort-dir: .*; .*mounter.worker-num: .*; .*time-zone: .*; .*unknown dispatcher unknown.*")
+}
+
+func (s *changefeedSuite) TestValidateSorter(c *check.C) {
+	dir := c.MkDir()
+	sorter := &config.SorterConfig{Engine: "file", Dir: filepath.Join(dir, "sort")}
+	// the sort dir is created on demand
+	c.Assert(sorter.Validate(true).Err(), check.IsNil)
+	sorter.Engine = "unknown"
+	c.Assert(sorter.Validate(false).Err(), check.ErrorMatches, ".*unknown sort engine unknown.*")
+
+	file := filepath.Join(dir, "file")
+	c.Assert(ioutil.WriteFile(file, nil, 0644), check.IsNil)
+	sorter = &config.SorterConfig{Engine: "file", Dir: filepath.Join(file, "sort")}
+	c.Assert(sorter.Validate(false).Err(), check.IsNil)
+	c.Assert(sorter.Validate(true).Err(), check.ErrorMatches, ".*not a directory.*")
 }
 
 func (s *changefeedSuite) TestChangefeedInfoStringer(c *check.C) {
@@ -216,3 +341,91 @@ func (s *changefeedSuite) TestChangefeedInfoStringer(c *check.C) {
 	str := info.String()
 	c.Check(str, check.Matches, ".*sink-uri\":\"\\*\\*\\*\".*")
 }
+
+func (s *changefeedSuite) TestVerifyHotReload(c *check.C) {
+	info := &ChangeFeedInfo{
+		SinkURI: "blackhole://",
+		Opts:    map[string]string{},
+		StartTs: 418881574869139457,
+		Config:  config.GetDefaultReplicaConfig(),
+	}
+	c.Assert(info.VerifyAndFix(), check.IsNil)
+	newInfo, err := info.Clone()
+	c.Assert(err, check.IsNil)
+	newInfo.SinkURI = "kafka://127.0.0.1:9092/topic"
+	newInfo.Opts["max-txn-row"] = "256"
+	newInfo.Config.Filter.Rules = []string{"test.*"}
+	newInfo.Config.Filter.IgnoreTxnStartTs = []uint64{418881574869139458}
+	newInfo.Config.Sink.DispatchRules = []*config.DispatchRule{{Matcher: []string{"test.*"}, Dispatcher: "ts"}}
+	c.Assert(info.VerifyHotReload(newInfo), check.IsNil)
+	c.Assert(info.SinkURI, check.Equals, "blackhole://")
+	c.Assert(info.Config.Filter.Rules, check.DeepEquals, []string{"*.*"})
+
+	newInfo.Config.EnableOldValue = true
+	err = info.VerifyHotReload(newInfo)
+	c.Assert(err, check.ErrorMatches, ".*replica config except the filter rules, the sink config and the retry policy can't be updated.*")
+	newInfo.Config.EnableOldValue = false
+	newInfo.Config.Filter.DDLAllowlist = []model.ActionType{model.ActionCreateTable}
+	c.Assert(info.VerifyHotReload(newInfo), check.NotNil)
+	newInfo.Config.Filter.DDLAllowlist = nil
+	newInfo.TargetTs = 418881574869139459
+	c.Assert(info.VerifyHotReload(newInfo), check.ErrorMatches, ".*target ts can't be updated.*")
+	newInfo.TargetTs = 0
+
+	// the sync points are written to the original sink
+	info.SyncPointEnabled = true
+	newInfo.SyncPointEnabled = true
+	c.Assert(info.VerifyHotReload(newInfo), check.ErrorMatches, ".*sink uri with sync point enabled.*")
+	newInfo.SinkURI = info.SinkURI
+	newInfo.Credential = []byte("encrypted")
+	c.Assert(info.VerifyHotReload(newInfo), check.ErrorMatches, ".*sink credential with sync point enabled.*")
+	newInfo.Credential = nil
+
+	newInfo.Upstream = &UpstreamInfo{PDAddrs: []string{"http://127.0.0.1:2379"}}
+	c.Assert(info.VerifyHotReload(newInfo), check.ErrorMatches, ".*upstream can't be updated.*")
+}
+
+func (s *changefeedSuite) TestUpstreamInfo(c *check.C) {
+	var upstream *UpstreamInfo
+	c.Assert(upstream.ID(), check.Equals, "")
+
+	upstream = &UpstreamInfo{PDAddrs: []string{"http://10.0.0.2:2379", "http://10.0.0.1:2379"}}
+	c.Assert(upstream.ID(), check.Equals, "http://10.0.0.1:2379,http://10.0.0.2:2379")
+	c.Assert(upstream.PDAddrs[0], check.Equals, "http://10.0.0.2:2379")
+	c.Assert(upstream.Validate(), check.IsNil)
+
+	for _, tc := range []struct {
+		upstream *UpstreamInfo
+		err      string
+	}{
+		{&UpstreamInfo{}, ".*no PD address.*"},
+		{&UpstreamInfo{PDAddrs: []string{"127.0.0.1:2379"}}, ".*invalid PD address.*"},
+		{&UpstreamInfo{PDAddrs: []string{"https://127.0.0.1:2379"}}, ".*should be http.*"},
+		{&UpstreamInfo{PDAddrs: []string{"https://127.0.0.1:2379"}, CAPath: "not-exist"}, ".*ErrInvalidUpstream.*"},
+	} {
+		c.Assert(tc.upstream.Validate(), check.ErrorMatches, tc.err)
+	}
+}
+
+func (s *changefeedSuite) TestCredential(c *check.C) {
+	info := &ChangeFeedInfo{SinkURI: "kafka://127.0.0.1:9092/topic"}
+	credential := &security.SinkCredential{SASLUser: "ticdc", SASLPassword: "secret"}
+	security.SetCredentialKey(nil)
+	c.Assert(info.SetCredential(credential), check.ErrorMatches, ".*credential key is not set.*")
+
+	security.SetCredentialKey(bytes.Repeat([]byte{1}, 32))
+	defer security.SetCredentialKey(nil)
+	c.Assert(info.SetCredential(credential), check.IsNil)
+	c.Assert(bytes.Contains(info.Credential, []byte("secret")), check.IsFalse)
+	c.Assert(strings.Contains(info.String(), "credential"), check.IsFalse)
+	cloned, err := info.Clone()
+	c.Assert(err, check.IsNil)
+	decrypted, err := cloned.GetCredential()
+	c.Assert(err, check.IsNil)
+	c.Assert(decrypted, check.DeepEquals, credential)
+
+	c.Assert(info.SetCredential(nil), check.IsNil)
+	decrypted, err = info.GetCredential()
+	c.Assert(err, check.IsNil)
+	c.Assert(decrypted, check.IsNil)
+}
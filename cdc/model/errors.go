@@ -13,9 +13,32 @@
 
 package model
 
+import (
+	"github.com/pingcap/errors"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+)
+
 // RunningError represents some running error from cdc components, such as processor.
 type RunningError struct {
 	Addr    string `json:"addr"`
 	Code    string `json:"code"`
 	Message string `json:"message"`
+	// Retryable is false if the error can't be recovered by restarting the
+	// changefeed, e.g. an invalid config.
+	Retryable bool `json:"retryable"`
+}
+
+// NewRunningError creates a RunningError by the RFC code in the cause chain
+// of the error, the code of unknownErr is used if there is no RFC code.
+func NewRunningError(addr string, err error, unknownErr *errors.Error) *RunningError {
+	code, ok := cerror.RFCCode(err)
+	if !ok {
+		code = unknownErr.RFCCode()
+	}
+	return &RunningError{
+		Addr:      addr,
+		Code:      string(code),
+		Message:   err.Error(),
+		Retryable: cerror.IsRetryableError(err),
+	}
 }
@@ -0,0 +1,44 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"github.com/pingcap/check"
+	"github.com/pingcap/errors"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+)
+
+type runningErrorSuite struct{}
+
+var _ = check.Suite(&runningErrorSuite{})
+
+func (s *runningErrorSuite) TestNewRunningError(c *check.C) {
+	err := errors.Annotate(cerror.ErrKafkaSendMessage.GenWithStackByArgs(), "test")
+	runningErr := NewRunningError("127.0.0.1:8300", err, cerror.ErrProcessorUnknown)
+	c.Assert(runningErr, check.DeepEquals, &RunningError{
+		Addr:      "127.0.0.1:8300",
+		Code:      "CDC:ErrKafkaSendMessage",
+		Message:   err.Error(),
+		Retryable: true,
+	})
+
+	runningErr = NewRunningError("127.0.0.1:8300", errors.New("test"), cerror.ErrProcessorUnknown)
+	c.Assert(runningErr.Code, check.Equals, "CDC:ErrProcessorUnknown")
+	c.Assert(runningErr.Retryable, check.IsTrue)
+
+	err = errors.Trace(cerror.ErrSinkURIInvalid.GenWithStackByArgs())
+	runningErr = NewRunningError("127.0.0.1:8300", err, cerror.ErrProcessorUnknown)
+	c.Assert(runningErr.Code, check.Equals, "CDC:ErrSinkURIInvalid")
+	c.Assert(runningErr.Retryable, check.IsFalse)
+}
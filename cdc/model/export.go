@@ -0,0 +1,244 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pingcap/ticdc/pkg/config"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+	"github.com/pingcap/ticdc/pkg/security"
+)
+
+// ChangefeedExportVersion is the version of the format of ChangefeedExport
+const ChangefeedExportVersion = 1
+
+// The fields of the changefeed definition a secret is referenced by
+const (
+	SecretFieldSinkUser     = "sink-uri.user"
+	SecretFieldSinkPassword = "sink-uri.password"
+	// SecretFieldSinkQuery is the prefix of the sink URI query parameters
+	SecretFieldSinkQuery = "sink-uri.query."
+	// SecretFieldCredential is the sink credential, whose secret is the JSON
+	// of security.SinkCredential.
+	SecretFieldCredential = "credential"
+)
+
+// ChangefeedExport is the exported definitions of the changefeeds in a cluster,
+// which can be imported into another cluster.
+type ChangefeedExport struct {
+	Version     int                     `json:"version"`
+	ExportTime  time.Time               `json:"export-time"`
+	Changefeeds []*ChangefeedDefinition `json:"changefeeds"`
+}
+
+// ChangefeedDefinition is the exported definition of a changefeed. The secrets,
+// e.g. the password in the sink URI and the sink credential, are not embedded
+// but referenced by name, and they must be provided when it's imported. The
+// certificates of the upstream are referenced by their paths, which must exist
+// on the captures of the cluster it's imported into.
+type ChangefeedDefinition struct {
+	ID       ChangeFeedID      `json:"id"`
+	SinkURI  string            `json:"sink-uri"`
+	Opts     map[string]string `json:"opts"`
+	StartTs  uint64            `json:"start-ts"`
+	TargetTs uint64            `json:"target-ts"`
+	// CheckpointTs is the checkpoint of the changefeed when it's exported, the
+	// imported changefeed starts from it by default.
+	CheckpointTs uint64                `json:"checkpoint-ts"`
+	Engine       SortEngine            `json:"sort-engine"`
+	SortDir      string                `json:"sort-dir"`
+	Config       *config.ReplicaConfig `json:"config"`
+	State        FeedState             `json:"state"`
+
+	SyncPointEnabled  bool          `json:"sync-point-enabled"`
+	SyncPointInterval time.Duration `json:"sync-point-interval"`
+	// GCTTL is the seconds the checkpoint holds the GC safepoint, 0 is the
+	// gc-ttl of the server.
+	GCTTL int64 `json:"gc-ttl,omitempty"`
+
+	// Upstream is the cluster the changefeed replicates from, the checkpoint is
+	// a ts of it. It's nil for the cluster of the captures.
+	Upstream *UpstreamInfo `json:"upstream,omitempty"`
+
+	Secrets []*SecretRef `json:"secrets,omitempty"`
+}
+
+// SecretRef references a secret removed from a changefeed definition
+type SecretRef struct {
+	// Name is the name the secret is provided by when it's imported
+	Name string `json:"name"`
+	// Field is where the secret is placed in the changefeed definition
+	Field string `json:"field"`
+}
+
+// sensitiveSinkParam returns whether a query parameter of the sink URI is a
+// secret.
+func sensitiveSinkParam(key string) bool {
+	key = strings.ToLower(key)
+	return strings.Contains(key, "password") || strings.Contains(key, "token") ||
+		strings.Contains(key, "secret") || strings.HasPrefix(key, "auth.")
+}
+
+// secretName returns the name of a secret of a changefeed, e.g.
+// TICDC_MY_CHANGEFEED_SINK_PASSWORD, which can be used as an environment
+// variable.
+func secretName(changefeedID ChangeFeedID, field string) string {
+	name := "TICDC_" + changefeedID + "_" + field
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9'):
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+// NewChangefeedDefinition exports the definition of a changefeed, the secrets
+// in the sink URI and the sink credential are replaced by the references.
+func NewChangefeedDefinition(id ChangeFeedID, info *ChangeFeedInfo, status *ChangeFeedStatus) (*ChangefeedDefinition, error) {
+	def := &ChangefeedDefinition{
+		ID:                id,
+		Opts:              info.Opts,
+		StartTs:           info.GetStartTs(),
+		TargetTs:          info.TargetTs,
+		CheckpointTs:      info.GetCheckpointTs(status),
+		Engine:            info.Engine,
+		SortDir:           info.SortDir,
+		Config:            info.Config,
+		State:             info.State,
+		SyncPointEnabled:  info.SyncPointEnabled,
+		SyncPointInterval: info.SyncPointInterval,
+		GCTTL:             info.GCTTL,
+		Upstream:          info.Upstream,
+	}
+	addSecret := func(field string) {
+		def.Secrets = append(def.Secrets, &SecretRef{Name: secretName(id, field), Field: field})
+	}
+
+	sinkURI, err := url.Parse(info.SinkURI)
+	if err != nil {
+		return nil, cerror.WrapError(cerror.ErrSinkURIInvalid, err)
+	}
+	if sinkURI.User != nil {
+		if _, ok := sinkURI.User.Password(); ok {
+			addSecret(SecretFieldSinkPassword)
+			sinkURI.User = url.User(sinkURI.User.Username())
+		} else if strings.HasPrefix(strings.ToLower(sinkURI.Scheme), "pulsar") {
+			// the user of the pulsar sink URI is the authentication token
+			addSecret(SecretFieldSinkUser)
+			sinkURI.User = nil
+		}
+	}
+	query := sinkURI.Query()
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		if sensitiveSinkParam(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		addSecret(SecretFieldSinkQuery + key)
+		query.Del(key)
+	}
+	sinkURI.RawQuery = query.Encode()
+	def.SinkURI = sinkURI.String()
+
+	if len(info.Credential) != 0 {
+		addSecret(SecretFieldCredential)
+	}
+	return def, nil
+}
+
+// ToChangeFeedInfo restores the changefeed info from the definition, the
+// referenced secrets are looked up by getSecret. The changefeed starts from
+// startTs, or the checkpoint of the definition if startTs is 0.
+func (d *ChangefeedDefinition) ToChangeFeedInfo(
+	getSecret func(name string) (string, bool), startTs uint64,
+) (*ChangeFeedInfo, *security.SinkCredential, error) {
+	if startTs == 0 {
+		startTs = d.CheckpointTs
+	}
+	if startTs == 0 {
+		startTs = d.StartTs
+	}
+	info := &ChangeFeedInfo{
+		Opts:              make(map[string]string, len(d.Opts)),
+		CreateTime:        time.Now(),
+		StartTs:           startTs,
+		TargetTs:          d.TargetTs,
+		Engine:            d.Engine,
+		SortDir:           d.SortDir,
+		Config:            d.Config,
+		State:             StateNormal,
+		SyncPointEnabled:  d.SyncPointEnabled,
+		SyncPointInterval: d.SyncPointInterval,
+		GCTTL:             d.GCTTL,
+		Upstream:          d.Upstream,
+	}
+	for key, value := range d.Opts {
+		info.Opts[key] = value
+	}
+	if info.Config == nil {
+		info.Config = config.GetDefaultReplicaConfig()
+	}
+
+	sinkURI, err := url.Parse(d.SinkURI)
+	if err != nil {
+		return nil, nil, cerror.WrapError(cerror.ErrSinkURIInvalid, err)
+	}
+	query := sinkURI.Query()
+	var credential *security.SinkCredential
+	for _, ref := range d.Secrets {
+		secret, ok := getSecret(ref.Name)
+		if !ok {
+			return nil, nil, cerror.ErrChangefeedSecretNotFound.GenWithStackByArgs(ref.Name, d.ID)
+		}
+		switch {
+		case ref.Field == SecretFieldSinkUser:
+			sinkURI.User = url.User(secret)
+		case ref.Field == SecretFieldSinkPassword:
+			username := ""
+			if sinkURI.User != nil {
+				username = sinkURI.User.Username()
+			}
+			sinkURI.User = url.UserPassword(username, secret)
+		case strings.HasPrefix(ref.Field, SecretFieldSinkQuery):
+			query.Set(strings.TrimPrefix(ref.Field, SecretFieldSinkQuery), secret)
+		case ref.Field == SecretFieldCredential:
+			credential = new(security.SinkCredential)
+			if err := json.Unmarshal([]byte(secret), credential); err != nil {
+				return nil, nil, cerror.ErrInvalidChangefeedExport.GenWithStack(
+					"the secret %s of the changefeed %s isn't a valid credential: %s", ref.Name, d.ID, err)
+			}
+		default:
+			return nil, nil, cerror.ErrInvalidChangefeedExport.GenWithStack(
+				"unknown secret field %s of the changefeed %s", ref.Field, d.ID)
+		}
+	}
+	sinkURI.RawQuery = query.Encode()
+	info.SinkURI = sinkURI.String()
+	if err := info.SetCredential(credential); err != nil {
+		return nil, nil, err
+	}
+	return info, credential, nil
+}
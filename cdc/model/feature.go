@@ -0,0 +1,77 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/pingcap/ticdc/pkg/config"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+)
+
+// protocolFeatures are the gated protocols of the mq sinks
+var protocolFeatures = map[string]config.Feature{
+	"avro":       config.FeatureAvroProtocol,
+	"maxwell":    config.FeatureMaxwellProtocol,
+	"canal-json": config.FeatureCanalJSONProtocol,
+}
+
+// RequiredFeatures returns the gated features used by the changefeed
+func (info *ChangeFeedInfo) RequiredFeatures() []config.Feature {
+	var features []config.Feature
+	if info.Engine == SortInFile {
+		features = append(features, config.FeatureFileSorter)
+	}
+	sinkURI, err := url.Parse(info.SinkURI)
+	if err != nil {
+		return features
+	}
+	switch strings.ToLower(sinkURI.Scheme) {
+	case "kafka", "kafka+ssl", "pulsar", "pulsar+ssl":
+		// the protocol in the sink URI overrides the one in the config
+		protocol := sinkURI.Query().Get("protocol")
+		if protocol == "" && info.Config != nil && info.Config.Sink != nil {
+			protocol = info.Config.Sink.Protocol
+		}
+		if f, ok := protocolFeatures[strings.ToLower(protocol)]; ok {
+			features = append(features, f)
+		}
+	}
+	return features
+}
+
+// CheckFeatures checks the features used by the changefeed are enabled by the
+// feature gates of the changefeed and the captures, which run the changefeed.
+func (info *ChangeFeedInfo) CheckFeatures(captures ...*CaptureInfo) error {
+	var gates config.FeatureGates
+	if info.Config != nil {
+		gates = info.Config.FeatureGates
+		if err := gates.Validate(); err != nil {
+			return err
+		}
+	}
+	for _, f := range info.RequiredFeatures() {
+		if !gates.Enabled(f) {
+			return cerror.ErrFeatureDisabled.GenWithStackByArgs(f, "by the changefeed")
+		}
+		for _, c := range captures {
+			// the captures of the older versions are in the default states
+			if !c.FeatureGates.Enabled(f) {
+				return cerror.ErrFeatureDisabled.GenWithStackByArgs(f, "on capture "+c.AdvertiseAddr)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"github.com/BurntSushi/toml"
+	"github.com/pingcap/check"
+	"github.com/pingcap/ticdc/pkg/config"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+)
+
+type featureSuite struct{}
+
+var _ = check.Suite(&featureSuite{})
+
+func (s *featureSuite) TestParseFeatureGates(c *check.C) {
+	gates, err := config.ParseFeatureGates("")
+	c.Assert(err, check.IsNil)
+	c.Assert(gates, check.HasLen, 0)
+	c.Assert(gates.Enabled(config.FeatureAvroProtocol), check.IsTrue)
+
+	gates, err = config.ParseFeatureGates(" avro-protocol=false, file-sorter = true ")
+	c.Assert(err, check.IsNil)
+	c.Assert(gates.Enabled(config.FeatureAvroProtocol), check.IsFalse)
+	c.Assert(gates.Enabled(config.FeatureFileSorter), check.IsTrue)
+	c.Assert(gates.String(), check.Equals, "avro-protocol=false,file-sorter=true")
+	c.Assert(gates.Effective(), check.HasLen, len(config.Features()))
+	c.Assert(gates.Effective().String(), check.Equals,
+		"avro-protocol=false,canal-json-protocol=true,file-sorter=true,maxwell-protocol=true")
+
+	_, err = config.ParseFeatureGates("avro-protocol")
+	c.Assert(cerror.ErrInvalidFeatureGates.Equal(err), check.IsTrue)
+	_, err = config.ParseFeatureGates("avro-protocol=no")
+	c.Assert(cerror.ErrInvalidFeatureGates.Equal(err), check.IsTrue)
+	_, err = config.ParseFeatureGates("unified-sorter=true")
+	c.Assert(cerror.ErrUnknownFeature.Equal(err), check.IsTrue)
+
+	cfg := config.GetDefaultReplicaConfig()
+	_, err = toml.Decode("[feature-gates]\nmaxwell-protocol = false\n", cfg)
+	c.Assert(err, check.IsNil)
+	c.Assert(cfg.FeatureGates.Enabled(config.FeatureMaxwellProtocol), check.IsFalse)
+	c.Assert(cfg.Clone().FeatureGates, check.DeepEquals, cfg.FeatureGates)
+}
+
+func (s *featureSuite) TestRequiredFeatures(c *check.C) {
+	info := &ChangeFeedInfo{
+		SinkURI: "mysql://root@127.0.0.1:3306/?protocol=avro",
+		Engine:  SortInMemory,
+		Config:  config.GetDefaultReplicaConfig(),
+	}
+	c.Assert(info.RequiredFeatures(), check.HasLen, 0)
+
+	info.Engine = SortInFile
+	info.SinkURI = "kafka://127.0.0.1:9092/test?protocol=Canal-JSON"
+	c.Assert(info.RequiredFeatures(), check.DeepEquals,
+		[]config.Feature{config.FeatureFileSorter, config.FeatureCanalJSONProtocol})
+
+	// the protocol in the sink URI overrides the one in the config
+	info.Engine = SortInMemory
+	info.Config.Sink.Protocol = "maxwell"
+	c.Assert(info.RequiredFeatures(), check.DeepEquals, []config.Feature{config.FeatureCanalJSONProtocol})
+	info.SinkURI = "pulsar://127.0.0.1:6650/test"
+	c.Assert(info.RequiredFeatures(), check.DeepEquals, []config.Feature{config.FeatureMaxwellProtocol})
+	info.Config.Sink.Protocol = "default"
+	c.Assert(info.RequiredFeatures(), check.HasLen, 0)
+}
+
+func (s *featureSuite) TestCheckFeatures(c *check.C) {
+	info := &ChangeFeedInfo{
+		SinkURI: "kafka://127.0.0.1:9092/test?protocol=avro",
+		Engine:  SortInMemory,
+		Config:  config.GetDefaultReplicaConfig(),
+	}
+	older := &CaptureInfo{ID: "1", AdvertiseAddr: "127.0.0.1:8300"}
+	enabled := &CaptureInfo{ID: "2", AdvertiseAddr: "127.0.0.1:8301", FeatureGates: config.FeatureGates{}.Effective()}
+	disabled := &CaptureInfo{ID: "3", AdvertiseAddr: "127.0.0.1:8302", FeatureGates: config.FeatureGates{
+		config.FeatureAvroProtocol: false,
+	}}
+	c.Assert(info.CheckFeatures(older, enabled), check.IsNil)
+	err := info.CheckFeatures(older, enabled, disabled)
+	c.Assert(cerror.ErrFeatureDisabled.Equal(err), check.IsTrue)
+	c.Assert(err, check.ErrorMatches, ".*feature avro-protocol required by the changefeed is disabled on capture 127.0.0.1:8302.*")
+
+	info.Config.FeatureGates = config.FeatureGates{config.FeatureAvroProtocol: false}
+	err = info.CheckFeatures(enabled)
+	c.Assert(err, check.ErrorMatches, ".*feature avro-protocol required by the changefeed is disabled by the changefeed.*")
+	// the gates of the features not used don't matter
+	info.SinkURI = "kafka://127.0.0.1:9092/test?protocol=maxwell"
+	c.Assert(info.CheckFeatures(disabled), check.IsNil)
+
+	info.Config.FeatureGates = config.FeatureGates{"unknown": true}
+	err = info.CheckFeatures()
+	c.Assert(cerror.ErrUnknownFeature.Equal(err), check.IsTrue)
+}
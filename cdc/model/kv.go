@@ -17,6 +17,7 @@ import (
 	"fmt"
 
 	"github.com/pingcap/ticdc/pkg/regionspan"
+	"github.com/pingcap/ticdc/pkg/tracing"
 )
 
 // OpType for the kv, delete or put
@@ -38,6 +39,8 @@ type RegionFeedEvent struct {
 
 	// Additonal debug info
 	RegionID uint64
+	// StoreAddr is the address of the store sending the resolved ts
+	StoreAddr string
 }
 
 // GetValue returns the underlying value
@@ -72,6 +75,10 @@ type RawKVEntry struct {
 
 	// Additonal debug info
 	RegionID uint64
+
+	// Trace is the trace of the sampled row change, it isn't kept if the
+	// entry is encoded by the file sorter.
+	Trace *tracing.EventTrace `msgpack:"-" json:"-"`
 }
 
 func (v *RawKVEntry) String() string {
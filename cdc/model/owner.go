@@ -17,6 +17,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"math"
+	"time"
 
 	"github.com/pingcap/errors"
 	cerror "github.com/pingcap/ticdc/pkg/errors"
@@ -28,6 +29,15 @@ type AdminJobType int
 // AdminJobOption records addition options of an admin job
 type AdminJobOption struct {
 	ForceRemove bool
+	// ResumeTs overwrites the checkpoint of the resumed changefeed if it's not 0
+	ResumeTs uint64
+	// SafeMode enables the safe mode of the sink for the events replicated
+	// again, when the changefeed is resumed from a ts earlier than the
+	// checkpoint.
+	SafeMode bool
+	// AutoRestart is true if the changefeed is resumed by the owner after a
+	// retryable error, its error history is kept for the retry policy.
+	AutoRestart bool
 }
 
 // AdminJob holds an admin job
@@ -83,6 +93,23 @@ type TaskPosition struct {
 	Count uint64 `json:"count"`
 	// Error code when error happens
 	Error *RunningError `json:"error"`
+	// ConfigVersion is the version of the changefeed config used by the processor
+	ConfigVersion uint64 `json:"config-version"`
+	// LaggingTables are the tables of the processor with the least resolved ts,
+	// at most MaxLaggingTables tables are reported.
+	LaggingTables []*TableLag `json:"lagging-tables,omitempty"`
+}
+
+// MaxLaggingTables is the max number of the lagging tables reported by a processor
+const MaxLaggingTables = 10
+
+// TableLag records the progress of a table in a processor
+type TableLag struct {
+	TableID TableID `json:"table-id"`
+	// TableName is the quoted schema and table
+	TableName    string `json:"table-name"`
+	CheckPointTs uint64 `json:"checkpoint-ts"`
+	ResolvedTs   uint64 `json:"resolved-ts"`
 }
 
 // Marshal returns the json marshal format of a TaskStatus
@@ -104,6 +131,45 @@ func (tp *TaskPosition) String() string {
 	return data
 }
 
+// TableProgress records the positions of the tables of a processor, up to
+// which the events of the tables have been flushed to the sink. When the
+// changefeed is restarted, the tables are resumed from the positions instead of
+// the checkpoint of the changefeed.
+type TableProgress struct {
+	// SinkURI and CreateTime identify the changefeed the progress belongs to,
+	// the progress is stale if the changefeed is updated or recreated.
+	SinkURI    string         `json:"sink-uri"`
+	CreateTime time.Time      `json:"create-time"`
+	Tables     map[TableID]Ts `json:"tables"`
+}
+
+// NewTableProgress creates a TableProgress of the changefeed
+func NewTableProgress(info *ChangeFeedInfo) *TableProgress {
+	return &TableProgress{
+		SinkURI:    info.SinkURI,
+		CreateTime: info.CreateTime,
+		Tables:     make(map[TableID]Ts),
+	}
+}
+
+// Match returns whether the progress belongs to the changefeed
+func (tp *TableProgress) Match(info *ChangeFeedInfo) bool {
+	return tp.SinkURI == info.SinkURI && tp.CreateTime.Equal(info.CreateTime)
+}
+
+// Marshal returns the json marshal format of a TableProgress
+func (tp *TableProgress) Marshal() (string, error) {
+	data, err := json.Marshal(tp)
+	return string(data), cerror.WrapError(cerror.ErrMarshalFailed, err)
+}
+
+// Unmarshal unmarshals into *TableProgress from json marshal byte slice
+func (tp *TableProgress) Unmarshal(data []byte) error {
+	err := json.Unmarshal(data, tp)
+	return errors.Annotatef(
+		cerror.WrapError(cerror.ErrUnmarshalFailed, err), "Unmarshal data: %v", data)
+}
+
 // MoveTableStatus represents for the status of a MoveTableJob
 type MoveTableStatus int
 
@@ -202,13 +268,31 @@ func (i *TableReplicaInfo) Clone() *TableReplicaInfo {
 	return &clone
 }
 
+// TableFailure records the panic a table is failed with in the processor
+type TableFailure struct {
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+}
+
+// Clone clones a TableFailure
+func (f *TableFailure) Clone() *TableFailure {
+	if f == nil {
+		return nil
+	}
+	clone := *f
+	return &clone
+}
+
 // TaskStatus records the task information of a capture
 type TaskStatus struct {
 	// Table information list, containing tables that processor should process, updated by ownrer, processor is read only.
-	Tables       map[TableID]*TableReplicaInfo `json:"tables"`
-	Operation    map[TableID]*TableOperation   `json:"operation"`
-	AdminJobType AdminJobType                  `json:"admin-job-type"`
-	ModRevision  int64                         `json:"-"`
+	Tables    map[TableID]*TableReplicaInfo `json:"tables"`
+	Operation map[TableID]*TableOperation   `json:"operation"`
+	// FailedTables are the tables stopped by a panic, updated by processor,
+	// the owner removes them from the capture and reschedules them.
+	FailedTables map[TableID]*TableFailure `json:"failed-tables,omitempty"`
+	AdminJobType AdminJobType              `json:"admin-job-type"`
+	ModRevision  int64                     `json:"-"`
 }
 
 // String implements fmt.Stringer interface.
@@ -326,6 +410,13 @@ func (ts *TaskStatus) Clone() *TaskStatus {
 		operation[tableID] = opt.Clone()
 	}
 	clone.Operation = operation
+	if ts.FailedTables != nil {
+		failedTables := make(map[TableID]*TableFailure, len(ts.FailedTables))
+		for tableID, failure := range ts.FailedTables {
+			failedTables[tableID] = failure.Clone()
+		}
+		clone.FailedTables = failedTables
+	}
 	return &clone
 }
 
@@ -42,6 +42,9 @@ func (s *taskStatusSuite) TestShouldBeDeepCopy(c *check.C) {
 				Delete: false, BoundaryTs: 7, Done: false,
 			},
 		},
+		FailedTables: map[TableID]*TableFailure{
+			4: {Message: "panic"},
+		},
 		AdminJobType: AdminStop,
 	}
 
@@ -61,6 +64,9 @@ func (s *taskStatusSuite) TestShouldBeDeepCopy(c *check.C) {
 				Delete: false, BoundaryTs: 7, Done: false,
 			},
 		})
+		c.Assert(clone.FailedTables, check.DeepEquals, map[TableID]*TableFailure{
+			4: {Message: "panic"},
+		})
 		c.Assert(clone.AdminJobType, check.Equals, AdminStop)
 	}
 
@@ -68,6 +74,7 @@ func (s *taskStatusSuite) TestShouldBeDeepCopy(c *check.C) {
 
 	info.Tables[7] = &TableReplicaInfo{StartTs: 100}
 	info.Operation[7] = &TableOperation{Delete: true, BoundaryTs: 7, Done: true}
+	info.FailedTables[4].Message = "changed"
 
 	info.Operation[5].BoundaryTs = 8
 	info.Tables[1].StartTs = 200
@@ -0,0 +1,258 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+
+	"github.com/pingcap/parser/model"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+	"github.com/pingcap/ticdc/proto/event"
+)
+
+// ToProto converts the row to its protobuf representation, the trace and the
+// unsupported column values can't be converted.
+func (r *RowChangedEvent) ToProto() (*event.RowChangedEvent, error) {
+	pb := &event.RowChangedEvent{
+		StartTs:          r.StartTs,
+		CommitTs:         r.CommitTs,
+		RowId:            r.RowID,
+		TableInfoVersion: r.TableInfoVersion,
+		ApproximateSize:  r.ApproximateSize,
+	}
+	if r.Table != nil {
+		pb.Table = &event.TableName{
+			Schema:      r.Table.Schema,
+			Table:       r.Table.Table,
+			TableId:     r.Table.TableID,
+			IsPartition: r.Table.IsPartition,
+		}
+	}
+	var err error
+	if pb.Columns, err = columnsToProto(r.Columns); err != nil {
+		return nil, err
+	}
+	if pb.PreColumns, err = columnsToProto(r.PreColumns); err != nil {
+		return nil, err
+	}
+	for _, index := range r.IndexColumns {
+		offsets := make([]int32, 0, len(index))
+		for _, offset := range index {
+			offsets = append(offsets, int32(offset))
+		}
+		pb.IndexColumns = append(pb.IndexColumns, &event.IndexColumns{Offsets: offsets})
+	}
+	return pb, nil
+}
+
+// FromProto fills the values of RowChangedEvent from its protobuf
+// representation
+func (r *RowChangedEvent) FromProto(pb *event.RowChangedEvent) {
+	r.StartTs = pb.StartTs
+	r.CommitTs = pb.CommitTs
+	r.RowID = pb.RowId
+	r.TableInfoVersion = pb.TableInfoVersion
+	r.ApproximateSize = pb.ApproximateSize
+	r.Table = nil
+	if pb.Table != nil {
+		r.Table = &TableName{
+			Schema:      pb.Table.Schema,
+			Table:       pb.Table.Table,
+			TableID:     pb.Table.TableId,
+			IsPartition: pb.Table.IsPartition,
+		}
+	}
+	r.Columns = columnsFromProto(pb.Columns)
+	r.PreColumns = columnsFromProto(pb.PreColumns)
+	r.IndexColumns = nil
+	for _, index := range pb.IndexColumns {
+		offsets := make([]int, 0, len(index.Offsets))
+		for _, offset := range index.Offsets {
+			offsets = append(offsets, int(offset))
+		}
+		r.IndexColumns = append(r.IndexColumns, offsets)
+	}
+}
+
+func columnsToProto(cols []*Column) ([]*event.Column, error) {
+	if cols == nil {
+		return nil, nil
+	}
+	pbs := make([]*event.Column, 0, len(cols))
+	for _, col := range cols {
+		if col == nil {
+			// the absent column is kept as the one without the name, so that
+			// the offsets of the index columns remain valid.
+			pbs = append(pbs, &event.Column{})
+			continue
+		}
+		pb := &event.Column{
+			Name: col.Name,
+			Type: uint32(col.Type),
+			Flag: uint64(col.Flag),
+		}
+		switch v := col.Value.(type) {
+		case nil:
+		case int:
+			pb.Value = &event.Column_IntValue{IntValue: int64(v)}
+		case int8:
+			pb.Value = &event.Column_IntValue{IntValue: int64(v)}
+		case int16:
+			pb.Value = &event.Column_IntValue{IntValue: int64(v)}
+		case int32:
+			pb.Value = &event.Column_IntValue{IntValue: int64(v)}
+		case int64:
+			pb.Value = &event.Column_IntValue{IntValue: v}
+		case uint:
+			pb.Value = &event.Column_UintValue{UintValue: uint64(v)}
+		case uint8:
+			pb.Value = &event.Column_UintValue{UintValue: uint64(v)}
+		case uint16:
+			pb.Value = &event.Column_UintValue{UintValue: uint64(v)}
+		case uint32:
+			pb.Value = &event.Column_UintValue{UintValue: uint64(v)}
+		case uint64:
+			pb.Value = &event.Column_UintValue{UintValue: v}
+		case float32:
+			pb.Value = &event.Column_FloatValue{FloatValue: v}
+		case float64:
+			pb.Value = &event.Column_DoubleValue{DoubleValue: v}
+		case string:
+			pb.Value = &event.Column_StringValue{StringValue: v}
+		case []byte:
+			pb.Value = &event.Column_BytesValue{BytesValue: v}
+		default:
+			return nil, cerror.ErrEncodeFailed.GenWithStackByArgs(
+				fmt.Sprintf("unsupported value type %T of the column %s", v, col.Name))
+		}
+		pbs = append(pbs, pb)
+	}
+	return pbs, nil
+}
+
+func columnsFromProto(pbs []*event.Column) []*Column {
+	if pbs == nil {
+		return nil
+	}
+	cols := make([]*Column, 0, len(pbs))
+	for _, pb := range pbs {
+		if pb.Name == "" {
+			cols = append(cols, nil)
+			continue
+		}
+		col := &Column{
+			Name: pb.Name,
+			Type: byte(pb.Type),
+			Flag: ColumnFlagType(pb.Flag),
+		}
+		switch v := pb.Value.(type) {
+		case *event.Column_IntValue:
+			col.Value = v.IntValue
+		case *event.Column_UintValue:
+			col.Value = v.UintValue
+		case *event.Column_FloatValue:
+			col.Value = v.FloatValue
+		case *event.Column_DoubleValue:
+			col.Value = v.DoubleValue
+		case *event.Column_StringValue:
+			col.Value = v.StringValue
+		case *event.Column_BytesValue:
+			col.Value = v.BytesValue
+			if col.Value == nil {
+				// the empty bytes are decoded as nil
+				col.Value = []byte{}
+			}
+		}
+		cols = append(cols, col)
+	}
+	return cols
+}
+
+// ToProto converts the DDL event to its protobuf representation
+func (d *DDLEvent) ToProto() *event.DDLEvent {
+	return &event.DDLEvent{
+		StartTs:      d.StartTs,
+		CommitTs:     d.CommitTs,
+		TableInfo:    tableInfoToProto(d.TableInfo),
+		PreTableInfo: tableInfoToProto(d.PreTableInfo),
+		Query:        d.Query,
+		Type:         int32(d.Type),
+	}
+}
+
+// FromProto fills the values of DDLEvent from its protobuf representation
+func (d *DDLEvent) FromProto(pb *event.DDLEvent) {
+	d.StartTs = pb.StartTs
+	d.CommitTs = pb.CommitTs
+	d.TableInfo = tableInfoFromProto(pb.TableInfo)
+	d.PreTableInfo = tableInfoFromProto(pb.PreTableInfo)
+	d.Query = pb.Query
+	d.Type = model.ActionType(pb.Type)
+}
+
+func tableInfoToProto(info *SimpleTableInfo) *event.SimpleTableInfo {
+	if info == nil {
+		return nil
+	}
+	pb := &event.SimpleTableInfo{
+		Schema:  info.Schema,
+		Table:   info.Table,
+		TableId: info.TableID,
+	}
+	for _, col := range info.ColumnInfo {
+		pb.ColumnInfo = append(pb.ColumnInfo, &event.ColumnInfo{Name: col.Name, Type: uint32(col.Type)})
+	}
+	return pb
+}
+
+func tableInfoFromProto(pb *event.SimpleTableInfo) *SimpleTableInfo {
+	if pb == nil {
+		return nil
+	}
+	info := &SimpleTableInfo{
+		Schema:  pb.Schema,
+		Table:   pb.Table,
+		TableID: pb.TableId,
+	}
+	for _, col := range pb.ColumnInfo {
+		info.ColumnInfo = append(info.ColumnInfo, &ColumnInfo{Name: col.Name, Type: byte(col.Type)})
+	}
+	return info
+}
+
+// ToProto converts the raw KV entry to its protobuf representation, the trace
+// isn't kept.
+func (v *RawKVEntry) ToProto() *event.RawKVEntry {
+	return &event.RawKVEntry{
+		OpType:   event.OpType(v.OpType),
+		Key:      v.Key,
+		Value:    v.Value,
+		OldValue: v.OldValue,
+		StartTs:  v.StartTs,
+		Crts:     v.CRTs,
+		RegionId: v.RegionID,
+	}
+}
+
+// FromProto fills the values of RawKVEntry from its protobuf representation
+func (v *RawKVEntry) FromProto(pb *event.RawKVEntry) {
+	v.OpType = OpType(pb.OpType)
+	v.Key = pb.Key
+	v.Value = pb.Value
+	v.OldValue = pb.OldValue
+	v.StartTs = pb.StartTs
+	v.CRTs = pb.Crts
+	v.RegionID = pb.RegionId
+	v.Trace = nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"github.com/pingcap/check"
+	timodel "github.com/pingcap/parser/model"
+	"github.com/pingcap/parser/mysql"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+	"github.com/pingcap/ticdc/proto/event"
+)
+
+type protoSuite struct{}
+
+var _ = check.Suite(&protoSuite{})
+
+func (s *protoSuite) TestRowChangedEvent(c *check.C) {
+	row := &RowChangedEvent{
+		StartTs:          1,
+		CommitTs:         2,
+		RowID:            3,
+		Table:            &TableName{Schema: "test", Table: "t", TableID: 45, IsPartition: true},
+		TableInfoVersion: 4,
+		Columns: []*Column{
+			{Name: "a", Type: mysql.TypeLong, Flag: HandleKeyFlag, Value: int64(-1)},
+			nil,
+			{Name: "b", Type: mysql.TypeLonglong, Value: uint64(1)},
+			{Name: "c", Type: mysql.TypeFloat, Value: float32(1.5)},
+			{Name: "d", Type: mysql.TypeDouble, Value: 2.5},
+			{Name: "e", Type: mysql.TypeDatetime, Value: "2020-01-01 00:00:00"},
+			{Name: "f", Type: mysql.TypeVarchar, Flag: BinaryFlag, Value: []byte{}},
+			{Name: "g", Type: mysql.TypeBlob, Value: nil},
+		},
+		PreColumns:      []*Column{{Name: "a", Type: mysql.TypeLong, Flag: HandleKeyFlag, Value: int64(-2)}},
+		IndexColumns:    [][]int{{0}, {2, 4}},
+		ApproximateSize: 100,
+	}
+	pb, err := row.ToProto()
+	c.Assert(err, check.IsNil)
+	data, err := pb.Marshal()
+	c.Assert(err, check.IsNil)
+	decodedPb := new(event.RowChangedEvent)
+	c.Assert(decodedPb.Unmarshal(data), check.IsNil)
+	decoded := new(RowChangedEvent)
+	decoded.FromProto(decodedPb)
+	c.Assert(decoded, check.DeepEquals, row)
+
+	row.Columns = []*Column{{Name: "a", Value: int8(1)}, {Name: "b", Value: uint16(1)}}
+	pb, err = row.ToProto()
+	c.Assert(err, check.IsNil)
+	c.Assert(pb.Columns[0].GetIntValue(), check.Equals, int64(1))
+	c.Assert(pb.Columns[1].GetUintValue(), check.Equals, uint64(1))
+
+	row.Columns = []*Column{{Name: "a", Value: struct{}{}}}
+	_, err = row.ToProto()
+	c.Assert(cerror.ErrEncodeFailed.Equal(err), check.IsTrue)
+}
+
+func (s *protoSuite) TestDDLEvent(c *check.C) {
+	ddl := &DDLEvent{
+		StartTs:  1,
+		CommitTs: 2,
+		TableInfo: &SimpleTableInfo{
+			Schema:     "test",
+			Table:      "t2",
+			TableID:    46,
+			ColumnInfo: []*ColumnInfo{{Name: "a", Type: mysql.TypeLong}},
+		},
+		PreTableInfo: &SimpleTableInfo{Schema: "test", Table: "t1", TableID: 45},
+		Query:        "rename table t1 to t2",
+		Type:         timodel.ActionRenameTable,
+	}
+	data, err := ddl.ToProto().Marshal()
+	c.Assert(err, check.IsNil)
+	pb := new(event.DDLEvent)
+	c.Assert(pb.Unmarshal(data), check.IsNil)
+	decoded := new(DDLEvent)
+	decoded.FromProto(pb)
+	c.Assert(decoded, check.DeepEquals, ddl)
+}
+
+func (s *protoSuite) TestRawKVEntry(c *check.C) {
+	entry := &RawKVEntry{
+		OpType:   OpTypePut,
+		Key:      []byte("key"),
+		Value:    []byte("value"),
+		OldValue: []byte("old"),
+		StartTs:  1,
+		CRTs:     2,
+		RegionID: 3,
+	}
+	data, err := entry.ToProto().Marshal()
+	c.Assert(err, check.IsNil)
+	pb := new(event.RawKVEntry)
+	c.Assert(pb.Unmarshal(data), check.IsNil)
+	c.Assert(pb.OpType, check.Equals, event.OpType_PUT)
+	decoded := new(RawKVEntry)
+	decoded.FromProto(pb)
+	c.Assert(decoded, check.DeepEquals, entry)
+}
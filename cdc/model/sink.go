@@ -19,6 +19,8 @@ import (
 
 	"github.com/pingcap/log"
 	"github.com/pingcap/parser/model"
+	"github.com/pingcap/ticdc/pkg/config"
+	"github.com/pingcap/ticdc/pkg/logutil"
 	"github.com/pingcap/ticdc/pkg/util"
 	"go.uber.org/zap"
 )
@@ -236,6 +238,19 @@ func (r *RowChangedEvent) IsDelete() bool {
 	return len(r.PreColumns) != 0 && len(r.Columns) == 0
 }
 
+// EventType returns the DML type of the row, an update is an insert if the
+// old value isn't enabled.
+func (r *RowChangedEvent) EventType() config.EventType {
+	switch {
+	case r.IsDelete():
+		return config.EventDelete
+	case len(r.PreColumns) != 0:
+		return config.EventUpdate
+	default:
+		return config.EventInsert
+	}
+}
+
 // HandleKeyColumns returns the column(s) corresponding to the handle key(s)
 func (r *RowChangedEvent) PrimaryKeyColumns() []*Column {
 	pkeyCols := make([]*Column, 0)
@@ -425,7 +440,7 @@ func (t *SingleTableTxn) Append(row *RowChangedEvent) {
 			zap.Uint64("startTs of txn", t.StartTs),
 			zap.Uint64("commitTs of txn", t.CommitTs),
 			zap.Any("table of txn", t.Table),
-			zap.Any("row", row))
+			logutil.ZapRedactReflect("row", row))
 	}
 	t.Rows = append(t.Rows, row)
 }
@@ -17,8 +17,8 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"math"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -52,8 +52,9 @@ type Owner struct {
 	changeFeeds map[model.ChangeFeedID]*changeFeed
 	// failInitFeeds record changefeeds that meet error during initialization
 	failInitFeeds map[model.ChangeFeedID]struct{}
-	// stoppedFeeds record changefeeds that meet running error
-	stoppedFeeds              map[model.ChangeFeedID]*model.ChangeFeedStatus
+	// stoppedFeeds record changefeeds that are paused or failed, which hold
+	// the GC safepoint until their gc ttl expire
+	stoppedFeeds              map[model.ChangeFeedID]*stoppedFeed
 	rebalanceTigger           map[model.ChangeFeedID]bool
 	rebalanceForAllChangefeed bool
 	manualScheduleCommand     map[model.ChangeFeedID][]*model.MoveTableJob
@@ -67,6 +68,10 @@ type Owner struct {
 	credential  *security.Credential
 	pdClient    pd.Client
 	etcdClient  kv.CDCEtcdClient
+	// upstreams are the clients of the upstream clusters other than the one
+	// of the captures, keyed by the ID of the upstream, see getUpstream.
+	upstreams   map[string]*upstreamClient
+	upstreamsMu sync.Mutex
 
 	captures map[model.CaptureID]*model.CaptureInfo
 
@@ -84,11 +89,56 @@ type Owner struct {
 	flushChangefeedInterval time.Duration
 }
 
+// stoppedFeed is a paused or failed changefeed, which holds the GC safepoint
+// at its checkpoint for its gc ttl since it's stopped, so that it can be
+// resumed in the meantime.
+type stoppedFeed struct {
+	status *model.ChangeFeedStatus
+	// since is the time the owner finds the changefeed stopped, it's reset
+	// when the owner changes, which holds the GC safepoint longer rather than
+	// shorter.
+	since time.Time
+	// gcTTL is in seconds
+	gcTTL   int64
+	warned  bool
+	expired bool
+	// upstreamID is the ID of the upstream whose GC safepoint the changefeed
+	// holds, see model.UpstreamInfo.ID.
+	upstreamID string
+}
+
+// holdsGCSafepoint returns whether the gc ttl of the stopped changefeed
+// hasn't expired, it warns once the gc ttl is about to expire.
+func (f *stoppedFeed) holdsGCSafepoint(id model.ChangeFeedID, now time.Time) bool {
+	ttl := time.Duration(f.gcTTL) * time.Second
+	remaining := ttl - now.Sub(f.since)
+	if remaining <= 0 {
+		if !f.expired {
+			log.Warn("the gc ttl of the stopped changefeed expires, its checkpoint doesn't hold the GC safepoint "+
+				"anymore and it can't be resumed once the checkpoint is garbage collected",
+				zap.String("changefeed", id), zap.Uint64("checkpoint-ts", f.status.CheckpointTs),
+				zap.Int64("gc-ttl", f.gcTTL))
+			f.expired = true
+		}
+		return false
+	}
+	if !f.warned && float64(remaining) < float64(ttl)*stoppedFeedGCWarningRatio {
+		log.Warn("the gc ttl of the stopped changefeed is about to expire, resume or remove it",
+			zap.String("changefeed", id), zap.Uint64("checkpoint-ts", f.status.CheckpointTs),
+			zap.Duration("remaining", remaining))
+		f.warned = true
+	}
+	return true
+}
+
 const (
 	// CDCServiceSafePointID is the ID of CDC service in pd.UpdateServiceGCSafePoint.
 	CDCServiceSafePointID = "ticdc"
 	// GCSafepointUpdateInterval is the minimual interval that CDC can update gc safepoint
 	GCSafepointUpdateInterval = time.Duration(2 * time.Second)
+	// stoppedFeedGCWarningRatio is the ratio of the remaining gc ttl of a
+	// stopped changefeed to warn it's about to expire
+	stoppedFeedGCWarningRatio = 0.1
 )
 
 // NewOwner creates a new Owner instance
@@ -110,7 +160,7 @@ func NewOwner(
 		credential:              credential,
 		changeFeeds:             make(map[model.ChangeFeedID]*changeFeed),
 		failInitFeeds:           make(map[model.ChangeFeedID]struct{}),
-		stoppedFeeds:            make(map[model.ChangeFeedID]*model.ChangeFeedStatus),
+		stoppedFeeds:            make(map[model.ChangeFeedID]*stoppedFeed),
 		captures:                make(map[model.CaptureID]*model.CaptureInfo),
 		rebalanceTigger:         make(map[model.ChangeFeedID]bool),
 		manualScheduleCommand:   make(map[model.ChangeFeedID][]*model.MoveTableJob),
@@ -124,6 +174,21 @@ func NewOwner(
 	return owner, nil
 }
 
+// addStoppedFeed records a paused or failed changefeed, the gc ttl is updated
+// if it's recorded already.
+func (o *Owner) addStoppedFeed(id model.ChangeFeedID, status *model.ChangeFeedStatus, info *model.ChangeFeedInfo) {
+	gcTTL := info.GCTTL
+	if gcTTL == 0 {
+		gcTTL = o.gcTTL
+	}
+	if feed, ok := o.stoppedFeeds[id]; ok {
+		feed.status = status
+		feed.gcTTL = gcTTL
+		return
+	}
+	o.stoppedFeeds[id] = &stoppedFeed{status: status, since: time.Now(), gcTTL: gcTTL, upstreamID: info.Upstream.ID()}
+}
+
 func (o *Owner) addCapture(info *model.CaptureInfo) {
 	o.l.Lock()
 	o.captures[info.ID] = info
@@ -133,6 +198,16 @@ func (o *Owner) addCapture(info *model.CaptureInfo) {
 	o.rebalanceMu.Unlock()
 }
 
+// captureList returns all the alive captures, the changefeeds may run on any
+// of them. o.l must be held.
+func (o *Owner) captureList() []*model.CaptureInfo {
+	captures := make([]*model.CaptureInfo, 0, len(o.captures))
+	for _, c := range o.captures {
+		captures = append(captures, c)
+	}
+	return captures
+}
+
 func (o *Owner) removeCapture(info *model.CaptureInfo) {
 	o.l.Lock()
 	defer o.l.Unlock()
@@ -187,11 +262,43 @@ func (o *Owner) addOrphanTable(cid model.CaptureID, tableID model.TableID, start
 	}
 }
 
+// mergeTableProgress returns the max position of each table recorded by the
+// processors of the changefeed. Every recorded position is flushed to the sink,
+// so it's safe to resume the table from the max one. The progress recorded for
+// an updated or recreated changefeed is ignored.
+func mergeTableProgress(progress map[string]*model.TableProgress, info *model.ChangeFeedInfo) map[model.TableID]model.Ts {
+	merged := make(map[model.TableID]model.Ts)
+	for captureID, p := range progress {
+		if !p.Match(info) {
+			log.Info("ignore stale table progress", zap.String("capture", captureID))
+			continue
+		}
+		for tableID, ts := range p.Tables {
+			if ts > merged[tableID] {
+				merged[tableID] = ts
+			}
+		}
+	}
+	return merged
+}
+
+// resumeTs returns the start ts of a table when the changefeed is restarted.
+// It's never less than the checkpoint of the changefeed, which is guarded by
+// the GC safepoint, and no DDL can be between the checkpoint and the progress
+// of the table, as a DDL blocks the processors until it's executed.
+func resumeTs(tableProgress map[model.TableID]model.Ts, tableID model.TableID, checkpointTs model.Ts) model.Ts {
+	if ts := tableProgress[tableID]; ts > checkpointTs {
+		return ts
+	}
+	return checkpointTs
+}
+
 func (o *Owner) newChangeFeed(
 	ctx context.Context,
 	id model.ChangeFeedID,
 	processorsInfos model.ProcessorsInfos,
 	taskPositions map[string]*model.TaskPosition,
+	tableProgress map[model.TableID]model.Ts,
 	info *model.ChangeFeedInfo,
 	checkpointTs uint64) (cf *changeFeed, resultErr error) {
 	log.Info("Find new changefeed", zap.Stringer("info", info),
@@ -205,8 +312,16 @@ func (o *Owner) newChangeFeed(
 		failpoint.Return(nil, errors.New("failpoint injected retriable error"))
 	})
 
+	if err := info.CheckFeatures(o.captureList()...); err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	pdEndpoints, credential, pdClient, _, err := o.getUpstream(ctx, info.Upstream)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
 	// TODO here we create another pb client,we should reuse them
-	kvStore, err := kv.CreateTiStore(strings.Join(o.pdEndpoints, ","), o.credential)
+	kvStore, err := kv.CreateTiStore(strings.Join(pdEndpoints, ","), credential)
 	if err != nil {
 		return nil, err
 	}
@@ -235,7 +350,7 @@ func (o *Owner) newChangeFeed(
 		}
 	}
 
-	ddlHandler := newDDLHandler(o.pdClient, o.credential, kvStore, checkpointTs)
+	ddlHandler := newDDLHandler(pdClient, credential, kvStore, checkpointTs)
 	defer func() {
 		if resultErr != nil {
 			ddlHandler.Close()
@@ -315,10 +430,10 @@ func (o *Owner) newChangeFeed(
 					log.Info("ignore known table partition", zap.Int64("tid", tid), zap.Int64("partitionID", id), zap.Stringer("table", table), zap.Uint64("ts", ts))
 					continue
 				}
-				orphanTables[id] = checkpointTs
+				orphanTables[id] = resumeTs(tableProgress, id, checkpointTs)
 			}
 		} else {
-			orphanTables[tid] = checkpointTs
+			orphanTables[tid] = resumeTs(tableProgress, tid, checkpointTs)
 		}
 
 		sinkTableInfo[j-1] = new(model.SimpleTableInfo)
@@ -331,9 +446,13 @@ func (o *Owner) newChangeFeed(
 		}
 
 	}
+	sinkCredential, err := info.GetCredential()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
 	errCh := make(chan error, 1)
 
-	primarySink, err := sink.NewSink(ctx, id, info.SinkURI, filter, info.Config, info.Opts, errCh)
+	primarySink, err := sink.NewSink(ctx, id, info.SinkURI, filter, info.Config, sinkCredential, info.Opts, errCh)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
@@ -359,7 +478,7 @@ func (o *Owner) newChangeFeed(
 
 	var syncpointStore sink.SyncpointStore
 	if info.SyncPointEnabled {
-		syncpointStore, err = sink.NewSyncpointStore(ctx, id, info.SinkURI)
+		syncpointStore, err = sink.NewSyncpointStore(ctx, id, info.SinkURI, sinkCredential)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
@@ -433,6 +552,7 @@ func (o *Owner) loadChangeFeeds(ctx context.Context) error {
 		return err
 	}
 	errorFeeds := make(map[model.ChangeFeedID]*model.RunningError)
+	var restartFeeds []model.ChangeFeedID
 	for changeFeedID, cfInfoRawValue := range details {
 		taskStatus, err := o.cfRWriter.GetAllTaskStatus(ctx, changeFeedID)
 		if err != nil {
@@ -452,6 +572,13 @@ func (o *Owner) loadChangeFeeds(ctx context.Context) error {
 					break
 				}
 			}
+			if err := o.reloadChangeFeedConfig(ctx, cf, cfInfoRawValue.Value); err != nil {
+				log.Error("failed to reload changefeed config",
+					zap.String("changefeed", changeFeedID), zap.Error(err))
+				errorFeeds[changeFeedID] = model.NewRunningError(
+					util.CaptureAddrFromCtx(ctx), err, cerror.ErrOwnerUnknown)
+			}
+			cf.addReloadedTables()
 			continue
 		}
 
@@ -467,6 +594,16 @@ func (o *Owner) loadChangeFeeds(ctx context.Context) error {
 			}
 			log.Warn("changefeed is not in normal state", zap.String("changefeed", changeFeedID))
 			o.failInitFeeds[changeFeedID] = struct{}{}
+			// the failed changefeed holds the GC safepoint until its gc ttl
+			// expires, so that it can be resumed after the error is fixed
+			status, _, err := o.cfRWriter.GetChangeFeedStatus(ctx, changeFeedID)
+			if err != nil && cerror.ErrChangeFeedNotExists.NotEqual(err) {
+				return err
+			}
+			if status == nil {
+				status = &model.ChangeFeedStatus{CheckpointTs: cfInfo.GetCheckpointTs(nil)}
+			}
+			o.addStoppedFeed(changeFeedID, status, cfInfo)
 			continue
 		}
 		if _, ok := o.failInitFeeds[changeFeedID]; ok {
@@ -498,27 +635,33 @@ func (o *Owner) loadChangeFeeds(ctx context.Context) error {
 		}
 		if status != nil && status.AdminJobType.IsStopState() {
 			if status.AdminJobType == model.AdminStop {
-				if _, ok := o.stoppedFeeds[changeFeedID]; !ok {
-					o.stoppedFeeds[changeFeedID] = status
+				o.addStoppedFeed(changeFeedID, status, cfInfo)
+				// the changefeed stopped by a retryable error is restarted
+				// once the backoff of the error is over
+				if cfInfo.Error != nil && cfInfo.Error.Retryable {
+					restartFeeds = append(restartFeeds, changeFeedID)
 				}
 			}
 			continue
 		}
 		checkpointTs := cfInfo.GetCheckpointTs(status)
+		progress, err := o.cfRWriter.GetAllTableProgress(ctx, changeFeedID)
+		if err != nil {
+			return err
+		}
+		tableProgress := mergeTableProgress(progress, cfInfo)
 
-		newCf, err := o.newChangeFeed(ctx, changeFeedID, taskStatus, taskPositions, cfInfo, checkpointTs)
+		newCf, err := o.newChangeFeed(ctx, changeFeedID, taskStatus, taskPositions, tableProgress, cfInfo, checkpointTs)
 		if err != nil {
-			cfInfo.Error = &model.RunningError{
-				Addr:    util.CaptureAddrFromCtx(ctx),
-				Code:    "CDC-owner-1001",
-				Message: err.Error(),
+			cfInfo.RecordError(model.NewRunningError(util.CaptureAddrFromCtx(ctx), err, cerror.ErrOwnerUnknown))
+			if filter.ChangefeedFastFailError(err) {
+				cfInfo.Error.Retryable = false
+				cfInfo.State = model.StateFailed
 			}
-			cfInfo.ErrorHis = append(cfInfo.ErrorHis, time.Now().UnixNano()/1e6)
 
-			if filter.ChangefeedFastFailError(err) {
-				log.Error("create changefeed with fast fail error, mark changefeed as failed",
+			if cfInfo.State == model.StateFailed {
+				log.Error("create changefeed with unretryable error, mark changefeed as failed",
 					zap.Error(err), zap.String("changefeedid", changeFeedID))
-				cfInfo.State = model.StateFailed
 				err := o.etcdClient.SaveChangeFeedInfo(ctx, cfInfo, changeFeedID)
 				if err != nil {
 					return err
@@ -561,10 +704,38 @@ func (o *Owner) loadChangeFeeds(ctx context.Context) error {
 		}
 		o.adminJobs = append(o.adminJobs, job)
 	}
+	for _, cfID := range restartFeeds {
+		log.Info("restart the changefeed stopped by a retryable error", zap.String("changefeed", cfID))
+		o.adminJobs = append(o.adminJobs, model.AdminJob{
+			CfID: cfID,
+			Type: model.AdminResume,
+			Opts: &model.AdminJobOption{AutoRestart: true},
+		})
+	}
 	o.adminJobsLock.Unlock()
 	return nil
 }
 
+// reloadChangeFeedConfig reloads the config of a running changefeed if its
+// config version is increased.
+func (o *Owner) reloadChangeFeedConfig(ctx context.Context, cf *changeFeed, rawInfo []byte) error {
+	info := &model.ChangeFeedInfo{}
+	if err := info.Unmarshal(rawInfo); err != nil {
+		return errors.Trace(err)
+	}
+	if info.ConfigVersion <= cf.info.ConfigVersion {
+		return nil
+	}
+	if err := info.VerifyAndFix(); err != nil {
+		return errors.Trace(err)
+	}
+	// the protocol of the sink may be changed
+	if err := info.CheckFeatures(o.captureList()...); err != nil {
+		return errors.Trace(err)
+	}
+	return cf.reloadConfig(ctx, info)
+}
+
 func (o *Owner) balanceTables(ctx context.Context) error {
 	rebalanceForAllChangefeed := false
 	o.rebalanceMu.Lock()
@@ -573,6 +744,17 @@ func (o *Owner) balanceTables(ctx context.Context) error {
 		o.rebalanceForAllChangefeed = false
 	}
 	o.rebalanceMu.Unlock()
+	// the draining captures are exiting, no table is scheduled to them and
+	// their tables are moved to the others.
+	captures := make(map[model.CaptureID]*model.CaptureInfo, len(o.captures))
+	draining := make(map[model.CaptureID]*model.CaptureInfo)
+	for id, info := range o.captures {
+		if info.Draining {
+			draining[id] = info
+		} else {
+			captures[id] = info
+		}
+	}
 	for id, changefeed := range o.changeFeeds {
 		rebalanceNow := false
 		var scheduleCommands []*model.MoveTableJob
@@ -589,7 +771,8 @@ func (o *Owner) balanceTables(ctx context.Context) error {
 			delete(o.manualScheduleCommand, id)
 		}
 		o.rebalanceMu.Unlock()
-		err := changefeed.tryBalance(ctx, o.captures, rebalanceNow, scheduleCommands)
+		changefeed.drainCaptures(captures, draining)
+		err := changefeed.tryBalance(ctx, captures, rebalanceNow, scheduleCommands)
 		if err != nil {
 			return errors.Trace(err)
 		}
@@ -598,27 +781,23 @@ func (o *Owner) balanceTables(ctx context.Context) error {
 }
 
 func (o *Owner) flushChangeFeedInfos(ctx context.Context) error {
-	// no running or stopped changefeed, clear gc safepoint.
-	if len(o.changeFeeds) == 0 && len(o.stoppedFeeds) == 0 {
-		if !o.gcSafepointLastUpdate.IsZero() {
-			_, err := o.pdClient.UpdateServiceGCSafePoint(ctx, CDCServiceSafePointID, 0, 0)
-			if err != nil {
-				log.Warn("failed to update service safe point", zap.Error(err))
-			} else {
-				o.gcSafepointLastUpdate = time.Time{}
-			}
+	// the min checkpoint of the changefeeds of each upstream, the cluster of
+	// the captures is the empty ID.
+	minCheckpointTs := make(map[string]uint64)
+	holdCheckpoint := func(upstreamID string, checkpointTs uint64) {
+		if ts, ok := minCheckpointTs[upstreamID]; !ok || checkpointTs < ts {
+			minCheckpointTs[upstreamID] = checkpointTs
 		}
-		return nil
 	}
-
-	minCheckpointTs := uint64(math.MaxUint64)
 	if len(o.changeFeeds) > 0 {
 		snapshot := make(map[model.ChangeFeedID]*model.ChangeFeedStatus, len(o.changeFeeds))
 		for id, changefeed := range o.changeFeeds {
 			snapshot[id] = changefeed.status
-			if changefeed.status.CheckpointTs < minCheckpointTs {
-				minCheckpointTs = changefeed.status.CheckpointTs
+			var upstreamID string
+			if changefeed.info != nil {
+				upstreamID = changefeed.info.Upstream.ID()
 			}
+			holdCheckpoint(upstreamID, changefeed.status.CheckpointTs)
 		}
 		if time.Since(o.lastFlushChangefeeds) > o.flushChangefeedInterval {
 			err := o.cfRWriter.PutAllChangeFeedStatus(ctx, snapshot)
@@ -628,20 +807,47 @@ func (o *Owner) flushChangeFeedInfos(ctx context.Context) error {
 			o.lastFlushChangefeeds = time.Now()
 		}
 	}
-	for _, status := range o.stoppedFeeds {
-		if status.CheckpointTs < minCheckpointTs {
-			minCheckpointTs = status.CheckpointTs
+	now := time.Now()
+	for id, feed := range o.stoppedFeeds {
+		if feed.holdsGCSafepoint(id, now) {
+			holdCheckpoint(feed.upstreamID, feed.status.CheckpointTs)
+		}
+	}
+	o.updateGCSafepoint(ctx, "", o.pdClient, &o.gcSafepointLastUpdate, minCheckpointTs)
+	o.upstreamsMu.Lock()
+	defer o.upstreamsMu.Unlock()
+	for id, upstream := range o.upstreams {
+		o.updateGCSafepoint(ctx, id, upstream.pdClient, &upstream.gcSafepointLastUpdate, minCheckpointTs)
+	}
+	return nil
+}
+
+// updateGCSafepoint updates the service GC safepoint of an upstream to the
+// min checkpoint of its changefeeds, the safepoint is cleared if there is no
+// running changefeed or stopped one within its gc ttl.
+func (o *Owner) updateGCSafepoint(
+	ctx context.Context, upstreamID string, pdClient pd.Client, lastUpdate *time.Time, minCheckpointTs map[string]uint64,
+) {
+	checkpointTs, ok := minCheckpointTs[upstreamID]
+	if !ok {
+		if !lastUpdate.IsZero() {
+			_, err := pdClient.UpdateServiceGCSafePoint(ctx, CDCServiceSafePointID, 0, 0)
+			if err != nil {
+				log.Warn("failed to update service safe point", zap.String("upstream", upstreamID), zap.Error(err))
+			} else {
+				*lastUpdate = time.Time{}
+			}
 		}
+		return
 	}
-	if time.Since(o.gcSafepointLastUpdate) > GCSafepointUpdateInterval {
-		_, err := o.pdClient.UpdateServiceGCSafePoint(ctx, CDCServiceSafePointID, o.gcTTL, minCheckpointTs)
+	if time.Since(*lastUpdate) > GCSafepointUpdateInterval {
+		_, err := pdClient.UpdateServiceGCSafePoint(ctx, CDCServiceSafePointID, o.gcTTL, checkpointTs)
 		if err != nil {
-			log.Warn("failed to update service safe point", zap.Error(err))
+			log.Warn("failed to update service safe point", zap.String("upstream", upstreamID), zap.Error(err))
 		} else {
-			o.gcSafepointLastUpdate = time.Now()
+			*lastUpdate = time.Now()
 		}
 	}
-	return nil
 }
 
 // calcResolvedTs call calcResolvedTs of every changefeeds
@@ -712,7 +918,7 @@ func (o *Owner) dispatchJob(ctx context.Context, job model.AdminJob) error {
 	// For `AdminResume`, we remove stopped feed in changefeed initialization phase.
 	// For `AdminRemove`, we need to update stoppedFeeds when removing a stopped changefeed.
 	if job.Type == model.AdminStop {
-		o.stoppedFeeds[job.CfID] = cf.status
+		o.addStoppedFeed(job.CfID, cf.status, cf.info)
 	}
 	delete(o.changeFeeds, job.CfID)
 	return nil
@@ -804,6 +1010,10 @@ func (o *Owner) handleAdminJob(ctx context.Context) error {
 				if err != nil {
 					return errors.Trace(err)
 				}
+				err = o.etcdClient.RemoveAllTableProgress(ctx, job.CfID)
+				if err != nil {
+					return errors.Trace(err)
+				}
 			} else {
 				log.Warn("invalid admin job, changefeed status not found", zap.String("changefeed", job.CfID))
 			}
@@ -813,6 +1023,23 @@ func (o *Owner) handleAdminJob(ctx context.Context) error {
 		case model.AdminStop:
 			switch feedState {
 			case model.StateStopped:
+				// the changefeed stopped by a retryable error isn't restarted
+				// automatically once it's paused by the user
+				if job.Error == nil {
+					cfInfo, err := o.etcdClient.GetChangeFeedInfo(ctx, job.CfID)
+					if err != nil {
+						return errors.Trace(err)
+					}
+					if cfInfo.Error != nil && cfInfo.Error.Retryable {
+						cfInfo.Error.Retryable = false
+						if err := o.etcdClient.SaveChangeFeedInfo(ctx, cfInfo, job.CfID); err != nil {
+							return errors.Trace(err)
+						}
+						log.Info("changefeed stopped by an error is paused, it won't be restarted automatically",
+							zap.String("changefeed", job.CfID))
+						continue
+					}
+				}
 				log.Info("changefeed has been stopped, pause command will do nothing")
 				continue
 			case model.StateRemoved:
@@ -828,9 +1055,9 @@ func (o *Owner) handleAdminJob(ctx context.Context) error {
 			}
 
 			cf.info.AdminJobType = model.AdminStop
-			cf.info.Error = job.Error
+			cf.info.Error = nil
 			if job.Error != nil {
-				cf.info.ErrorHis = append(cf.info.ErrorHis, time.Now().UnixNano()/1e6)
+				cf.info.RecordError(job.Error)
 			}
 			err := o.etcdClient.SaveChangeFeedInfo(ctx, cf.info, job.CfID)
 			if err != nil {
@@ -879,6 +1106,10 @@ func (o *Owner) handleAdminJob(ctx context.Context) error {
 			if err != nil {
 				return errors.Trace(err)
 			}
+			err = o.etcdClient.RemoveAllTableProgress(ctx, job.CfID)
+			if err != nil {
+				return errors.Trace(err)
+			}
 			if job.Opts != nil && job.Opts.ForceRemove {
 				// if `ForceRemove` is enabled, remove all information related to this changefeed
 				err := o.etcdClient.RemoveChangeFeedStatus(ctx, job.CfID)
@@ -907,6 +1138,44 @@ func (o *Owner) handleAdminJob(ctx context.Context) error {
 				return errors.Trace(err)
 			}
 
+			if job.Opts != nil && job.Opts.ResumeTs != 0 {
+				if cf != nil {
+					log.Warn("changefeed is running, it can't be resumed from a specified ts",
+						zap.String("changefeed", job.CfID), zap.Uint64("resume-ts", job.Opts.ResumeTs))
+					continue
+				}
+				// the resume ts is checked against the GC safepoint of the
+				// upstream of the changefeed
+				_, gcCli, err := o.upstreamClients(ctx, cfInfo.Upstream)
+				if err != nil {
+					log.Warn("invalid admin job, the upstream is unavailable",
+						zap.String("changefeed", job.CfID), zap.Error(err))
+					continue
+				}
+				if err := verifyStartTs(ctx, gcCli, job.Opts.ResumeTs); err != nil {
+					log.Warn("invalid admin job, the resume ts is invalid",
+						zap.String("changefeed", job.CfID), zap.Error(err))
+					continue
+				}
+				checkpointTs := status.CheckpointTs
+				status.CheckpointTs = job.Opts.ResumeTs
+				status.ResolvedTs = job.Opts.ResumeTs
+				// the table progress is later than the resume ts
+				err = o.etcdClient.RemoveAllTableProgress(ctx, job.CfID)
+				if err != nil {
+					return errors.Trace(err)
+				}
+				if job.Opts.SafeMode && job.Opts.ResumeTs < checkpointTs {
+					if cfInfo.Opts == nil {
+						cfInfo.Opts = make(map[string]string)
+					}
+					cfInfo.Opts[sink.OptSafeModeUntilTs] = strconv.FormatUint(checkpointTs, 10)
+				}
+				log.Info("changefeed is resumed from a specified ts",
+					zap.String("changefeed", job.CfID), zap.Uint64("checkpoint-ts", checkpointTs),
+					zap.Uint64("resume-ts", job.Opts.ResumeTs), zap.Bool("safe-mode", job.Opts.SafeMode))
+			}
+
 			// set admin job in changefeed status to tell owner resume changefeed
 			status.AdminJobType = model.AdminResume
 			err = o.etcdClient.PutChangeFeedStatus(ctx, job.CfID, status)
@@ -919,6 +1188,10 @@ func (o *Owner) handleAdminJob(ctx context.Context) error {
 			// clear last running error
 			cfInfo.State = model.StateNormal
 			cfInfo.Error = nil
+			// a changefeed resumed by the user is given a fresh retry budget
+			if job.Opts == nil || !job.Opts.AutoRestart {
+				cfInfo.ErrorHis = nil
+			}
 			err = o.etcdClient.SaveChangeFeedInfo(ctx, cfInfo, job.CfID)
 			if err != nil {
 				return errors.Trace(err)
@@ -975,6 +1248,7 @@ func (o *Owner) Run(ctx context.Context, tickTime time.Duration) error {
 
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
+	defer o.closeUpstreams()
 
 	if err := o.throne(ctx); err != nil {
 		return err
@@ -35,6 +35,7 @@ import (
 	"github.com/pingcap/ticdc/pkg/util"
 	"github.com/pingcap/tidb/meta"
 	"github.com/pingcap/tidb/store/mockstore"
+	"github.com/pingcap/tidb/store/tikv"
 	pd "github.com/tikv/pd/client"
 	"go.etcd.io/etcd/clientv3"
 	"go.etcd.io/etcd/embed"
@@ -99,6 +100,175 @@ func (s *ownerSuite) TestOwnerFlushChangeFeedInfos(c *check.C) {
 	c.Assert(mockPDCli.invokeCounter, check.Equals, 1)
 }
 
+type gcSafepointPDClient struct {
+	pd.Client
+	ttl       int64
+	safePoint uint64
+}
+
+func (m *gcSafepointPDClient) UpdateServiceGCSafePoint(ctx context.Context, serviceID string, ttl int64, safePoint uint64) (uint64, error) {
+	m.ttl, m.safePoint = ttl, safePoint
+	return 0, nil
+}
+
+func (s *ownerSuite) TestStoppedFeedGCTTL(c *check.C) {
+	pdCli := &gcSafepointPDClient{}
+	owner := &Owner{
+		pdClient:     pdCli,
+		gcTTL:        100,
+		changeFeeds:  make(map[model.ChangeFeedID]*changeFeed),
+		stoppedFeeds: make(map[model.ChangeFeedID]*stoppedFeed),
+	}
+	owner.addStoppedFeed("paused", &model.ChangeFeedStatus{CheckpointTs: 100}, &model.ChangeFeedInfo{GCTTL: 10})
+	owner.addStoppedFeed("failed", &model.ChangeFeedStatus{CheckpointTs: 50}, &model.ChangeFeedInfo{})
+	c.Assert(owner.stoppedFeeds["paused"].gcTTL, check.Equals, int64(10))
+	c.Assert(owner.stoppedFeeds["failed"].gcTTL, check.Equals, int64(100))
+
+	// the changefeeds hold the GC safepoint within their gc ttl
+	c.Assert(owner.flushChangeFeedInfos(s.ctx), check.IsNil)
+	c.Assert(pdCli.ttl, check.Equals, int64(100))
+	c.Assert(pdCli.safePoint, check.Equals, uint64(50))
+
+	// the GC safepoint is advanced once the gc ttl of a changefeed expires
+	owner.stoppedFeeds["failed"].since = time.Now().Add(-100 * time.Second)
+	owner.gcSafepointLastUpdate = time.Time{}
+	c.Assert(owner.flushChangeFeedInfos(s.ctx), check.IsNil)
+	c.Assert(pdCli.safePoint, check.Equals, uint64(100))
+	c.Assert(owner.stoppedFeeds["failed"].expired, check.IsTrue)
+
+	// it warns when the gc ttl is about to expire
+	owner.stoppedFeeds["paused"].since = time.Now().Add(-9500 * time.Millisecond)
+	owner.gcSafepointLastUpdate = time.Time{}
+	c.Assert(owner.flushChangeFeedInfos(s.ctx), check.IsNil)
+	c.Assert(pdCli.safePoint, check.Equals, uint64(100))
+	c.Assert(owner.stoppedFeeds["paused"].warned, check.IsTrue)
+
+	// the GC safepoint is removed once no changefeed holds it
+	owner.stoppedFeeds["paused"].since = time.Now().Add(-10 * time.Second)
+	c.Assert(owner.flushChangeFeedInfos(s.ctx), check.IsNil)
+	c.Assert(pdCli.ttl, check.Equals, int64(0))
+	c.Assert(pdCli.safePoint, check.Equals, uint64(0))
+	c.Assert(owner.gcSafepointLastUpdate.IsZero(), check.IsTrue)
+
+	// updating a recorded changefeed keeps the time it's stopped
+	since := owner.stoppedFeeds["paused"].since
+	owner.addStoppedFeed("paused", &model.ChangeFeedStatus{CheckpointTs: 100}, &model.ChangeFeedInfo{GCTTL: 20})
+	c.Assert(owner.stoppedFeeds["paused"].since, check.Equals, since)
+	c.Assert(owner.stoppedFeeds["paused"].gcTTL, check.Equals, int64(20))
+}
+
+func (s *ownerSuite) TestUpstreamGCSafepoint(c *check.C) {
+	pdCli := &gcSafepointPDClient{}
+	upstreamPDCli := &gcSafepointPDClient{}
+	upstream := &model.UpstreamInfo{PDAddrs: []string{"http://10.0.0.1:2379"}}
+	owner := &Owner{
+		pdClient:     pdCli,
+		gcTTL:        100,
+		changeFeeds:  make(map[model.ChangeFeedID]*changeFeed),
+		stoppedFeeds: make(map[model.ChangeFeedID]*stoppedFeed),
+		upstreams: map[string]*upstreamClient{
+			upstream.ID(): {info: upstream, pdClient: upstreamPDCli},
+		},
+		// the status of the changefeeds is not flushed
+		lastFlushChangefeeds:    time.Now(),
+		flushChangefeedInterval: time.Hour,
+	}
+	owner.changeFeeds["local"] = &changeFeed{
+		info:   &model.ChangeFeedInfo{},
+		status: &model.ChangeFeedStatus{CheckpointTs: 200},
+	}
+	owner.changeFeeds["remote"] = &changeFeed{
+		info:   &model.ChangeFeedInfo{Upstream: upstream},
+		status: &model.ChangeFeedStatus{CheckpointTs: 300},
+	}
+	owner.addStoppedFeed("remote-paused", &model.ChangeFeedStatus{CheckpointTs: 250}, &model.ChangeFeedInfo{Upstream: upstream})
+
+	// each upstream holds the min checkpoint of its changefeeds
+	c.Assert(owner.flushChangeFeedInfos(s.ctx), check.IsNil)
+	c.Assert(pdCli.safePoint, check.Equals, uint64(200))
+	c.Assert(upstreamPDCli.safePoint, check.Equals, uint64(250))
+
+	// the GC safepoint of the upstream is removed once no changefeed holds it
+	delete(owner.changeFeeds, "remote")
+	delete(owner.stoppedFeeds, "remote-paused")
+	c.Assert(owner.flushChangeFeedInfos(s.ctx), check.IsNil)
+	c.Assert(upstreamPDCli.safePoint, check.Equals, uint64(0))
+	c.Assert(upstreamPDCli.ttl, check.Equals, int64(0))
+	c.Assert(pdCli.safePoint, check.Equals, uint64(200))
+}
+
+func (s *ownerSuite) TestResumeTsCheckedByUpstream(c *check.C) {
+	upstreamURL, upstreamEtcd, err := etcd.SetupEmbedEtcd(c.MkDir())
+	c.Assert(err, check.IsNil)
+	defer upstreamEtcd.Close()
+	cli, err := clientv3.New(clientv3.Config{
+		Endpoints:   []string{upstreamURL.String()},
+		DialTimeout: 3 * time.Second,
+	})
+	c.Assert(err, check.IsNil)
+	upstreamCli := kv.NewCDCEtcdClient(s.ctx, cli)
+	defer cli.Close() //nolint:errcheck
+
+	upstream := &model.UpstreamInfo{PDAddrs: []string{upstreamURL.String()}}
+	owner := &Owner{
+		etcdClient:   s.client,
+		changeFeeds:  make(map[model.ChangeFeedID]*changeFeed),
+		stoppedFeeds: make(map[model.ChangeFeedID]*stoppedFeed),
+		upstreams: map[string]*upstreamClient{
+			upstream.ID(): {info: upstream, etcdClient: upstreamCli},
+		},
+	}
+	cfID := "remote"
+	c.Assert(s.client.SaveChangeFeedInfo(s.ctx, &model.ChangeFeedInfo{
+		SinkURI:      "blackhole://",
+		AdminJobType: model.AdminStop,
+		Upstream:     upstream,
+	}, cfID), check.IsNil)
+	c.Assert(s.client.PutChangeFeedStatus(s.ctx, cfID, &model.ChangeFeedStatus{
+		ResolvedTs:   300,
+		CheckpointTs: 300,
+		AdminJobType: model.AdminStop,
+	}), check.IsNil)
+	// only the upstream has a GC safepoint later than the resume ts
+	_, err = upstreamCli.Client.Put(s.ctx, tikv.GcSavedSafePoint, "250")
+	c.Assert(err, check.IsNil)
+
+	resume := func(ts uint64) *model.ChangeFeedStatus {
+		c.Assert(owner.EnqueueJob(model.AdminJob{
+			CfID: cfID,
+			Type: model.AdminResume,
+			Opts: &model.AdminJobOption{ResumeTs: ts},
+		}), check.IsNil)
+		c.Assert(owner.handleAdminJob(s.ctx), check.IsNil)
+		status, _, err := s.client.GetChangeFeedStatus(s.ctx, cfID)
+		c.Assert(err, check.IsNil)
+		return status
+	}
+	status := resume(200)
+	c.Assert(status.AdminJobType, check.Equals, model.AdminStop)
+	c.Assert(status.CheckpointTs, check.Equals, uint64(300))
+	status = resume(260)
+	c.Assert(status.AdminJobType, check.Equals, model.AdminResume)
+	c.Assert(status.CheckpointTs, check.Equals, uint64(260))
+}
+
+func (s *ownerSuite) TestMergeTableProgress(c *check.C) {
+	info := &model.ChangeFeedInfo{SinkURI: "blackhole://", CreateTime: time.Now()}
+	stale := &model.ChangeFeedInfo{SinkURI: "blackhole://", CreateTime: info.CreateTime.Add(-time.Hour)}
+	progress := map[string]*model.TableProgress{
+		"capture1": {SinkURI: info.SinkURI, CreateTime: info.CreateTime, Tables: map[model.TableID]model.Ts{1: 100, 2: 200}},
+		"capture2": {SinkURI: info.SinkURI, CreateTime: info.CreateTime, Tables: map[model.TableID]model.Ts{2: 150, 3: 300}},
+		"capture3": model.NewTableProgress(stale),
+	}
+	progress["capture3"].Tables[4] = 400
+	merged := mergeTableProgress(progress, info)
+	c.Assert(merged, check.DeepEquals, map[model.TableID]model.Ts{1: 100, 2: 200, 3: 300})
+
+	c.Assert(resumeTs(merged, 1, 120), check.Equals, uint64(120))
+	c.Assert(resumeTs(merged, 2, 120), check.Equals, uint64(200))
+	c.Assert(resumeTs(merged, 4, 120), check.Equals, uint64(120))
+}
+
 /*
 type handlerForPrueDMLTest struct {
 	mu               sync.RWMutex
@@ -486,7 +656,7 @@ func (s *ownerSuite) TestHandleAdmin(c *check.C) {
 		},
 	}
 	errCh := make(chan error, 1)
-	sink, err := sink.NewSink(ctx, cfID, "blackhole://", f, replicaConf, map[string]string{}, errCh)
+	sink, err := sink.NewSink(ctx, cfID, "blackhole://", f, replicaConf, nil, map[string]string{}, errCh)
 	c.Assert(err, check.IsNil)
 	sampleCF.sink = sink
 
@@ -550,6 +720,59 @@ func (s *ownerSuite) TestHandleAdmin(c *check.C) {
 	c.Assert(err, check.IsNil)
 	c.Assert(st.AdminJobType, check.Equals, model.AdminResume)
 
+	// the error history of a changefeed stopped by an error is kept when it's
+	// restarted by the owner, and cleared when it's resumed by the user
+	owner.changeFeeds[cfID] = sampleCF
+	c.Assert(owner.EnqueueJob(model.AdminJob{
+		CfID:  cfID,
+		Type:  model.AdminStop,
+		Error: &model.RunningError{Code: "CDC:ErrProcessorUnknown", Retryable: true},
+	}), check.IsNil)
+	c.Assert(owner.handleAdminJob(ctx), check.IsNil)
+	info, err = owner.etcdClient.GetChangeFeedInfo(ctx, cfID)
+	c.Assert(err, check.IsNil)
+	c.Assert(info.Error.Retryable, check.IsTrue)
+	c.Assert(info.ErrorHis, check.HasLen, 1)
+	c.Assert(owner.EnqueueJob(model.AdminJob{
+		CfID: cfID,
+		Type: model.AdminResume,
+		Opts: &model.AdminJobOption{AutoRestart: true},
+	}), check.IsNil)
+	c.Assert(owner.handleAdminJob(ctx), check.IsNil)
+	info, err = owner.etcdClient.GetChangeFeedInfo(ctx, cfID)
+	c.Assert(err, check.IsNil)
+	c.Assert(info.Error, check.IsNil)
+	c.Assert(info.ErrorHis, check.HasLen, 1)
+	c.Assert(owner.EnqueueJob(model.AdminJob{CfID: cfID, Type: model.AdminResume}), check.IsNil)
+	c.Assert(owner.handleAdminJob(ctx), check.IsNil)
+	info, err = owner.etcdClient.GetChangeFeedInfo(ctx, cfID)
+	c.Assert(err, check.IsNil)
+	c.Assert(info.ErrorHis, check.HasLen, 0)
+
+	// resume from a ts earlier than the checkpoint in safe mode
+	err = owner.etcdClient.PutChangeFeedStatus(ctx, cfID, &model.ChangeFeedStatus{
+		ResolvedTs:   300,
+		CheckpointTs: 300,
+		AdminJobType: model.AdminStop,
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(owner.EnqueueJob(model.AdminJob{
+		CfID: cfID,
+		Type: model.AdminResume,
+		Opts: &model.AdminJobOption{ResumeTs: 200, SafeMode: true},
+	}), check.IsNil)
+	c.Assert(owner.handleAdminJob(ctx), check.IsNil)
+	checkAdminJobLen(0)
+	info, err = owner.etcdClient.GetChangeFeedInfo(ctx, cfID)
+	c.Assert(err, check.IsNil)
+	c.Assert(info.AdminJobType, check.Equals, model.AdminResume)
+	c.Assert(info.Opts, check.DeepEquals, map[string]string{"_safe_mode_until_ts": "300"})
+	st, _, err = owner.etcdClient.GetChangeFeedStatus(ctx, cfID)
+	c.Assert(err, check.IsNil)
+	c.Assert(st.AdminJobType, check.Equals, model.AdminResume)
+	c.Assert(st.CheckpointTs, check.Equals, uint64(200))
+	c.Assert(st.ResolvedTs, check.Equals, uint64(200))
+
 	owner.changeFeeds[cfID] = sampleCF
 	c.Assert(owner.EnqueueJob(model.AdminJob{CfID: cfID, Type: model.AdminRemove}), check.IsNil)
 	c.Assert(owner.handleAdminJob(ctx), check.IsNil)
@@ -767,3 +990,210 @@ func (s *ownerSuite) TestChangefeedApplyDDLJob(c *check.C) {
 		c.Assert(cf.tables, check.DeepEquals, expectTables[i])
 	}
 }
+
+func (s *ownerSuite) TestChangefeedReloadConfig(c *check.C) {
+	newJob := func(tableID model.TableID, name string, schemaVersion int64) *timodel.Job {
+		return &timodel.Job{
+			ID:       tableID,
+			SchemaID: 1,
+			Type:     timodel.ActionCreateTable,
+			State:    timodel.JobStateSynced,
+			BinlogInfo: &timodel.HistoryInfo{
+				SchemaVersion: schemaVersion,
+				DBInfo:        &timodel.DBInfo{ID: 1, Name: timodel.NewCIStr("test")},
+				TableInfo: &timodel.TableInfo{
+					ID:         tableID,
+					Name:       timodel.NewCIStr(name),
+					PKIsHandle: true,
+					Columns: []*timodel.ColumnInfo{
+						{ID: 1, FieldType: types.FieldType{Flag: mysql.PriKeyFlag}, State: timodel.StatePublic},
+					},
+				},
+			},
+		}
+	}
+	jobs := []*timodel.Job{
+		{
+			ID:       1,
+			SchemaID: 1,
+			Type:     timodel.ActionCreateSchema,
+			State:    timodel.JobStateSynced,
+			BinlogInfo: &timodel.HistoryInfo{
+				SchemaVersion: 1,
+				DBInfo:        &timodel.DBInfo{ID: 1, Name: timodel.NewCIStr("test")},
+			},
+		},
+		newJob(47, "t1", 2),
+		newJob(49, "t2", 3),
+	}
+
+	store, err := mockstore.NewMockTikvStore()
+	c.Assert(err, check.IsNil)
+	defer func() {
+		_ = store.Close()
+	}()
+	txn, err := store.Begin()
+	c.Assert(err, check.IsNil)
+	defer func() {
+		_ = txn.Rollback()
+	}()
+	schemaSnap, err := entry.NewSingleSchemaSnapshotFromMeta(meta.NewMeta(txn), 0)
+	c.Assert(err, check.IsNil)
+
+	info := &model.ChangeFeedInfo{SinkURI: "blackhole://", Config: config.GetDefaultReplicaConfig()}
+	c.Assert(info.VerifyAndFix(), check.IsNil)
+	f, err := filter.NewFilter(info.Config)
+	c.Assert(err, check.IsNil)
+	primarySink, err := sink.NewSink(s.ctx, "test-cf", info.SinkURI, f, info.Config, nil, nil, make(chan error, 1))
+	c.Assert(err, check.IsNil)
+	cf := &changeFeed{
+		id:            "test-cf",
+		info:          info,
+		status:        &model.ChangeFeedStatus{CheckpointTs: 100},
+		schema:        schemaSnap,
+		schemas:       make(map[model.SchemaID]tableIDMap),
+		tables:        make(map[model.TableID]model.TableName),
+		partitions:    make(map[model.TableID][]int64),
+		orphanTables:  make(map[model.TableID]model.Ts),
+		toCleanTables: make(map[model.TableID]model.Ts),
+		filter:        f,
+		sink:          primarySink,
+	}
+	for _, job := range jobs {
+		c.Assert(cf.schema.HandleDDL(job), check.IsNil)
+		c.Assert(cf.schema.FillSchemaName(job), check.IsNil)
+		_, err = cf.applyJob(s.ctx, job)
+		c.Assert(err, check.IsNil)
+	}
+	// t1 is dispatched to a processor
+	delete(cf.orphanTables, 47)
+
+	// the tables ignored by the filter rules are removed
+	newInfo, err := info.Clone()
+	c.Assert(err, check.IsNil)
+	newInfo.Config.Filter.Rules = []string{"test.t2"}
+	newInfo.ConfigVersion = 1
+	c.Assert(cf.reloadConfig(s.ctx, newInfo), check.IsNil)
+	c.Assert(cf.info.ConfigVersion, check.Equals, uint64(1))
+	c.Assert(cf.tables, check.DeepEquals, map[model.TableID]model.TableName{49: {Schema: "test", Table: "t2"}})
+	c.Assert(cf.toCleanTables, check.DeepEquals, map[model.TableID]model.Ts{47: 100})
+
+	// the tables matching the filter rules are added once all the processors
+	// reload the config
+	newInfo, err = newInfo.Clone()
+	c.Assert(err, check.IsNil)
+	newInfo.Config.Filter.Rules = []string{"*.*"}
+	newInfo.ConfigVersion = 2
+	c.Assert(cf.reloadConfig(s.ctx, newInfo), check.IsNil)
+	cf.status.CheckpointTs = 200
+	cf.taskStatus = model.ProcessorsInfos{"capture-1": {}}
+	cf.taskPositions = map[model.CaptureID]*model.TaskPosition{"capture-1": {ConfigVersion: 1}}
+	cf.addReloadedTables()
+	c.Assert(cf.tables, check.HasLen, 1)
+	cf.taskPositions["capture-1"].ConfigVersion = 2
+	cf.addReloadedTables()
+	c.Assert(cf.tables, check.HasLen, 2)
+	c.Assert(cf.orphanTables, check.DeepEquals, map[model.TableID]model.Ts{47: 200, 49: 0})
+
+	// the replica config other than the filter rules, the sink config and
+	// the retry policy can't be reloaded
+	newInfo, err = newInfo.Clone()
+	c.Assert(err, check.IsNil)
+	newInfo.Config.EnableOldValue = true
+	newInfo.ConfigVersion = 3
+	err = cf.reloadConfig(s.ctx, newInfo)
+	c.Assert(cerror.ErrConfigNotReloadable.Equal(err), check.IsTrue)
+	c.Assert(cf.info.ConfigVersion, check.Equals, uint64(2))
+}
+
+func (s *ownerSuite) TestChangefeedDrainCaptures(c *check.C) {
+	cf := &changeFeed{
+		id: "test-drain",
+		taskStatus: model.ProcessorsInfos{
+			"capture-1": {},
+			"capture-2": {Tables: map[model.TableID]*model.TableReplicaInfo{1: {}}},
+			"capture-3": {Tables: map[model.TableID]*model.TableReplicaInfo{2: {}, 3: {}, 4: {}}},
+		},
+	}
+	captures := map[model.CaptureID]*model.CaptureInfo{
+		"capture-1": {ID: "capture-1"},
+		"capture-2": {ID: "capture-2"},
+	}
+	draining := map[model.CaptureID]*model.CaptureInfo{
+		"capture-3": {ID: "capture-3", Draining: true},
+	}
+
+	// no capture to move the tables to
+	cf.drainCaptures(nil, draining)
+	c.Assert(cf.manualMoveCommands, check.HasLen, 0)
+
+	cf.drainCaptures(captures, draining)
+	c.Assert(cf.manualMoveCommands, check.HasLen, 3)
+	targets := make(map[model.CaptureID]int)
+	for _, job := range cf.manualMoveCommands {
+		targets[job.To]++
+	}
+	c.Assert(targets, check.DeepEquals, map[model.CaptureID]int{"capture-1": 2, "capture-2": 1})
+
+	// the jobs are generated once the previous ones are finished
+	cf.drainCaptures(captures, draining)
+	c.Assert(cf.manualMoveCommands, check.HasLen, 3)
+	c.Assert(cf.handleManualMoveTableJobs(s.ctx, captures), check.IsNil)
+	c.Assert(cf.moveTableJobs, check.HasLen, 3)
+	for _, job := range cf.moveTableJobs {
+		c.Assert(job.From, check.Equals, "capture-3")
+	}
+	cf.drainCaptures(captures, draining)
+	c.Assert(cf.manualMoveCommands, check.HasLen, 0)
+}
+
+func (s *ownerSuite) TestChangefeedHandleFailedTables(c *check.C) {
+	cf := &changeFeed{
+		id:     "test-failed-tables",
+		status: &model.ChangeFeedStatus{CheckpointTs: 100},
+		taskStatus: model.ProcessorsInfos{
+			"capture-1": {
+				Tables:       map[model.TableID]*model.TableReplicaInfo{1: {}, 2: {}},
+				FailedTables: map[model.TableID]*model.TableFailure{1: {Message: "panic"}},
+			},
+			"capture-2": {Tables: map[model.TableID]*model.TableReplicaInfo{3: {}, 4: {}}},
+			"capture-3": {Tables: map[model.TableID]*model.TableReplicaInfo{5: {}}},
+		},
+	}
+	captures := map[model.CaptureID]*model.CaptureInfo{
+		"capture-1": {ID: "capture-1"},
+		"capture-2": {ID: "capture-2"},
+		"capture-3": {ID: "capture-3"},
+	}
+
+	// the failed table is moved to the capture with the fewest tables
+	cf.handleFailedTables(captures)
+	c.Assert(cf.moveTableJobs, check.DeepEquals, map[model.TableID]*model.MoveTableJob{
+		1: {TableID: 1, From: "capture-1", To: "capture-3"},
+	})
+	c.Assert(cf.tableFailures[1], check.Equals, 1)
+	// the failure is handled once until the job is finished
+	cf.handleFailedTables(captures)
+	c.Assert(cf.tableFailures[1], check.Equals, 1)
+
+	// the failed table is added back to the same capture if it's the only one
+	cf.moveTableJobs = nil
+	cf.handleFailedTables(map[model.CaptureID]*model.CaptureInfo{"capture-1": {ID: "capture-1"}})
+	c.Assert(cf.moveTableJobs[1].To, check.Equals, "capture-1")
+	c.Assert(cf.tableFailures[1], check.Equals, 2)
+
+	// the table is quarantined after maxTableFailures failures
+	cf.moveTableJobs = nil
+	cf.handleFailedTables(captures)
+	c.Assert(cf.tableFailures[1], check.Equals, maxTableFailures)
+	c.Assert(cf.moveTableJobs[1].To, check.Equals, "")
+	c.Assert(cf.quarantinedTables, check.DeepEquals, map[model.TableID]model.Ts{1: 100})
+
+	// the quarantined table is not added back once it's removed
+	cf.taskStatus["capture-1"] = &model.TaskStatus{Tables: map[model.TableID]*model.TableReplicaInfo{2: {}}}
+	cf.moveTableJobs[1].Status = model.MoveTableStatusDeleted
+	cf.moveTableJobs[1].TableReplicaInfo = &model.TableReplicaInfo{StartTs: 100}
+	c.Assert(cf.handleMoveTableJobs(s.ctx, captures), check.IsNil)
+	c.Assert(cf.moveTableJobs, check.HasLen, 0)
+	c.Assert(cf.orphanTables, check.HasLen, 0)
+}
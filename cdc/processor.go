@@ -17,7 +17,10 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"math"
 	"os"
+	"runtime/debug"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -34,12 +37,16 @@ import (
 	"github.com/pingcap/ticdc/cdc/model"
 	"github.com/pingcap/ticdc/cdc/puller"
 	"github.com/pingcap/ticdc/cdc/sink"
+	"github.com/pingcap/ticdc/pkg/buckets"
+	"github.com/pingcap/ticdc/pkg/config"
 	cerror "github.com/pingcap/ticdc/pkg/errors"
 	"github.com/pingcap/ticdc/pkg/filter"
+	"github.com/pingcap/ticdc/pkg/logutil"
 	"github.com/pingcap/ticdc/pkg/notify"
 	"github.com/pingcap/ticdc/pkg/regionspan"
 	"github.com/pingcap/ticdc/pkg/retry"
 	"github.com/pingcap/ticdc/pkg/security"
+	"github.com/pingcap/ticdc/pkg/tracing"
 	"github.com/pingcap/ticdc/pkg/util"
 	tidbkv "github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/store/tikv/oracle"
@@ -48,7 +55,6 @@ import (
 	"go.etcd.io/etcd/clientv3/concurrency"
 	"go.etcd.io/etcd/mvcc"
 	"go.uber.org/zap"
-	"golang.org/x/sync/errgroup"
 )
 
 const (
@@ -61,7 +67,21 @@ const (
 	// defaultMemBufferCapacity is the default memory buffer per change feed.
 	defaultMemBufferCapacity int64 = 10 * 1024 * 1024 * 1024 // 10G
 
+	// defaultTableMemQuota is the default memory quota of the events buffered
+	// by a table, including the events in puller, sorter and sink queue.
+	defaultTableMemQuota int64 = 512 * 1024 * 1024 // 512M
+	// memQuotaWaitTimeout is the max time a table is blocked by a memory quota
+	// before it overcommits, see puller.MemoryQuota.
+	memQuotaWaitTimeout = 10 * time.Second
+
 	defaultSyncResolvedBatch = 1024
+
+	// tableProgressFlushInterval is the interval the progress of the tables is
+	// persisted, see model.TableProgress.
+	tableProgressFlushInterval = 10 * time.Second
+	// tableLagUpdateInterval is the interval the progress metrics of the
+	// tables and the lagging tables in the task position are updated.
+	tableLagUpdateInterval = time.Second
 )
 
 var (
@@ -72,9 +92,14 @@ type processor struct {
 	id           string
 	captureInfo  model.CaptureInfo
 	changefeedID string
-	changefeed   model.ChangeFeedInfo
-	limitter     *puller.BlurResourceLimitter
-	stopped      int32
+	// changefeed is the info the processor is started with, the reloadable
+	// config is only used by the sink, which is replaced once it's reloaded.
+	changefeed model.ChangeFeedInfo
+	limitter   *puller.BlurResourceLimitter
+	memQuota   *buckets.BucketGroup
+	// sorterMemQuota is shared by the sorters of all the processors in a capture
+	sorterMemQuota *buckets.Bucket
+	stopped        int32
 
 	pdCli      pd.Client
 	credential *security.Credential
@@ -82,7 +107,16 @@ type processor struct {
 	etcdCli    kv.CDCEtcdClient
 	session    *concurrency.Session
 
-	sink sink.Sink
+	// sinkMu protects the sink from being replaced by syncResolved while it's
+	// flushed by sinkDriver.
+	sinkMu sync.Mutex
+	sink   sink.Sink
+	// filter applies the row filters to the rows before they're emitted to
+	// the sink, it's only accessed by syncResolved.
+	filter *filter.Filter
+	// configVersion is the version of the changefeed config used by the sink
+	configVersion  uint64
+	configReloadCh chan *configReload
 
 	sinkEmittedResolvedTs   uint64
 	globalResolvedTs        uint64
@@ -111,24 +145,50 @@ type processor struct {
 	localCheckpointTsNotifier   *notify.Notifier
 	localCheckpointTsReceiver   *notify.Receiver
 
-	wg       *errgroup.Group
-	errCh    chan<- error
+	wg       *util.SafeGroup
+	errCh    chan error
 	opDoneCh chan int64
 }
 
+// configReload is the updated changefeed config and the sink and the filter
+// created with it, which replace the current ones at a resolved ts boundary.
+type configReload struct {
+	info   model.ChangeFeedInfo
+	sink   sink.Sink
+	filter *filter.Filter
+}
+
 type tableInfo struct {
 	id          int64
 	name        string // quoted schema and table, used in metircs only
+	startTs     uint64
 	resolvedTs  uint64
 	markTableID int64
 	mResolvedTs uint64
 	sorter      *puller.Rectifier
+	memQuota    *buckets.Bucket
 	workload    model.WorkloadInfo
 	cancel      context.CancelFunc
 	// isDying shows that the table is being removed.
 	// In the case the same table is added back before safe removal is finished,
 	// this flag is used to tell whether it's safe to kill the table.
 	isDying uint32
+	// pullers are the pullers of the table and its mark table
+	pullers map[model.TableID]puller.Puller
+	// failed is set once a goroutine of the table panics, the failed table is
+	// stopped and waits for being removed by the owner.
+	failed  uint32
+	failure atomic.Value
+}
+
+// loadFailure returns the panic the table is failed with, nil if the table is
+// not failed.
+func (t *tableInfo) loadFailure() *model.TableFailure {
+	if atomic.LoadUint32(&t.failed) == 0 {
+		return nil
+	}
+	failure, _ := t.failure.Load().(*model.TableFailure)
+	return failure
 }
 
 func (t *tableInfo) loadResolvedTs() uint64 {
@@ -165,10 +225,18 @@ func newProcessor(
 	checkpointTs uint64,
 	errCh chan error,
 	flushCheckpointInterval time.Duration,
+	sorterMemQuota *buckets.Bucket,
 ) (*processor, error) {
 	etcdCli := session.Client()
 	endpoints := session.Client().Endpoints()
-	pdCli, err := fNewPDCli(ctx, endpoints, credential.PDSecurityOption())
+	// the changefeed replicates from another upstream cluster, the task
+	// status and position are still stored in the cluster of the capture.
+	if changefeed.Upstream != nil {
+		endpoints = changefeed.Upstream.PDAddrs
+		credential = changefeed.Upstream.GetCredential()
+	}
+	pdSecurity, pdDialOption := credential.PDClientOptions()
+	pdCli, err := fNewPDCli(ctx, endpoints, pdSecurity, pd.WithGRPCDialOptions(pdDialOption))
 	if err != nil {
 		return nil, errors.Annotatef(
 			cerror.WrapError(cerror.ErrNewProcessorFailed, err), "create pd client failed, addr: %v", endpoints)
@@ -196,23 +264,28 @@ func newProcessor(
 	localResolvedNotifier := new(notify.Notifier)
 	localCheckpointTsNotifier := new(notify.Notifier)
 	p := &processor{
-		id:            uuid.New().String(),
-		limitter:      limitter,
-		captureInfo:   captureInfo,
-		changefeedID:  changefeedID,
-		changefeed:    changefeed,
-		pdCli:         pdCli,
-		credential:    credential,
-		kvStorage:     kvStorage,
-		etcdCli:       cdcEtcdCli,
-		session:       session,
-		sink:          sink,
-		ddlPuller:     ddlPuller,
-		mounter:       entry.NewMounter(schemaStorage, changefeed.Config.Mounter.WorkerNum, changefeed.Config.EnableOldValue),
-		schemaStorage: schemaStorage,
-		errCh:         errCh,
-
-		position: &model.TaskPosition{CheckPointTs: checkpointTs},
+		id:             uuid.New().String(),
+		limitter:       limitter,
+		memQuota:       buckets.NewBucketGroup(math.MaxInt64, time.Second),
+		sorterMemQuota: sorterMemQuota,
+		captureInfo:    captureInfo,
+		changefeedID:   changefeedID,
+		changefeed:     changefeed,
+		pdCli:          pdCli,
+		credential:     credential,
+		kvStorage:      kvStorage,
+		etcdCli:        cdcEtcdCli,
+		session:        session,
+		sink:           sink,
+		filter:         filter,
+		configVersion:  changefeed.ConfigVersion,
+		configReloadCh: make(chan *configReload),
+		ddlPuller:      ddlPuller,
+		mounter:        entry.NewMounter(schemaStorage, changefeed.Config.Mounter.WorkerNum, changefeed.Config.EnableOldValue),
+		schemaStorage:  schemaStorage,
+		errCh:          errCh,
+
+		position: &model.TaskPosition{CheckPointTs: checkpointTs, ConfigVersion: changefeed.ConfigVersion},
 		output:   make(chan *model.PolymorphicEvent, defaultOutputChanSize),
 
 		sinkEmittedResolvedNotifier: sinkEmittedResolvedNotifier,
@@ -244,7 +317,9 @@ func newProcessor(
 }
 
 func (p *processor) Run(ctx context.Context) {
-	wg, cctx := errgroup.WithContext(ctx)
+	// the panics of the workers, e.g. the ones driving the sink shared by the
+	// tables, fail the processor instead of the capture
+	wg, cctx := util.SafeGroupWithContext(ctx)
 	p.wg = wg
 	ddlPullerCtx, ddlPullerCancel :=
 		context.WithCancel(util.PutTableInfoInCtx(cctx, 0, "ticdc-processor-ddl"))
@@ -266,6 +341,10 @@ func (p *processor) Run(ctx context.Context) {
 		return p.syncResolved(cctx)
 	})
 
+	wg.Go(func() error {
+		return p.configWorker(cctx)
+	})
+
 	wg.Go(func() error {
 		return p.collectMetrics(cctx)
 	})
@@ -321,6 +400,19 @@ func (p *processor) writeDebugInfo(w io.Writer) {
 	fmt.Fprintf(w, "\n")
 }
 
+// diagnoseResolvedTs returns the resolved ts diagnoses of the table pullers
+func (p *processor) diagnoseResolvedTs() map[model.TableID]puller.ResolvedTsDiagnosis {
+	p.stateMu.Lock()
+	defer p.stateMu.Unlock()
+	diagnoses := make(map[model.TableID]puller.ResolvedTsDiagnosis)
+	for _, table := range p.tables {
+		for tableID, plr := range table.pullers {
+			diagnoses[tableID] = plr.DiagnoseResolvedTs()
+		}
+	}
+	return diagnoses
+}
+
 // localResolvedWorker do the flowing works.
 // 1, update resolve ts by scanning all table's resolve ts.
 // 2, update checkpoint ts by consuming entry from p.executedTxns.
@@ -376,10 +468,23 @@ func (p *processor) positionWorker(ctx context.Context) error {
 	metricResolvedTsLagGauge := resolvedTsLagGauge.WithLabelValues(p.changefeedID, p.captureInfo.AdvertiseAddr)
 	checkpointTsGauge := checkpointTsGauge.WithLabelValues(p.changefeedID, p.captureInfo.AdvertiseAddr)
 	metricCheckpointTsLagGauge := checkpointTsLagGauge.WithLabelValues(p.changefeedID, p.captureInfo.AdvertiseAddr)
+	progressTicker := time.NewTicker(tableProgressFlushInterval)
+	defer progressTicker.Stop()
+	tableLagTicker := time.NewTicker(tableLagUpdateInterval)
+	defer tableLagTicker.Stop()
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
+		case <-tableLagTicker.C:
+			p.updateTableLags()
+		case <-progressTicker.C:
+			// the progress only speeds up restarting, so failing to persist
+			// it isn't an error of the processor.
+			if err := p.flushTableProgress(ctx); err != nil && errors.Cause(err) != context.Canceled {
+				log.Warn("failed to flush table progress",
+					zap.String("changefeed", p.changefeedID), zap.Error(err))
+			}
 		case <-p.localResolvedReceiver.C:
 			minResolvedTs := p.ddlPuller.GetResolvedTs()
 			p.stateMu.Lock()
@@ -421,6 +526,7 @@ func (p *processor) positionWorker(ctx context.Context) error {
 			}
 
 			p.position.CheckPointTs = checkpointTs
+			p.position.ConfigVersion = atomic.LoadUint64(&p.configVersion)
 			checkpointTsGauge.Set(float64(phyTs))
 			if err := retryFlushTaskStatusAndPosition(); err != nil {
 				return errors.Trace(err)
@@ -430,6 +536,52 @@ func (p *processor) positionWorker(ctx context.Context) error {
 	}
 }
 
+// updateTableLags updates the progress metrics of the tables, and records the
+// tables with the least resolved ts in the task position, which is reported to
+// the owner with the position.
+func (p *processor) updateTableLags() {
+	checkpointTs := atomic.LoadUint64(&p.checkpointTs)
+	now := oracle.GetPhysical(time.Now())
+	p.stateMu.Lock()
+	lags := make([]*model.TableLag, 0, len(p.tables))
+	for id, table := range p.tables {
+		lag := &model.TableLag{
+			TableID:      id,
+			TableName:    table.name,
+			CheckPointTs: tableCheckpointTs(table, checkpointTs),
+			ResolvedTs:   table.loadResolvedTs(),
+		}
+		lags = append(lags, lag)
+		checkpointPhyTs := oracle.ExtractPhysical(lag.CheckPointTs)
+		tableResolvedTsLagGauge.WithLabelValues(p.changefeedID, p.captureInfo.AdvertiseAddr, table.name).
+			Set(float64(now-oracle.ExtractPhysical(lag.ResolvedTs)) / 1e3)
+		tableCheckpointTsGauge.WithLabelValues(p.changefeedID, p.captureInfo.AdvertiseAddr, table.name).
+			Set(float64(checkpointPhyTs))
+		tableCheckpointTsLagGauge.WithLabelValues(p.changefeedID, p.captureInfo.AdvertiseAddr, table.name).
+			Set(float64(now-checkpointPhyTs) / 1e3)
+	}
+	p.stateMu.Unlock()
+	sort.Slice(lags, func(i, j int) bool {
+		if lags[i].ResolvedTs != lags[j].ResolvedTs {
+			return lags[i].ResolvedTs < lags[j].ResolvedTs
+		}
+		return lags[i].TableID < lags[j].TableID
+	})
+	if len(lags) > model.MaxLaggingTables {
+		lags = lags[:model.MaxLaggingTables]
+	}
+	p.position.LaggingTables = lags
+}
+
+// tableCheckpointTs returns the checkpoint of the table, which is the start ts
+// of the table if it's added after the checkpoint of the processor.
+func tableCheckpointTs(table *tableInfo, checkpointTs uint64) uint64 {
+	if table.startTs > checkpointTs {
+		return table.startTs
+	}
+	return checkpointTs
+}
+
 func (p *processor) ddlPullWorker(ctx context.Context) error {
 	ddlRawKVCh := puller.SortOutput(ctx, p.ddlPuller.Output())
 	var ddlRawKV *model.RawKVEntry
@@ -508,6 +660,29 @@ func (p *processor) flushTaskPosition(ctx context.Context) error {
 	return nil
 }
 
+// flushTableProgress persists the positions of the tables, up to which the
+// events are flushed to the sink. It's the checkpoint of the processor, or the
+// start ts of a table added after the checkpoint, rather than the resolved ts
+// of the puller, as the events buffered in the processor are lost when it
+// restarts.
+func (p *processor) flushTableProgress(ctx context.Context) error {
+	if p.isStopped() {
+		return nil
+	}
+	progress := model.NewTableProgress(&p.changefeed)
+	p.stateMu.Lock()
+	checkpointTs := p.position.CheckPointTs
+	for id, table := range p.tables {
+		ts := tableCheckpointTs(table, checkpointTs)
+		progress.Tables[id] = ts
+	}
+	p.stateMu.Unlock()
+	if checkpointTs == 0 || len(progress.Tables) == 0 {
+		return nil
+	}
+	return errors.Trace(p.etcdCli.PutTableProgress(ctx, p.changefeedID, p.captureInfo.ID, progress))
+}
+
 // First try to synchronize task status from etcd.
 // If local cached task status is outdated (caused by new table scheduling),
 // update it to latest value, and force update task position, since add new
@@ -521,7 +696,8 @@ func (p *processor) flushTaskStatusAndPosition(ctx context.Context) error {
 		func(modRevision int64, taskStatus *model.TaskStatus) (bool, error) {
 			// if the task status is not changed and not operation to handle
 			// we need not to change the task status
-			if p.statusModRevision == modRevision && !taskStatus.SomeOperationsUnapplied() {
+			if p.statusModRevision == modRevision && !taskStatus.SomeOperationsUnapplied() &&
+				!p.reportFailedTables(taskStatus) {
 				return false, nil
 			}
 			if taskStatus.AdminJobType.IsStopState() {
@@ -531,6 +707,7 @@ func (p *processor) flushTaskStatusAndPosition(ctx context.Context) error {
 				}
 				return false, backoff.Permanent(cerror.ErrAdminStopProcessor.GenWithStackByArgs())
 			}
+			p.reportFailedTables(taskStatus)
 			toRemove, err := p.handleTables(ctx, taskStatus)
 			tablesToRemove = append(tablesToRemove, toRemove...)
 			if err != nil {
@@ -576,11 +753,15 @@ func (p *processor) removeTable(tableID int64) {
 		return
 	}
 	table.cancel()
+	table.memQuota.Close()
 	delete(p.tables, tableID)
 	if table.markTableID != 0 {
 		delete(p.markTableIDs, table.markTableID)
 	}
 	tableResolvedTsGauge.DeleteLabelValues(p.changefeedID, p.captureInfo.AdvertiseAddr, table.name)
+	tableResolvedTsLagGauge.DeleteLabelValues(p.changefeedID, p.captureInfo.AdvertiseAddr, table.name)
+	tableCheckpointTsGauge.DeleteLabelValues(p.changefeedID, p.captureInfo.AdvertiseAddr, table.name)
+	tableCheckpointTsLagGauge.DeleteLabelValues(p.changefeedID, p.captureInfo.AdvertiseAddr, table.name)
 	syncTableNumGauge.WithLabelValues(p.changefeedID, p.captureInfo.AdvertiseAddr).Dec()
 }
 
@@ -599,6 +780,16 @@ func (p *processor) handleTables(ctx context.Context, status *model.TaskStatus)
 					opt.Status = model.OperFinished
 					continue
 				}
+				if table.loadFailure() != nil {
+					// the goroutines of the failed table are stopped already,
+					// the owner adds it back at the boundary ts.
+					atomic.StoreUint32(&table.isDying, 1)
+					tablesToRemove = append(tablesToRemove, tableID)
+					delete(status.FailedTables, tableID)
+					opt.Done = true
+					opt.Status = model.OperFinished
+					continue
+				}
 				stopped, checkpointTs := table.safeStop()
 				log.Debug("safeStop table", zap.Int64("tableID", tableID),
 					zap.Bool("stopped", stopped), zap.Uint64("checkpointTs", checkpointTs))
@@ -646,9 +837,78 @@ done:
 	if !status.SomeOperationsUnapplied() {
 		status.Operation = nil
 	}
+	if len(status.FailedTables) == 0 {
+		status.FailedTables = nil
+	}
 	return tablesToRemove, nil
 }
 
+// reportFailedTables adds the failed tables to the task status, returns
+// whether the task status is changed.
+func (p *processor) reportFailedTables(status *model.TaskStatus) bool {
+	p.stateMu.Lock()
+	defer p.stateMu.Unlock()
+	changed := false
+	for tableID, table := range p.tables {
+		failure := table.loadFailure()
+		if failure == nil {
+			continue
+		}
+		if _, exist := status.Tables[tableID]; !exist {
+			continue
+		}
+		if _, exist := status.FailedTables[tableID]; exist {
+			continue
+		}
+		if status.FailedTables == nil {
+			status.FailedTables = make(map[model.TableID]*model.TableFailure)
+		}
+		status.FailedTables[tableID] = failure.Clone()
+		changed = true
+	}
+	return changed
+}
+
+// recoverTable recovers the panic in a goroutine of the table, it must be
+// deferred directly. The table is stopped and marked failed, so that the
+// other tables keep replicating and the owner reschedules the failed one.
+func (p *processor) recoverTable(table *tableInfo) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	p.failTable(table, &util.PanicError{Value: r, Stack: string(debug.Stack())})
+}
+
+// handleTableErr handles the error returned by a goroutine of the table, the
+// panics recovered by the goroutines the puller and the sorter spawn fail the
+// table only, and the other errors except the cancellation fail the processor.
+func (p *processor) handleTableErr(table *tableInfo, err error) {
+	if panicErr, ok := errors.Cause(err).(*util.PanicError); ok {
+		p.failTable(table, panicErr)
+		return
+	}
+	if errors.Cause(err) != context.Canceled {
+		p.errCh <- err
+	}
+}
+
+// failTable stops the table by the panic and marks it failed
+func (p *processor) failTable(table *tableInfo, panicErr *util.PanicError) {
+	log.Error("table is stopped by a panic",
+		zap.String("changefeed", p.changefeedID),
+		zap.Int64("tableID", table.id),
+		zap.String("name", table.name),
+		zap.Reflect("panic", panicErr.Value),
+		zap.String("stack", panicErr.Stack))
+	table.cancel()
+	if !atomic.CompareAndSwapUint32(&table.failed, 0, 1) {
+		return
+	}
+	table.failure.Store(&model.TableFailure{Message: fmt.Sprintf("%v", panicErr.Value), Time: time.Now()})
+	tableFailedCounter.WithLabelValues(p.changefeedID, p.captureInfo.AdvertiseAddr).Inc()
+}
+
 // globalStatusWorker read global resolve ts from changefeed level info and forward `tableInputChans` regularly.
 func (p *processor) globalStatusWorker(ctx context.Context) error {
 	log.Info("Global status worker started")
@@ -773,7 +1033,9 @@ func (p *processor) sinkDriver(ctx context.Context) error {
 			}
 			start := time.Now()
 
+			p.sinkMu.Lock()
 			checkpointTs, err := p.sink.FlushRowChangedEvents(ctx, minTs)
+			p.sinkMu.Unlock()
 			if err != nil {
 				return errors.Trace(err)
 			}
@@ -793,7 +1055,23 @@ func (p *processor) sinkDriver(ctx context.Context) error {
 
 // syncResolved handle `p.ddlJobsCh` and `p.resolvedTxns`
 func (p *processor) syncResolved(ctx context.Context) error {
+	var (
+		resolvedTs uint64
+		// maxEmittedTs is the max CRTs of the events emitted to the sink.
+		maxEmittedTs uint64
+		// reload is the pending config reload, the events after reloadTs are
+		// held until the sink is replaced.
+		reload     *configReload
+		reloadTs   uint64
+		heldEvents []*model.PolymorphicEvent
+		reloadCh   = p.configReloadCh
+	)
 	defer func() {
+		if reload != nil {
+			if err := reload.sink.Close(); err != nil {
+				log.Warn("failed to close the sink of the changefeed config reload", zap.Error(err))
+			}
+		}
 		p.sinkEmittedResolvedReceiver.Stop()
 		log.Info("syncResolved stopped")
 	}()
@@ -810,7 +1088,16 @@ func (p *processor) syncResolved(ctx context.Context) error {
 			if ev.Row == nil {
 				continue
 			}
-			rows = append(rows, ev.Row)
+			row, err := p.filter.FilterRow(ev.Row)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			if row == nil {
+				ev.RawKV.Trace.Finish()
+				continue
+			}
+			ev.RawKV.Trace.StartStage(tracing.StageSink)
+			rows = append(rows, row)
 		}
 		failpoint.Inject("ProcessorSyncResolvedPreEmit", func() {
 			log.Info("Prepare to panic for ProcessorSyncResolvedPreEmit")
@@ -821,6 +1108,9 @@ func (p *processor) syncResolved(ctx context.Context) error {
 		if err != nil {
 			return errors.Trace(err)
 		}
+		for _, ev := range events {
+			ev.RawKV.Trace.Finish()
+		}
 		events = events[:0]
 		rows = rows[:0]
 		return nil
@@ -828,6 +1118,9 @@ func (p *processor) syncResolved(ctx context.Context) error {
 
 	processRowChangedEvent := func(row *model.PolymorphicEvent) error {
 		events = append(events, row)
+		if row.CRTs > maxEmittedTs {
+			maxEmittedTs = row.CRTs
+		}
 
 		if len(events) >= defaultSyncResolvedBatch {
 			err := flushRowChangedEvents()
@@ -838,11 +1131,23 @@ func (p *processor) syncResolved(ctx context.Context) error {
 		return nil
 	}
 
-	var resolvedTs uint64
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
+		case reload = <-reloadCh:
+			// The events emitted to the current sink can't be taken back, so
+			// the sink is replaced once it's flushed after all of them, and
+			// the events after them are held until then.
+			reloadCh = nil
+			reloadTs = maxEmittedTs
+			if resolvedTs > reloadTs {
+				reloadTs = resolvedTs
+			}
+			log.Info("prepare to reload the changefeed config",
+				zap.String("changefeed", p.changefeedID),
+				zap.Uint64("configVersion", reload.info.ConfigVersion),
+				zap.Uint64("reloadTs", reloadTs))
 		case row := <-p.output:
 			if row == nil {
 				continue
@@ -855,6 +1160,21 @@ func (p *processor) syncResolved(ctx context.Context) error {
 				if err != nil {
 					return errors.Trace(err)
 				}
+				if reload != nil && row.CRTs >= reloadTs {
+					if err := p.reloadConfig(ctx, reload, row.CRTs); err != nil {
+						return errors.Trace(err)
+					}
+					reload, reloadCh = nil, p.configReloadCh
+					for _, ev := range heldEvents {
+						if err := processRowChangedEvent(ev); err != nil {
+							return errors.Trace(err)
+						}
+					}
+					heldEvents = nil
+					if err := flushRowChangedEvents(); err != nil {
+						return errors.Trace(err)
+					}
+				}
 				resolvedTs = row.CRTs
 				atomic.StoreUint64(&p.sinkEmittedResolvedTs, row.CRTs)
 				p.sinkEmittedResolvedNotifier.Notify()
@@ -877,7 +1197,11 @@ func (p *processor) syncResolved(ctx context.Context) error {
 					zap.String("model", "processor"),
 					zap.String("changefeed", p.changefeedID),
 					zap.Uint64("resolvedTs", resolvedTs),
-					zap.Any("row", row))
+					logutil.ZapRedactReflect("row", row))
+			}
+			if reload != nil && row.CRTs > reloadTs {
+				heldEvents = append(heldEvents, row)
+				continue
 			}
 			err := processRowChangedEvent(row)
 			if err != nil {
@@ -898,6 +1222,106 @@ func (p *processor) collectMetrics(ctx context.Context) error {
 	}
 }
 
+// reloadConfig replaces the sink and the filter with the ones created with the
+// updated config, after all the events before resolvedTs are flushed to the
+// current sink.
+func (p *processor) reloadConfig(ctx context.Context, reload *configReload, resolvedTs uint64) error {
+	p.sinkMu.Lock()
+	defer p.sinkMu.Unlock()
+	checkpointTs, err := p.sink.FlushRowChangedEvents(ctx, resolvedTs)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err := p.sink.Close(); err != nil {
+		log.Warn("failed to close the sink", zap.String("changefeed", p.changefeedID), zap.Error(err))
+	}
+	p.sink = reload.sink
+	p.filter = reload.filter
+	atomic.StoreUint64(&p.configVersion, reload.info.ConfigVersion)
+	if checkpointTs != 0 {
+		atomic.StoreUint64(&p.checkpointTs, checkpointTs)
+	}
+	p.localCheckpointTsNotifier.Notify()
+	log.Info("changefeed config reloaded",
+		zap.String("changefeed", p.changefeedID),
+		zap.Uint64("configVersion", reload.info.ConfigVersion),
+		zap.Uint64("resolvedTs", resolvedTs))
+	return nil
+}
+
+// configWorker watches the changefeed info, and creates the sink and the filter
+// with the updated config once the config version is increased, which are
+// handed over to syncResolved to replace the current ones.
+func (p *processor) configWorker(ctx context.Context) error {
+	info := p.changefeed
+	watchKey := kv.GetEtcdKeyChangeFeedInfo(p.changefeedID)
+
+	handleUpdate := func(value []byte) error {
+		newInfo := new(model.ChangeFeedInfo)
+		if err := newInfo.Unmarshal(value); err != nil {
+			return errors.Trace(err)
+		}
+		if newInfo.ConfigVersion <= info.ConfigVersion {
+			return nil
+		}
+		if err := newInfo.VerifyAndFix(); err != nil {
+			return errors.Trace(err)
+		}
+		if err := info.VerifyHotReload(newInfo); err != nil {
+			return errors.Trace(err)
+		}
+		newFilter, err := filter.NewFilter(newInfo.Config)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		newSink, err := newProcessorSink(ctx, *newInfo, p.changefeedID, p.captureInfo, p.errCh)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		select {
+		case <-ctx.Done():
+			if err := newSink.Close(); err != nil {
+				log.Warn("failed to close the sink", zap.String("changefeed", p.changefeedID), zap.Error(err))
+			}
+			return ctx.Err()
+		case p.configReloadCh <- &configReload{info: *newInfo, sink: newSink, filter: newFilter}:
+		}
+		info = *newInfo
+		return nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		resp, err := p.etcdCli.Client.Get(ctx, watchKey)
+		if err != nil {
+			return cerror.WrapError(cerror.ErrPDEtcdAPIError, err)
+		}
+		if len(resp.Kvs) > 0 {
+			if err := handleUpdate(resp.Kvs[0].Value); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		ch := p.etcdCli.Client.Watch(ctx, watchKey, clientv3.WithRev(resp.Header.Revision+1), clientv3.WithFilterDelete())
+		for resp := range ch {
+			if resp.Err() == mvcc.ErrCompacted {
+				break
+			}
+			if resp.Err() != nil {
+				return cerror.WrapError(cerror.ErrProcessorEtcdWatch, resp.Err())
+			}
+			for _, ev := range resp.Events {
+				if err := handleUpdate(ev.Kv.Value); err != nil {
+					return errors.Trace(err)
+				}
+			}
+		}
+	}
+}
+
 func createSchemaStorage(pdEndpoints []string, credential *security.Credential, checkpointTs uint64, filter *filter.Filter) (*entry.SchemaStorage, error) {
 	// TODO here we create another pb client,we should reuse them
 	kvStore, err := kv.CreateTiStore(strings.Join(pdEndpoints, ","), credential)
@@ -932,6 +1356,7 @@ func (p *processor) addTable(ctx context.Context, tableID int64, replicaInfo *mo
 		if atomic.SwapUint32(&table.isDying, 0) == 1 {
 			log.Warn("The same table exists but is dying. Cancel it and continue.", zap.Int64("ID", tableID))
 			table.cancel()
+			table.memQuota.Close()
 		} else {
 			log.Warn("Ignore existing table", zap.Int64("ID", tableID))
 			return
@@ -943,12 +1368,24 @@ func (p *processor) addTable(ctx context.Context, tableID int64, replicaInfo *mo
 		zap.Any("replicaInfo", replicaInfo),
 		zap.Uint64("globalResolvedTs", globalResolvedTs))
 
+	// The memory of the buffered events is given back when they are emitted
+	// by the sink.
+	memQuota, err := p.memQuota.CreateBucketWithMode(buckets.BucketModeResidency, 0, defaultTableMemQuota, 0)
+	if err != nil {
+		p.errCh <- errors.Trace(err)
+		return
+	}
+	memQuota.SetMetricLabels(p.changefeedID, tableName)
+
 	ctx = util.PutTableInfoInCtx(ctx, tableID, tableName)
 	ctx, cancel := context.WithCancel(ctx)
 	table := &tableInfo{
 		id:         tableID,
 		name:       tableName,
+		startTs:    replicaInfo.StartTs,
 		resolvedTs: replicaInfo.StartTs,
+		pullers:    make(map[model.TableID]puller.Puller),
+		memQuota:   memQuota,
 		cancel:     cancel,
 	}
 	// TODO(leoppro) calculate the workload of this table
@@ -961,17 +1398,19 @@ func (p *processor) addTable(ctx context.Context, tableID int64, replicaInfo *mo
 		enableOldValue := p.changefeed.Config.EnableOldValue
 		span := regionspan.GetTableSpan(tableID, enableOldValue)
 		plr := puller.NewPuller(p.pdCli, p.credential, p.kvStorage, replicaInfo.StartTs, []regionspan.Span{span}, p.limitter, enableOldValue)
+		table.pullers[tableID] = plr
 		go func() {
-			err := plr.Run(ctx)
-			if errors.Cause(err) != context.Canceled {
-				p.errCh <- err
-			}
+			defer p.recoverTable(table)
+			p.handleTableErr(table, plr.Run(ctx))
 		}()
 
+		sorterMemQuota := puller.NewMemoryQuota(p.sorterMemQuota, memQuotaWaitTimeout)
 		var sorterImpl puller.EventSorter
 		switch p.changefeed.Engine {
 		case model.SortInMemory:
-			sorterImpl = puller.NewEntrySorter()
+			entrySorter := puller.NewEntrySorter()
+			entrySorter.SetMemoryQuota(sorterMemQuota)
+			sorterImpl = entrySorter
 		case model.SortInFile:
 			err := util.IsDirAndWritable(p.changefeed.SortDir)
 			if err != nil {
@@ -986,7 +1425,9 @@ func (p *processor) addTable(ctx context.Context, tableID int64, replicaInfo *mo
 					return nil
 				}
 			}
-			sorterImpl = puller.NewFileSorter(p.changefeed.SortDir)
+			fileSorter := puller.NewFileSorter(p.changefeed.SortDir)
+			fileSorter.SetMemoryQuota(sorterMemQuota)
+			sorterImpl = fileSorter
 		default:
 			p.errCh <- cerror.ErrUnknownSortEngine.GenWithStackByArgs(p.changefeed.Engine)
 			return nil
@@ -994,18 +1435,19 @@ func (p *processor) addTable(ctx context.Context, tableID int64, replicaInfo *mo
 		sorter := puller.NewRectifier(sorterImpl, p.changefeed.GetTargetTs())
 
 		go func() {
-			err := sorter.Run(ctx)
-			if errors.Cause(err) != context.Canceled {
-				p.errCh <- err
-			}
+			defer p.recoverTable(table)
+			p.handleTableErr(table, sorter.Run(ctx))
 		}()
 
+		tableMemQuota := puller.NewMemoryQuota(memQuota, memQuotaWaitTimeout)
 		go func() {
-			p.pullerConsume(ctx, plr, sorter)
+			defer p.recoverTable(table)
+			p.pullerConsume(ctx, plr, sorter, tableMemQuota)
 		}()
 
 		go func() {
-			p.sorterConsume(ctx, tableID, tableName, sorter, pResolvedTs, replicaInfo)
+			defer p.recoverTable(table)
+			p.sorterConsume(ctx, tableID, tableName, sorter, tableMemQuota, pResolvedTs, replicaInfo)
 		}()
 
 		return sorter
@@ -1044,10 +1486,38 @@ func (p *processor) sorterConsume(
 	tableID int64,
 	tableName string,
 	sorter *puller.Rectifier,
+	memQuota *puller.MemoryQuota,
 	pResolvedTs *uint64,
 	replicaInfo *model.TableReplicaInfo,
 ) {
 	var lastResolvedTs uint64
+	// The memory of the events sent to the sink is released once the sink has
+	// emitted the resolved ts following them. pendingMemory records the memory
+	// of the events before each resolved ts which is not released yet, and
+	// unresolvedMemory is the memory of the events after the last resolved ts.
+	type resolvedMemory struct {
+		resolvedTs uint64
+		size       int64
+	}
+	var pendingMemory []resolvedMemory
+	var unresolvedMemory int64
+	// output is nil once the rectifier stops at the target ts
+	output := sorter.Output()
+	sinkEmittedReceiver := p.sinkEmittedResolvedNotifier.NewReceiver(0)
+	defer sinkEmittedReceiver.Stop()
+	releaseMemory := func() {
+		sinkResolvedTs := atomic.LoadUint64(&p.sinkEmittedResolvedTs)
+		i := 0
+		for ; i < len(pendingMemory) && pendingMemory[i].resolvedTs <= sinkResolvedTs; i++ {
+			memQuota.Release(pendingMemory[i].size)
+		}
+		pendingMemory = pendingMemory[i:]
+		// the events after the target ts are never sent to the sink, the
+		// memory held by them is released once the events sent are emitted
+		if output == nil && len(pendingMemory) == 0 {
+			memQuota.Close()
+		}
+	}
 	opDone := false
 	resolvedTsGauge := tableResolvedTsGauge.WithLabelValues(p.changefeedID, p.captureInfo.AdvertiseAddr, tableName)
 	checkDoneTicker := time.NewTicker(1 * time.Second)
@@ -1086,11 +1556,20 @@ func (p *processor) sorterConsume(
 				p.errCh <- ctx.Err()
 			}
 			return
-		case pEvent := <-sorter.Output():
+		case pEvent, ok := <-output:
+			if !ok {
+				output = nil
+				releaseMemory()
+				continue
+			}
 			if pEvent == nil {
 				continue
 			}
 			if pEvent.RawKV != nil && pEvent.RawKV.OpType == model.OpTypeResolved {
+				if unresolvedMemory > 0 {
+					pendingMemory = append(pendingMemory, resolvedMemory{resolvedTs: pEvent.CRTs, size: unresolvedMemory})
+					unresolvedMemory = 0
+				}
 				atomic.StoreUint64(pResolvedTs, pEvent.CRTs)
 				lastResolvedTs = pEvent.CRTs
 				p.localResolvedNotifier.Notify()
@@ -1100,6 +1579,7 @@ func (p *processor) sorterConsume(
 				}
 				continue
 			}
+			pEvent.RawKV.Trace.FinishStage(tracing.StageSorter)
 			sinkResolvedTs := atomic.LoadUint64(&p.sinkEmittedResolvedTs)
 			if pEvent.CRTs <= lastResolvedTs || pEvent.CRTs < replicaInfo.StartTs {
 				log.Fatal("The CRTs of event is not expected, please report a bug",
@@ -1108,7 +1588,7 @@ func (p *processor) sorterConsume(
 					zap.Uint64("resolvedTs", lastResolvedTs),
 					zap.Int64("tableID", tableID),
 					zap.Any("replicaInfo", replicaInfo),
-					zap.Any("row", pEvent))
+					logutil.ZapRedactReflect("row", pEvent))
 			}
 			select {
 			case <-ctx.Done():
@@ -1118,6 +1598,9 @@ func (p *processor) sorterConsume(
 				return
 			case p.output <- pEvent:
 			}
+			unresolvedMemory += pEvent.RawKV.ApproximateSize()
+		case <-sinkEmittedReceiver.C:
+			releaseMemory()
 		case <-checkDoneTicker.C:
 			if !opDone {
 				checkDone()
@@ -1132,6 +1615,7 @@ func (p *processor) pullerConsume(
 	ctx context.Context,
 	plr puller.Puller,
 	sorter *puller.Rectifier,
+	memQuota *puller.MemoryQuota,
 ) {
 	for {
 		select {
@@ -1140,19 +1624,35 @@ func (p *processor) pullerConsume(
 				p.errCh <- ctx.Err()
 			}
 			return
-		case rawKV := <-plr.Output():
-			if rawKV == nil {
+		case rawKVs := <-plr.Output():
+			if len(rawKVs) == 0 {
 				continue
 			}
-			pEvent := model.NewPolymorphicEvent(rawKV)
-			sorter.AddEntry(ctx, pEvent)
-			select {
-			case <-ctx.Done():
-				if errors.Cause(ctx.Err()) != context.Canceled {
-					p.errCh <- ctx.Err()
+			pEvents := make([]*model.PolymorphicEvent, 0, len(rawKVs))
+			for _, rawKV := range rawKVs {
+				rawKV.Trace.FinishStage(tracing.StagePuller)
+				rawKV.Trace.StartStage(tracing.StageSorter)
+				rawKV.Trace.StartStage(tracing.StageMounter)
+				pEvent := model.NewPolymorphicEvent(rawKV)
+				if err := memQuota.Acquire(ctx, pEvent); err != nil {
+					// the table is removed or finished if the quota is closed
+					if errors.Cause(err) != context.Canceled && cerror.ErrBucketClosed.NotEqual(err) {
+						p.errCh <- err
+					}
+					return
+				}
+				pEvents = append(pEvents, pEvent)
+			}
+			sorter.AddEntries(ctx, pEvents)
+			for _, pEvent := range pEvents {
+				select {
+				case <-ctx.Done():
+					if errors.Cause(ctx.Err()) != context.Canceled {
+						p.errCh <- ctx.Err()
+					}
+					return
+				case p.mounter.Input() <- pEvent:
 				}
-				return
-			case p.mounter.Input() <- pEvent:
 			}
 		}
 	}
@@ -1163,6 +1663,7 @@ func (p *processor) stop(ctx context.Context) error {
 	p.stateMu.Lock()
 	for _, tbl := range p.tables {
 		tbl.cancel()
+		tbl.memQuota.Close()
 	}
 	p.ddlPullerCancel()
 	// mark tables share the same context with its original table, don't need to cancel
@@ -1177,6 +1678,8 @@ func (p *processor) stop(ctx context.Context) error {
 	if err := p.etcdCli.DeleteTaskWorkload(ctx, p.changefeedID, p.captureInfo.ID); err != nil {
 		return err
 	}
+	p.sinkMu.Lock()
+	defer p.sinkMu.Unlock()
 	return p.sink.Close()
 }
 
@@ -1184,6 +1687,42 @@ func (p *processor) isStopped() bool {
 	return atomic.LoadInt32(&p.stopped) == 1
 }
 
+// newProcessorSink creates the sink of a processor with the changefeed info
+func newProcessorSink(
+	ctx context.Context,
+	info model.ChangeFeedInfo,
+	changefeedID string,
+	captureInfo model.CaptureInfo,
+	errCh chan error,
+) (sink.Sink, error) {
+	opts := make(map[string]string, len(info.Opts)+2)
+	for k, v := range info.Opts {
+		opts[k] = v
+	}
+	opts[sink.OptChangefeedID] = changefeedID
+	opts[sink.OptCaptureAddr] = captureInfo.AdvertiseAddr
+	filter, err := filter.NewFilter(info.Config)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	credential, err := info.GetCredential()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	s, err := sink.NewSink(ctx, changefeedID, info.SinkURI, filter, info.Config, credential, opts, errCh)
+	return s, errors.Trace(err)
+}
+
+// checkLocalSorter checks the sort dir on the capture running the sorters, the
+// warnings are logged and the errors are returned.
+func checkLocalSorter(info model.ChangeFeedInfo, changefeedID string) error {
+	diags := info.SorterConfig().Validate(true /* local */)
+	for _, d := range diags.Filter(config.DiagnosticWarning) {
+		log.Warn("sorter config diagnostic", zap.String("changefeed", changefeedID), zap.Stringer("diagnostic", d))
+	}
+	return diags.Err()
+}
+
 // runProcessor creates a new processor then starts it.
 func runProcessor(
 	ctx context.Context,
@@ -1194,27 +1733,24 @@ func runProcessor(
 	captureInfo model.CaptureInfo,
 	checkpointTs uint64,
 	flushCheckpointInterval time.Duration,
+	sorterMemQuota *buckets.Bucket,
 ) (*processor, error) {
-	opts := make(map[string]string, len(info.Opts)+2)
-	for k, v := range info.Opts {
-		opts[k] = v
-	}
-	opts[sink.OptChangefeedID] = changefeedID
-	opts[sink.OptCaptureAddr] = captureInfo.AdvertiseAddr
 	ctx = util.PutChangefeedIDInCtx(ctx, changefeedID)
-	filter, err := filter.NewFilter(info.Config)
+	// the mounter and the sink convert the temporal columns in the timezone
+	// of the changefeed
+	ctx, err := util.PutChangefeedTimezoneInCtx(ctx, info.Config.TimeZone)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
 	ctx, cancel := context.WithCancel(ctx)
 	errCh := make(chan error, 1)
-	sink, err := sink.NewSink(ctx, changefeedID, info.SinkURI, filter, info.Config, opts, errCh)
+	sink, err := newProcessorSink(ctx, info, changefeedID, captureInfo, errCh)
 	if err != nil {
 		cancel()
 		return nil, errors.Trace(err)
 	}
 	processor, err := newProcessor(ctx, credential, session, info, sink,
-		changefeedID, captureInfo, checkpointTs, errCh, flushCheckpointInterval)
+		changefeedID, captureInfo, checkpointTs, errCh, flushCheckpointInterval, sorterMemQuota)
 	if err != nil {
 		cancel()
 		return nil, err
@@ -1222,6 +1758,11 @@ func runProcessor(
 	log.Info("start to run processor", zap.String("changefeed id", changefeedID))
 
 	processorErrorCounter.WithLabelValues(changefeedID, captureInfo.AdvertiseAddr).Add(0)
+	// the errors are reported like the other errors of the processor, so they
+	// are recorded in its task position rather than failing the capture
+	if err := checkLocalSorter(info, changefeedID); err != nil {
+		errCh <- errors.Trace(err)
+	}
 	processor.Run(ctx)
 
 	go func() {
@@ -1236,17 +1777,7 @@ func runProcessor(
 				zap.String("processorid", processor.id),
 				zap.Error(err))
 			// record error information in etcd
-			var code string
-			if terror, ok := err.(*errors.Error); ok {
-				code = string(terror.RFCCode())
-			} else {
-				code = string(cerror.ErrProcessorUnknown.RFCCode())
-			}
-			processor.position.Error = &model.RunningError{
-				Addr:    captureInfo.AdvertiseAddr,
-				Code:    code,
-				Message: err.Error(),
-			}
+			processor.position.Error = model.NewRunningError(captureInfo.AdvertiseAddr, err, cerror.ErrProcessorUnknown)
 			_, err = processor.etcdCli.PutTaskPositionOnChange(ctx, processor.changefeedID, processor.captureInfo.ID, processor.position)
 			if err != nil {
 				log.Warn("upload processor error failed", zap.Error(err))
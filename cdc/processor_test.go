@@ -13,6 +13,34 @@
 
 package cdc
 
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/pingcap/check"
+	"github.com/pingcap/ticdc/cdc/model"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+)
+
+type localSorterSuite struct{}
+
+var _ = check.Suite(&localSorterSuite{})
+
+func (s *localSorterSuite) TestCheckLocalSorter(c *check.C) {
+	info := model.ChangeFeedInfo{Engine: model.SortInMemory}
+	c.Assert(checkLocalSorter(info, "test-cf"), check.IsNil)
+
+	info = model.ChangeFeedInfo{Engine: model.SortInFile, SortDir: filepath.Join(c.MkDir(), "sort")}
+	c.Assert(checkLocalSorter(info, "test-cf"), check.IsNil)
+
+	file := filepath.Join(c.MkDir(), "file")
+	c.Assert(ioutil.WriteFile(file, nil, 0644), check.IsNil)
+	info.SortDir = file
+	err := checkLocalSorter(info, "test-cf")
+	c.Assert(cerror.ErrInvalidConfig.Equal(err), check.IsTrue)
+	c.Assert(err, check.ErrorMatches, ".*sort-dir.*not a directory.*")
+}
+
 /*
 import (
 	"context"
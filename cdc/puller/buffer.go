@@ -20,18 +20,21 @@ import (
 	"unsafe"
 
 	"github.com/edwingeng/deque"
+	"github.com/pingcap/errors"
 	"github.com/pingcap/log"
 	"github.com/pingcap/ticdc/cdc/model"
-	cerror "github.com/pingcap/ticdc/pkg/errors"
 )
 
 const (
 	defaultBufferSize = 128000
+	// defaultTableBufferBudget is the memory budget of the mem buffer of a
+	// single puller
+	defaultTableBufferBudget = 256 * 1024 * 1024
 )
 
 // EventBuffer in a interface for communicating kv entries.
 type EventBuffer interface {
-	// AddEntry adds an entry to the buffer, return ErrReachLimit if reach budget limit.
+	// AddEntry adds an entry to the buffer, blocking while the buffer is full.
 	AddEntry(ctx context.Context, entry model.RegionFeedEvent) error
 	Get(ctx context.Context) (model.RegionFeedEvent, error)
 }
@@ -68,7 +71,12 @@ func (b ChanBuffer) Get(ctx context.Context) (model.RegionFeedEvent, error) {
 var _ EventBuffer = &memBuffer{}
 
 type memBuffer struct {
+	// limitter accounts the memory of the buffers of all the pullers of the
+	// processor, it's never waited on.
 	limitter *BlurResourceLimitter
+	// budget bounds the memory of this buffer alone, so that a slow table
+	// only blocks its own kv client.
+	budget *BlurResourceLimitter
 
 	mu struct {
 		sync.Mutex
@@ -77,10 +85,16 @@ type memBuffer struct {
 	signalCh chan struct{}
 }
 
-// Passing nil will make a unlimited  buffer.
-func makeMemBuffer(limitter *BlurResourceLimitter) *memBuffer {
+// Passing a non-positive budget will make a unlimited buffer, and passing a
+// nil limitter will not account the memory of the buffer.
+func makeMemBuffer(limitter *BlurResourceLimitter, budget int64) *memBuffer {
+	var own *BlurResourceLimitter
+	if budget > 0 {
+		own = NewBlurResourceLimmter(budget)
+	}
 	return &memBuffer{
 		limitter: limitter,
+		budget:   own,
 		mu: struct {
 			sync.Mutex
 			entries deque.Deque
@@ -93,17 +107,18 @@ func makeMemBuffer(limitter *BlurResourceLimitter) *memBuffer {
 }
 
 // AddEntry implements EventBuffer interface.
+// The buffer is bounded by its own budget, so that it doesn't balloon while the
+// sorter is busy, the kv client of the table is blocked instead.
 func (b *memBuffer) AddEntry(ctx context.Context, entry model.RegionFeedEvent) error {
-	b.mu.Lock()
-	if b.limitter != nil && b.limitter.OverBucget() {
-		b.mu.Unlock()
-		return cerror.ErrBufferReachLimit.GenWithStackByArgs()
+	if b.budget != nil {
+		if err := b.budget.WaitBudget(ctx); err != nil {
+			return errors.Trace(err)
+		}
 	}
 
+	b.mu.Lock()
 	b.mu.entries.PushBack(entry)
-	if b.limitter != nil {
-		b.limitter.Add(int64(entrySize(entry)))
-	}
+	b.addSizeLocked(int64(entrySize(entry)))
 	b.mu.Unlock()
 
 	select {
@@ -120,9 +135,7 @@ func (b *memBuffer) Get(ctx context.Context) (model.RegionFeedEvent, error) {
 		b.mu.Lock()
 		if !b.mu.entries.Empty() {
 			e := b.mu.entries.PopFront().(model.RegionFeedEvent)
-			if b.limitter != nil {
-				b.limitter.Add(int64(-entrySize(e)))
-			}
+			b.addSizeLocked(int64(-entrySize(e)))
 			b.mu.Unlock()
 			return e, nil
 		}
@@ -137,14 +150,55 @@ func (b *memBuffer) Get(ctx context.Context) (model.RegionFeedEvent, error) {
 	}
 }
 
+// GetBatch waits for at least one entry and takes the entries in the buffer
+// until maxCount entries or maxBytes bytes are taken.
+func (b *memBuffer) GetBatch(ctx context.Context, maxCount int, maxBytes int) ([]model.RegionFeedEvent, error) {
+	for {
+		b.mu.Lock()
+		if !b.mu.entries.Empty() {
+			n := b.mu.entries.Len()
+			if n > maxCount {
+				n = maxCount
+			}
+			batch := make([]model.RegionFeedEvent, 0, n)
+			bytes := 0
+			for len(batch) < maxCount && bytes < maxBytes && !b.mu.entries.Empty() {
+				e := b.mu.entries.PopFront().(model.RegionFeedEvent)
+				bytes += entrySize(e)
+				batch = append(batch, e)
+			}
+			b.addSizeLocked(int64(-bytes))
+			b.mu.Unlock()
+			return batch, nil
+		}
+
+		b.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-b.signalCh:
+		}
+	}
+}
+
+func (b *memBuffer) addSizeLocked(n int64) {
+	if b.budget != nil {
+		b.budget.Add(n)
+	}
+	if b.limitter != nil {
+		b.limitter.Add(n)
+	}
+}
+
 // Size returns the memory size of memBuffer
 func (b *memBuffer) Size() int64 {
 	b.mu.Lock()
 	defer b.mu.Unlock()
-	if b.limitter == nil {
+	if b.budget == nil {
 		return 0
 	}
-	return atomic.LoadInt64(&b.limitter.used)
+	return atomic.LoadInt64(&b.budget.used)
 }
 
 var sizeOfVal = unsafe.Sizeof(model.RawKVEntry{})
@@ -166,6 +220,10 @@ func entrySize(e model.RegionFeedEvent) int {
 type BlurResourceLimitter struct {
 	budget int64
 	used   int64
+
+	mu sync.Mutex
+	// freed is closed when some resource is freed, to wake up the waiters
+	freed chan struct{}
 }
 
 // NewBlurResourceLimmter create a BlurResourceLimitter.
@@ -178,6 +236,36 @@ func NewBlurResourceLimmter(budget int64) *BlurResourceLimitter {
 // Add used resource into limmter
 func (rl *BlurResourceLimitter) Add(n int64) {
 	atomic.AddInt64(&rl.used, n)
+	if n < 0 {
+		rl.mu.Lock()
+		if rl.freed != nil {
+			close(rl.freed)
+			rl.freed = nil
+		}
+		rl.mu.Unlock()
+	}
+}
+
+// WaitBudget blocks until the resource used is under the budget or ctx is done.
+func (rl *BlurResourceLimitter) WaitBudget(ctx context.Context) error {
+	for {
+		rl.mu.Lock()
+		if !rl.OverBucget() {
+			rl.mu.Unlock()
+			return nil
+		}
+		if rl.freed == nil {
+			rl.freed = make(chan struct{})
+		}
+		freed := rl.freed
+		rl.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-freed:
+		}
+	}
 }
 
 // OverBucget retun true if over budget.
@@ -19,8 +19,8 @@ import (
 	"time"
 
 	"github.com/pingcap/check"
+	"github.com/pingcap/errors"
 	"github.com/pingcap/ticdc/cdc/model"
-	cerror "github.com/pingcap/ticdc/pkg/errors"
 	"github.com/pingcap/ticdc/pkg/regionspan"
 )
 
@@ -94,8 +94,11 @@ var _ = check.Suite(&memBufferSuite{})
 
 func (bs *memBufferSuite) TestMemBuffer(c *check.C) {
 	limitter := NewBlurResourceLimmter(1024 * 1024)
-	bf := makeMemBuffer(limitter)
+	bf := makeMemBuffer(limitter, 1024*1024)
 
+	// AddEntry blocks once the buffer is full.
+	timeout, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
 	var err error
 	var entries []model.RegionFeedEvent
 	for {
@@ -104,7 +107,7 @@ func (bs *memBufferSuite) TestMemBuffer(c *check.C) {
 				Value: make([]byte, 1024),
 			},
 		}
-		err = bf.AddEntry(context.Background(), entry)
+		err = bf.AddEntry(timeout, entry)
 		if err != nil {
 			break
 		}
@@ -112,12 +115,38 @@ func (bs *memBufferSuite) TestMemBuffer(c *check.C) {
 		entries = append(entries, entry)
 	}
 
-	c.Assert(cerror.ErrBufferReachLimit.Equal(err), check.IsTrue)
+	c.Assert(errors.Cause(err), check.Equals, context.DeadlineExceeded)
 	num := float64(bf.mu.entries.Len())
 	nearNum := 1024.0
 	c.Assert(num >= nearNum*0.9, check.IsTrue)
 	c.Assert(num <= nearNum*1.1, check.IsTrue)
 
+	// A blocked AddEntry is woken up once an entry is taken.
+	added := make(chan error, 1)
+	extra := model.RegionFeedEvent{
+		Val: &model.RawKVEntry{
+			Value: make([]byte, 1024),
+		},
+	}
+	go func() {
+		added <- bf.AddEntry(context.Background(), extra)
+	}()
+	select {
+	case <-added:
+		c.Fatal("AddEntry doesn't block on a full buffer.")
+	case <-time.After(10 * time.Millisecond):
+	}
+	entry, err := bf.Get(context.Background())
+	c.Assert(err, check.IsNil)
+	c.Assert(entry, check.DeepEquals, entries[0])
+	select {
+	case err = <-added:
+		c.Assert(err, check.IsNil)
+	case <-time.After(time.Second):
+		c.Fatal("AddEntry isn't woken up after an entry is taken.")
+	}
+	entries = append(entries[1:], extra)
+
 	// Check can get back the entries.
 	var getEntries []model.RegionFeedEvent
 	for len(getEntries) < len(entries) {
@@ -127,3 +156,58 @@ func (bs *memBufferSuite) TestMemBuffer(c *check.C) {
 	}
 	c.Assert(getEntries, check.DeepEquals, entries)
 }
+
+func (bs *memBufferSuite) TestMemBufferGetBatch(c *check.C) {
+	bf := makeMemBuffer(NewBlurResourceLimmter(1024*1024), 1024*1024)
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		err := bf.AddEntry(ctx, model.RegionFeedEvent{
+			Val: &model.RawKVEntry{CRTs: uint64(i), Value: make([]byte, 100)},
+		})
+		c.Assert(err, check.IsNil)
+	}
+
+	// bounded by count
+	batch, err := bf.GetBatch(ctx, 4, 1024*1024)
+	c.Assert(err, check.IsNil)
+	c.Assert(batch, check.HasLen, 4)
+	c.Assert(batch[0].Val.CRTs, check.Equals, uint64(0))
+	// bounded by bytes, the entry exceeding the bytes is still taken
+	batch, err = bf.GetBatch(ctx, 100, 1)
+	c.Assert(err, check.IsNil)
+	c.Assert(batch, check.HasLen, 1)
+	c.Assert(batch[0].Val.CRTs, check.Equals, uint64(4))
+	batch, err = bf.GetBatch(ctx, 100, 1024*1024)
+	c.Assert(err, check.IsNil)
+	c.Assert(batch, check.HasLen, 5)
+	c.Assert(bf.Size(), check.Equals, int64(0))
+
+	// blocks until an entry is added
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = bf.GetBatch(timeout, 100, 1024*1024)
+	c.Assert(err, check.Equals, context.DeadlineExceeded)
+}
+
+func (bs *memBufferSuite) TestMemBufferOwnBudget(c *check.C) {
+	// the buffers share the limitter, but each of them is bounded by its
+	// own budget
+	limitter := NewBlurResourceLimmter(1024)
+	slow := makeMemBuffer(limitter, 1024)
+	fast := makeMemBuffer(limitter, 1024)
+	entry := model.RegionFeedEvent{
+		Val: &model.RawKVEntry{
+			Value: make([]byte, 1024),
+		},
+	}
+	c.Assert(slow.AddEntry(context.Background(), entry), check.IsNil)
+	timeout, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := slow.AddEntry(timeout, entry)
+	c.Assert(errors.Cause(err), check.Equals, context.DeadlineExceeded)
+
+	// the full buffer doesn't block the other one
+	c.Assert(fast.AddEntry(context.Background(), entry), check.IsNil)
+	c.Assert(limitter.OverBucget(), check.IsTrue)
+	c.Assert(fast.Size(), check.Equals, slow.Size())
+}
@@ -23,10 +23,10 @@ import (
 	"github.com/pingcap/errors"
 	"github.com/pingcap/log"
 	"github.com/pingcap/ticdc/cdc/model"
+	cdcContext "github.com/pingcap/ticdc/pkg/context"
 	"github.com/pingcap/ticdc/pkg/notify"
 	"github.com/pingcap/ticdc/pkg/util"
 	"go.uber.org/zap"
-	"golang.org/x/sync/errgroup"
 )
 
 // EntrySorter accepts out-of-order raw kv entries and output sorted entries
@@ -38,6 +38,7 @@ type EntrySorter struct {
 
 	outputCh         chan *model.PolymorphicEvent
 	resolvedNotifier *notify.Notifier
+	memQuota         *MemoryQuota
 }
 
 // NewEntrySorter creates a new EntrySorter
@@ -48,8 +49,15 @@ func NewEntrySorter() *EntrySorter {
 	}
 }
 
+// SetMemoryQuota makes the sorter acquire the memory of buffered events from
+// the quota. It must be called before Run.
+func (es *EntrySorter) SetMemoryQuota(q *MemoryQuota) {
+	es.memQuota = q
+}
+
 // Run runs EntrySorter
 func (es *EntrySorter) Run(ctx context.Context) error {
+	ctx = cdcContext.WithSync(ctx)
 	captureAddr := util.CaptureAddrFromCtx(ctx)
 	changefeedID := util.ChangefeedIDFromCtx(ctx)
 	_, tableName := util.TableIDFromCtx(ctx)
@@ -88,15 +96,19 @@ func (es *EntrySorter) Run(ctx context.Context) error {
 			output(kvsB[j])
 		}
 	}
+	var outputSize int64
 	output := func(ctx context.Context, entry *model.PolymorphicEvent) {
 		select {
 		case <-ctx.Done():
 			return
 		case es.outputCh <- entry:
 		}
+		if entry.RawKV.OpType != model.OpTypeResolved {
+			outputSize += entry.RawKV.ApproximateSize()
+		}
 	}
 
-	errg, ctx := errgroup.WithContext(ctx)
+	errg, ctx := util.SafeGroupWithContext(ctx)
 	errg.Go(func() error {
 		for {
 			select {
@@ -119,6 +131,7 @@ func (es *EntrySorter) Run(ctx context.Context) error {
 			select {
 			case <-ctx.Done():
 				atomic.StoreInt32(&es.closed, 1)
+				es.memQuota.Close()
 				close(es.outputCh)
 				return errors.Trace(ctx.Err())
 			case <-receiver.C:
@@ -157,6 +170,8 @@ func (es *EntrySorter) Run(ctx context.Context) error {
 				})
 				metricEntrySorterMergeDuration.Observe(time.Since(startTime).Seconds())
 				sorted = merged
+				es.memQuota.Release(outputSize)
+				outputSize = 0
 			}
 		}
 	})
@@ -168,6 +183,10 @@ func (es *EntrySorter) AddEntry(ctx context.Context, entry *model.PolymorphicEve
 	if atomic.LoadInt32(&es.closed) != 0 {
 		return
 	}
+	ctx = cdcContext.WithSync(ctx)
+	if err := es.memQuota.Acquire(ctx, entry); err != nil {
+		return
+	}
 	es.lock.Lock()
 	if entry.RawKV.OpType == model.OpTypeResolved {
 		es.resolvedTsGroup = append(es.resolvedTsGroup, entry.CRTs)
@@ -178,13 +197,38 @@ func (es *EntrySorter) AddEntry(ctx context.Context, entry *model.PolymorphicEve
 	es.lock.Unlock()
 }
 
+// AddEntries adds a batch of RawKVEntries to the EntryGroup, taking the lock once
+func (es *EntrySorter) AddEntries(ctx context.Context, entries []*model.PolymorphicEvent) {
+	if atomic.LoadInt32(&es.closed) != 0 {
+		return
+	}
+	ctx = cdcContext.WithSync(ctx)
+	if err := es.memQuota.AcquireBatch(ctx, entries); err != nil {
+		return
+	}
+	resolved := false
+	es.lock.Lock()
+	for _, entry := range entries {
+		if entry.RawKV.OpType == model.OpTypeResolved {
+			es.resolvedTsGroup = append(es.resolvedTsGroup, entry.CRTs)
+			resolved = true
+		} else {
+			es.unsorted = append(es.unsorted, entry)
+		}
+	}
+	es.lock.Unlock()
+	if resolved {
+		es.resolvedNotifier.Notify()
+	}
+}
+
 // Output returns the sorted raw kv output channel
 func (es *EntrySorter) Output() <-chan *model.PolymorphicEvent {
 	return es.outputCh
 }
 
-// SortOutput receives a channel from a puller, then sort event and output to the channel returned.
-func SortOutput(ctx context.Context, input <-chan *model.RawKVEntry) <-chan *model.RawKVEntry {
+// SortOutput receives the batches from a puller, then sort event and output to the channel returned.
+func SortOutput(ctx context.Context, input <-chan []*model.RawKVEntry) <-chan *model.RawKVEntry {
 	ctx, cancel := context.WithCancel(ctx)
 	sorter := NewEntrySorter()
 	outputCh := make(chan *model.RawKVEntry, 128)
@@ -192,7 +236,7 @@ func SortOutput(ctx context.Context, input <-chan *model.RawKVEntry) <-chan *mod
 		select {
 		case <-ctx.Done():
 			if errors.Cause(ctx.Err()) != context.Canceled {
-				log.Error("sorter exited with error", zap.Error(ctx.Err()))
+				log.Error("sorter exited with error", append(cdcContext.ZapFields(ctx), zap.Error(ctx.Err()))...)
 			}
 			return
 		case outputCh <- rawKV:
@@ -203,14 +247,18 @@ func SortOutput(ctx context.Context, input <-chan *model.RawKVEntry) <-chan *mod
 			select {
 			case <-ctx.Done():
 				if errors.Cause(ctx.Err()) != context.Canceled {
-					log.Error("sorter exited with error", zap.Error(ctx.Err()))
+					log.Error("sorter exited with error", append(cdcContext.ZapFields(ctx), zap.Error(ctx.Err()))...)
 				}
 				return
-			case rawKV := <-input:
-				if rawKV == nil {
+			case rawKVs := <-input:
+				if len(rawKVs) == 0 {
 					continue
 				}
-				sorter.AddEntry(ctx, model.NewPolymorphicEvent(rawKV))
+				entries := make([]*model.PolymorphicEvent, len(rawKVs))
+				for i, rawKV := range rawKVs {
+					entries[i] = model.NewPolymorphicEvent(rawKV)
+				}
+				sorter.AddEntries(ctx, entries)
 			case sorted := <-sorter.Output():
 				if sorted != nil {
 					output(sorted.RawKV)
@@ -221,7 +269,7 @@ func SortOutput(ctx context.Context, input <-chan *model.RawKVEntry) <-chan *mod
 	go func() {
 		if err := sorter.Run(ctx); err != nil {
 			if errors.Cause(ctx.Err()) != context.Canceled {
-				log.Error("sorter exited with error", zap.Error(ctx.Err()))
+				log.Error("sorter exited with error", append(cdcContext.ZapFields(ctx), zap.Error(ctx.Err()))...)
 			}
 		}
 		cancel()
@@ -177,6 +177,41 @@ func (s *mockEntrySorterSuite) TestEntrySorterRandomly(c *check.C) {
 	wg.Wait()
 }
 
+func (s *mockEntrySorterSuite) TestEntrySorterAddEntries(c *check.C) {
+	es := NewEntrySorter()
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		err := es.Run(ctx)
+		c.Assert(errors.Cause(err), check.Equals, context.Canceled)
+	}()
+
+	es.AddEntries(ctx, []*model.PolymorphicEvent{
+		model.NewPolymorphicEvent(&model.RawKVEntry{CRTs: 3, OpType: model.OpTypePut}),
+		model.NewPolymorphicEvent(&model.RawKVEntry{CRTs: 1, OpType: model.OpTypePut}),
+		model.NewResolvedPolymorphicEvent(0, 2),
+		model.NewPolymorphicEvent(&model.RawKVEntry{CRTs: 2, OpType: model.OpTypeDelete}),
+	})
+	es.AddEntries(ctx, []*model.PolymorphicEvent{model.NewResolvedPolymorphicEvent(0, 3)})
+	var crts []uint64
+	var types []model.OpType
+	for entry := range es.Output() {
+		crts = append(crts, entry.CRTs)
+		types = append(types, entry.RawKV.OpType)
+		if entry.CRTs == 3 && entry.RawKV.OpType == model.OpTypeResolved {
+			break
+		}
+	}
+	c.Assert(crts, check.DeepEquals, []uint64{1, 2, 2, 3, 3})
+	c.Assert(types, check.DeepEquals, []model.OpType{
+		model.OpTypePut, model.OpTypeDelete, model.OpTypeResolved, model.OpTypePut, model.OpTypeResolved,
+	})
+	cancel()
+	wg.Wait()
+}
+
 func BenchmarkSorter(b *testing.B) {
 	es := NewEntrySorter()
 	ctx, cancel := context.WithCancel(context.Background())
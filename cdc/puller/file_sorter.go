@@ -18,7 +18,6 @@ import (
 	"bytes"
 	"container/heap"
 	"context"
-	"encoding/binary"
 	"io"
 	"io/ioutil"
 	"math/rand"
@@ -33,10 +32,11 @@ import (
 	"github.com/pingcap/errors"
 	"github.com/pingcap/log"
 	"github.com/pingcap/ticdc/cdc/model"
+	cdcContext "github.com/pingcap/ticdc/pkg/context"
 	cerror "github.com/pingcap/ticdc/pkg/errors"
-	"github.com/vmihailenco/msgpack/v5"
+	"github.com/pingcap/ticdc/pkg/spill"
+	"github.com/pingcap/ticdc/pkg/util"
 	"go.uber.org/zap"
-	"golang.org/x/sync/errgroup"
 )
 
 var (
@@ -56,6 +56,10 @@ type fileCache struct {
 	lastSortedFile        string
 	availableFileIdx      []int
 	availableFileSize     map[int]uint64
+
+	metrics *fileSorterMetrics
+	// onDiskBytes is the size of the files written and not removed yet
+	onDiskBytes int64
 }
 
 func newFileCache(dir string) *fileCache {
@@ -91,7 +95,10 @@ func (cache *fileCache) extendUnsortFiles() {
 // if no more available unsorted file, create some new unsorted files
 func (cache *fileCache) next() (int, string) {
 	if len(cache.availableFileIdx) == 0 {
+		cache.metrics.filePoolMiss.Inc()
 		cache.extendUnsortFiles()
+	} else {
+		cache.metrics.filePoolHit.Inc()
 	}
 	idx := rand.Intn(len(cache.availableFileIdx))
 	return idx, cache.unsortedFiles[cache.availableFileIdx[idx]]
@@ -126,10 +133,12 @@ func (cache *fileCache) gc(maxRunDuration time.Duration) {
 			return
 		}
 		fpath := filepath.Join(cache.dir, f)
-		if _, err := os.Stat(fpath); err == nil {
+		if info, err := os.Stat(fpath); err == nil {
 			err2 := os.Remove(fpath)
 			if err2 != nil {
 				log.Warn("remove file failed", zap.Error(err2))
+			} else {
+				cache.addOnDiskBytes(-info.Size())
 			}
 		}
 		index = i + 1
@@ -167,21 +176,33 @@ func (cache *fileCache) flush(ctx context.Context, entries []*model.PolymorphicE
 	defer cache.fileLock.Unlock()
 	idx, filename := cache.next()
 	fpath := filepath.Join(cache.dir, filename)
+	cache.metrics.activeFlushes.Inc()
 	dataLen, err := flushEventsToFile(ctx, fpath, entries)
+	cache.metrics.activeFlushes.Dec()
 	if err != nil {
 		return errors.Trace(err)
 	}
+	cache.metrics.spillBytes.Add(float64(dataLen))
+	cache.addOnDiskBytes(int64(dataLen))
 	cache.increase(idx, dataLen)
 	return nil
 }
 
+// addOnDiskBytes records the size of the files written or removed.
+func (cache *fileCache) addOnDiskBytes(n int64) {
+	atomic.AddInt64(&cache.onDiskBytes, n)
+	cache.metrics.onDiskBytes.Add(float64(n))
+}
+
 // FileSorter accepts out-of-order raw kv entries, sort in local file system
 // and output sorted entries
 type FileSorter struct {
 	dir      string
 	outputCh chan *model.PolymorphicEvent
-	inputCh  chan *model.PolymorphicEvent
+	// inputCh receives the events in batches, see AddEntries
+	inputCh  chan []*model.PolymorphicEvent
 	cache    *fileCache
+	memQuota *MemoryQuota
 }
 
 // flushEventsToFile writes a slice of model.PolymorphicEvent to a given file in sequence
@@ -190,8 +211,7 @@ func flushEventsToFile(ctx context.Context, fullpath string, entries []*model.Po
 		return 0, nil
 	}
 	buf := new(bytes.Buffer)
-	dataBuf := new(bytes.Buffer)
-	var dataLen [8]byte
+	var encoder spill.Encoder
 	for _, entry := range entries {
 		err := entry.WaitPrepare(ctx)
 		if err != nil {
@@ -200,14 +220,9 @@ func flushEventsToFile(ctx context.Context, fullpath string, entries []*model.Po
 		if entry.Row == nil {
 			continue
 		}
-		dataBuf.Reset()
-		err = msgpack.NewEncoder(dataBuf).Encode(entry)
-		if err != nil {
-			return 0, cerror.WrapError(cerror.ErrFileSorterEncode, err)
+		if _, err := encoder.Encode(buf, entry); err != nil {
+			return 0, errors.Trace(err)
 		}
-		binary.BigEndian.PutUint64(dataLen[:], uint64(dataBuf.Len()))
-		buf.Write(dataLen[:])
-		buf.Write(dataBuf.Bytes())
 	}
 	if buf.Len() == 0 {
 		return 0, nil
@@ -233,7 +248,7 @@ func NewFileSorter(dir string) *FileSorter {
 	fs := &FileSorter{
 		dir:      dir,
 		outputCh: make(chan *model.PolymorphicEvent, 128000),
-		inputCh:  make(chan *model.PolymorphicEvent, 128000),
+		inputCh:  make(chan []*model.PolymorphicEvent, 128000),
 		cache:    newFileCache(dir),
 	}
 	return fs
@@ -262,36 +277,17 @@ func (h *sortHeap) Pop() interface{} {
 	return x
 }
 
-// readPolymorphicEvent reads a PolymorphicEvent from file reader and also advance reader
+// readPolymorphicEvent reads a PolymorphicEvent from file reader and also advance reader,
+// nil is returned once all the events are read.
 // TODO: batch read
-func readPolymorphicEvent(rd *bufio.Reader, readBuf *bytes.Reader) (*model.PolymorphicEvent, error) {
-	var byteLen [8]byte
-	n, err := io.ReadFull(rd, byteLen[:])
-	if err != nil {
-		if err == io.EOF {
-			return nil, nil
-		}
-		return nil, cerror.WrapError(cerror.ErrFileSorterWriteFile, err)
-	}
-	if n < 8 {
-		return nil, cerror.ErrFileSorterInvalidData.GenWithStack("invalid length data %s, read %d bytes", byteLen, n)
-	}
-	dataLen := int(binary.BigEndian.Uint64(byteLen[:]))
-
-	data := make([]byte, dataLen)
-	n, err = io.ReadFull(rd, data)
-	if err != nil {
-		return nil, cerror.WrapError(cerror.ErrFileSorterReadFile, err)
-	}
-	if n != dataLen {
-		return nil, cerror.ErrFileSorterInvalidData.GenWithStack("truncated data %s n: %d dataLen: %d", data, n, dataLen)
-	}
-
-	readBuf.Reset(data)
+func readPolymorphicEvent(rd *bufio.Reader, decoder *spill.Decoder) (*model.PolymorphicEvent, error) {
 	ev := &model.PolymorphicEvent{}
-	err = msgpack.NewDecoder(readBuf).Decode(ev)
+	err := decoder.Decode(rd, ev)
+	if err == io.EOF {
+		return nil, nil
+	}
 	if err != nil {
-		return nil, cerror.WrapError(cerror.ErrFileSorterDecode, err)
+		return nil, errors.Trace(err)
 	}
 	return ev, nil
 }
@@ -304,6 +300,16 @@ func (fs *FileSorter) output(ctx context.Context, entry *model.PolymorphicEvent)
 	}
 }
 
+// flushSorted writes the sorted events to a file during the merge.
+func (fs *FileSorter) flushSorted(ctx context.Context, fullpath string, entries []*model.PolymorphicEvent) error {
+	dataLen, err := flushEventsToFile(ctx, fullpath, entries)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	fs.cache.addOnDiskBytes(int64(dataLen))
+	return nil
+}
+
 func (fs *FileSorter) rotate(ctx context.Context, resolvedTs uint64) error {
 	// sortSingleFile reads an unsorted file into memory, sort in memory and rewritten
 	// sorted events ta a new file.
@@ -318,21 +324,18 @@ func (fs *FileSorter) rotate(ctx context.Context, resolvedTs uint64) error {
 			return "", cerror.WrapError(cerror.ErrFileSorterReadFile, err)
 		}
 		evs := make([]*model.PolymorphicEvent, 0)
-		idx := 0
-		reader := new(bytes.Reader)
-		for idx < len(data) {
-			dataLen := int(binary.BigEndian.Uint64(data[idx : idx+8]))
-			if idx+8+dataLen > len(data) {
-				return "", cerror.ErrFileSorterInvalidData.GenWithStack("unsorted file unexpected truncated")
-			}
+		reader := bytes.NewReader(data)
+		var decoder spill.Decoder
+		for {
 			ev := &model.PolymorphicEvent{}
-			reader.Reset(data[idx+8 : idx+8+dataLen])
-			err = msgpack.NewDecoder(reader).Decode(ev)
+			err = decoder.Decode(reader, ev)
+			if err == io.EOF {
+				break
+			}
 			if err != nil {
-				return "", cerror.WrapError(cerror.ErrFileSorterDecode, err)
+				return "", errors.Annotate(err, "unsorted file")
 			}
 			evs = append(evs, ev)
-			idx = idx + 8 + dataLen
 		}
 		// event count in unsorted file may be zero
 		if len(evs) == 0 {
@@ -347,7 +350,7 @@ func (fs *FileSorter) rotate(ctx context.Context, resolvedTs uint64) error {
 		for _, entry := range evs {
 			buffer = append(buffer, entry)
 			if len(buffer) >= defaultSorterBufferSize {
-				_, err := flushEventsToFile(ctx, newfpath, buffer)
+				err := fs.flushSorted(ctx, newfpath, buffer)
 				if err != nil {
 					return "", errors.Trace(err)
 				}
@@ -355,7 +358,7 @@ func (fs *FileSorter) rotate(ctx context.Context, resolvedTs uint64) error {
 			}
 		}
 		if len(buffer) > 0 {
-			_, err := flushEventsToFile(ctx, newfpath, buffer)
+			err := fs.flushSorted(ctx, newfpath, buffer)
 			if err != nil {
 				return "", errors.Trace(err)
 			}
@@ -367,6 +370,7 @@ func (fs *FileSorter) rotate(ctx context.Context, resolvedTs uint64) error {
 	if !start {
 		return nil
 	}
+	startTime := time.Now()
 
 	// prepare buffer reader of all sorted files
 	readers := make([]*bufio.Reader, 0, len(files)+1)
@@ -382,7 +386,7 @@ func (fs *FileSorter) rotate(ctx context.Context, resolvedTs uint64) error {
 		toRemoveFiles = append(toRemoveFiles, sortedFile)
 		fd, err := os.Open(filepath.Join(fs.dir, sortedFile))
 		if err != nil {
-			return errors.Trace(err)
+			return cerror.WrapError(cerror.ErrFileSorterOpenFile, err)
 		}
 		rd := bufio.NewReader(fd)
 		readers = append(readers, rd)
@@ -401,10 +405,10 @@ func (fs *FileSorter) rotate(ctx context.Context, resolvedTs uint64) error {
 	// the rest events will be rewritten into the new lastSortedFile
 	h := &sortHeap{}
 	heap.Init(h)
-	readBuf := new(bytes.Reader)
+	decoder := new(spill.Decoder)
 	rowCount := 0
 	for i, fd := range readers {
-		ev, err := readPolymorphicEvent(fd, readBuf)
+		ev, err := readPolymorphicEvent(fd, decoder)
 		if err != nil {
 			return errors.Trace(err)
 		}
@@ -435,14 +439,14 @@ func (fs *FileSorter) rotate(ctx context.Context, resolvedTs uint64) error {
 			lastSortedFileUpdated = true
 			buffer = append(buffer, item.entry)
 			if len(buffer) > defaultSorterBufferSize {
-				_, err := flushEventsToFile(ctx, filepath.Join(fs.dir, newLastSortedFile), buffer)
+				err := fs.flushSorted(ctx, filepath.Join(fs.dir, newLastSortedFile), buffer)
 				if err != nil {
 					return errors.Trace(err)
 				}
 				buffer = buffer[:0]
 			}
 		}
-		ev, err := readPolymorphicEvent(readers[item.fileIndex], readBuf)
+		ev, err := readPolymorphicEvent(readers[item.fileIndex], decoder)
 		if err != nil {
 			return errors.Trace(err)
 		}
@@ -453,7 +457,7 @@ func (fs *FileSorter) rotate(ctx context.Context, resolvedTs uint64) error {
 		heap.Push(h, &sortItem{entry: ev, fileIndex: item.fileIndex})
 	}
 	if len(buffer) > 0 {
-		_, err := flushEventsToFile(ctx, filepath.Join(fs.dir, newLastSortedFile), buffer)
+		err := fs.flushSorted(ctx, filepath.Join(fs.dir, newLastSortedFile), buffer)
 		if err != nil {
 			return errors.Trace(err)
 		}
@@ -463,6 +467,7 @@ func (fs *FileSorter) rotate(ctx context.Context, resolvedTs uint64) error {
 	}
 
 	fs.cache.finishSorting(newLastSortedFile, toRemoveFiles)
+	fs.cache.metrics.mergeDuration.Observe(time.Since(startTime).Seconds())
 	// regionID = 0 means the event is produced by TiCDC
 	fs.output(ctx, model.NewResolvedPolymorphicEvent(0, resolvedTs))
 
@@ -471,13 +476,32 @@ func (fs *FileSorter) rotate(ctx context.Context, resolvedTs uint64) error {
 
 // AddEntry adds an RawKVEntry to file sorter cache
 func (fs *FileSorter) AddEntry(ctx context.Context, entry *model.PolymorphicEvent) {
+	fs.AddEntries(ctx, []*model.PolymorphicEvent{entry})
+}
+
+// AddEntries adds a batch of RawKVEntries to file sorter cache, the memory of
+// the batch is acquired at once and the batch is sent in one operation.
+func (fs *FileSorter) AddEntries(ctx context.Context, entries []*model.PolymorphicEvent) {
+	if len(entries) == 0 {
+		return
+	}
+	ctx = cdcContext.WithSync(ctx)
+	if err := fs.memQuota.AcquireBatch(ctx, entries); err != nil {
+		return
+	}
 	select {
 	case <-ctx.Done():
 		return
-	case fs.inputCh <- entry:
+	case fs.inputCh <- entries:
 	}
 }
 
+// SetMemoryQuota makes the sorter acquire the memory of the events from the
+// quota until they are flushed to files. It must be called before Run.
+func (fs *FileSorter) SetMemoryQuota(q *MemoryQuota) {
+	fs.memQuota = q
+}
+
 // Output returns the sorted PolymorphicEvent in output channel
 func (fs *FileSorter) Output() <-chan *model.PolymorphicEvent {
 	return fs.outputCh
@@ -485,7 +509,16 @@ func (fs *FileSorter) Output() <-chan *model.PolymorphicEvent {
 
 // Run implements EventSorter.Run, runs in background, sorts and sends sorted events to output channel
 func (fs *FileSorter) Run(ctx context.Context) error {
-	wg, ctx := errgroup.WithContext(ctx)
+	ctx = cdcContext.WithSync(ctx)
+	captureAddr := util.CaptureAddrFromCtx(ctx)
+	changefeedID := util.ChangefeedIDFromCtx(ctx)
+	fs.cache.metrics = newFileSorterMetrics(captureAddr, changefeedID)
+	// the files left are not counted once the sorter exits
+	defer func() {
+		fs.cache.addOnDiskBytes(-atomic.LoadInt64(&fs.cache.onDiskBytes))
+	}()
+
+	wg, ctx := util.SafeGroupWithContext(ctx)
 
 	wg.Go(func() error {
 		return fs.sortAndOutput(ctx)
@@ -500,6 +533,8 @@ func (fs *FileSorter) Run(ctx context.Context) error {
 
 func (fs *FileSorter) sortAndOutput(ctx context.Context) error {
 	buffer := make([]*model.PolymorphicEvent, 0, defaultSorterBufferSize)
+	var bufferSize int64
+	defer fs.memQuota.Close()
 
 	flush := func() error {
 		err := fs.cache.flush(ctx, buffer)
@@ -507,6 +542,8 @@ func (fs *FileSorter) sortAndOutput(ctx context.Context) error {
 			return errors.Trace(err)
 		}
 		buffer = buffer[:0]
+		fs.memQuota.Release(bufferSize)
+		bufferSize = 0
 		return nil
 	}
 
@@ -514,23 +551,26 @@ func (fs *FileSorter) sortAndOutput(ctx context.Context) error {
 		select {
 		case <-ctx.Done():
 			return errors.Trace(ctx.Err())
-		case ev := <-fs.inputCh:
-			if ev.RawKV.OpType == model.OpTypeResolved {
-				err := flush()
-				if err != nil {
-					return errors.Trace(err)
+		case evs := <-fs.inputCh:
+			for _, ev := range evs {
+				if ev.RawKV.OpType == model.OpTypeResolved {
+					err := flush()
+					if err != nil {
+						return errors.Trace(err)
+					}
+					err = fs.rotate(ctx, ev.RawKV.CRTs)
+					if err != nil {
+						return errors.Trace(err)
+					}
+					continue
 				}
-				err = fs.rotate(ctx, ev.RawKV.CRTs)
-				if err != nil {
-					return errors.Trace(err)
-				}
-				continue
-			}
-			buffer = append(buffer, ev)
-			if len(buffer) >= defaultSorterBufferSize {
-				err := flush()
-				if err != nil {
-					return errors.Trace(err)
+				buffer = append(buffer, ev)
+				bufferSize += ev.RawKV.ApproximateSize()
+				if len(buffer) >= defaultSorterBufferSize {
+					err := flush()
+					if err != nil {
+						return errors.Trace(err)
+					}
 				}
 			}
 		}
@@ -0,0 +1,73 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package puller
+
+import (
+	"context"
+	"time"
+
+	"github.com/pingcap/check"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/pkg/util"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type fileSorterSuite struct{}
+
+var _ = check.Suite(&fileSorterSuite{})
+
+func (s *fileSorterSuite) TestFileSorterMetrics(c *check.C) {
+	ctx := util.PutCaptureAddrInCtx(context.Background(), "capture-file-sorter")
+	ctx = util.PutChangefeedIDInCtx(ctx, "changefeed-file-sorter")
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sorter := NewFileSorter(c.MkDir())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- sorter.Run(ctx)
+	}()
+
+	for _, ts := range []uint64{3, 1, 2} {
+		ev := model.NewPolymorphicEvent(&model.RawKVEntry{OpType: model.OpTypePut, CRTs: ts})
+		ev.Row = &model.RowChangedEvent{CommitTs: ts}
+		ev.PrepareFinished()
+		sorter.AddEntry(ctx, ev)
+	}
+	sorter.AddEntry(ctx, model.NewResolvedPolymorphicEvent(0, 2))
+
+	var outputs []uint64
+	for len(outputs) < 3 {
+		select {
+		case ev := <-sorter.Output():
+			outputs = append(outputs, ev.CRTs)
+		case <-time.After(10 * time.Second):
+			c.Fatal("the sorted events are not output")
+		}
+	}
+	c.Assert(outputs, check.DeepEquals, []uint64{1, 2, 2})
+
+	metrics := sorter.cache.metrics
+	c.Assert(testutil.ToFloat64(metrics.spillBytes), check.Greater, float64(0))
+	c.Assert(testutil.ToFloat64(metrics.activeFlushes), check.Equals, float64(0))
+	c.Assert(testutil.ToFloat64(metrics.filePoolHit), check.Equals, float64(1))
+	c.Assert(testutil.ToFloat64(metrics.filePoolMiss), check.Equals, float64(0))
+	// the event at ts 3 is kept in the last sorted file
+	c.Assert(testutil.ToFloat64(metrics.onDiskBytes), check.Greater, float64(0))
+
+	cancel()
+	c.Assert(errors.Cause(<-errCh), check.Equals, context.Canceled)
+	c.Assert(testutil.ToFloat64(metrics.onDiskBytes), check.Equals, float64(0))
+}
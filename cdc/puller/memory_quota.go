@@ -0,0 +1,192 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package puller
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/pkg/buckets"
+	cdcContext "github.com/pingcap/ticdc/pkg/context"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// MemoryQuota accounts the memory of the events buffered by a single table
+// against a bucket, which may be shared by many tables.
+//
+// The events between two resolved events can't be flushed out of a sorter
+// until the later resolved event arrives, so blocking on them for too long may
+// cause a deadlock. Once an acquisition is blocked longer than the wait
+// timeout, the quota overcommits without waiting until the memory is released
+// below the quota, so that the wait is not repeated on every resolved event.
+// The overcommit is capped at the quota of the bucket, after which the
+// acquisitions block until the memory is released, so that a table flooding
+// its quota is still pushed back.
+//
+// Acquire must be called in a single goroutine, while Release and Close can be
+// called concurrently. All methods of a nil MemoryQuota are no-ops.
+type MemoryQuota struct {
+	bucket      *buckets.Bucket
+	waitTimeout time.Duration
+	overCommit  bool
+	// overCommitted is the memory acquired beyond the quota since the
+	// overcommit starts
+	overCommitted int64
+
+	mu     sync.Mutex
+	held   int64
+	closed bool
+}
+
+// NewMemoryQuota creates a MemoryQuota acquiring memory from the bucket.
+// It returns nil if the bucket is nil, which means no limit.
+func NewMemoryQuota(bucket *buckets.Bucket, waitTimeout time.Duration) *MemoryQuota {
+	if bucket == nil {
+		return nil
+	}
+	return &MemoryQuota{
+		bucket:      bucket,
+		waitTimeout: waitTimeout,
+	}
+}
+
+// Acquire acquires the memory of the event, blocking while the bucket is
+// exhausted. Resolved events take no memory.
+func (q *MemoryQuota) Acquire(ctx context.Context, ev *model.PolymorphicEvent) error {
+	if q == nil {
+		return nil
+	}
+	return errors.Trace(q.acquireSize(ctx, eventSize(ev)))
+}
+
+// AcquireBatch acquires the memory of the events at once, so that the bucket
+// is only waited on once for a batch.
+func (q *MemoryQuota) AcquireBatch(ctx context.Context, evs []*model.PolymorphicEvent) error {
+	if q == nil {
+		return nil
+	}
+	var size int64
+	for _, ev := range evs {
+		size += eventSize(ev)
+	}
+	return errors.Trace(q.acquireSize(ctx, size))
+}
+
+func eventSize(ev *model.PolymorphicEvent) int64 {
+	if ev.RawKV == nil || ev.RawKV.OpType == model.OpTypeResolved {
+		return 0
+	}
+	return ev.RawKV.ApproximateSize()
+}
+
+func (q *MemoryQuota) acquireSize(ctx context.Context, size int64) error {
+	if size == 0 {
+		return nil
+	}
+	switch {
+	case !q.overCommit:
+		if err := q.acquire(ctx, size); err != nil {
+			return errors.Trace(err)
+		}
+	case q.bucket.TryAcquire(size):
+		q.overCommit = false
+	case q.overCommitted+size <= q.bucket.Quota():
+		q.bucket.ForceAcquire(size)
+		q.overCommitted += size
+	default:
+		if err := q.acquireCapped(ctx, size); err != nil {
+			return errors.Trace(err)
+		}
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		q.bucket.Release(size)
+		return cerror.ErrBucketClosed.GenWithStackByArgs()
+	}
+	q.held += size
+	return nil
+}
+
+func (q *MemoryQuota) acquire(ctx context.Context, size int64) error {
+	waitCtx, cancel := cdcContext.WithStageTimeout(ctx, "memory quota", q.waitTimeout)
+	err := q.bucket.Acquire(waitCtx, size)
+	cancel()
+	switch {
+	case err == nil:
+		return nil
+	case ctx.Err() != nil:
+		return errors.Trace(ctx.Err())
+	case cerror.ErrBucketClosed.Equal(errors.Cause(err)):
+		return errors.Trace(err)
+	}
+	log.Warn("memory quota exceeded, overcommit until the memory is released",
+		append(cdcContext.ZapFields(ctx), zap.Int64("size", size), zap.Error(cdcContext.StageErr(waitCtx, err)))...)
+	q.overCommit = true
+	q.overCommitted = size
+	q.bucket.ForceAcquire(size)
+	return nil
+}
+
+// acquireCapped blocks until the memory released repays the overcommit.
+func (q *MemoryQuota) acquireCapped(ctx context.Context, size int64) error {
+	log.Warn("memory quota overcommit is capped, wait until the memory is released",
+		append(cdcContext.ZapFields(ctx), zap.Int64("size", size), zap.Int64("overCommitted", q.overCommitted))...)
+	err := q.bucket.Acquire(ctx, size)
+	if cerror.ErrBucketAcquireTooLarge.Equal(errors.Cause(err)) {
+		// the event never fits in the bucket, waiting for it is pointless
+		q.bucket.ForceAcquire(size)
+		err = nil
+	}
+	if err != nil {
+		return errors.Trace(err)
+	}
+	q.overCommit = false
+	return nil
+}
+
+// Release gives back the memory of freed events.
+func (q *MemoryQuota) Release(size int64) {
+	if q == nil || size == 0 {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	q.held -= size
+	q.bucket.Release(size)
+}
+
+// Close gives back all the memory held and rejects further acquisitions.
+func (q *MemoryQuota) Close() {
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	q.closed = true
+	q.bucket.Release(q.held)
+	q.held = 0
+}
@@ -0,0 +1,117 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package puller
+
+import (
+	"context"
+	"time"
+
+	"github.com/pingcap/check"
+	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/pkg/buckets"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+)
+
+type memoryQuotaSuite struct{}
+
+var _ = check.Suite(&memoryQuotaSuite{})
+
+func newDataEvent(size int) *model.PolymorphicEvent {
+	return model.NewPolymorphicEvent(&model.RawKVEntry{
+		OpType: model.OpTypePut,
+		Key:    make([]byte, size),
+	})
+}
+
+func (s *memoryQuotaSuite) TestMemoryQuota(c *check.C) {
+	ctx := context.Background()
+	g := buckets.NewBucketGroup(100, time.Second)
+	b, err := g.CreateBucketWithMode(buckets.BucketModeResidency, 0, 100, 0)
+	c.Assert(err, check.IsNil)
+	q := NewMemoryQuota(b, 50*time.Millisecond)
+
+	c.Assert(q.Acquire(ctx, newDataEvent(60)), check.IsNil)
+	c.Assert(q.Acquire(ctx, model.NewResolvedPolymorphicEvent(0, 1)), check.IsNil)
+	c.Assert(b.Available(), check.Equals, int64(40))
+
+	// overcommit after the wait timeout until the memory is released
+	c.Assert(q.Acquire(ctx, newDataEvent(60)), check.IsNil)
+	c.Assert(b.Available(), check.Equals, int64(-20))
+	c.Assert(q.Acquire(ctx, newDataEvent(10)), check.IsNil)
+	c.Assert(b.Available(), check.Equals, int64(-30))
+	// the wait is not repeated after the resolved event
+	c.Assert(q.Acquire(ctx, model.NewResolvedPolymorphicEvent(0, 2)), check.IsNil)
+	c.Assert(q.overCommit, check.IsTrue)
+	start := time.Now()
+	c.Assert(q.Acquire(ctx, newDataEvent(10)), check.IsNil)
+	c.Assert(time.Since(start) < 50*time.Millisecond, check.IsTrue)
+	c.Assert(b.Available(), check.Equals, int64(-40))
+
+	q.Release(60)
+	c.Assert(b.Available(), check.Equals, int64(20))
+	c.Assert(q.Acquire(ctx, newDataEvent(10)), check.IsNil)
+	c.Assert(q.overCommit, check.IsFalse)
+	c.Assert(b.Available(), check.Equals, int64(10))
+
+	// the overcommit is capped at the quota, then the acquisition blocks
+	// until the memory is released
+	c.Assert(q.Acquire(ctx, newDataEvent(60)), check.IsNil)
+	c.Assert(q.Acquire(ctx, newDataEvent(30)), check.IsNil)
+	c.Assert(b.Available(), check.Equals, int64(-80))
+	acquired := make(chan error, 1)
+	go func() {
+		acquired <- q.Acquire(ctx, newDataEvent(20))
+	}()
+	select {
+	case err := <-acquired:
+		c.Fatalf("the acquisition beyond the cap doesn't block: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	q.Release(100)
+	c.Assert(<-acquired, check.IsNil)
+	c.Assert(q.overCommit, check.IsFalse)
+	c.Assert(b.Available(), check.Equals, int64(0))
+	q.Close()
+	c.Assert(b.Available(), check.Equals, int64(100))
+	err = q.Acquire(ctx, newDataEvent(10))
+	c.Assert(cerror.ErrBucketClosed.Equal(err), check.IsTrue)
+	c.Assert(b.Available(), check.Equals, int64(100))
+
+	// a nil quota doesn't limit anything
+	var nilQuota *MemoryQuota
+	c.Assert(nilQuota.Acquire(ctx, newDataEvent(1000)), check.IsNil)
+	nilQuota.Release(1000)
+	nilQuota.Close()
+}
+
+func (s *memoryQuotaSuite) TestAcquireBatch(c *check.C) {
+	ctx := context.Background()
+	g := buckets.NewBucketGroup(100, time.Second)
+	b, err := g.CreateBucketWithMode(buckets.BucketModeResidency, 0, 100, 0)
+	c.Assert(err, check.IsNil)
+	q := NewMemoryQuota(b, 50*time.Millisecond)
+
+	batch := []*model.PolymorphicEvent{
+		newDataEvent(30), model.NewResolvedPolymorphicEvent(0, 1), newDataEvent(20),
+	}
+	c.Assert(q.AcquireBatch(ctx, batch), check.IsNil)
+	c.Assert(b.Available(), check.Equals, int64(50))
+	// the batch is acquired as a whole, it overcommits after the wait timeout
+	c.Assert(q.AcquireBatch(ctx, []*model.PolymorphicEvent{newDataEvent(30), newDataEvent(30)}), check.IsNil)
+	c.Assert(q.overCommit, check.IsTrue)
+	c.Assert(b.Available(), check.Equals, int64(-10))
+	q.Release(110)
+	c.Assert(b.Available(), check.Equals, int64(100))
+	c.Assert(q.AcquireBatch(ctx, nil), check.IsNil)
+}
@@ -45,6 +45,20 @@ var (
 			Name:      "resolved_ts",
 			Help:      "puller forward resolved ts",
 		}, []string{"capture", "changefeed", "table"})
+	resolvedTsStallGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "ticdc",
+			Subsystem: "puller",
+			Name:      "resolved_ts_stall_seconds",
+			Help:      "How long the puller resolved ts hasn't advanced",
+		}, []string{"capture", "changefeed", "table"})
+	stuckRegionGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "ticdc",
+			Subsystem: "puller",
+			Name:      "stuck_region_count",
+			Help:      "The number of regions holding back the stuck puller resolved ts",
+		}, []string{"capture", "changefeed", "table"})
 	outputChanSizeGauge = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "ticdc",
@@ -52,6 +66,14 @@ var (
 			Name:      "output_chan_size",
 			Help:      "Puller entry buffer size",
 		}, []string{"capture", "changefeed", "table"})
+	outputBatchSizeHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "ticdc",
+			Subsystem: "puller",
+			Name:      "output_batch_size",
+			Help:      "The number of entries in a batch sent by puller",
+			Buckets:   prometheus.ExponentialBuckets(1, 2, 11),
+		}, []string{"capture", "changefeed"})
 	memBufferSizeGauge = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "ticdc",
@@ -103,6 +125,45 @@ var (
 			Help:      "Bucketed histogram of processing time (s) of merge in entry sorter.",
 			Buckets:   prometheus.ExponentialBuckets(0.000001, 10, 10),
 		}, []string{"capture", "changefeed", "table"})
+
+	// the metrics of the file sorter, which are aggregated by changefeed since
+	// there is a sorter for each table
+	sorterSpillBytesCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "ticdc",
+			Subsystem: "sorter",
+			Name:      "spill_bytes_total",
+			Help:      "The bytes of the events spilled from memory to the unsorted files",
+		}, []string{"capture", "changefeed"})
+	sorterActiveFlushTasksGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "ticdc",
+			Subsystem: "sorter",
+			Name:      "active_flush_tasks",
+			Help:      "The number of the flushes of the events to files in progress",
+		}, []string{"capture", "changefeed"})
+	sorterMergeDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "ticdc",
+			Subsystem: "sorter",
+			Name:      "merge_duration_seconds",
+			Help:      "Bucketed histogram of the duration (s) of merging the sorted files, which happens on each resolved ts",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 2, 18),
+		}, []string{"capture", "changefeed"})
+	sorterFilePoolCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "ticdc",
+			Subsystem: "sorter",
+			Name:      "file_pool_access_count",
+			Help:      "The number of the flushes appending to a pooled unsorted file (hit) or creating new files (miss)",
+		}, []string{"capture", "changefeed", "type"})
+	sorterOnDiskBytesGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "ticdc",
+			Subsystem: "sorter",
+			Name:      "on_disk_bytes",
+			Help:      "The bytes of the files written by the running sorters and not removed yet",
+		}, []string{"capture", "changefeed"})
 )
 
 // InitMetrics registers all metrics in this file
@@ -110,12 +171,41 @@ func InitMetrics(registry *prometheus.Registry) {
 	registry.MustRegister(kvEventCounter)
 	registry.MustRegister(txnCollectCounter)
 	registry.MustRegister(pullerResolvedTsGauge)
+	registry.MustRegister(resolvedTsStallGauge)
+	registry.MustRegister(stuckRegionGauge)
 	registry.MustRegister(memBufferSizeGauge)
 	registry.MustRegister(outputChanSizeGauge)
+	registry.MustRegister(outputBatchSizeHistogram)
 	registry.MustRegister(eventChanSizeGauge)
 	registry.MustRegister(entrySorterResolvedChanSizeGauge)
 	registry.MustRegister(entrySorterOutputChanSizeGauge)
 	registry.MustRegister(entrySorterUnsortedSizeGauge)
 	registry.MustRegister(entrySorterSortDuration)
 	registry.MustRegister(entrySorterMergeDuration)
+	registry.MustRegister(sorterSpillBytesCounter)
+	registry.MustRegister(sorterActiveFlushTasksGauge)
+	registry.MustRegister(sorterMergeDuration)
+	registry.MustRegister(sorterFilePoolCounter)
+	registry.MustRegister(sorterOnDiskBytesGauge)
+}
+
+// fileSorterMetrics is the metrics of a file sorter
+type fileSorterMetrics struct {
+	spillBytes    prometheus.Counter
+	activeFlushes prometheus.Gauge
+	mergeDuration prometheus.Observer
+	filePoolHit   prometheus.Counter
+	filePoolMiss  prometheus.Counter
+	onDiskBytes   prometheus.Gauge
+}
+
+func newFileSorterMetrics(captureAddr, changefeedID string) *fileSorterMetrics {
+	return &fileSorterMetrics{
+		spillBytes:    sorterSpillBytesCounter.WithLabelValues(captureAddr, changefeedID),
+		activeFlushes: sorterActiveFlushTasksGauge.WithLabelValues(captureAddr, changefeedID),
+		mergeDuration: sorterMergeDuration.WithLabelValues(captureAddr, changefeedID),
+		filePoolHit:   sorterFilePoolCounter.WithLabelValues(captureAddr, changefeedID, "hit"),
+		filePoolMiss:  sorterFilePoolCounter.WithLabelValues(captureAddr, changefeedID, "miss"),
+		onDiskBytes:   sorterOnDiskBytesGauge.WithLabelValues(captureAddr, changefeedID),
+	}
 }
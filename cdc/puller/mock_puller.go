@@ -131,7 +131,7 @@ type mockPuller struct {
 	rawKVOffset int
 }
 
-func (p *mockPuller) Output() <-chan *model.RawKVEntry {
+func (p *mockPuller) Output() <-chan []*model.RawKVEntry {
 	panic("implement me")
 }
 
@@ -180,6 +180,10 @@ func (p *mockPuller) IsInitialized() bool {
 	return false
 }
 
+func (p *mockPuller) DiagnoseResolvedTs() ResolvedTsDiagnosis {
+	return ResolvedTsDiagnosis{ResolvedTs: p.resolvedTs}
+}
+
 // NewMockPullerManager creates and sets up a mock puller manager
 func NewMockPullerManager(c *check.C, newRowFormat bool) *MockPullerManager {
 	m := &MockPullerManager{
@@ -23,6 +23,7 @@ import (
 	"github.com/pingcap/ticdc/cdc/kv"
 	"github.com/pingcap/ticdc/cdc/model"
 	"github.com/pingcap/ticdc/cdc/puller/frontier"
+	"github.com/pingcap/ticdc/pkg/logutil"
 	"github.com/pingcap/ticdc/pkg/regionspan"
 	"github.com/pingcap/ticdc/pkg/security"
 	"github.com/pingcap/ticdc/pkg/txnutil"
@@ -32,12 +33,15 @@ import (
 	"github.com/pingcap/tidb/store/tikv/oracle"
 	pd "github.com/tikv/pd/client"
 	"go.uber.org/zap"
-	"golang.org/x/sync/errgroup"
 )
 
 const (
 	defaultPullerEventChanSize  = 128000
-	defaultPullerOutputChanSize = 128000
+	defaultPullerOutputChanSize = 128
+	// The puller outputs the entries in batches bounded by the count and bytes,
+	// so that the high-throughput tables don't pay a channel operation per entry.
+	defaultPullerOutputBatchCount = 1024
+	defaultPullerOutputBatchBytes = 4 * 1024 * 1024
 )
 
 // Puller pull data from tikv and push changes into a buffer
@@ -45,8 +49,13 @@ type Puller interface {
 	// Run the puller, continually fetch event from TiKV and add event into buffer
 	Run(ctx context.Context) error
 	GetResolvedTs() uint64
-	Output() <-chan *model.RawKVEntry
+	// Output returns the batches of the entries in the order they're received,
+	// a batch is never empty.
+	Output() <-chan []*model.RawKVEntry
 	IsInitialized() bool
+	// DiagnoseResolvedTs tells whether the resolved ts is stuck and the regions
+	// holding it back.
+	DiagnoseResolvedTs() ResolvedTsDiagnosis
 }
 
 type pullerImpl struct {
@@ -56,8 +65,9 @@ type pullerImpl struct {
 	checkpointTs   uint64
 	spans          []regionspan.ComparableSpan
 	buffer         *memBuffer
-	outputCh       chan *model.RawKVEntry
+	outputCh       chan []*model.RawKVEntry
 	tsTracker      frontier.Frontier
+	regionTracker  *regionResolvedTsTracker
 	resolvedTs     uint64
 	initialized    int64
 	enableOldValue bool
@@ -92,9 +102,10 @@ func NewPuller(
 		kvStorage:      tikvStorage,
 		checkpointTs:   checkpointTs,
 		spans:          comparableSpans,
-		buffer:         makeMemBuffer(limitter),
-		outputCh:       make(chan *model.RawKVEntry, defaultPullerOutputChanSize),
+		buffer:         makeMemBuffer(limitter, defaultTableBufferBudget),
+		outputCh:       make(chan []*model.RawKVEntry, defaultPullerOutputChanSize),
 		tsTracker:      tsTracker,
+		regionTracker:  newRegionResolvedTsTracker(time.Now()),
 		resolvedTs:     checkpointTs,
 		initialized:    0,
 		enableOldValue: enableOldValue,
@@ -102,10 +113,14 @@ func NewPuller(
 	return p
 }
 
-func (p *pullerImpl) Output() <-chan *model.RawKVEntry {
+func (p *pullerImpl) Output() <-chan []*model.RawKVEntry {
 	return p.outputCh
 }
 
+func (p *pullerImpl) DiagnoseResolvedTs() ResolvedTsDiagnosis {
+	return p.regionTracker.diagnose(time.Now())
+}
+
 // Run the puller, continually fetch event from TiKV and add event into buffer
 func (p *pullerImpl) Run(ctx context.Context) error {
 	cli, err := kv.NewCDCClient(ctx, p.pdCli, p.kvStorage, p.credential)
@@ -115,7 +130,7 @@ func (p *pullerImpl) Run(ctx context.Context) error {
 
 	defer cli.Close()
 
-	g, ctx := errgroup.WithContext(ctx)
+	g, ctx := util.SafeGroupWithContext(ctx)
 
 	checkpointTs := p.checkpointTs
 	eventCh := make(chan *model.RegionFeedEvent, defaultPullerEventChanSize)
@@ -136,10 +151,13 @@ func (p *pullerImpl) Run(ctx context.Context) error {
 	metricEventChanSize := eventChanSizeGauge.WithLabelValues(captureAddr, changefeedID, tableName)
 	metricMemBufferSize := memBufferSizeGauge.WithLabelValues(captureAddr, changefeedID, tableName)
 	metricPullerResolvedTs := pullerResolvedTsGauge.WithLabelValues(captureAddr, changefeedID, tableName)
+	metricOutputBatchSize := outputBatchSizeHistogram.WithLabelValues(captureAddr, changefeedID)
 	metricEventCounterKv := kvEventCounter.WithLabelValues(captureAddr, changefeedID, "kv")
 	metricEventCounterResolved := kvEventCounter.WithLabelValues(captureAddr, changefeedID, "resolved")
 	metricTxnCollectCounterKv := txnCollectCounter.WithLabelValues(captureAddr, changefeedID, tableName, "kv")
 	metricTxnCollectCounterResolved := txnCollectCounter.WithLabelValues(captureAddr, changefeedID, tableName, "resolved")
+	metricResolvedTsStall := resolvedTsStallGauge.WithLabelValues(captureAddr, changefeedID, tableName)
+	metricStuckRegions := stuckRegionGauge.WithLabelValues(captureAddr, changefeedID, tableName)
 	defer func() {
 		outputChanSizeGauge.DeleteLabelValues(captureAddr, changefeedID, tableName)
 		eventChanSizeGauge.DeleteLabelValues(captureAddr, changefeedID, tableName)
@@ -149,6 +167,8 @@ func (p *pullerImpl) Run(ctx context.Context) error {
 		kvEventCounter.DeleteLabelValues(captureAddr, changefeedID, "resolved")
 		txnCollectCounter.DeleteLabelValues(captureAddr, changefeedID, tableName, "kv")
 		txnCollectCounter.DeleteLabelValues(captureAddr, changefeedID, tableName, "resolved")
+		resolvedTsStallGauge.DeleteLabelValues(captureAddr, changefeedID, tableName)
+		stuckRegionGauge.DeleteLabelValues(captureAddr, changefeedID, tableName)
 	}()
 	g.Go(func() error {
 		for {
@@ -160,6 +180,27 @@ func (p *pullerImpl) Run(ctx context.Context) error {
 				metricMemBufferSize.Set(float64(p.buffer.Size()))
 				metricOutputChanSize.Set(float64(len(p.outputCh)))
 				metricPullerResolvedTs.Set(float64(oracle.ExtractPhysical(atomic.LoadUint64(&p.resolvedTs))))
+
+				now := time.Now()
+				diagnosis := p.regionTracker.diagnose(now)
+				metricResolvedTsStall.Set(now.Sub(diagnosis.LastAdvance).Seconds())
+				if !diagnosis.Stuck {
+					metricStuckRegions.Set(0)
+					continue
+				}
+				metricStuckRegions.Set(float64(len(diagnosis.BlockingRegions)))
+				regions := diagnosis.BlockingRegions
+				if len(regions) > maxLoggedBlockingRegions {
+					regions = regions[:maxLoggedBlockingRegions]
+				}
+				log.Warn("puller resolved ts is stuck",
+					zap.String("changefeedid", changefeedID),
+					zap.Int64("tableID", tableID),
+					zap.Uint64("resolvedTs", diagnosis.ResolvedTs),
+					zap.Duration("duration", now.Sub(diagnosis.LastAdvance)),
+					zap.Int("blockingRegionCount", len(diagnosis.BlockingRegions)),
+					zap.Reflect("blockingStores", diagnosis.BlockingStores()),
+					zap.Reflect("blockingRegions", regions))
 			}
 		}
 	})
@@ -177,6 +218,7 @@ func (p *pullerImpl) Run(ctx context.Context) error {
 					// we can make tikv only return the events about the keys in the specified range.
 					comparableKey := regionspan.ToComparableKey(val.Key)
 					if !regionspan.KeyInSpans(comparableKey, p.spans) {
+						val.Trace.Finish()
 						// log.Warn("key not in spans range", zap.Binary("key", val.Key), zap.Stringer("span", p.spans))
 						continue
 					}
@@ -198,69 +240,85 @@ func (p *pullerImpl) Run(ctx context.Context) error {
 
 	lastResolvedTs := p.checkpointTs
 	g.Go(func() error {
-		output := func(raw *model.RawKVEntry) error {
-			if raw.CRTs < p.resolvedTs || (raw.CRTs == p.resolvedTs && raw.OpType != model.OpTypeResolved) {
+		var batch []*model.RawKVEntry
+		// lastResolvedTs is the last resolved ts put into the batch, it's
+		// stored to p.resolvedTs only after the batch is sent, so that the
+		// resolved ts never runs ahead of the entries not sent yet.
+		output := func(raw *model.RawKVEntry) {
+			if raw.CRTs < lastResolvedTs || (raw.CRTs == lastResolvedTs && raw.OpType != model.OpTypeResolved) {
 				log.Fatal("The CRTs must be greater than the resolvedTs",
-					zap.Reflect("row", raw),
+					logutil.ZapRedactReflect("row", raw),
 					zap.Uint64("CRTs", raw.CRTs),
-					zap.Uint64("resolvedTs", p.resolvedTs),
+					zap.Uint64("resolvedTs", lastResolvedTs),
 					zap.Int64("tableID", tableID))
 			}
+			batch = append(batch, raw)
+		}
+		flush := func() error {
+			if len(batch) == 0 {
+				return nil
+			}
+			metricOutputBatchSize.Observe(float64(len(batch)))
 			select {
 			case <-ctx.Done():
 				return errors.Trace(ctx.Err())
-			case p.outputCh <- raw:
+			case p.outputCh <- batch:
 			}
+			batch = nil
 			return nil
 		}
 
 		start := time.Now()
 		initialized := false
 		for {
-			e, err := p.buffer.Get(ctx)
+			events, err := p.buffer.GetBatch(ctx, defaultPullerOutputBatchCount, defaultPullerOutputBatchBytes)
 			if err != nil {
 				return errors.Trace(err)
 			}
-			if e.Val != nil {
-				metricTxnCollectCounterKv.Inc()
-				if err := output(e.Val); err != nil {
-					return errors.Trace(err)
-				}
-			} else if e.Resolved != nil {
-				metricTxnCollectCounterResolved.Inc()
-				if !regionspan.IsSubSpan(e.Resolved.Span, p.spans...) {
-					log.Fatal("the resolved span is not in the total span", zap.Reflect("resolved", e.Resolved), zap.Int64("tableID", tableID))
-				}
-				// Forward is called in a single thread
-				p.tsTracker.Forward(e.Resolved.Span, e.Resolved.ResolvedTs)
-				resolvedTs := p.tsTracker.Frontier()
-				if resolvedTs > 0 && !initialized {
-					// Advancing to a non-zero value means the puller level
-					// resolved ts is initialized.
-					atomic.StoreInt64(&p.initialized, 1)
-					initialized = true
+			for _, e := range events {
+				if e.Val != nil {
+					metricTxnCollectCounterKv.Inc()
+					output(e.Val)
+				} else if e.Resolved != nil {
+					metricTxnCollectCounterResolved.Inc()
+					if !regionspan.IsSubSpan(e.Resolved.Span, p.spans...) {
+						log.Fatal("the resolved span is not in the total span", zap.Reflect("resolved", e.Resolved), zap.Int64("tableID", tableID))
+					}
+					// Forward is called in a single thread
+					p.tsTracker.Forward(e.Resolved.Span, e.Resolved.ResolvedTs)
+					resolvedTs := p.tsTracker.Frontier()
+					now := time.Now()
+					p.regionTracker.forwardRegion(e.RegionID, e.StoreAddr, e.Resolved.Span, e.Resolved.ResolvedTs, now)
+					p.regionTracker.forward(resolvedTs, now)
+					if resolvedTs > 0 && !initialized {
+						// Advancing to a non-zero value means the puller level
+						// resolved ts is initialized.
+						atomic.StoreInt64(&p.initialized, 1)
+						initialized = true
 
-					spans := make([]string, 0, len(p.spans))
-					for i := range p.spans {
-						spans = append(spans, p.spans[i].String())
+						spans := make([]string, 0, len(p.spans))
+						for i := range p.spans {
+							spans = append(spans, p.spans[i].String())
+						}
+						log.Info("puller is initialized",
+							zap.Duration("duration", time.Since(start)),
+							zap.String("changefeedid", changefeedID),
+							zap.Int64("tableID", tableID),
+							zap.Strings("spans", spans),
+							zap.Bool("enableOldValue", p.enableOldValue),
+							zap.Uint64("resolvedTs", resolvedTs))
 					}
-					log.Info("puller is initialized",
-						zap.Duration("duration", time.Since(start)),
-						zap.String("changefeedid", changefeedID),
-						zap.Int64("tableID", tableID),
-						zap.Strings("spans", spans),
-						zap.Uint64("resolvedTs", resolvedTs))
-				}
-				if !initialized || resolvedTs == lastResolvedTs {
-					continue
-				}
-				lastResolvedTs = resolvedTs
-				err := output(&model.RawKVEntry{CRTs: resolvedTs, OpType: model.OpTypeResolved, RegionID: e.RegionID})
-				if err != nil {
-					return errors.Trace(err)
+					if !initialized || resolvedTs == lastResolvedTs {
+						continue
+					}
+					lastResolvedTs = resolvedTs
+					output(&model.RawKVEntry{CRTs: resolvedTs, OpType: model.OpTypeResolved, RegionID: e.RegionID})
 				}
-				atomic.StoreUint64(&p.resolvedTs, resolvedTs)
 			}
+			if err := flush(); err != nil {
+				return errors.Trace(err)
+			}
+			atomic.StoreUint64(&p.resolvedTs, lastResolvedTs)
 		}
 	})
 	return g.Wait()
@@ -21,7 +21,8 @@ import (
 
 	"github.com/pingcap/log"
 	"github.com/pingcap/ticdc/cdc/model"
-	"golang.org/x/sync/errgroup"
+	cdcContext "github.com/pingcap/ticdc/pkg/context"
+	"github.com/pingcap/ticdc/pkg/util"
 )
 
 // Rectifier filters and collates the output stream from the sorter
@@ -63,14 +64,15 @@ func (r *Rectifier) SafeStop() {
 
 // Run running the Rectifier
 func (r *Rectifier) Run(ctx context.Context) error {
+	ctx = cdcContext.WithSync(ctx)
 	output := func(event *model.PolymorphicEvent) {
 		select {
 		case <-ctx.Done():
-			log.Warn("failed to send to output channel", zap.Error(ctx.Err()))
+			log.Warn("failed to send to output channel", append(cdcContext.ZapFields(ctx), zap.Error(ctx.Err()))...)
 		case r.outputCh <- event:
 		}
 	}
-	errg, ctx := errgroup.WithContext(ctx)
+	errg, ctx := util.SafeGroupWithContext(ctx)
 	errg.Go(func() error {
 		return r.EventSorter.Run(ctx)
 	})
@@ -21,6 +21,8 @@ import (
 
 	"github.com/pingcap/check"
 	"github.com/pingcap/ticdc/cdc/model"
+	cdcContext "github.com/pingcap/ticdc/pkg/context"
+	"github.com/pingcap/ticdc/pkg/util"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -51,6 +53,12 @@ func (m *mockSorter) AddEntry(ctx context.Context, entry *model.PolymorphicEvent
 	}
 }
 
+func (m *mockSorter) AddEntries(ctx context.Context, entries []*model.PolymorphicEvent) {
+	for _, entry := range entries {
+		m.AddEntry(ctx, entry)
+	}
+}
+
 func (m *mockSorter) Output() <-chan *model.PolymorphicEvent {
 	return m.outputCh
 }
@@ -194,3 +202,45 @@ func (s *rectifierSuite) TestRectifierFinished(c *check.C) {
 	cancel()
 	c.Assert(errg.Wait(), check.IsNil)
 }
+
+type panicSorter struct {
+	*mockSorter
+}
+
+func (m *panicSorter) Run(ctx context.Context) error {
+	panic("sorter panicked")
+}
+
+func (s *rectifierSuite) TestRectifierSorterPanic(c *check.C) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	r := NewRectifier(&panicSorter{mockSorter: newMockSorter()}, math.MaxUint64)
+	// the panic of the sorter is returned, so it fails the table only
+	err := r.Run(ctx)
+	panicErr, ok := err.(*util.PanicError)
+	c.Assert(ok, check.IsTrue, check.Commentf("%v", err))
+	c.Assert(panicErr.Value, check.Equals, "sorter panicked")
+	c.Assert(panicErr.Stack, check.Matches, "(?s).*panicSorter.*")
+}
+
+type asyncCheckSorter struct {
+	*mockSorter
+	async chan bool
+}
+
+func (m *asyncCheckSorter) Run(ctx context.Context) error {
+	m.async <- cdcContext.IsAsync(ctx)
+	return nil
+}
+
+func (s *rectifierSuite) TestRectifierInAsyncSection(c *check.C) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	sorter := &asyncCheckSorter{mockSorter: newMockSorter(), async: make(chan bool, 1)}
+	close(sorter.outputCh)
+	r := NewRectifier(sorter, math.MaxUint64)
+	// the sorter runs in its own goroutine, so it waits even if the rectifier
+	// is started in an async section
+	c.Assert(r.Run(cdcContext.WithAsync(ctx)), check.IsNil)
+	c.Assert(<-sorter.async, check.IsFalse)
+}
@@ -0,0 +1,142 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package puller
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pingcap/ticdc/pkg/regionspan"
+)
+
+const (
+	// resolvedTsStuckThreshold is how long the resolved ts of a puller doesn't
+	// advance before it's considered stuck. The kv client tries to resolve the
+	// locks of a region after its resolved ts doesn't advance for 20s, so the
+	// threshold is longer than that.
+	resolvedTsStuckThreshold = time.Minute
+	// maxLoggedBlockingRegions is the max number of the blocking regions in a log
+	maxLoggedBlockingRegions = 10
+)
+
+// RegionResolvedTs is the resolved ts of a region span received by a puller
+type RegionResolvedTs struct {
+	RegionID  uint64 `json:"region-id"`
+	StoreAddr string `json:"store"`
+	Span      string `json:"span"`
+	// ResolvedTs is the last resolved ts of the region span
+	ResolvedTs uint64 `json:"resolved-ts"`
+	// LastAdvance is when the resolved ts of the region span advanced last time
+	LastAdvance time.Time `json:"last-advance"`
+}
+
+// ResolvedTsDiagnosis tells whether the resolved ts of a puller is stuck, and
+// the regions holding it back.
+type ResolvedTsDiagnosis struct {
+	ResolvedTs  uint64    `json:"resolved-ts"`
+	LastAdvance time.Time `json:"last-advance"`
+	Stuck       bool      `json:"stuck"`
+	// BlockingRegions are the region spans whose resolved ts is the resolved
+	// ts of the puller, the ones stalled longest first.
+	BlockingRegions []RegionResolvedTs `json:"blocking-regions"`
+}
+
+// BlockingStores returns the number of the blocking regions of each store
+func (d ResolvedTsDiagnosis) BlockingStores() map[string]int {
+	stores := make(map[string]int)
+	for _, r := range d.BlockingRegions {
+		stores[r.StoreAddr]++
+	}
+	return stores
+}
+
+// regionSpanKey identifies a region span, a region may have several spans in
+// a puller if it's split for hot traffic.
+type regionSpanKey struct {
+	regionID uint64
+	start    string
+}
+
+// regionResolvedTsTracker tracks the resolved ts advancement of the region
+// spans of a puller, to find the regions holding back the resolved ts of the
+// puller when it's stuck.
+type regionResolvedTsTracker struct {
+	mu          sync.Mutex
+	regions     map[regionSpanKey]*RegionResolvedTs
+	resolvedTs  uint64
+	lastAdvance time.Time
+}
+
+func newRegionResolvedTsTracker(now time.Time) *regionResolvedTsTracker {
+	return &regionResolvedTsTracker{
+		regions:     make(map[regionSpanKey]*RegionResolvedTs),
+		lastAdvance: now,
+	}
+}
+
+// forwardRegion records the resolved ts of a region span
+func (t *regionResolvedTsTracker) forwardRegion(regionID uint64, storeAddr string, span regionspan.ComparableSpan, ts uint64, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key := regionSpanKey{regionID: regionID, start: string(span.Start)}
+	r, ok := t.regions[key]
+	if !ok {
+		r = &RegionResolvedTs{RegionID: regionID, LastAdvance: now}
+		t.regions[key] = r
+	} else if ts > r.ResolvedTs {
+		r.LastAdvance = now
+	}
+	r.StoreAddr = storeAddr
+	r.Span = span.String()
+	r.ResolvedTs = ts
+}
+
+// forward records the resolved ts of the puller
+func (t *regionResolvedTsTracker) forward(ts uint64, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if ts != t.resolvedTs {
+		t.resolvedTs = ts
+		t.lastAdvance = now
+	}
+}
+
+// diagnose finds the regions holding back the resolved ts of the puller. The
+// resolved ts of a live region span is never less than the resolved ts of the
+// puller, so the ones less than it are left by the regions split or merged,
+// and they're removed.
+func (t *regionResolvedTsTracker) diagnose(now time.Time) ResolvedTsDiagnosis {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	d := ResolvedTsDiagnosis{
+		ResolvedTs:  t.resolvedTs,
+		LastAdvance: t.lastAdvance,
+		// The resolved ts of a puller is 0 before it's initialized.
+		Stuck: t.resolvedTs != 0 && now.Sub(t.lastAdvance) > resolvedTsStuckThreshold,
+	}
+	for key, r := range t.regions {
+		if r.ResolvedTs < t.resolvedTs {
+			delete(t.regions, key)
+			continue
+		}
+		if r.ResolvedTs == t.resolvedTs {
+			d.BlockingRegions = append(d.BlockingRegions, *r)
+		}
+	}
+	sort.Slice(d.BlockingRegions, func(i, j int) bool {
+		return d.BlockingRegions[i].LastAdvance.Before(d.BlockingRegions[j].LastAdvance)
+	})
+	return d
+}
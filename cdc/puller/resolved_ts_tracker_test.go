@@ -0,0 +1,68 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package puller
+
+import (
+	"time"
+
+	"github.com/pingcap/check"
+	"github.com/pingcap/ticdc/pkg/regionspan"
+)
+
+type resolvedTsTrackerSuite struct{}
+
+var _ = check.Suite(&resolvedTsTrackerSuite{})
+
+func (s *resolvedTsTrackerSuite) TestDiagnose(c *check.C) {
+	spanAB := regionspan.ComparableSpan{Start: []byte("a"), End: []byte("b")}
+	spanBC := regionspan.ComparableSpan{Start: []byte("b"), End: []byte("c")}
+	spanCD := regionspan.ComparableSpan{Start: []byte("c"), End: []byte("d")}
+	t0 := time.Now()
+	t := newRegionResolvedTsTracker(t0)
+
+	// not initialized
+	d := t.diagnose(t0.Add(time.Hour))
+	c.Assert(d.Stuck, check.IsFalse)
+	c.Assert(d.BlockingRegions, check.HasLen, 0)
+
+	t.forwardRegion(1, "store1", spanAB, 10, t0)
+	t.forwardRegion(2, "store2", spanBC, 10, t0)
+	t.forwardRegion(3, "store2", spanCD, 10, t0)
+	t.forward(10, t0)
+	t1 := t0.Add(time.Second)
+	t.forwardRegion(1, "store1", spanAB, 20, t1)
+	t.forwardRegion(3, "store2", spanCD, 20, t1)
+
+	d = t.diagnose(t1)
+	c.Assert(d.Stuck, check.IsFalse)
+	c.Assert(d.ResolvedTs, check.Equals, uint64(10))
+	c.Assert(d.BlockingRegions, check.HasLen, 1)
+	c.Assert(d.BlockingRegions[0].RegionID, check.Equals, uint64(2))
+
+	d = t.diagnose(t0.Add(resolvedTsStuckThreshold + time.Second))
+	c.Assert(d.Stuck, check.IsTrue)
+	c.Assert(d.BlockingRegions, check.HasLen, 1)
+	c.Assert(d.BlockingStores(), check.DeepEquals, map[string]int{"store2": 1})
+
+	// Region 2 is merged into region 3, the state of region 2 is removed once
+	// the resolved ts advances.
+	t2 := t1.Add(time.Second)
+	t.forwardRegion(3, "store2", regionspan.ComparableSpan{Start: []byte("b"), End: []byte("d")}, 20, t2)
+	t.forward(20, t2)
+	d = t.diagnose(t2)
+	c.Assert(d.Stuck, check.IsFalse)
+	c.Assert(d.BlockingRegions, check.HasLen, 3)
+	c.Assert(d.BlockingRegions[2].RegionID, check.Equals, uint64(3))
+	c.Assert(t.regions, check.HasLen, 3)
+}
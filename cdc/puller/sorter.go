@@ -20,9 +20,14 @@ import (
 )
 
 // EventSorter accepts unsorted PolymorphicEvents, sort them in background and returns
-// sorted PolymorphicEvents in Output channel
+// sorted PolymorphicEvents in Output channel.
+// Run and the AddEntry methods wait even if they're called in an async
+// section, since they have no ErrWouldBlock to return.
 type EventSorter interface {
 	Run(ctx context.Context) error
 	AddEntry(ctx context.Context, entry *model.PolymorphicEvent)
+	// AddEntries adds a batch of entries in order, it's the same as calling
+	// AddEntry for each entry but cheaper.
+	AddEntries(ctx context.Context, entries []*model.PolymorphicEvent)
 	Output() <-chan *model.PolymorphicEvent
 }
@@ -16,16 +16,24 @@ package cdc
 import (
 	"context"
 	"crypto/tls"
+	"io"
 	"net"
 	"net/http"
+	"os"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/pingcap/errors"
 	"github.com/pingcap/log"
+	"github.com/pingcap/ticdc/cdc/kv"
+	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/pkg/buckets"
+	"github.com/pingcap/ticdc/pkg/config"
+	"github.com/pingcap/ticdc/pkg/diagnostics"
 	cerror "github.com/pingcap/ticdc/pkg/errors"
 	"github.com/pingcap/ticdc/pkg/security"
+	"github.com/pingcap/ticdc/pkg/tracing"
 	"github.com/pingcap/ticdc/pkg/util"
 	"github.com/pingcap/ticdc/pkg/version"
 	pd "github.com/tikv/pd/client"
@@ -39,9 +47,21 @@ import (
 
 const (
 	ownerRunInterval = time.Millisecond * 500
+	// ownerPreferenceCheckInterval is the interval the owner checks whether
+	// to hand over the ownership to a preferred capture
+	ownerPreferenceCheckInterval = 10 * time.Second
+	// certCheckInterval is the interval of checking whether the certificates are rotated
+	certCheckInterval = 10 * time.Second
+	// drainOwnerCloseTimeout bounds closing the owner after draining the
+	// capture, which may have used up the graceful shutdown timeout
+	drainOwnerCloseTimeout = 5 * time.Second
 
 	// DefaultCDCGCSafePointTTL is the default value of cdc gc safe-point ttl, specified in seconds.
 	DefaultCDCGCSafePointTTL = 24 * 60 * 60
+
+	// DefaultMaxMemoryConsumption is the default memory budget of a capture
+	// if there is no cgroup memory limit, specified in bytes.
+	DefaultMaxMemoryConsumption int64 = 8 * 1024 * 1024 * 1024 // 8G
 )
 
 type options struct {
@@ -53,6 +73,34 @@ type options struct {
 	timezone               *time.Location
 	ownerFlushInterval     time.Duration
 	processorFlushInterval time.Duration
+	maxMemoryConsumption   int64
+	// the rate limits of the incremental scans, 0 is unlimited
+	scanRegionsPerSecond int64
+	scanBytesPerSecond   int64
+	// the limits of the prewrites cached by a region feed, 0 is unlimited
+	matcherCacheEntries int
+	matcherCacheAge     time.Duration
+	// credentialKeyPath is the path of the key to encrypt the changefeed credentials
+	credentialKeyPath string
+	credentialKey     []byte
+	tracing           tracing.Config
+	// gracefulShutdownTimeout bounds the time of moving the tables out of
+	// the capture before it exits, 0 exits without moving the tables
+	gracefulShutdownTimeout time.Duration
+	// diagnosticsDir is the directory the heap profiles are captured into
+	// under memory pressure, empty disables the profiling
+	diagnosticsDir string
+	// ownerPreference is the preference of the capture to be elected as the owner
+	ownerPreference model.OwnerPreference
+	// auditLogFile is the file the audit records handled by the capture are
+	// appended to besides etcd, empty disables it
+	auditLogFile string
+	// auditLogRetention is the duration the audit records are kept in etcd,
+	// 0 keeps them forever
+	auditLogRetention time.Duration
+	// featureGates overrides the states of the experimental features on the
+	// capture
+	featureGates config.FeatureGates
 }
 
 func (o *options) validateAndAdjust() error {
@@ -77,6 +125,64 @@ func (o *options) validateAndAdjust() error {
 	}
 	if o.gcTTL == 0 {
 		return cerror.ErrInvalidServerOption.GenWithStack("empty GC TTL is not allowed")
+	} else if o.gcTTL < 0 {
+		return cerror.ErrInvalidServerOption.GenWithStack("invalid GC TTL %d", o.gcTTL)
+	}
+	if o.ownerFlushInterval < 0 || o.processorFlushInterval < 0 {
+		return cerror.ErrInvalidServerOption.GenWithStack("invalid flush interval %s of owner, %s of processor",
+			o.ownerFlushInterval, o.processorFlushInterval)
+	}
+	if o.maxMemoryConsumption == 0 {
+		o.maxMemoryConsumption = DefaultMaxMemoryConsumption
+		if limit, ok := buckets.MemoryLimitFromCgroup(); ok {
+			o.maxMemoryConsumption = limit
+		}
+	} else if o.maxMemoryConsumption < 0 {
+		return cerror.ErrInvalidServerOption.GenWithStack("invalid max memory consumption %d", o.maxMemoryConsumption)
+	} else if limit, ok := buckets.MemoryLimitFromCgroup(); ok && o.maxMemoryConsumption > limit {
+		// the capture is likely killed by OOM before the memory manager evicts
+		log.Warn("max memory consumption exceeds the memory limit of the cgroup, set it to at most the limit",
+			zap.Int64("max-memory-consumption", o.maxMemoryConsumption), zap.Int64("cgroup-limit", limit))
+	}
+	if o.scanRegionsPerSecond < 0 || o.scanBytesPerSecond < 0 {
+		return cerror.ErrInvalidServerOption.GenWithStack("invalid scan rate limit %d regions/s, %d bytes/s",
+			o.scanRegionsPerSecond, o.scanBytesPerSecond)
+	}
+	if o.matcherCacheEntries < 0 || o.matcherCacheAge < 0 {
+		return cerror.ErrInvalidServerOption.GenWithStack("invalid matcher cache limit %d entries, %s",
+			o.matcherCacheEntries, o.matcherCacheAge)
+	}
+	if o.diagnosticsDir != "" {
+		if err := os.MkdirAll(o.diagnosticsDir, 0755); err != nil {
+			return cerror.WrapError(cerror.ErrInvalidServerOption, err)
+		}
+		if err := util.IsDirWritable(o.diagnosticsDir); err != nil {
+			return errors.Annotate(err, "invalidate diagnostics dir")
+		}
+	}
+	ownerPreference, err := model.ParseOwnerPreference(string(o.ownerPreference))
+	if err != nil {
+		return cerror.WrapError(cerror.ErrInvalidServerOption, err)
+	}
+	o.ownerPreference = ownerPreference
+	if o.gracefulShutdownTimeout < 0 {
+		return cerror.ErrInvalidServerOption.GenWithStack("invalid graceful shutdown timeout %s", o.gracefulShutdownTimeout)
+	}
+	if o.auditLogRetention < 0 {
+		return cerror.ErrInvalidServerOption.GenWithStack("invalid audit log retention %s", o.auditLogRetention)
+	}
+	if err := o.featureGates.Validate(); err != nil {
+		return cerror.WrapError(cerror.ErrInvalidServerOption, err)
+	}
+	if o.tracing.SampleRate < 0 || o.tracing.SampleRate > 1 {
+		return cerror.ErrInvalidServerOption.GenWithStack("invalid tracing sample rate %v", o.tracing.SampleRate)
+	}
+	if o.credentialKeyPath != "" {
+		key, err := security.LoadCredentialKey(o.credentialKeyPath)
+		if err != nil {
+			return errors.Annotate(err, "invalidate credential key")
+		}
+		o.credentialKey = key
 	}
 	var tlsConfig *tls.Config
 	if o.credential != nil {
@@ -152,6 +258,31 @@ func ProcessorFlushInterval(dur time.Duration) ServerOption {
 	}
 }
 
+// MaxMemoryConsumption returns a ServerOption that sets the memory budget of the capture
+func MaxMemoryConsumption(n int64) ServerOption {
+	return func(o *options) {
+		o.maxMemoryConsumption = n
+	}
+}
+
+// ScanRateLimit returns a ServerOption that limits the rate of the incremental
+// scans of the kv clients in the capture, a rate of 0 is unlimited.
+func ScanRateLimit(regionsPerSecond, bytesPerSecond int64) ServerOption {
+	return func(o *options) {
+		o.scanRegionsPerSecond = regionsPerSecond
+		o.scanBytesPerSecond = bytesPerSecond
+	}
+}
+
+// MatcherCacheLimit returns a ServerOption that limits the prewrites cached by
+// a region feed while waiting for their commits, a limit of 0 is unlimited.
+func MatcherCacheLimit(maxEntries int, maxAge time.Duration) ServerOption {
+	return func(o *options) {
+		o.matcherCacheEntries = maxEntries
+		o.matcherCacheAge = maxAge
+	}
+}
+
 // Credential returns a ServerOption that sets the TLS
 func Credential(credential *security.Credential) ServerOption {
 	return func(o *options) {
@@ -159,6 +290,66 @@ func Credential(credential *security.Credential) ServerOption {
 	}
 }
 
+// CredentialKeyPath returns a ServerOption that sets the path of the key to
+// encrypt the changefeed credentials, the key must be the same in all the
+// captures.
+func CredentialKeyPath(path string) ServerOption {
+	return func(o *options) {
+		o.credentialKeyPath = path
+	}
+}
+
+// Tracing returns a ServerOption that sets the config of tracing the row
+// changes across the replication pipeline.
+func Tracing(cfg tracing.Config) ServerOption {
+	return func(o *options) {
+		o.tracing = cfg
+	}
+}
+
+// GracefulShutdownTimeout returns a ServerOption that sets the max duration of
+// moving the tables out of the capture before it exits.
+func GracefulShutdownTimeout(dur time.Duration) ServerOption {
+	return func(o *options) {
+		o.gracefulShutdownTimeout = dur
+	}
+}
+
+// DiagnosticsDir returns a ServerOption that sets the directory the heap
+// profiles and the memory breakdowns are captured into when the RSS of the
+// capture stays close to the max memory consumption.
+func DiagnosticsDir(dir string) ServerOption {
+	return func(o *options) {
+		o.diagnosticsDir = dir
+	}
+}
+
+// OwnerPreference returns a ServerOption that sets the preference of the
+// capture to be elected as the owner.
+func OwnerPreference(p model.OwnerPreference) ServerOption {
+	return func(o *options) {
+		o.ownerPreference = p
+	}
+}
+
+// AuditLog returns a ServerOption that sets the file the audit records handled
+// by the capture are appended to besides etcd, and the duration the records
+// are kept in etcd.
+func AuditLog(file string, retention time.Duration) ServerOption {
+	return func(o *options) {
+		o.auditLogFile = file
+		o.auditLogRetention = retention
+	}
+}
+
+// FeatureGates returns a ServerOption that sets the states of the experimental
+// features on the capture, which apply to all the changefeeds it runs.
+func FeatureGates(gates config.FeatureGates) ServerOption {
+	return func(o *options) {
+		o.featureGates = gates
+	}
+}
+
 // A ServerOption sets options such as the addr of PD.
 type ServerOption func(*options)
 
@@ -171,6 +362,11 @@ type Server struct {
 	statusServer *http.Server
 	pdClient     pd.Client
 	pdEndpoints  []string
+
+	memoryManager *buckets.GlobalMemoryManager
+	scanLimiter   *kv.ScanLimiter
+	tracerCloser  io.Closer
+	auditLog      *auditLogger
 }
 
 // NewServer creates a Server instance.
@@ -190,25 +386,47 @@ func NewServer(opt ...ServerOption) (*Server, error) {
 		zap.Any("timezone", opts.timezone),
 		zap.Duration("owner-flush-interval", opts.ownerFlushInterval),
 		zap.Duration("processor-flush-interval", opts.processorFlushInterval),
+		zap.Int64("max-memory-consumption", opts.maxMemoryConsumption),
+		zap.Int64("scan-regions-per-second", opts.scanRegionsPerSecond),
+		zap.Int64("scan-bytes-per-second", opts.scanBytesPerSecond),
+		zap.Int("matcher-cache-entries", opts.matcherCacheEntries),
+		zap.Duration("matcher-cache-age", opts.matcherCacheAge),
+		zap.String("credential-key-path", opts.credentialKeyPath),
+		zap.Float64("tracing-sample-rate", opts.tracing.SampleRate),
+		zap.String("tracing-endpoint", opts.tracing.Endpoint),
+		zap.Duration("graceful-shutdown-timeout", opts.gracefulShutdownTimeout),
+		zap.String("diagnostics-dir", opts.diagnosticsDir),
+		zap.String("owner-preference", string(opts.ownerPreference)),
+		zap.String("audit-log-file", opts.auditLogFile),
+		zap.Duration("audit-log-retention", opts.auditLogRetention),
+		zap.Stringer("feature-gates", opts.featureGates.Effective()),
 	)
 
+	auditLog, err := newAuditLogger(opts.auditLogFile, opts.auditLogRetention)
+	if err != nil {
+		return nil, err
+	}
 	s := &Server{
-		opts: opts,
+		opts:     opts,
+		auditLog: auditLog,
 	}
 	return s, nil
 }
 
 // Run runs the server.
 func (s *Server) Run(ctx context.Context) error {
-	s.pdEndpoints = strings.Split(s.opts.pdEndpoints, ",")
-	grpcTLSOption, err := s.opts.credential.ToGRPCDialOption()
+	security.SetCredentialKey(s.opts.credentialKey)
+	tracerCloser, err := tracing.Init("ticdc", s.opts.tracing)
 	if err != nil {
 		return errors.Trace(err)
 	}
+	s.tracerCloser = tracerCloser
+	s.pdEndpoints = strings.Split(s.opts.pdEndpoints, ",")
+	pdSecurity, pdDialOption := s.opts.credential.PDClientOptions()
 	pdClient, err := pd.NewClientWithContext(
-		ctx, s.pdEndpoints, s.opts.credential.PDSecurityOption(),
+		ctx, s.pdEndpoints, pdSecurity,
 		pd.WithGRPCDialOptions(
-			grpcTLSOption,
+			pdDialOption,
 			grpc.WithBlock(),
 			grpc.WithConnectParams(grpc.ConnectParams{
 				Backoff: backoff.Config{
@@ -265,6 +483,24 @@ func (s *Server) campaignOwnerLoop(ctx context.Context) error {
 			return errors.Trace(err)
 		}
 
+		// the draining capture is exiting, it doesn't campaign again after
+		// handing over the ownership
+		if s.capture.IsDraining() {
+			return nil
+		}
+		if s.capture.info.OwnerPreference == model.OwnerNever {
+			log.Info("the capture is never elected as the owner", zap.String("capture", s.capture.info.ID))
+			return nil
+		}
+		shouldCampaign, err := s.capture.ShouldCampaign(ctx)
+		if err != nil {
+			log.Warn("check the owner preference failed", zap.Error(err))
+			continue
+		}
+		if !shouldCampaign {
+			continue
+		}
+
 		// Campaign to be an owner, it blocks until it becomes the owner
 		if err := s.capture.Campaign(ctx); err != nil {
 			switch errors.Cause(err) {
@@ -284,7 +520,11 @@ func (s *Server) campaignOwnerLoop(ctx context.Context) error {
 		}
 
 		s.setOwner(owner)
-		if err := owner.Run(ctx, ownerRunInterval); err != nil {
+		handOverCtx, cancelHandOver := context.WithCancel(ctx)
+		go s.handOverOwnerToPreferred(handOverCtx, owner)
+		err = owner.Run(ctx, ownerRunInterval)
+		cancelHandOver()
+		if err != nil {
 			if errors.Cause(err) == context.Canceled {
 				log.Info("owner exited", zap.String("capture", s.capture.info.ID))
 				return nil
@@ -301,14 +541,74 @@ func (s *Server) campaignOwnerLoop(ctx context.Context) error {
 	}
 }
 
+// handOverOwnerToPreferred resigns the owner once a preferred capture is
+// alive, if the capture isn't preferred itself. The preferred capture is
+// elected as the owner then, and the capture doesn't campaign again until
+// there is no preferred capture alive.
+func (s *Server) handOverOwnerToPreferred(ctx context.Context, owner *Owner) {
+	if s.capture.info.OwnerPreference == model.OwnerPreferred {
+		return
+	}
+	ticker := time.NewTicker(ownerPreferenceCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		preferred, err := s.capture.hasPreferredOwner(ctx)
+		if err != nil {
+			log.Warn("check the owner preference failed", zap.Error(err))
+			continue
+		}
+		if !preferred {
+			continue
+		}
+		log.Info("hand over the ownership to the preferred capture", zap.String("capture", s.capture.info.ID))
+		// resign after the owner exits, see handleResignOwner
+		owner.Close(ctx, func(ctx context.Context) error {
+			return s.capture.Resign(ctx)
+		})
+		return
+	}
+}
+
 func (s *Server) run(ctx context.Context) (err error) {
 	ctx = util.PutCaptureAddrInCtx(ctx, s.opts.advertiseAddr)
 	ctx = util.PutTimezoneInCtx(ctx, s.opts.timezone)
-	procOpts := &processorOpts{flushCheckpointInterval: s.opts.processorFlushInterval}
+	memoryManager, err := buckets.NewGlobalMemoryManager(s.opts.maxMemoryConsumption)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	s.memoryManager = memoryManager
+	// the memory quota of sorters is shared by all the processors in the capture
+	sorterGroup := memoryManager.Group(buckets.MemorySubsystemSorter)
+	sorterMemQuota, err := sorterGroup.CreateBucketWithMode(buckets.BucketModeResidency, 0, sorterGroup.FreeQuota(), 0)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// the incremental scans of all the kv clients in the capture share the rate limits
+	scanLimiter, err := kv.NewScanLimiter(s.opts.scanRegionsPerSecond, s.opts.scanBytesPerSecond)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	s.scanLimiter = scanLimiter
+	kv.SetGlobalScanLimiter(scanLimiter)
+	kv.SetMatcherCacheConfig(kv.MatcherCacheConfig{
+		MaxEntries: s.opts.matcherCacheEntries,
+		MaxAge:     s.opts.matcherCacheAge,
+	})
+	procOpts := &processorOpts{
+		flushCheckpointInterval: s.opts.processorFlushInterval,
+		sorterMemQuota:          sorterMemQuota,
+	}
 	capture, err := NewCapture(ctx, s.pdEndpoints, s.opts.credential, s.opts.advertiseAddr, procOpts)
 	if err != nil {
 		return err
 	}
+	capture.info.OwnerPreference = s.opts.ownerPreference
+	capture.info.FeatureGates = s.opts.featureGates.Effective()
 	s.capture = capture
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
@@ -323,9 +623,75 @@ func (s *Server) run(ctx context.Context) (err error) {
 		return s.capture.Run(cctx)
 	})
 
+	wg.Go(func() error {
+		return s.memoryManager.Run(cctx)
+	})
+
+	wg.Go(func() error {
+		return s.scanLimiter.Run(cctx)
+	})
+
+	if s.opts.diagnosticsDir != "" {
+		profiler := diagnostics.NewHeapProfiler(s.opts.diagnosticsDir, s.opts.maxMemoryConsumption, s.memoryManager)
+		wg.Go(func() error {
+			return profiler.Run(cctx)
+		})
+	}
+
+	if s.opts.credential.IsTLSEnabled() {
+		wg.Go(func() error {
+			return s.opts.credential.WatchCertificates(cctx, certCheckInterval)
+		})
+	}
+
 	return wg.Wait()
 }
 
+// Drain moves the tables of the capture to the other captures and hands over
+// the ownership before the server exits, instead of leaving them to be
+// rescheduled after the lease of the capture expires. It is bounded by the
+// graceful shutdown timeout.
+func (s *Server) Drain(ctx context.Context) error {
+	if s.capture == nil || s.opts.gracefulShutdownTimeout == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.opts.gracefulShutdownTimeout)
+	defer cancel()
+	record := &model.AuditRecord{
+		Time:   time.Now(),
+		Action: model.AuditDrainCapture,
+		Source: s.opts.advertiseAddr,
+		Params: map[string]interface{}{
+			"graceful-shutdown-timeout": s.opts.gracefulShutdownTimeout.String(),
+		},
+	}
+	err := s.capture.Drain(ctx)
+	if err != nil {
+		log.Warn("drain capture failed, the remaining tables are rescheduled after the capture exits", zap.Error(err))
+	}
+	record.SetResult(err)
+	s.recordAudit(context.Background(), record)
+
+	s.ownerLock.RLock()
+	owner := s.owner
+	s.ownerLock.RUnlock()
+	if owner != nil {
+		// the ctx may be done if draining the capture timed out, the owner
+		// is still closed with a fresh ctx so that the ownership is handed
+		// over instead of expiring with the lease.
+		closeCtx, closeCancel := context.WithTimeout(context.Background(), drainOwnerCloseTimeout)
+		// resign after the owner exits, see handleResignOwner
+		owner.Close(closeCtx, func(ctx context.Context) error {
+			return s.capture.Resign(ctx)
+		})
+		closeCancel()
+		// the owner is cleared only after it exits, so that the requests
+		// are still forwarded to it while it's closing
+		s.setOwner(nil)
+	}
+	return errors.Trace(err)
+}
+
 // Close closes the server.
 func (s *Server) Close() {
 	if s.capture != nil {
@@ -345,4 +711,13 @@ func (s *Server) Close() {
 		}
 		s.statusServer = nil
 	}
+	if s.tracerCloser != nil {
+		if err := s.tracerCloser.Close(); err != nil {
+			log.Error("close tracer", zap.Error(err))
+		}
+		s.tracerCloser = nil
+	}
+	if s.auditLog != nil {
+		s.auditLog.close()
+	}
 }
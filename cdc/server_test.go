@@ -14,7 +14,13 @@
 package cdc
 
 import (
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
 	"github.com/pingcap/check"
+	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/pkg/util"
 )
 
 type serverOptionSuite struct{}
@@ -38,6 +44,54 @@ func (s *serverOptionSuite) TestNewServer(c *check.C) {
 	c.Assert(err, check.IsNil)
 	c.Assert(svr, check.NotNil)
 	c.Assert(svr.opts.advertiseAddr, check.Equals, "cdc:1234")
+	c.Assert(svr.opts.maxMemoryConsumption > 0, check.IsTrue)
+	c.Assert(svr.opts.ownerPreference, check.Equals, model.OwnerNormal)
+
+	svr, err = NewServer(PDEndpoints("http://pd"), Address("cdc:1234"), GCTTL(DefaultCDCGCSafePointTTL),
+		MaxMemoryConsumption(-1))
+	c.Assert(err, check.ErrorMatches, ".*invalid max memory consumption.*")
+	c.Assert(svr, check.IsNil)
+
+	svr, err = NewServer(PDEndpoints("http://pd"), Address("cdc:1234"), GCTTL(-1))
+	c.Assert(err, check.ErrorMatches, ".*invalid GC TTL.*")
+	c.Assert(svr, check.IsNil)
+
+	svr, err = NewServer(PDEndpoints("http://pd"), Address("cdc:1234"), GCTTL(DefaultCDCGCSafePointTTL),
+		ProcessorFlushInterval(-time.Second))
+	c.Assert(err, check.ErrorMatches, ".*invalid flush interval.*")
+	c.Assert(svr, check.IsNil)
+
+	svr, err = NewServer(PDEndpoints("http://pd"), Address("cdc:1234"), GCTTL(DefaultCDCGCSafePointTTL),
+		ScanRateLimit(-1, 0))
+	c.Assert(err, check.ErrorMatches, ".*invalid scan rate limit.*")
+	c.Assert(svr, check.IsNil)
+
+	svr, err = NewServer(PDEndpoints("http://pd"), Address("cdc:1234"), GCTTL(DefaultCDCGCSafePointTTL),
+		MatcherCacheLimit(0, -time.Second))
+	c.Assert(err, check.ErrorMatches, ".*invalid matcher cache limit.*")
+	c.Assert(svr, check.IsNil)
+
+	dir := filepath.Join(c.MkDir(), "diagnostics")
+	svr, err = NewServer(PDEndpoints("http://pd"), Address("cdc:1234"), GCTTL(DefaultCDCGCSafePointTTL),
+		DiagnosticsDir(dir))
+	c.Assert(err, check.IsNil)
+	c.Assert(util.IsDirAndWritable(dir), check.IsNil)
+	file := filepath.Join(dir, "file")
+	c.Assert(ioutil.WriteFile(file, nil, 0644), check.IsNil)
+	svr, err = NewServer(PDEndpoints("http://pd"), Address("cdc:1234"), GCTTL(DefaultCDCGCSafePointTTL),
+		DiagnosticsDir(file))
+	c.Assert(err, check.ErrorMatches, ".*ErrInvalidServerOption.*not a directory")
+	c.Assert(svr, check.IsNil)
+
+	svr, err = NewServer(PDEndpoints("http://pd"), Address("cdc:1234"), GCTTL(DefaultCDCGCSafePointTTL),
+		OwnerPreference("always"))
+	c.Assert(err, check.ErrorMatches, ".*ErrInvalidServerOption.*invalid owner preference always.*")
+	c.Assert(svr, check.IsNil)
+
+	svr, err = NewServer(PDEndpoints("http://pd"), Address("cdc:1234"), GCTTL(DefaultCDCGCSafePointTTL),
+		OwnerPreference(model.OwnerPreferred))
+	c.Assert(err, check.IsNil)
+	c.Assert(svr.opts.ownerPreference, check.Equals, model.OwnerPreferred)
 
 	svr, err = NewServer(PDEndpoints("http://pd"), Address("cdc:1234"), GCTTL(DefaultCDCGCSafePointTTL),
 		AdvertiseAddress("advertise:1234"))
@@ -67,3 +121,18 @@ func (s *serverOptionSuite) TestNewServer(c *check.C) {
 	c.Assert(err, check.ErrorMatches, ".*does not contain a port")
 	c.Assert(svr, check.IsNil)
 }
+
+func (s *serverOptionSuite) TestHasPreferredOwner(c *check.C) {
+	captures := []*model.CaptureInfo{
+		{ID: "capture-1"},
+		{ID: "capture-2", OwnerPreference: model.OwnerNever},
+	}
+	c.Assert(hasPreferredOwner(captures, "capture-1"), check.IsFalse)
+	captures = append(captures, &model.CaptureInfo{ID: "capture-3", OwnerPreference: model.OwnerPreferred})
+	c.Assert(hasPreferredOwner(captures, "capture-1"), check.IsTrue)
+	// the preferred capture doesn't wait for itself
+	c.Assert(hasPreferredOwner(captures, "capture-3"), check.IsFalse)
+	// the owner fails over to the normal captures once the preferred one is draining
+	captures[2].Draining = true
+	c.Assert(hasPreferredOwner(captures, "capture-1"), check.IsFalse)
+}
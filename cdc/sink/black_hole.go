@@ -19,6 +19,7 @@ import (
 
 	"github.com/pingcap/log"
 	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/pkg/logutil"
 	"go.uber.org/zap"
 )
 
@@ -44,7 +45,7 @@ func (b *blackHoleSink) EmitRowChangedEvents(ctx context.Context, rows ...*model
 				zap.Uint64("CommitTs", row.CommitTs),
 				zap.Uint64("checkpointTs", checkpointTs))
 		}
-		log.Debug("BlockHoleSink: EmitRowChangedEvents", zap.Any("row", row))
+		log.Debug("BlockHoleSink: EmitRowChangedEvents", logutil.ZapRedactReflect("row", row))
 	}
 	rowsCount := len(rows)
 	atomic.AddUint64(&b.accumulated, uint64(rowsCount))
@@ -72,7 +73,7 @@ func (b *blackHoleSink) EmitCheckpointTs(ctx context.Context, ts uint64) error {
 }
 
 func (b *blackHoleSink) EmitDDLEvent(ctx context.Context, ddl *model.DDLEvent) error {
-	log.Debug("BlockHoleSink: DDL Event", zap.Any("ddl", ddl))
+	log.Debug("BlockHoleSink: DDL Event", logutil.ZapRedactReflect("ddl", ddl))
 	return nil
 }
 
@@ -27,6 +27,7 @@ import (
 	"github.com/pingcap/ticdc/cdc/model"
 	"github.com/pingcap/ticdc/cdc/sink/codec"
 	cerror "github.com/pingcap/ticdc/pkg/errors"
+	"github.com/pingcap/ticdc/pkg/logutil"
 	"github.com/uber-go/atomic"
 	"go.uber.org/zap"
 )
@@ -280,7 +281,7 @@ func (s *s3Sink) EmitDDLEvent(ctx context.Context, ddl *model.DDLEvent) error {
 		log.Debug("[EmitDDLEvent] list content from s3",
 			zap.String("key", key),
 			zap.Int64("size", size),
-			zap.Any("ddl", ddl))
+			logutil.ZapRedactReflect("ddl", ddl))
 		name = strings.ReplaceAll(key, s.prefix, "")
 		size = fileSize
 		return nil
@@ -294,7 +295,7 @@ func (s *s3Sink) EmitDDLEvent(ctx context.Context, ddl *model.DDLEvent) error {
 		fileData = data
 		name = makeDDLFileObject(ddl.CommitTs)
 		log.Debug("[EmitDDLEvent] create first or rotate ddl log",
-			zap.String("name", name), zap.Any("ddl", ddl))
+			zap.String("name", name), logutil.ZapRedactReflect("ddl", ddl))
 		if size > maxDDLFlushSize {
 			// reset ddl encoder for new file
 			s.ddlEncoder = nil
@@ -302,7 +303,7 @@ func (s *s3Sink) EmitDDLEvent(ctx context.Context, ddl *model.DDLEvent) error {
 	} else {
 		// hack way: append data to old file
 		log.Debug("[EmitDDLEvent] append ddl to origin log",
-			zap.String("name", name), zap.Any("ddl", ddl))
+			zap.String("name", name), logutil.ZapRedactReflect("ddl", ddl))
 		fileData, err = s.storage.Read(ctx, name)
 		if err != nil {
 			return cerror.WrapError(cerror.ErrS3SinkStorageAPI, err)
@@ -29,6 +29,7 @@ import (
 	"github.com/pingcap/parser/mysql"
 	"github.com/pingcap/ticdc/cdc/model"
 	cerror "github.com/pingcap/ticdc/pkg/errors"
+	"github.com/pingcap/ticdc/pkg/logutil"
 	"github.com/pingcap/tidb/types"
 	tijson "github.com/pingcap/tidb/types/json"
 	"go.uber.org/zap"
@@ -39,6 +40,8 @@ type AvroEventBatchEncoder struct {
 	keySchemaManager   *AvroSchemaManager
 	valueSchemaManager *AvroSchemaManager
 	resultBuf          []*MQMessage
+	// tz is the time zone the temporal columns are formatted in by the mounter
+	tz *time.Location
 }
 
 type avroEncodeResult struct {
@@ -75,13 +78,19 @@ func (a *AvroEventBatchEncoder) GetKeySchemaManager() *AvroSchemaManager {
 	return a.keySchemaManager
 }
 
+// SetTimeZone sets the time zone of the temporal columns for an Avro encoder,
+// UTC is used if it's not set.
+func (a *AvroEventBatchEncoder) SetTimeZone(tz *time.Location) {
+	a.tz = tz
+}
+
 // AppendRowChangedEvent appends a row change event to the encoder
 // NOTE: the encoder can only store one RowChangedEvent!
 func (a *AvroEventBatchEncoder) AppendRowChangedEvent(e *model.RowChangedEvent) (EncoderResult, error) {
 	mqMessage := NewMQMessage(nil, nil, e.CommitTs)
 
 	if !e.IsDelete() {
-		res, err := avroEncode(e.Table, a.valueSchemaManager, e.TableInfoVersion, e.Columns)
+		res, err := avroEncode(e.Table, a.valueSchemaManager, e.TableInfoVersion, e.Columns, a.tz)
 		if err != nil {
 			log.Warn("AppendRowChangedEvent: avro encoding failed", zap.String("table", e.Table.String()))
 			return EncoderNoOperation, errors.Annotate(err, "AppendRowChangedEvent could not encode to Avro")
@@ -100,7 +109,7 @@ func (a *AvroEventBatchEncoder) AppendRowChangedEvent(e *model.RowChangedEvent)
 
 	pkeyCols := e.HandleKeyColumns()
 
-	res, err := avroEncode(e.Table, a.keySchemaManager, e.TableInfoVersion, pkeyCols)
+	res, err := avroEncode(e.Table, a.keySchemaManager, e.TableInfoVersion, pkeyCols, a.tz)
 	if err != nil {
 		log.Warn("AppendRowChangedEvent: avro encoding failed", zap.String("table", e.Table.String()))
 		return EncoderNoOperation, errors.Annotate(err, "AppendRowChangedEvent could not encode to Avro")
@@ -163,7 +172,7 @@ func (a *AvroEventBatchEncoder) Size() int {
 	return sum
 }
 
-func avroEncode(table *model.TableName, manager *AvroSchemaManager, tableVersion uint64, cols []*model.Column) (*avroEncodeResult, error) {
+func avroEncode(table *model.TableName, manager *AvroSchemaManager, tableVersion uint64, cols []*model.Column, tz *time.Location) (*avroEncodeResult, error) {
 	schemaGen := func() (string, error) {
 		schema, err := ColumnInfoToAvroSchema(table.Table, cols)
 		if err != nil {
@@ -178,7 +187,7 @@ func avroEncode(table *model.TableName, manager *AvroSchemaManager, tableVersion
 		return nil, errors.Annotate(err, "AvroEventBatchEncoder: get-or-register failed")
 	}
 
-	native, err := rowToAvroNativeData(cols)
+	native, err := rowToAvroNativeData(cols, tz)
 	if err != nil {
 		return nil, errors.Annotate(err, "AvroEventBatchEncoder: converting to native failed")
 	}
@@ -249,13 +258,13 @@ func ColumnInfoToAvroSchema(name string, columnInfo []*model.Column) (string, er
 	return string(str), nil
 }
 
-func rowToAvroNativeData(cols []*model.Column) (interface{}, error) {
+func rowToAvroNativeData(cols []*model.Column, tz *time.Location) (interface{}, error) {
 	ret := make(map[string]interface{}, len(cols))
 	for _, col := range cols {
 		if col == nil {
 			continue
 		}
-		data, str, err := columnToAvroNativeData(col)
+		data, str, err := columnToAvroNativeData(col, tz)
 		if err != nil {
 			return nil, err
 		}
@@ -303,7 +312,7 @@ var unsignedLongAvroType = avroLogicalType{
 }
 
 func getAvroDataTypeFromColumn(col *model.Column) (interface{}, error) {
-	log.Info("DEBUG: getAvroDataTypeFromColumn", zap.Reflect("col", col))
+	log.Info("DEBUG: getAvroDataTypeFromColumn", logutil.ZapRedactReflect("col", col))
 	switch col.Type {
 	case mysql.TypeFloat:
 		return "float", nil
@@ -351,11 +360,11 @@ func getAvroDataTypeFromColumn(col *model.Column) (interface{}, error) {
 		return "long", nil
 	default:
 		log.Fatal("Unknown MySql type", zap.Reflect("mysql-type", col.Type))
-		return "", errors.New("Unknown Mysql type")
+		return "", cerror.ErrAvroUnknownType.GenWithStackByArgs(col.Type)
 	}
 }
 
-func columnToAvroNativeData(col *model.Column) (interface{}, string, error) {
+func columnToAvroNativeData(col *model.Column, tz *time.Location) (interface{}, string, error) {
 	if col.Value == nil {
 		return nil, "null", nil
 	}
@@ -374,18 +383,21 @@ func columnToAvroNativeData(col *model.Column) (interface{}, string, error) {
 	switch col.Type {
 	case mysql.TypeDate, mysql.TypeDatetime, mysql.TypeNewDate, mysql.TypeTimestamp:
 		str := col.Value.(string)
-		t, err := time.Parse(types.DateFormat, str)
+		if tz == nil {
+			tz = time.UTC
+		}
+		t, err := time.ParseInLocation(types.DateFormat, str, tz)
 		const fullType = "long." + timestampMillis
 		if err == nil {
 			return t, string(fullType), nil
 		}
 
-		t, err = time.Parse(types.TimeFormat, str)
+		t, err = time.ParseInLocation(types.TimeFormat, str, tz)
 		if err == nil {
 			return t, string(fullType), nil
 		}
 
-		t, err = time.Parse(types.TimeFSPFormat, str)
+		t, err = time.ParseInLocation(types.TimeFSPFormat, str, tz)
 		if err != nil {
 			return nil, "", cerror.WrapError(cerror.ErrAvroEncodeFailed, err)
 		}
@@ -434,8 +446,8 @@ func columnToAvroNativeData(col *model.Column) (interface{}, string, error) {
 				return string(val), "string", nil
 			}
 		}
-		log.Fatal("Avro could not process text-like type", zap.Reflect("col", col))
-		return nil, "", errors.New("Unknown datum type")
+		log.Fatal("Avro could not process text-like type", logutil.ZapRedactReflect("col", col))
+		return nil, "", cerror.ErrAvroUnknownType.GenWithStackByArgs(col.Value)
 	case mysql.TypeYear:
 		return col.Value.(int64), "long", nil
 	case mysql.TypeJSON:
@@ -86,7 +86,7 @@ func (s *avroBatchEncoderSuite) TestAvroEncodeOnly(c *check.C) {
 		{Name: "myfloat", Value: float32(3.14), Type: mysql.TypeFloat},
 		{Name: "mybytes", Value: []byte("Hello World"), Type: mysql.TypeBlob},
 		{Name: "ts", Value: time.Now().Format(types.TimeFSPFormat), Type: mysql.TypeTimestamp},
-	})
+	}, time.Local)
 	c.Assert(err, check.IsNil)
 
 	res, _, err := avroCodec.NativeFromBinary(r.data)
@@ -98,6 +98,20 @@ func (s *avroBatchEncoderSuite) TestAvroEncodeOnly(c *check.C) {
 	log.Info("TestAvroEncodeOnly", zap.ByteString("result", txt))
 }
 
+func (s *avroBatchEncoderSuite) TestAvroTimeZone(c *check.C) {
+	col := &model.Column{Name: "ts", Value: "2020-10-01 08:00:00", Type: mysql.TypeTimestamp}
+	data, _, err := columnToAvroNativeData(col, nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(data.(time.Time).Unix(), check.Equals, time.Date(2020, 10, 1, 8, 0, 0, 0, time.UTC).Unix())
+
+	// the value formatted in the time zone of the changefeed is parsed back
+	// in the same time zone
+	tz := time.FixedZone("+08:00", 8*3600)
+	data, _, err = columnToAvroNativeData(col, tz)
+	c.Assert(err, check.IsNil)
+	c.Assert(data.(time.Time).Unix(), check.Equals, time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC).Unix())
+}
+
 func (s *avroBatchEncoderSuite) TestAvroEnvelope(c *check.C) {
 	avroCodec, err := goavro.NewCodec(`
         {
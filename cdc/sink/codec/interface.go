@@ -133,6 +133,12 @@ func (p *Protocol) FromString(protocol string) {
 	}
 }
 
+// RequireOldValue returns whether the messages of the protocol need the old
+// values of the rows, which are only requested from TiKV if old value is enabled.
+func (p Protocol) RequireOldValue() bool {
+	return p == ProtocolCanal || p == ProtocolCanalJson
+}
+
 // NewEventBatchEncoder returns a function of creating an EventBatchEncoder
 func NewEventBatchEncoder(p Protocol) func() EventBatchEncoder {
 	switch p {
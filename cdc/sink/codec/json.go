@@ -27,6 +27,7 @@ import (
 	timodel "github.com/pingcap/parser/model"
 	"github.com/pingcap/parser/mysql"
 	cerror "github.com/pingcap/ticdc/pkg/errors"
+	"github.com/pingcap/ticdc/pkg/logutil"
 	"go.uber.org/zap"
 
 	"github.com/pingcap/ticdc/cdc/model"
@@ -87,7 +88,7 @@ func (c *column) ToSinkColumn(name string) *model.Column {
 		if c.Flag.IsBinary() {
 			str, err = strconv.Unquote("\"" + str + "\"")
 			if err != nil {
-				log.Fatal("invalid column value, please report a bug", zap.Any("col", c), zap.Error(err))
+				log.Fatal("invalid column value, please report a bug", logutil.ZapRedactReflect("col", c), zap.Error(err))
 			}
 		}
 		col.Value = []byte(str)
@@ -105,14 +106,14 @@ func formatColumnVal(c column) column {
 			var err error
 			c.Value, err = base64.StdEncoding.DecodeString(s)
 			if err != nil {
-				log.Fatal("invalid column value, please report a bug", zap.Any("col", c), zap.Error(err))
+				log.Fatal("invalid column value, please report a bug", logutil.ZapRedactReflect("col", c), zap.Error(err))
 			}
 		}
 	case mysql.TypeBit:
 		if s, ok := c.Value.(json.Number); ok {
 			intNum, err := s.Int64()
 			if err != nil {
-				log.Fatal("invalid column value, please report a bug", zap.Any("col", c), zap.Error(err))
+				log.Fatal("invalid column value, please report a bug", logutil.ZapRedactReflect("col", c), zap.Error(err))
 			}
 			c.Value = uint64(intNum)
 		}
@@ -142,7 +142,8 @@ func (m *AvroSchemaManager) Register(ctx context.Context, tableName model.TableN
 
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return 0, errors.Annotate(err, "Failed to read response from Registry")
+		return 0, errors.Annotate(
+			cerror.WrapError(cerror.ErrAvroSchemaAPIError, err), "Failed to read response from Registry")
 	}
 
 	if resp.StatusCode != 200 {
@@ -163,7 +164,7 @@ func (m *AvroSchemaManager) Register(ctx context.Context, tableName model.TableN
 	}
 
 	if jsonResp.ID == 0 {
-		return 0, cerror.ErrAvroSchemaAPIError.GenWithStack("Illegal schema ID returned from Registry %d", jsonResp.ID)
+		return 0, cerror.ErrAvroInvalidSchemaID.GenWithStackByArgs(jsonResp.ID)
 	}
 
 	log.Info("Registered schema successfully",
@@ -228,7 +229,7 @@ func (m *AvroSchemaManager) Lookup(ctx context.Context, tableName model.TableNam
 			zap.String("key", key),
 			zap.Uint64("tiSchemaID", tiSchemaID))
 
-		return nil, 0, cerror.ErrAvroSchemaAPIError.GenWithStackByArgs("Schema not found in Registry")
+		return nil, 0, cerror.ErrAvroSchemaNotFound.GenWithStackByArgs(key)
 	}
 
 	var jsonResp lookupResponse
@@ -375,7 +376,8 @@ func httpRetry(ctx context.Context, credential *security.Credential, r *http.Req
 	checkCtx:
 		select {
 		case <-ctx.Done():
-			return nil, errors.New("HTTP retry cancelled")
+			return nil, errors.Annotate(
+				cerror.WrapError(cerror.ErrAvroSchemaAPIError, ctx.Err()), "HTTP retry cancelled")
 
 		default:
 		}
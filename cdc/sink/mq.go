@@ -18,7 +18,6 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
-	"sync/atomic"
 	"time"
 
 	"github.com/pingcap/errors"
@@ -29,18 +28,22 @@ import (
 	"github.com/pingcap/ticdc/cdc/sink/producer"
 	"github.com/pingcap/ticdc/cdc/sink/producer/kafka"
 	"github.com/pingcap/ticdc/cdc/sink/producer/pulsar"
+	"github.com/pingcap/ticdc/cdc/sink/route"
 	"github.com/pingcap/ticdc/pkg/config"
+	cdcContext "github.com/pingcap/ticdc/pkg/context"
 	cerror "github.com/pingcap/ticdc/pkg/errors"
 	"github.com/pingcap/ticdc/pkg/filter"
+	"github.com/pingcap/ticdc/pkg/logutil"
 	"github.com/pingcap/ticdc/pkg/notify"
 	"github.com/pingcap/ticdc/pkg/security"
+	"github.com/pingcap/ticdc/pkg/util"
 	"go.uber.org/zap"
-	"golang.org/x/sync/errgroup"
 )
 
 type mqSink struct {
 	mqProducer producer.Producer
 	dispatcher dispatcher.Dispatcher
+	router     *route.Router
 	newEncoder func() codec.EventBatchEncoder
 	filter     *filter.Filter
 	protocol   codec.Protocol
@@ -50,14 +53,23 @@ type mqSink struct {
 		row        *model.RowChangedEvent
 		resolvedTs uint64
 	}
+	// partitionResolvedTs is the resolved ts flushed to the producer of each
+	// partition, which is updated by the notifications of the workers
 	partitionResolvedTs []uint64
 	checkpointTs        uint64
-	resolvedNotifier    *notify.Notifier
-	resolvedReceiver    *notify.Receiver
+	resolvedNotifier    *notify.ValueNotifier
+	resolvedReceiver    *notify.ValueReceiver
 
 	statistics *Statistics
 }
 
+// partitionResolved is the notification of a partition worker after the
+// events before the resolved ts are flushed to the producer
+type partitionResolved struct {
+	partition  int32
+	resolvedTs uint64
+}
+
 func newMqSink(
 	ctx context.Context, credential *security.Credential, mqProducer producer.Producer,
 	filter *filter.Filter, config *config.ReplicaConfig, opts map[string]string, errCh chan error,
@@ -77,7 +89,12 @@ func newMqSink(
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
-	notifier := new(notify.Notifier)
+	router, err := route.NewRouter(config)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	// every notification of the partitions is kept to be received
+	notifier := notify.NewValueNotifier(notify.PolicyQueue)
 	var protocol codec.Protocol
 	protocol.FromString(config.Sink.Protocol)
 
@@ -99,21 +116,24 @@ func newMqSink(
 				cerror.WrapError(cerror.ErrPrepareAvroFailed, err),
 				"Could not create Avro schema manager for message values")
 		}
+		tz := util.TimezoneFromCtx(ctx)
 		newEncoder1 := newEncoder
 		newEncoder = func() codec.EventBatchEncoder {
 			avroEncoder := newEncoder1().(*codec.AvroEventBatchEncoder)
 			avroEncoder.SetKeySchemaManager(keySchemaManager)
 			avroEncoder.SetValueSchemaManager(valueSchemaManager)
+			avroEncoder.SetTimeZone(tz)
 			return avroEncoder
 		}
-	} else if (protocol == codec.ProtocolCanal || protocol == codec.ProtocolCanalJson) && !config.EnableOldValue {
+	} else if protocol.RequireOldValue() && !config.EnableOldValue {
 		log.Error("Old value is not enabled when using Canal protocol. Please update changefeed config")
-		return nil, cerror.WrapError(cerror.ErrKafkaInvalidConfig, errors.New("Canal requires old value to be enabled"))
+		return nil, cerror.ErrMQCodecInvalidConfig.GenWithStack("Canal requires old value to be enabled")
 	}
 
 	k := &mqSink{
 		mqProducer: mqProducer,
 		dispatcher: d,
+		router:     router,
 		newEncoder: newEncoder,
 		filter:     filter,
 		protocol:   protocol,
@@ -122,7 +142,7 @@ func newMqSink(
 		partitionInput:      partitionInput,
 		partitionResolvedTs: make([]uint64, partitionNum),
 		resolvedNotifier:    notifier,
-		resolvedReceiver:    notifier.NewReceiver(50 * time.Millisecond),
+		resolvedReceiver:    notifier.NewReceiver(),
 
 		statistics: NewStatistics(ctx, "MQ", opts),
 	}
@@ -142,10 +162,12 @@ func newMqSink(
 func (k *mqSink) EmitRowChangedEvents(ctx context.Context, rows ...*model.RowChangedEvent) error {
 	rowsCount := 0
 	for _, row := range rows {
-		if k.filter.ShouldIgnoreDMLEvent(row.StartTs, row.Table.Schema, row.Table.Table) {
+		if k.filter.ShouldIgnoreDMLEvent(row.StartTs, row.EventType(), row.Table.Schema, row.Table.Table) {
 			log.Info("Row changed event ignored", zap.Uint64("start-ts", row.StartTs))
 			continue
 		}
+		// the rows are dispatched by the downstream schemas and tables
+		row = k.router.RouteRow(row)
 		partition := k.dispatcher.Dispatch(row)
 		select {
 		case <-ctx.Done():
@@ -177,19 +199,17 @@ func (k *mqSink) FlushRowChangedEvents(ctx context.Context, resolvedTs uint64) (
 		}
 	}
 
-	// waiting for all row events are sent to mq producer
-flushLoop:
-	for {
-		select {
-		case <-ctx.Done():
-			return 0, ctx.Err()
-		case <-k.resolvedReceiver.C:
-			for i := 0; i < int(k.partitionNum); i++ {
-				if resolvedTs > atomic.LoadUint64(&k.partitionResolvedTs[i]) {
-					continue flushLoop
-				}
-			}
-			break flushLoop
+	// waiting for all row events are sent to mq producer, which blocks even if
+	// the flush is called in an async section
+	ctx = cdcContext.WithSync(ctx)
+	for !k.partitionsResolved(resolvedTs) {
+		v, err := k.resolvedReceiver.Recv(ctx)
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+		resolved := v.(partitionResolved)
+		if resolved.resolvedTs > k.partitionResolvedTs[resolved.partition] {
+			k.partitionResolvedTs[resolved.partition] = resolved.resolvedTs
 		}
 	}
 	err := k.mqProducer.Flush(ctx)
@@ -201,6 +221,17 @@ flushLoop:
 	return k.checkpointTs, nil
 }
 
+// partitionsResolved returns whether the events of all the partitions before
+// the resolved ts are flushed to the producer
+func (k *mqSink) partitionsResolved(resolvedTs uint64) bool {
+	for _, ts := range k.partitionResolvedTs {
+		if ts < resolvedTs {
+			return false
+		}
+	}
+	return true
+}
+
 func (k *mqSink) EmitCheckpointTs(ctx context.Context, ts uint64) error {
 	encoder := k.newEncoder()
 	msg, err := encoder.EncodeCheckpointEvent(ts)
@@ -218,12 +249,16 @@ func (k *mqSink) EmitDDLEvent(ctx context.Context, ddl *model.DDLEvent) error {
 	if k.filter.ShouldIgnoreDDLEvent(ddl.StartTs, ddl.Type, ddl.TableInfo.Schema, ddl.TableInfo.Table) {
 		log.Info(
 			"DDL event ignored",
-			zap.String("query", ddl.Query),
+			logutil.ZapRedactString("query", ddl.Query),
 			zap.Uint64("startTs", ddl.StartTs),
 			zap.Uint64("commitTs", ddl.CommitTs),
 		)
 		return cerror.ErrDDLEventIgnored.GenWithStackByArgs()
 	}
+	ddl, err := k.router.RouteDDL(ddl)
+	if err != nil {
+		return errors.Trace(err)
+	}
 	encoder := k.newEncoder()
 	msg, err := encoder.EncodeDDLEvent(ddl)
 	if err != nil {
@@ -233,7 +268,7 @@ func (k *mqSink) EmitDDLEvent(ctx context.Context, ddl *model.DDLEvent) error {
 	if msg == nil {
 		return nil
 	}
-	log.Debug("emit ddl event", zap.String("query", ddl.Query), zap.Uint64("commit-ts", ddl.CommitTs))
+	log.Debug("emit ddl event", logutil.ZapRedactString("query", ddl.Query), zap.Uint64("commit-ts", ddl.CommitTs))
 	err = k.writeToProducer(ctx, msg.Key, msg.Value, codec.EncoderNeedSyncWrite, -1)
 	return errors.Trace(err)
 }
@@ -251,7 +286,8 @@ func (k *mqSink) Close() error {
 
 func (k *mqSink) run(ctx context.Context) error {
 	defer k.resolvedReceiver.Stop()
-	wg, ctx := errgroup.WithContext(ctx)
+	// the panics of the workers fail the processor the sink belongs to
+	wg, ctx := util.SafeGroupWithContext(ctx)
 	for i := int32(0); i < k.partitionNum; i++ {
 		partition := i
 		wg.Go(func() error {
@@ -314,8 +350,7 @@ func (k *mqSink) runWorker(ctx context.Context, partition int32) error {
 				if err := flushToProducer(codec.EncoderNeedAsyncWrite); err != nil {
 					return errors.Trace(err)
 				}
-				atomic.StoreUint64(&k.partitionResolvedTs[partition], e.resolvedTs)
-				k.resolvedNotifier.Notify()
+				k.resolvedNotifier.Notify(partitionResolved{partition: partition, resolvedTs: e.resolvedTs})
 			}
 			continue
 		}
@@ -355,13 +390,13 @@ func (k *mqSink) writeToProducer(ctx context.Context, key []byte, value []byte,
 	}
 
 	log.Warn("writeToProducer called with no-op",
-		zap.ByteString("key", key),
-		zap.ByteString("value", value),
+		logutil.ZapRedactByteString("key", key),
+		logutil.ZapRedactByteString("value", value),
 		zap.Int32("partition", partition))
 	return nil
 }
 
-func newKafkaSaramaSink(ctx context.Context, sinkURI *url.URL, filter *filter.Filter, replicaConfig *config.ReplicaConfig, opts map[string]string, errCh chan error) (*mqSink, error) {
+func newKafkaSaramaSink(ctx context.Context, sinkURI *url.URL, filter *filter.Filter, replicaConfig *config.ReplicaConfig, credential *security.SinkCredential, opts map[string]string, errCh chan error) (*mqSink, error) {
 	config := kafka.NewKafkaConfig()
 
 	scheme := strings.ToLower(sinkURI.Scheme)
@@ -427,6 +462,15 @@ func newKafkaSaramaSink(ctx context.Context, sinkURI *url.URL, filter *filter.Fi
 		config.Credential.KeyPath = s
 	}
 
+	err := config.ParseSASL(sinkURI.Query())
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	err = config.ApplyCredential(credential)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
 	topic := strings.TrimFunc(sinkURI.Path, func(r rune) bool {
 		return r == '/'
 	})
@@ -441,7 +485,7 @@ func newKafkaSaramaSink(ctx context.Context, sinkURI *url.URL, filter *filter.Fi
 	return sink, nil
 }
 
-func newPulsarSink(ctx context.Context, sinkURI *url.URL, filter *filter.Filter, replicaConfig *config.ReplicaConfig, opts map[string]string, errCh chan error) (*mqSink, error) {
+func newPulsarSink(ctx context.Context, sinkURI *url.URL, filter *filter.Filter, replicaConfig *config.ReplicaConfig, credential *security.SinkCredential, opts map[string]string, errCh chan error) (*mqSink, error) {
 	producer, err := pulsar.NewProducer(sinkURI, errCh)
 	if err != nil {
 		return nil, errors.Trace(err)
@@ -450,10 +494,13 @@ func newPulsarSink(ctx context.Context, sinkURI *url.URL, filter *filter.Filter,
 	if s != "" {
 		replicaConfig.Sink.Protocol = s
 	}
-	// For now, it's a place holder. Avro format have to make connection to Schema Registery,
-	// and it may needs credential.
-	credential := &security.Credential{}
-	sink, err := newMqSink(ctx, credential, producer, filter, replicaConfig, opts, errCh)
+	// The credential is only used by the Avro format to connect to the Schema
+	// Registry for now.
+	registryCredential := &security.Credential{}
+	if credential.IsTLSEnabled() {
+		registryCredential = credential.TLSCredential()
+	}
+	sink, err := newMqSink(ctx, registryCredential, producer, filter, replicaConfig, opts, errCh)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
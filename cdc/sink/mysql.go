@@ -34,12 +34,14 @@ import (
 	"github.com/pingcap/parser/mysql"
 	"github.com/pingcap/ticdc/cdc/model"
 	"github.com/pingcap/ticdc/cdc/sink/common"
+	"github.com/pingcap/ticdc/cdc/sink/route"
 	"github.com/pingcap/ticdc/pkg/config"
 	"github.com/pingcap/ticdc/pkg/cyclic"
 	"github.com/pingcap/ticdc/pkg/cyclic/mark"
 	cerror "github.com/pingcap/ticdc/pkg/errors"
 	"github.com/pingcap/ticdc/pkg/filter"
 	tifilter "github.com/pingcap/ticdc/pkg/filter"
+	"github.com/pingcap/ticdc/pkg/logutil"
 	"github.com/pingcap/ticdc/pkg/notify"
 	"github.com/pingcap/ticdc/pkg/quotes"
 	"github.com/pingcap/ticdc/pkg/retry"
@@ -85,6 +87,7 @@ type mysqlSink struct {
 	params *sinkParams
 
 	filter *filter.Filter
+	router *route.Router
 	cyclic *cyclic.Cyclic
 
 	txnCache   *common.UnresolvedTxnCache
@@ -170,13 +173,17 @@ func (s *mysqlSink) EmitDDLEvent(ctx context.Context, ddl *model.DDLEvent) error
 	if s.filter.ShouldIgnoreDDLEvent(ddl.StartTs, ddl.Type, ddl.TableInfo.Schema, ddl.TableInfo.Table) {
 		log.Info(
 			"DDL event ignored",
-			zap.String("query", ddl.Query),
+			logutil.ZapRedactString("query", ddl.Query),
 			zap.Uint64("startTs", ddl.StartTs),
 			zap.Uint64("commitTs", ddl.CommitTs),
 		)
 		return cerror.ErrDDLEventIgnored.GenWithStackByArgs()
 	}
-	err := s.execDDLWithMaxRetries(ctx, ddl, defaultDDLMaxRetryTime)
+	ddl, err := s.router.RouteDDL(ddl)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	err = s.execDDLWithMaxRetries(ctx, ddl, defaultDDLMaxRetryTime)
 	return errors.Trace(err)
 }
 
@@ -190,14 +197,14 @@ func (s *mysqlSink) execDDLWithMaxRetries(ctx context.Context, ddl *model.DDLEve
 		func() error {
 			err := s.execDDL(ctx, ddl)
 			if isIgnorableDDLError(err) {
-				log.Info("execute DDL failed, but error can be ignored", zap.String("query", ddl.Query), zap.Error(err))
+				log.Info("execute DDL failed, but error can be ignored", logutil.ZapRedactString("query", ddl.Query), zap.Error(err))
 				return nil
 			}
 			if errors.Cause(err) == context.Canceled {
 				return backoff.Permanent(err)
 			}
 			if err != nil {
-				log.Warn("execute DDL with error, retry later", zap.String("query", ddl.Query), zap.Error(err))
+				log.Warn("execute DDL with error, retry later", logutil.ZapRedactString("query", ddl.Query), zap.Error(err))
 			}
 			return err
 		})
@@ -232,7 +239,7 @@ func (s *mysqlSink) execDDL(ctx context.Context, ddl *model.DDLEvent) error {
 
 	if _, err = tx.ExecContext(ctx, ddl.Query); err != nil {
 		if rbErr := tx.Rollback(); rbErr != nil {
-			log.Error("Failed to rollback", zap.String("sql", ddl.Query), zap.Error(err))
+			log.Error("Failed to rollback", logutil.ZapRedactString("sql", ddl.Query), zap.Error(err))
 		}
 		return cerror.WrapError(cerror.ErrMySQLTxnError, err)
 	}
@@ -241,7 +248,7 @@ func (s *mysqlSink) execDDL(ctx context.Context, ddl *model.DDLEvent) error {
 		return cerror.WrapError(cerror.ErrMySQLTxnError, err)
 	}
 
-	log.Info("Exec DDL succeeded", zap.String("sql", ddl.Query))
+	log.Info("Exec DDL succeeded", logutil.ZapRedactString("sql", ddl.Query))
 	return nil
 }
 
@@ -282,6 +289,8 @@ type sinkParams struct {
 	writeTimeout        string
 	enableOldValue      bool
 	safeMode            bool
+	// the rows committed no later than safeModeUntilTs are written in safe mode
+	safeModeUntilTs uint64
 }
 
 func (s *sinkParams) Clone() *sinkParams {
@@ -357,6 +366,23 @@ func configureSinkURI(
 	return dsnCfg.FormatDSN(), nil
 }
 
+// mysqlTLSCredential returns the credential of the TLS connection to the
+// downstream, or nil if TLS isn't enabled. The changefeed credential takes
+// precedence over the certificates in the sink URI.
+func mysqlTLSCredential(sinkURI *url.URL, credential *security.SinkCredential) *security.Credential {
+	if credential.IsTLSEnabled() {
+		return credential.TLSCredential()
+	}
+	if sinkURI.Query().Get("ssl-ca") == "" {
+		return nil
+	}
+	return &security.Credential{
+		CAPath:   sinkURI.Query().Get("ssl-ca"),
+		CertPath: sinkURI.Query().Get("ssl-cert"),
+		KeyPath:  sinkURI.Query().Get("ssl-key"),
+	}
+}
+
 // newMySQLSink creates a new MySQL sink using schema storage
 func newMySQLSink(
 	ctx context.Context,
@@ -364,6 +390,7 @@ func newMySQLSink(
 	sinkURI *url.URL,
 	filter *tifilter.Filter,
 	replicaConfig *config.ReplicaConfig,
+	credential *security.SinkCredential,
 	opts map[string]string,
 ) (Sink, error) {
 	var db *sql.DB
@@ -375,6 +402,13 @@ func newMySQLSink(
 	if caddr, ok := opts[OptCaptureAddr]; ok {
 		params.captureAddr = caddr
 	}
+	if untilTs, ok := opts[OptSafeModeUntilTs]; ok {
+		ts, err := strconv.ParseUint(untilTs, 10, 64)
+		if err != nil {
+			return nil, cerror.WrapError(cerror.ErrMySQLInvalidConfig, err)
+		}
+		params.safeModeUntilTs = ts
+	}
 	tz := util.TimezoneFromCtx(ctx)
 
 	if sinkURI == nil {
@@ -384,6 +418,10 @@ func newMySQLSink(
 	if _, ok := validSchemes[scheme]; !ok {
 		return nil, cerror.ErrMySQLConnectionError.GenWithStack("can't create mysql sink with unsupported scheme: %s", scheme)
 	}
+	router, err := route.NewRouter(replicaConfig)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
 	s := sinkURI.Query().Get("worker-count")
 	if s != "" {
 		c, err := strconv.Atoi(s)
@@ -411,13 +449,8 @@ func newMySQLSink(
 		}
 	}
 	var tlsParam string
-	if sinkURI.Query().Get("ssl-ca") != "" {
-		credential := security.Credential{
-			CAPath:   sinkURI.Query().Get("ssl-ca"),
-			CertPath: sinkURI.Query().Get("ssl-cert"),
-			KeyPath:  sinkURI.Query().Get("ssl-key"),
-		}
-		tlsCfg, err := credential.ToTLSConfig()
+	if tlsCredential := mysqlTLSCredential(sinkURI, credential); tlsCredential != nil {
+		tlsCfg, err := tlsCredential.ToTLSConfig()
 		if err != nil {
 			return nil, errors.Annotate(err, "fail to open MySQL connection")
 		}
@@ -520,6 +553,7 @@ func newMySQLSink(
 		db:                              db,
 		params:                          params,
 		filter:                          filter,
+		router:                          router,
 		txnCache:                        common.NewUnresolvedTxnCache(),
 		statistics:                      NewStatistics(ctx, "mysql", opts),
 		metricConflictDetectDurationHis: metricConflictDetectDurationHis,
@@ -558,7 +592,8 @@ func (s *mysqlSink) createSinkWorkers(ctx context.Context) {
 			s.params.maxTxnRow, i, s.metricBucketSizeCounters[i], receiver, s.execDMLs)
 		s.workers[i] = worker
 		go func() {
-			err := worker.run(ctx)
+			// the panics of the workers fail the processor the sink belongs to
+			err := util.CallSafe(func() error { return worker.run(ctx) })
 			if err != nil && errors.Cause(err) != context.Canceled {
 				select {
 				case s.errCh <- err:
@@ -740,8 +775,8 @@ func (s *mysqlSink) execDMLWithMaxRetries(
 ) error {
 	if len(dmls.sqls) != len(dmls.values) {
 		log.Fatal("unexpected number of sqls and values",
-			zap.Strings("sqls", dmls.sqls),
-			zap.Any("values", dmls.values))
+			logutil.ZapRedactStrings("sqls", dmls.sqls),
+			logutil.ZapRedactReflect("values", dmls.values))
 	}
 	checkTxnErr := func(err error) error {
 		if errors.Cause(err) == context.Canceled {
@@ -765,7 +800,7 @@ func (s *mysqlSink) execDMLWithMaxRetries(
 				}
 				for i, query := range dmls.sqls {
 					args := dmls.values[i]
-					log.Debug("exec row", zap.String("sql", query), zap.Any("args", args))
+					log.Debug("exec row", logutil.ZapRedactString("sql", query), logutil.ZapRedactReflect("args", args))
 					if _, err := tx.ExecContext(ctx, query, args...); err != nil {
 						return 0, checkTxnErr(cerror.WrapError(cerror.ErrMySQLTxnError, err))
 					}
@@ -821,10 +856,13 @@ func (s *mysqlSink) prepareDMLs(rows []*model.RowChangedEvent, replicaID uint64,
 	for _, row := range rows {
 		var query string
 		var args []interface{}
-		quoteTable := quotes.QuoteSchema(row.Table.Schema, row.Table.Table)
+		quoteTable := quotes.QuoteSchema(s.router.Route(row.Table.Schema, row.Table.Table))
+		// the rows may be already written if they're committed before the
+		// safe mode ends
+		rowTranslateToInsert := translateToInsert && row.CommitTs > s.params.safeModeUntilTs
 
 		// Translate to UPDATE if old value is enabled, not in safe mode and is update event
-		if translateToInsert && len(row.PreColumns) != 0 && len(row.Columns) != 0 {
+		if rowTranslateToInsert && len(row.PreColumns) != 0 && len(row.Columns) != 0 {
 			flushCacheDMLs()
 			query, args = prepareUpdate(quoteTable, row.PreColumns, row.Columns)
 			if query != "" {
@@ -851,7 +889,7 @@ func (s *mysqlSink) prepareDMLs(rows []*model.RowChangedEvent, replicaID uint64,
 		// Case for insert event or update event
 		if len(row.Columns) != 0 {
 			if s.params.batchReplaceEnabled {
-				query, args = prepareReplace(quoteTable, row.Columns, false /* appendPlaceHolder */, translateToInsert)
+				query, args = prepareReplace(quoteTable, row.Columns, false /* appendPlaceHolder */, rowTranslateToInsert)
 				if query != "" {
 					if _, ok := replaces[query]; !ok {
 						replaces[query] = make([][]interface{}, 0)
@@ -860,7 +898,7 @@ func (s *mysqlSink) prepareDMLs(rows []*model.RowChangedEvent, replicaID uint64,
 					rowCount++
 				}
 			} else {
-				query, args = prepareReplace(quoteTable, row.Columns, true /* appendPlaceHolder */, translateToInsert)
+				query, args = prepareReplace(quoteTable, row.Columns, true /* appendPlaceHolder */, rowTranslateToInsert)
 				sqls = append(sqls, query)
 				values = append(values, args)
 				if query != "" {
@@ -902,7 +940,7 @@ func (s *mysqlSink) execDMLs(ctx context.Context, rows []*model.RowChangedEvent,
 		failpoint.Return(errors.Trace(dmysql.ErrInvalidConn))
 	})
 	dmls := s.prepareDMLs(rows, replicaID, bucket)
-	log.Debug("prepare DMLs", zap.Any("rows", rows), zap.Strings("sqls", dmls.sqls), zap.Any("values", dmls.values))
+	log.Debug("prepare DMLs", logutil.ZapRedactReflect("rows", rows), logutil.ZapRedactStrings("sqls", dmls.sqls), logutil.ZapRedactReflect("values", dmls.values))
 	if err := s.execDMLWithMaxRetries(ctx, dmls, defaultDMLMaxRetryTime, bucket); err != nil {
 		ts := make([]uint64, 0, len(rows))
 		for _, row := range rows {
@@ -1113,7 +1151,7 @@ func buildColumnList(names []string) string {
 }
 
 // newSyncpointStore create a sink to record the syncpoint map in downstream DB for every changefeed
-func newMySQLSyncpointStore(ctx context.Context, id string, sinkURI *url.URL) (SyncpointStore, error) {
+func newMySQLSyncpointStore(ctx context.Context, id string, sinkURI *url.URL, credential *security.SinkCredential) (SyncpointStore, error) {
 	var syncDB *sql.DB
 
 	//todo If is neither mysql nor tidb, such as kafka, just ignore this feature.
@@ -1131,13 +1169,8 @@ func newMySQLSyncpointStore(ctx context.Context, id string, sinkURI *url.URL) (S
 		}
 	}
 	var tlsParam string
-	if sinkURI.Query().Get("ssl-ca") != "" {
-		credential := security.Credential{
-			CAPath:   sinkURI.Query().Get("ssl-ca"),
-			CertPath: sinkURI.Query().Get("ssl-cert"),
-			KeyPath:  sinkURI.Query().Get("ssl-key"),
-		}
-		tlsCfg, err := credential.ToTLSConfig()
+	if tlsCredential := mysqlTLSCredential(sinkURI, credential); tlsCredential != nil {
+		tlsCfg, err := tlsCredential.ToTLSConfig()
 		if err != nil {
 			return nil, errors.Annotate(err, "fail to open MySQL connection")
 		}
@@ -29,6 +29,7 @@ import (
 	"github.com/pingcap/parser/mysql"
 	"github.com/pingcap/ticdc/cdc/model"
 	"github.com/pingcap/ticdc/cdc/sink/common"
+	"github.com/pingcap/ticdc/cdc/sink/route"
 	"github.com/pingcap/ticdc/pkg/config"
 	"github.com/pingcap/ticdc/pkg/filter"
 	"github.com/pingcap/ticdc/pkg/notify"
@@ -443,6 +444,44 @@ func (s MySQLSinkSuite) TestPrepareDML(c *check.C) {
 		dmls := ms.prepareDMLs(tc.input, 0, 0)
 		c.Assert(dmls, check.DeepEquals, tc.expected, check.Commentf("%d", i))
 	}
+
+	// the rows are written to the routed tables
+	cfg := config.GetDefaultReplicaConfig()
+	cfg.Sink.RouteRules = []*config.RouteRule{
+		{Matcher: []string{"common_*.*"}, TargetSchema: "common", TargetTable: "merged"},
+	}
+	router, err := route.NewRouter(cfg)
+	c.Assert(err, check.IsNil)
+	ms.router = router
+	dmls := ms.prepareDMLs(testCases[1].input, 0, 0)
+	c.Assert(dmls.sqls, check.DeepEquals, []string{"DELETE FROM `common`.`merged` WHERE `a1` = ? AND `a3` = ? LIMIT 1;"})
+}
+
+func (s MySQLSinkSuite) TestPrepareDMLWithSafeModeUntilTs(c *check.C) {
+	ms := newMySQLSink4Test(c)
+	ms.params.enableOldValue = true
+	ms.params.safeMode = false
+	ms.params.safeModeUntilTs = 100
+	newRow := func(commitTs uint64) *model.RowChangedEvent {
+		return &model.RowChangedEvent{
+			StartTs:  commitTs - 1,
+			CommitTs: commitTs,
+			Table:    &model.TableName{Schema: "test", Table: "t"},
+			Columns: []*model.Column{{
+				Name:  "a",
+				Type:  mysql.TypeLong,
+				Flag:  model.BinaryFlag | model.HandleKeyFlag,
+				Value: 1,
+			}},
+		}
+	}
+	// the rows committed in the safe mode window are replaced
+	for _, commitTs := range []uint64{99, 100} {
+		dmls := ms.prepareDMLs([]*model.RowChangedEvent{newRow(commitTs)}, 0, 0)
+		c.Assert(dmls.sqls[0], check.Matches, "REPLACE INTO `test`.`t`.*")
+	}
+	dmls := ms.prepareDMLs([]*model.RowChangedEvent{newRow(101)}, 0, 0)
+	c.Assert(dmls.sqls[0], check.Matches, "INSERT INTO `test`.`t`.*")
 }
 
 func (s MySQLSinkSuite) TestPrepareUpdate(c *check.C) {
@@ -16,6 +16,7 @@ package kafka
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"regexp"
 	"strings"
 	"sync"
@@ -43,7 +44,69 @@ type Config struct {
 	Compression     string
 	ClientID        string
 	Credential      *security.Credential
-	// TODO support SASL authentication
+	// SASLMechanism is the SASL mechanism used to authenticate with Kafka, SASL
+	// is disabled if it's empty. Only GSSAPI is supported.
+	SASLMechanism string
+	// GSSAPI is the Kerberos configuration of the GSSAPI mechanism
+	GSSAPI sarama.GSSAPIConfig
+}
+
+// ParseSASL sets the SASL configuration from the parameters of the sink URI:
+// sasl-mechanism, and sasl-gssapi-auth-type (keytab or user), sasl-gssapi-keytab-path,
+// sasl-gssapi-kerberos-config-path, sasl-gssapi-service-name, sasl-gssapi-user,
+// sasl-gssapi-password and sasl-gssapi-realm for the GSSAPI mechanism.
+func (c *Config) ParseSASL(params url.Values) error {
+	s := params.Get("sasl-mechanism")
+	if s == "" {
+		return nil
+	}
+	if !strings.EqualFold(s, sarama.SASLTypeGSSAPI) {
+		return cerror.ErrKafkaInvalidConfig.GenWithStack("unsupported SASL mechanism %s", s)
+	}
+	c.SASLMechanism = sarama.SASLTypeGSSAPI
+
+	switch strings.ToLower(params.Get("sasl-gssapi-auth-type")) {
+	case "", "keytab":
+		c.GSSAPI.AuthType = sarama.KRB5_KEYTAB_AUTH
+	case "user":
+		c.GSSAPI.AuthType = sarama.KRB5_USER_AUTH
+	default:
+		return cerror.ErrKafkaInvalidConfig.GenWithStack(
+			"invalid GSSAPI auth type %s, keytab or user is expected", params.Get("sasl-gssapi-auth-type"))
+	}
+	c.GSSAPI.KeyTabPath = params.Get("sasl-gssapi-keytab-path")
+	c.GSSAPI.KerberosConfigPath = params.Get("sasl-gssapi-kerberos-config-path")
+	c.GSSAPI.ServiceName = params.Get("sasl-gssapi-service-name")
+	if c.GSSAPI.ServiceName == "" {
+		c.GSSAPI.ServiceName = "kafka"
+	}
+	c.GSSAPI.Username = params.Get("sasl-gssapi-user")
+	c.GSSAPI.Password = params.Get("sasl-gssapi-password")
+	c.GSSAPI.Realm = params.Get("sasl-gssapi-realm")
+	return nil
+}
+
+// ApplyCredential sets the certificates and the SASL secrets of the changefeed
+// credential, which take precedence over the ones in the sink URI.
+func (c *Config) ApplyCredential(credential *security.SinkCredential) error {
+	if credential.IsTLSEnabled() {
+		c.Credential = credential.TLSCredential()
+	}
+	if !credential.IsSASLEnabled() {
+		return nil
+	}
+	if c.SASLMechanism == "" {
+		return cerror.ErrKafkaInvalidConfig.GenWithStack(
+			"sasl-mechanism is required by the SASL secrets of the changefeed credential")
+	}
+	if credential.SASLUser != "" {
+		c.GSSAPI.Username = credential.SASLUser
+	}
+	if credential.SASLPassword != "" {
+		c.GSSAPI.AuthType = sarama.KRB5_USER_AUTH
+		c.GSSAPI.Password = credential.SASLPassword
+	}
+	return nil
 }
 
 // NewKafkaConfig returns a default Kafka configuration
@@ -418,7 +481,7 @@ func newSaramaConfig(ctx context.Context, c Config) (*sarama.Config, error) {
 	config.Admin.Retry.Backoff = 500 * time.Millisecond
 	config.Admin.Timeout = 20 * time.Second
 
-	if c.Credential != nil && len(c.Credential.CAPath) != 0 {
+	if c.Credential != nil && c.Credential.IsTLSEnabled() {
 		config.Net.TLS.Enable = true
 		config.Net.TLS.Config, err = c.Credential.ToTLSConfig()
 		if err != nil {
@@ -426,5 +489,11 @@ func newSaramaConfig(ctx context.Context, c Config) (*sarama.Config, error) {
 		}
 	}
 
+	if c.SASLMechanism != "" {
+		config.Net.SASL.Enable = true
+		config.Net.SASL.Mechanism = sarama.SASLMechanism(c.SASLMechanism)
+		config.Net.SASL.GSSAPI = c.GSSAPI
+	}
+
 	return config, err
 }
@@ -14,9 +14,13 @@
 package kafka
 
 import (
+	"context"
+	"net/url"
 	"testing"
 
+	"github.com/Shopify/sarama"
 	"github.com/pingcap/check"
+	"github.com/pingcap/ticdc/pkg/security"
 )
 
 type kafkaSuite struct{}
@@ -50,3 +54,63 @@ func (s *kafkaSuite) TestClientID(c *check.C) {
 		}
 	}
 }
+
+func (s *kafkaSuite) TestParseSASL(c *check.C) {
+	config := NewKafkaConfig()
+	err := config.ParseSASL(url.Values{})
+	c.Assert(err, check.IsNil)
+	c.Assert(config.SASLMechanism, check.Equals, "")
+
+	params := url.Values{}
+	params.Set("sasl-mechanism", "gssapi")
+	params.Set("sasl-gssapi-user", "ticdc")
+	params.Set("sasl-gssapi-realm", "TICDC.TEST")
+	params.Set("sasl-gssapi-keytab-path", "/kerberos/ticdc.keytab")
+	params.Set("sasl-gssapi-kerberos-config-path", "/kerberos/krb5.conf")
+	err = config.ParseSASL(params)
+	c.Assert(err, check.IsNil)
+	c.Assert(config.SASLMechanism, check.Equals, sarama.SASLTypeGSSAPI)
+	c.Assert(config.GSSAPI, check.DeepEquals, sarama.GSSAPIConfig{
+		AuthType:           sarama.KRB5_KEYTAB_AUTH,
+		KeyTabPath:         "/kerberos/ticdc.keytab",
+		KerberosConfigPath: "/kerberos/krb5.conf",
+		ServiceName:        "kafka",
+		Username:           "ticdc",
+		Realm:              "TICDC.TEST",
+	})
+
+	saramaConfig, err := newSaramaConfig(context.Background(), config)
+	c.Assert(err, check.IsNil)
+	c.Assert(saramaConfig.Net.SASL.Enable, check.IsTrue)
+	c.Assert(saramaConfig.Net.SASL.Mechanism, check.Equals, sarama.SASLMechanism(sarama.SASLTypeGSSAPI))
+	c.Assert(saramaConfig.Validate(), check.IsNil)
+
+	params.Set("sasl-gssapi-auth-type", "password")
+	c.Assert(config.ParseSASL(params), check.ErrorMatches, ".*invalid GSSAPI auth type.*")
+	params.Set("sasl-mechanism", "plain")
+	c.Assert(config.ParseSASL(params), check.ErrorMatches, ".*unsupported SASL mechanism.*")
+}
+
+func (s *kafkaSuite) TestApplyCredential(c *check.C) {
+	config := NewKafkaConfig()
+	config.Credential.CAPath = "/tls/ca.pem"
+	credential := &security.SinkCredential{SASLUser: "cdc", SASLPassword: "secret"}
+	c.Assert(config.ApplyCredential(credential), check.ErrorMatches, ".*sasl-mechanism is required.*")
+
+	params := url.Values{}
+	params.Set("sasl-mechanism", "gssapi")
+	params.Set("sasl-gssapi-user", "ticdc")
+	params.Set("sasl-gssapi-keytab-path", "/kerberos/ticdc.keytab")
+	c.Assert(config.ParseSASL(params), check.IsNil)
+	c.Assert(config.ApplyCredential(credential), check.IsNil)
+	c.Assert(config.GSSAPI.AuthType, check.Equals, sarama.KRB5_USER_AUTH)
+	c.Assert(config.GSSAPI.Username, check.Equals, "cdc")
+	c.Assert(config.GSSAPI.Password, check.Equals, "secret")
+	// the certificates in the sink URI are used if the credential has no CA
+	c.Assert(config.Credential.CAPath, check.Equals, "/tls/ca.pem")
+
+	credential = &security.SinkCredential{CA: "ca", Cert: "cert", Key: "key"}
+	c.Assert(config.ApplyCredential(credential), check.IsNil)
+	c.Assert(config.Credential, check.DeepEquals, credential.TLSCredential())
+	c.Assert(config.GSSAPI.Password, check.Equals, "secret")
+}
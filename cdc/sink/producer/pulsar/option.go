@@ -15,13 +15,13 @@ package pulsar
 
 import (
 	"encoding/json"
-	"fmt"
 	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/apache/pulsar-client-go/pulsar"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
 )
 
 // Option is pulsar producer's option.
@@ -36,7 +36,7 @@ func parseSinkOptions(u *url.URL) (opt *Option, err error) {
 	switch u.Scheme {
 	case "pulsar", "pulsar+ssl":
 	default:
-		return nil, fmt.Errorf("unsupported pulsar scheme: %s", u.Scheme)
+		return nil, cerror.ErrPulsarInvalidOption.GenWithStack("unsupported pulsar scheme: %s", u.Scheme)
 	}
 	c, err := parseClientOption(u)
 	if err != nil {
@@ -83,7 +83,7 @@ func parseClientOption(u *url.URL) (opt *pulsar.ClientOptions, err error) {
 	param := jsonStr(vs.SubPathKV("auth"))
 	opt.Authentication, err = pulsar.NewAuthentication(auth, param)
 	if err != nil {
-		return nil, err
+		return nil, cerror.WrapError(cerror.ErrPulsarInvalidOption, err)
 	}
 	return opt, nil
 }
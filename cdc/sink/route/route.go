@@ -0,0 +1,213 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package route
+
+import (
+	"strings"
+
+	"github.com/pingcap/parser"
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/format"
+	timodel "github.com/pingcap/parser/model"
+	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/pkg/config"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+	filter "github.com/pingcap/tidb-tools/pkg/table-filter"
+	// the parser driver is required to parse the values in the DDLs
+	_ "github.com/pingcap/tidb/types/parser_driver"
+)
+
+// Router maps the upstream schemas and tables to the downstream ones by the
+// route rules, the first matched rule takes effect. A nil Router keeps all
+// the names.
+type Router struct {
+	rules []*rule
+}
+
+type rule struct {
+	filter.Filter
+	targetSchema string
+	targetTable  string
+}
+
+// NewRouter creates a Router by the route rules of the sink config, it returns
+// nil if there are no route rules.
+func NewRouter(cfg *config.ReplicaConfig) (*Router, error) {
+	if cfg.Sink == nil || len(cfg.Sink.RouteRules) == 0 {
+		return nil, nil
+	}
+	rules := make([]*rule, 0, len(cfg.Sink.RouteRules))
+	for _, ruleConfig := range cfg.Sink.RouteRules {
+		if ruleConfig.TargetSchema == "" && ruleConfig.TargetTable == "" {
+			return nil, cerror.ErrRouteRuleInvalid.GenWithStack(
+				"the target schema or table is required for the route rule %v", ruleConfig.Matcher)
+		}
+		f, err := filter.Parse(ruleConfig.Matcher)
+		if err != nil {
+			return nil, cerror.WrapError(cerror.ErrRouteRuleInvalid, err)
+		}
+		if !cfg.CaseSensitive {
+			f = filter.CaseInsensitive(f)
+		}
+		rules = append(rules, &rule{
+			Filter:       f,
+			targetSchema: ruleConfig.TargetSchema,
+			targetTable:  ruleConfig.TargetTable,
+		})
+	}
+	return &Router{rules: rules}, nil
+}
+
+// Route returns the downstream schema and table of the upstream table
+func (r *Router) Route(schema, table string) (string, string) {
+	if r == nil {
+		return schema, table
+	}
+	for _, rule := range r.rules {
+		if !rule.MatchTable(schema, table) {
+			continue
+		}
+		if rule.targetSchema != "" {
+			schema = rule.targetSchema
+		}
+		if rule.targetTable != "" {
+			table = rule.targetTable
+		}
+		break
+	}
+	return schema, table
+}
+
+// RouteSchema returns the downstream schema of the upstream schema. Only the
+// rules without the target table rename the schemas in the schema DDLs, so
+// that dropping a schema of the merged shards won't drop the target schema.
+func (r *Router) RouteSchema(schema string) string {
+	if r == nil {
+		return schema
+	}
+	for _, rule := range r.rules {
+		if rule.targetTable == "" && rule.targetSchema != "" && rule.MatchSchema(schema) {
+			return rule.targetSchema
+		}
+	}
+	return schema
+}
+
+// RouteRow returns the row with the downstream schema and table, the row is
+// copied if it's routed.
+func (r *Router) RouteRow(row *model.RowChangedEvent) *model.RowChangedEvent {
+	if r == nil || row.Table == nil {
+		return row
+	}
+	schema, table := r.Route(row.Table.Schema, row.Table.Table)
+	if schema == row.Table.Schema && table == row.Table.Table {
+		return row
+	}
+	routed := *row
+	tableName := *row.Table
+	tableName.Schema, tableName.Table = schema, table
+	routed.Table = &tableName
+	return &routed
+}
+
+// RouteDDL returns the DDL with the downstream schemas and tables, the table
+// names in the query are rewritten with the schemas, and the DDL is copied if
+// it's routed.
+func (r *Router) RouteDDL(ddl *model.DDLEvent) (*model.DDLEvent, error) {
+	if r == nil || ddl.TableInfo == nil {
+		return ddl, nil
+	}
+	stmt, err := parser.New().ParseOneStmt(ddl.Query, "", "")
+	if err != nil {
+		return nil, cerror.ErrRouteDDL.GenWithStack("route the DDL %s failed: %s", ddl.Query, err)
+	}
+	v := &tableNameRouter{router: r, defaultSchema: ddl.TableInfo.Schema}
+	switch s := stmt.(type) {
+	case *ast.CreateDatabaseStmt:
+		v.routeSchema(&s.Name)
+	case *ast.AlterDatabaseStmt:
+		if !s.AlterDefaultDatabase {
+			v.routeSchema(&s.Name)
+		}
+	case *ast.DropDatabaseStmt:
+		v.routeSchema(&s.Name)
+	default:
+		stmt.Accept(v)
+	}
+	if !v.routed {
+		return ddl, nil
+	}
+	var sb strings.Builder
+	if err := stmt.Restore(format.NewRestoreCtx(format.DefaultRestoreFlags, &sb)); err != nil {
+		return nil, cerror.ErrRouteDDL.GenWithStack("route the DDL %s failed: %s", ddl.Query, err)
+	}
+	routed := *ddl
+	routed.Query = sb.String()
+	routed.TableInfo = r.routeTableInfo(ddl.TableInfo, ddl.Type)
+	if ddl.PreTableInfo != nil {
+		routed.PreTableInfo = r.routeTableInfo(ddl.PreTableInfo, ddl.Type)
+	}
+	return &routed, nil
+}
+
+func (r *Router) routeTableInfo(info *model.SimpleTableInfo, tp timodel.ActionType) *model.SimpleTableInfo {
+	routed := *info
+	switch tp {
+	case timodel.ActionCreateSchema, timodel.ActionDropSchema, timodel.ActionModifySchemaCharsetAndCollate:
+		routed.Schema = r.RouteSchema(info.Schema)
+	default:
+		routed.Schema, routed.Table = r.Route(info.Schema, info.Table)
+	}
+	return &routed
+}
+
+// tableNameRouter rewrites the table names in the DDL, all the table names are
+// qualified with the schemas once any of them is routed, because the current
+// schema of the downstream may be different from the upstream.
+type tableNameRouter struct {
+	router        *Router
+	defaultSchema string
+	routed        bool
+}
+
+func (v *tableNameRouter) routeSchema(schema *string) {
+	targetSchema := v.router.RouteSchema(*schema)
+	if targetSchema != *schema {
+		*schema = targetSchema
+		v.routed = true
+	}
+}
+
+// Enter implements ast.Visitor
+func (v *tableNameRouter) Enter(in ast.Node) (ast.Node, bool) {
+	tn, ok := in.(*ast.TableName)
+	if !ok {
+		return in, false
+	}
+	schema := tn.Schema.O
+	if schema == "" {
+		schema = v.defaultSchema
+	}
+	targetSchema, targetTable := v.router.Route(schema, tn.Name.O)
+	if targetSchema != schema || targetTable != tn.Name.O {
+		v.routed = true
+	}
+	tn.Schema, tn.Name = timodel.NewCIStr(targetSchema), timodel.NewCIStr(targetTable)
+	return in, true
+}
+
+// Leave implements ast.Visitor
+func (v *tableNameRouter) Leave(in ast.Node) (ast.Node, bool) {
+	return in, true
+}
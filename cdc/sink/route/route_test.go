@@ -0,0 +1,159 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package route
+
+import (
+	"testing"
+
+	"github.com/pingcap/check"
+	timodel "github.com/pingcap/parser/model"
+	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/pkg/config"
+)
+
+func Test(t *testing.T) { check.TestingT(t) }
+
+type routeSuite struct{}
+
+var _ = check.Suite(&routeSuite{})
+
+func newTestRouter(c *check.C) *Router {
+	cfg := config.GetDefaultReplicaConfig()
+	cfg.CaseSensitive = false
+	cfg.Sink.RouteRules = []*config.RouteRule{
+		{Matcher: []string{"shard_*.orders_*"}, TargetSchema: "merged", TargetTable: "orders"},
+		{Matcher: []string{"test.t1"}, TargetTable: "t1_copy"},
+		{Matcher: []string{"src.*"}, TargetSchema: "dst"},
+	}
+	r, err := NewRouter(cfg)
+	c.Assert(err, check.IsNil)
+	return r
+}
+
+func (s *routeSuite) TestRoute(c *check.C) {
+	r := newTestRouter(c)
+	testCases := []struct {
+		schema, table             string
+		targetSchema, targetTable string
+	}{
+		{"shard_1", "orders_1", "merged", "orders"},
+		{"SHARD_2", "orders_2", "merged", "orders"},
+		{"shard_1", "users", "shard_1", "users"},
+		{"test", "t1", "test", "t1_copy"},
+		{"test", "t2", "test", "t2"},
+		{"src", "t1", "dst", "t1"},
+	}
+	for _, tc := range testCases {
+		schema, table := r.Route(tc.schema, tc.table)
+		c.Assert(schema, check.Equals, tc.targetSchema)
+		c.Assert(table, check.Equals, tc.targetTable)
+	}
+	c.Assert(r.RouteSchema("src"), check.Equals, "dst")
+	c.Assert(r.RouteSchema("shard_1"), check.Equals, "shard_1")
+
+	row := &model.RowChangedEvent{Table: &model.TableName{Schema: "shard_1", Table: "orders_1", TableID: 1}}
+	routed := r.RouteRow(row)
+	c.Assert(routed.Table, check.DeepEquals, &model.TableName{Schema: "merged", Table: "orders", TableID: 1})
+	c.Assert(row.Table.Schema, check.Equals, "shard_1")
+	row = &model.RowChangedEvent{Table: &model.TableName{Schema: "test", Table: "t2"}}
+	c.Assert(r.RouteRow(row), check.Equals, row)
+
+	// the nil router keeps all the names
+	r = nil
+	schema, table := r.Route("test", "t1")
+	c.Assert(schema, check.Equals, "test")
+	c.Assert(table, check.Equals, "t1")
+	c.Assert(r.RouteRow(row), check.Equals, row)
+
+	cfg := config.GetDefaultReplicaConfig()
+	r, err := NewRouter(cfg)
+	c.Assert(err, check.IsNil)
+	c.Assert(r, check.IsNil)
+	cfg.Sink.RouteRules = []*config.RouteRule{{Matcher: []string{"test.*"}}}
+	_, err = NewRouter(cfg)
+	c.Assert(err, check.ErrorMatches, ".*the target schema or table is required.*")
+}
+
+func (s *routeSuite) TestRouteDDL(c *check.C) {
+	r := newTestRouter(c)
+	testCases := []struct {
+		tp                        timodel.ActionType
+		schema, table             string
+		query                     string
+		expected                  string
+		targetSchema, targetTable string
+	}{
+		{
+			timodel.ActionCreateTable, "shard_1", "orders_1",
+			"create table orders_1 (id int primary key, v varchar(10) default 'a')",
+			"CREATE TABLE `merged`.`orders` (`id` INT PRIMARY KEY,`v` VARCHAR(10) DEFAULT 'a')",
+			"merged", "orders",
+		},
+		{
+			timodel.ActionAddColumn, "test", "t1",
+			"alter table test.t1 add column c int",
+			"ALTER TABLE `test`.`t1_copy` ADD COLUMN `c` INT",
+			"test", "t1_copy",
+		},
+		{
+			timodel.ActionCreateTable, "test", "t3",
+			"create table t3 like t1",
+			"CREATE TABLE `test`.`t3` LIKE `test`.`t1_copy`",
+			"test", "t3",
+		},
+		{
+			timodel.ActionCreateSchema, "src", "",
+			"create database src",
+			"CREATE DATABASE `dst`",
+			"dst", "",
+		},
+		{
+			timodel.ActionDropSchema, "shard_1", "",
+			"drop database shard_1",
+			"drop database shard_1",
+			"shard_1", "",
+		},
+		{
+			timodel.ActionAddColumn, "test", "t2",
+			"alter table t2 add column c int",
+			"alter table t2 add column c int",
+			"test", "t2",
+		},
+	}
+	for _, tc := range testCases {
+		ddl := &model.DDLEvent{
+			TableInfo: &model.SimpleTableInfo{Schema: tc.schema, Table: tc.table},
+			Query:     tc.query,
+			Type:      tc.tp,
+		}
+		routed, err := r.RouteDDL(ddl)
+		c.Assert(err, check.IsNil)
+		c.Assert(routed.Query, check.Equals, tc.expected)
+		c.Assert(routed.TableInfo.Schema, check.Equals, tc.targetSchema)
+		c.Assert(routed.TableInfo.Table, check.Equals, tc.targetTable)
+		c.Assert(ddl.Query, check.Equals, tc.query)
+	}
+
+	ddl := &model.DDLEvent{
+		TableInfo:    &model.SimpleTableInfo{Schema: "test", Table: "t1"},
+		PreTableInfo: &model.SimpleTableInfo{Schema: "src", Table: "t1"},
+		Query:        "rename table src.t1 to test.t1",
+		Type:         timodel.ActionRenameTable,
+	}
+	routed, err := r.RouteDDL(ddl)
+	c.Assert(err, check.IsNil)
+	c.Assert(routed.Query, check.Equals, "RENAME TABLE `dst`.`t1` TO `test`.`t1_copy`")
+	c.Assert(routed.PreTableInfo.Schema, check.Equals, "dst")
+	c.Assert(routed.TableInfo.Table, check.Equals, "t1_copy")
+}
@@ -20,15 +20,22 @@ import (
 
 	"github.com/pingcap/ticdc/cdc/model"
 	"github.com/pingcap/ticdc/cdc/sink/cdclog"
+	"github.com/pingcap/ticdc/cdc/sink/codec"
 	"github.com/pingcap/ticdc/pkg/config"
 	cerror "github.com/pingcap/ticdc/pkg/errors"
 	"github.com/pingcap/ticdc/pkg/filter"
+	"github.com/pingcap/ticdc/pkg/security"
+	"github.com/pingcap/ticdc/pkg/util"
 )
 
 // Sink options keys
 const (
 	OptChangefeedID = "_changefeed_id"
 	OptCaptureAddr  = "_capture_addr"
+	// OptSafeModeUntilTs is the commit ts until which the rows are written in
+	// safe mode, it's set when the changefeed is resumed from a ts earlier than
+	// the checkpoint, so that the replicated rows are written idempotently.
+	OptSafeModeUntilTs = "_safe_mode_until_ts"
 )
 
 // Sink is an abstraction for anything that a changefeed may emit into.
@@ -55,8 +62,59 @@ type Sink interface {
 	Close() error
 }
 
-// NewSink creates a new sink with the sink-uri
-func NewSink(ctx context.Context, changefeedID model.ChangeFeedID, sinkURIStr string, filter *filter.Filter, config *config.ReplicaConfig, opts map[string]string, errCh chan error) (Sink, error) {
+// RequireOldValue returns whether the sink of the URI or the event filters and
+// the row filters of the config require the old values of the rows. Old value
+// is enabled per changefeed, only the changefeeds whose sinks or filters
+// require it should pay the cost of reading the old values in TiKV.
+func RequireOldValue(sinkURI *url.URL, config *config.ReplicaConfig) bool {
+	if filter.RequireOldValue(config) {
+		return true
+	}
+	switch strings.ToLower(sinkURI.Scheme) {
+	case "kafka", "kafka+ssl", "pulsar", "pulsar+ssl":
+		// the protocol in the sink URI overrides the one in the config
+		protocolStr := config.Sink.Protocol
+		if s := sinkURI.Query().Get("protocol"); s != "" {
+			protocolStr = s
+		}
+		var protocol codec.Protocol
+		protocol.FromString(protocolStr)
+		return protocol.RequireOldValue()
+	default:
+		return false
+	}
+}
+
+// NewSink creates a new sink with the sink-uri, the credential of the changefeed
+// can be nil. The sink applies the time zone, the column transforms and the
+// throughput limits of the config if any.
+func NewSink(ctx context.Context, changefeedID model.ChangeFeedID, sinkURIStr string, filter *filter.Filter, config *config.ReplicaConfig, credential *security.SinkCredential, opts map[string]string, errCh chan error) (Sink, error) {
+	ctx, err := util.PutChangefeedTimezoneInCtx(ctx, config.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+	transformer, err := newColumnTransformer(config)
+	if err != nil {
+		return nil, err
+	}
+	throttle, err := newThrottleSink(config)
+	if err != nil {
+		return nil, err
+	}
+	s, err := newSink(ctx, changefeedID, sinkURIStr, filter, config, credential, opts, errCh)
+	if err != nil {
+		return nil, err
+	}
+	if transformer != nil {
+		s = &transformSink{Sink: s, transformer: transformer}
+	}
+	if throttle != nil {
+		s = throttle.wrap(ctx, s)
+	}
+	return s, nil
+}
+
+func newSink(ctx context.Context, changefeedID model.ChangeFeedID, sinkURIStr string, filter *filter.Filter, config *config.ReplicaConfig, credential *security.SinkCredential, opts map[string]string, errCh chan error) (Sink, error) {
 	// parse sinkURI as a URI
 	sinkURI, err := url.Parse(sinkURIStr)
 	if err != nil {
@@ -66,11 +124,11 @@ func NewSink(ctx context.Context, changefeedID model.ChangeFeedID, sinkURIStr st
 	case "blackhole":
 		return newBlackHoleSink(ctx, opts), nil
 	case "mysql", "tidb", "mysql+ssl", "tidb+ssl":
-		return newMySQLSink(ctx, changefeedID, sinkURI, filter, config, opts)
+		return newMySQLSink(ctx, changefeedID, sinkURI, filter, config, credential, opts)
 	case "kafka", "kafka+ssl":
-		return newKafkaSaramaSink(ctx, sinkURI, filter, config, opts, errCh)
+		return newKafkaSaramaSink(ctx, sinkURI, filter, config, credential, opts, errCh)
 	case "pulsar", "pulsar+ssl":
-		return newPulsarSink(ctx, sinkURI, filter, config, opts, errCh)
+		return newPulsarSink(ctx, sinkURI, filter, config, credential, opts, errCh)
 	case "local":
 		return cdclog.NewLocalFileSink(ctx, sinkURI, errCh)
 	case "s3":
@@ -79,3 +137,29 @@ func NewSink(ctx context.Context, changefeedID model.ChangeFeedID, sinkURIStr st
 		return nil, cerror.ErrSinkURIInvalid.GenWithStack("the sink scheme (%s) is not supported", sinkURI.Scheme)
 	}
 }
+
+// Validate sink if given valid parameters, the sink is created and closed at
+// once to check it can be connected.
+func Validate(ctx context.Context, sinkURI string, cfg *config.ReplicaConfig, credential *security.SinkCredential, opts map[string]string) error {
+	sinkFilter, err := filter.NewFilter(cfg)
+	if err != nil {
+		return err
+	}
+	errCh := make(chan error)
+	s, err := NewSink(ctx, "sink-verify", sinkURI, sinkFilter, cfg, credential, opts, errCh)
+	if err != nil {
+		return err
+	}
+	err = s.Close()
+	if err != nil {
+		return err
+	}
+	select {
+	case err = <-errCh:
+		if err != nil {
+			return err
+		}
+	default:
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"net/url"
+
+	"github.com/pingcap/check"
+	"github.com/pingcap/ticdc/pkg/config"
+)
+
+type sinkSuite struct{}
+
+var _ = check.Suite(&sinkSuite{})
+
+func (s sinkSuite) TestRequireOldValue(c *check.C) {
+	testCases := []struct {
+		uri      string
+		protocol string
+		expected bool
+	}{
+		{"mysql://root@127.0.0.1:3306/", "canal", false},
+		{"blackhole://", "", false},
+		{"kafka://127.0.0.1:9092/topic", "", false},
+		{"kafka://127.0.0.1:9092/topic", "canal", true},
+		{"kafka://127.0.0.1:9092/topic?protocol=canal", "", true},
+		{"kafka+ssl://127.0.0.1:9092/topic?protocol=canal-json", "", true},
+		{"kafka://127.0.0.1:9092/topic?protocol=avro", "canal", false},
+		{"pulsar://127.0.0.1:6650/topic?protocol=canal", "", true},
+		{"pulsar://127.0.0.1:6650/topic", "maxwell", false},
+	}
+	for _, tc := range testCases {
+		uri, err := url.Parse(tc.uri)
+		c.Assert(err, check.IsNil)
+		cfg := config.GetDefaultReplicaConfig()
+		cfg.Sink.Protocol = tc.protocol
+		c.Assert(RequireOldValue(uri, cfg), check.Equals, tc.expected, check.Commentf("%s %s", tc.uri, tc.protocol))
+	}
+	// the updates are the same as the inserts without old value
+	uri, err := url.Parse("mysql://root@127.0.0.1:3306/")
+	c.Assert(err, check.IsNil)
+	cfg := config.GetDefaultReplicaConfig()
+	cfg.Filter.EventFilters = []*config.EventFilterRule{
+		{Matcher: []string{"test.*"}, IgnoreEvent: []config.EventType{config.EventUpdate}},
+	}
+	c.Assert(RequireOldValue(uri, cfg), check.IsTrue)
+	// the deletes only have the handle columns without old value
+	cfg = config.GetDefaultReplicaConfig()
+	cfg.Filter.RowFilters = []*config.RowFilterRule{
+		{Matcher: []string{"test.*"}, Expr: "id > 10"},
+	}
+	c.Assert(RequireOldValue(uri, cfg), check.IsTrue)
+}
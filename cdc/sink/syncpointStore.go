@@ -20,6 +20,7 @@ import (
 
 	"github.com/pingcap/ticdc/cdc/model"
 	cerror "github.com/pingcap/ticdc/pkg/errors"
+	"github.com/pingcap/ticdc/pkg/security"
 )
 
 // SyncpointStore is an abstraction for anything that a changefeed may emit into.
@@ -35,7 +36,7 @@ type SyncpointStore interface {
 }
 
 // NewSyncpointStore creates a new Spyncpoint sink with the sink-uri
-func NewSyncpointStore(ctx context.Context, changefeedID model.ChangeFeedID, sinkURIStr string) (SyncpointStore, error) {
+func NewSyncpointStore(ctx context.Context, changefeedID model.ChangeFeedID, sinkURIStr string, credential *security.SinkCredential) (SyncpointStore, error) {
 	// parse sinkURI as a URI
 	sinkURI, err := url.Parse(sinkURIStr)
 	if err != nil {
@@ -43,7 +44,7 @@ func NewSyncpointStore(ctx context.Context, changefeedID model.ChangeFeedID, sin
 	}
 	switch strings.ToLower(sinkURI.Scheme) {
 	case "mysql", "tidb", "mysql+ssl", "tidb+ssl":
-		return newMySQLSyncpointStore(ctx, changefeedID, sinkURI)
+		return newMySQLSyncpointStore(ctx, changefeedID, sinkURI, credential)
 	default:
 		return nil, cerror.ErrSinkURIInvalid.GenWithStack("the sink scheme (%s) is not supported", sinkURI.Scheme)
 	}
@@ -0,0 +1,121 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"context"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/pkg/buckets"
+	"github.com/pingcap/ticdc/pkg/config"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+)
+
+const throttleRefillInterval = time.Second
+
+// throttleSink limits the rows and the bytes emitted to the underlying sink
+// per second. The limits are throughput buckets of a bucket group, which is
+// refilled until the sink is closed.
+type throttleSink struct {
+	Sink
+	group *buckets.BucketGroup
+	// rows and bytes are nil if the corresponding rate is unlimited
+	rows   *buckets.Bucket
+	bytes  *buckets.Bucket
+	cancel context.CancelFunc
+}
+
+// newThrottleSink creates a throttleSink by the throughput limits of the
+// config, it returns nil if the throughput is unlimited.
+func newThrottleSink(cfg *config.ReplicaConfig) (*throttleSink, error) {
+	if cfg.Sink == nil || cfg.Sink.Throughput == nil {
+		return nil, nil
+	}
+	limits := cfg.Sink.Throughput
+	if limits.RowsPerSecond < 0 || limits.BytesPerSecond < 0 {
+		return nil, cerror.ErrSinkThroughputInvalid.GenWithStackByArgs(limits.RowsPerSecond, limits.BytesPerSecond)
+	}
+	if limits.RowsPerSecond == 0 && limits.BytesPerSecond == 0 {
+		return nil, nil
+	}
+	t := &throttleSink{
+		group: buckets.NewBucketGroup(limits.RowsPerSecond+limits.BytesPerSecond, throttleRefillInterval),
+	}
+	var err error
+	if limits.RowsPerSecond > 0 {
+		t.rows, err = t.group.CreateBucket(0, limits.RowsPerSecond, 0)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	if limits.BytesPerSecond > 0 {
+		t.bytes, err = t.group.CreateBucket(0, limits.BytesPerSecond, 0)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return t, nil
+}
+
+// wrap sets the underlying sink and starts refilling the limits until the sink
+// is closed.
+func (s *throttleSink) wrap(ctx context.Context, sink Sink) Sink {
+	s.Sink = sink
+	ctx, s.cancel = context.WithCancel(ctx)
+	go func() {
+		_ = s.group.Run(ctx)
+	}()
+	return s
+}
+
+func (s *throttleSink) EmitRowChangedEvents(ctx context.Context, rows ...*model.RowChangedEvent) error {
+	var size int64
+	for _, row := range rows {
+		size += row.ApproximateSize
+	}
+	if err := acquireThroughput(ctx, s.rows, int64(len(rows))); err != nil {
+		return errors.Trace(err)
+	}
+	if err := acquireThroughput(ctx, s.bytes, size); err != nil {
+		return errors.Trace(err)
+	}
+	return s.Sink.EmitRowChangedEvents(ctx, rows...)
+}
+
+func (s *throttleSink) Close() error {
+	s.cancel()
+	return s.Sink.Close()
+}
+
+// acquireThroughput blocks until n tokens are acquired from b, an acquisition
+// larger than the quota waits for several refill rounds.
+func acquireThroughput(ctx context.Context, b *buckets.Bucket, n int64) error {
+	if b == nil {
+		return nil
+	}
+	limit := b.Quota()
+	for n > 0 {
+		chunk := n
+		if chunk > limit {
+			chunk = limit
+		}
+		if err := b.Acquire(ctx, chunk); err != nil {
+			return errors.Trace(err)
+		}
+		n -= chunk
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"context"
+	"time"
+
+	"github.com/pingcap/check"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/pkg/config"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+	"github.com/pingcap/ticdc/pkg/filter"
+)
+
+type throttleSuite struct{}
+
+var _ = check.Suite(&throttleSuite{})
+
+func (s throttleSuite) TestThrottleConfig(c *check.C) {
+	cfg := config.GetDefaultReplicaConfig()
+	t, err := newThrottleSink(cfg)
+	c.Assert(err, check.IsNil)
+	c.Assert(t, check.IsNil)
+
+	cfg.Sink.Throughput = &config.ThroughputConfig{}
+	t, err = newThrottleSink(cfg)
+	c.Assert(err, check.IsNil)
+	c.Assert(t, check.IsNil)
+
+	cfg.Sink.Throughput = &config.ThroughputConfig{RowsPerSecond: -1}
+	_, err = newThrottleSink(cfg)
+	c.Assert(cerror.ErrSinkThroughputInvalid.Equal(err), check.IsTrue)
+
+	cfg.Sink.Throughput = &config.ThroughputConfig{BytesPerSecond: 100}
+	sinkFilter, err := filter.NewFilter(cfg)
+	c.Assert(err, check.IsNil)
+	sink, err := NewSink(context.Background(), "test", "blackhole://", sinkFilter, cfg, nil, nil, make(chan error, 1))
+	c.Assert(err, check.IsNil)
+	t, ok := sink.(*throttleSink)
+	c.Assert(ok, check.IsTrue)
+	c.Assert(t.rows, check.IsNil)
+	c.Assert(t.bytes.Quota(), check.Equals, int64(100))
+	c.Assert(sink.Close(), check.IsNil)
+}
+
+func (s throttleSuite) TestThrottleSink(c *check.C) {
+	cfg := config.GetDefaultReplicaConfig()
+	cfg.Sink.Throughput = &config.ThroughputConfig{RowsPerSecond: 2, BytesPerSecond: 100}
+	t, err := newThrottleSink(cfg)
+	c.Assert(err, check.IsNil)
+	record := &recordSink{}
+	t.Sink = record
+
+	ctx := context.Background()
+	row := &model.RowChangedEvent{ApproximateSize: 10}
+	c.Assert(t.EmitRowChangedEvents(ctx, row, row), check.IsNil)
+	c.Assert(record.rows, check.HasLen, 2)
+
+	// the rows are blocked until the limits are refilled
+	timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	err = t.EmitRowChangedEvents(timeoutCtx, row)
+	cancel()
+	c.Assert(errors.Cause(err), check.Equals, context.DeadlineExceeded)
+	c.Assert(record.rows, check.HasLen, 2)
+
+	// a row larger than the limit waits for several refill rounds
+	done := make(chan error, 1)
+	go func() {
+		done <- t.EmitRowChangedEvents(ctx, &model.RowChangedEvent{ApproximateSize: 250})
+	}()
+	for i := 0; i < 3; i++ {
+		select {
+		case <-done:
+			c.Fatal("the row is emitted before the limits are refilled")
+		case <-time.After(20 * time.Millisecond):
+		}
+		t.group.Refill()
+	}
+	select {
+	case err := <-done:
+		c.Assert(err, check.IsNil)
+	case <-time.After(5 * time.Second):
+		c.Fatal("the row is not emitted after the limits are refilled")
+	}
+	c.Assert(record.rows, check.HasLen, 3)
+}
@@ -0,0 +1,225 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/pkg/config"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+	filter "github.com/pingcap/tidb-tools/pkg/table-filter"
+)
+
+// transformSink applies the column transformations to the rows before they're
+// emitted to the underlying sink, so that the sensitive values are masked in
+// all kinds of sinks.
+type transformSink struct {
+	Sink
+	transformer *columnTransformer
+}
+
+func (s *transformSink) EmitRowChangedEvents(ctx context.Context, rows ...*model.RowChangedEvent) error {
+	transformed := make([]*model.RowChangedEvent, 0, len(rows))
+	for _, row := range rows {
+		row, err := s.transformer.transform(row)
+		if err != nil {
+			return err
+		}
+		transformed = append(transformed, row)
+	}
+	return s.Sink.EmitRowChangedEvents(ctx, transformed...)
+}
+
+type columnTransformer struct {
+	rules []*columnTransformRule
+}
+
+type columnTransformRule struct {
+	filter.Filter
+	// columns are the lower case column names
+	columns map[string]struct{}
+	config.ColumnTransformRule
+}
+
+// newColumnTransformer creates a columnTransformer by the column transforms of
+// the sink config, it returns nil if there are no column transforms.
+func newColumnTransformer(cfg *config.ReplicaConfig) (*columnTransformer, error) {
+	if cfg.Sink == nil || len(cfg.Sink.ColumnTransforms) == 0 {
+		return nil, nil
+	}
+	rules := make([]*columnTransformRule, 0, len(cfg.Sink.ColumnTransforms))
+	for _, ruleConfig := range cfg.Sink.ColumnTransforms {
+		switch ruleConfig.Type {
+		case config.TransformHash, config.TransformRedact, config.TransformConstant:
+		case config.TransformTruncate:
+			if ruleConfig.Length <= 0 {
+				return nil, cerror.ErrColumnTransformInvalid.GenWithStack(
+					"the length of truncate must be positive, got %d", ruleConfig.Length)
+			}
+		default:
+			return nil, cerror.ErrColumnTransformInvalid.GenWithStack(
+				"invalid transform type %s, hash, redact, truncate or constant is expected", ruleConfig.Type)
+		}
+		if len(ruleConfig.Columns) == 0 {
+			return nil, cerror.ErrColumnTransformInvalid.GenWithStack(
+				"the columns are required for the column transform %v", ruleConfig.Matcher)
+		}
+		f, err := filter.Parse(ruleConfig.Matcher)
+		if err != nil {
+			return nil, cerror.WrapError(cerror.ErrColumnTransformInvalid, err)
+		}
+		if !cfg.CaseSensitive {
+			f = filter.CaseInsensitive(f)
+		}
+		columns := make(map[string]struct{}, len(ruleConfig.Columns))
+		for _, column := range ruleConfig.Columns {
+			columns[strings.ToLower(column)] = struct{}{}
+		}
+		rules = append(rules, &columnTransformRule{
+			Filter:              f,
+			columns:             columns,
+			ColumnTransformRule: *ruleConfig,
+		})
+	}
+	return &columnTransformer{rules: rules}, nil
+}
+
+// transform returns the row with the transformed columns, the row is copied if
+// any of the columns is transformed.
+func (t *columnTransformer) transform(row *model.RowChangedEvent) (*model.RowChangedEvent, error) {
+	if row.Table == nil {
+		return row, nil
+	}
+	var rules []*columnTransformRule
+	for _, rule := range t.rules {
+		if rule.MatchTable(row.Table.Schema, row.Table.Table) {
+			rules = append(rules, rule)
+		}
+	}
+	if len(rules) == 0 {
+		return row, nil
+	}
+	columns, err := transformColumns(rules, row.Columns)
+	if err != nil {
+		return nil, err
+	}
+	preColumns, err := transformColumns(rules, row.PreColumns)
+	if err != nil {
+		return nil, err
+	}
+	transformed := *row
+	transformed.Columns, transformed.PreColumns = columns, preColumns
+	return &transformed, nil
+}
+
+// transformColumns returns the transformed copy of the columns, the first
+// matched rule of a column takes effect.
+func transformColumns(rules []*columnTransformRule, cols []*model.Column) ([]*model.Column, error) {
+	if len(cols) == 0 {
+		return cols, nil
+	}
+	transformed := make([]*model.Column, len(cols))
+	for i, col := range cols {
+		transformed[i] = col
+		if col == nil || col.Value == nil {
+			continue
+		}
+		name := strings.ToLower(col.Name)
+		for _, rule := range rules {
+			if _, ok := rule.columns[name]; !ok {
+				continue
+			}
+			value, err := rule.transformValue(col.Value)
+			if err != nil {
+				return nil, cerror.ErrTransformColumn.GenWithStack(
+					"transform the column %s by %s failed: %s", col.Name, rule.Type, err)
+			}
+			newCol := *col
+			newCol.Value = value
+			transformed[i] = &newCol
+			break
+		}
+	}
+	return transformed, nil
+}
+
+// transformValue transforms the value of the column, the type of the value is
+// kept, so that it can be written to the same column in the downstream. The
+// numbers are hashed to the numbers, redacted to 0, and kept by truncate.
+func (r *columnTransformRule) transformValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return r.transformString(v), nil
+	case []byte:
+		return []byte(r.transformString(string(v))), nil
+	case int64:
+		switch r.Type {
+		case config.TransformHash:
+			return int64(hashUint64(strconv.FormatInt(v, 10)) >> 1), nil
+		case config.TransformRedact:
+			return int64(0), nil
+		case config.TransformConstant:
+			return strconv.ParseInt(r.Value, 10, 64)
+		}
+	case uint64:
+		switch r.Type {
+		case config.TransformHash:
+			return hashUint64(strconv.FormatUint(v, 10)), nil
+		case config.TransformRedact:
+			return uint64(0), nil
+		case config.TransformConstant:
+			return strconv.ParseUint(r.Value, 10, 64)
+		}
+	case float64:
+		switch r.Type {
+		case config.TransformHash:
+			return float64(hashUint64(strconv.FormatFloat(v, 'g', -1, 64)) >> 11), nil
+		case config.TransformRedact:
+			return float64(0), nil
+		case config.TransformConstant:
+			return strconv.ParseFloat(r.Value, 64)
+		}
+	}
+	return value, nil
+}
+
+func (r *columnTransformRule) transformString(s string) string {
+	switch r.Type {
+	case config.TransformHash:
+		sum := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(sum[:])
+	case config.TransformRedact:
+		return strings.Repeat("*", utf8.RuneCountInString(s))
+	case config.TransformTruncate:
+		if utf8.RuneCountInString(s) <= r.Length {
+			return s
+		}
+		return string([]rune(s)[:r.Length])
+	case config.TransformConstant:
+		return r.Value
+	}
+	return s
+}
+
+func hashUint64(s string) uint64 {
+	sum := sha256.Sum256([]byte(s))
+	return binary.BigEndian.Uint64(sum[:8])
+}
@@ -0,0 +1,121 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"context"
+
+	"github.com/pingcap/check"
+	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/pkg/config"
+)
+
+type transformSuite struct{}
+
+var _ = check.Suite(&transformSuite{})
+
+type recordSink struct {
+	Sink
+	rows []*model.RowChangedEvent
+}
+
+func (s *recordSink) EmitRowChangedEvents(ctx context.Context, rows ...*model.RowChangedEvent) error {
+	s.rows = append(s.rows, rows...)
+	return nil
+}
+
+func (s transformSuite) TestTransformSink(c *check.C) {
+	cfg := config.GetDefaultReplicaConfig()
+	cfg.Sink.ColumnTransforms = []*config.ColumnTransformRule{
+		{Matcher: []string{"test.user"}, Columns: []string{"Email"}, Type: config.TransformHash},
+		{Matcher: []string{"test.*"}, Columns: []string{"email", "phone", "age"}, Type: config.TransformRedact},
+		{Matcher: []string{"test.*"}, Columns: []string{"address"}, Type: config.TransformTruncate, Length: 3},
+		{Matcher: []string{"test.*"}, Columns: []string{"ssn", "score"}, Type: config.TransformConstant, Value: "0"},
+	}
+	transformer, err := newColumnTransformer(cfg)
+	c.Assert(err, check.IsNil)
+	record := &recordSink{}
+	ts := &transformSink{Sink: record, transformer: transformer}
+
+	columns := []*model.Column{
+		{Name: "id", Value: int64(1)},
+		{Name: "email", Value: []byte("alice@example.com")},
+		{Name: "phone", Value: "12345"},
+		{Name: "age", Value: int64(30)},
+		{Name: "address", Value: []byte("北京市海淀区")},
+		{Name: "ssn", Value: nil},
+		{Name: "score", Value: float64(99.5)},
+		nil,
+	}
+	rows := []*model.RowChangedEvent{
+		{Table: &model.TableName{Schema: "test", Table: "user"}, Columns: columns, PreColumns: columns},
+		{Table: &model.TableName{Schema: "test", Table: "order"}, Columns: columns},
+		{Table: &model.TableName{Schema: "other", Table: "user"}, Columns: columns},
+	}
+	err = ts.EmitRowChangedEvents(context.Background(), rows...)
+	c.Assert(err, check.IsNil)
+	c.Assert(record.rows, check.HasLen, 3)
+
+	// the first matched rule of a column takes effect
+	transformed := record.rows[0]
+	c.Assert(transformed.Columns[1].Value, check.DeepEquals,
+		[]byte("ff8d9819fc0e12bf0d24892e45987e249a28dce836a85cad60e28eaaa8c6d976"))
+	c.Assert(transformed.Columns[2].Value, check.Equals, "*****")
+	c.Assert(transformed.Columns[3].Value, check.Equals, int64(0))
+	c.Assert(transformed.Columns[4].Value, check.DeepEquals, []byte("北京市"))
+	c.Assert(transformed.Columns[5].Value, check.IsNil)
+	c.Assert(transformed.Columns[6].Value, check.Equals, float64(0))
+	c.Assert(transformed.Columns[7], check.IsNil)
+	c.Assert(transformed.PreColumns, check.DeepEquals, transformed.Columns)
+	c.Assert(record.rows[1].Columns[1].Value, check.DeepEquals, []byte("*****************"))
+	// the original rows are kept
+	c.Assert(columns[1].Value, check.DeepEquals, []byte("alice@example.com"))
+	c.Assert(record.rows[2], check.Equals, rows[2])
+
+	// the hashed numbers are the same for the same values
+	hashRule := &columnTransformRule{ColumnTransformRule: config.ColumnTransformRule{Type: config.TransformHash}}
+	v1, err := hashRule.transformValue(int64(42))
+	c.Assert(err, check.IsNil)
+	v2, err := hashRule.transformValue(int64(42))
+	c.Assert(err, check.IsNil)
+	c.Assert(v1, check.Equals, v2)
+	c.Assert(v1.(int64) >= 0, check.IsTrue)
+
+	cfg.Sink.ColumnTransforms[3].Value = "abc"
+	transformer, err = newColumnTransformer(cfg)
+	c.Assert(err, check.IsNil)
+	_, err = transformer.transform(rows[0])
+	c.Assert(err, check.ErrorMatches, ".*transform the column score by constant failed.*")
+}
+
+func (s transformSuite) TestNewColumnTransformer(c *check.C) {
+	cfg := config.GetDefaultReplicaConfig()
+	transformer, err := newColumnTransformer(cfg)
+	c.Assert(err, check.IsNil)
+	c.Assert(transformer, check.IsNil)
+
+	testCases := []struct {
+		rule *config.ColumnTransformRule
+		err  string
+	}{
+		{&config.ColumnTransformRule{Matcher: []string{"*.*"}, Columns: []string{"a"}, Type: "encrypt"}, ".*invalid transform type encrypt.*"},
+		{&config.ColumnTransformRule{Matcher: []string{"*.*"}, Columns: []string{"a"}, Type: config.TransformTruncate}, ".*the length of truncate must be positive.*"},
+		{&config.ColumnTransformRule{Matcher: []string{"*.*"}, Type: config.TransformHash}, ".*the columns are required.*"},
+	}
+	for _, tc := range testCases {
+		cfg.Sink.ColumnTransforms = []*config.ColumnTransformRule{tc.rule}
+		_, err := newColumnTransformer(cfg)
+		c.Assert(err, check.ErrorMatches, tc.err)
+	}
+}
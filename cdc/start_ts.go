@@ -0,0 +1,97 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdc
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/ticdc/cdc/kv"
+	"github.com/pingcap/ticdc/cdc/model"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+	"github.com/pingcap/tidb/store/tikv"
+)
+
+// verifyStartTs checks the start ts of a changefeed isn't earlier than the GC
+// safepoint of the upstream.
+func verifyStartTs(ctx context.Context, cli kv.CDCEtcdClient, startTs uint64) error {
+	resp, err := cli.Client.Get(ctx, tikv.GcSavedSafePoint)
+	if err != nil {
+		return cerror.WrapError(cerror.ErrPDEtcdAPIError, err)
+	}
+	if resp.Count == 0 {
+		return nil
+	}
+	safePoint, err := strconv.ParseUint(string(resp.Kvs[0].Value), 10, 64)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if startTs < safePoint {
+		return cerror.ErrStartTsBeforeGC.GenWithStackByArgs(startTs, safePoint)
+	}
+	return nil
+}
+
+// VerifyResumeTs checks a paused changefeed can be resumed from resumeTs, which
+// must not be earlier than the GC safepoint of its upstream. The warnings about
+// the events replicated again or skipped are returned, the events replicated
+// again are written idempotently by the MySQL sink if the safe mode is enabled.
+func VerifyResumeTs(
+	ctx context.Context, cli kv.CDCEtcdClient, upstreams UpstreamClients,
+	changefeedID model.ChangeFeedID, resumeTs uint64, safeMode bool,
+) ([]string, error) {
+	info, err := cli.GetChangeFeedInfo(ctx, changefeedID)
+	if err != nil {
+		return nil, err
+	}
+	status, _, err := cli.GetChangeFeedStatus(ctx, changefeedID)
+	if cerror.ErrChangeFeedNotExists.Equal(err) {
+		return nil, cerror.ErrInvalidResumeTs.GenWithStackByArgs(resumeTs, "the changefeed isn't initialized")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if status.AdminJobType != model.AdminStop {
+		return nil, cerror.ErrInvalidResumeTs.GenWithStackByArgs(resumeTs, "the changefeed isn't paused")
+	}
+	if info.TargetTs != 0 && resumeTs >= info.TargetTs {
+		return nil, cerror.ErrInvalidResumeTs.GenWithStackByArgs(
+			resumeTs, fmt.Sprintf("it's not earlier than the target ts %d", info.TargetTs))
+	}
+	_, gcCli, err := upstreams(ctx, info.Upstream)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyStartTs(ctx, gcCli, resumeTs); err != nil {
+		return nil, err
+	}
+
+	var warnings []string
+	switch {
+	case resumeTs < status.CheckpointTs:
+		warning := fmt.Sprintf("the events committed in (%d, %d] are replicated again", resumeTs, status.CheckpointTs)
+		if safeMode {
+			warning += ", they're written in safe mode by the MySQL sink, but the other sinks may receive duplicates"
+		} else {
+			warning += ", which may be duplicated or conflict in the downstream, enable the safe mode to write them idempotently"
+		}
+		warnings = append(warnings, warning)
+	case resumeTs > status.CheckpointTs:
+		warnings = append(warnings, fmt.Sprintf(
+			"the events committed in (%d, %d] are skipped, which are lost in the downstream", status.CheckpointTs, resumeTs))
+	}
+	return warnings, nil
+}
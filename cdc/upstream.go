@@ -0,0 +1,144 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdc
+
+import (
+	"context"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/ticdc/cdc/kv"
+	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/pkg/security"
+	pd "github.com/tikv/pd/client"
+	"go.etcd.io/etcd/clientv3"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+)
+
+// upstreamClient is the clients of an upstream cluster other than the one of
+// the captures, which are shared by the changefeeds replicating from it.
+type upstreamClient struct {
+	info       *model.UpstreamInfo
+	credential *security.Credential
+	pdClient   pd.Client
+	etcdClient kv.CDCEtcdClient
+	// last update gc safepoint time. zero time means has not updated or cleared
+	gcSafepointLastUpdate time.Time
+}
+
+func newUpstreamClient(ctx context.Context, info *model.UpstreamInfo) (*upstreamClient, error) {
+	if err := info.Validate(); err != nil {
+		return nil, err
+	}
+	credential := info.GetCredential()
+	tlsConfig, err := credential.ToTLSConfig()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	grpcTLSOption, err := credential.ToGRPCDialOption()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	etcdCli, err := clientv3.New(clientv3.Config{
+		Endpoints:   info.PDAddrs,
+		TLS:         tlsConfig,
+		Context:     ctx,
+		DialTimeout: 5 * time.Second,
+		DialOptions: []grpc.DialOption{
+			grpcTLSOption,
+			grpc.WithBlock(),
+			grpc.WithConnectParams(grpc.ConnectParams{
+				Backoff: backoff.Config{
+					BaseDelay:  time.Second,
+					Multiplier: 1.1,
+					Jitter:     0.1,
+					MaxDelay:   3 * time.Second,
+				},
+				MinConnectTimeout: 3 * time.Second,
+			}),
+		},
+	})
+	if err != nil {
+		return nil, errors.Annotatef(err, "new etcd client of the upstream %s", info.ID())
+	}
+	pdSecurity, pdDialOption := credential.PDClientOptions()
+	pdClient, err := pd.NewClientWithContext(ctx, info.PDAddrs, pdSecurity, pd.WithGRPCDialOptions(pdDialOption))
+	if err != nil {
+		etcdCli.Close() //nolint:errcheck
+		return nil, errors.Annotatef(err, "new pd client of the upstream %s", info.ID())
+	}
+	return &upstreamClient{
+		info:       info,
+		credential: credential,
+		pdClient:   pdClient,
+		etcdClient: kv.NewCDCEtcdClient(ctx, etcdCli),
+	}, nil
+}
+
+func (u *upstreamClient) close() {
+	u.pdClient.Close()
+	if err := u.etcdClient.Client.Unwrap().Close(); err != nil {
+		log.Warn("close the etcd client of the upstream failed", zap.String("upstream", u.info.ID()), zap.Error(err))
+	}
+}
+
+// getUpstream returns the clients of the upstream a changefeed replicates
+// from, the clients of the cluster of the captures are returned if info is
+// nil. The clients of the other upstreams are created once they're used, and
+// closed when the owner exits.
+func (o *Owner) getUpstream(ctx context.Context, info *model.UpstreamInfo) (
+	pdEndpoints []string, credential *security.Credential, pdClient pd.Client, etcdClient kv.CDCEtcdClient, err error,
+) {
+	if info == nil {
+		return o.pdEndpoints, o.credential, o.pdClient, o.etcdClient, nil
+	}
+	o.upstreamsMu.Lock()
+	defer o.upstreamsMu.Unlock()
+	id := info.ID()
+	upstream, ok := o.upstreams[id]
+	if !ok {
+		upstream, err = newUpstreamClient(ctx, info)
+		if err != nil {
+			return nil, nil, nil, kv.CDCEtcdClient{}, err
+		}
+		if o.upstreams == nil {
+			o.upstreams = make(map[string]*upstreamClient)
+		}
+		o.upstreams[id] = upstream
+		log.Info("connected to the upstream", zap.String("upstream", id))
+	}
+	return upstream.info.PDAddrs, upstream.credential, upstream.pdClient, upstream.etcdClient, nil
+}
+
+func (o *Owner) closeUpstreams() {
+	o.upstreamsMu.Lock()
+	defer o.upstreamsMu.Unlock()
+	for id, upstream := range o.upstreams {
+		upstream.close()
+		delete(o.upstreams, id)
+	}
+}
+
+// UpstreamClients returns the PD client and the etcd client of the upstream a
+// changefeed replicates from, which is the cluster of the captures if info is
+// nil. The GC safepoint of the upstream is read by the etcd client.
+type UpstreamClients func(ctx context.Context, info *model.UpstreamInfo) (pd.Client, kv.CDCEtcdClient, error)
+
+func (o *Owner) upstreamClients(ctx context.Context, info *model.UpstreamInfo) (pd.Client, kv.CDCEtcdClient, error) {
+	_, _, pdClient, etcdClient, err := o.getUpstream(ctx, info)
+	return pdClient, etcdClient, err
+}
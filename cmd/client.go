@@ -28,7 +28,9 @@ import (
 	"github.com/pingcap/ticdc/cdc"
 	"github.com/pingcap/ticdc/cdc/kv"
 	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/pkg/config"
 	"github.com/pingcap/ticdc/pkg/logutil"
+	"github.com/pingcap/ticdc/pkg/security"
 	"github.com/pingcap/ticdc/pkg/version"
 	"github.com/spf13/cobra"
 	pd "github.com/tikv/pd/client"
@@ -56,6 +58,7 @@ var (
 	noConfirm  bool
 	sortEngine string
 	sortDir    string
+	cfGCTTL    int64
 
 	cyclicReplicaID        uint64
 	cyclicFilterReplicaIDs []uint
@@ -78,6 +81,12 @@ var (
 	syncPointInterval time.Duration
 
 	optForceRemove bool
+	optResumeTs    uint64
+	optSafeMode    bool
+
+	exportFile   string
+	secretsFile  string
+	manifestFile string
 
 	defaultContext context.Context
 )
@@ -90,9 +99,11 @@ type changefeedCommonInfo struct {
 
 // capture holds capture information
 type capture struct {
-	ID            string `json:"id"`
-	IsOwner       bool   `json:"is-owner"`
-	AdvertiseAddr string `json:"address"`
+	ID              string                `json:"id"`
+	IsOwner         bool                  `json:"is-owner"`
+	AdvertiseAddr   string                `json:"address"`
+	OwnerPreference model.OwnerPreference `json:"owner-preference,omitempty"`
+	FeatureGates    config.FeatureGates   `json:"feature-gates,omitempty"`
 }
 
 // cfMeta holds changefeed info and changefeed status
@@ -128,66 +139,15 @@ func newCliCommand() *cobra.Command {
 			initCmd(cmd, &logutil.Config{Level: cliLogLevel})
 
 			credential := getCredential()
-			tlsConfig, err := credential.ToTLSConfig()
-			if err != nil {
-				return errors.Annotate(err, "fail to validate TLS settings")
-			}
-			if tlsConfig != nil {
-				if strings.Contains(cliPdAddr, "http://") {
-					return errors.New("PD endpoint scheme should be https")
-				}
-			} else if !strings.Contains(cliPdAddr, "http://") {
-				return errors.New("PD endpoint scheme should be http")
-			}
-			grpcTLSOption, err := credential.ToGRPCDialOption()
+			etcdCli, pdClient, err := newPDClients(defaultContext, cliPdAddr, credential)
 			if err != nil {
-				return errors.Annotate(err, "fail to validate TLS settings")
-			}
-
-			pdEndpoints := strings.Split(cliPdAddr, ",")
-			etcdCli, err := clientv3.New(clientv3.Config{
-				Context:     defaultContext,
-				Endpoints:   pdEndpoints,
-				TLS:         tlsConfig,
-				DialTimeout: 30 * time.Second,
-				DialOptions: []grpc.DialOption{
-					grpcTLSOption,
-					grpc.WithBlock(),
-					grpc.WithConnectParams(grpc.ConnectParams{
-						Backoff: backoff.Config{
-							BaseDelay:  time.Second,
-							Multiplier: 1.1,
-							Jitter:     0.1,
-							MaxDelay:   3 * time.Second,
-						},
-						MinConnectTimeout: 3 * time.Second,
-					}),
-				},
-			})
-			if err != nil {
-				// PD embeds an etcd server.
-				return errors.Annotatef(err, "fail to open PD etcd client, pd-addr=\"%s\"", cliPdAddr)
+				return err
 			}
 			cdcEtcdCli = kv.NewCDCEtcdClient(defaultContext, etcdCli)
-			pdCli, err = pd.NewClientWithContext(
-				defaultContext, pdEndpoints, credential.PDSecurityOption(),
-				pd.WithGRPCDialOptions(
-					grpcTLSOption,
-					grpc.WithBlock(),
-					grpc.WithConnectParams(grpc.ConnectParams{
-						Backoff: backoff.Config{
-							BaseDelay:  time.Second,
-							Multiplier: 1.1,
-							Jitter:     0.1,
-							MaxDelay:   3 * time.Second,
-						},
-						MinConnectTimeout: 3 * time.Second,
-					}),
-				))
-			if err != nil {
-				return errors.Annotatef(err, "fail to open PD client, pd-addr=\"%s\"", cliPdAddr)
-			}
+			pdCli = pdClient
+
 			ctx := defaultContext
+			pdEndpoints := strings.Split(cliPdAddr, ",")
 			errorTiKVIncompatible := true // Error if TiKV is incompatible.
 			err = version.CheckClusterVersion(ctx, pdCli, pdEndpoints[0], credential, errorTiKVIncompatible)
 			if err != nil {
@@ -208,11 +168,77 @@ func newCliCommand() *cobra.Command {
 		newProcessorCommand(),
 		newUnsafeCommand(),
 		newTsoCommand(),
+		newVerifyCommand(),
+		newAuditCommand(),
 	)
 
 	return command
 }
 
+// newPDClients opens the etcd client and the client of PD at pdAddr.
+func newPDClients(ctx context.Context, pdAddr string, credential *security.Credential) (*clientv3.Client, pd.Client, error) {
+	tlsConfig, err := credential.ToTLSConfig()
+	if err != nil {
+		return nil, nil, errors.Annotate(err, "fail to validate TLS settings")
+	}
+	if tlsConfig != nil {
+		if strings.Contains(pdAddr, "http://") {
+			return nil, nil, errors.New("PD endpoint scheme should be https")
+		}
+	} else if !strings.Contains(pdAddr, "http://") {
+		return nil, nil, errors.New("PD endpoint scheme should be http")
+	}
+	grpcTLSOption, err := credential.ToGRPCDialOption()
+	if err != nil {
+		return nil, nil, errors.Annotate(err, "fail to validate TLS settings")
+	}
+
+	pdEndpoints := strings.Split(pdAddr, ",")
+	etcdCli, err := clientv3.New(clientv3.Config{
+		Context:     ctx,
+		Endpoints:   pdEndpoints,
+		TLS:         tlsConfig,
+		DialTimeout: 30 * time.Second,
+		DialOptions: []grpc.DialOption{
+			grpcTLSOption,
+			grpc.WithBlock(),
+			grpc.WithConnectParams(grpc.ConnectParams{
+				Backoff: backoff.Config{
+					BaseDelay:  time.Second,
+					Multiplier: 1.1,
+					Jitter:     0.1,
+					MaxDelay:   3 * time.Second,
+				},
+				MinConnectTimeout: 3 * time.Second,
+			}),
+		},
+	})
+	if err != nil {
+		// PD embeds an etcd server.
+		return nil, nil, errors.Annotatef(err, "fail to open PD etcd client, pd-addr=\"%s\"", pdAddr)
+	}
+	pdCli, err := pd.NewClientWithContext(
+		ctx, pdEndpoints, credential.PDSecurityOption(),
+		pd.WithGRPCDialOptions(
+			grpcTLSOption,
+			grpc.WithBlock(),
+			grpc.WithConnectParams(grpc.ConnectParams{
+				Backoff: backoff.Config{
+					BaseDelay:  time.Second,
+					Multiplier: 1.1,
+					Jitter:     0.1,
+					MaxDelay:   3 * time.Second,
+				},
+				MinConnectTimeout: 3 * time.Second,
+			}),
+		))
+	if err != nil {
+		etcdCli.Close() //nolint:errcheck
+		return nil, nil, errors.Annotatef(err, "fail to open PD client, pd-addr=\"%s\"", pdAddr)
+	}
+	return etcdCli, pdCli, nil
+}
+
 func loop() {
 	l, err := readline.NewEx(&readline.Config{
 		Prompt:            "\033[31m»\033[0m ",
@@ -254,3 +280,36 @@ func loop() {
 		}
 	}
 }
+
+// newUpstreamClients returns the clients of the upstream of a changefeed. The
+// clients of the upstreams other than the cluster of --pd are opened on
+// demand, and closed by the returned func.
+func newUpstreamClients() (cdc.UpstreamClients, func()) {
+	type clients struct {
+		etcdCli *clientv3.Client
+		pdCli   pd.Client
+	}
+	opened := make(map[string]*clients)
+	getClients := func(ctx context.Context, info *model.UpstreamInfo) (pd.Client, kv.CDCEtcdClient, error) {
+		if info == nil {
+			return pdCli, cdcEtcdCli, nil
+		}
+		c, ok := opened[info.ID()]
+		if !ok {
+			etcdCli, pdClient, err := newPDClients(ctx, strings.Join(info.PDAddrs, ","), info.GetCredential())
+			if err != nil {
+				return nil, kv.CDCEtcdClient{}, errors.Annotate(err, "fail to connect to the upstream")
+			}
+			c = &clients{etcdCli: etcdCli, pdCli: pdClient}
+			opened[info.ID()] = c
+		}
+		return c.pdCli, kv.NewCDCEtcdClient(ctx, c.etcdCli), nil
+	}
+	closeClients := func() {
+		for _, c := range opened {
+			c.pdCli.Close()
+			c.etcdCli.Close() //nolint:errcheck
+		}
+	}
+	return getClients, closeClients
+}
@@ -0,0 +1,120 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"go.uber.org/zap"
+)
+
+var (
+	auditAction string
+	auditSince  string
+	auditLimit  int
+)
+
+func newAuditCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "audit",
+		Short: "Manage the audit log of the administrative actions",
+	}
+	command.AddCommand(newListAuditCommand())
+	return command
+}
+
+func newListAuditCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "list",
+		Short: "List the audit records of the administrative actions",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := defaultContext
+			since := time.Time{}
+			if auditSince != "" {
+				t, err := time.Parse(time.RFC3339, auditSince)
+				if err != nil {
+					return errors.Annotatef(err, "invalid since %s", auditSince)
+				}
+				since = t
+			}
+			records, err := cdcEtcdCli.GetAuditRecords(ctx, since)
+			if err != nil {
+				return err
+			}
+			return jsonPrint(cmd, model.FilterAuditRecords(records, changefeedID, model.AuditAction(auditAction), auditLimit))
+		},
+	}
+	command.PersistentFlags().StringVarP(&changefeedID, "changefeed-id", "c", "", "Replication task (changefeed) ID, the records of all the changefeeds are listed if it's empty")
+	command.PersistentFlags().StringVar(&auditAction, "action", "", "Action of the records, e.g. create-changefeed, all the actions are listed if it's empty")
+	command.PersistentFlags().StringVar(&auditSince, "since", "", "List the records since the time in RFC3339, e.g. 2020-12-01T00:00:00+08:00")
+	command.PersistentFlags().IntVar(&auditLimit, "limit", 100, "Max number of the latest records listed, 0 is unlimited")
+	return command
+}
+
+// recordCliAudit appends the action applied by the cli to etcd directly into
+// the audit log, the parameters are the flags set by the user.
+func recordCliAudit(ctx context.Context, cmd *cobra.Command, action model.AuditAction, changefeedID model.ChangeFeedID, err error) {
+	record := &model.AuditRecord{
+		Time:       time.Now(),
+		Action:     action,
+		Principal:  certCommonName(certPath),
+		Changefeed: changefeedID,
+		Params:     make(map[string]interface{}),
+	}
+	if u, err := user.Current(); err == nil {
+		record.User = u.Username
+	}
+	if host, err := os.Hostname(); err == nil {
+		record.Source = host
+	}
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		record.Params[flag.Name] = model.RedactAuditParam(flag.Name, flag.Value.String())
+	})
+	record.SetResult(err)
+	if err := cdcEtcdCli.PutAuditRecord(ctx, record); err != nil {
+		log.Warn("write audit record to etcd failed", zap.Reflect("record", record), zap.Error(err))
+	}
+}
+
+// certCommonName returns the common name of the certificate, which is empty
+// if the certificate can't be read.
+func certCommonName(path string) string {
+	if path == "" {
+		return ""
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return ""
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return ""
+	}
+	return cert.Subject.CommonName
+}
@@ -17,7 +17,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/url"
+	"os"
 	"strings"
 	"time"
 
@@ -26,6 +28,7 @@ import (
 	"github.com/pingcap/log"
 	"github.com/pingcap/ticdc/cdc"
 	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/cdc/sink"
 	"github.com/pingcap/ticdc/pkg/config"
 	"github.com/pingcap/ticdc/pkg/cyclic"
 	"github.com/pingcap/ticdc/pkg/cyclic/mark"
@@ -50,6 +53,9 @@ func newChangefeedCommand() *cobra.Command {
 		newUpdateChangefeedCommand(),
 		newStatisticsChangefeedCommand(),
 		newCreateChangefeedCyclicCommand(),
+		newExportChangefeedCommand(),
+		newImportChangefeedCommand(),
+		newBatchCreateChangefeedCommand(),
 	)
 	// Add pause, resume, remove changefeed
 	for _, cmd := range newAdminChangefeedCommand() {
@@ -81,6 +87,33 @@ func newAdminChangefeedCommand() []*cobra.Command {
 					CfID: changefeedID,
 					Type: model.AdminResume,
 				}
+				if optResumeTs != 0 {
+					upstreams, closeUpstreams := newUpstreamClients()
+					defer closeUpstreams()
+					warnings, err := cdc.VerifyResumeTs(ctx, cdcEtcdCli, upstreams, changefeedID, optResumeTs, optSafeMode)
+					if err != nil {
+						return err
+					}
+					for _, warning := range warnings {
+						cmd.Printf("Warning: %s\n", warning)
+					}
+					if len(warnings) != 0 && !noConfirm {
+						cmd.Printf("Could you agree to resume the changefeed from %d [Y/N]\n", optResumeTs)
+						var yOrN string
+						_, err = fmt.Scan(&yOrN)
+						if err != nil {
+							return err
+						}
+						if strings.ToLower(strings.TrimSpace(yOrN)) != "y" {
+							cmd.Printf("No resume of the changefeed.\n")
+							return nil
+						}
+					}
+					job.Opts = &model.AdminJobOption{
+						ResumeTs: optResumeTs,
+						SafeMode: optSafeMode,
+					}
+				}
 				return applyAdminChangefeed(ctx, job, getCredential())
 			},
 		},
@@ -107,6 +140,12 @@ func newAdminChangefeedCommand() []*cobra.Command {
 		if cmd.Use == "remove" {
 			cmd.PersistentFlags().BoolVarP(&optForceRemove, "force", "f", false, "remove all information of the changefeed")
 		}
+		if cmd.Use == "resume" {
+			cmd.PersistentFlags().Uint64Var(&optResumeTs, "resume-ts", 0, "Resume the changefeed from the specified ts instead of its checkpoint")
+			cmd.PersistentFlags().BoolVar(&optSafeMode, "safe-mode", false,
+				"Write the events replicated again in safe mode, if the resume ts is earlier than the checkpoint (only for the MySQL sink)")
+			cmd.PersistentFlags().BoolVar(&noConfirm, "no-confirm", false, "Don't ask user whether to confirm the warnings of the resume ts")
+		}
 	}
 	return cmds
 }
@@ -211,16 +250,32 @@ func newQueryChangefeedCommand() *cobra.Command {
 	return command
 }
 
-func verifyChangefeedParamers(ctx context.Context, cmd *cobra.Command, isCreate bool, credential *security.Credential) (*model.ChangeFeedInfo, error) {
+func verifyChangefeedParamers(ctx context.Context, cmd *cobra.Command, isCreate bool, credential *security.Credential, sinkCredential *security.SinkCredential) (*model.ChangeFeedInfo, error) {
+	// the upstream is set on creation only
+	var upstream *model.UpstreamInfo
+	pdAddr := cliPdAddr
 	if isCreate {
+		upstream = getUpstreamInfo()
+		if upstream != nil {
+			if err := upstream.Validate(); err != nil {
+				return nil, err
+			}
+			pdAddr, credential = strings.Join(upstream.PDAddrs, ","), upstream.GetCredential()
+		}
+		upstreams, closeUpstreams := newUpstreamClients()
+		defer closeUpstreams()
+		upstreamPDCli, gcCli, err := upstreams(ctx, upstream)
+		if err != nil {
+			return nil, err
+		}
 		if startTs == 0 {
-			ts, logical, err := pdCli.GetTS(ctx)
+			ts, logical, err := upstreamPDCli.GetTS(ctx)
 			if err != nil {
 				return nil, err
 			}
 			startTs = oracle.ComposeTS(ts, logical)
 		}
-		if err := verifyStartTs(ctx, startTs, cdcEtcdCli); err != nil {
+		if err := verifyStartTs(ctx, startTs, gcCli); err != nil {
 			return nil, err
 		}
 		if err := verifyTargetTs(ctx, startTs, targetTs); err != nil {
@@ -258,22 +313,12 @@ func verifyChangefeedParamers(ctx context.Context, cmd *cobra.Command, isCreate
 			return nil, cerror.WrapError(cerror.ErrSinkURIInvalid, err)
 		}
 
-		if strings.ToLower(sinkURIParsed.Scheme) == "kafka" && sinkURIParsed.Query().Get("protocol") == "canal" {
-			log.Warn("Attempting to use Canal without old value. CDC will enable old value and continue.")
+		if sink.RequireOldValue(sinkURIParsed, cfg) {
+			log.Warn("Attempting to use a protocol, an event filter or a row filter requiring old value without old value. CDC will enable old value and continue.")
 			cfg.EnableOldValue = true
 		}
 	}
 
-	for _, rules := range cfg.Sink.DispatchRules {
-		switch strings.ToLower(rules.Dispatcher) {
-		case "rowid", "index-value":
-			if cfg.EnableOldValue {
-				cmd.Printf("[WARN] This index-value distribution mode "+
-					"does not guarantee row-level orderliness when "+
-					"switching on the old value, so please use caution! dispatch-rules: %#v", rules)
-			}
-		}
-	}
 	info := &model.ChangeFeedInfo{
 		SinkURI:           sinkURI,
 		Opts:              make(map[string]string),
@@ -286,6 +331,18 @@ func verifyChangefeedParamers(ctx context.Context, cmd *cobra.Command, isCreate
 		State:             model.StateNormal,
 		SyncPointEnabled:  syncPointEnabled,
 		SyncPointInterval: syncPointInterval,
+		GCTTL:             cfGCTTL,
+		Upstream:          upstream,
+	}
+	if cfGCTTL < 0 {
+		return nil, errors.Errorf("invalid gc-ttl %d", cfGCTTL)
+	}
+	diags := info.Validate()
+	for _, d := range diags.Filter(config.DiagnosticWarning) {
+		cmd.Printf("[WARN] %s\n", d)
+	}
+	if err := diags.Err(); err != nil {
+		return nil, err
 	}
 
 	tz, err := util.GetTimezone(timezone)
@@ -295,7 +352,7 @@ func verifyChangefeedParamers(ctx context.Context, cmd *cobra.Command, isCreate
 
 	if isCreate {
 		ctx = util.PutTimezoneInCtx(ctx, tz)
-		ineligibleTables, eligibleTables, err := verifyTables(ctx, credential, cfg, startTs)
+		ineligibleTables, eligibleTables, err := verifyTables(ctx, pdAddr, credential, cfg, startTs)
 		if err != nil {
 			return nil, err
 		}
@@ -314,36 +371,65 @@ func verifyChangefeedParamers(ctx context.Context, cmd *cobra.Command, isCreate
 				}
 			}
 		}
+		warning, err := verifyTimezone(pdAddr, credential, cfg, startTs)
+		if err != nil {
+			return nil, err
+		}
+		if warning != "" {
+			cmd.Printf("[WARN] %s\n", warning)
+			if !noConfirm {
+				cmd.Printf("Could you agree to replicate in the time zone of the changefeed, and continue to replicate [Y/N]\n")
+				var yOrN string
+				_, err := fmt.Scan(&yOrN)
+				if err != nil {
+					return nil, err
+				}
+				if strings.ToLower(strings.TrimSpace(yOrN)) != "y" {
+					cmd.Printf("No changefeed is created because you don't want to replicate in the time zone of the changefeed.\n")
+					return nil, nil
+				}
+			}
+		}
 		if cfg.Cyclic.IsEnabled() && !cyclic.IsTablesPaired(eligibleTables) {
 			return nil, errors.New("normal tables and mark tables are not paired, " +
 				"please run `cdc cli changefeed cyclic create-marktables`")
 		}
 	}
 
-	for _, opt := range opts {
-		s := strings.SplitN(opt, "=", 2)
-		if len(s) <= 0 {
-			cmd.Printf("omit opt: %s", opt)
-			continue
-		}
-
-		var key string
-		var value string
-
-		key = s[0]
-		if len(s) > 1 {
-			value = s[1]
-		}
+	for key, value := range parseSinkOpts(opts) {
 		info.Opts[key] = value
 	}
 
-	err = verifySink(ctx, info.SinkURI, info.Config, info.Opts)
+	// the changefeed may run on any capture
+	_, captures, err := cdcEtcdCli.GetCaptures(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := info.CheckFeatures(captures...); err != nil {
+		return nil, err
+	}
+
+	err = sink.Validate(ctx, info.SinkURI, info.Config, sinkCredential, info.Opts)
 	if err != nil {
 		return nil, err
 	}
 	return info, nil
 }
 
+// parseSinkOpts parses the extra options of the sink in the `key=value` format.
+func parseSinkOpts(opts []string) map[string]string {
+	result := make(map[string]string, len(opts))
+	for _, opt := range opts {
+		s := strings.SplitN(opt, "=", 2)
+		var value string
+		if len(s) > 1 {
+			value = s[1]
+		}
+		result[s[0]] = value
+	}
+	return result
+}
+
 func changefeedConfigVariables(command *cobra.Command) {
 	command.PersistentFlags().Uint64Var(&startTs, "start-ts", 0, "Start ts of changefeed")
 	command.PersistentFlags().Uint64Var(&targetTs, "target-ts", 0, "Target ts of changefeed")
@@ -352,12 +438,19 @@ func changefeedConfigVariables(command *cobra.Command) {
 	command.PersistentFlags().StringSliceVar(&opts, "opts", nil, "Extra options, in the `key=value` format")
 	command.PersistentFlags().StringVar(&sortEngine, "sort-engine", "memory", "sort engine used for data sort")
 	command.PersistentFlags().StringVar(&sortDir, "sort-dir", ".", "directory used for file sort")
-	command.PersistentFlags().StringVar(&timezone, "tz", "SYSTEM", "timezone used when checking sink uri (changefeed timezone is determined by cdc server)")
+	command.PersistentFlags().Int64Var(&cfGCTTL, "gc-ttl", 0, "seconds the changefeed holds the GC safepoint after it's paused or failed, 0 is the gc-ttl of the server")
+	command.PersistentFlags().StringVar(&timezone, "tz", "SYSTEM", "timezone used when checking sink uri (changefeed timezone is the time-zone of the config, or the one of cdc server if it is not set)")
 	command.PersistentFlags().Uint64Var(&cyclicReplicaID, "cyclic-replica-id", 0, "(Expremental) Cyclic replication replica ID of changefeed")
 	command.PersistentFlags().UintSliceVar(&cyclicFilterReplicaIDs, "cyclic-filter-replica-ids", []uint{}, "(Expremental) Cyclic replication filter replica ID of changefeed")
 	command.PersistentFlags().BoolVar(&cyclicSyncDDL, "cyclic-sync-ddl", true, "(Expremental) Cyclic replication sync DDL of changefeed")
 	command.PersistentFlags().BoolVar(&syncPointEnabled, "sync-point", false, "(Expremental) Set and Record syncpoint in replication(default off)")
 	command.PersistentFlags().DurationVar(&syncPointInterval, "sync-interval", 10*time.Minute, "(Expremental) Set the interval for syncpoint in replication(default 10min)")
+	command.PersistentFlags().StringVar(&sinkCAPath, "sink-ca", "", "CA certificate path for the TLS connection to the sink, the content is stored encrypted in the changefeed credential")
+	command.PersistentFlags().StringVar(&sinkCertPath, "sink-cert", "", "Certificate path for the TLS connection to the sink, the content is stored encrypted in the changefeed credential")
+	command.PersistentFlags().StringVar(&sinkKeyPath, "sink-key", "", "Private key path for the TLS connection to the sink, the content is stored encrypted in the changefeed credential")
+	command.PersistentFlags().StringVar(&sinkSASLUser, "sink-sasl-user", "", "SASL user of the sink, which is stored encrypted in the changefeed credential")
+	command.PersistentFlags().StringVar(&sinkSASLPassword, "sink-sasl-password", "", "SASL password of the sink, which is stored encrypted in the changefeed credential")
+	command.PersistentFlags().StringVar(&credentialKeyPath, "credential-key-path", "", "Path of the key to encrypt the changefeed credential, which must be the same as the one of the captures")
 }
 
 func newCreateChangefeedCommand() *cobra.Command {
@@ -372,19 +465,28 @@ func newCreateChangefeedCommand() *cobra.Command {
 				id = uuid.New().String()
 			}
 
-			info, err := verifyChangefeedParamers(ctx, cmd, true /* isCreate */, getCredential())
+			sinkCredential, err := getSinkCredential()
+			if err != nil {
+				return err
+			}
+			info, err := verifyChangefeedParamers(ctx, cmd, true /* isCreate */, getCredential(), sinkCredential)
 			if err != nil {
 				return err
 			}
 			if info == nil {
 				return nil
 			}
+			err = info.SetCredential(sinkCredential)
+			if err != nil {
+				return err
+			}
 
 			infoStr, err := info.Marshal()
 			if err != nil {
 				return err
 			}
 			err = cdcEtcdCli.CreateChangefeedInfo(ctx, info, id)
+			recordCliAudit(ctx, cmd, model.AuditCreateChangefeed, id, err)
 			if err != nil {
 				return err
 			}
@@ -395,6 +497,10 @@ func newCreateChangefeedCommand() *cobra.Command {
 	changefeedConfigVariables(command)
 	command.PersistentFlags().BoolVar(&noConfirm, "no-confirm", false, "Don't ask user whether to ignore ineligible table")
 	command.PersistentFlags().StringVarP(&changefeedID, "changefeed-id", "c", "", "Replication task (changefeed) ID")
+	command.PersistentFlags().StringVar(&upstreamPD, "upstream-pd", "", "PD address of the upstream cluster, use ',' to separate multiple PDs, the changefeed replicates from the cluster of --pd if it's not specified")
+	command.PersistentFlags().StringVar(&upstreamCAPath, "upstream-ca", "", "CA certificate path for the TLS connection to the upstream cluster, which is read from the same path on all the captures")
+	command.PersistentFlags().StringVar(&upstreamCertPath, "upstream-cert", "", "Certificate path for the TLS connection to the upstream cluster, which is read from the same path on all the captures")
+	command.PersistentFlags().StringVar(&upstreamKeyPath, "upstream-key", "", "Private key path for the TLS connection to the upstream cluster, which is read from the same path on all the captures")
 
 	return command
 }
@@ -412,7 +518,19 @@ func newUpdateChangefeedCommand() *cobra.Command {
 				return err
 			}
 
-			info, err := verifyChangefeedParamers(ctx, cmd, false /* isCreate */, getCredential())
+			sinkCredential, err := getSinkCredential()
+			if err != nil {
+				return err
+			}
+			// the credential is left unchanged if it's not specified
+			newCredential := sinkCredential != nil
+			if !newCredential {
+				sinkCredential, err = old.GetCredential()
+				if err != nil {
+					return err
+				}
+			}
+			info, err := verifyChangefeedParamers(ctx, cmd, false /* isCreate */, getCredential(), sinkCredential)
 			if err != nil {
 				return err
 			}
@@ -422,6 +540,14 @@ func newUpdateChangefeedCommand() *cobra.Command {
 			info.StartTs = old.StartTs
 			info.ErrorHis = old.ErrorHis
 			info.Error = old.Error
+			info.Credential = old.Credential
+			info.Upstream = old.Upstream
+			if newCredential {
+				err = info.SetCredential(sinkCredential)
+				if err != nil {
+					return err
+				}
+			}
 
 			resp, err := applyOwnerChangefeedQuery(ctx, changefeedID, getCredential())
 			// if no cdc owner exists, allow user to update changefeed config
@@ -430,8 +556,15 @@ func newUpdateChangefeedCommand() *cobra.Command {
 			}
 			// Note that the correctness of the logic here depends on the return value of `/capture/owner/changefeed/query` interface.
 			// TODO: Using error codes instead of string containing judgments
+			// A running changefeed reloads the config by itself, as long as
+			// only the reloadable fields are updated.
 			if err == nil && !strings.Contains(resp, `"state": "stopped"`) {
-				return errors.Errorf("can only update changefeed config when it is stopped\nstatus: %s", resp)
+				if err := old.VerifyAndFix(); err != nil {
+					return err
+				}
+				if err := old.VerifyHotReload(info); err != nil {
+					return errors.Annotatef(err, "status: %s", resp)
+				}
 			}
 
 			changelog, err := diff.Diff(old, info)
@@ -460,7 +593,9 @@ func newUpdateChangefeedCommand() *cobra.Command {
 				}
 			}
 
+			info.ConfigVersion = old.ConfigVersion + 1
 			err = cdcEtcdCli.SaveChangeFeedInfo(ctx, info, changefeedID)
+			recordCliAudit(ctx, cmd, model.AuditUpdateChangefeed, changefeedID, err)
 			if err != nil {
 				return err
 			}
@@ -469,7 +604,7 @@ func newUpdateChangefeedCommand() *cobra.Command {
 				return err
 			}
 			cmd.Printf("Update changefeed config successfully! "+
-				"Will take effect only if the changefeed has been paused before this command"+
+				"Will take effect once the running changefeed reloads the config, or the paused changefeed is resumed"+
 				"\nID: %s\nInfo: %s\n", changefeedID, infoStr)
 			return nil
 		},
@@ -536,6 +671,162 @@ func newStatisticsChangefeedCommand() *cobra.Command {
 	return command
 }
 
+func newExportChangefeedCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "export",
+		Short: "Export the definitions of all the replication tasks (changefeeds), the secrets are referenced by name instead of embedded",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := defaultContext
+			export, err := cdc.ExportChangefeeds(ctx, cdcEtcdCli)
+			if err != nil {
+				return err
+			}
+			if exportFile == "" {
+				return jsonPrint(cmd, export)
+			}
+			data, err := json.MarshalIndent(export, "", "  ")
+			if err != nil {
+				return err
+			}
+			if err := ioutil.WriteFile(exportFile, data, 0600); err != nil {
+				return errors.Annotatef(err, "write %s", exportFile)
+			}
+			cmd.Printf("Export %d changefeeds to %s successfully!\n", len(export.Changefeeds), exportFile)
+			for _, def := range export.Changefeeds {
+				for _, secret := range def.Secrets {
+					cmd.Printf("The secret %s of the changefeed %s is required to import it\n", secret.Name, def.ID)
+				}
+			}
+			return nil
+		},
+	}
+	command.PersistentFlags().StringVarP(&exportFile, "file", "f", "", "Path of the file to export to, the definitions are printed if it's empty")
+	return command
+}
+
+func newImportChangefeedCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "import",
+		Short: "Import the exported replication tasks (changefeeds)",
+		Long: "Import the exported replication tasks (changefeeds). The referenced secrets are looked up in the secrets file, " +
+			"then the environment variables. The secret of a sink credential is the JSON of the credential, " +
+			"e.g. {\"ca\": \"...\", \"cert\": \"...\", \"key\": \"...\", \"sasl-user\": \"...\", \"sasl-password\": \"...\"}",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := defaultContext
+			data, err := ioutil.ReadFile(exportFile)
+			if err != nil {
+				return errors.Annotatef(err, "read %s", exportFile)
+			}
+			export := new(model.ChangefeedExport)
+			if err := json.Unmarshal(data, export); err != nil {
+				return cerror.WrapError(cerror.ErrInvalidChangefeedExport, err)
+			}
+			secrets := make(map[string]string)
+			if secretsFile != "" {
+				data, err := ioutil.ReadFile(secretsFile)
+				if err != nil {
+					return errors.Annotatef(err, "read %s", secretsFile)
+				}
+				if err := json.Unmarshal(data, &secrets); err != nil {
+					return errors.Annotatef(err, "the secrets file %s must be a JSON object of strings", secretsFile)
+				}
+			}
+			if credentialKeyPath != "" {
+				key, err := security.LoadCredentialKey(credentialKeyPath)
+				if err != nil {
+					return err
+				}
+				security.SetCredentialKey(key)
+			}
+			tz, err := util.GetTimezone(timezone)
+			if err != nil {
+				return errors.Annotate(err, "can not load timezone, Please specify the time zone through environment variable `TZ` or command line parameters `--tz`")
+			}
+			upstreams, closeUpstreams := newUpstreamClients()
+			defer closeUpstreams()
+			created, err := cdc.ImportChangefeeds(ctx, cdcEtcdCli, export, cdc.ImportOptions{
+				StartTs: startTs,
+				GetSecret: func(name string) (string, bool) {
+					if secret, ok := secrets[name]; ok {
+						return secret, true
+					}
+					return os.LookupEnv(name)
+				},
+				Timezone:  tz,
+				Upstreams: upstreams,
+			})
+			recordCliAudit(ctx, cmd, model.AuditImportChangefeeds, "", err)
+			if len(created) != 0 {
+				cmd.Printf("Import changefeeds successfully!\nID: %s\n", strings.Join(created, ", "))
+			}
+			return err
+		},
+	}
+	command.PersistentFlags().StringVarP(&exportFile, "file", "f", "", "Path of the exported file")
+	command.PersistentFlags().StringVar(&secretsFile, "secrets-file", "", "Path of the JSON file of the referenced secrets, e.g. {\"TICDC_CF_SINK_URI_PASSWORD\": \"...\"}")
+	command.PersistentFlags().Uint64Var(&startTs, "start-ts", 0, "Start ts of all the changefeeds, the exported checkpoints are used if it's 0")
+	command.PersistentFlags().StringVar(&timezone, "tz", "SYSTEM", "timezone used when checking sink uri (changefeed timezone is the time-zone of the config, or the one of cdc server if it is not set)")
+	command.PersistentFlags().StringVar(&credentialKeyPath, "credential-key-path", "", "Path of the key to encrypt the changefeed credentials, which must be the same as the one of the captures")
+	_ = command.MarkPersistentFlagRequired("file")
+	return command
+}
+
+func newBatchCreateChangefeedCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "create-batch",
+		Short: "Create the replication tasks (changefeeds) of a manifest",
+		Long: "Create the replication tasks (changefeeds) of a manifest, which is a JSON of the shared defaults and the changefeeds, " +
+			"e.g. {\"defaults\": {\"sink_uri\": \"...\"}, \"changefeeds\": [{\"changefeed_id\": \"...\", \"replica_config\": {...}}]}. " +
+			"The fields of the changefeeds are the same as the ones of the HTTP API. " +
+			"No changefeed is created if any of them is invalid.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := defaultContext
+			data, err := ioutil.ReadFile(manifestFile)
+			if err != nil {
+				return errors.Annotatef(err, "read %s", manifestFile)
+			}
+			manifest := new(cdc.ChangefeedManifest)
+			if err := json.Unmarshal(data, manifest); err != nil {
+				return errors.Annotatef(err, "invalid manifest %s", manifestFile)
+			}
+			if credentialKeyPath != "" {
+				key, err := security.LoadCredentialKey(credentialKeyPath)
+				if err != nil {
+					return err
+				}
+				security.SetCredentialKey(key)
+			}
+			tz, err := util.GetTimezone(timezone)
+			if err != nil {
+				return errors.Annotate(err, "can not load timezone, Please specify the time zone through environment variable `TZ` or command line parameters `--tz`")
+			}
+			upstreams, closeUpstreams := newUpstreamClients()
+			defer closeUpstreams()
+			results, err := cdc.CreateChangefeeds(ctx, cdcEtcdCli, upstreams, manifest, tz)
+			recordCliAudit(ctx, cmd, model.AuditBatchCreateChangefeeds, "", err)
+			if results != nil {
+				if jsonErr := jsonPrint(cmd, results); jsonErr != nil {
+					return jsonErr
+				}
+			}
+			if err != nil {
+				return err
+			}
+			for _, result := range results {
+				if !result.Created {
+					return errors.Errorf("failed to create the changefeed %s", result.ID)
+				}
+			}
+			return nil
+		},
+	}
+	command.PersistentFlags().StringVarP(&manifestFile, "file", "f", "", "Path of the manifest of the changefeeds")
+	command.PersistentFlags().StringVar(&timezone, "tz", "SYSTEM", "timezone used when checking sink uri (changefeed timezone is the time-zone of the config, or the one of cdc server if it is not set)")
+	command.PersistentFlags().StringVar(&credentialKeyPath, "credential-key-path", "", "Path of the key to encrypt the changefeed credentials, which must be the same as the one of the captures")
+	_ = command.MarkPersistentFlagRequired("file")
+	return command
+}
+
 func newCreateChangefeedCyclicCommand() *cobra.Command {
 	command := &cobra.Command{
 		Use:   "cyclic",
@@ -561,7 +852,7 @@ func newCreateChangefeedCyclicCommand() *cobra.Command {
 				}
 				startTs = oracle.ComposeTS(ts, logical)
 
-				_, eligibleTables, err := verifyTables(ctx, getCredential(), cfg, startTs)
+				_, eligibleTables, err := verifyTables(ctx, cliPdAddr, getCredential(), cfg, startTs)
 				if err != nil {
 					return err
 				}
@@ -598,3 +889,70 @@ func getUpstreamCredential() *security.Credential {
 		KeyPath:  upstreamSslKeyPath,
 	}
 }
+
+var (
+	sinkCAPath       string
+	sinkCertPath     string
+	sinkKeyPath      string
+	sinkSASLUser     string
+	sinkSASLPassword string
+
+	credentialKeyPath string
+
+	upstreamPD       string
+	upstreamCAPath   string
+	upstreamCertPath string
+	upstreamKeyPath  string
+)
+
+// getSinkCredential returns the changefeed credential of the flags, nil is
+// returned if none of them is specified.
+func getSinkCredential() (*security.SinkCredential, error) {
+	if credentialKeyPath != "" {
+		key, err := security.LoadCredentialKey(credentialKeyPath)
+		if err != nil {
+			return nil, err
+		}
+		security.SetCredentialKey(key)
+	}
+	credential := &security.SinkCredential{
+		SASLUser:     sinkSASLUser,
+		SASLPassword: sinkSASLPassword,
+	}
+	files := []struct {
+		path    string
+		content *string
+	}{
+		{sinkCAPath, &credential.CA},
+		{sinkCertPath, &credential.Cert},
+		{sinkKeyPath, &credential.Key},
+	}
+	for _, file := range files {
+		if file.path == "" {
+			continue
+		}
+		content, err := ioutil.ReadFile(file.path)
+		if err != nil {
+			return nil, errors.Annotatef(err, "read %s", file.path)
+		}
+		*file.content = string(content)
+	}
+	if *credential == (security.SinkCredential{}) {
+		return nil, nil
+	}
+	return credential, credential.Validate()
+}
+
+// getUpstreamInfo returns the upstream of the flags, nil is returned if the
+// changefeed replicates from the cluster of --pd.
+func getUpstreamInfo() *model.UpstreamInfo {
+	if upstreamPD == "" {
+		return nil
+	}
+	return &model.UpstreamInfo{
+		PDAddrs:  strings.Split(upstreamPD, ","),
+		CAPath:   upstreamCAPath,
+		CertPath: upstreamCertPath,
+		KeyPath:  upstreamKeyPath,
+	}
+}
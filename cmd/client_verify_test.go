@@ -0,0 +1,105 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/pingcap/check"
+	"github.com/spf13/cobra"
+)
+
+type verifySuite struct{}
+
+var _ = check.Suite(&verifySuite{})
+
+func (s *verifySuite) TestVerifyChangefeed(c *check.C) {
+	dir := c.MkDir()
+	defer func() {
+		sinkURI, configFile, sortEngine, sortDir, timezone = "", "", "", "", ""
+	}()
+	sinkURI = "blackhole://"
+	sortEngine = "file"
+	sortDir = dir
+	timezone = "UTC"
+
+	p := &preflight{}
+	p.verifyChangefeed(context.Background())
+	c.Assert(p.failed(), check.Equals, 0)
+	c.Assert(p.results, check.DeepEquals, []verifyResult{
+		{Name: "replica config", Status: verifyPass},
+		{Name: "filter rules", Status: verifyPass},
+		{Name: "sort-dir writability", Status: verifyPass},
+		{Name: "sink reachability", Status: verifyPass},
+	})
+
+	// the sink is skipped if the filter rules are invalid
+	configFile = filepath.Join(dir, "changefeed.toml")
+	err := ioutil.WriteFile(configFile, []byte("[filter]\nrules = ['a.b.c']\n"), 0644)
+	c.Assert(err, check.IsNil)
+	sortDir = filepath.Join(dir, "changefeed.toml")
+	p = &preflight{}
+	p.verifyChangefeed(context.Background())
+	c.Assert(p.failed(), check.Equals, 2)
+	c.Assert(p.results, check.HasLen, 4)
+	c.Assert(p.results[0].Status, check.Equals, verifyPass)
+	c.Assert(p.results[1].Status, check.Equals, verifyFail)
+	c.Assert(p.results[1].Message, check.Matches, ".*ErrFilterRuleInvalid.*")
+	c.Assert(p.results[2].Status, check.Equals, verifyFail)
+	c.Assert(p.results[3], check.DeepEquals, verifyResult{
+		Name: "sink reachability", Status: verifySkip, Message: "the replica config is invalid",
+	})
+
+	// the sort-dir is not checked for the memory sort engine
+	configFile = ""
+	sortEngine = "memory"
+	p = &preflight{}
+	p.verifyChangefeed(context.Background())
+	c.Assert(p.failed(), check.Equals, 0)
+	c.Assert(p.results[2].Status, check.Equals, verifySkip)
+
+	// the features used by the changefeed must be enabled by its gates
+	configFile = filepath.Join(dir, "feature.toml")
+	err = ioutil.WriteFile(configFile, []byte("[feature-gates]\nfile-sorter = false\n"), 0644)
+	c.Assert(err, check.IsNil)
+	p = &preflight{}
+	p.verifyChangefeed(context.Background())
+	c.Assert(p.failed(), check.Equals, 0)
+	sortEngine = "file"
+	sortDir = dir
+	p = &preflight{}
+	p.verifyChangefeed(context.Background())
+	c.Assert(p.results[0].Status, check.Equals, verifyFail)
+	c.Assert(p.results[0].Message, check.Matches, ".*feature file-sorter required by the changefeed is disabled by the changefeed.*")
+}
+
+func (s *verifySuite) TestPrintReport(c *check.C) {
+	p := &preflight{results: []verifyResult{
+		{Name: "PD connectivity", Status: verifyPass},
+		{Name: "TiKV connectivity", Status: verifyFail, Message: "timeout"},
+		{Name: "sort-dir writability", Status: verifySkip, Message: "not used"},
+	}}
+	var buf bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&buf)
+	p.print(cmd)
+	c.Assert(buf.String(), check.Equals, `[PASS] PD connectivity
+[FAIL] TiKV connectivity: timeout
+[SKIP] sort-dir writability: not used
+Verification failed, 1 of 3 checks failed
+`)
+}
@@ -35,11 +35,18 @@ func (s *decodeFileSuite) TestCanDecodeTOML(c *check.C) {
 	path := filepath.Join(dir, "config.toml")
 	content := `
 case-sensitive = false
+time-zone = "Asia/Shanghai"
 
 [filter]
 ignore-txn-start-ts = [1, 2]
 ddl-allow-list = [1, 2]
 rules = ['*.*', '!test.*']
+event-filters = [
+	{matcher = ['test1.*', 'test2.*'], ignore-event = ["update", "delete"]},
+]
+row-filters = [
+	{matcher = ['test1.*'], expr = "status != 'archived'"},
+]
 
 [mounter]
 worker-num = 64
@@ -50,6 +57,17 @@ dispatchers = [
 	{matcher = ['test3.*', 'test4.*'], dispatcher = "rowid"},
 ]
 protocol = "default"
+route-rules = [
+	{matcher = ['shard_*.orders_*'], target-schema = "merged", target-table = "orders"},
+]
+column-transforms = [
+	{matcher = ['test1.*'], columns = ['email'], type = "hash"},
+	{matcher = ['test1.*'], columns = ['address'], type = "truncate", length = 10},
+]
+
+[sink.throughput]
+rows-per-second = 1000
+bytes-per-second = 1048576
 
 [cyclic-replication]
 enable = true
@@ -61,6 +79,11 @@ sync-ddl = true
 [scheduler]
 type = "manual"
 polling-time = 5
+
+[retry]
+max-restarts = 10
+backoff = [0, 60]
+never-retry = ["CDC:ErrKafkaNewSaramaProducer"]
 `
 	err := ioutil.WriteFile(path, []byte(content), 0644)
 	c.Assert(err, check.IsNil)
@@ -70,10 +93,17 @@ polling-time = 5
 	c.Assert(err, check.IsNil)
 
 	c.Assert(cfg.CaseSensitive, check.IsFalse)
+	c.Assert(cfg.TimeZone, check.Equals, "Asia/Shanghai")
 	c.Assert(cfg.Filter, check.DeepEquals, &config.FilterConfig{
 		IgnoreTxnStartTs: []uint64{1, 2},
 		DDLAllowlist:     []model.ActionType{1, 2},
 		Rules:            []string{"*.*", "!test.*"},
+		EventFilters: []*config.EventFilterRule{
+			{Matcher: []string{"test1.*", "test2.*"}, IgnoreEvent: []config.EventType{config.EventUpdate, config.EventDelete}},
+		},
+		RowFilters: []*config.RowFilterRule{
+			{Matcher: []string{"test1.*"}, Expr: "status != 'archived'"},
+		},
 	})
 	c.Assert(cfg.Mounter, check.DeepEquals, &config.MounterConfig{
 		WorkerNum: 64,
@@ -84,6 +114,14 @@ polling-time = 5
 			{Dispatcher: "rowid", Matcher: []string{"test3.*", "test4.*"}},
 		},
 		Protocol: "default",
+		RouteRules: []*config.RouteRule{
+			{Matcher: []string{"shard_*.orders_*"}, TargetSchema: "merged", TargetTable: "orders"},
+		},
+		ColumnTransforms: []*config.ColumnTransformRule{
+			{Matcher: []string{"test1.*"}, Columns: []string{"email"}, Type: config.TransformHash},
+			{Matcher: []string{"test1.*"}, Columns: []string{"address"}, Type: config.TransformTruncate, Length: 10},
+		},
+		Throughput: &config.ThroughputConfig{RowsPerSecond: 1000, BytesPerSecond: 1048576},
 	})
 	c.Assert(cfg.Cyclic, check.DeepEquals, &config.CyclicConfig{
 		Enable:          true,
@@ -96,6 +134,12 @@ polling-time = 5
 		Tp:          "manual",
 		PollingTime: 5,
 	})
+	c.Assert(cfg.Retry, check.DeepEquals, &config.RetryConfig{
+		MaxRestarts:   10,
+		HistoryWindow: 600,
+		Backoff:       []int{0, 60},
+		NeverRetry:    []string{"CDC:ErrKafkaNewSaramaProducer"},
+	})
 }
 
 func (s *decodeFileSuite) TestAndWriteExampleTOML(c *check.C) {
@@ -107,6 +151,11 @@ func (s *decodeFileSuite) TestAndWriteExampleTOML(c *check.C) {
 # This configuration will affect both filter and sink related configurations, the default is true
 case-sensitive = true
 
+# 同步的 TIMESTAMP 类型的列转换到的时区，如 "Asia/Shanghai"，默认为空，即使用 TiCDC 节点的时区
+# The time zone that the TIMESTAMP columns are converted to, e.g. "Asia/Shanghai"
+# The time zone of the TiCDC server is used by default
+time-zone = ""
+
 [filter]
 # 忽略哪些 StartTs 的事务
 # Transactions with the following StartTs will be ignored
@@ -118,6 +167,22 @@ ignore-txn-start-ts = [1, 2]
 # Filter rules syntax: https://docs.pingcap.com/tidb/stable/table-filter#syntax
 rules = ['*.*', '!test.*']
 
+# 事件过滤器规则，忽略匹配的表的指定类型的 DML 事件
+# 事件类型支持 insert, update, delete 三种，忽略 insert 或 update 需要开启 old value
+# The rules of the event filters, the DML events of the types are ignored for the matched tables
+# Event types support insert, update and delete, ignoring insert or update requires old value
+event-filters = [
+	{matcher = ['test1.*'], ignore-event = ["delete"]},
+]
+
+# 行过滤器规则，只同步匹配的表中满足 SQL 表达式的行，需要开启 old value
+# 表达式支持列和常量的比较、AND、OR、XOR、NOT、IN、BETWEEN、LIKE 和 IS NULL
+# The rules of the row filters, only the rows satisfying the SQL expression are replicated for the matched tables, which requires old value
+# The expression supports the comparisons, AND, OR, XOR, NOT, IN, BETWEEN, LIKE and IS NULL of the columns and the constants
+row-filters = [
+	{matcher = ['test2.*'], expr = "status != 'archived'"},
+]
+
 [mounter]
 # mounter 线程数
 # the thread number of the the mounter
@@ -137,6 +202,26 @@ dispatchers = [
 # For MQ Sinks, you can configure the protocol of the messages sending to MQ
 # Currently the protocol support default and canal
 protocol = "default"
+# 路由规则，将匹配的表同步到下游指定的库和表，未指定的库名或表名保持不变
+# 多个上游分表可以路由到同一个下游表进行合并，MQ 类的 Sink 按路由后的库名和表名分发 event
+# The route rules, the matched tables are replicated to the target schema and table in the downstream, the schema or table name is kept if it's not specified
+# Multiple upstream shards can be routed to the same downstream table to be merged, MQ Sinks dispatch the events by the routed schema and table names
+route-rules = [
+	{matcher = ['shard_*.orders_*'], target-schema = "merged", target-table = "orders"},
+]
+# 列转换规则，在写入 Sink 前转换匹配的表的指定列的值，NULL 值保持不变
+# 转换类型支持 hash, redact, truncate, constant 四种，truncate 需要指定 length，constant 需要指定 value
+# The column transforms, the values of the columns are transformed for the matched tables before they're written to the sink, NULL values are kept
+# Transform types support hash, redact, truncate and constant, truncate requires length and constant requires value
+column-transforms = [
+	{matcher = ['test1.*'], columns = ['email', 'phone'], type = "redact"},
+]
+
+# Sink 的吞吐上限，限制每个 capture 中该 changefeed 写入 Sink 的行数和字节数，0 表示不限制
+# The max throughput of the sink of the changefeed in each capture, so that a changefeed catching up doesn't saturate the shared downstream, 0 is unlimited
+[sink.throughput]
+rows-per-second = 0
+bytes-per-second = 0
 
 [cyclic-replication]
 # 是否开启环形复制
@@ -151,6 +236,21 @@ filter-replica-ids = [2,3]
 # 是否同步 DDL
 # Whether to replicate DDL
 sync-ddl = true
+
+[retry]
+# 错误历史中的自动重启次数上限，超过后 changefeed 被标记为 failed，0 表示不限制
+# The max number of the automatic restarts in the error history, the changefeed is failed once it's exceeded, 0 is unlimited
+max-restarts = 0
+# 错误历史保留的秒数
+# The seconds the errors are kept in the error history
+history-window = 600
+# 错误历史中第 n 个错误后重启前等待的秒数，超出列表的错误使用最后一项
+# The seconds waited before the restart after the n-th error in the error history, the last one is used for the subsequent errors
+backoff = [1, 5, 10, 30, 60, 120]
+# 总是重试或从不重试的错误码，如 "CDC:ErrMySQLTxnError"，其他错误按错误码的默认分类处理
+# The error codes which are always or never retried, e.g. "CDC:ErrMySQLTxnError", the other errors are classified by default
+always-retry = []
+never-retry = []
 `
 	err := ioutil.WriteFile("changefeed.toml", []byte(content), 0644)
 	c.Assert(err, check.IsNil)
@@ -163,6 +263,12 @@ sync-ddl = true
 	c.Assert(cfg.Filter, check.DeepEquals, &config.FilterConfig{
 		IgnoreTxnStartTs: []uint64{1, 2},
 		Rules:            []string{"*.*", "!test.*"},
+		EventFilters: []*config.EventFilterRule{
+			{Matcher: []string{"test1.*"}, IgnoreEvent: []config.EventType{config.EventDelete}},
+		},
+		RowFilters: []*config.RowFilterRule{
+			{Matcher: []string{"test2.*"}, Expr: "status != 'archived'"},
+		},
 	})
 	c.Assert(cfg.Mounter, check.DeepEquals, &config.MounterConfig{
 		WorkerNum: 16,
@@ -173,6 +279,13 @@ sync-ddl = true
 			{Dispatcher: "rowid", Matcher: []string{"test3.*", "test4.*"}},
 		},
 		Protocol: "default",
+		RouteRules: []*config.RouteRule{
+			{Matcher: []string{"shard_*.orders_*"}, TargetSchema: "merged", TargetTable: "orders"},
+		},
+		ColumnTransforms: []*config.ColumnTransformRule{
+			{Matcher: []string{"test1.*"}, Columns: []string{"email", "phone"}, Type: config.TransformRedact},
+		},
+		Throughput: &config.ThroughputConfig{},
 	})
 	c.Assert(cfg.Cyclic, check.DeepEquals, &config.CyclicConfig{
 		Enable:          false,
@@ -180,6 +293,12 @@ sync-ddl = true
 		FilterReplicaID: []uint64{2, 3},
 		SyncDDL:         true,
 	})
+	c.Assert(cfg.Retry, check.DeepEquals, &config.RetryConfig{
+		HistoryWindow: 600,
+		Backoff:       []int{1, 5, 10, 30, 60, 120},
+		AlwaysRetry:   []string{},
+		NeverRetry:    []string{},
+	})
 }
 
 func (s *decodeFileSuite) TestShouldReturnErrForUnknownCfgs(c *check.C) {
@@ -20,7 +20,10 @@ import (
 	"github.com/pingcap/errors"
 	"github.com/pingcap/log"
 	"github.com/pingcap/ticdc/cdc"
+	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/pkg/config"
 	"github.com/pingcap/ticdc/pkg/logutil"
+	"github.com/pingcap/ticdc/pkg/tracing"
 	"github.com/pingcap/ticdc/pkg/util"
 	"github.com/pingcap/ticdc/pkg/version"
 	"github.com/spf13/cobra"
@@ -35,9 +38,23 @@ var (
 	gcTTL         int64
 	logFile       string
 	logLevel      string
+	logRedact     string
 
-	ownerFlushInterval     time.Duration
-	processorFlushInterval time.Duration
+	ownerFlushInterval      time.Duration
+	processorFlushInterval  time.Duration
+	maxMemoryConsumption    int64
+	scanRateLimitRegions    int64
+	scanRateLimitMB         int64
+	matcherCacheEntries     int
+	matcherCacheAge         time.Duration
+	tracingSampleRate       float64
+	tracingEndpoint         string
+	gracefulShutdownTimeout time.Duration
+	diagnosticsDir          string
+	ownerPreference         string
+	auditLogFile            string
+	auditLogRetention       time.Duration
+	featureGates            string
 
 	serverCmd = &cobra.Command{
 		Use:   "server",
@@ -56,15 +73,31 @@ func init() {
 	serverCmd.Flags().Int64Var(&gcTTL, "gc-ttl", cdc.DefaultCDCGCSafePointTTL, "CDC GC safepoint TTL duration, specified in seconds")
 	serverCmd.Flags().StringVar(&logFile, "log-file", "", "log file path")
 	serverCmd.Flags().StringVar(&logLevel, "log-level", "info", "log level (etc: debug|info|warn|error)")
+	serverCmd.Flags().StringVar(&logRedact, "log-redact", "off", "redact mode of the user data, e.g. the keys, the values and the queries, in the logs (off|hash|elide)")
 	serverCmd.Flags().DurationVar(&ownerFlushInterval, "owner-flush-interval", time.Millisecond*200, "owner flushes changefeed status interval")
 	serverCmd.Flags().DurationVar(&processorFlushInterval, "processor-flush-interval", time.Millisecond*100, "processor flushes task status interval")
+	serverCmd.Flags().Int64Var(&maxMemoryConsumption, "max-memory-consumption", 0, "max memory consumption of the capture in bytes, if it is 0, the cgroup memory limit or 8GB is used")
+	serverCmd.Flags().Int64Var(&scanRateLimitRegions, "scan-rate-limit-regions", 0, "max number of regions incrementally scanned per second by the capture, 0 is unlimited")
+	serverCmd.Flags().Int64Var(&scanRateLimitMB, "scan-rate-limit-mb", 0, "max MB of incrementally scanned data received per second by the capture, 0 is unlimited")
+	serverCmd.Flags().IntVar(&matcherCacheEntries, "matcher-cache-entries", 0, "max number of prewrites cached by a region waiting for commits, the values of the evicted ones are fetched from TiKV, 0 is unlimited")
+	serverCmd.Flags().DurationVar(&matcherCacheAge, "matcher-cache-age", 10*time.Minute, "max duration a prewrite is cached waiting for its commit, the values of the evicted ones are fetched from TiKV, 0 is unlimited")
+	serverCmd.Flags().StringVar(&credentialKeyPath, "credential-key-path", "", "Path of the hex-encoded 256-bit key to encrypt the changefeed credentials, which must be the same in all the captures")
+	serverCmd.Flags().Float64Var(&tracingSampleRate, "tracing-sample-rate", 0, "ratio of the row changes traced across the replication pipeline, 0 disables tracing")
+	serverCmd.Flags().StringVar(&tracingEndpoint, "tracing-endpoint", "", "address the tracing spans are exported to, the default is the local jaeger agent at localhost:6831")
+	serverCmd.Flags().DurationVar(&gracefulShutdownTimeout, "graceful-shutdown-timeout", time.Minute, "max duration of moving the tables to the other captures on SIGTERM before exiting, 0 exits immediately")
+	serverCmd.Flags().StringVar(&diagnosticsDir, "diagnostics-dir", "", "directory the heap profiles and the memory breakdowns are captured into when the memory usage stays close to max-memory-consumption, empty disables it")
+	serverCmd.Flags().StringVar(&ownerPreference, "owner-preference", string(model.OwnerNormal), "preference of the capture to be elected as the owner (preferred|normal|never), a normal capture is elected only if there is no preferred capture alive")
+	serverCmd.Flags().StringVar(&auditLogFile, "audit-log-file", "", "file the audit records of the administrative actions handled by the capture are appended to besides etcd, empty disables it")
+	serverCmd.Flags().DurationVar(&auditLogRetention, "audit-log-retention", 7*24*time.Hour, "duration the audit records are kept in etcd, 0 keeps them forever")
+	serverCmd.Flags().StringVar(&featureGates, "feature-gates", "", "states of the experimental features on the capture in the `feature=bool,...` format, e.g. avro-protocol=false, the features not set are in their default states")
 	addSecurityFlags(serverCmd.Flags(), true /* isServer */)
 }
 
 func runEServer(cmd *cobra.Command, args []string) error {
 	cancel := initCmd(cmd, &logutil.Config{
-		File:  logFile,
-		Level: logLevel,
+		File:   logFile,
+		Level:  logLevel,
+		Redact: logRedact,
 	})
 	defer cancel()
 	tz, err := util.GetTimezone(timezone)
@@ -72,6 +105,11 @@ func runEServer(cmd *cobra.Command, args []string) error {
 		return errors.Annotate(err, "can not load timezone, Please specify the time zone through environment variable `TZ` or command line parameters `--tz`")
 	}
 
+	gates, err := config.ParseFeatureGates(featureGates)
+	if err != nil {
+		return errors.Annotate(err, "invalid feature gates")
+	}
+
 	version.LogVersionInfo()
 	opts := []cdc.ServerOption{
 		cdc.PDEndpoints(serverPdAddr),
@@ -82,11 +120,26 @@ func runEServer(cmd *cobra.Command, args []string) error {
 		cdc.Credential(getCredential()),
 		cdc.OwnerFlushInterval(ownerFlushInterval),
 		cdc.ProcessorFlushInterval(processorFlushInterval),
+		cdc.MaxMemoryConsumption(maxMemoryConsumption),
+		cdc.ScanRateLimit(scanRateLimitRegions, scanRateLimitMB*1024*1024),
+		cdc.MatcherCacheLimit(matcherCacheEntries, matcherCacheAge),
+		cdc.CredentialKeyPath(credentialKeyPath),
+		cdc.Tracing(tracing.Config{SampleRate: tracingSampleRate, Endpoint: tracingEndpoint}),
+		cdc.GracefulShutdownTimeout(gracefulShutdownTimeout),
+		cdc.DiagnosticsDir(diagnosticsDir),
+		cdc.OwnerPreference(model.OwnerPreference(ownerPreference)),
+		cdc.AuditLog(auditLogFile, auditLogRetention),
+		cdc.FeatureGates(gates),
 	}
 	server, err := cdc.NewServer(opts...)
 	if err != nil {
 		return errors.Annotate(err, "new server")
 	}
+	gracefulExit.Store(func() {
+		if err := server.Drain(context.Background()); err != nil {
+			log.Warn("exit the server ungracefully", zap.Error(err))
+		}
+	})
 	err = server.Run(defaultContext)
 	if err != nil && errors.Cause(err) != context.Canceled {
 		log.Error("run server", zap.String("error", errors.ErrorStack(err)))
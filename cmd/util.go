@@ -24,7 +24,9 @@ import (
 	"os/signal"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"syscall"
+	"time"
 
 	"github.com/BurntSushi/toml"
 	"github.com/pingcap/errors"
@@ -33,12 +35,12 @@ import (
 	"github.com/pingcap/ticdc/cdc/entry"
 	"github.com/pingcap/ticdc/cdc/kv"
 	"github.com/pingcap/ticdc/cdc/model"
-	"github.com/pingcap/ticdc/cdc/sink"
 	"github.com/pingcap/ticdc/pkg/config"
 	"github.com/pingcap/ticdc/pkg/filter"
 	"github.com/pingcap/ticdc/pkg/httputil"
 	"github.com/pingcap/ticdc/pkg/logutil"
 	"github.com/pingcap/ticdc/pkg/security"
+	"github.com/pingcap/ticdc/pkg/util"
 	"github.com/pingcap/tidb/store/tikv"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -57,6 +59,11 @@ var (
 	errOwnerNotFound = liberrors.New("owner not found")
 )
 
+// gracefulExit holds a func() called on SIGTERM before the default context is
+// canceled, e.g. to move the tables out of the capture. Another signal during
+// it cancels the default context immediately.
+var gracefulExit atomic.Value
+
 func addSecurityFlags(flags *pflag.FlagSet, isServer bool) {
 	flags.StringVar(&caPath, "ca", "", "CA certificate path for TLS connection")
 	flags.StringVar(&certPath, "cert", "", "Certificate path for TLS connection")
@@ -99,9 +106,27 @@ func initCmd(cmd *cobra.Command, logCfg *logutil.Config) context.CancelFunc {
 
 	ctx, cancel := context.WithCancel(context.Background())
 	go func() {
-		sig := <-sc
-		log.Info("got signal to exit", zap.Stringer("signal", sig))
-		cancel()
+		exiting := false
+		for sig := range sc {
+			// SIGHUP reloads the certificates rotated
+			if sig == syscall.SIGHUP {
+				log.Info("got signal to reload certificates", zap.Stringer("signal", sig))
+				security.ReloadCertificates()
+				continue
+			}
+			if exit, ok := gracefulExit.Load().(func()); ok && sig == syscall.SIGTERM && !exiting {
+				log.Info("got signal to exit gracefully", zap.Stringer("signal", sig))
+				exiting = true
+				go func() {
+					exit()
+					cancel()
+				}()
+				continue
+			}
+			log.Info("got signal to exit", zap.Stringer("signal", sig))
+			cancel()
+			return
+		}
 	}()
 	defaultContext = ctx
 	return cancel
@@ -120,7 +145,7 @@ func getAllCaptures(ctx context.Context) ([]*capture, error) {
 	for _, c := range raw {
 		isOwner := c.ID == ownerID
 		captures = append(captures,
-			&capture{ID: c.ID, IsOwner: isOwner, AdvertiseAddr: c.AdvertiseAddr})
+			&capture{ID: c.ID, IsOwner: isOwner, AdvertiseAddr: c.AdvertiseAddr, OwnerPreference: c.OwnerPreference, FeatureGates: c.FeatureGates})
 	}
 	return captures, nil
 }
@@ -156,11 +181,16 @@ func applyAdminChangefeed(ctx context.Context, job model.AdminJob, credential *s
 	if job.Opts != nil && job.Opts.ForceRemove {
 		forceRemoveOpt = "true"
 	}
-	resp, err := cli.PostForm(addr, url.Values(map[string][]string{
+	form := url.Values(map[string][]string{
 		cdc.APIOpVarAdminJob:           {fmt.Sprint(int(job.Type))},
 		cdc.APIOpVarChangefeedID:       {job.CfID},
 		cdc.APIOpForceRemoveChangefeed: {forceRemoveOpt},
-	}))
+	})
+	if job.Opts != nil && job.Opts.ResumeTs != 0 {
+		form.Set(cdc.APIOpVarResumeTs, strconv.FormatUint(job.Opts.ResumeTs, 10))
+		form.Set(cdc.APIOpVarSafeMode, strconv.FormatBool(job.Opts.SafeMode))
+	}
+	resp, err := cli.PostForm(addr, form)
 	if err != nil {
 		return err
 	}
@@ -240,8 +270,8 @@ func verifyTargetTs(ctx context.Context, startTs, targetTs uint64) error {
 	return nil
 }
 
-func verifyTables(ctx context.Context, credential *security.Credential, cfg *config.ReplicaConfig, startTs uint64) (ineligibleTables, eligibleTables []model.TableName, err error) {
-	kvStore, err := kv.CreateTiStore(cliPdAddr, credential)
+func verifyTables(ctx context.Context, pdAddr string, credential *security.Credential, cfg *config.ReplicaConfig, startTs uint64) (ineligibleTables, eligibleTables []model.TableName, err error) {
+	kvStore, err := kv.CreateTiStore(pdAddr, credential)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -277,30 +307,37 @@ func verifyTables(ctx context.Context, credential *security.Credential, cfg *con
 	return
 }
 
-func verifySink(
-	ctx context.Context, sinkURI string, cfg *config.ReplicaConfig, opts map[string]string,
-) error {
-	filter, err := filter.NewFilter(cfg)
+// verifyTimezone checks the time zone of the changefeed against the time_zone
+// of the upstream cluster, it returns a warning if they are different.
+func verifyTimezone(pdAddr string, credential *security.Credential, cfg *config.ReplicaConfig, startTs uint64) (string, error) {
+	if cfg.TimeZone == "" {
+		return "", nil
+	}
+	tz, err := util.GetTimezone(cfg.TimeZone)
 	if err != nil {
-		return err
+		return "", err
 	}
-	errCh := make(chan error)
-	s, err := sink.NewSink(ctx, "cli-verify", sinkURI, filter, cfg, opts, errCh)
+	kvStore, err := kv.CreateTiStore(pdAddr, credential)
 	if err != nil {
-		return err
+		return "", err
 	}
-	err = s.Close()
+	upstream, err := entry.GetUpstreamTimezone(kvStore, startTs)
 	if err != nil {
-		return err
+		return "", errors.Trace(err)
 	}
-	select {
-	case err = <-errCh:
-		if err != nil {
-			return err
-		}
-	default:
+	if upstream == "" {
+		return "", nil
 	}
-	return nil
+	upstreamTz, err := util.GetMySQLTimezone(upstream)
+	if err != nil {
+		return fmt.Sprintf("the time zone %s of the upstream cluster can't be loaded, "+
+			"so it can't be checked against the time zone %s of the changefeed", upstream, cfg.TimeZone), nil
+	}
+	if !util.IsSameTimezone(tz, upstreamTz, time.Now().Year()) {
+		return fmt.Sprintf("the time zone %s of the changefeed is different from the time zone %s of the upstream cluster, "+
+			"the TIMESTAMP columns are replicated in the time zone of the changefeed", cfg.TimeZone, upstream), nil
+	}
+	return "", nil
 }
 
 // strictDecodeFile decodes the toml file strictly. If any item in confFile file is not mapped
@@ -16,6 +16,7 @@ package main
 import (
 	"fmt"
 	"math/rand"
+	"time"
 
 	"github.com/pingcap/errors"
 	"github.com/pingcap/ticdc/integration/framework"
@@ -66,5 +67,23 @@ func (c *alterCase) Run(ctx *framework.TaskContext) error {
 		}
 	}
 
-	return nil
+	// every ALTER registers a new version of the value schema
+	registry, err := ctx.SchemaRegistry()
+	if err != nil {
+		return err
+	}
+	subject := framework.AvroValueSubject(ctx.Database, "test")
+	err = registry.RequireVersions(subject, 20, time.Minute)
+	if err != nil {
+		return err
+	}
+	fields := []string{"id"}
+	for i := 0; i < 20; i++ {
+		fields = append(fields, fmt.Sprintf("value%d", i))
+	}
+	err = registry.RequireFields(subject, fields...)
+	if err != nil {
+		return err
+	}
+	return registry.RequireFields(framework.AvroKeySubject(ctx.Database, "test"), "id")
 }
@@ -0,0 +1,51 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"github.com/pingcap/ticdc/integration/framework"
+)
+
+type canalJSONSimpleCase struct {
+	framework.CanalJSONSingleTableTask
+}
+
+func newCanalJSONSimpleCase() *canalJSONSimpleCase {
+	canalJSONSimpleCase := new(canalJSONSimpleCase)
+	canalJSONSimpleCase.CanalJSONSingleTableTask.TableName = "test"
+	return canalJSONSimpleCase
+}
+
+func (s *canalJSONSimpleCase) Name() string {
+	return "Canal-JSON Simple"
+}
+
+func (s *canalJSONSimpleCase) Run(ctx *framework.TaskContext) error {
+	verifier := ctx.ConsumeTopic(ctx.Database+"_test", framework.NewCanalJSONDecoder())
+	err := runSimpleTableConsistentCase(ctx)
+	if err != nil {
+		return err
+	}
+
+	// check the messages themselves besides the data replayed in the downstream
+	err = verifier.RequireRow("test", []interface{}{3}, map[string]interface{}{"value": 4})
+	if err != nil {
+		return err
+	}
+	err = verifier.RequireNoRow("test", []interface{}{5})
+	if err != nil {
+		return err
+	}
+	return verifier.RequireOrderedByCommitTs("test", []interface{}{3})
+}
@@ -0,0 +1,72 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/ticdc/integration/framework"
+)
+
+type captureKillCase struct {
+	framework.MySQLSingleTableTask
+}
+
+func newCaptureKillCase() *captureKillCase {
+	captureKillCase := new(captureKillCase)
+	captureKillCase.MySQLSingleTableTask.TableName = "test"
+	return captureKillCase
+}
+
+func (s *captureKillCase) Name() string {
+	return "Capture Kill"
+}
+
+func (s *captureKillCase) Run(ctx *framework.TaskContext) error {
+	_, err := ctx.Upstream.ExecContext(ctx.Ctx, "create table test (id int primary key, value int)")
+	if err != nil {
+		return errors.AddStack(err)
+	}
+
+	chaos := ctx.Chaos()
+	wait := ctx.ScheduleChaos(
+		framework.KillServiceEvent(chaos, "capturer0", 2*time.Second, 10*time.Second),
+		framework.PauseServiceEvent(chaos, "capturer1", 5*time.Second, 10*time.Second),
+	)
+
+	// keep writing while the captures are killed and paused
+	deadline := time.Now().Add(20 * time.Second)
+	for i := 0; time.Now().Before(deadline); i++ {
+		_, err := ctx.Upstream.ExecContext(ctx.Ctx, "replace into test values (?, ?)", i%100, i)
+		if err != nil {
+			return errors.AddStack(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	err = wait()
+	if err != nil {
+		return err
+	}
+	err = ctx.TableConsistent(ctx.Database, "test").Wait().Check()
+	if err != nil {
+		return err
+	}
+	report, err := ctx.ConsistencyChecker().CheckSchema(ctx.Ctx, ctx.Database, nil)
+	if err != nil {
+		return err
+	}
+	return report.Err()
+}
@@ -0,0 +1,134 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/integration/framework"
+)
+
+const (
+	captureScaleChangefeedID = "capture-scale"
+	captureScaleTableNum     = 8
+)
+
+type captureScaleCase struct {
+	framework.MySQLSingleTableTask
+}
+
+func newCaptureScaleCase() *captureScaleCase {
+	captureScaleCase := new(captureScaleCase)
+	captureScaleCase.MySQLSingleTableTask.TableName = "test"
+	return captureScaleCase
+}
+
+func (s *captureScaleCase) Name() string {
+	return "Capture Scale"
+}
+
+func (s *captureScaleCase) GetCDCProfile() *framework.CDCProfile {
+	profile := s.MySQLSingleTableTask.GetCDCProfile()
+	profile.ChangefeedID = captureScaleChangefeedID
+	return profile
+}
+
+func (s *captureScaleCase) Run(ctx *framework.TaskContext) error {
+	for i := 0; i < captureScaleTableNum; i++ {
+		_, err := ctx.Upstream.ExecContext(ctx.Ctx, fmt.Sprintf("create table test%d (id int primary key, value int)", i))
+		if err != nil {
+			return errors.AddStack(err)
+		}
+	}
+	cluster := ctx.Cluster()
+	err := cluster.WaitTableDistribution(captureScaleChangefeedID, framework.TablesDrainedFrom(captureScaleTableNum), time.Minute)
+	if err != nil {
+		return err
+	}
+
+	// the tables are rebalanced to the new captures after they join
+	err = cluster.ScaleCDC(framework.MultiCaptureNum + 2)
+	if err != nil {
+		return err
+	}
+	err = cluster.WaitTableDistribution(captureScaleChangefeedID, func(distribution map[string][]model.TableID) bool {
+		return len(distribution) > framework.MultiCaptureNum &&
+			framework.TablesDrainedFrom(captureScaleTableNum)(distribution)
+	}, 2*time.Minute)
+	if err != nil {
+		return err
+	}
+	if err := insertIntoTables(ctx, captureScaleTableNum, 1); err != nil {
+		return err
+	}
+
+	// the tables of the stopped captures are moved to the remaining ones
+	captures, err := cluster.Captures()
+	if err != nil {
+		return err
+	}
+	err = cluster.ScaleCDC(2)
+	if err != nil {
+		return err
+	}
+	remaining, err := cluster.Captures()
+	if err != nil {
+		return err
+	}
+	var stopped []string
+	for _, capture := range captures {
+		if !containsCapture(remaining, capture.ID) {
+			stopped = append(stopped, capture.ID)
+		}
+	}
+	err = cluster.WaitTableDistribution(captureScaleChangefeedID,
+		framework.TablesDrainedFrom(captureScaleTableNum, stopped...), 2*time.Minute)
+	if err != nil {
+		return err
+	}
+	if err := insertIntoTables(ctx, captureScaleTableNum, 2); err != nil {
+		return err
+	}
+	for i := 0; i < captureScaleTableNum; i++ {
+		err := ctx.TableConsistent(ctx.Database, fmt.Sprintf("test%d", i)).Wait().Check()
+		if err != nil {
+			return err
+		}
+	}
+
+	// restore the cluster for the following cases
+	return cluster.ScaleCDC(framework.MultiCaptureNum)
+}
+
+func insertIntoTables(ctx *framework.TaskContext, tableNum int, id int) error {
+	for i := 0; i < tableNum; i++ {
+		_, err := ctx.Upstream.ExecContext(ctx.Ctx, fmt.Sprintf("insert into test%d values (%d, %d)", i, id, id))
+		if err != nil {
+			return errors.AddStack(err)
+		}
+	}
+	return nil
+}
+
+func containsCapture(captures []framework.CaptureInfo, id string) bool {
+	for _, capture := range captures {
+		if capture.ID == id {
+			return true
+		}
+	}
+	return false
+}
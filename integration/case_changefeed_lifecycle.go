@@ -0,0 +1,102 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/integration/framework"
+)
+
+type changefeedLifecycleCase struct {
+	framework.MySQLSingleTableTask
+}
+
+func newChangefeedLifecycleCase() *changefeedLifecycleCase {
+	changefeedLifecycleCase := new(changefeedLifecycleCase)
+	changefeedLifecycleCase.MySQLSingleTableTask.TableName = "test"
+	return changefeedLifecycleCase
+}
+
+func (s *changefeedLifecycleCase) Name() string {
+	return "Changefeed Lifecycle"
+}
+
+func (s *changefeedLifecycleCase) Run(ctx *framework.TaskContext) error {
+	_, err := ctx.Upstream.ExecContext(ctx.Ctx, "create table test (id int primary key, value int)")
+	if err != nil {
+		return errors.AddStack(err)
+	}
+	insert := func(from, to int) error {
+		for i := from; i < to; i++ {
+			_, err := ctx.Upstream.ExecContext(ctx.Ctx, "insert into test values (?, ?)", i, i)
+			if err != nil {
+				return errors.AddStack(err)
+			}
+		}
+		return nil
+	}
+
+	err = insert(0, 100)
+	if err != nil {
+		return err
+	}
+	err = ctx.TableConsistent(ctx.Database, "test").Wait().Check()
+	if err != nil {
+		return err
+	}
+
+	// nothing is replicated while the changefeed is paused
+	err = ctx.PauseChangefeed()
+	if err != nil {
+		return err
+	}
+	err = insert(100, 200)
+	if err != nil {
+		return err
+	}
+	time.Sleep(5 * time.Second)
+	var count int
+	err = ctx.Downstream.QueryRowContext(ctx.Ctx, "select count(*) from test").Scan(&count)
+	if err != nil {
+		return errors.AddStack(err)
+	}
+	if count != 100 {
+		return errors.Errorf("%d rows are replicated while the changefeed is paused, expect 100", count)
+	}
+
+	err = ctx.ResumeChangefeed(0)
+	if err != nil {
+		return err
+	}
+	err = ctx.TableConsistent(ctx.Database, "test").Wait().Check()
+	if err != nil {
+		return err
+	}
+
+	err = ctx.RemoveChangefeed()
+	if err != nil {
+		return err
+	}
+	status, err := ctx.QueryChangefeedStatus()
+	if err != nil {
+		return err
+	}
+	if status.State != model.StateRemoved {
+		return errors.Errorf("changefeed %s is %s after being removed", ctx.ChangefeedID(), status.State)
+	}
+	return nil
+}
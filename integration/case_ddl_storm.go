@@ -0,0 +1,29 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"time"
+
+	"github.com/pingcap/ticdc/integration/framework"
+)
+
+// newDDLStormCase creates many tables and runs DDLs on them while rows are written,
+// the tables are scheduled among all the captures of the multi-capture environment.
+func newDDLStormCase() *framework.DDLStormTask {
+	config := framework.DefaultDDLStormConfig("testdb", "storm")
+	config.Tables = 500
+	config.Duration = 30 * time.Second
+	return framework.NewDDLStormTask(&framework.MySQLSingleTableTask{TableName: "storm"}, config)
+}
@@ -0,0 +1,24 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"github.com/pingcap/ticdc/integration/framework"
+)
+
+// newExoticSchemaCase replicates the partitioned tables, the clustered indexes, the
+// generated columns and the charsets of framework.ExoticTables to the downstream TiDB.
+func newExoticSchemaCase() *framework.ExoticSchemaTask {
+	return framework.NewExoticSchemaTask(&framework.MySQLSingleTableTask{TableName: "exotic"}, 200)
+}
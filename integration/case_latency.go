@@ -0,0 +1,43 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"time"
+
+	"github.com/pingcap/ticdc/integration/framework"
+)
+
+// the thresholds are loose so that the cases are stable on a busy machine, they
+// only catch severe regressions in the sorter and the sinks
+const (
+	latencyMaxP50 = 5 * time.Second
+	latencyMaxP99 = 15 * time.Second
+)
+
+func newLatencyConfig() framework.LatencyConfig {
+	config := framework.DefaultLatencyConfig("testdb", "latency")
+	config.MaxP50 = latencyMaxP50
+	config.MaxP99 = latencyMaxP99
+	return config
+}
+
+func newMySQLLatencyCase() *framework.LatencyTask {
+	return framework.NewMySQLLatencyTask(&framework.MySQLSingleTableTask{TableName: "latency"}, newLatencyConfig())
+}
+
+func newCanalJSONLatencyCase() *framework.LatencyTask {
+	return framework.NewKafkaLatencyTask(&framework.CanalJSONSingleTableTask{TableName: "latency"},
+		newLatencyConfig(), framework.NewCanalJSONDecoder())
+}
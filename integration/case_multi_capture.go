@@ -0,0 +1,103 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/integration/framework"
+	"go.uber.org/zap"
+)
+
+const (
+	multiCaptureChangefeedID = "multi-capture"
+	multiCaptureTableNum     = 8
+)
+
+type multiCaptureCase struct {
+	framework.MySQLSingleTableTask
+}
+
+func newMultiCaptureCase() *multiCaptureCase {
+	multiCaptureCase := new(multiCaptureCase)
+	multiCaptureCase.MySQLSingleTableTask.TableName = "test"
+	return multiCaptureCase
+}
+
+func (s *multiCaptureCase) Name() string {
+	return "Multi Capture"
+}
+
+func (s *multiCaptureCase) GetCDCProfile() *framework.CDCProfile {
+	profile := s.MySQLSingleTableTask.GetCDCProfile()
+	profile.ChangefeedID = multiCaptureChangefeedID
+	return profile
+}
+
+func (s *multiCaptureCase) Run(ctx *framework.TaskContext) error {
+	for i := 0; i < multiCaptureTableNum; i++ {
+		_, err := ctx.Upstream.ExecContext(ctx.Ctx, fmt.Sprintf("create table test%d (id int primary key, value int)", i))
+		if err != nil {
+			return errors.AddStack(err)
+		}
+	}
+
+	cluster := ctx.Cluster()
+	// all tables are replicated, and they are scheduled to more than one capture
+	err := cluster.WaitTableDistribution(multiCaptureChangefeedID, func(distribution map[string][]model.TableID) bool {
+		tableNum := 0
+		for _, tables := range distribution {
+			tableNum += len(tables)
+		}
+		return tableNum == multiCaptureTableNum && len(distribution) > 1
+	}, time.Minute)
+	if err != nil {
+		return err
+	}
+
+	oldOwner, err := cluster.Owner()
+	if err != nil {
+		return err
+	}
+	err = cluster.ResignOwner()
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < multiCaptureTableNum; i++ {
+		_, err := ctx.Upstream.ExecContext(ctx.Ctx, fmt.Sprintf("insert into test%d values (1, 1), (2, 2)", i))
+		if err != nil {
+			return errors.AddStack(err)
+		}
+	}
+	for i := 0; i < multiCaptureTableNum; i++ {
+		err := ctx.TableConsistent(ctx.Database, fmt.Sprintf("test%d", i)).Wait().Check()
+		if err != nil {
+			return err
+		}
+	}
+
+	// the changefeed keeps running after the owner resigned, and an owner must have been
+	// elected again, which may be the same capture
+	owner, err := cluster.Owner()
+	if err != nil {
+		return err
+	}
+	log.Info("owner re-elected", zap.String("old", oldOwner.ID), zap.String("new", owner.ID))
+	return nil
+}
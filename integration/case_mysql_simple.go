@@ -0,0 +1,72 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"github.com/pingcap/errors"
+	"github.com/pingcap/ticdc/integration/framework"
+)
+
+type mysqlSimpleCase struct {
+	framework.MySQLSingleTableTask
+}
+
+func newMySQLSimpleCase() *mysqlSimpleCase {
+	mysqlSimpleCase := new(mysqlSimpleCase)
+	mysqlSimpleCase.MySQLSingleTableTask.TableName = "test"
+	return mysqlSimpleCase
+}
+
+func (s *mysqlSimpleCase) Name() string {
+	return "MySQL Simple"
+}
+
+func (s *mysqlSimpleCase) Run(ctx *framework.TaskContext) error {
+	return runSimpleTableConsistentCase(ctx)
+}
+
+// runSimpleTableConsistentCase runs some DMLs on a single table and checks the
+// whole table is replicated, it's shared by the sinks replicating to a downstream database.
+func runSimpleTableConsistentCase(ctx *framework.TaskContext) error {
+	_, err := ctx.Upstream.ExecContext(ctx.Ctx, "create table test (id int primary key, value int)")
+	if err != nil {
+		return err
+	}
+
+	table := ctx.SQLHelper().GetTable("test")
+	reqs := make([]framework.Awaitable, 0)
+	for i := 0; i < 1000; i++ {
+		req := table.Insert(map[string]interface{}{
+			"id":    i,
+			"value": i,
+		}).Send()
+		reqs = append(reqs, req)
+	}
+	err = framework.All(ctx.SQLHelper(), reqs).Wait().Check()
+	if err != nil {
+		return errors.AddStack(err)
+	}
+
+	_, err = ctx.Upstream.ExecContext(ctx.Ctx, "update test set value = value + 1 where id % 3 = 0")
+	if err != nil {
+		return errors.AddStack(err)
+	}
+	_, err = ctx.Upstream.ExecContext(ctx.Ctx, "delete from test where id % 5 = 0")
+	if err != nil {
+		return errors.AddStack(err)
+	}
+
+	// The whole table should be the same in the upstream and the downstream eventually
+	return ctx.TableConsistent(ctx.Database, "test").Wait().Check()
+}
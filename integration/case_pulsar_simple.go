@@ -0,0 +1,51 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"github.com/pingcap/ticdc/integration/framework"
+)
+
+type pulsarSimpleCase struct {
+	framework.PulsarSingleTableTask
+}
+
+func newPulsarSimpleCase() *pulsarSimpleCase {
+	pulsarSimpleCase := new(pulsarSimpleCase)
+	pulsarSimpleCase.PulsarSingleTableTask.TableName = "test"
+	return pulsarSimpleCase
+}
+
+func (s *pulsarSimpleCase) Name() string {
+	return "Pulsar Simple"
+}
+
+func (s *pulsarSimpleCase) Run(ctx *framework.TaskContext) error {
+	verifier := ctx.ConsumePulsarTopic(ctx.Database+"_test", framework.NewCanalJSONDecoder())
+	err := runSimpleTableConsistentCase(ctx)
+	if err != nil {
+		return err
+	}
+
+	// check the messages themselves besides the data replayed in the downstream
+	err = verifier.RequireRow("test", []interface{}{3}, map[string]interface{}{"value": 4})
+	if err != nil {
+		return err
+	}
+	err = verifier.RequireNoRow("test", []interface{}{5})
+	if err != nil {
+		return err
+	}
+	return verifier.RequireOrderedByCommitTs("test", []interface{}{3})
+}
@@ -0,0 +1,24 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"github.com/pingcap/ticdc/integration/framework"
+)
+
+// newSorterDiskFullCase writes 128MB to a table while the sort dir of the captures is a 32MB tmpfs
+func newSorterDiskFullCase() *framework.SorterDiskFullTask {
+	config := framework.DefaultSorterDiskFullConfig("sorter")
+	return framework.NewSorterDiskFullTask(&framework.MySQLSingleTableTask{TableName: "sorter"}, config)
+}
@@ -14,6 +14,8 @@
 package main
 
 import (
+	"time"
+
 	"github.com/pingcap/ticdc/integration/framework"
 )
 
@@ -58,11 +60,24 @@ func (s *unsignedCase) Run(ctx *framework.TaskContext) error {
 
 	// Get a handle of an existing table
 	table := ctx.SQLHelper().GetTable("test")
-	return table.Insert(map[string]interface{}{
+	err = table.Insert(map[string]interface{}{
 		"id":       0,
 		"t_int":    0xFEEDBEEF,
 		"t_bigint": uint64(0xFEEDBEEFFEEDBEEF),
 		"t_bit":    uint64(0xFFFFFFFFFFFFFFFA),
 	}).Send().Wait().Check()
+	if err != nil {
+		return err
+	}
 
+	// an unsigned int doesn't fit in an Avro int, so it must be encoded as a long
+	messages, err := ctx.FetchAvroMessages(ctx.Database+"_test", 1, time.Minute)
+	if err != nil {
+		return err
+	}
+	msg, err := messages.Last(map[string]interface{}{"id": int32(0)})
+	if err != nil {
+		return err
+	}
+	return msg.RequireFields(map[string]interface{}{"t_int": int64(0xFEEDBEEF)})
 }
@@ -0,0 +1,192 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/ticdc/cdc/kv"
+	"go.etcd.io/etcd/clientv3"
+	"go.uber.org/zap"
+)
+
+const defaultArtifactsDir = "artifacts"
+
+var unsafeFileNameChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// SetArtifactsDir sets the directory in which the artifacts of the failed tasks
+// are collected, it's "artifacts" in the working directory by default.
+func (d *dockerComposeOperator) SetArtifactsDir(dir string) {
+	d.artifactsDir = dir
+}
+
+// collectArtifacts collects the logs of all the services, the etcd keyspace of
+// TiCDC and the status of the changefeeds into a new timestamped directory, and
+// returns the directory. Failing to collect one of them doesn't stop collecting
+// the others.
+func (d *dockerComposeOperator) collectArtifacts(name string) string {
+	root := d.artifactsDir
+	if root == "" {
+		root = defaultArtifactsDir
+	}
+	dir := filepath.Join(root, time.Now().Format("20060102-150405.000")+"-"+
+		unsafeFileNameChars.ReplaceAllString(name, "_"))
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		log.Warn("Failed to create the artifacts directory", zap.String("dir", dir), zap.Error(err))
+		return ""
+	}
+
+	collectors := []struct {
+		name    string
+		collect func(dir string) error
+	}{
+		{"service logs", d.collectServiceLogs},
+		{"etcd keyspace", d.collectEtcdKeyspace},
+		{"changefeed status", d.collectChangefeedStatus},
+	}
+	for _, collector := range collectors {
+		err := collector.collect(dir)
+		if err != nil {
+			log.Warn("Failed to collect artifacts", zap.String("artifacts", collector.name), zap.Error(err))
+		}
+	}
+	log.Info("Artifacts collected", zap.String("task", name), zap.String("dir", dir))
+	return dir
+}
+
+// collectServiceLogs writes the logs of every service into logs/<service>.log
+func (d *dockerComposeOperator) collectServiceLogs(dir string) error {
+	out, err := runCmd(exec.Command("docker-compose", "-f", d.fileName, "ps", "--services"))
+	if err != nil {
+		return err
+	}
+	logDir := filepath.Join(dir, "logs")
+	err = os.MkdirAll(logDir, 0755)
+	if err != nil {
+		return errors.AddStack(err)
+	}
+	for _, service := range strings.Fields(string(out)) {
+		f, err := os.Create(filepath.Join(logDir, service+".log"))
+		if err != nil {
+			return errors.AddStack(err)
+		}
+		cmd := exec.Command("docker-compose", "-f", d.fileName, "logs", "-t", "--no-color", service)
+		cmd.Stdout = f
+		err = cmd.Run()
+		_ = f.Close()
+		if err != nil {
+			log.Warn("Failed to collect the logs of service", zap.String("service", service), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// etcdRangeResponse is the response of the range API of the etcd gRPC gateway,
+// the keys and values are base64 encoded.
+type etcdRangeResponse struct {
+	Kvs []struct {
+		Key         []byte `json:"key"`
+		Value       []byte `json:"value"`
+		ModRevision string `json:"mod_revision"`
+	} `json:"kvs"`
+}
+
+// collectEtcdKeyspace dumps the keys of TiCDC in the etcd of PD into etcd.txt
+func (d *dockerComposeOperator) collectEtcdKeyspace(dir string) error {
+	request := fmt.Sprintf(`{"key":"%s","range_end":"%s"}`,
+		base64.StdEncoding.EncodeToString([]byte(kv.EtcdKeyBase)),
+		base64.StdEncoding.EncodeToString([]byte(clientv3.GetPrefixRangeEnd(kv.EtcdKeyBase))))
+	curlCmd := "curl -sf -X POST " + cliPDUri + "/v3/kv/range -d '" + request + "'"
+	if d.tls {
+		curlCmd = "curl -sf -X POST --cacert " + tlsContainerCAPath + " --cert " + tlsContainerClientCertPath +
+			" --key " + tlsContainerClientKeyPath + " " + tlsCLIPDUri + "/v3/kv/range -d '" + request + "'"
+	}
+	out, err := d.ExecInController(curlCmd)
+	if err != nil {
+		return errors.Annotate(err, "failed to read the etcd keyspace")
+	}
+	dump, err := formatEtcdKvs(out)
+	if err != nil {
+		return err
+	}
+	return errors.AddStack(ioutil.WriteFile(filepath.Join(dir, "etcd.txt"), dump, 0644))
+}
+
+// formatEtcdKvs formats the response of the etcd range API as the key, the
+// mod revision and the value of every key separated by empty lines.
+func formatEtcdKvs(data []byte) ([]byte, error) {
+	resp := new(etcdRangeResponse)
+	err := json.Unmarshal(data, resp)
+	if err != nil {
+		return nil, errors.Annotatef(err, "invalid etcd range response: %s", data)
+	}
+	var buf bytes.Buffer
+	for _, kv := range resp.Kvs {
+		fmt.Fprintf(&buf, "%s (mod_revision: %s)\n%s\n\n", kv.Key, kv.ModRevision, kv.Value)
+	}
+	return buf.Bytes(), nil
+}
+
+// collectChangefeedStatus writes the captures, the processors and the status of
+// every changefeed reported by the cdc cli into JSON files.
+func (d *dockerComposeOperator) collectChangefeedStatus(dir string) error {
+	var errs []string
+	writeCliOutput := func(fileName string, args string) []byte {
+		out, err := d.ExecCDCCli(args)
+		if err != nil {
+			errs = append(errs, err.Error())
+			return nil
+		}
+		err = ioutil.WriteFile(filepath.Join(dir, fileName), out, 0644)
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+		return out
+	}
+
+	writeCliOutput("captures.json", "capture list")
+	writeCliOutput("processors.json", "processor list")
+	out := writeCliOutput("changefeeds.json", "changefeed list --all")
+	if out != nil {
+		var changefeeds []struct {
+			ID string `json:"id"`
+		}
+		err := json.Unmarshal(out, &changefeeds)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("invalid changefeed list: %s", out))
+		}
+		for _, changefeed := range changefeeds {
+			writeCliOutput("changefeed-"+unsafeFileNameChars.ReplaceAllString(changefeed.ID, "_")+".json",
+				"changefeed query --changefeed-id="+changefeed.ID)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatEtcdKvs(t *testing.T) {
+	// keys and values are base64 encoded by the etcd gRPC gateway
+	resp := `{"header":{"revision":"12"},"kvs":[` +
+		`{"key":"L3RpZGIvY2RjL293bmVy","value":"Y2FwdHVyZS0x","mod_revision":"10"},` +
+		`{"key":"L3RpZGIvY2RjL2NhcHR1cmUvMQ==","value":"e30=","mod_revision":"11"}],"count":"2"}`
+	dump, err := formatEtcdKvs([]byte(resp))
+	require.NoError(t, err)
+	require.Equal(t, "/tidb/cdc/owner (mod_revision: 10)\ncapture-1\n\n"+
+		"/tidb/cdc/capture/1 (mod_revision: 11)\n{}\n\n", string(dump))
+
+	dump, err = formatEtcdKvs([]byte(`{"header":{"revision":"12"}}`))
+	require.NoError(t, err)
+	require.Empty(t, dump)
+
+	_, err = formatEtcdKvs([]byte("not json"))
+	require.Error(t, err)
+}
@@ -14,149 +14,89 @@
 package framework
 
 import (
-	"context"
-	"database/sql"
 	"encoding/json"
 	"errors"
 	"io/ioutil"
 	"net/http"
-	"os/exec"
 	"time"
 
-	"github.com/integralist/go-findroot/find"
-	"github.com/pingcap/log"
 	"github.com/pingcap/ticdc/pkg/retry"
-	"go.uber.org/zap"
 )
 
 const (
 	healthCheckURI          = "http://127.0.0.1:18083"
-	dockerComposeFilePath   = "/docker-compose-avro.yml"
 	controllerContainerName = "ticdc_controller_1"
 	upstreamDSN             = "root@tcp(127.0.0.1:4000)/"
 	downstreamDSN           = "root@tcp(127.0.0.1:5000)/"
 )
 
-// AvroKafkaDockerEnv represents the docker-compose service defined in docker-compose-avro.yml
+// AvroKafkaDockerEnv represents the docker-compose service generated from avroComposeConfig,
+// in which the changefeeds write Avro messages to Kafka, and Kafka Connect writes them to the downstream TiDB.
 type AvroKafkaDockerEnv struct {
 	dockerComposeOperator
 }
 
-// NewAvroKafkaDockerEnv creates a new AvroKafkaDockerEnv
-func NewAvroKafkaDockerEnv(dockerComposeFile string) *AvroKafkaDockerEnv {
-	healthChecker := func() error {
-		resp, err := http.Get(healthCheckURI)
-		if err != nil {
-			return err
-		}
-
-		if resp.Body == nil {
-			return errors.New("kafka Connect HealthCheck returns empty body")
-		}
-		defer func() { _ = resp.Body.Close() }()
-
-		bytes, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			return err
-		}
-
-		m := make(map[string]interface{})
-		err = json.Unmarshal(bytes, &m)
-		if err != nil {
-			return err
-		}
-
-		healthy, ok := m["healthy"]
-		if !ok {
-			return errors.New("kafka connect healthcheck did not return health info")
-		}
-
-		if !healthy.(bool) {
-			return errors.New("kafka connect not healthy")
-		}
-
-		return nil
+// kafkaConnectHealthCheck checks the health of Kafka Connect
+func kafkaConnectHealthCheck() error {
+	resp, err := http.Get(healthCheckURI)
+	if err != nil {
+		return err
 	}
 
-	var file string
-	if dockerComposeFile == "" {
-		st, err := find.Repo()
-		if err != nil {
-			log.Fatal("Could not find git repo root", zap.Error(err))
-		}
-		file = st.Path + dockerComposeFilePath
-	} else {
-		file = dockerComposeFile
+	if resp.Body == nil {
+		return errors.New("kafka Connect HealthCheck returns empty body")
 	}
+	defer func() { _ = resp.Body.Close() }()
 
-	return &AvroKafkaDockerEnv{dockerComposeOperator{
-		fileName:      file,
-		controller:    controllerContainerName,
-		healthChecker: healthChecker,
-	}}
-}
-
-// Reset implements Environment
-func (e *AvroKafkaDockerEnv) Reset() {
-	e.TearDown()
-	e.Setup()
-}
-
-// RunTest implements Environment
-func (e *AvroKafkaDockerEnv) RunTest(task Task) {
-	cmdLine := "/cdc " + task.GetCDCProfile().String()
-	bytes, err := e.ExecInController(cmdLine)
+	bytes, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		log.Fatal("RunTest failed: cannot setup changefeed",
-			zap.Error(err),
-			zap.ByteString("stdout", bytes),
-			zap.ByteString("stderr", err.(*exec.ExitError).Stderr))
+		return err
 	}
 
-	upstream, err := sql.Open("mysql", upstreamDSN)
+	m := make(map[string]interface{})
+	err = json.Unmarshal(bytes, &m)
 	if err != nil {
-		log.Fatal("RunTest: cannot connect to upstream database", zap.Error(err))
+		return err
 	}
 
-	_, err = upstream.Exec("set @@global.tidb_enable_clustered_index=0")
-	if err != nil {
-		log.Info("tidb_enable_clustered_index not supported.")
-	} else {
-		time.Sleep(2 * time.Second)
+	healthy, ok := m["healthy"]
+	if !ok {
+		return errors.New("kafka connect healthcheck did not return health info")
 	}
 
-	downstream, err := sql.Open("mysql", downstreamDSN)
-	if err != nil {
-		log.Fatal("RunTest: cannot connect to downstream database", zap.Error(err))
+	if !healthy.(bool) {
+		return errors.New("kafka connect not healthy")
 	}
 
-	taskCtx := &TaskContext{
-		Upstream:   upstream,
-		Downstream: downstream,
-		env:        e,
-		waitForReady: func() error {
-			return retry.Run(time.Second, 120, e.healthChecker)
-		},
-		Ctx: context.Background(),
-	}
+	return nil
+}
 
-	err = task.Prepare(taskCtx)
-	if err != nil {
-		e.TearDown()
-		log.Fatal("RunTest: task preparation failed", zap.String("name", task.Name()), zap.Error(err))
-	}
+// NewAvroKafkaDockerEnv creates a new AvroKafkaDockerEnv
+func NewAvroKafkaDockerEnv(dockerComposeFile string) *AvroKafkaDockerEnv {
+	env := &AvroKafkaDockerEnv{newDockerComposeOperator(dockerComposeFile, avroComposeConfig())}
+	env.controller = controllerContainerName
+	env.healthChecker = kafkaConnectHealthCheck
+	return env
+}
 
-	log.Info("Start running task", zap.String("name", task.Name()))
-	err = task.Run(taskCtx)
-	if err != nil {
-		err1 := e.DumpStdout()
-		if err1 != nil {
-			log.Warn("Failed to dump container logs", zap.Error(err1))
-		}
-		e.TearDown()
-		log.Fatal("RunTest: task failed", zap.String("name", task.Name()), zap.Error(err))
-	}
-	log.Info("Finished running task", zap.String("name", task.Name()))
+// Reset implements Environment
+func (e *AvroKafkaDockerEnv) Reset() {
+	e.TearDown()
+	e.Setup()
+}
+
+// RunTest implements Environment
+func (e *AvroKafkaDockerEnv) RunTest(task Task) {
+	runTask(&e.dockerComposeOperator, e, task, func() error {
+		return retry.Run(time.Second, 120, e.healthChecker)
+	})
+}
+
+// RunTests implements Environment
+func (e *AvroKafkaDockerEnv) RunTests(tasks []Task, parallelism int) []TaskResult {
+	return runTasks(&e.dockerComposeOperator, e, tasks, parallelism, func() error {
+		return retry.Run(time.Second, 120, e.healthChecker)
+	})
 }
 
 // SetListener implements Environment. Currently unfinished, will be used to monitor Kafka output
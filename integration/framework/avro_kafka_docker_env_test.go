@@ -52,7 +52,7 @@ func (t *dummyTask) Prepare(taskContext *TaskContext) error {
 func (t *dummyTask) GetCDCProfile() *CDCProfile {
 	return &CDCProfile{
 		PDUri:   "http://upstream-pd:2379",
-		SinkURI: "kafka://kafka:9092/testdb_test?protocol=avro",
+		SinkURI: "kafka://kafka:29092/testdb_test?protocol=avro",
 		Opts:    map[string]string{"registry": "http://schema-registry:8081"},
 	}
 }
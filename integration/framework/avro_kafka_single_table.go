@@ -29,6 +29,7 @@ import (
 // AvroSingleTableTask provides a basic implementation for an Avro test case
 type AvroSingleTableTask struct {
 	TableName string
+	database  string
 }
 
 // Name implements Task
@@ -40,27 +41,45 @@ func (a *AvroSingleTableTask) Name() string {
 // GetCDCProfile implements Task
 func (a *AvroSingleTableTask) GetCDCProfile() *CDCProfile {
 	return &CDCProfile{
-		PDUri:   "http://upstream-pd:2379",
-		SinkURI: "kafka://kafka:9092/testdb_" + a.TableName + "?protocol=avro",
-		Opts:    map[string]string{"registry": "http://schema-registry:8081"},
+		PDUri:       "http://upstream-pd:2379",
+		SinkURI:     "kafka://" + kafkaInternalAddr + "/" + a.topic() + "?protocol=avro",
+		Opts:        map[string]string{"registry": "http://schema-registry:8081"},
+		FilterRules: []string{a.db() + ".*"},
 	}
 }
 
+func (a *AvroSingleTableTask) topic() string {
+	return a.db() + "_" + a.TableName
+}
+
+// SetDatabase implements IsolatedTask
+func (a *AvroSingleTableTask) SetDatabase(name string) {
+	a.database = name
+}
+
+func (a *AvroSingleTableTask) db() string {
+	if a.database == "" {
+		return defaultDatabase
+	}
+	return a.database
+}
+
 // Prepare implements Task
 func (a *AvroSingleTableTask) Prepare(taskContext *TaskContext) error {
-	err := taskContext.CreateDB("testdb")
+	taskContext.Database = a.db()
+	err := taskContext.CreateDB(a.db())
 	if err != nil {
 		return err
 	}
 
 	_ = taskContext.Upstream.Close()
-	taskContext.Upstream, err = sql.Open("mysql", upstreamDSN+"testdb")
+	taskContext.Upstream, err = sql.Open("mysql", upstreamDSN+a.db())
 	if err != nil {
 		return err
 	}
 
 	_ = taskContext.Downstream.Close()
-	taskContext.Downstream, err = sql.Open("mysql", downstreamDSN+"testdb")
+	taskContext.Downstream, err = sql.Open("mysql", downstreamDSN+a.db())
 	if err != nil {
 		return err
 	}
@@ -68,12 +87,12 @@ func (a *AvroSingleTableTask) Prepare(taskContext *TaskContext) error {
 
 	// TODO better way to generate JSON
 	connectorConfigFmt := `{
-	  "name": "jdbc-sink-connector",
+	  "name": "jdbc-sink-connector-%s",
 	  "config": {
 		"connector.class": "io.confluent.connect.jdbc.JdbcSinkConnector",
 		"tasks.max": "1",
-		"topics": "testdb_%s",
-		"connection.url": "jdbc:mysql://root@downstream-tidb:4000/testdb",
+		"topics": "%s",
+		"connection.url": "jdbc:mysql://root@downstream-tidb:4000/%s",
 		"connection.ds.pool.size": 5,
 		"table.name.format": "%s",
 		"insert.mode": "upsert",
@@ -83,7 +102,7 @@ func (a *AvroSingleTableTask) Prepare(taskContext *TaskContext) error {
 		"auto.evolve": true
 	  }
 	}`
-	connectorConfig := fmt.Sprintf(connectorConfigFmt, a.TableName, a.TableName)
+	connectorConfig := fmt.Sprintf(connectorConfigFmt, a.topic(), a.topic(), a.db(), a.TableName)
 	log.Debug("Creating Kafka sink connector", zap.String("config", connectorConfig))
 
 	resp, err := http.Post(
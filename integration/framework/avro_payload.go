@@ -0,0 +1,237 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"context"
+	"math/big"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/linkedin/goavro/v2"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/ticdc/pkg/retry"
+)
+
+// AvroMessage is a message of the Avro protocol decoded into native Go values
+type AvroMessage struct {
+	Partition     int32
+	Offset        int64
+	KeySchemaID   uint32
+	ValueSchemaID uint32
+	// Key and Value map the field names to the values decoded by goavro, with the
+	// unions unwrapped, e.g. a long is an int64, a decimal is a *big.Rat and a
+	// timestamp-millis is a time.Time. Value is nil if the row is deleted.
+	Key   map[string]interface{}
+	Value map[string]interface{}
+}
+
+// IsDelete returns whether the message is the tombstone of a deleted row
+func (m *AvroMessage) IsDelete() bool {
+	return m.Value == nil
+}
+
+// RequireFields returns an error if the message is a delete, or any field in expected
+// has another value in the message. The values are compared by their native Go
+// types, so expected should hold the types goavro decodes the fields to.
+func (m *AvroMessage) RequireFields(expected map[string]interface{}) error {
+	if m.IsDelete() {
+		return errors.Errorf("message at partition %d offset %d is a delete of %v", m.Partition, m.Offset, m.Key)
+	}
+	for name, value := range expected {
+		actual, ok := m.Value[name]
+		if !ok {
+			return errors.Errorf("message at partition %d offset %d has no field %s", m.Partition, m.Offset, name)
+		}
+		if !avroValueEqual(actual, value) {
+			return errors.Errorf("field %s of the message at partition %d offset %d is %v (%T), expected %v (%T)",
+				name, m.Partition, m.Offset, actual, actual, value, value)
+		}
+	}
+	return nil
+}
+
+// AvroMessages is a list of messages in the order they are consumed from every partition
+type AvroMessages []*AvroMessage
+
+// ForKey returns the messages whose keys have the fields in key
+func (m AvroMessages) ForKey(key map[string]interface{}) AvroMessages {
+	var ret AvroMessages
+	for _, msg := range m {
+		matched := true
+		for name, value := range key {
+			if !avroValueEqual(msg.Key[name], value) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			ret = append(ret, msg)
+		}
+	}
+	return ret
+}
+
+// Last returns the last message of the key, which carries the latest version of the row
+func (m AvroMessages) Last(key map[string]interface{}) (*AvroMessage, error) {
+	messages := m.ForKey(key)
+	if len(messages) == 0 {
+		return nil, errors.Errorf("no message of key %v", key)
+	}
+	return messages[len(messages)-1], nil
+}
+
+// avroValueEqual compares the native values decoded by goavro
+func avroValueEqual(actual interface{}, expected interface{}) bool {
+	switch e := expected.(type) {
+	case *big.Rat:
+		a, ok := actual.(*big.Rat)
+		return ok && a.Cmp(e) == 0
+	case time.Time:
+		a, ok := actual.(time.Time)
+		return ok && a.Equal(e)
+	}
+	return reflect.DeepEqual(actual, expected)
+}
+
+// FetchAvroMessages consumes the topic from the oldest offsets until count messages
+// are received, and decodes them with the schemas in the schema registry, so a case
+// can check the fields of the messages rather than only the rows in the downstream:
+//
+//	messages, err := ctx.FetchAvroMessages(topic, 1, time.Minute)
+//	msg, err := messages.Last(map[string]interface{}{"id": int32(0)})
+//	err = msg.RequireFields(map[string]interface{}{"value": int32(1)})
+func (c *TaskContext) FetchAvroMessages(topic string, count int, timeout time.Duration) (AvroMessages, error) {
+	registry, err := c.SchemaRegistry()
+	if err != nil {
+		return nil, err
+	}
+	config := sarama.NewConfig()
+	if c.docker != nil {
+		tlsConfig, err := c.docker.tlsClientConfig()
+		if err != nil {
+			return nil, err
+		}
+		config.Net.TLS.Enable = tlsConfig != nil
+		config.Net.TLS.Config = tlsConfig
+	}
+	ctx, cancel := context.WithTimeout(c.Ctx, timeout)
+	defer cancel()
+	raw, err := fetchKafkaMessages(ctx, []string{kafkaHostAddr}, config, topic, count)
+	if err != nil {
+		return nil, errors.Annotatef(err, "failed to fetch %d messages from topic %s", count, topic)
+	}
+
+	decoder := &avroDecoder{
+		registryURL: registry.url,
+		client:      registry.client,
+		codecs:      make(map[uint32]*goavro.Codec),
+	}
+	messages := make(AvroMessages, 0, len(raw))
+	for _, msg := range raw {
+		m := &AvroMessage{Partition: msg.Partition, Offset: msg.Offset}
+		m.KeySchemaID, m.Key, err = decoder.decodeWithSchemaID(msg.Key)
+		if err != nil {
+			return nil, errors.Annotatef(err, "invalid avro key at partition %d offset %d", msg.Partition, msg.Offset)
+		}
+		if len(msg.Value) != 0 {
+			m.ValueSchemaID, m.Value, err = decoder.decodeWithSchemaID(msg.Value)
+			if err != nil {
+				return nil, errors.Annotatef(err, "invalid avro value at partition %d offset %d", msg.Partition, msg.Offset)
+			}
+		}
+		messages = append(messages, m)
+	}
+	return messages, nil
+}
+
+// fetchKafkaMessages consumes all the partitions of the topic from the oldest offsets
+// until count messages are received, the messages are sorted by the partitions and
+// the offsets.
+func fetchKafkaMessages(
+	ctx context.Context, brokers []string, config *sarama.Config, topic string, count int,
+) ([]*sarama.ConsumerMessage, error) {
+	consumer, err := sarama.NewConsumer(brokers, config)
+	if err != nil {
+		return nil, errors.AddStack(err)
+	}
+	defer consumer.Close()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var partitions []int32
+	// the topic may not have been created yet
+	err = retry.Run(time.Second, 60, func() error {
+		var err error
+		partitions, err = consumer.Partitions(topic)
+		return err
+	})
+	if err != nil {
+		return nil, errors.AddStack(err)
+	}
+
+	msgCh := make(chan *sarama.ConsumerMessage)
+	errCh := make(chan error, len(partitions))
+	for _, partition := range partitions {
+		partitionConsumer, err := consumer.ConsumePartition(topic, partition, sarama.OffsetOldest)
+		if err != nil {
+			return nil, errors.AddStack(err)
+		}
+		defer partitionConsumer.Close()
+		go func(partitionConsumer sarama.PartitionConsumer) {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case err := <-partitionConsumer.Errors():
+					errCh <- errors.AddStack(err)
+					return
+				case msg, ok := <-partitionConsumer.Messages():
+					if !ok {
+						return
+					}
+					select {
+					case <-ctx.Done():
+						return
+					case msgCh <- msg:
+					}
+				}
+			}
+		}(partitionConsumer)
+	}
+
+	messages := make([]*sarama.ConsumerMessage, 0, count)
+	for len(messages) < count {
+		select {
+		case <-ctx.Done():
+			return nil, errors.Annotatef(ctx.Err(), "%d messages received", len(messages))
+		case err := <-errCh:
+			return nil, err
+		case msg := <-msgCh:
+			// skip the messages without keys, e.g. the resolved events
+			if len(msg.Key) != 0 {
+				messages = append(messages, msg)
+			}
+		}
+	}
+	sort.Slice(messages, func(i, j int) bool {
+		if messages[i].Partition != messages[j].Partition {
+			return messages[i].Partition < messages[j].Partition
+		}
+		return messages[i].Offset < messages[j].Offset
+	})
+	return messages, nil
+}
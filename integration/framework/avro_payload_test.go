@@ -0,0 +1,90 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/linkedin/goavro/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAvroDecoderSchemaID(t *testing.T) {
+	schema := `{"type":"record","name":"value","fields":[{"name":"id","type":"long"},` +
+		`{"name":"d","type":["null",{"type":"bytes","logicalType":"decimal","precision":8,"scale":0}],"default":null}]}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/schemas/ids/7" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"schema": schema})
+	}))
+	defer server.Close()
+
+	avroCodec, err := goavro.NewCodec(schema)
+	require.NoError(t, err)
+	data := []byte{0, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(data[1:], 7)
+	data, err = avroCodec.BinaryFromNative(data, map[string]interface{}{
+		"id": int64(1),
+		"d":  goavro.Union("bytes.decimal", big.NewRat(0, 1).SetUint64(0x7EEDBEEFFEEDBEEF)),
+	})
+	require.NoError(t, err)
+
+	decoder := NewAvroDecoder(server.URL, "testdb", "test").(*avroDecoder)
+	schemaID, native, err := decoder.decodeWithSchemaID(data)
+	require.NoError(t, err)
+	require.Equal(t, uint32(7), schemaID)
+	msg := &AvroMessage{Key: map[string]interface{}{"id": int64(1)}, Value: native}
+	require.NoError(t, msg.RequireFields(map[string]interface{}{
+		"id": int64(1),
+		"d":  big.NewRat(0, 1).SetUint64(0x7EEDBEEFFEEDBEEF),
+	}))
+	// the types matter
+	require.Error(t, msg.RequireFields(map[string]interface{}{"id": 1}))
+	require.Error(t, msg.RequireFields(map[string]interface{}{"d": big.NewRat(1, 1)}))
+	require.Error(t, msg.RequireFields(map[string]interface{}{"unknown": nil}))
+}
+
+func TestAvroMessages(t *testing.T) {
+	now := time.Now()
+	messages := AvroMessages{
+		{Offset: 0, Key: map[string]interface{}{"id": int32(0)}, Value: map[string]interface{}{"id": int32(0), "t": now}},
+		{Offset: 1, Key: map[string]interface{}{"id": int32(1)}, Value: map[string]interface{}{"id": int32(1), "t": now}},
+		{Offset: 2, Key: map[string]interface{}{"id": int32(0)}, Value: map[string]interface{}{"id": int32(0), "t": now.Add(time.Second)}},
+		{Offset: 3, Key: map[string]interface{}{"id": int32(1)}},
+	}
+	require.Len(t, messages.ForKey(map[string]interface{}{"id": int32(0)}), 2)
+	require.Empty(t, messages.ForKey(map[string]interface{}{"id": int64(0)}))
+
+	last, err := messages.Last(map[string]interface{}{"id": int32(0)})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), last.Offset)
+	require.NoError(t, last.RequireFields(map[string]interface{}{"t": now.Add(time.Second).UTC()}))
+	require.Error(t, last.RequireFields(map[string]interface{}{"t": now}))
+
+	last, err = messages.Last(map[string]interface{}{"id": int32(1)})
+	require.NoError(t, err)
+	require.True(t, last.IsDelete())
+	require.Error(t, last.RequireFields(map[string]interface{}{"id": int32(1)}))
+
+	_, err = messages.Last(map[string]interface{}{"id": int32(2)})
+	require.Error(t, err)
+}
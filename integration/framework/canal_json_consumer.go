@@ -0,0 +1,235 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/ticdc/pkg/quotes"
+	"github.com/pingcap/ticdc/pkg/retry"
+	"go.uber.org/zap"
+)
+
+// canalJSONMessage is the subset of the canal-json message needed to replay it in the downstream
+type canalJSONMessage struct {
+	Schema    string              `json:"database"`
+	Table     string              `json:"table"`
+	PKNames   []string            `json:"pkNames"`
+	IsDDL     bool                `json:"isDdl"`
+	EventType string              `json:"type"`
+	Query     string              `json:"sql"`
+	Data      []map[string]string `json:"data"`
+	Old       []map[string]string `json:"old"`
+}
+
+// canalJSONConsumer consumes the canal-json messages in a single-partition topic
+// and applies them to the downstream, acting as a canal client would. The topic is
+// in Kafka, or in Pulsar if pulsarURL is set.
+// NULL values can't be told from empty strings in canal-json, so they are replayed as empty strings.
+type canalJSONConsumer struct {
+	brokers    []string
+	pulsarURL  string
+	topic      string
+	downstream *sql.DB
+}
+
+// newCanalJSONConsumer returns a consumer of the topic in Kafka, the downstream is
+// set when the task is prepared
+func newCanalJSONConsumer(topic string) *canalJSONConsumer {
+	return &canalJSONConsumer{
+		brokers: []string{kafkaHostAddr},
+		topic:   topic,
+	}
+}
+
+// newPulsarCanalJSONConsumer returns a consumer of the topic in Pulsar
+func newPulsarCanalJSONConsumer(topic string) *canalJSONConsumer {
+	return &canalJSONConsumer{
+		pulsarURL: pulsarHostURL,
+		topic:     topic,
+	}
+}
+
+// run consumes the topic until ctx is done
+func (c *canalJSONConsumer) run(ctx context.Context) error {
+	conn, err := c.downstream.Conn(ctx)
+	if err != nil {
+		return errors.AddStack(err)
+	}
+	defer conn.Close()
+
+	if c.pulsarURL != "" {
+		return c.runPulsar(ctx, conn)
+	}
+	return c.runKafka(ctx, conn)
+}
+
+func (c *canalJSONConsumer) runKafka(ctx context.Context, conn *sql.Conn) error {
+	consumer, err := sarama.NewConsumer(c.brokers, sarama.NewConfig())
+	if err != nil {
+		return errors.AddStack(err)
+	}
+	defer consumer.Close()
+
+	var partitionConsumer sarama.PartitionConsumer
+	// the topic may not have been created yet
+	err = retry.Run(time.Second, 60, func() error {
+		var err error
+		partitionConsumer, err = consumer.ConsumePartition(c.topic, 0, sarama.OffsetOldest)
+		return err
+	})
+	if err != nil {
+		return errors.AddStack(err)
+	}
+	defer partitionConsumer.Close()
+
+	log.Info("canal-json consumer started", zap.String("topic", c.topic))
+	for {
+		select {
+		case <-ctx.Done():
+			return errors.Trace(ctx.Err())
+		case err := <-partitionConsumer.Errors():
+			return errors.AddStack(err)
+		case kafkaMsg := <-partitionConsumer.Messages():
+			err := c.apply(ctx, conn, kafkaMsg.Value, fmt.Sprintf("offset %d", kafkaMsg.Offset))
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// apply applies the message at the position of the topic to the downstream
+func (c *canalJSONConsumer) apply(ctx context.Context, conn *sql.Conn, value []byte, position string) error {
+	msg := new(canalJSONMessage)
+	err := json.Unmarshal(value, msg)
+	if err != nil {
+		return errors.Annotatef(err, "invalid canal-json message at %s", position)
+	}
+	err = applyCanalJSONMessage(ctx, conn, msg)
+	if err != nil {
+		return errors.Annotatef(err, "failed to apply canal-json message at %s", position)
+	}
+	return nil
+}
+
+func applyCanalJSONMessage(ctx context.Context, conn *sql.Conn, msg *canalJSONMessage) error {
+	if msg.IsDDL {
+		log.Debug("canal-json consumer applying DDL", zap.String("query", msg.Query))
+		// DDLs on databases have no table, and the database may not exist yet
+		if msg.Table != "" {
+			_, err := conn.ExecContext(ctx, "use "+quotes.QuoteName(msg.Schema))
+			if err != nil {
+				return errors.AddStack(err)
+			}
+		}
+		_, err := conn.ExecContext(ctx, msg.Query)
+		return errors.AddStack(err)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.AddStack(err)
+	}
+	err = applyCanalJSONRow(ctx, tx, msg)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return errors.AddStack(tx.Commit())
+}
+
+func applyCanalJSONRow(ctx context.Context, tx *sql.Tx, msg *canalJSONMessage) error {
+	switch msg.EventType {
+	case "INSERT":
+		for _, row := range msg.Data {
+			if err := replaceRow(ctx, tx, msg, row); err != nil {
+				return err
+			}
+		}
+	case "UPDATE":
+		// the primary key may have been updated, so the old row is deleted first
+		for _, row := range msg.Old {
+			if err := deleteRow(ctx, tx, msg, row); err != nil {
+				return err
+			}
+		}
+		for _, row := range msg.Data {
+			if err := replaceRow(ctx, tx, msg, row); err != nil {
+				return err
+			}
+		}
+	case "DELETE":
+		rows := msg.Data
+		if len(rows) == 0 {
+			rows = msg.Old
+		}
+		for _, row := range rows {
+			if err := deleteRow(ctx, tx, msg, row); err != nil {
+				return err
+			}
+		}
+	default:
+		log.Warn("canal-json consumer skipped unknown event type", zap.String("type", msg.EventType))
+	}
+	return nil
+}
+
+func replaceRow(ctx context.Context, tx *sql.Tx, msg *canalJSONMessage, row map[string]string) error {
+	cols := sortedColumns(row)
+	placeholders := make([]string, len(cols))
+	args := make([]interface{}, len(cols))
+	for i, col := range cols {
+		placeholders[i] = "?"
+		args[i] = row[col]
+	}
+	query := "replace into " + quotes.QuoteSchema(msg.Schema, msg.Table) + " " + makeColumnTuple(cols) +
+		" values (" + strings.Join(placeholders, ",") + ")"
+	_, err := tx.ExecContext(ctx, query, args...)
+	return errors.AddStack(err)
+}
+
+func deleteRow(ctx context.Context, tx *sql.Tx, msg *canalJSONMessage, row map[string]string) error {
+	keys := msg.PKNames
+	if len(keys) == 0 {
+		keys = sortedColumns(row)
+	}
+	conds := make([]string, len(keys))
+	args := make([]interface{}, len(keys))
+	for i, key := range keys {
+		conds[i] = quotes.QuoteName(key) + " = ?"
+		args[i] = row[key]
+	}
+	query := "delete from " + quotes.QuoteSchema(msg.Schema, msg.Table) + " where " + strings.Join(conds, " and ")
+	_, err := tx.ExecContext(ctx, query, args...)
+	return errors.AddStack(err)
+}
+
+func sortedColumns(row map[string]string) []string {
+	cols := make([]string, 0, len(row))
+	for col := range row {
+		cols = append(cols, col)
+	}
+	sort.Strings(cols)
+	return cols
+}
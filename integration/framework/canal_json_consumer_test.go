@@ -0,0 +1,71 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyCanalJSONMessage(t *testing.T) {
+	ctx := context.Background()
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+
+	mock.ExpectExec("use `testdb`").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("create table test (id int primary key, value int)").WillReturnResult(sqlmock.NewResult(0, 0))
+	err = applyCanalJSONMessage(ctx, conn, &canalJSONMessage{
+		Schema:    "testdb",
+		Table:     "test",
+		IsDDL:     true,
+		EventType: "CREATE",
+		Query:     "create table test (id int primary key, value int)",
+	})
+	require.NoError(t, err)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("delete from `testdb`.`test` where `id` = ?").
+		WithArgs("1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("replace into `testdb`.`test` (`id`,`value`) values (?,?)").
+		WithArgs("2", "10").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	err = applyCanalJSONMessage(ctx, conn, &canalJSONMessage{
+		Schema:    "testdb",
+		Table:     "test",
+		PKNames:   []string{"id"},
+		EventType: "UPDATE",
+		Data:      []map[string]string{{"id": "2", "value": "10"}},
+		Old:       []map[string]string{{"id": "1", "value": "10"}},
+	})
+	require.NoError(t, err)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("delete from `testdb`.`test` where `id` = ? and `value` = ?").
+		WithArgs("2", "10").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	err = applyCanalJSONMessage(ctx, conn, &canalJSONMessage{
+		Schema:    "testdb",
+		Table:     "test",
+		EventType: "DELETE",
+		Old:       []map[string]string{{"id": "2", "value": "10"}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
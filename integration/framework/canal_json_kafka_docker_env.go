@@ -0,0 +1,82 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/ticdc/pkg/retry"
+)
+
+const (
+	// the address of Kafka for the consumer running on the host
+	kafkaHostAddr = "127.0.0.1:9092"
+	// the address of Kafka inside the docker network
+	kafkaInternalAddr = "kafka:29092"
+)
+
+// CanalJSONKafkaDockerEnv represents the docker-compose service generated from canalJSONComposeConfig,
+// in which the changefeeds write canal-json messages to Kafka, and the messages are applied to the
+// downstream TiDB by a consumer built into the framework.
+type CanalJSONKafkaDockerEnv struct {
+	dockerComposeOperator
+}
+
+// NewCanalJSONKafkaDockerEnv creates a new CanalJSONKafkaDockerEnv
+func NewCanalJSONKafkaDockerEnv(dockerComposeFile string) *CanalJSONKafkaDockerEnv {
+	healthChecker := func() error {
+		err := pingDatabases(upstreamDSN, downstreamDSN)
+		if err != nil {
+			return err
+		}
+
+		client, err := sarama.NewClient([]string{kafkaHostAddr}, sarama.NewConfig())
+		if err != nil {
+			return errors.Annotate(err, "kafka not ready")
+		}
+		return errors.AddStack(client.Close())
+	}
+
+	env := &CanalJSONKafkaDockerEnv{newDockerComposeOperator(dockerComposeFile, canalJSONComposeConfig())}
+	env.controller = controllerContainerName
+	env.healthChecker = healthChecker
+	return env
+}
+
+// Reset implements Environment
+func (e *CanalJSONKafkaDockerEnv) Reset() {
+	e.TearDown()
+	e.Setup()
+}
+
+// RunTest implements Environment
+func (e *CanalJSONKafkaDockerEnv) RunTest(task Task) {
+	runTask(&e.dockerComposeOperator, e, task, func() error {
+		return retry.Run(time.Second, 120, e.healthChecker)
+	})
+}
+
+// RunTests implements Environment
+func (e *CanalJSONKafkaDockerEnv) RunTests(tasks []Task, parallelism int) []TaskResult {
+	return runTasks(&e.dockerComposeOperator, e, tasks, parallelism, func() error {
+		return retry.Run(time.Second, 120, e.healthChecker)
+	})
+}
+
+// SetListener implements Environment. Currently unfinished, will be used to monitor Kafka output
+func (e *CanalJSONKafkaDockerEnv) SetListener(states interface{}, listener MqListener) {
+	// TODO
+}
@@ -0,0 +1,215 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"encoding/json"
+	"os/exec"
+	"sort"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/ticdc/cdc/model"
+	"go.uber.org/zap"
+)
+
+const (
+	// the PD address used by the cdc cli inside the controller container
+	cliPDUri = "http://upstream-pd:2379"
+)
+
+// CDCCluster provides the operations on the TiCDC cluster in the environment
+type CDCCluster interface {
+	ExecCDCCli(args string) ([]byte, error)
+	Captures() ([]CaptureInfo, error)
+	Owner() (*CaptureInfo, error)
+	ResignOwner() error
+	TableDistribution(changefeedID string) (map[string][]model.TableID, error)
+	WaitTableDistribution(changefeedID string, cond func(map[string][]model.TableID) bool, timeout time.Duration) error
+	ChangefeedCheckpoint(changefeedID string) (uint64, error)
+	WaitCheckpoint(changefeedID string, ts uint64, timeout time.Duration) error
+	QueryChangefeedStatus(changefeedID string) (*ChangefeedStatus, error)
+	PauseChangefeed(changefeedID string) error
+	ResumeChangefeed(changefeedID string, atTs uint64) error
+	RemoveChangefeed(changefeedID string) error
+	ScaleCDC(n int) error
+	WaitCaptures(n int, timeout time.Duration) ([]CaptureInfo, error)
+}
+
+// CaptureInfo is the information of a capture reported by the cdc cli
+type CaptureInfo struct {
+	ID            string `json:"id"`
+	IsOwner       bool   `json:"is-owner"`
+	AdvertiseAddr string `json:"address"`
+}
+
+type changefeedQueryResult struct {
+	Info       *model.ChangeFeedInfo   `json:"info"`
+	Status     *model.ChangeFeedStatus `json:"status"`
+	TaskStatus []struct {
+		CaptureID  string            `json:"capture-id"`
+		TaskStatus *model.TaskStatus `json:"status"`
+	} `json:"task-status"`
+}
+
+// ExecCDCCli runs the cdc cli with the given arguments in the controller container
+// and returns its stdout.
+func (d *dockerComposeOperator) ExecCDCCli(args string) ([]byte, error) {
+	pdArgs := "--pd=" + cliPDUri
+	if d.tls {
+		pdArgs = "--pd=" + tlsCLIPDUri + " --ca=" + tlsContainerCAPath +
+			" --cert=" + tlsContainerClientCertPath + " --key=" + tlsContainerClientKeyPath
+	}
+	bytes, err := d.ExecInController("/cdc cli " + args + " " + pdArgs)
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return bytes, errors.Annotatef(err, "cdc cli %s failed: %s", args, exitErr.Stderr)
+		}
+		return bytes, errors.AddStack(err)
+	}
+	return bytes, nil
+}
+
+// Captures returns all the captures in the cluster
+func (d *dockerComposeOperator) Captures() ([]CaptureInfo, error) {
+	bytes, err := d.ExecCDCCli("capture list")
+	if err != nil {
+		return nil, err
+	}
+	var captures []CaptureInfo
+	err = json.Unmarshal(bytes, &captures)
+	if err != nil {
+		return nil, errors.Annotatef(err, "invalid capture list: %s", bytes)
+	}
+	sort.Slice(captures, func(i, j int) bool { return captures[i].ID < captures[j].ID })
+	return captures, nil
+}
+
+// Owner returns the owner capture of the cluster
+func (d *dockerComposeOperator) Owner() (*CaptureInfo, error) {
+	captures, err := d.Captures()
+	if err != nil {
+		return nil, err
+	}
+	for i := range captures {
+		if captures[i].IsOwner {
+			return &captures[i], nil
+		}
+	}
+	return nil, errors.New("no owner found")
+}
+
+// ResignOwner asks the current owner to resign, the owner will be re-elected among all captures
+func (d *dockerComposeOperator) ResignOwner() error {
+	owner, err := d.Owner()
+	if err != nil {
+		return err
+	}
+	curlCmd := "curl -sf -X POST http://" + owner.AdvertiseAddr + "/capture/owner/resign"
+	if d.tls {
+		curlCmd = "curl -sf -X POST --cacert " + tlsContainerCAPath + " --cert " + tlsContainerClientCertPath +
+			" --key " + tlsContainerClientKeyPath + " https://" + owner.AdvertiseAddr + "/capture/owner/resign"
+	}
+	_, err = d.ExecInController(curlCmd)
+	return errors.Annotatef(err, "failed to resign owner %s", owner.ID)
+}
+
+func (d *dockerComposeOperator) queryChangefeed(changefeedID string) (*changefeedQueryResult, error) {
+	bytes, err := d.ExecCDCCli("changefeed query --changefeed-id=" + changefeedID)
+	if err != nil {
+		return nil, err
+	}
+	result := new(changefeedQueryResult)
+	err = json.Unmarshal(bytes, result)
+	if err != nil {
+		return nil, errors.Annotatef(err, "invalid changefeed query result: %s", bytes)
+	}
+	return result, nil
+}
+
+// TableDistribution returns the tables replicated by each capture in the changefeed.
+// Captures without tables are not included.
+func (d *dockerComposeOperator) TableDistribution(changefeedID string) (map[string][]model.TableID, error) {
+	result, err := d.queryChangefeed(changefeedID)
+	if err != nil {
+		return nil, err
+	}
+	distribution := make(map[string][]model.TableID, len(result.TaskStatus))
+	for _, task := range result.TaskStatus {
+		if task.TaskStatus == nil || len(task.TaskStatus.Tables) == 0 {
+			continue
+		}
+		tables := make([]model.TableID, 0, len(task.TaskStatus.Tables))
+		for tableID := range task.TaskStatus.Tables {
+			tables = append(tables, tableID)
+		}
+		sort.Slice(tables, func(i, j int) bool { return tables[i] < tables[j] })
+		distribution[task.CaptureID] = tables
+	}
+	return distribution, nil
+}
+
+// WaitTableDistribution waits until the tables replicated by the captures in the
+// changefeed satisfy cond.
+func (d *dockerComposeOperator) WaitTableDistribution(
+	changefeedID string, cond func(map[string][]model.TableID) bool, timeout time.Duration,
+) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		distribution, err := d.TableDistribution(changefeedID)
+		if err != nil {
+			log.Debug("failed to get the table distribution", zap.Error(err))
+		} else if cond(distribution) {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return errors.Errorf("table distribution of changefeed %s is not as expected after %s, last: %v",
+				changefeedID, timeout, distribution)
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// ChangefeedCheckpoint returns the checkpoint ts of the changefeed
+func (d *dockerComposeOperator) ChangefeedCheckpoint(changefeedID string) (uint64, error) {
+	result, err := d.queryChangefeed(changefeedID)
+	if err != nil {
+		return 0, err
+	}
+	if result.Status == nil {
+		return 0, errors.Errorf("changefeed %s has no status yet", changefeedID)
+	}
+	return result.Status.CheckpointTs, nil
+}
+
+// WaitCheckpoint waits until the checkpoint ts of the changefeed reaches ts
+func (d *dockerComposeOperator) WaitCheckpoint(changefeedID string, ts uint64, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	var checkpoint uint64
+	for {
+		var err error
+		checkpoint, err = d.ChangefeedCheckpoint(changefeedID)
+		if err != nil {
+			log.Debug("failed to get the checkpoint", zap.Error(err))
+		} else if checkpoint >= ts {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return errors.Errorf("checkpoint %d of changefeed %s doesn't reach %d after %s",
+				checkpoint, changefeedID, ts, timeout)
+		}
+		time.Sleep(time.Second)
+	}
+}
@@ -0,0 +1,229 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/ticdc/cdc/model"
+	"go.uber.org/zap"
+)
+
+const (
+	// the service whose configuration the captures started by ScaleCDC copy
+	scaleTemplateService = "capturer0"
+	// the prefix of the names of the containers started by ScaleCDC, which are
+	// also their host names in the docker network
+	scaledCapturePrefix = "capturer-scaled-"
+	// maxScaledCaptures is the max number of captures started by ScaleCDC,
+	// the TLS certificates are only valid for these host names
+	maxScaledCaptures = 4
+	// scaleTimeout is how long ScaleCDC waits for the captures to join or leave
+	scaleTimeout = 2 * time.Minute
+)
+
+func scaledCaptureName(index int) string {
+	return fmt.Sprintf("%s%d", scaledCapturePrefix, index)
+}
+
+// ScaleCDC starts or stops captures until there are n captures in the cluster,
+// and waits for them to join or leave. New captures are started in containers
+// with the same configuration as capturer0, and the captures started by ScaleCDC
+// are stopped before the ones defined in the docker-compose file. The owner is
+// stopped last. The captures are stopped gracefully, so their tables are moved
+// to the other captures.
+func (d *dockerComposeOperator) ScaleCDC(n int) error {
+	if n < 1 {
+		return errors.Errorf("cannot scale the cluster to %d captures", n)
+	}
+	captures, err := d.Captures()
+	if err != nil {
+		return err
+	}
+	if n > len(captures) {
+		for i := len(captures); i < n; i++ {
+			err := d.startScaledCapture()
+			if err != nil {
+				return err
+			}
+		}
+	} else if n < len(captures) {
+		for _, capture := range scaleInOrder(captures)[:len(captures)-n] {
+			err := d.stopCapture(capture)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	_, err = d.WaitCaptures(n, scaleTimeout)
+	return err
+}
+
+// scaleInOrder returns the captures in the order to be stopped
+func scaleInOrder(captures []CaptureInfo) []CaptureInfo {
+	ordered := make([]CaptureInfo, len(captures))
+	copy(ordered, captures)
+	rank := func(c CaptureInfo) int {
+		switch {
+		case c.IsOwner:
+			return 2
+		case strings.HasPrefix(c.AdvertiseAddr, scaledCapturePrefix):
+			return 0
+		default:
+			return 1
+		}
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		ri, rj := rank(ordered[i]), rank(ordered[j])
+		if ri != rj {
+			return ri < rj
+		}
+		// stop the latest scaled capture first
+		return ordered[i].AdvertiseAddr > ordered[j].AdvertiseAddr
+	})
+	return ordered
+}
+
+func (d *dockerComposeOperator) startScaledCapture() error {
+	index := -1
+	for i := 0; i < maxScaledCaptures; i++ {
+		if _, ok := d.scaledCaptures[scaledCaptureName(i)]; !ok {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return errors.Errorf("cannot start more than %d captures with ScaleCDC", maxScaledCaptures)
+	}
+	name := scaledCaptureName(index)
+	pdURI := cliPDUri
+	var tlsArgs []string
+	if d.tls {
+		pdURI = tlsCLIPDUri
+		tlsArgs = []string{"--ca=" + tlsContainerCAPath, "--cert=/tls/" + ServerCertFileName, "--key=/tls/" + ServerKeyFileName}
+	}
+	args := []string{"-f", d.fileName, "run", "-d", "--no-deps", "--name", name, scaleTemplateService,
+		"--addr=0.0.0.0:8300",
+		"--pd=" + pdURI,
+		"--log-file=/logs/" + name + ".log",
+		"--log-level=debug",
+		"--advertise-addr=" + name + ":8300",
+	}
+	_, err := runCmd(exec.Command("docker-compose", append(args, tlsArgs...)...))
+	if err != nil {
+		return err
+	}
+	if d.scaledCaptures == nil {
+		d.scaledCaptures = make(map[string]struct{})
+	}
+	d.scaledCaptures[name] = struct{}{}
+	log.Info("capture started", zap.String("name", name))
+	return nil
+}
+
+func (d *dockerComposeOperator) stopCapture(capture CaptureInfo) error {
+	host := capture.AdvertiseAddr
+	if i := strings.LastIndex(host, ":"); i >= 0 {
+		host = host[:i]
+	}
+	if _, ok := d.scaledCaptures[host]; ok {
+		_, err := runCmd(exec.Command("docker", "stop", host))
+		if err != nil {
+			return err
+		}
+		_, err = runCmd(exec.Command("docker", "rm", "-v", host))
+		if err != nil {
+			return err
+		}
+		delete(d.scaledCaptures, host)
+		log.Info("capture stopped", zap.String("name", host))
+		return nil
+	}
+	// the host name of a capture defined in the docker-compose file is its service name
+	return d.runCompose("stop", host)
+}
+
+// removeScaledCaptures removes the containers started by ScaleCDC, which are
+// not managed by docker-compose
+func (d *dockerComposeOperator) removeScaledCaptures() {
+	for name := range d.scaledCaptures {
+		_, err := runCmd(exec.Command("docker", "rm", "-f", "-v", name))
+		if err != nil {
+			log.Warn("failed to remove capture", zap.String("name", name), zap.Error(err))
+		}
+	}
+	d.scaledCaptures = nil
+}
+
+// WaitCaptures waits until there are n captures in the cluster and returns them
+func (d *dockerComposeOperator) WaitCaptures(n int, timeout time.Duration) ([]CaptureInfo, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		captures, err := d.Captures()
+		if err != nil {
+			log.Debug("failed to list the captures", zap.Error(err))
+		} else if len(captures) == n {
+			return captures, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, errors.Errorf("the cluster doesn't have %d captures after %s, last: %v", n, timeout, captures)
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// TablesBalanced returns a condition for WaitTableDistribution which holds if
+// the tables are replicated by exactly captureNum captures, and the numbers of
+// the tables replicated by the captures differ by at most one.
+func TablesBalanced(captureNum int) func(map[string][]model.TableID) bool {
+	return func(distribution map[string][]model.TableID) bool {
+		if len(distribution) != captureNum {
+			return false
+		}
+		min, max := -1, 0
+		for _, tables := range distribution {
+			if min < 0 || len(tables) < min {
+				min = len(tables)
+			}
+			if len(tables) > max {
+				max = len(tables)
+			}
+		}
+		return max-min <= 1
+	}
+}
+
+// TablesDrainedFrom returns a condition for WaitTableDistribution which holds if
+// none of the captures replicates any table, and the tables replicated by all
+// the captures add up to tableNum.
+func TablesDrainedFrom(tableNum int, captureIDs ...string) func(map[string][]model.TableID) bool {
+	return func(distribution map[string][]model.TableID) bool {
+		for _, id := range captureIDs {
+			if len(distribution[id]) > 0 {
+				return false
+			}
+		}
+		total := 0
+		for _, tables := range distribution {
+			total += len(tables)
+		}
+		return total == tableNum
+	}
+}
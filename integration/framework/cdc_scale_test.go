@@ -0,0 +1,54 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"testing"
+
+	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScaleInOrder(t *testing.T) {
+	captures := []CaptureInfo{
+		{ID: "a", AdvertiseAddr: "capturer0:8300", IsOwner: true},
+		{ID: "b", AdvertiseAddr: "capturer1:8300"},
+		{ID: "c", AdvertiseAddr: "capturer-scaled-0:8300"},
+		{ID: "d", AdvertiseAddr: "capturer-scaled-1:8300"},
+	}
+	ordered := scaleInOrder(captures)
+	ids := make([]string, 0, len(ordered))
+	for _, capture := range ordered {
+		ids = append(ids, capture.ID)
+	}
+	require.Equal(t, []string{"d", "c", "b", "a"}, ids)
+	// the input is not changed
+	require.Equal(t, "a", captures[0].ID)
+}
+
+func TestTableDistributionConditions(t *testing.T) {
+	distribution := map[string][]model.TableID{
+		"a": {1, 2, 3},
+		"b": {4, 5},
+		"c": {6, 7},
+	}
+	require.True(t, TablesBalanced(3)(distribution))
+	require.False(t, TablesBalanced(4)(distribution))
+	distribution["a"] = append(distribution["a"], 8)
+	require.False(t, TablesBalanced(3)(distribution))
+
+	require.True(t, TablesDrainedFrom(8, "d")(distribution))
+	require.False(t, TablesDrainedFrom(8, "c")(distribution))
+	require.False(t, TablesDrainedFrom(9, "d")(distribution))
+}
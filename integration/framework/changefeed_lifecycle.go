@@ -0,0 +1,256 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/pkg/config"
+	"go.uber.org/zap"
+)
+
+const (
+	// changefeedAdminTimeout is how long to wait for the owner to handle an admin job
+	changefeedAdminTimeout = 30 * time.Second
+)
+
+// changefeedIDRe matches the ID printed by `cdc cli changefeed create`
+var changefeedIDRe = regexp.MustCompile(`(?m)^ID: (\S+)$`)
+
+// ChangefeedStatus is the status of a changefeed reported by the cdc cli
+type ChangefeedStatus struct {
+	State        model.FeedState
+	CheckpointTs uint64
+	ResolvedTs   uint64
+	// Error is the last error of the changefeed, it's nil if the changefeed has no error
+	Error *model.RunningError
+}
+
+// QueryChangefeedStatus returns the status of the changefeed, the State of a
+// changefeed which doesn't exist is model.StateRemoved.
+func (d *dockerComposeOperator) QueryChangefeedStatus(changefeedID string) (*ChangefeedStatus, error) {
+	result, err := d.queryChangefeed(changefeedID)
+	if err != nil {
+		return nil, err
+	}
+	return result.changefeedStatus(), nil
+}
+
+// changefeedStatus derives the state of the changefeed from the admin job type
+// of its status, in the same way as the owner does.
+func (r *changefeedQueryResult) changefeedStatus() *ChangefeedStatus {
+	status := &ChangefeedStatus{State: model.StateNormal}
+	if r.Info == nil {
+		status.State = model.StateRemoved
+	} else {
+		status.Error = r.Info.Error
+	}
+	if r.Status == nil {
+		return status
+	}
+	status.CheckpointTs = r.Status.CheckpointTs
+	status.ResolvedTs = r.Status.ResolvedTs
+	switch r.Status.AdminJobType {
+	case model.AdminNone, model.AdminResume:
+		if status.Error != nil && status.State == model.StateNormal {
+			status.State = model.StateFailed
+		}
+	case model.AdminStop:
+		status.State = model.StateStopped
+	case model.AdminRemove:
+		status.State = model.StateRemoved
+	case model.AdminFinish:
+		status.State = model.StateFinished
+	}
+	return status
+}
+
+// waitChangefeedState waits until the changefeed is in the state
+func (d *dockerComposeOperator) waitChangefeedState(changefeedID string, state model.FeedState) error {
+	deadline := time.Now().Add(changefeedAdminTimeout)
+	var last model.FeedState
+	for {
+		status, err := d.QueryChangefeedStatus(changefeedID)
+		if err != nil {
+			log.Debug("failed to query the changefeed", zap.Error(err))
+		} else if status.State == state {
+			return nil
+		} else {
+			last = status.State
+		}
+		if time.Now().After(deadline) {
+			return errors.Errorf("changefeed %s is not %s after %s, last state: %s",
+				changefeedID, state, changefeedAdminTimeout, last)
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// PauseChangefeed pauses the changefeed and waits until it's stopped
+func (d *dockerComposeOperator) PauseChangefeed(changefeedID string) error {
+	_, err := d.ExecCDCCli("changefeed pause --changefeed-id=" + changefeedID)
+	if err != nil {
+		return err
+	}
+	return d.waitChangefeedState(changefeedID, model.StateStopped)
+}
+
+// ResumeChangefeed resumes the paused changefeed from its checkpoint if atTs is 0,
+// and waits until it's running. The cdc cli can't resume a changefeed from another
+// ts, so if atTs is not 0, the changefeed is removed and created again with the
+// same ID, sink URI and configuration, starting at atTs.
+func (d *dockerComposeOperator) ResumeChangefeed(changefeedID string, atTs uint64) error {
+	if atTs == 0 {
+		_, err := d.ExecCDCCli("changefeed resume --changefeed-id=" + changefeedID)
+		if err != nil {
+			return err
+		}
+		return d.waitChangefeedState(changefeedID, model.StateNormal)
+	}
+
+	result, err := d.queryChangefeed(changefeedID)
+	if err != nil {
+		return err
+	}
+	if result.Info == nil {
+		return errors.Errorf("changefeed %s doesn't exist", changefeedID)
+	}
+	args, err := d.recreateChangefeedArgs(changefeedID, result.Info, atTs)
+	if err != nil {
+		return err
+	}
+	_, err = d.ExecCDCCli("changefeed remove --force --changefeed-id=" + changefeedID)
+	if err != nil {
+		return err
+	}
+	err = d.waitChangefeedState(changefeedID, model.StateRemoved)
+	if err != nil {
+		return err
+	}
+	_, err = d.ExecCDCCli(args)
+	if err != nil {
+		return err
+	}
+	log.Info("Changefeed recreated", zap.String("changefeed", changefeedID), zap.Uint64("startTs", atTs))
+	return d.waitChangefeedState(changefeedID, model.StateNormal)
+}
+
+// recreateChangefeedArgs writes the configuration of the changefeed into the controller
+// container, and returns the arguments of the cdc cli creating the changefeed from atTs.
+func (d *dockerComposeOperator) recreateChangefeedArgs(
+	changefeedID string, info *model.ChangeFeedInfo, atTs uint64,
+) (string, error) {
+	args := []string{
+		"changefeed create --no-confirm",
+		"--changefeed-id=" + changefeedID,
+		"--start-ts=" + fmt.Sprint(atTs),
+		"--sink-uri=" + shellQuote(info.SinkURI),
+		"--sort-engine=" + shellQuote(string(info.Engine)),
+		"--sort-dir=" + shellQuote(info.SortDir),
+	}
+	if info.TargetTs != 0 {
+		args = append(args, "--target-ts="+fmt.Sprint(info.TargetTs))
+	}
+	if info.Config != nil {
+		data, err := encodeReplicaConfig(info.Config)
+		if err != nil {
+			return "", err
+		}
+		path := changefeedConfigDir + "/changefeed-" + changefeedID + "-resume.toml"
+		err = d.writeInController(path, data)
+		if err != nil {
+			return "", err
+		}
+		args = append(args, "--config="+path)
+	}
+	opts := make([]string, 0, len(info.Opts))
+	for k, v := range info.Opts {
+		opts = append(opts, "--opts="+shellQuote(k+"="+v))
+	}
+	sort.Strings(opts)
+	return strings.Join(append(args, opts...), " "), nil
+}
+
+// encodeReplicaConfig encodes the configuration in the format of the file passed to
+// `cdc cli changefeed create --config`
+func encodeReplicaConfig(cfg *config.ReplicaConfig) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	err := toml.NewEncoder(buf).Encode(cfg)
+	if err != nil {
+		return nil, errors.AddStack(err)
+	}
+	return buf.Bytes(), nil
+}
+
+// RemoveChangefeed removes the changefeed and waits until it's removed
+func (d *dockerComposeOperator) RemoveChangefeed(changefeedID string) error {
+	_, err := d.ExecCDCCli("changefeed remove --changefeed-id=" + changefeedID)
+	if err != nil {
+		return err
+	}
+	return d.waitChangefeedState(changefeedID, model.StateRemoved)
+}
+
+// writeInController writes data to the file at path in the controller container
+func (d *dockerComposeOperator) writeInController(path string, data []byte) error {
+	cmd := exec.Command("docker", "exec", "-i", d.controller, "sh", "-c", "cat > "+shellQuote(path))
+	cmd.Stdin = bytes.NewReader(data)
+	_, err := runCmd(cmd)
+	return errors.Annotatef(err, "failed to write %s in the controller container", path)
+}
+
+// parseChangefeedID returns the ID of the changefeed in the output of
+// `cdc cli changefeed create`, or an empty string if there is none.
+func parseChangefeedID(out []byte) string {
+	match := changefeedIDRe.FindSubmatch(out)
+	if match == nil {
+		return ""
+	}
+	return string(match[1])
+}
+
+// ChangefeedID returns the ID of the changefeed created for the task
+func (c *TaskContext) ChangefeedID() string {
+	return c.changefeedID
+}
+
+// QueryChangefeedStatus returns the status of the changefeed of the task
+func (c *TaskContext) QueryChangefeedStatus() (*ChangefeedStatus, error) {
+	return c.docker.QueryChangefeedStatus(c.changefeedID)
+}
+
+// PauseChangefeed pauses the changefeed of the task, see CDCCluster.PauseChangefeed
+func (c *TaskContext) PauseChangefeed() error {
+	return c.docker.PauseChangefeed(c.changefeedID)
+}
+
+// ResumeChangefeed resumes the changefeed of the task, see CDCCluster.ResumeChangefeed
+func (c *TaskContext) ResumeChangefeed(atTs uint64) error {
+	return c.docker.ResumeChangefeed(c.changefeedID, atTs)
+}
+
+// RemoveChangefeed removes the changefeed of the task, see CDCCluster.RemoveChangefeed
+func (c *TaskContext) RemoveChangefeed() error {
+	return c.docker.RemoveChangefeed(c.changefeedID)
+}
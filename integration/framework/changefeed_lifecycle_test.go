@@ -0,0 +1,67 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseChangefeedID(t *testing.T) {
+	out := []byte("Create changefeed successfully!\nID: 0bd4f0a8-1f2a-4b7e-9a43-6d6c4a7c0e1b\nInfo: {\"sink-uri\":\"mysql://root@downstream-mysql:3306/\"}\n")
+	require.Equal(t, "0bd4f0a8-1f2a-4b7e-9a43-6d6c4a7c0e1b", parseChangefeedID(out))
+	require.Equal(t, "", parseChangefeedID([]byte("Error: [CDC:ErrPDEtcdAPIError]")))
+}
+
+func TestChangefeedStatus(t *testing.T) {
+	result := &changefeedQueryResult{}
+	require.Equal(t, model.StateRemoved, result.changefeedStatus().State)
+
+	result.Info = &model.ChangeFeedInfo{}
+	require.Equal(t, model.StateNormal, result.changefeedStatus().State)
+
+	result.Status = &model.ChangeFeedStatus{CheckpointTs: 10, ResolvedTs: 20, AdminJobType: model.AdminStop}
+	status := result.changefeedStatus()
+	require.Equal(t, model.StateStopped, status.State)
+	require.Equal(t, uint64(10), status.CheckpointTs)
+	require.Equal(t, uint64(20), status.ResolvedTs)
+
+	result.Status.AdminJobType = model.AdminResume
+	result.Info.Error = &model.RunningError{Code: "CDC:ErrSinkURIInvalid"}
+	status = result.changefeedStatus()
+	require.Equal(t, model.StateFailed, status.State)
+	require.Equal(t, "CDC:ErrSinkURIInvalid", status.Error.Code)
+
+	result.Info = nil
+	result.Status.AdminJobType = model.AdminRemove
+	require.Equal(t, model.StateRemoved, result.changefeedStatus().State)
+}
+
+func TestEncodeReplicaConfig(t *testing.T) {
+	cfg := config.GetDefaultReplicaConfig()
+	cfg.Filter.Rules = []string{"testdb.*"}
+	cfg.EnableOldValue = true
+	data, err := encodeReplicaConfig(cfg)
+	require.NoError(t, err)
+
+	decoded := config.GetDefaultReplicaConfig()
+	meta, err := toml.Decode(string(data), decoded)
+	require.NoError(t, err)
+	require.Empty(t, meta.Undecoded())
+	require.Equal(t, cfg, decoded)
+}
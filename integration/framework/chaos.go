@@ -0,0 +1,243 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"go.uber.org/zap"
+)
+
+// the image used to manipulate iptables in the network namespace of a container
+const networkChaosImage = "nicolaka/netshoot"
+
+// ChaosOperator injects faults into the services of the environment. The services
+// are the names in the docker-compose file, such as "capturer0" and "kafka".
+type ChaosOperator interface {
+	// PauseService freezes all processes of the service
+	PauseService(service string) error
+	// UnpauseService resumes the processes paused by PauseService
+	UnpauseService(service string) error
+	// KillService kills the service with SIGKILL
+	KillService(service string) error
+	// StartService starts the service killed by KillService
+	StartService(service string) error
+	// RestartService stops the service gracefully and starts it again
+	RestartService(service string) error
+	// PartitionNetwork drops all packets sent from one service to another
+	PartitionNetwork(from string, to string) error
+	// HealNetwork removes the partition created by PartitionNetwork
+	HealNetwork(from string, to string) error
+}
+
+// PauseService implements ChaosOperator
+func (d *dockerComposeOperator) PauseService(service string) error {
+	return d.runCompose("pause", service)
+}
+
+// UnpauseService implements ChaosOperator
+func (d *dockerComposeOperator) UnpauseService(service string) error {
+	return d.runCompose("unpause", service)
+}
+
+// KillService implements ChaosOperator
+func (d *dockerComposeOperator) KillService(service string) error {
+	return d.runCompose("kill", service)
+}
+
+// StartService implements ChaosOperator
+func (d *dockerComposeOperator) StartService(service string) error {
+	return d.runCompose("start", service)
+}
+
+// RestartService implements ChaosOperator
+func (d *dockerComposeOperator) RestartService(service string) error {
+	return d.runCompose("restart", service)
+}
+
+// PartitionNetwork implements ChaosOperator
+func (d *dockerComposeOperator) PartitionNetwork(from string, to string) error {
+	return d.setNetworkPartition(from, to, "-A")
+}
+
+// HealNetwork implements ChaosOperator
+func (d *dockerComposeOperator) HealNetwork(from string, to string) error {
+	return d.setNetworkPartition(from, to, "-D")
+}
+
+func (d *dockerComposeOperator) setNetworkPartition(from string, to string, op string) error {
+	fromID, err := d.containerID(from)
+	if err != nil {
+		return err
+	}
+	toID, err := d.containerID(to)
+	if err != nil {
+		return err
+	}
+	out, err := runCmd(exec.Command("docker", "inspect", "-f",
+		"{{range .NetworkSettings.Networks}}{{.IPAddress}} {{end}}", toID))
+	if err != nil {
+		return err
+	}
+	for _, ip := range strings.Fields(string(out)) {
+		_, err := runCmd(exec.Command("docker", "run", "--rm", "--net", "container:"+fromID, "--cap-add", "NET_ADMIN",
+			networkChaosImage, "iptables", op, "OUTPUT", "-d", ip, "-j", "DROP"))
+		if err != nil {
+			return err
+		}
+	}
+	log.Info("network partition changed", zap.String("from", from), zap.String("to", to), zap.String("op", op))
+	return nil
+}
+
+func (d *dockerComposeOperator) containerID(service string) (string, error) {
+	out, err := runCmd(exec.Command("docker-compose", "-f", d.fileName, "ps", "-q", service))
+	if err != nil {
+		return "", err
+	}
+	id := strings.TrimSpace(string(out))
+	if id == "" {
+		return "", errors.Errorf("no container found for service %s", service)
+	}
+	return id, nil
+}
+
+func (d *dockerComposeOperator) runCompose(op string, service string) error {
+	_, err := runCmd(exec.Command("docker-compose", "-f", d.fileName, op, service))
+	if err == nil {
+		log.Info("service changed by chaos operator", zap.String("op", op), zap.String("service", service))
+	}
+	return err
+}
+
+// runCmd runs the command and returns its stdout, unlike runCmdHandleError it
+// returns the error instead of aborting the test.
+func runCmd(cmd *exec.Cmd) ([]byte, error) {
+	bytes, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return bytes, errors.Annotatef(err, "%s failed: %s", cmd.String(), exitErr.Stderr)
+		}
+		return bytes, errors.Annotatef(err, "%s failed", cmd.String())
+	}
+	return bytes, nil
+}
+
+// ChaosEvent is a fault injected into the environment after a delay, and recovered
+// after a duration.
+type ChaosEvent struct {
+	Name string
+	// After is the delay before the fault is injected
+	After time.Duration
+	// Duration is how long the fault lasts, Recover is not called if it's 0
+	Duration time.Duration
+	Inject   func() error
+	Recover  func() error
+}
+
+// KillServiceEvent kills the service after the delay, and starts it again after the downtime
+func KillServiceEvent(chaos ChaosOperator, service string, after time.Duration, downtime time.Duration) ChaosEvent {
+	return ChaosEvent{
+		Name:     "kill " + service,
+		After:    after,
+		Duration: downtime,
+		Inject:   func() error { return chaos.KillService(service) },
+		Recover:  func() error { return chaos.StartService(service) },
+	}
+}
+
+// PauseServiceEvent pauses the service after the delay for the duration
+func PauseServiceEvent(chaos ChaosOperator, service string, after time.Duration, duration time.Duration) ChaosEvent {
+	return ChaosEvent{
+		Name:     "pause " + service,
+		After:    after,
+		Duration: duration,
+		Inject:   func() error { return chaos.PauseService(service) },
+		Recover:  func() error { return chaos.UnpauseService(service) },
+	}
+}
+
+// PartitionNetworkEvent partitions the network between two services after the delay for the duration
+func PartitionNetworkEvent(chaos ChaosOperator, from string, to string, after time.Duration, duration time.Duration) ChaosEvent {
+	return ChaosEvent{
+		Name:     "partition " + from + " -> " + to,
+		After:    after,
+		Duration: duration,
+		Inject:   func() error { return chaos.PartitionNetwork(from, to) },
+		Recover:  func() error { return chaos.HealNetwork(from, to) },
+	}
+}
+
+// ScheduleChaos runs the chaos events in the background, and returns a function
+// that waits for all the events to be recovered and returns the first error.
+// The events not injected yet are skipped when the task context is canceled.
+func (c *TaskContext) ScheduleChaos(events ...ChaosEvent) (wait func() error) {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	setErr := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	for _, event := range events {
+		event := event
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if !sleepWithContext(c.Ctx, event.After) {
+				return
+			}
+			log.Info("injecting chaos", zap.String("event", event.Name))
+			if err := event.Inject(); err != nil {
+				setErr(errors.Annotatef(err, "failed to inject %s", event.Name))
+				return
+			}
+			if event.Duration == 0 || event.Recover == nil {
+				return
+			}
+			// the fault is always recovered to leave the environment usable
+			time.Sleep(event.Duration)
+			log.Info("recovering chaos", zap.String("event", event.Name))
+			if err := event.Recover(); err != nil {
+				setErr(errors.Annotatef(err, "failed to recover %s", event.Name))
+			}
+		}()
+	}
+	return func() error {
+		wg.Wait()
+		return firstErr
+	}
+}
+
+func sleepWithContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingChaosOperator struct {
+	ChaosOperator
+	mu  sync.Mutex
+	ops []string
+}
+
+func (r *recordingChaosOperator) record(op string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ops = append(r.ops, op)
+	return nil
+}
+
+func (r *recordingChaosOperator) KillService(service string) error {
+	return r.record("kill " + service)
+}
+
+func (r *recordingChaosOperator) StartService(service string) error {
+	return r.record("start " + service)
+}
+
+func (r *recordingChaosOperator) PauseService(service string) error {
+	return errors.New("pause failed")
+}
+
+func TestScheduleChaos(t *testing.T) {
+	chaos := &recordingChaosOperator{}
+	ctx := &TaskContext{Ctx: context.Background()}
+	wait := ctx.ScheduleChaos(
+		KillServiceEvent(chaos, "capturer0", 10*time.Millisecond, 10*time.Millisecond),
+		KillServiceEvent(chaos, "kafka", 50*time.Millisecond, 0),
+	)
+	require.NoError(t, wait())
+	require.Equal(t, []string{"kill capturer0", "start capturer0", "kill kafka"}, chaos.ops)
+
+	wait = ctx.ScheduleChaos(PauseServiceEvent(chaos, "kafka", 0, time.Second))
+	require.Error(t, wait())
+
+	// the events not injected yet are skipped after the context is canceled
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	ctx = &TaskContext{Ctx: cancelCtx}
+	wait = ctx.ScheduleChaos(KillServiceEvent(chaos, "capturer1", time.Hour, 0))
+	cancel()
+	require.NoError(t, wait())
+	require.Len(t, chaos.ops, 3)
+}
@@ -0,0 +1,843 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"text/template"
+
+	"github.com/integralist/go-findroot/find"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"go.uber.org/zap"
+)
+
+const (
+	// DownstreamTiDB replicates to a downstream TiDB cluster, see ComposeConfig.Downstream
+	DownstreamTiDB = "tidb"
+	// DownstreamMySQL replicates to a downstream MySQL, see ComposeConfig.Downstream
+	DownstreamMySQL = "mysql"
+
+	defaultTiDBVersion   = "nightly"
+	defaultKafkaVersion  = "5.5.1"
+	defaultMySQLVersion  = "5.7"
+	defaultPulsarVersion = "2.6.1"
+
+	// the number of TiKV stores in every TiDB cluster
+	composeTiKVNum = 3
+	// the host port of the first Kafka broker, the others use the following ports
+	composeKafkaHostPort = 9092
+)
+
+// ComposeConfig describes a docker-compose environment, the docker-compose file of
+// an environment is generated from it when the environment is set up, see
+// Environment.OverrideCompose.
+type ComposeConfig struct {
+	// Name is used in the name of the generated file, docker-compose-<Name>.yml
+	Name string
+	// TiDBVersion is the tag of the PD, TiKV and TiDB images
+	TiDBVersion string
+	// KafkaVersion is the tag of the Confluent Platform images
+	KafkaVersion string
+	// SchemaRegistryVersion is the tag of the schema registry image, it's KafkaVersion if it's empty
+	SchemaRegistryVersion string
+	// MySQLVersion is the tag of the MySQL image if Downstream is DownstreamMySQL
+	MySQLVersion string
+	// PulsarVersion is the tag of the Pulsar image if Pulsar is true
+	PulsarVersion string
+	// KafkaBrokers is the number of Kafka brokers, there is no Kafka if it's 0
+	KafkaBrokers int
+	// SchemaRegistry indicates whether to run the Confluent schema registry
+	SchemaRegistry bool
+	// KafkaConnect indicates whether to run Kafka Connect with the JDBC sink
+	KafkaConnect bool
+	// Pulsar indicates whether to run a standalone Pulsar broker and a proxy for
+	// the clients on the host
+	Pulsar bool
+	// Captures is the number of captures started with the environment
+	Captures int
+	// TLS indicates whether all the components use TLS, see TLSDockerEnv
+	TLS bool
+	// NewCollation enables the new collation framework in the TiDB clusters, which
+	// supports the collations like utf8mb4_general_ci
+	NewCollation bool
+	// SortDirSize limits the size of the tmpfs mounted at the sort dir of the captures,
+	// like "32m", there is no tmpfs if it's empty, see SorterDiskFullDockerEnv
+	SortDirSize string
+	// Kerberos indicates whether to run a KDC, with which the captures authenticate
+	// to Kafka with SASL/GSSAPI, see KerberosDockerEnv
+	Kerberos bool
+	// Downstream is DownstreamTiDB or DownstreamMySQL
+	Downstream string
+}
+
+func newComposeConfig(name string) *ComposeConfig {
+	return &ComposeConfig{
+		Name:          name,
+		TiDBVersion:   defaultTiDBVersion,
+		KafkaVersion:  defaultKafkaVersion,
+		MySQLVersion:  defaultMySQLVersion,
+		PulsarVersion: defaultPulsarVersion,
+		Captures:      3,
+		Downstream:    DownstreamTiDB,
+	}
+}
+
+func avroComposeConfig() *ComposeConfig {
+	c := newComposeConfig("avro")
+	c.KafkaBrokers = 1
+	c.SchemaRegistry = true
+	c.KafkaConnect = true
+	return c
+}
+
+func tlsComposeConfig() *ComposeConfig {
+	c := avroComposeConfig()
+	c.Name = "tls"
+	c.TLS = true
+	return c
+}
+
+func canalJSONComposeConfig() *ComposeConfig {
+	c := newComposeConfig("canal")
+	c.KafkaBrokers = 1
+	return c
+}
+
+func pulsarComposeConfig() *ComposeConfig {
+	c := newComposeConfig("pulsar")
+	c.Pulsar = true
+	return c
+}
+
+func kerberosComposeConfig() *ComposeConfig {
+	c := canalJSONComposeConfig()
+	c.Name = "kerberos"
+	c.Kerberos = true
+	return c
+}
+
+func exoticComposeConfig() *ComposeConfig {
+	c := newComposeConfig("exotic")
+	c.NewCollation = true
+	return c
+}
+
+func sorterDiskFullComposeConfig() *ComposeConfig {
+	c := newComposeConfig("sorter")
+	c.Downstream = DownstreamMySQL
+	c.SortDirSize = defaultSortDirSize
+	return c
+}
+
+func mysqlComposeConfig() *ComposeConfig {
+	c := newComposeConfig("mysql")
+	c.Downstream = DownstreamMySQL
+	return c
+}
+
+func multiCaptureComposeConfig() *ComposeConfig {
+	c := mysqlComposeConfig()
+	c.Name = "multi-capture"
+	c.Captures = MultiCaptureNum
+	return c
+}
+
+// Validate checks whether the config describes a valid environment
+func (c *ComposeConfig) Validate() error {
+	if c.Name == "" {
+		return errors.New("the name of the docker-compose environment is empty")
+	}
+	if c.Captures < 1 {
+		return errors.Errorf("at least one capture is required, got %d", c.Captures)
+	}
+	if c.Downstream != DownstreamTiDB && c.Downstream != DownstreamMySQL {
+		return errors.Errorf("unknown downstream %s", c.Downstream)
+	}
+	if c.KafkaBrokers < 0 {
+		return errors.Errorf("invalid number of Kafka brokers %d", c.KafkaBrokers)
+	}
+	if c.KafkaBrokers == 0 && (c.SchemaRegistry || c.KafkaConnect) {
+		return errors.New("the schema registry and Kafka Connect require Kafka")
+	}
+	if c.KafkaConnect && !c.SchemaRegistry {
+		return errors.New("Kafka Connect requires the schema registry")
+	}
+	if c.KafkaConnect && c.Downstream != DownstreamTiDB {
+		return errors.New("Kafka Connect only writes to a downstream TiDB")
+	}
+	if c.NewCollation && c.TLS {
+		return errors.New("the new collation framework doesn't work with TLS")
+	}
+	if c.Kerberos && (c.KafkaBrokers == 0 || c.TLS || c.SchemaRegistry) {
+		return errors.New("Kerberos requires Kafka, and doesn't work with TLS or the schema registry")
+	}
+	return nil
+}
+
+// FileName returns the base name of the generated docker-compose file
+func (c *ComposeConfig) FileName() string {
+	return "docker-compose-" + c.Name + ".yml"
+}
+
+// Hosts returns the host names of all the services in the environment
+func (c *ComposeConfig) Hosts() []string {
+	v := c.view()
+	var hosts []string
+	for _, cluster := range v.Clusters {
+		hosts = append(hosts, cluster.Name+"-pd")
+		hosts = append(hosts, cluster.TiKVs...)
+		hosts = append(hosts, cluster.Name+"-tidb")
+	}
+	if c.Downstream == DownstreamMySQL {
+		hosts = append(hosts, v.DownstreamService)
+	}
+	hosts = append(hosts, v.CaptureNames...)
+	for _, broker := range v.Brokers {
+		hosts = append(hosts, broker.Name)
+	}
+	if c.SchemaRegistry {
+		hosts = append(hosts, "schema-registry")
+	}
+	if c.KafkaConnect {
+		hosts = append(hosts, "kafka-connect-01")
+	}
+	if c.Pulsar {
+		hosts = append(hosts, "pulsar", "pulsar-proxy")
+	}
+	if c.Kerberos {
+		hosts = append(hosts, "kdc")
+	}
+	return hosts
+}
+
+// Render writes the docker-compose file described by the config
+func (c *ComposeConfig) Render() ([]byte, error) {
+	if err := c.Validate(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	var buf bytes.Buffer
+	if err := composeTemplate.Execute(&buf, c.view()); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return buf.Bytes(), nil
+}
+
+type composeClusterView struct {
+	Name       string
+	PDPort     int
+	TiDBPort   int
+	StatusPort int
+	TiKVs      []string
+}
+
+type composeBrokerView struct {
+	Name     string
+	ID       int
+	HostPort int
+}
+
+type composeView struct {
+	*ComposeConfig
+	Scheme            string
+	ConfigSuffix      string
+	TiDBConfigFile    string
+	RegistryVersion   string
+	SortDir           string
+	Clusters          []composeClusterView
+	DownstreamService string
+	CaptureNames      []string
+	Brokers           []composeBrokerView
+	BootstrapServers  string
+	ReplicationFactor int
+}
+
+func (c *ComposeConfig) view() composeView {
+	v := composeView{
+		ComposeConfig: c,
+		Scheme:        "http",
+	}
+	if c.TLS {
+		v.Scheme = "https"
+		v.ConfigSuffix = "-tls"
+	}
+	v.SortDir = sorterSortDir
+	v.RegistryVersion = c.SchemaRegistryVersion
+	if v.RegistryVersion == "" {
+		v.RegistryVersion = c.KafkaVersion
+	}
+	v.TiDBConfigFile = "tidb" + v.ConfigSuffix + ".toml"
+	if c.NewCollation {
+		v.TiDBConfigFile = "tidb-new-collation.toml"
+	}
+
+	newCluster := func(name string, pdPort, tidbPort, statusPort int) composeClusterView {
+		cluster := composeClusterView{Name: name, PDPort: pdPort, TiDBPort: tidbPort, StatusPort: statusPort}
+		for i := 0; i < composeTiKVNum; i++ {
+			cluster.TiKVs = append(cluster.TiKVs, fmt.Sprintf("%s-tikv%d", name, i))
+		}
+		return cluster
+	}
+	v.Clusters = []composeClusterView{newCluster("upstream", 2379, 4000, 10080)}
+	if c.Downstream == DownstreamTiDB {
+		v.Clusters = append(v.Clusters, newCluster("downstream", 3379, 5000, 20080))
+		v.DownstreamService = "downstream-tidb"
+	} else {
+		v.DownstreamService = "downstream-mysql"
+	}
+
+	for i := 0; i < c.Captures; i++ {
+		v.CaptureNames = append(v.CaptureNames, fmt.Sprintf("capturer%d", i))
+	}
+
+	var servers []string
+	for i := 0; i < c.KafkaBrokers; i++ {
+		name := "kafka"
+		if i > 0 {
+			name = fmt.Sprintf("kafka-%d", i)
+		}
+		v.Brokers = append(v.Brokers, composeBrokerView{Name: name, ID: i + 1, HostPort: composeKafkaHostPort + i})
+		servers = append(servers, name+":29092")
+	}
+	v.BootstrapServers = strings.Join(servers, ",")
+	v.ReplicationFactor = c.KafkaBrokers
+	if v.ReplicationFactor > 3 {
+		v.ReplicationFactor = 3
+	}
+	return v
+}
+
+// writeComposeFile generates the docker-compose file of the operator if it's not
+// given by the user.
+func (d *dockerComposeOperator) writeComposeFile() {
+	if d.compose == nil {
+		return
+	}
+	data, err := d.compose.Render()
+	if err != nil {
+		log.Fatal("Failed to render the docker-compose file", zap.Error(err))
+	}
+	err = ioutil.WriteFile(d.fileName, data, 0644)
+	if err != nil {
+		log.Fatal("Failed to write the docker-compose file", zap.String("file", d.fileName), zap.Error(err))
+	}
+	log.Info("Generated the docker-compose file", zap.String("file", d.fileName))
+}
+
+// OverrideCompose modifies the config the docker-compose file is generated from,
+// it must be called before the environment is set up. It does nothing if the
+// docker-compose file is given by the user.
+func (d *dockerComposeOperator) OverrideCompose(fn func(config *ComposeConfig)) {
+	if d.compose == nil {
+		log.Warn("The docker-compose file is given, ignore the overrides", zap.String("file", d.fileName))
+		return
+	}
+	fn(d.compose)
+	d.fileName = generatedComposeFilePath(d.compose)
+}
+
+// GenerateComposeFile writes the docker-compose file of the environment without
+// bringing it up, and returns the path of the file.
+func (d *dockerComposeOperator) GenerateComposeFile() string {
+	d.writeComposeFile()
+	return d.fileName
+}
+
+// generatedComposeFilePath returns the path of the docker-compose file generated
+// from the config. It's in the root of the git repo so that the relative paths in
+// the file and the project name of docker-compose are the same as before.
+func generatedComposeFilePath(config *ComposeConfig) string {
+	st, err := find.Repo()
+	if err != nil {
+		log.Fatal("Could not find git repo root", zap.Error(err))
+	}
+	return st.Path + "/" + config.FileName()
+}
+
+// newDockerComposeOperator returns an operator using the given docker-compose file,
+// or the file generated from config if the given file is empty.
+func newDockerComposeOperator(dockerComposeFile string, config *ComposeConfig) dockerComposeOperator {
+	if dockerComposeFile != "" {
+		return dockerComposeOperator{fileName: dockerComposeFile, keepEnv: keepEnvEnabled()}
+	}
+	return dockerComposeOperator{
+		fileName: generatedComposeFilePath(config),
+		compose:  config,
+		keepEnv:  keepEnvEnabled(),
+	}
+}
+
+var composeTemplate = template.Must(template.New("docker-compose").Parse(composeTemplateText))
+
+const composeTemplateText = `---
+# Generated by the integration framework, see integration/framework/compose_template.go
+version: '2.1'
+
+services:
+  controller:
+    image: ticdc:latest
+    build:
+      context: .
+      dockerfile: ./Dockerfile.development
+    volumes:
+      - ./docker/data:/data
+      - ./docker/logs:/logs
+{{- if .TLS}}
+      - ./docker/tls:/tls:ro
+{{- end}}
+{{- if .Kerberos}}
+      - ./docker/kerberos:/kerberos:ro
+{{- end}}
+      - ./docker/config:/config
+    command:
+      - /usr/bin/socat
+      - -v
+      - tcp-l:1234,fork
+      - exec:'/bin/cat'
+    ports:
+      - "1234:1234"
+    depends_on:
+      - "upstream-pd"
+      - "{{.DownstreamService}}"
+{{- if .SchemaRegistry}}
+      - "schema-registry"
+{{- end}}
+{{- if .KafkaConnect}}
+      - "kafka-connect-01"
+{{- end}}
+{{- range .Brokers}}
+      - "{{.Name}}"
+{{- end}}
+{{- if .Pulsar}}
+      - "pulsar-proxy"
+{{- end}}
+{{- range .CaptureNames}}
+      - "{{.}}"
+{{- end}}
+    restart: on-failure
+{{range .CaptureNames}}
+  {{.}}:
+    image: ticdc:latest
+    build:
+      context: .
+      dockerfile: ./Dockerfile.development
+    volumes:
+      - /data
+      - ./docker/logs:/logs
+{{- if $.TLS}}
+      - ./docker/tls:/tls:ro
+{{- end}}
+{{- if $.Kerberos}}
+      - ./docker/kerberos:/kerberos:ro
+{{- end}}
+{{- if $.SortDirSize}}
+    tmpfs:
+      - {{$.SortDir}}:size={{$.SortDirSize}}
+{{- end}}
+    entrypoint: "/cdc server"
+    command:
+      - --addr=0.0.0.0:8300
+      - --pd={{$.Scheme}}://upstream-pd:2379
+{{- if $.TLS}}
+      - --ca=/tls/ca.pem
+      - --cert=/tls/server.pem
+      - --key=/tls/server-key.pem
+{{- end}}
+      - --log-file=/logs/{{.}}.log
+      - --log-level=debug
+      - --advertise-addr={{.}}:8300
+    depends_on:
+      - "upstream-tidb"
+      - "{{$.DownstreamService}}"
+{{- range $.Brokers}}
+      - "{{.Name}}"
+{{- end}}
+{{- if $.Pulsar}}
+      - "pulsar"
+{{- end}}
+    restart: on-failure
+{{end}}
+{{- range .Clusters}}
+  {{.Name}}-pd:
+    image: pingcap/pd:{{$.TiDBVersion}}
+    ports:
+      - "{{.PDPort}}:2379"
+    volumes:
+      - ./docker/config/pd{{$.ConfigSuffix}}.toml:/pd.toml:ro
+      - /data
+      - ./docker/logs:/logs
+{{- if $.TLS}}
+      - ./docker/tls:/tls:ro
+{{- end}}
+    command:
+      - --name={{.Name}}-pd
+      - --client-urls={{$.Scheme}}://0.0.0.0:2379
+      - --peer-urls={{$.Scheme}}://0.0.0.0:2380
+      - --advertise-client-urls={{$.Scheme}}://{{.Name}}-pd:2379
+      - --advertise-peer-urls={{$.Scheme}}://{{.Name}}-pd:2380
+      - --initial-cluster={{.Name}}-pd={{$.Scheme}}://{{.Name}}-pd:2380
+      - --data-dir=/data/{{.Name}}-pd
+      - --config=/pd.toml
+      - --log-file=/logs/{{.Name}}-pd.log
+      - -L=debug
+    restart: on-failure
+{{$cluster := .}}
+{{- range .TiKVs}}
+  {{.}}:
+    image: pingcap/tikv:{{$.TiDBVersion}}
+    volumes:
+      - ./docker/config/tikv{{$.ConfigSuffix}}.toml:/tikv.toml:ro
+      - /data
+      - ./docker/logs:/logs
+{{- if $.TLS}}
+      - ./docker/tls:/tls:ro
+{{- end}}
+    command:
+      - --addr=0.0.0.0:20160
+      - --advertise-addr={{.}}:20160
+      - --data-dir=/data/{{.}}
+      - --pd={{$cluster.Name}}-pd:2379
+      - --config=/tikv.toml
+      - --log-file=/logs/{{.}}.log
+      - --log-level=debug
+    depends_on:
+      - "{{$cluster.Name}}-pd"
+    restart: on-failure
+{{end}}
+  {{.Name}}-tidb:
+    image: pingcap/tidb:{{$.TiDBVersion}}
+    ports:
+      - "{{.TiDBPort}}:4000"
+      - "{{.StatusPort}}:10080"
+    volumes:
+      - ./docker/config/{{$.TiDBConfigFile}}:/tidb.toml:ro
+      - ./docker/logs:/logs
+{{- if $.TLS}}
+      - ./docker/tls:/tls:ro
+{{- end}}
+    command:
+      - --store=tikv
+      - --path={{.Name}}-pd:2379
+      - --config=/tidb.toml
+      - --log-file=/logs/{{.Name}}-tidb.log
+      - --advertise-address={{.Name}}-tidb
+      - -L=debug
+    depends_on:
+{{- range .TiKVs}}
+      - "{{.}}"
+{{- end}}
+    restart: on-failure
+{{end}}
+{{- if eq .Downstream "mysql"}}
+  downstream-mysql:
+    image: mysql:{{.MySQLVersion}}
+    container_name: downstream-mysql
+    ports:
+      - "5000:3306"
+    environment:
+      MYSQL_ALLOW_EMPTY_PASSWORD: "yes"
+    command:
+      - --default-authentication-plugin=mysql_native_password
+      - --character-set-server=utf8mb4
+      - --collation-server=utf8mb4_bin
+      - --sql-mode=
+    restart: on-failure
+{{end}}
+{{- if .Brokers}}
+# The Kafka services are adapted from https://github.com/confluentinc/demo-scene/blob/master/connect-jdbc/docker-compose.yml
+# Use kafka:29092 for connections internal on the docker network, and localhost:9092
+# for the consumers running on the host, the other brokers use the following host ports.
+{{- if .TLS}}
+# All the Kafka services use TLS, the JKS keystores are generated from the PEM certificates
+# by the integration framework.
+{{- end}}
+
+  zookeeper:
+    image: confluentinc/cp-zookeeper:{{.KafkaVersion}}
+    container_name: zookeeper
+    environment:
+      ZOOKEEPER_CLIENT_PORT: 2181
+      ZOOKEEPER_TICK_TIME: 2000
+{{range .Brokers}}
+  {{.Name}}:
+    image: confluentinc/cp-enterprise-kafka:{{$.KafkaVersion}}
+    container_name: {{.Name}}
+    depends_on:
+      - zookeeper
+{{- if $.Kerberos}}
+      - kdc
+{{- end}}
+    ports:
+      - {{.HostPort}}:{{.HostPort}}
+{{- if $.TLS}}
+    volumes:
+      - ./docker/tls:/etc/kafka/secrets:ro
+{{- end}}
+{{- if $.Kerberos}}
+    volumes:
+      - ./docker/kerberos:/kerberos:ro
+    # the keytab is exported by the KDC when it starts
+    command: bash -c "while [ ! -f /kerberos/{{.Name}}.keytab ]; do sleep 1; done; exec /etc/confluent/docker/run"
+{{- end}}
+    environment:
+      KAFKA_BROKER_ID: {{.ID}}
+      KAFKA_ZOOKEEPER_CONNECT: zookeeper:2181
+{{- if $.TLS}}
+      KAFKA_LISTENER_SECURITY_PROTOCOL_MAP: SSL:SSL,SSL_HOST:SSL
+      KAFKA_INTER_BROKER_LISTENER_NAME: SSL
+      KAFKA_ADVERTISED_LISTENERS: SSL://{{.Name}}:29092,SSL_HOST://localhost:{{.HostPort}}
+{{- else if $.Kerberos}}
+      KAFKA_LISTENER_SECURITY_PROTOCOL_MAP: SASL_PLAINTEXT:SASL_PLAINTEXT,PLAINTEXT:PLAINTEXT,PLAINTEXT_HOST:PLAINTEXT
+      KAFKA_INTER_BROKER_LISTENER_NAME: PLAINTEXT
+      KAFKA_ADVERTISED_LISTENERS: SASL_PLAINTEXT://{{.Name}}:29092,PLAINTEXT://{{.Name}}:29093,PLAINTEXT_HOST://localhost:{{.HostPort}}
+      KAFKA_SASL_ENABLED_MECHANISMS: GSSAPI
+      KAFKA_SASL_KERBEROS_SERVICE_NAME: kafka
+      KAFKA_OPTS: -Djava.security.auth.login.config=/kerberos/{{.Name}}_jaas.conf -Djava.security.krb5.conf=/kerberos/krb5.conf
+{{- else}}
+      KAFKA_LISTENER_SECURITY_PROTOCOL_MAP: PLAINTEXT:PLAINTEXT,PLAINTEXT_HOST:PLAINTEXT
+      KAFKA_INTER_BROKER_LISTENER_NAME: PLAINTEXT
+      KAFKA_ADVERTISED_LISTENERS: PLAINTEXT://{{.Name}}:29092,PLAINTEXT_HOST://localhost:{{.HostPort}}
+{{- end}}
+      KAFKA_AUTO_CREATE_TOPICS_ENABLE: "true"
+      KAFKA_OFFSETS_TOPIC_REPLICATION_FACTOR: {{$.ReplicationFactor}}
+      KAFKA_GROUP_INITIAL_REBALANCE_DELAY_MS: 100
+{{- if $.TLS}}
+      KAFKA_SSL_KEYSTORE_FILENAME: kafka.server.keystore.jks
+      KAFKA_SSL_KEYSTORE_CREDENTIALS: keystore_credentials
+      KAFKA_SSL_KEY_CREDENTIALS: keystore_credentials
+      KAFKA_SSL_TRUSTSTORE_FILENAME: kafka.server.truststore.jks
+      KAFKA_SSL_TRUSTSTORE_CREDENTIALS: keystore_credentials
+      KAFKA_SSL_CLIENT_AUTH: required
+      KAFKA_SSL_ENDPOINT_IDENTIFICATION_ALGORITHM: " "
+      CONFLUENT_METRICS_ENABLE: 'false'
+{{- else if $.Kerberos}}
+      CONFLUENT_METRICS_ENABLE: 'false'
+{{- else}}
+      KAFKA_METRIC_REPORTERS: io.confluent.metrics.reporter.ConfluentMetricsReporter
+      CONFLUENT_METRICS_REPORTER_BOOTSTRAP_SERVERS: {{$.BootstrapServers}}
+      CONFLUENT_METRICS_REPORTER_ZOOKEEPER_CONNECT: zookeeper:2181
+      CONFLUENT_METRICS_REPORTER_TOPIC_REPLICAS: {{$.ReplicationFactor}}
+      CONFLUENT_METRICS_ENABLE: 'true'
+{{- end}}
+      CONFLUENT_SUPPORT_CUSTOMER_ID: 'anonymous'
+{{end}}
+{{- end}}
+{{- if .SchemaRegistry}}
+  schema-registry:
+    image: confluentinc/cp-schema-registry:{{.RegistryVersion}}
+    container_name: schema-registry
+    ports:
+      - 8081:8081
+    depends_on:
+      - zookeeper
+{{- range .Brokers}}
+      - {{.Name}}
+{{- end}}
+{{- if .TLS}}
+    volumes:
+      - ./docker/tls:/tls:ro
+{{- end}}
+    environment:
+      SCHEMA_REGISTRY_HOST_NAME: schema-registry
+{{- if .TLS}}
+      SCHEMA_REGISTRY_LISTENERS: https://0.0.0.0:8081
+      SCHEMA_REGISTRY_INTER_INSTANCE_PROTOCOL: https
+      SCHEMA_REGISTRY_SSL_KEYSTORE_LOCATION: /tls/kafka.server.keystore.jks
+      SCHEMA_REGISTRY_SSL_KEYSTORE_PASSWORD: ticdc-test
+      SCHEMA_REGISTRY_SSL_KEY_PASSWORD: ticdc-test
+      SCHEMA_REGISTRY_SSL_TRUSTSTORE_LOCATION: /tls/kafka.server.truststore.jks
+      SCHEMA_REGISTRY_SSL_TRUSTSTORE_PASSWORD: ticdc-test
+      SCHEMA_REGISTRY_SSL_CLIENT_AUTH: "true"
+      SCHEMA_REGISTRY_KAFKASTORE_BOOTSTRAP_SERVERS: {{range $i, $b := .Brokers}}{{if $i}},{{end}}SSL://{{$b.Name}}:29092{{end}}
+      SCHEMA_REGISTRY_KAFKASTORE_SECURITY_PROTOCOL: SSL
+      SCHEMA_REGISTRY_KAFKASTORE_SSL_KEYSTORE_LOCATION: /tls/kafka.server.keystore.jks
+      SCHEMA_REGISTRY_KAFKASTORE_SSL_KEYSTORE_PASSWORD: ticdc-test
+      SCHEMA_REGISTRY_KAFKASTORE_SSL_KEY_PASSWORD: ticdc-test
+      SCHEMA_REGISTRY_KAFKASTORE_SSL_TRUSTSTORE_LOCATION: /tls/kafka.server.truststore.jks
+      SCHEMA_REGISTRY_KAFKASTORE_SSL_TRUSTSTORE_PASSWORD: ticdc-test
+      SCHEMA_REGISTRY_KAFKASTORE_SSL_ENDPOINT_IDENTIFICATION_ALGORITHM: " "
+{{- else}}
+      SCHEMA_REGISTRY_KAFKASTORE_CONNECTION_URL: zookeeper:2181
+{{- end}}
+{{end}}
+{{- if .KafkaConnect}}
+  kafka-connect-01:
+    image: confluentinc/cp-kafka-connect:{{.KafkaVersion}}
+    container_name: kafka-connect-01
+    depends_on:
+      - zookeeper
+{{- range .Brokers}}
+      - {{.Name}}
+{{- end}}
+      - schema-registry
+      - downstream-tidb
+    ports:
+      - 8083:8083
+{{- if .TLS}}
+    volumes:
+      - ./docker/tls:/tls:ro
+{{- end}}
+    environment:
+      CONNECT_LOG4J_APPENDER_STDOUT_LAYOUT_CONVERSIONPATTERN: "[%d] %p %X{connector.context}%m (%c:%L)%n"
+{{- if .TLS}}
+      CONNECT_BOOTSTRAP_SERVERS: "{{range $i, $b := .Brokers}}{{if $i}},{{end}}SSL://{{$b.Name}}:29092{{end}}"
+      CONNECT_SECURITY_PROTOCOL: SSL
+      CONNECT_SSL_KEYSTORE_LOCATION: /tls/kafka.server.keystore.jks
+      CONNECT_SSL_KEYSTORE_PASSWORD: ticdc-test
+      CONNECT_SSL_KEY_PASSWORD: ticdc-test
+      CONNECT_SSL_TRUSTSTORE_LOCATION: /tls/kafka.server.truststore.jks
+      CONNECT_SSL_TRUSTSTORE_PASSWORD: ticdc-test
+      CONNECT_SSL_ENDPOINT_IDENTIFICATION_ALGORITHM: " "
+      CONNECT_CONSUMER_SECURITY_PROTOCOL: SSL
+      CONNECT_CONSUMER_SSL_KEYSTORE_LOCATION: /tls/kafka.server.keystore.jks
+      CONNECT_CONSUMER_SSL_KEYSTORE_PASSWORD: ticdc-test
+      CONNECT_CONSUMER_SSL_KEY_PASSWORD: ticdc-test
+      CONNECT_CONSUMER_SSL_TRUSTSTORE_LOCATION: /tls/kafka.server.truststore.jks
+      CONNECT_CONSUMER_SSL_TRUSTSTORE_PASSWORD: ticdc-test
+      CONNECT_CONSUMER_SSL_ENDPOINT_IDENTIFICATION_ALGORITHM: " "
+{{- else}}
+      CONNECT_BOOTSTRAP_SERVERS: "{{.BootstrapServers}}"
+{{- end}}
+      CONNECT_REST_PORT: 8083
+      CONNECT_REST_ADVERTISED_HOST_NAME: "kafka-connect-01"
+      CONNECT_GROUP_ID: compose-connect-group
+      CONNECT_CONFIG_STORAGE_TOPIC: docker-connect-configs
+      CONNECT_OFFSET_STORAGE_TOPIC: docker-connect-offsets
+      CONNECT_STATUS_STORAGE_TOPIC: docker-connect-status
+      CONNECT_KEY_CONVERTER: io.confluent.connect.avro.AvroConverter
+      CONNECT_KEY_CONVERTER_SCHEMA_REGISTRY_URL: '{{.Scheme}}://schema-registry:8081'
+      CONNECT_VALUE_CONVERTER: io.confluent.connect.avro.AvroConverter
+      CONNECT_VALUE_CONVERTER_SCHEMA_REGISTRY_URL: '{{.Scheme}}://schema-registry:8081'
+      CONNECT_INTERNAL_KEY_CONVERTER: "org.apache.kafka.connect.json.JsonConverter"
+      CONNECT_INTERNAL_VALUE_CONVERTER: "org.apache.kafka.connect.json.JsonConverter"
+      CONNECT_LOG4J_ROOT_LOGLEVEL: "INFO"
+      CONNECT_LOG4J_LOGGERS: "org.apache.kafka.connect.runtime.rest=WARN,org.reflections=ERROR"
+      CONNECT_CONFIG_STORAGE_REPLICATION_FACTOR: "{{.ReplicationFactor}}"
+      CONNECT_OFFSET_STORAGE_REPLICATION_FACTOR: "{{.ReplicationFactor}}"
+      CONNECT_STATUS_STORAGE_REPLICATION_FACTOR: "{{.ReplicationFactor}}"
+      CONNECT_PLUGIN_PATH: '/usr/share/java'
+{{- if .TLS}}
+      # the Avro converters connect to the schema registry with the JVM-wide key store and trust store
+      KAFKA_OPTS: >-
+        -Djavax.net.ssl.keyStore=/tls/kafka.server.keystore.jks
+        -Djavax.net.ssl.keyStorePassword=ticdc-test
+        -Djavax.net.ssl.trustStore=/tls/kafka.server.truststore.jks
+        -Djavax.net.ssl.trustStorePassword=ticdc-test
+{{- end}}
+    command:
+      - /bin/bash
+      - -c
+      - |
+        # JDBC Drivers
+        # ------------
+        # MySQL
+        cd /usr/share/java/kafka-connect-jdbc/
+        wget https://dev.mysql.com/get/Downloads/Connector-J/mysql-connector-java-8.0.21.tar.gz
+        tar -xf mysql-connector-java-8.0.21.tar.gz
+        mv mysql-connector-java-8.0.21/mysql-connector-java-8.0.21.jar ./
+        # Now launch Kafka Connect
+        sleep infinity &
+        /etc/confluent/docker/run
+
+  kafka-connect-healthcheck:
+    image: devshawn/kafka-connect-healthcheck:0.1.0
+    container_name: kafka-connect-healthcheck
+    depends_on:
+      - kafka-connect-01
+    ports:
+      - 18083:18083
+    environment:
+      HEALTHCHECK_CONNECT_URL: 'http://kafka-connect-01:8083'
+{{end}}
+{{- if .Kerberos}}
+# The KDC of the TICDC.TEST realm. It writes krb5.conf, the JAAS configurations of the
+# brokers and the keytabs of the principals to docker/kerberos when it's created. The
+# captures authenticate as ticdc@TICDC.TEST to the SASL_PLAINTEXT listeners of Kafka on
+# port 29092, and the clients on the host use the plaintext listeners.
+  kdc:
+    image: debian:buster-slim
+    container_name: kdc
+    volumes:
+      - ./docker/kerberos:/kerberos
+    command:
+      - /bin/bash
+      - -c
+      - |
+        set -e
+        export DEBIAN_FRONTEND=noninteractive
+        apt-get update -qq > /dev/null
+        apt-get install -y -qq krb5-kdc krb5-admin-server > /dev/null
+        cat > /etc/krb5.conf <<EOF
+        [libdefaults]
+          default_realm = TICDC.TEST
+          dns_lookup_realm = false
+          dns_lookup_kdc = false
+          rdns = false
+          udp_preference_limit = 1
+        [realms]
+          TICDC.TEST = {
+            kdc = kdc
+            admin_server = kdc
+          }
+        EOF
+        # the realm is kept if the container is restarted
+        if [ ! -f /var/lib/krb5kdc/principal ]; then
+          rm -f /kerberos/*
+          cp /etc/krb5.conf /kerberos/krb5.conf
+          kdb5_util create -s -r TICDC.TEST -P ticdc-test
+          kadmin.local -q "addprinc -randkey ticdc@TICDC.TEST"
+          kadmin.local -q "ktadd -k /kerberos/ticdc.keytab ticdc@TICDC.TEST"
+{{- range .Brokers}}
+          printf 'KafkaServer {\n  com.sun.security.auth.module.Krb5LoginModule required\n  useKeyTab=true\n  storeKey=true\n  keyTab="/kerberos/{{.Name}}.keytab"\n  principal="kafka/{{.Name}}@TICDC.TEST";\n};\n' > /kerberos/{{.Name}}_jaas.conf
+          kadmin.local -q "addprinc -randkey kafka/{{.Name}}@TICDC.TEST"
+          kadmin.local -q "ktadd -k /kerberos/{{.Name}}.keytab.tmp kafka/{{.Name}}@TICDC.TEST"
+{{- end}}
+          chmod 644 /kerberos/*
+{{- range .Brokers}}
+          mv /kerberos/{{.Name}}.keytab.tmp /kerberos/{{.Name}}.keytab
+{{- end}}
+        fi
+        exec krb5kdc -n
+    restart: on-failure
+{{end}}
+{{- if .Pulsar}}
+# The captures connect to the broker with pulsar://pulsar:6650, and the consumers
+# running on the host with pulsar://localhost:6651 through the proxy, as the broker
+# advertises an address only resolvable in the docker network.
+  pulsar:
+    image: apachepulsar/pulsar:{{.PulsarVersion}}
+    container_name: pulsar
+    command: bin/pulsar standalone --no-functions-worker --no-stream-storage
+    ports:
+      - 6650:6650
+      - 8080:8080
+    restart: on-failure
+
+  pulsar-proxy:
+    image: apachepulsar/pulsar:{{.PulsarVersion}}
+    container_name: pulsar-proxy
+    depends_on:
+      - pulsar
+    environment:
+      brokerServiceURL: pulsar://pulsar:6650
+      brokerWebServiceURL: http://pulsar:8080
+      clusterName: standalone
+    command: bash -c "bin/apply-config-from-env.py conf/proxy.conf && exec bin/pulsar proxy"
+    ports:
+      - 6651:6650
+    restart: on-failure
+{{end}}`
@@ -0,0 +1,113 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestComposeConfigPresets(t *testing.T) {
+	for _, config := range []*ComposeConfig{
+		avroComposeConfig(),
+		tlsComposeConfig(),
+		canalJSONComposeConfig(),
+		mysqlComposeConfig(),
+		multiCaptureComposeConfig(),
+		pulsarComposeConfig(),
+		kerberosComposeConfig(),
+		exoticComposeConfig(),
+		sorterDiskFullComposeConfig(),
+	} {
+		data, err := config.Render()
+		require.NoError(t, err, config.Name)
+		out := string(data)
+		require.Contains(t, out, "image: pingcap/tidb:nightly")
+		require.Contains(t, out, "\n  upstream-tidb:\n")
+		require.Equal(t, config.KafkaBrokers > 0, strings.Contains(out, "\n  kafka:\n"), config.Name)
+		require.Equal(t, config.SchemaRegistry, strings.Contains(out, "\n  schema-registry:\n"), config.Name)
+		require.Equal(t, config.KafkaConnect, strings.Contains(out, "\n  kafka-connect-01:\n"), config.Name)
+		require.Equal(t, config.TLS, strings.Contains(out, "--pd=https://upstream-pd:2379"), config.Name)
+		require.Contains(t, out, "\n  "+config.Hosts()[len(config.Hosts())-1]+":\n")
+		require.NotContains(t, out, "<no value>")
+	}
+
+	data, err := multiCaptureComposeConfig().Render()
+	require.NoError(t, err)
+	out := string(data)
+	require.Contains(t, out, "\n  capturer3:\n")
+	require.Contains(t, out, "\n  downstream-mysql:\n")
+	require.NotContains(t, out, "downstream-tidb")
+
+	data, err = pulsarComposeConfig().Render()
+	require.NoError(t, err)
+	out = string(data)
+	require.Contains(t, out, "image: apachepulsar/pulsar:"+defaultPulsarVersion)
+	require.Contains(t, out, "- 6651:6650")
+	require.NotContains(t, out, "zookeeper")
+
+	data, err = sorterDiskFullComposeConfig().Render()
+	require.NoError(t, err)
+	out = string(data)
+	require.Equal(t, 3, strings.Count(out, "\n    tmpfs:\n      - /tmp/cdc_sort:size=32m\n"))
+	data, err = mysqlComposeConfig().Render()
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "tmpfs")
+}
+
+func TestComposeConfigOverrides(t *testing.T) {
+	config := avroComposeConfig()
+	config.TiDBVersion = "v4.0.8"
+	config.KafkaVersion = "6.0.0"
+	config.KafkaBrokers = 3
+	config.Captures = 2
+	data, err := config.Render()
+	require.NoError(t, err)
+	out := string(data)
+	require.Contains(t, out, "image: pingcap/pd:v4.0.8")
+	require.Contains(t, out, "image: pingcap/tikv:v4.0.8")
+	require.Contains(t, out, "image: confluentinc/cp-enterprise-kafka:6.0.0")
+	require.NotContains(t, out, "nightly")
+	require.NotContains(t, out, "capturer2")
+	require.Contains(t, out, "KAFKA_ADVERTISED_LISTENERS: PLAINTEXT://kafka-2:29092,PLAINTEXT_HOST://localhost:9094")
+	require.Contains(t, out, "CONNECT_BOOTSTRAP_SERVERS: \"kafka:29092,kafka-1:29092,kafka-2:29092\"")
+	require.Contains(t, out, "KAFKA_OFFSETS_TOPIC_REPLICATION_FACTOR: 3")
+	require.Contains(t, config.Hosts(), "kafka-2")
+
+	config.TLS = true
+	data, err = config.Render()
+	require.NoError(t, err)
+	require.Contains(t, string(data), "SCHEMA_REGISTRY_KAFKASTORE_BOOTSTRAP_SERVERS: SSL://kafka:29092,SSL://kafka-1:29092,SSL://kafka-2:29092")
+}
+
+func TestComposeConfigValidate(t *testing.T) {
+	config := mysqlComposeConfig()
+	config.Captures = 0
+	_, err := config.Render()
+	require.Error(t, err)
+
+	config = avroComposeConfig()
+	config.KafkaBrokers = 0
+	require.Error(t, config.Validate())
+
+	config = avroComposeConfig()
+	config.Downstream = DownstreamMySQL
+	require.Error(t, config.Validate())
+
+	config = canalJSONComposeConfig()
+	config.Downstream = "oracle"
+	require.Error(t, config.Validate())
+}
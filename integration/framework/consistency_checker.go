@@ -0,0 +1,339 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/ticdc/pkg/quotes"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultChecksumChunkSize = 10000
+	// the schema and table of the syncpoints written by the MySQL sink to a downstream TiDB
+	syncpointSchema = "tidb_cdc"
+	syncpointTable  = "syncpoint_v1"
+)
+
+// Syncpoint is a pair of timestamps at which the upstream and the downstream are consistent
+type Syncpoint struct {
+	PrimaryTs   uint64
+	SecondaryTs uint64
+}
+
+// ChunkRange is the range of the integer primary key of a chunk, [Lower, Upper].
+// A table without an integer primary key is checked as a single chunk with Whole set.
+type ChunkRange struct {
+	Lower int64
+	Upper int64
+	Whole bool
+}
+
+func (r ChunkRange) String() string {
+	if r.Whole {
+		return "[whole table]"
+	}
+	return fmt.Sprintf("[%d, %d]", r.Lower, r.Upper)
+}
+
+// TableDiff is the difference of a table between the upstream and the downstream
+type TableDiff struct {
+	Schema         string
+	Table          string
+	UpstreamRows   int64
+	DownstreamRows int64
+	// MismatchedChunks are the chunks whose checksums are different
+	MismatchedChunks []ChunkRange
+}
+
+// ConsistencyReport is the result of a consistency check
+type ConsistencyReport struct {
+	Syncpoint *Syncpoint
+	Tables    int
+	Diffs     []TableDiff
+}
+
+// Err returns an error describing all the differences, or nil if the upstream and the downstream are consistent
+func (r *ConsistencyReport) Err() error {
+	if len(r.Diffs) == 0 {
+		return nil
+	}
+	builder := strings.Builder{}
+	fmt.Fprintf(&builder, "%d of %d tables are inconsistent:", len(r.Diffs), r.Tables)
+	for _, diff := range r.Diffs {
+		fmt.Fprintf(&builder, " %s.%s (rows upstream %d, downstream %d, mismatched chunks %v);",
+			diff.Schema, diff.Table, diff.UpstreamRows, diff.DownstreamRows, diff.MismatchedChunks)
+	}
+	return errors.New(builder.String())
+}
+
+// ConsistencyChecker compares the tables in the upstream and the downstream by the
+// checksums of chunks of rows, which is much cheaper than comparing the rows.
+type ConsistencyChecker struct {
+	upstream   *sql.DB
+	downstream *sql.DB
+	// ChunkSize is the number of primary key values in a chunk
+	ChunkSize int64
+}
+
+// NewConsistencyChecker creates a new ConsistencyChecker
+func NewConsistencyChecker(upstream *sql.DB, downstream *sql.DB) *ConsistencyChecker {
+	return &ConsistencyChecker{
+		upstream:   upstream,
+		downstream: downstream,
+		ChunkSize:  defaultChecksumChunkSize,
+	}
+}
+
+// ConsistencyChecker returns a ConsistencyChecker of the upstream and the downstream
+func (c *TaskContext) ConsistencyChecker() *ConsistencyChecker {
+	return NewConsistencyChecker(c.Upstream, c.Downstream)
+}
+
+// LatestSyncpoint returns the latest syncpoint of the changefeed recorded in the
+// downstream, the changefeed must be created with --sync-point and replicate to a TiDB.
+func (c *ConsistencyChecker) LatestSyncpoint(ctx context.Context, changefeedID string) (*Syncpoint, error) {
+	row := c.downstream.QueryRowContext(ctx, fmt.Sprintf(
+		"select primary_ts, secondary_ts from %s where cf = ? order by cast(primary_ts as unsigned) desc limit 1",
+		quotes.QuoteSchema(syncpointSchema, syncpointTable)), changefeedID)
+	syncpoint := new(Syncpoint)
+	err := row.Scan(&syncpoint.PrimaryTs, &syncpoint.SecondaryTs)
+	if err != nil {
+		return nil, errors.Annotatef(err, "failed to get the syncpoint of changefeed %s", changefeedID)
+	}
+	return syncpoint, nil
+}
+
+// ListTables returns all the tables of the schema in the upstream
+func (c *ConsistencyChecker) ListTables(ctx context.Context, schema string) ([]string, error) {
+	rows, err := c.upstream.QueryContext(ctx,
+		"select table_name from information_schema.tables where table_schema = ? and table_type = 'BASE TABLE' order by table_name",
+		schema)
+	if err != nil {
+		return nil, errors.AddStack(err)
+	}
+	defer rows.Close()
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			return nil, errors.AddStack(err)
+		}
+		tables = append(tables, table)
+	}
+	return tables, errors.AddStack(rows.Err())
+}
+
+// CheckSchema checks all the tables of the schema, see Check
+func (c *ConsistencyChecker) CheckSchema(ctx context.Context, schema string, syncpoint *Syncpoint) (*ConsistencyReport, error) {
+	tables, err := c.ListTables(ctx, schema)
+	if err != nil {
+		return nil, err
+	}
+	return c.Check(ctx, schema, tables, syncpoint)
+}
+
+// Check compares the tables in the upstream and the downstream. If syncpoint is
+// not nil, the upstream is read at PrimaryTs and the downstream at SecondaryTs,
+// otherwise the latest data are compared, which requires the replication to have
+// caught up and no more writes in the upstream.
+func (c *ConsistencyChecker) Check(ctx context.Context, schema string, tables []string, syncpoint *Syncpoint) (*ConsistencyReport, error) {
+	upstream, err := snapshotConn(ctx, c.upstream, syncpoint, true)
+	if err != nil {
+		return nil, err
+	}
+	defer upstream.Close()
+	downstream, err := snapshotConn(ctx, c.downstream, syncpoint, false)
+	if err != nil {
+		return nil, err
+	}
+	defer downstream.Close()
+
+	report := &ConsistencyReport{Syncpoint: syncpoint, Tables: len(tables)}
+	for _, table := range tables {
+		diff, err := c.checkTable(ctx, upstream, downstream, schema, table)
+		if err != nil {
+			return nil, errors.Annotatef(err, "failed to check table %s.%s", schema, table)
+		}
+		if diff != nil {
+			log.Warn("table is inconsistent",
+				zap.String("schema", schema),
+				zap.String("table", table),
+				zap.Int64("upstreamRows", diff.UpstreamRows),
+				zap.Int64("downstreamRows", diff.DownstreamRows),
+				zap.String("mismatchedChunks", fmt.Sprint(diff.MismatchedChunks)))
+			report.Diffs = append(report.Diffs, *diff)
+		}
+	}
+	return report, nil
+}
+
+func snapshotConn(ctx context.Context, db *sql.DB, syncpoint *Syncpoint, isUpstream bool) (*sql.Conn, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, errors.AddStack(err)
+	}
+	if syncpoint == nil {
+		return conn, nil
+	}
+	ts := syncpoint.PrimaryTs
+	if !isUpstream {
+		ts = syncpoint.SecondaryTs
+	}
+	_, err = conn.ExecContext(ctx, fmt.Sprintf("set @@tidb_snapshot = '%d'", ts))
+	if err != nil {
+		_ = conn.Close()
+		return nil, errors.AddStack(err)
+	}
+	return conn, nil
+}
+
+func (c *ConsistencyChecker) checkTable(ctx context.Context, upstream *sql.Conn, downstream *sql.Conn, schema string, table string) (*TableDiff, error) {
+	columns, intPK, err := tableColumns(ctx, upstream, schema, table)
+	if err != nil {
+		return nil, err
+	}
+	chunks := []ChunkRange{{Whole: true}}
+	if intPK != "" {
+		chunks, err = c.splitChunks(ctx, upstream, schema, table, intPK)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	diff := &TableDiff{Schema: schema, Table: table}
+	for _, chunk := range chunks {
+		upRows, upSum, err := chunkChecksum(ctx, upstream, schema, table, columns, intPK, chunk)
+		if err != nil {
+			return nil, err
+		}
+		downRows, downSum, err := chunkChecksum(ctx, downstream, schema, table, columns, intPK, chunk)
+		if err != nil {
+			return nil, err
+		}
+		diff.UpstreamRows += upRows
+		diff.DownstreamRows += downRows
+		if upRows != downRows || upSum != downSum {
+			diff.MismatchedChunks = append(diff.MismatchedChunks, chunk)
+		}
+	}
+	if len(diff.MismatchedChunks) == 0 {
+		return nil, nil
+	}
+	return diff, nil
+}
+
+// tableColumns returns the columns of the table, and the primary key column if the
+// table has a single-column integer primary key.
+func tableColumns(ctx context.Context, conn *sql.Conn, schema string, table string) ([]string, string, error) {
+	rows, err := conn.QueryContext(ctx, `
+		SELECT COLUMN_NAME, COLUMN_KEY, DATA_TYPE FROM INFORMATION_SCHEMA.COLUMNS
+		WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?
+		ORDER BY ORDINAL_POSITION`, schema, table)
+	if err != nil {
+		return nil, "", errors.AddStack(err)
+	}
+	defer rows.Close()
+	var (
+		columns []string
+		pks     []string
+		intPK   string
+	)
+	for rows.Next() {
+		var name, key, dataType string
+		if err := rows.Scan(&name, &key, &dataType); err != nil {
+			return nil, "", errors.AddStack(err)
+		}
+		columns = append(columns, name)
+		if key == "PRI" {
+			pks = append(pks, name)
+			if strings.HasSuffix(strings.ToLower(dataType), "int") {
+				intPK = name
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", errors.AddStack(err)
+	}
+	if len(columns) == 0 {
+		return nil, "", errors.Errorf("table %s.%s does not exist", schema, table)
+	}
+	if len(pks) != 1 {
+		intPK = ""
+	}
+	return columns, intPK, nil
+}
+
+func (c *ConsistencyChecker) splitChunks(ctx context.Context, conn *sql.Conn, schema string, table string, pk string) ([]ChunkRange, error) {
+	var min, max sql.NullInt64
+	err := conn.QueryRowContext(ctx, fmt.Sprintf("select min(%s), max(%s) from %s",
+		quotes.QuoteName(pk), quotes.QuoteName(pk), quotes.QuoteSchema(schema, table))).Scan(&min, &max)
+	if err != nil {
+		return nil, errors.AddStack(err)
+	}
+	if !min.Valid {
+		// the upstream table is empty, the downstream table should be empty too
+		return []ChunkRange{{Whole: true}}, nil
+	}
+	return splitRange(min.Int64, max.Int64, c.ChunkSize), nil
+}
+
+// splitRange splits [min, max] into chunks of size at most chunkSize
+func splitRange(min int64, max int64, chunkSize int64) []ChunkRange {
+	var chunks []ChunkRange
+	for lower := min; ; {
+		upper := lower + chunkSize - 1
+		// upper < lower if it overflows
+		if upper >= max || upper < lower {
+			return append(chunks, ChunkRange{Lower: lower, Upper: max})
+		}
+		chunks = append(chunks, ChunkRange{Lower: lower, Upper: upper})
+		lower = upper + 1
+	}
+}
+
+// chunkChecksum returns the number of rows and the xor of the CRC32 of all rows in the chunk
+func chunkChecksum(
+	ctx context.Context, conn *sql.Conn, schema string, table string, columns []string, pk string, chunk ChunkRange,
+) (int64, int64, error) {
+	quoted := make([]string, len(columns))
+	isNulls := make([]string, len(columns))
+	for i, col := range columns {
+		quoted[i] = quotes.QuoteName(col)
+		isNulls[i] = "ISNULL(" + quoted[i] + ")"
+	}
+	query := fmt.Sprintf("SELECT COUNT(*), COALESCE(BIT_XOR(CAST(CRC32(CONCAT_WS(',', %s, CONCAT(%s))) AS UNSIGNED)), 0) FROM %s",
+		strings.Join(quoted, ", "), strings.Join(isNulls, ", "), quotes.QuoteSchema(schema, table))
+	var args []interface{}
+	if !chunk.Whole {
+		query += fmt.Sprintf(" WHERE %s BETWEEN ? AND ?", quotes.QuoteName(pk))
+		args = append(args, chunk.Lower, chunk.Upper)
+	}
+	var rows, checksum int64
+	err := conn.QueryRowContext(ctx, query, args...).Scan(&rows, &checksum)
+	if err != nil {
+		if strings.Contains(err.Error(), "Error 1146") {
+			// the table does not exist
+			return -1, 0, nil
+		}
+		return 0, 0, errors.AddStack(err)
+	}
+	return rows, checksum, nil
+}
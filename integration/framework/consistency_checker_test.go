@@ -0,0 +1,52 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitRange(t *testing.T) {
+	require.Equal(t, []ChunkRange{{Lower: 1, Upper: 1}}, splitRange(1, 1, 10))
+	require.Equal(t, []ChunkRange{
+		{Lower: -5, Upper: 4},
+		{Lower: 5, Upper: 14},
+		{Lower: 15, Upper: 20},
+	}, splitRange(-5, 20, 10))
+	require.Equal(t, []ChunkRange{
+		{Lower: 0, Upper: 9},
+		{Lower: 10, Upper: 19},
+	}, splitRange(0, 19, 10))
+	require.Equal(t, []ChunkRange{
+		{Lower: math.MaxInt64 - 15, Upper: math.MaxInt64 - 6},
+		{Lower: math.MaxInt64 - 5, Upper: math.MaxInt64},
+	}, splitRange(math.MaxInt64-15, math.MaxInt64, 10))
+}
+
+func TestConsistencyReport(t *testing.T) {
+	report := &ConsistencyReport{Tables: 2}
+	require.NoError(t, report.Err())
+	report.Diffs = append(report.Diffs, TableDiff{
+		Schema:           "testdb",
+		Table:            "test",
+		UpstreamRows:     10,
+		DownstreamRows:   9,
+		MismatchedChunks: []ChunkRange{{Lower: 0, Upper: 9}, {Whole: true}},
+	})
+	require.EqualError(t, report.Err(), "1 of 2 tables are inconsistent: "+
+		"testdb.test (rows upstream 10, downstream 9, mismatched chunks [[0, 9] [whole table]]);")
+}
@@ -0,0 +1,427 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/ticdc/pkg/quotes"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+// ddlStormCheckpointTimeout is how long DDLStormTask waits for the checkpoint of
+// the changefeed to pass the end of the storm
+const ddlStormCheckpointTimeout = 5 * time.Minute
+
+// DDLStormConfig configures the DDLs and DMLs run by RunDDLStorm
+type DDLStormConfig struct {
+	Schema string
+	// TablePrefix is the prefix of the names of the tables, which are <TablePrefix>_<n>
+	TablePrefix string
+	// Tables is the number of tables created before the storm by CreateTables
+	Tables int
+	// CreateConcurrency is the number of connections creating the initial tables
+	CreateConcurrency int
+	Duration          time.Duration
+	// DDLConcurrency is the number of workers running DDLs, and DDLInterval is
+	// how long every worker sleeps between two DDLs
+	DDLConcurrency int
+	DDLInterval    time.Duration
+	// The weights of the kinds of DDLs
+	AddColumnWeight   int
+	CreateTableWeight int
+	DropTableWeight   int
+	// DMLConcurrency is the number of workers inserting and updating rows in random tables
+	DMLConcurrency int
+	Seed           int64
+}
+
+// DefaultDDLStormConfig returns a DDLStormConfig with a thousand tables and 20
+// DDLs per second while rows are written to the tables
+func DefaultDDLStormConfig(schema string, tablePrefix string) DDLStormConfig {
+	return DDLStormConfig{
+		Schema:            schema,
+		TablePrefix:       tablePrefix,
+		Tables:            1000,
+		CreateConcurrency: 16,
+		Duration:          time.Minute,
+		DDLConcurrency:    4,
+		DDLInterval:       200 * time.Millisecond,
+		AddColumnWeight:   5,
+		CreateTableWeight: 3,
+		DropTableWeight:   2,
+		DMLConcurrency:    8,
+		Seed:              time.Now().UnixNano(),
+	}
+}
+
+// DDLStormStats are the statistics of a finished storm
+type DDLStormStats struct {
+	AddColumns   int64
+	CreateTables int64
+	DropTables   int64
+	DMLs         int64
+	// Failed is the number of failed DMLs, which are usually caused by the
+	// concurrent DDLs on the same table
+	Failed int64
+	// Tables are the names of the tables existing after the storm, in order
+	Tables []string
+}
+
+// CreateTables creates n tables named <prefix>_<i> for i in [0, n) in the schema
+// with the given number of connections. The tables have an integer primary key
+// `id` and an integer column `value`.
+func CreateTables(ctx context.Context, db *sql.DB, schema string, prefix string, n int, concurrency int) ([]string, error) {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	names := make([]string, n)
+	for i := range names {
+		names[i] = fmt.Sprintf("%s_%d", prefix, i)
+	}
+	var next int64 = -1
+	errg, ctx := errgroup.WithContext(ctx)
+	for i := 0; i < concurrency; i++ {
+		errg.Go(func() error {
+			for {
+				i := atomic.AddInt64(&next, 1)
+				if i >= int64(n) {
+					return nil
+				}
+				if err := createStormTable(ctx, db, schema, names[i]); err != nil {
+					return err
+				}
+			}
+		})
+	}
+	if err := errg.Wait(); err != nil {
+		return nil, err
+	}
+	log.Info("tables created", zap.String("schema", schema), zap.String("prefix", prefix), zap.Int("tables", n))
+	return names, nil
+}
+
+func createStormTable(ctx context.Context, db *sql.DB, schema string, table string) error {
+	_, err := db.ExecContext(ctx, "create table if not exists "+quotes.QuoteSchema(schema, table)+
+		" (id bigint primary key, value bigint)")
+	return errors.Annotatef(err, "failed to create table %s", table)
+}
+
+type ddlKind int
+
+const (
+	ddlAddColumn ddlKind = iota
+	ddlCreateTable
+	ddlDropTable
+)
+
+// RunDDLStorm creates the tables with CreateTables, and then runs concurrent DDLs
+// adding columns to, creating and dropping the tables while rows are written to
+// them, until the duration elapses or ctx is done.
+func RunDDLStorm(ctx context.Context, db *sql.DB, cfg DDLStormConfig) (*DDLStormStats, error) {
+	if cfg.Tables <= 0 || cfg.DDLConcurrency <= 0 || cfg.DMLConcurrency < 0 ||
+		cfg.AddColumnWeight+cfg.CreateTableWeight+cfg.DropTableWeight <= 0 {
+		return nil, errors.Errorf("invalid DDL storm config %+v", cfg)
+	}
+	names, err := CreateTables(ctx, db, cfg.Schema, cfg.TablePrefix, cfg.Tables, cfg.CreateConcurrency)
+	if err != nil {
+		return nil, err
+	}
+	tables := newStormTables(names)
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Duration)
+	defer cancel()
+	log.Info("DDL storm started", zap.Reflect("config", cfg))
+	stats := new(DDLStormStats)
+	errg, ctx := errgroup.WithContext(ctx)
+	for i := 0; i < cfg.DDLConcurrency; i++ {
+		w := &stormWorker{
+			db:     db,
+			cfg:    &cfg,
+			rand:   rand.New(rand.NewSource(cfg.Seed + int64(i))),
+			tables: tables,
+			stats:  stats,
+		}
+		errg.Go(func() error {
+			for {
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-time.After(cfg.DDLInterval):
+				}
+				if err := w.runDDL(ctx); err != nil {
+					return err
+				}
+			}
+		})
+	}
+	for i := 0; i < cfg.DMLConcurrency; i++ {
+		w := &stormWorker{
+			db:     db,
+			cfg:    &cfg,
+			rand:   rand.New(rand.NewSource(cfg.Seed + int64(cfg.DDLConcurrency+i))),
+			tables: tables,
+			stats:  stats,
+		}
+		errg.Go(func() error {
+			for ctx.Err() == nil {
+				w.runDML(ctx)
+			}
+			return nil
+		})
+	}
+	err = errg.Wait()
+	stats.Tables = tables.list()
+	log.Info("DDL storm finished",
+		zap.Int64("addColumns", stats.AddColumns),
+		zap.Int64("createTables", stats.CreateTables),
+		zap.Int64("dropTables", stats.DropTables),
+		zap.Int64("dmls", stats.DMLs),
+		zap.Int64("failed", stats.Failed),
+		zap.Int("tables", len(stats.Tables)),
+		zap.Error(err))
+	return stats, err
+}
+
+// stormTables are the tables existing during the storm. A table being dropped is
+// removed before the DDL is executed, so the DMLs on it are expected to fail.
+type stormTables struct {
+	mu     sync.Mutex
+	names  []string
+	nextID int
+}
+
+func newStormTables(names []string) *stormTables {
+	return &stormTables{names: append([]string(nil), names...), nextID: len(names)}
+}
+
+func (t *stormTables) random(r *rand.Rand) (string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.names) == 0 {
+		return "", false
+	}
+	return t.names[r.Intn(len(t.names))], true
+}
+
+func (t *stormTables) remove(r *rand.Rand) (string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.names) == 0 {
+		return "", false
+	}
+	i := r.Intn(len(t.names))
+	name := t.names[i]
+	t.names[i] = t.names[len(t.names)-1]
+	t.names = t.names[:len(t.names)-1]
+	return name, true
+}
+
+// newName returns the name of a table to be created
+func (t *stormTables) newName(prefix string) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.nextID++
+	return fmt.Sprintf("%s_%d", prefix, t.nextID-1)
+}
+
+func (t *stormTables) add(name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.names = append(t.names, name)
+}
+
+func (t *stormTables) list() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	names := append([]string(nil), t.names...)
+	sort.Strings(names)
+	return names
+}
+
+type stormWorker struct {
+	db     *sql.DB
+	cfg    *DDLStormConfig
+	rand   *rand.Rand
+	tables *stormTables
+	stats  *DDLStormStats
+}
+
+func (w *stormWorker) nextDDL() ddlKind {
+	n := w.rand.Intn(w.cfg.AddColumnWeight + w.cfg.CreateTableWeight + w.cfg.DropTableWeight)
+	switch {
+	case n < w.cfg.AddColumnWeight:
+		return ddlAddColumn
+	case n < w.cfg.AddColumnWeight+w.cfg.CreateTableWeight:
+		return ddlCreateTable
+	default:
+		return ddlDropTable
+	}
+}
+
+// runDDL runs a random DDL, the DDLs are expected to succeed
+func (w *stormWorker) runDDL(ctx context.Context) error {
+	var err error
+	switch w.nextDDL() {
+	case ddlAddColumn:
+		name, ok := w.tables.random(w.rand)
+		if !ok {
+			return nil
+		}
+		column := fmt.Sprintf("c_%d", w.rand.Int63())
+		_, err = w.db.ExecContext(ctx, fmt.Sprintf("alter table %s add column %s bigint default %d",
+			quotes.QuoteSchema(w.cfg.Schema, name), quotes.QuoteName(column), w.rand.Int63n(1000)))
+		if err == nil {
+			atomic.AddInt64(&w.stats.AddColumns, 1)
+		}
+	case ddlCreateTable:
+		name := w.tables.newName(w.cfg.TablePrefix)
+		err = createStormTable(ctx, w.db, w.cfg.Schema, name)
+		if err == nil {
+			w.tables.add(name)
+			atomic.AddInt64(&w.stats.CreateTables, 1)
+		}
+	case ddlDropTable:
+		name, ok := w.tables.remove(w.rand)
+		if !ok {
+			return nil
+		}
+		_, err = w.db.ExecContext(ctx, "drop table "+quotes.QuoteSchema(w.cfg.Schema, name))
+		if err == nil {
+			atomic.AddInt64(&w.stats.DropTables, 1)
+		}
+	}
+	if err != nil && ctx.Err() == nil {
+		// a column may be added to a table being dropped by another worker
+		if strings.Contains(err.Error(), "Error 1146") {
+			return nil
+		}
+		return errors.AddStack(err)
+	}
+	return nil
+}
+
+// runDML inserts or updates a row in a random table, the failures are counted
+func (w *stormWorker) runDML(ctx context.Context) {
+	name, ok := w.tables.random(w.rand)
+	if !ok {
+		return
+	}
+	_, err := w.db.ExecContext(ctx, "insert into "+quotes.QuoteSchema(w.cfg.Schema, name)+
+		" (id, value) values (?, ?) on duplicate key update value = value + 1", w.rand.Int63n(100), w.rand.Int63())
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Debug("DDL storm DML failed", zap.String("table", name), zap.Error(err))
+			atomic.AddInt64(&w.stats.Failed, 1)
+		}
+		return
+	}
+	atomic.AddInt64(&w.stats.DMLs, 1)
+}
+
+// UpstreamTs returns a ts allocated by the PD of db, which is after all the
+// transactions committed before
+func UpstreamTs(ctx context.Context, db *sql.DB) (uint64, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, errors.AddStack(err)
+	}
+	var ts uint64
+	err = tx.QueryRowContext(ctx, "select @@tidb_current_ts").Scan(&ts)
+	_ = tx.Rollback()
+	return ts, errors.AddStack(err)
+}
+
+// DDLStormTask runs a DDL storm in the environment prepared by the embedded Task,
+// asserts the checkpoint of the changefeed passes the end of the storm, and checks
+// the tables existing after the storm are replicated correctly.
+type DDLStormTask struct {
+	Task
+	Config DDLStormConfig
+	// Stats are the statistics of the storm after the task has run
+	Stats *DDLStormStats
+}
+
+// NewDDLStormTask creates a DDLStormTask, base provides the CDCProfile and prepares the databases
+func NewDDLStormTask(base Task, config DDLStormConfig) *DDLStormTask {
+	return &DDLStormTask{Task: base, Config: config}
+}
+
+// Name implements Task
+func (d *DDLStormTask) Name() string {
+	return "DDL-Storm-" + d.Config.TablePrefix
+}
+
+// SetDatabase implements IsolatedTask, the storm runs in the database of base
+// if base is an IsolatedTask.
+func (d *DDLStormTask) SetDatabase(name string) {
+	if isolated, ok := d.Task.(IsolatedTask); ok {
+		isolated.SetDatabase(name)
+		d.Config.Schema = name
+	}
+}
+
+// GetCDCProfile implements Task, the changefeed ID is set so that its checkpoint can be queried
+func (d *DDLStormTask) GetCDCProfile() *CDCProfile {
+	profile := d.Task.GetCDCProfile()
+	if profile.ChangefeedID == "" {
+		profile.ChangefeedID = d.changefeedID()
+	}
+	return profile
+}
+
+func (d *DDLStormTask) changefeedID() string {
+	return "ddl-storm-" + strings.ReplaceAll(d.Config.Schema, "_", "-")
+}
+
+// Run implements Task
+func (d *DDLStormTask) Run(taskContext *TaskContext) error {
+	stats, err := RunDDLStorm(taskContext.Ctx, taskContext.Upstream, d.Config)
+	if err != nil {
+		return err
+	}
+	d.Stats = stats
+	if stats.DMLs == 0 {
+		return errors.New("no DML of the DDL storm succeeded")
+	}
+
+	ts, err := UpstreamTs(taskContext.Ctx, taskContext.Upstream)
+	if err != nil {
+		return err
+	}
+	changefeedID := d.GetCDCProfile().ChangefeedID
+	err = taskContext.Cluster().WaitCheckpoint(changefeedID, ts, ddlStormCheckpointTimeout)
+	if err != nil {
+		return err
+	}
+	for _, table := range stats.Tables {
+		// the sinks to MQ are ahead of the consumers writing to the downstream
+		err := taskContext.TableConsistent(d.Config.Schema, table).Wait().Check()
+		if err != nil {
+			return errors.Annotatef(err, "table %s is not consistent after the checkpoint passed %d", table, ts)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStormTables(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	tables := newStormTables([]string{"t_0", "t_1"})
+	name := tables.newName("t")
+	require.Equal(t, "t_2", name)
+	tables.add(name)
+	require.Equal(t, []string{"t_0", "t_1", "t_2"}, tables.list())
+
+	removed, ok := tables.remove(r)
+	require.True(t, ok)
+	require.NotContains(t, tables.list(), removed)
+	require.Len(t, tables.list(), 2)
+	for i := 0; i < 10; i++ {
+		name, ok := tables.random(r)
+		require.True(t, ok)
+		require.NotEqual(t, removed, name)
+	}
+
+	_, _ = tables.remove(r)
+	_, _ = tables.remove(r)
+	_, ok = tables.random(r)
+	require.False(t, ok)
+	_, ok = tables.remove(r)
+	require.False(t, ok)
+	// the names of the dropped tables are not reused
+	require.Equal(t, "t_3", tables.newName("t"))
+}
+
+func TestDDLStormTask(t *testing.T) {
+	task := NewDDLStormTask(&MySQLSingleTableTask{TableName: "storm"}, DefaultDDLStormConfig("testdb", "storm"))
+	task.SetDatabase("testdb_1")
+	require.Equal(t, "testdb_1", task.Config.Schema)
+	profile := task.GetCDCProfile()
+	require.Equal(t, "ddl-storm-testdb-1", profile.ChangefeedID)
+	require.Equal(t, []string{"testdb_1.*"}, profile.FilterRules)
+
+	config := DefaultDDLStormConfig("testdb", "storm")
+	config.AddColumnWeight, config.CreateTableWeight, config.DropTableWeight = 0, 0, 0
+	_, err := RunDDLStorm(context.Background(), nil, config)
+	require.Error(t, err)
+}
@@ -28,10 +28,34 @@ type dockerComposeOperator struct {
 	fileName      string
 	controller    string
 	healthChecker func() error
+	// tls indicates whether the cluster uses TLS, the credentials are mounted
+	// at /tls in the containers, see TLSDockerEnv.
+	tls bool
+	// report collects the results of the tasks if it is not nil, see SetReportDir
+	report *testReport
+	// artifactsDir is the directory to collect the artifacts of the failed tasks in
+	artifactsDir string
+	// statsInterval is the interval to sample the resource usage of the containers
+	// while a task is running, it's disabled if it's 0, see SetStatsInterval
+	statsInterval time.Duration
+	// scaledCaptures are the names of the containers started by ScaleCDC
+	scaledCaptures map[string]struct{}
+	// compose is the config the docker-compose file is generated from, it's
+	// nil if the file is given by the user, see OverrideCompose
+	compose *ComposeConfig
+	// keepEnv indicates whether the services are kept after the tests and
+	// reused by the next run, see keepEnvVar
+	keepEnv bool
 }
 
 // Setup brings up a docker-compose service
 func (d *dockerComposeOperator) Setup() {
+	d.writeComposeFile()
+	reused := d.keepEnv && d.isUp()
+	if reused {
+		log.Info("Reusing the docker-compose services kept by a previous run", zap.String("file", d.fileName))
+	}
+	// the stopped services are started if the environment is reused
 	cmd := exec.Command("docker-compose", "-f", d.fileName, "up", "--detach")
 	runCmdHandleError(cmd)
 
@@ -41,6 +65,12 @@ func (d *dockerComposeOperator) Setup() {
 			log.Fatal("Docker service health check failed after max retries", zap.Error(err))
 		}
 	}
+	if reused {
+		err := d.cleanupKeptEnv()
+		if err != nil {
+			log.Fatal("Failed to clean up the reused environment", zap.Error(err))
+		}
+	}
 }
 
 func runCmdHandleError(cmd *exec.Cmd) []byte {
@@ -81,7 +111,13 @@ func (d *dockerComposeOperator) DumpStdout() error {
 
 // TearDown terminates a docker-compose service and remove all volumes
 func (d *dockerComposeOperator) TearDown() {
+	d.writeReport()
 	log.Info("Start tearing down docker-compose services")
+	d.removeScaledCaptures()
+	if d.keepEnv {
+		log.Info("Keeping the docker-compose services because "+keepEnvVar+" is set", zap.String("file", d.fileName))
+		return
+	}
 	cmd := exec.Command("docker-compose", "-f", d.fileName, "down", "-v")
 	runCmdHandleError(cmd)
 	log.Info("Finished tearing down docker-compose services")
@@ -15,19 +15,11 @@ package framework
 
 import (
 	"testing"
-
-	"github.com/integralist/go-findroot/find"
-	"github.com/stretchr/testify/assert"
 )
 
 func TestDockerComposeOperator_SetupTearDown(t *testing.T) {
-	st, err := find.Repo()
-	assert.NoError(t, err)
-
-	d := &dockerComposeOperator{
-		fileName:   st.Path + "/docker-compose-avro.yml",
-		controller: "controller0",
-	}
+	d := newDockerComposeOperator("", avroComposeConfig())
+	d.controller = "controller0"
 	d.Setup()
 	d.TearDown()
 }
@@ -13,6 +13,8 @@
 
 package framework
 
+import "time"
+
 // MqListener represents a callback function for listening on the MQ output
 type MqListener func(states interface{}, topic string, key []byte, value []byte) error
 
@@ -22,5 +24,18 @@ type Environment interface {
 	TearDown()
 	Reset()
 	RunTest(Task)
+	// RunTests runs the tasks, at most parallelism IsolatedTasks at a time, and
+	// returns the results in the order of the tasks
+	RunTests(tasks []Task, parallelism int) []TaskResult
 	SetListener(states interface{}, listener MqListener)
+	// SetReportDir sets the directory to write the test reports to when the environment is torn down
+	SetReportDir(dir string)
+	// SetArtifactsDir sets the directory to collect the logs and the state of the cluster in when a task fails
+	SetArtifactsDir(dir string)
+	// SetStatsInterval enables sampling the resource usage of the containers while a task is running
+	SetStatsInterval(interval time.Duration)
+	// OverrideCompose modifies the config the docker-compose file is generated from before the environment is set up
+	OverrideCompose(fn func(config *ComposeConfig))
+	// GenerateComposeFile writes the docker-compose file without setting up the environment, and returns its path
+	GenerateComposeFile() string
 }
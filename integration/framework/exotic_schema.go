@@ -0,0 +1,216 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/ticdc/pkg/quotes"
+	"go.uber.org/zap"
+)
+
+// ExoticTable is a table with a schema that is uncommon or hard to replicate.
+// All the tables have an integer column `id` identifying the rows written by
+// WriteExoticRows and a string column `v`.
+type ExoticTable struct {
+	Name string
+	// Feature is what the table covers
+	Feature string
+	// Create is the create table statement, %s is the quoted name of the table
+	Create string
+	// Insert is the insert statement, %s is the quoted name of the table
+	Insert string
+	// Values returns the values inserted by Insert for the i-th row
+	Values func(i int) []interface{}
+	// Move updates the i-th row so that it's moved to another partition or gets
+	// another primary key, with the id of the row as the only argument. The row
+	// is not moved if it's empty.
+	Move string
+}
+
+// exoticStrings are the strings with the characters that are hard to encode
+var exoticStrings = []string{
+	"", " ", "ascii", "中文字符", "emoji 😀🚀", "ümlaut ß", "tab\tnew\nline", "quote ' \" \\ `",
+}
+
+// ExoticTables returns the tables created by CreateExoticTables. The clustered
+// index requires TiDB 5.0, and utf8mb4_general_ci requires the new collation
+// framework, see ExoticSchemaDockerEnv.
+func ExoticTables() []ExoticTable {
+	return []ExoticTable{{
+		Name:    "range_partitioned",
+		Feature: "range partitions, with the rows moved among the partitions",
+		Create: `create table %s (id bigint primary key, v varchar(64))
+			partition by range (id) (
+				partition p0 values less than (100),
+				partition p1 values less than (1000000),
+				partition p2 values less than maxvalue)`,
+		Insert: "insert into %s (id, v) values (?, ?)",
+		Values: func(i int) []interface{} {
+			return []interface{}{i, exoticString(i)}
+		},
+		Move: "update %s set id = id + 1000000 where id = ?",
+	}, {
+		Name:    "hash_partitioned",
+		Feature: "hash partitions with a unique key on the partition column",
+		Create: `create table %s (id bigint not null, k bigint not null, v varchar(64), unique key uk (k, id))
+			partition by hash (id) partitions 4`,
+		Insert: "insert into %s (id, k, v) values (?, ?, ?)",
+		Values: func(i int) []interface{} {
+			return []interface{}{i, i * 7, exoticString(i)}
+		},
+		Move: "update %s set k = k + 1 where id = ?",
+	}, {
+		Name:    "clustered",
+		Feature: "a clustered index on a composite primary key with a string column",
+		Create: `create table %s (k varchar(64), id bigint, v varchar(64),
+			primary key (k, id) clustered, key idx_v (v))`,
+		Insert: "insert into %s (k, id, v) values (?, ?, ?)",
+		Values: func(i int) []interface{} {
+			return []interface{}{fmt.Sprintf("key-%d", i), i, exoticString(i)}
+		},
+		Move: "update %s set k = concat(k, '-moved') where id = ?",
+	}, {
+		Name:    "generated_columns",
+		Feature: "stored and virtual generated columns, with an index on the virtual one",
+		Create: `create table %s (id bigint primary key, a int, b int, v varchar(64),
+			s bigint as (a + b) stored,
+			g bigint as (a * b) virtual,
+			j json,
+			jv varchar(64) as (json_unquote(json_extract(j, '$.name'))) virtual,
+			key idx_g (g))`,
+		Insert: "insert into %s (id, a, b, v, j) values (?, ?, ?, ?, ?)",
+		Values: func(i int) []interface{} {
+			return []interface{}{i, i, i % 7, exoticString(i), fmt.Sprintf(`{"name": "row-%d"}`, i)}
+		},
+		Move: "update %s set a = a + 1, j = json_set(j, '$.name', 'moved') where id = ?",
+	}, {
+		Name:    "charsets",
+		Feature: "mixed charsets and collations, with a case-insensitive unique key",
+		Create: `create table %s (id bigint primary key,
+			v varchar(64) charset utf8mb4 collate utf8mb4_bin,
+			ci varchar(64) charset utf8mb4 collate utf8mb4_general_ci,
+			l varchar(64) charset latin1,
+			a varchar(64) charset ascii,
+			b varbinary(64),
+			unique key uk_ci (ci)) default charset = utf8mb4`,
+		Insert: "insert into %s (id, v, ci, l, a, b) values (?, ?, ?, ?, ?, ?)",
+		Values: func(i int) []interface{} {
+			return []interface{}{
+				i, exoticString(i), fmt.Sprintf("Case-%d", i), fmt.Sprintf("café %d", i),
+				fmt.Sprintf("ascii %d", i), []byte{0, byte(i), 0xff, '\''},
+			}
+		},
+		// only the case is changed, which is the same key for the unique key
+		Move: "update %s set ci = upper(ci) where id = ?",
+	}}
+}
+
+func exoticString(i int) string {
+	return exoticStrings[i%len(exoticStrings)]
+}
+
+// CreateExoticTables creates all the ExoticTables in the schema
+func CreateExoticTables(ctx context.Context, db *sql.DB, schema string) error {
+	for _, table := range ExoticTables() {
+		_, err := db.ExecContext(ctx, fmt.Sprintf(table.Create, quotes.QuoteSchema(schema, table.Name)))
+		if err != nil {
+			return errors.Annotatef(err, "failed to create table %s for %s", table.Name, table.Feature)
+		}
+	}
+	return nil
+}
+
+// WriteExoticRows inserts n rows into every table created by CreateExoticTables,
+// and then updates the string column of the even rows, moves every third row,
+// and deletes every fourth row which is not moved.
+func WriteExoticRows(ctx context.Context, db *sql.DB, schema string, n int) error {
+	for _, table := range ExoticTables() {
+		name := quotes.QuoteSchema(schema, table.Name)
+		exec := func(query string, args ...interface{}) error {
+			_, err := db.ExecContext(ctx, fmt.Sprintf(query, name), args...)
+			return errors.Annotatef(err, "failed to write table %s", table.Name)
+		}
+		for i := 0; i < n; i++ {
+			if err := exec(table.Insert, table.Values(i)...); err != nil {
+				return err
+			}
+		}
+		for i := 0; i < n; i++ {
+			var err error
+			switch {
+			case i%3 == 0 && table.Move != "":
+				err = exec(table.Move, i)
+			case i%4 == 1:
+				err = exec("delete from %s where id = ?", i)
+			case i%2 == 0:
+				err = exec("update %s set v = ? where id = ?", exoticString(i+1), i)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		log.Info("exotic rows written", zap.String("table", table.Name), zap.Int("rows", n))
+	}
+	return nil
+}
+
+// ExoticSchemaTask writes the ExoticTables in the environment prepared by the embedded
+// Task, and checks they are replicated correctly.
+type ExoticSchemaTask struct {
+	Task
+	// Rows is the number of rows written to every table
+	Rows int
+}
+
+// NewExoticSchemaTask creates an ExoticSchemaTask, base provides the CDCProfile and prepares the databases
+func NewExoticSchemaTask(base Task, rows int) *ExoticSchemaTask {
+	return &ExoticSchemaTask{Task: base, Rows: rows}
+}
+
+// Name implements Task
+func (e *ExoticSchemaTask) Name() string {
+	return "Exotic-Schema"
+}
+
+// SetDatabase implements IsolatedTask, the tables are created in the database of
+// base if base is an IsolatedTask.
+func (e *ExoticSchemaTask) SetDatabase(name string) {
+	if isolated, ok := e.Task.(IsolatedTask); ok {
+		isolated.SetDatabase(name)
+	}
+}
+
+// Run implements Task
+func (e *ExoticSchemaTask) Run(taskContext *TaskContext) error {
+	err := CreateExoticTables(taskContext.Ctx, taskContext.Upstream, taskContext.Database)
+	if err != nil {
+		return err
+	}
+	err = WriteExoticRows(taskContext.Ctx, taskContext.Upstream, taskContext.Database, e.Rows)
+	if err != nil {
+		return err
+	}
+	for _, table := range ExoticTables() {
+		err := taskContext.TableConsistent(taskContext.Database, table.Name).Wait().Check()
+		if err != nil {
+			return errors.Annotatef(err, "%s is not replicated correctly", table.Feature)
+		}
+	}
+	return nil
+}
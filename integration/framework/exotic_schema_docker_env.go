@@ -0,0 +1,85 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"time"
+
+	"github.com/pingcap/ticdc/pkg/retry"
+)
+
+const (
+	// the address of the downstream TiDB inside the docker network
+	tidbDownstreamSinkURI = "mysql://root@downstream-tidb:4000/"
+)
+
+// ExoticSchemaDockerEnv represents the docker-compose service generated from exoticComposeConfig,
+// in which the upstream TiDB cluster is replicated to a downstream TiDB cluster with the MySQL
+// sink, and both clusters enable the new collation framework, so that the partitioned tables,
+// the clustered indexes, the generated columns and the collations of CreateExoticTables are
+// supported in both of them. The tasks for MySQLDockerEnv can run in it unchanged, their
+// CDCProfiles are rewritten to replicate to the downstream TiDB.
+type ExoticSchemaDockerEnv struct {
+	dockerComposeOperator
+}
+
+// NewExoticSchemaDockerEnv creates a new ExoticSchemaDockerEnv
+func NewExoticSchemaDockerEnv(dockerComposeFile string) *ExoticSchemaDockerEnv {
+	healthChecker := func() error {
+		return pingDatabases(upstreamDSN, downstreamDSN)
+	}
+
+	env := &ExoticSchemaDockerEnv{newDockerComposeOperator(dockerComposeFile, exoticComposeConfig())}
+	env.controller = controllerContainerName
+	env.healthChecker = healthChecker
+	return env
+}
+
+// Reset implements Environment
+func (e *ExoticSchemaDockerEnv) Reset() {
+	e.TearDown()
+	e.Setup()
+}
+
+// RunTest implements Environment
+func (e *ExoticSchemaDockerEnv) RunTest(task Task) {
+	runTask(&e.dockerComposeOperator, e, wrapProfileTask(task, tidbDownstreamProfile), func() error {
+		return retry.Run(time.Second, 120, e.healthChecker)
+	})
+}
+
+// RunTests implements Environment
+func (e *ExoticSchemaDockerEnv) RunTests(tasks []Task, parallelism int) []TaskResult {
+	wrapped := make([]Task, len(tasks))
+	for i, task := range tasks {
+		wrapped[i] = wrapProfileTask(task, tidbDownstreamProfile)
+	}
+	return runTasks(&e.dockerComposeOperator, e, wrapped, parallelism, func() error {
+		return retry.Run(time.Second, 120, e.healthChecker)
+	})
+}
+
+// SetListener implements Environment. The MySQL sink has no MQ output, so it does nothing.
+func (e *ExoticSchemaDockerEnv) SetListener(states interface{}, listener MqListener) {
+}
+
+// tidbDownstreamProfile returns a copy of the profile which replicates to the
+// downstream TiDB instead of the downstream MySQL
+func tidbDownstreamProfile(profile *CDCProfile) *CDCProfile {
+	ret := *profile
+	if ret.SinkURI == mysqlDownstreamSinkURI {
+		ret.SinkURI = tidbDownstreamSinkURI
+	}
+	return &ret
+}
@@ -0,0 +1,57 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExoticTables(t *testing.T) {
+	names := make(map[string]bool)
+	for _, table := range ExoticTables() {
+		require.False(t, names[table.Name], table.Name)
+		names[table.Name] = true
+		require.NotEmpty(t, table.Feature)
+		require.Equal(t, 1, strings.Count(table.Create, "%s"), table.Name)
+		require.Equal(t, 1, strings.Count(table.Insert, "%s"), table.Name)
+		require.Equal(t, strings.Count(table.Insert, "?"), len(table.Values(1)), table.Name)
+		if table.Move != "" {
+			require.True(t, strings.HasSuffix(table.Move, "where id = ?"), table.Name)
+		}
+		require.NotContains(t, fmt.Sprintf(table.Create, "`testdb`.`t`"), "%!")
+	}
+}
+
+func TestExoticSchemaEnv(t *testing.T) {
+	data, err := exoticComposeConfig().Render()
+	require.NoError(t, err)
+	out := string(data)
+	require.Contains(t, out, "./docker/config/tidb-new-collation.toml:/tidb.toml:ro")
+	require.NotContains(t, out, "./docker/config/tidb.toml")
+	require.Contains(t, out, "\n  downstream-tidb:\n")
+
+	task := wrapProfileTask(NewExoticSchemaTask(&MySQLSingleTableTask{TableName: "test"}, 10), tidbDownstreamProfile)
+	_, ok := task.(IsolatedTask)
+	require.True(t, ok)
+	require.Equal(t, tidbDownstreamSinkURI, task.GetCDCProfile().SinkURI)
+	require.Equal(t, "Exotic-Schema", task.Name())
+
+	config := exoticComposeConfig()
+	config.TLS = true
+	require.Error(t, config.Validate())
+}
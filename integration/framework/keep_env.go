@@ -0,0 +1,272 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/ticdc/pkg/quotes"
+	"go.uber.org/zap"
+)
+
+const (
+	// keepEnvVar is the environment variable enabling the KEEP_ENV mode, in which
+	// the docker-compose services are not torn down, and are reused by the next
+	// run if they are still running.
+	keepEnvVar = "KEEP_ENV"
+
+	kafkaConnectHostURL       = "http://127.0.0.1:8083"
+	kafkaConnectorPrefix      = "jdbc-sink-connector-"
+	keepEnvTopicDeleteTimeout = 30 * time.Second
+)
+
+// keepEnvEnabled returns whether KEEP_ENV is set to a true value
+func keepEnvEnabled() bool {
+	switch strings.ToLower(os.Getenv(keepEnvVar)) {
+	case "", "0", "false", "no", "off":
+		return false
+	}
+	return true
+}
+
+// isUp returns whether the services of the docker-compose file have been brought up
+func (d *dockerComposeOperator) isUp() bool {
+	_, err := d.containerID("controller")
+	return err == nil
+}
+
+// belongsToDatabase returns whether the database, or the Kafka topic, Kafka Connect
+// connector or schema registry subject named name belongs to database. They belong
+// to all the databases with the prefix database if prefix is true.
+func belongsToDatabase(name string, database string, prefix bool) bool {
+	if prefix {
+		return strings.HasPrefix(name, database)
+	}
+	return name == database || strings.HasPrefix(name, database+"_")
+}
+
+// cleanupKeptEnv removes the changefeeds and the data left by the previous runs
+// in a reused environment.
+func (d *dockerComposeOperator) cleanupKeptEnv() error {
+	log.Info("Cleaning up the reused environment")
+	out, err := d.ExecCDCCli("changefeed list --all")
+	if err != nil {
+		return err
+	}
+	var changefeeds []struct {
+		ID string `json:"id"`
+	}
+	err = json.Unmarshal(out, &changefeeds)
+	if err != nil {
+		return errors.Annotatef(err, "invalid changefeed list: %s", out)
+	}
+	for _, changefeed := range changefeeds {
+		_, err := d.ExecCDCCli("changefeed remove --changefeed-id=" + changefeed.ID)
+		if err != nil {
+			return err
+		}
+		log.Info("Removed the changefeed of a previous run", zap.String("changefeed", changefeed.ID))
+	}
+	return d.cleanupDatabase(defaultDatabase, true)
+}
+
+// cleanupDatabase drops the database in the upstream and the downstream, and
+// removes the Kafka and Pulsar topics, the Kafka Connect connectors and the schema registry
+// subjects of it, see belongsToDatabase. The services not in the environment are skipped.
+func (d *dockerComposeOperator) cleanupDatabase(database string, prefix bool) error {
+	for _, dsn := range []string{upstreamDSN, downstreamDSN} {
+		err := dropDatabases(dsn, database, prefix)
+		if err != nil {
+			return err
+		}
+	}
+	if kafkaID, err := d.containerID("kafka"); err == nil {
+		err := deleteKafkaTopics(kafkaID, database, prefix)
+		if err != nil {
+			return err
+		}
+	}
+	if pulsarID, err := d.containerID("pulsar"); err == nil {
+		err := deletePulsarTopics(pulsarID, database, prefix)
+		if err != nil {
+			return err
+		}
+	}
+	if _, err := d.containerID("kafka-connect-01"); err == nil {
+		err := deleteKafkaConnectors(database, prefix)
+		if err != nil {
+			return err
+		}
+	}
+	if _, err := d.containerID("schema-registry"); err == nil {
+		registry, err := d.schemaRegistry()
+		if err != nil {
+			return err
+		}
+		subjects, err := registry.Subjects()
+		if err != nil {
+			return err
+		}
+		for _, subject := range subjects {
+			if belongsToDatabase(subject, database, prefix) {
+				err := registry.DeleteSubject(subject)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func dropDatabases(dsn string, database string, prefix bool) error {
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return errors.AddStack(err)
+	}
+	defer db.Close()
+	rows, err := db.Query("show databases")
+	if err != nil {
+		return errors.AddStack(err)
+	}
+	var databases []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			_ = rows.Close()
+			return errors.AddStack(err)
+		}
+		if name == database || (prefix && strings.HasPrefix(name, database)) {
+			databases = append(databases, name)
+		}
+	}
+	_ = rows.Close()
+	for _, name := range databases {
+		_, err := db.Exec("drop database if exists " + quotes.QuoteName(name))
+		if err != nil {
+			return errors.AddStack(err)
+		}
+		log.Info("Dropped the database of a previous run", zap.String("dsn", dsn), zap.String("database", name))
+	}
+	return nil
+}
+
+// deleteKafkaTopics deletes the topics with the tools in the Kafka container,
+// which connect to ZooKeeper so that they work with TLS as well, and waits
+// until the topics are deleted.
+func deleteKafkaTopics(kafkaID string, database string, prefix bool) error {
+	pattern := regexp.QuoteMeta(database) + "_.*"
+	if prefix {
+		pattern = regexp.QuoteMeta(database) + ".*"
+	}
+	topicsCmd := func(args ...string) ([]byte, error) {
+		args = append([]string{"exec", kafkaID, "kafka-topics", "--zookeeper", "zookeeper:2181"}, args...)
+		return runCmd(exec.Command("docker", args...))
+	}
+	_, err := topicsCmd("--delete", "--if-exists", "--topic", pattern)
+	if err != nil {
+		return err
+	}
+	deadline := time.Now().Add(keepEnvTopicDeleteTimeout)
+	for {
+		out, err := topicsCmd("--list")
+		if err != nil {
+			return err
+		}
+		var remaining []string
+		for _, topic := range strings.Fields(string(out)) {
+			if belongsToDatabase(topic, database, prefix) {
+				remaining = append(remaining, topic)
+			}
+		}
+		if len(remaining) == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return errors.Errorf("topics %v are not deleted after %s", remaining, keepEnvTopicDeleteTimeout)
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// deletePulsarTopics deletes the topics in the default namespace with the tools
+// in the Pulsar container, along with their subscriptions.
+func deletePulsarTopics(pulsarID string, database string, prefix bool) error {
+	adminCmd := func(args ...string) ([]byte, error) {
+		args = append([]string{"exec", pulsarID, "bin/pulsar-admin", "topics"}, args...)
+		return runCmd(exec.Command("docker", args...))
+	}
+	out, err := adminCmd("list", pulsarNamespace)
+	if err != nil {
+		return err
+	}
+	for _, topic := range strings.Fields(string(out)) {
+		if !belongsToDatabase(topic[strings.LastIndex(topic, "/")+1:], database, prefix) {
+			continue
+		}
+		_, err := adminCmd("delete", "--force", topic)
+		if err != nil {
+			return err
+		}
+		log.Info("Deleted the Pulsar topic of a previous run", zap.String("topic", topic))
+	}
+	return nil
+}
+
+func deleteKafkaConnectors(database string, prefix bool) error {
+	resp, err := http.Get(kafkaConnectHostURL + "/connectors")
+	if err != nil {
+		return errors.AddStack(err)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return errors.AddStack(err)
+	}
+	var connectors []string
+	err = json.Unmarshal(body, &connectors)
+	if err != nil {
+		return errors.Annotatef(err, "invalid connector list: %s", body)
+	}
+	for _, connector := range connectors {
+		if !strings.HasPrefix(connector, kafkaConnectorPrefix) ||
+			!belongsToDatabase(strings.TrimPrefix(connector, kafkaConnectorPrefix), database, prefix) {
+			continue
+		}
+		req, err := http.NewRequest(http.MethodDelete, kafkaConnectHostURL+"/connectors/"+connector, nil)
+		if err != nil {
+			return errors.AddStack(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return errors.AddStack(err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode >= 300 && resp.StatusCode != http.StatusNotFound {
+			return errors.Errorf("Kafka Connect Rest API returned status code %d when deleting %s",
+				resp.StatusCode, connector)
+		}
+	}
+	return nil
+}
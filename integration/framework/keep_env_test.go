@@ -0,0 +1,54 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeepEnvEnabled(t *testing.T) {
+	old, ok := os.LookupEnv(keepEnvVar)
+	defer func() {
+		if ok {
+			os.Setenv(keepEnvVar, old)
+		} else {
+			os.Unsetenv(keepEnvVar)
+		}
+	}()
+
+	for value, expected := range map[string]bool{
+		"": false, "0": false, "false": false, "OFF": false,
+		"1": true, "true": true, "yes": true,
+	} {
+		os.Setenv(keepEnvVar, value)
+		require.Equal(t, expected, keepEnvEnabled(), value)
+		require.Equal(t, expected, newDockerComposeOperator("docker-compose.yml", nil).keepEnv, value)
+	}
+}
+
+func TestBelongsToDatabase(t *testing.T) {
+	require.True(t, belongsToDatabase("testdb_1", "testdb_1", false))
+	require.True(t, belongsToDatabase("testdb_1_test", "testdb_1", false))
+	require.True(t, belongsToDatabase("testdb_1_test-value", "testdb_1", false))
+	require.False(t, belongsToDatabase("testdb_10_test", "testdb_1", false))
+	require.False(t, belongsToDatabase("testdb_10", "testdb_1", false))
+
+	require.True(t, belongsToDatabase("testdb", "testdb", true))
+	require.True(t, belongsToDatabase("testdb_10_test", "testdb", true))
+	require.False(t, belongsToDatabase("_schemas", "testdb", true))
+	require.False(t, belongsToDatabase("docker-connect-configs", "testdb", true))
+}
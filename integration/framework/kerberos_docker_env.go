@@ -0,0 +1,121 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/ticdc/pkg/retry"
+)
+
+const (
+	// the directory of the Kerberos files relative to the docker-compose file,
+	// they are written by the KDC container
+	kerberosDir = "docker/kerberos"
+
+	kerberosPrincipal          = "ticdc@TICDC.TEST"
+	kerberosContainerKeytab    = "/kerberos/ticdc.keytab"
+	kerberosContainerKrb5Conf  = "/kerberos/krb5.conf"
+	kerberosCleanupImage       = "alpine:3.12"
+	kerberosKeytabCheckCommand = "test -f " + kerberosContainerKeytab
+)
+
+// KerberosDockerEnv represents the docker-compose service generated from kerberosComposeConfig.
+// It's the same as CanalJSONKafkaDockerEnv except that the captures authenticate to Kafka
+// with SASL/GSSAPI, with the principals and the keytabs created by a KDC in the environment.
+// The tasks for CanalJSONKafkaDockerEnv can run in it unchanged, their CDCProfiles are
+// rewritten to use Kerberos.
+type KerberosDockerEnv struct {
+	dockerComposeOperator
+}
+
+// NewKerberosDockerEnv creates a new KerberosDockerEnv
+func NewKerberosDockerEnv(dockerComposeFile string) *KerberosDockerEnv {
+	env := &KerberosDockerEnv{newDockerComposeOperator(dockerComposeFile, kerberosComposeConfig())}
+	env.controller = controllerContainerName
+	env.healthChecker = func() error {
+		err := pingDatabases(upstreamDSN, downstreamDSN)
+		if err != nil {
+			return err
+		}
+		// the captures can't authenticate before the KDC exports the keytab
+		_, err = env.ExecInController(kerberosKeytabCheckCommand)
+		if err != nil {
+			return errors.Annotate(err, "keytab not ready")
+		}
+		client, err := sarama.NewClient([]string{kafkaHostAddr}, sarama.NewConfig())
+		if err != nil {
+			return errors.Annotate(err, "kafka not ready")
+		}
+		return errors.AddStack(client.Close())
+	}
+	return env
+}
+
+// Setup removes the Kerberos files of the previous runs, which are written again
+// by the KDC, and brings up the docker-compose service. The files are kept if the
+// running services are reused, see keepEnvVar.
+func (e *KerberosDockerEnv) Setup() {
+	if e.keepEnv && e.isUp() {
+		e.dockerComposeOperator.Setup()
+		return
+	}
+	// the files are owned by root, so they are removed in a container
+	dir := filepath.Join(filepath.Dir(e.fileName), kerberosDir)
+	runCmdHandleError(exec.Command("docker", "run", "--rm", "-v", dir+":/kerberos", kerberosCleanupImage,
+		"sh", "-c", "rm -rf /kerberos/*"))
+	e.dockerComposeOperator.Setup()
+}
+
+// Reset implements Environment
+func (e *KerberosDockerEnv) Reset() {
+	e.TearDown()
+	e.Setup()
+}
+
+// RunTest implements Environment
+func (e *KerberosDockerEnv) RunTest(task Task) {
+	runTask(&e.dockerComposeOperator, e, wrapProfileTask(task, kerberosProfile), func() error {
+		return retry.Run(time.Second, 120, e.healthChecker)
+	})
+}
+
+// RunTests implements Environment
+func (e *KerberosDockerEnv) RunTests(tasks []Task, parallelism int) []TaskResult {
+	wrapped := make([]Task, len(tasks))
+	for i, task := range tasks {
+		wrapped[i] = wrapProfileTask(task, kerberosProfile)
+	}
+	return runTasks(&e.dockerComposeOperator, e, wrapped, parallelism, func() error {
+		return retry.Run(time.Second, 120, e.healthChecker)
+	})
+}
+
+// SetListener implements Environment. Currently unfinished, will be used to monitor Kafka output
+func (e *KerberosDockerEnv) SetListener(states interface{}, listener MqListener) {
+	// TODO
+}
+
+// kerberosProfile returns a copy of the profile which authenticates to Kafka with Kerberos
+func kerberosProfile(profile *CDCProfile) *CDCProfile {
+	ret := *profile
+	ret.KerberosPrincipal = kerberosPrincipal
+	ret.KerberosKeytabPath = kerberosContainerKeytab
+	ret.KerberosConfigPath = kerberosContainerKrb5Conf
+	return &ret
+}
@@ -0,0 +1,51 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKerberosProfile(t *testing.T) {
+	task := wrapProfileTask(&CanalJSONSingleTableTask{TableName: "test"}, kerberosProfile)
+	_, ok := task.(IsolatedTask)
+	require.True(t, ok)
+
+	profile := task.GetCDCProfile()
+	require.Equal(t, kerberosPrincipal, profile.KerberosPrincipal)
+	require.Equal(t, "kafka://kafka:29092/testdb_test?protocol=canal-json&partition-num=1", profile.SinkURI)
+	require.Equal(t, "kafka://kafka:29092/testdb_test?partition-num=1&protocol=canal-json&"+
+		"sasl-gssapi-kerberos-config-path=%2Fkerberos%2Fkrb5.conf&sasl-gssapi-keytab-path=%2Fkerberos%2Fticdc.keytab&"+
+		"sasl-gssapi-realm=TICDC.TEST&sasl-gssapi-user=ticdc&sasl-mechanism=gssapi", profile.sinkURI())
+}
+
+func TestKerberosComposeConfig(t *testing.T) {
+	config := kerberosComposeConfig()
+	config.KafkaBrokers = 2
+	data, err := config.Render()
+	require.NoError(t, err)
+	out := string(data)
+	require.Contains(t, out, "\n  kdc:\n")
+	require.Contains(t, out, `kadmin.local -q "ktadd -k /kerberos/kafka-1.keytab.tmp kafka/kafka-1@TICDC.TEST"`)
+	require.Contains(t, out, "KAFKA_ADVERTISED_LISTENERS: SASL_PLAINTEXT://kafka-1:29092,PLAINTEXT://kafka-1:29093,PLAINTEXT_HOST://localhost:9093")
+	require.Contains(t, out, "-Djava.security.auth.login.config=/kerberos/kafka-1_jaas.conf")
+	require.Contains(t, out, "      - ./docker/kerberos:/kerberos:ro\n    entrypoint: \"/cdc server\"")
+	require.NotContains(t, out, "ConfluentMetricsReporter")
+	require.Contains(t, config.Hosts(), "kdc")
+
+	config.TLS = true
+	require.Error(t, config.Validate())
+}
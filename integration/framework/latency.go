@@ -0,0 +1,376 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/ticdc/pkg/quotes"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+// LatencyConfig configures the end-to-end latency measured by MeasureMySQLLatency
+// and MeasureKafkaLatency
+type LatencyConfig struct {
+	Schema string
+	// Table is created if it doesn't exist, with an integer primary key `id` and
+	// an integer column `written_at`, which is the time the row is written in
+	// nanoseconds since the epoch.
+	Table    string
+	Duration time.Duration
+	// Interval is the interval between two rows written to the upstream
+	Interval time.Duration
+	// PollInterval is the interval of querying the downstream for new rows
+	PollInterval time.Duration
+	// DrainTimeout is how long to wait for the rows not replicated yet after
+	// the writes stop, the rows still missing are counted as lost.
+	DrainTimeout time.Duration
+	// MaxP50 and MaxP99 are the thresholds of the latency, the measurement
+	// fails if they are exceeded. 0 means no threshold.
+	MaxP50 time.Duration
+	MaxP99 time.Duration
+}
+
+// DefaultLatencyConfig returns a LatencyConfig writing 10 rows per second for 30 seconds
+func DefaultLatencyConfig(schema string, table string) LatencyConfig {
+	return LatencyConfig{
+		Schema:       schema,
+		Table:        table,
+		Duration:     30 * time.Second,
+		Interval:     100 * time.Millisecond,
+		PollInterval: 10 * time.Millisecond,
+		DrainTimeout: time.Minute,
+	}
+}
+
+// LatencyStats are the latencies from the commit of the rows in the upstream
+// to their visibility in the sink
+type LatencyStats struct {
+	Samples int
+	// Lost is the number of rows not replicated before the drain timeout
+	Lost int
+	Min  time.Duration
+	P50  time.Duration
+	P90  time.Duration
+	P99  time.Duration
+	Max  time.Duration
+}
+
+func (s *LatencyStats) String() string {
+	return fmt.Sprintf("samples=%d lost=%d min=%s p50=%s p90=%s p99=%s max=%s",
+		s.Samples, s.Lost, s.Min, s.P50, s.P90, s.P99, s.Max)
+}
+
+// check returns an error if a row is lost or a threshold is exceeded
+func (s *LatencyStats) check(cfg *LatencyConfig) error {
+	if s.Lost > 0 {
+		return errors.Errorf("%d of %d rows are not replicated within %s", s.Lost, s.Lost+s.Samples, cfg.DrainTimeout)
+	}
+	if s.Samples == 0 {
+		return errors.New("no latency is measured")
+	}
+	if cfg.MaxP50 > 0 && s.P50 > cfg.MaxP50 {
+		return errors.Errorf("p50 latency %s exceeds %s", s.P50, cfg.MaxP50)
+	}
+	if cfg.MaxP99 > 0 && s.P99 > cfg.MaxP99 {
+		return errors.Errorf("p99 latency %s exceeds %s", s.P99, cfg.MaxP99)
+	}
+	return nil
+}
+
+// latencyRecorder records when every row is committed upstream and when it's
+// observed in the sink. A row may be observed before its commit is recorded,
+// so the latencies are only computed in stats.
+type latencyRecorder struct {
+	mu        sync.Mutex
+	committed map[int64]time.Time
+	observed  map[int64]time.Time
+}
+
+func newLatencyRecorder() *latencyRecorder {
+	return &latencyRecorder{
+		committed: make(map[int64]time.Time),
+		observed:  make(map[int64]time.Time),
+	}
+}
+
+func (r *latencyRecorder) commit(id int64, t time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.committed[id] = t
+}
+
+func (r *latencyRecorder) observe(id int64, t time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.observed[id]; !ok {
+		r.observed[id] = t
+	}
+}
+
+// minPending returns the smallest id committed but not observed, or the next
+// id to write if every committed row is observed.
+func (r *latencyRecorder) minPending() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	min := int64(len(r.committed))
+	for id := range r.committed {
+		if _, ok := r.observed[id]; !ok && id < min {
+			min = id
+		}
+	}
+	return min
+}
+
+func (r *latencyRecorder) pending() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for id := range r.committed {
+		if _, ok := r.observed[id]; !ok {
+			n++
+		}
+	}
+	return n
+}
+
+func (r *latencyRecorder) stats() *LatencyStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stats := new(LatencyStats)
+	latencies := make([]time.Duration, 0, len(r.committed))
+	for id, committedAt := range r.committed {
+		observedAt, ok := r.observed[id]
+		if !ok {
+			stats.Lost++
+			continue
+		}
+		latency := observedAt.Sub(committedAt)
+		if latency < 0 {
+			latency = 0
+		}
+		latencies = append(latencies, latency)
+	}
+	stats.Samples = len(latencies)
+	if stats.Samples == 0 {
+		return stats
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	percentile := func(p float64) time.Duration {
+		i := int(float64(len(latencies))*p+0.5) - 1
+		if i < 0 {
+			i = 0
+		}
+		return latencies[i]
+	}
+	stats.Min = latencies[0]
+	stats.P50 = percentile(0.5)
+	stats.P90 = percentile(0.9)
+	stats.P99 = percentile(0.99)
+	stats.Max = latencies[len(latencies)-1]
+	return stats
+}
+
+// MeasureMySQLLatency writes timestamped rows to upstream and polls downstream
+// until they are visible, and returns the latencies of the rows. It fails if a
+// row is lost or a threshold in cfg is exceeded.
+func MeasureMySQLLatency(ctx context.Context, upstream *sql.DB, downstream *sql.DB, cfg LatencyConfig) (*LatencyStats, error) {
+	query := fmt.Sprintf("select id from %s where id >= ?", quotes.QuoteSchema(cfg.Schema, cfg.Table))
+	return measureLatency(ctx, upstream, &cfg, newLatencyRecorder(), func(ctx context.Context, r *latencyRecorder) error {
+		ticker := time.NewTicker(cfg.PollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+			rows, err := downstream.QueryContext(ctx, query, r.minPending())
+			if err != nil {
+				// the table may not be created in the downstream yet
+				log.Debug("failed to poll the downstream", zap.Error(err))
+				continue
+			}
+			now := time.Now()
+			for rows.Next() {
+				var id int64
+				if err := rows.Scan(&id); err != nil {
+					_ = rows.Close()
+					return errors.AddStack(err)
+				}
+				r.observe(id, now)
+			}
+			_ = rows.Close()
+		}
+	})
+}
+
+// MeasureKafkaLatency writes timestamped rows to upstream and waits until they
+// are consumed by verifier, and returns the latencies of the rows. The rows are
+// visible when the consumer on the host receives the messages of them. It fails
+// if a row is lost or a threshold in cfg is exceeded.
+func MeasureKafkaLatency(ctx context.Context, upstream *sql.DB, verifier *TopicVerifier, cfg LatencyConfig) (*LatencyStats, error) {
+	r := newLatencyRecorder()
+	verifier.OnRowChange(func(change *RowChange) {
+		if change.Table != cfg.Table || change.Delete {
+			return
+		}
+		id, err := strconv.ParseInt(mqValueString(change.Columns["id"]), 10, 64)
+		if err != nil {
+			log.Warn("invalid id of the latency row", zap.Reflect("change", change))
+			return
+		}
+		r.observe(id, time.Now())
+	})
+	return measureLatency(ctx, upstream, &cfg, r, nil)
+}
+
+// measureLatency writes the rows recorded by r, and polls the sink with poll
+// if it's not nil, until every row is observed or the drain timeout elapses.
+func measureLatency(
+	ctx context.Context, upstream *sql.DB, cfg *LatencyConfig, r *latencyRecorder, poll func(ctx context.Context, r *latencyRecorder) error,
+) (*LatencyStats, error) {
+	if cfg.Interval <= 0 || cfg.Duration <= 0 || (poll != nil && cfg.PollInterval <= 0) {
+		return nil, errors.Errorf("invalid latency config %+v", *cfg)
+	}
+	_, err := upstream.ExecContext(ctx, fmt.Sprintf(
+		"create table if not exists %s (id bigint primary key, written_at bigint)",
+		quotes.QuoteSchema(cfg.Schema, cfg.Table)))
+	if err != nil {
+		return nil, errors.AddStack(err)
+	}
+
+	log.Info("latency measurement started", zap.Reflect("config", cfg))
+	measureCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	errg, measureCtx := errgroup.WithContext(measureCtx)
+	if poll != nil {
+		errg.Go(func() error {
+			return poll(measureCtx, r)
+		})
+	}
+	errg.Go(func() error {
+		defer cancel()
+		err := writeLatencyRows(measureCtx, upstream, cfg, r)
+		if err != nil {
+			return err
+		}
+		deadline := time.Now().Add(cfg.DrainTimeout)
+		for r.pending() > 0 && time.Now().Before(deadline) {
+			select {
+			case <-measureCtx.Done():
+				return nil
+			case <-time.After(100 * time.Millisecond):
+			}
+		}
+		return nil
+	})
+	err = errg.Wait()
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	stats := r.stats()
+	log.Info("latency measurement finished",
+		zap.String("table", cfg.Table), zap.Stringer("stats", stats))
+	return stats, stats.check(cfg)
+}
+
+// writeLatencyRows writes a row every interval until the duration elapses, and
+// records the time every row is committed.
+func writeLatencyRows(ctx context.Context, db *sql.DB, cfg *LatencyConfig, r *latencyRecorder) error {
+	query := fmt.Sprintf("insert into %s (id, written_at) values (?, ?)", quotes.QuoteSchema(cfg.Schema, cfg.Table))
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+	deadline := time.Now().Add(cfg.Duration)
+	for id := int64(0); time.Now().Before(deadline); id++ {
+		_, err := db.ExecContext(ctx, query, id, time.Now().UnixNano())
+		if err != nil {
+			return errors.AddStack(err)
+		}
+		r.commit(id, time.Now())
+		select {
+		case <-ctx.Done():
+			return errors.Trace(ctx.Err())
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// LatencyTask measures the end-to-end latency in the environment prepared by the
+// embedded Task, which fails if a threshold in the config is exceeded.
+type LatencyTask struct {
+	Task
+	Config LatencyConfig
+	// Decoder decodes the messages of the topic `<schema>_<table>` if the latency
+	// is measured in Kafka, otherwise the latency is measured in the downstream.
+	Decoder MQDecoder
+	// Stats are the latencies after the task has run
+	Stats *LatencyStats
+}
+
+// NewMySQLLatencyTask creates a LatencyTask measuring the latency of the rows in
+// the downstream database, base provides the CDCProfile and prepares the databases
+func NewMySQLLatencyTask(base Task, config LatencyConfig) *LatencyTask {
+	return &LatencyTask{Task: base, Config: config}
+}
+
+// NewKafkaLatencyTask creates a LatencyTask measuring the latency of the messages
+// in Kafka, the changefeed created by base is expected to write the table to the
+// topic named `<schema>_<table>`, like the single table tasks.
+func NewKafkaLatencyTask(base Task, config LatencyConfig, decoder MQDecoder) *LatencyTask {
+	return &LatencyTask{Task: base, Config: config, Decoder: decoder}
+}
+
+// Name implements Task
+func (l *LatencyTask) Name() string {
+	return "Latency-" + l.Config.Table
+}
+
+// SetDatabase implements IsolatedTask, the rows are written in the database of
+// base if base is an IsolatedTask.
+func (l *LatencyTask) SetDatabase(name string) {
+	if isolated, ok := l.Task.(IsolatedTask); ok {
+		isolated.SetDatabase(name)
+		l.Config.Schema = name
+	}
+}
+
+// Run implements Task
+func (l *LatencyTask) Run(taskContext *TaskContext) error {
+	var (
+		stats *LatencyStats
+		err   error
+	)
+	if l.Decoder != nil {
+		verifier := taskContext.ConsumeTopic(l.Config.Schema+"_"+l.Config.Table, l.Decoder)
+		stats, err = MeasureKafkaLatency(taskContext.Ctx, taskContext.Upstream, verifier, l.Config)
+	} else {
+		stats, err = MeasureMySQLLatency(taskContext.Ctx, taskContext.Upstream, taskContext.Downstream, l.Config)
+	}
+	l.Stats = stats
+	return err
+}
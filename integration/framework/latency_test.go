@@ -0,0 +1,76 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatencyRecorderStats(t *testing.T) {
+	r := newLatencyRecorder()
+	start := time.Now()
+	for id := int64(0); id < 100; id++ {
+		r.commit(id, start)
+		r.observe(id, start.Add(time.Duration(id+1)*time.Millisecond))
+	}
+	// only the first observation counts
+	r.observe(0, start.Add(time.Hour))
+	require.Equal(t, int64(100), r.minPending())
+	require.Equal(t, 0, r.pending())
+
+	stats := r.stats()
+	require.Equal(t, 100, stats.Samples)
+	require.Equal(t, 0, stats.Lost)
+	require.Equal(t, time.Millisecond, stats.Min)
+	require.Equal(t, 50*time.Millisecond, stats.P50)
+	require.Equal(t, 90*time.Millisecond, stats.P90)
+	require.Equal(t, 99*time.Millisecond, stats.P99)
+	require.Equal(t, 100*time.Millisecond, stats.Max)
+
+	cfg := DefaultLatencyConfig("testdb", "latency")
+	require.NoError(t, stats.check(&cfg))
+	cfg.MaxP99 = 50 * time.Millisecond
+	require.Error(t, stats.check(&cfg))
+	cfg.MaxP99 = 0
+	cfg.MaxP50 = 10 * time.Millisecond
+	require.Error(t, stats.check(&cfg))
+}
+
+func TestLatencyRecorderPending(t *testing.T) {
+	r := newLatencyRecorder()
+	now := time.Now()
+	// observed before the commit is recorded
+	r.observe(1, now)
+	r.commit(0, now)
+	r.commit(1, now.Add(time.Millisecond))
+	r.commit(2, now)
+	require.Equal(t, int64(0), r.minPending())
+	require.Equal(t, 2, r.pending())
+
+	r.observe(0, now.Add(time.Second))
+	require.Equal(t, int64(2), r.minPending())
+
+	stats := r.stats()
+	require.Equal(t, 2, stats.Samples)
+	require.Equal(t, 1, stats.Lost)
+	require.Equal(t, time.Duration(0), stats.Min)
+	require.Equal(t, time.Second, stats.Max)
+	cfg := DefaultLatencyConfig("testdb", "latency")
+	require.Error(t, stats.check(&cfg))
+
+	require.Error(t, newLatencyRecorder().stats().check(&cfg))
+}
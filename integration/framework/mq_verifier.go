@@ -0,0 +1,566 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/linkedin/goavro/v2"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/cdc/sink/codec"
+	"github.com/pingcap/ticdc/pkg/retry"
+	"github.com/pingcap/tidb/store/tikv/oracle"
+	"go.uber.org/zap"
+)
+
+const (
+	// the address of the schema registry for the clients running on the host
+	schemaRegistryHostURL = "http://127.0.0.1:8081"
+	// mqVerifierTimeout is how long the assertions of TopicVerifier wait for the
+	// expected rows to be consumed
+	mqVerifierTimeout = time.Minute
+)
+
+// RowChange is a row changed event decoded from an MQ message
+type RowChange struct {
+	Schema string
+	Table  string
+	// CommitTs is 0 if the protocol doesn't carry the commit ts
+	CommitTs uint64
+	Delete   bool
+	// PrimaryKey are the names of the columns identifying the row, in order
+	PrimaryKey []string
+	// Columns are the values of the row after the change, or before the change
+	// if the row is deleted
+	Columns map[string]interface{}
+}
+
+// MQDecoder decodes the row changes in a Kafka message, the messages which
+// aren't row changes are ignored.
+type MQDecoder interface {
+	Decode(key []byte, value []byte) ([]*RowChange, error)
+}
+
+type openProtocolDecoder struct{}
+
+// NewOpenProtocolDecoder returns an MQDecoder for the TiCDC open protocol
+func NewOpenProtocolDecoder() MQDecoder {
+	return openProtocolDecoder{}
+}
+
+// Decode implements MQDecoder
+func (openProtocolDecoder) Decode(key []byte, value []byte) ([]*RowChange, error) {
+	decoder, err := codec.NewJSONEventBatchDecoder(key, value)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	var changes []*RowChange
+	for {
+		tp, hasNext, err := decoder.HasNext()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if !hasNext {
+			return changes, nil
+		}
+		switch tp {
+		case model.MqMessageTypeRow:
+			row, err := decoder.NextRowChangedEvent()
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			change := &RowChange{
+				Schema:   row.Table.Schema,
+				Table:    row.Table.Table,
+				CommitTs: row.CommitTs,
+				Delete:   row.IsDelete(),
+				Columns:  make(map[string]interface{}),
+			}
+			columns := row.Columns
+			if change.Delete {
+				columns = row.PreColumns
+			}
+			for _, col := range columns {
+				if col == nil {
+					continue
+				}
+				change.Columns[col.Name] = col.Value
+				if col.Flag.IsHandleKey() {
+					change.PrimaryKey = append(change.PrimaryKey, col.Name)
+				}
+			}
+			changes = append(changes, change)
+		case model.MqMessageTypeResolved:
+			if _, err := decoder.NextResolvedEvent(); err != nil {
+				return nil, errors.Trace(err)
+			}
+		case model.MqMessageTypeDDL:
+			if _, err := decoder.NextDDLEvent(); err != nil {
+				return nil, errors.Trace(err)
+			}
+		default:
+			return nil, errors.Errorf("unknown message type %d", tp)
+		}
+	}
+}
+
+type canalJSONDecoder struct{}
+
+// NewCanalJSONDecoder returns an MQDecoder for the canal-json protocol. The
+// commit ts of a row change is the execution time in the message, so it's
+// only accurate to milliseconds, and all the values are strings.
+func NewCanalJSONDecoder() MQDecoder {
+	return canalJSONDecoder{}
+}
+
+// Decode implements MQDecoder
+func (canalJSONDecoder) Decode(key []byte, value []byte) ([]*RowChange, error) {
+	msg := new(struct {
+		canalJSONMessage
+		ExecutionTime int64 `json:"es"`
+	})
+	err := json.Unmarshal(value, msg)
+	if err != nil {
+		return nil, errors.Annotate(err, "invalid canal-json message")
+	}
+	if msg.IsDDL {
+		return nil, nil
+	}
+	changes := make([]*RowChange, 0, len(msg.Data))
+	for _, row := range msg.Data {
+		change := &RowChange{
+			Schema:     msg.Schema,
+			Table:      msg.Table,
+			CommitTs:   oracle.ComposeTS(msg.ExecutionTime, 0),
+			Delete:     msg.EventType == "DELETE",
+			PrimaryKey: msg.PKNames,
+			Columns:    make(map[string]interface{}, len(row)),
+		}
+		for name, value := range row {
+			change.Columns[name] = value
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+type avroDecoder struct {
+	registryURL string
+	schema      string
+	table       string
+	client      *http.Client
+	codecs      map[uint32]*goavro.Codec
+}
+
+// NewAvroDecoder returns an MQDecoder for the Avro protocol, the schemas are
+// fetched from the schema registry. The messages don't carry the table name
+// nor the commit ts, so the row changes are attributed to the given table and
+// their commit ts are 0.
+func NewAvroDecoder(registryURL string, schema string, table string) MQDecoder {
+	return &avroDecoder{
+		registryURL: registryURL,
+		schema:      schema,
+		table:       table,
+		client:      http.DefaultClient,
+		codecs:      make(map[uint32]*goavro.Codec),
+	}
+}
+
+// Decode implements MQDecoder
+func (d *avroDecoder) Decode(key []byte, value []byte) ([]*RowChange, error) {
+	keyColumns, err := d.decode(key)
+	if err != nil {
+		return nil, errors.Annotate(err, "invalid avro key")
+	}
+	change := &RowChange{
+		Schema:  d.schema,
+		Table:   d.table,
+		Columns: keyColumns,
+	}
+	for name := range keyColumns {
+		change.PrimaryKey = append(change.PrimaryKey, name)
+	}
+	// the order of the key columns is lost in the map
+	sort.Strings(change.PrimaryKey)
+	// a delete is a tombstone with the key only
+	if len(value) == 0 {
+		change.Delete = true
+		return []*RowChange{change}, nil
+	}
+	change.Columns, err = d.decode(value)
+	if err != nil {
+		return nil, errors.Annotate(err, "invalid avro value")
+	}
+	return []*RowChange{change}, nil
+}
+
+// decode decodes data in the Confluent wire format, which is a zero byte, the
+// 4-byte schema id and the Avro binary data
+func (d *avroDecoder) decode(data []byte) (map[string]interface{}, error) {
+	_, columns, err := d.decodeWithSchemaID(data)
+	return columns, err
+}
+
+// decodeWithSchemaID is like decode, but also returns the schema id in data
+func (d *avroDecoder) decodeWithSchemaID(data []byte) (uint32, map[string]interface{}, error) {
+	if len(data) < 5 || data[0] != 0 {
+		return 0, nil, errors.New("not in the Confluent wire format")
+	}
+	schemaID := binary.BigEndian.Uint32(data[1:5])
+	avroCodec, err := d.codec(schemaID)
+	if err != nil {
+		return 0, nil, err
+	}
+	native, _, err := avroCodec.NativeFromBinary(data[5:])
+	if err != nil {
+		return 0, nil, errors.AddStack(err)
+	}
+	record, ok := native.(map[string]interface{})
+	if !ok {
+		return 0, nil, errors.Errorf("unexpected avro data %v", native)
+	}
+	columns := make(map[string]interface{}, len(record))
+	for name, value := range record {
+		columns[name] = unwrapAvroUnion(value)
+	}
+	return schemaID, columns, nil
+}
+
+// unwrapAvroUnion returns the value in a union, which is decoded as a map from
+// the type name to the value by goavro
+func unwrapAvroUnion(value interface{}) interface{} {
+	if union, ok := value.(map[string]interface{}); ok && len(union) == 1 {
+		for _, v := range union {
+			return v
+		}
+	}
+	return value
+}
+
+func (d *avroDecoder) codec(schemaID uint32) (*goavro.Codec, error) {
+	if avroCodec, ok := d.codecs[schemaID]; ok {
+		return avroCodec, nil
+	}
+	resp, err := d.client.Get(fmt.Sprintf("%s/schemas/ids/%d", d.registryURL, schemaID))
+	if err != nil {
+		return nil, errors.AddStack(err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.AddStack(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("failed to get schema %d: %s", schemaID, body)
+	}
+	var schema struct {
+		Schema string `json:"schema"`
+	}
+	err = json.Unmarshal(body, &schema)
+	if err != nil {
+		return nil, errors.AddStack(err)
+	}
+	avroCodec, err := goavro.NewCodec(schema.Schema)
+	if err != nil {
+		return nil, errors.AddStack(err)
+	}
+	d.codecs[schemaID] = avroCodec
+	return avroCodec, nil
+}
+
+// TopicVerifier consumes a topic in the background and materializes the row
+// changes into in-memory tables, on which the assertions are made. The tables
+// are identified by their names without the schema, so a topic is expected to
+// carry the tables of a single database. The values are compared by their
+// string representations, as the protocols decode them to different types.
+type TopicVerifier struct {
+	topic   string
+	decoder MQDecoder
+
+	mu sync.Mutex
+	// table -> primary key -> column -> value
+	tables map[string]map[string]map[string]string
+	// table -> primary key -> changes in the order they are consumed
+	history map[string]map[string][]*RowChange
+	err     error
+	// observers are called with every row change when it's consumed
+	observers []func(change *RowChange)
+}
+
+func newTopicVerifier(topic string, decoder MQDecoder) *TopicVerifier {
+	return &TopicVerifier{
+		topic:   topic,
+		decoder: decoder,
+		tables:  make(map[string]map[string]map[string]string),
+		history: make(map[string]map[string][]*RowChange),
+	}
+}
+
+// ConsumeTopic starts consuming all the partitions of the topic from the oldest
+// offset with the decoder, until the task finishes. Kafka is accessed with the
+// plaintext listener on the host, so it doesn't work in TLSDockerEnv.
+func (c *TaskContext) ConsumeTopic(topic string, decoder MQDecoder) *TopicVerifier {
+	v := newTopicVerifier(topic, decoder)
+	c.startVerifier(v, func(ctx context.Context) error {
+		return v.run(ctx, []string{kafkaHostAddr})
+	})
+	return v
+}
+
+// startVerifier runs the consumer of the verifier until the task finishes
+func (c *TaskContext) startVerifier(v *TopicVerifier, run func(ctx context.Context) error) {
+	ctx, cancel := context.WithCancel(c.Ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		err := run(ctx)
+		if err != nil && errors.Cause(err) != context.Canceled {
+			log.Warn("topic verifier exited", zap.String("topic", v.topic), zap.Error(err))
+			v.mu.Lock()
+			v.err = err
+			v.mu.Unlock()
+		}
+	}()
+	c.addCleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func (v *TopicVerifier) run(ctx context.Context, brokers []string) error {
+	consumer, err := sarama.NewConsumer(brokers, sarama.NewConfig())
+	if err != nil {
+		return errors.AddStack(err)
+	}
+	defer consumer.Close()
+
+	var partitions []int32
+	// the topic may not have been created yet
+	err = retry.Run(time.Second, 60, func() error {
+		var err error
+		partitions, err = consumer.Partitions(v.topic)
+		return err
+	})
+	if err != nil {
+		return errors.AddStack(err)
+	}
+
+	errCh := make(chan error, len(partitions))
+	var wg sync.WaitGroup
+	for _, partition := range partitions {
+		partitionConsumer, err := consumer.ConsumePartition(v.topic, partition, sarama.OffsetOldest)
+		if err != nil {
+			return errors.AddStack(err)
+		}
+		wg.Add(1)
+		go func(partition int32) {
+			defer wg.Done()
+			defer partitionConsumer.Close()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case err := <-partitionConsumer.Errors():
+					errCh <- errors.AddStack(err)
+					return
+				case msg := <-partitionConsumer.Messages():
+					err := v.consume(msg.Key, msg.Value)
+					if err != nil {
+						errCh <- errors.Annotatef(err, "partition %d offset %d", partition, msg.Offset)
+						return
+					}
+				}
+			}
+		}(partition)
+	}
+	log.Info("topic verifier started", zap.String("topic", v.topic), zap.Int("partitions", len(partitions)))
+
+	select {
+	case <-ctx.Done():
+		err = errors.Trace(ctx.Err())
+	case err = <-errCh:
+	}
+	wg.Wait()
+	return err
+}
+
+// consume applies the row changes in a message to the in-memory tables
+func (v *TopicVerifier) consume(key []byte, value []byte) error {
+	changes, err := v.decoder.Decode(key, value)
+	if err != nil {
+		return err
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for _, change := range changes {
+		for _, observer := range v.observers {
+			observer(change)
+		}
+		pkValues := make([]interface{}, 0, len(change.PrimaryKey))
+		for _, name := range change.PrimaryKey {
+			pkValues = append(pkValues, change.Columns[name])
+		}
+		pk := primaryKeyString(pkValues)
+
+		if v.tables[change.Table] == nil {
+			v.tables[change.Table] = make(map[string]map[string]string)
+			v.history[change.Table] = make(map[string][]*RowChange)
+		}
+		v.history[change.Table][pk] = append(v.history[change.Table][pk], change)
+		if change.Delete {
+			delete(v.tables[change.Table], pk)
+			continue
+		}
+		row := make(map[string]string, len(change.Columns))
+		for name, value := range change.Columns {
+			row[name] = mqValueString(value)
+		}
+		v.tables[change.Table][pk] = row
+	}
+	return nil
+}
+
+func mqValueString(value interface{}) string {
+	if b, ok := value.([]byte); ok {
+		return string(b)
+	}
+	return fmt.Sprint(value)
+}
+
+func primaryKeyString(pk []interface{}) string {
+	values := make([]string, 0, len(pk))
+	for _, value := range pk {
+		values = append(values, mqValueString(value))
+	}
+	return strings.Join(values, ",")
+}
+
+// OnRowChange registers fn to be called with every row change consumed from
+// now on, fn must not call the methods of the verifier.
+func (v *TopicVerifier) OnRowChange(fn func(change *RowChange)) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.observers = append(v.observers, fn)
+}
+
+// waitFor polls check until it returns nil or mqVerifierTimeout elapses
+func (v *TopicVerifier) waitFor(check func() error) error {
+	deadline := time.Now().Add(mqVerifierTimeout)
+	for {
+		v.mu.Lock()
+		err := v.err
+		if err == nil {
+			err = check()
+		}
+		consumeErr := v.err
+		v.mu.Unlock()
+		if err == nil || consumeErr != nil || time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// RequireRow waits until the row identified by the values of its primary key
+// columns has the given values in the columns.
+func (v *TopicVerifier) RequireRow(table string, pk []interface{}, cols map[string]interface{}) error {
+	return v.waitFor(func() error {
+		row, ok := v.tables[table][primaryKeyString(pk)]
+		if !ok {
+			return errors.Errorf("row %v not found in table %s of topic %s", pk, table, v.topic)
+		}
+		for name, expected := range cols {
+			actual, ok := row[name]
+			if !ok {
+				return errors.Errorf("column %s not found in row %v of table %s", name, pk, table)
+			}
+			if actual != mqValueString(expected) {
+				return errors.Errorf("column %s of row %v of table %s is %s, expected %v",
+					name, pk, table, actual, expected)
+			}
+		}
+		return nil
+	})
+}
+
+// RequireNoRow waits until the row identified by the values of its primary key
+// columns has been deleted, or it was never written.
+func (v *TopicVerifier) RequireNoRow(table string, pk []interface{}) error {
+	return v.waitFor(func() error {
+		if row, ok := v.tables[table][primaryKeyString(pk)]; ok {
+			return errors.Errorf("row %v of table %s still exists: %v", pk, table, row)
+		}
+		return nil
+	})
+}
+
+// RequireRowCount waits until the table has count rows
+func (v *TopicVerifier) RequireRowCount(table string, count int) error {
+	return v.waitFor(func() error {
+		if n := len(v.tables[table]); n != count {
+			return errors.Errorf("table %s of topic %s has %d rows, expected %d", table, v.topic, n, count)
+		}
+		return nil
+	})
+}
+
+// RequireOrderedByCommitTs checks the changes of the row identified by the
+// values of its primary key columns have been consumed in the order of their
+// commit ts. It fails if the protocol doesn't carry the commit ts.
+func (v *TopicVerifier) RequireOrderedByCommitTs(table string, pk []interface{}) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	changes := v.history[table][primaryKeyString(pk)]
+	if len(changes) == 0 {
+		return errors.Errorf("no change of row %v of table %s found", pk, table)
+	}
+	for i, change := range changes {
+		if change.CommitTs == 0 {
+			return errors.New("the commit ts is not carried by the protocol")
+		}
+		if i > 0 && change.CommitTs < changes[i-1].CommitTs {
+			return errors.Errorf("change of row %v of table %s with commit ts %d is after the one with commit ts %d",
+				pk, table, change.CommitTs, changes[i-1].CommitTs)
+		}
+	}
+	return nil
+}
+
+// Row returns a copy of the row identified by the values of its primary key
+// columns, or nil if it doesn't exist.
+func (v *TopicVerifier) Row(table string, pk []interface{}) map[string]string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	row, ok := v.tables[table][primaryKeyString(pk)]
+	if !ok {
+		return nil
+	}
+	ret := make(map[string]string, len(row))
+	for name, value := range row {
+		ret[name] = value
+	}
+	return ret
+}
@@ -0,0 +1,131 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/linkedin/goavro/v2"
+	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/cdc/sink/codec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTopicVerifierOpenProtocol(t *testing.T) {
+	encoder := codec.NewJSONEventBatchEncoder()
+	pkFlag := model.HandleKeyFlag | model.PrimaryKeyFlag
+	table := &model.TableName{Schema: "testdb", Table: "test"}
+	events := []*model.RowChangedEvent{{
+		CommitTs: 100,
+		Table:    table,
+		Columns:  []*model.Column{{Name: "id", Flag: pkFlag, Value: 1}, {Name: "value", Value: "a"}},
+	}, {
+		CommitTs: 200,
+		Table:    table,
+		Columns:  []*model.Column{{Name: "id", Flag: pkFlag, Value: 1}, {Name: "value", Value: "b"}},
+	}, {
+		CommitTs: 200,
+		Table:    table,
+		Columns:  []*model.Column{{Name: "id", Flag: pkFlag, Value: 2}, {Name: "value", Value: "c"}},
+	}, {
+		CommitTs:   300,
+		Table:      table,
+		PreColumns: []*model.Column{{Name: "id", Flag: pkFlag, Value: 2}, {Name: "value", Value: "c"}},
+	}}
+	for _, event := range events {
+		_, err := encoder.AppendRowChangedEvent(event)
+		require.NoError(t, err)
+	}
+
+	v := newTopicVerifier("testdb_test", NewOpenProtocolDecoder())
+	for _, msg := range encoder.Build() {
+		require.NoError(t, v.consume(msg.Key, msg.Value))
+	}
+	require.NoError(t, v.RequireRow("test", []interface{}{1}, map[string]interface{}{"value": "b"}))
+	require.NoError(t, v.RequireNoRow("test", []interface{}{2}))
+	require.NoError(t, v.RequireRowCount("test", 1))
+	require.NoError(t, v.RequireOrderedByCommitTs("test", []interface{}{1}))
+	require.NoError(t, v.RequireOrderedByCommitTs("test", []interface{}{2}))
+	require.Error(t, v.RequireOrderedByCommitTs("test", []interface{}{3}))
+	require.Equal(t, map[string]string{"id": "1", "value": "b"}, v.Row("test", []interface{}{1}))
+
+	// the changes consumed out of order are detected
+	v.history["test"]["1"][0].CommitTs = 250
+	require.Error(t, v.RequireOrderedByCommitTs("test", []interface{}{1}))
+}
+
+func TestCanalJSONDecoder(t *testing.T) {
+	value := []byte(`{"id":0,"database":"testdb","table":"test","pkNames":["id"],"isDdl":false,"type":"UPDATE",` +
+		`"es":1600000000000,"ts":1600000000001,"sql":"","data":[{"id":"1","value":"b"}],"old":[{"value":"a"}]}`)
+	changes, err := NewCanalJSONDecoder().Decode(nil, value)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	require.Equal(t, "test", changes[0].Table)
+	require.Equal(t, []string{"id"}, changes[0].PrimaryKey)
+	require.False(t, changes[0].Delete)
+	require.Equal(t, map[string]interface{}{"id": "1", "value": "b"}, changes[0].Columns)
+	require.Equal(t, uint64(1600000000000)<<18, changes[0].CommitTs)
+
+	changes, err = NewCanalJSONDecoder().Decode(nil, []byte(`{"database":"testdb","isDdl":true,"sql":"create table t(a int)"}`))
+	require.NoError(t, err)
+	require.Empty(t, changes)
+}
+
+func TestAvroDecoder(t *testing.T) {
+	schemas := map[string]string{
+		"1": `{"type":"record","name":"key","fields":[{"name":"id","type":"long"}]}`,
+		"2": `{"type":"record","name":"value","fields":[{"name":"id","type":"long"},` +
+			`{"name":"value","type":["null","string"],"default":null}]}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		schema, ok := schemas[r.URL.Path[len("/schemas/ids/"):]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"schema": schema})
+	}))
+	defer server.Close()
+
+	encode := func(id uint32, native map[string]interface{}) []byte {
+		avroCodec, err := goavro.NewCodec(schemas[string(rune('0'+id))])
+		require.NoError(t, err)
+		data := []byte{0, 0, 0, 0, 0}
+		binary.BigEndian.PutUint32(data[1:], id)
+		data, err = avroCodec.BinaryFromNative(data, native)
+		require.NoError(t, err)
+		return data
+	}
+	key := encode(1, map[string]interface{}{"id": int64(1)})
+	value := encode(2, map[string]interface{}{"id": int64(1), "value": goavro.Union("string", "a")})
+
+	decoder := NewAvroDecoder(server.URL, "testdb", "test")
+	changes, err := decoder.Decode(key, value)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	require.Equal(t, "test", changes[0].Table)
+	require.Equal(t, []string{"id"}, changes[0].PrimaryKey)
+	require.Equal(t, map[string]interface{}{"id": int64(1), "value": "a"}, changes[0].Columns)
+
+	changes, err = decoder.Decode(key, nil)
+	require.NoError(t, err)
+	require.True(t, changes[0].Delete)
+
+	_, err = decoder.Decode([]byte{1, 2}, value)
+	require.Error(t, err)
+}
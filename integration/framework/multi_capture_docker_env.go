@@ -0,0 +1,83 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/ticdc/pkg/retry"
+)
+
+const (
+	// MultiCaptureNum is the number of captures in MultiCaptureDockerEnv
+	MultiCaptureNum = 4
+)
+
+// MultiCaptureDockerEnv represents the docker-compose service generated from multiCaptureComposeConfig,
+// in which MultiCaptureNum captures replicate the upstream TiDB cluster to a downstream MySQL.
+// It's used to test the scheduling of tables among captures, see CDCCluster.
+type MultiCaptureDockerEnv struct {
+	dockerComposeOperator
+}
+
+// NewMultiCaptureDockerEnv creates a new MultiCaptureDockerEnv
+func NewMultiCaptureDockerEnv(dockerComposeFile string) *MultiCaptureDockerEnv {
+	env := &MultiCaptureDockerEnv{newDockerComposeOperator(dockerComposeFile, multiCaptureComposeConfig())}
+	env.controller = controllerContainerName
+	env.healthChecker = func() error {
+		err := pingDatabases(upstreamDSN, downstreamDSN)
+		if err != nil {
+			return err
+		}
+		captures, err := env.Captures()
+		if err != nil {
+			return err
+		}
+		want := MultiCaptureNum
+		if env.compose != nil {
+			want = env.compose.Captures
+		}
+		if len(captures) < want {
+			return errors.Errorf("only %d of %d captures are online", len(captures), want)
+		}
+		return nil
+	}
+	return env
+}
+
+// Reset implements Environment
+func (e *MultiCaptureDockerEnv) Reset() {
+	e.TearDown()
+	e.Setup()
+}
+
+// RunTest implements Environment
+func (e *MultiCaptureDockerEnv) RunTest(task Task) {
+	runTask(&e.dockerComposeOperator, e, task, func() error {
+		return retry.Run(time.Second, 120, e.healthChecker)
+	})
+}
+
+// RunTests implements Environment. The tasks are always run one by one, because
+// they operate on the captures shared by the whole cluster.
+func (e *MultiCaptureDockerEnv) RunTests(tasks []Task, parallelism int) []TaskResult {
+	return runTasks(&e.dockerComposeOperator, e, tasks, 1, func() error {
+		return retry.Run(time.Second, 120, e.healthChecker)
+	})
+}
+
+// SetListener implements Environment. The MySQL sink has no MQ output, so it does nothing.
+func (e *MultiCaptureDockerEnv) SetListener(states interface{}, listener MqListener) {
+}
@@ -0,0 +1,67 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"time"
+
+	"github.com/pingcap/ticdc/pkg/retry"
+)
+
+const (
+	// the address of the downstream MySQL inside the docker network
+	mysqlDownstreamSinkURI = "mysql://root@downstream-mysql:3306/"
+)
+
+// MySQLDockerEnv represents the docker-compose service generated from mysqlComposeConfig,
+// in which the upstream TiDB cluster is replicated to a downstream MySQL.
+type MySQLDockerEnv struct {
+	dockerComposeOperator
+}
+
+// NewMySQLDockerEnv creates a new MySQLDockerEnv
+func NewMySQLDockerEnv(dockerComposeFile string) *MySQLDockerEnv {
+	healthChecker := func() error {
+		return pingDatabases(upstreamDSN, downstreamDSN)
+	}
+
+	env := &MySQLDockerEnv{newDockerComposeOperator(dockerComposeFile, mysqlComposeConfig())}
+	env.controller = controllerContainerName
+	env.healthChecker = healthChecker
+	return env
+}
+
+// Reset implements Environment
+func (e *MySQLDockerEnv) Reset() {
+	e.TearDown()
+	e.Setup()
+}
+
+// RunTest implements Environment
+func (e *MySQLDockerEnv) RunTest(task Task) {
+	runTask(&e.dockerComposeOperator, e, task, func() error {
+		return retry.Run(time.Second, 120, e.healthChecker)
+	})
+}
+
+// RunTests implements Environment
+func (e *MySQLDockerEnv) RunTests(tasks []Task, parallelism int) []TaskResult {
+	return runTasks(&e.dockerComposeOperator, e, tasks, parallelism, func() error {
+		return retry.Run(time.Second, 120, e.healthChecker)
+	})
+}
+
+// SetListener implements Environment. The MySQL sink has no MQ output, so it does nothing.
+func (e *MySQLDockerEnv) SetListener(states interface{}, listener MqListener) {
+}
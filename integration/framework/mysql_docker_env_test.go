@@ -0,0 +1,37 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type emptyMySQLSingleTableTask struct {
+	MySQLSingleTableTask
+}
+
+func TestMySQLDockerEnv_RunTest(t *testing.T) {
+	env := NewMySQLDockerEnv("")
+	require.NotNil(t, env)
+
+	env.Setup()
+	env.RunTest(&emptyMySQLSingleTableTask{MySQLSingleTableTask{TableName: "test"}})
+
+	err := env.healthChecker()
+	require.NoError(t, err)
+
+	env.TearDown()
+}
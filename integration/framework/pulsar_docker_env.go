@@ -0,0 +1,157 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/apache/pulsar-client-go/pulsar"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/ticdc/pkg/retry"
+	"go.uber.org/zap"
+)
+
+const (
+	// the URL of the Pulsar proxy for the consumers running on the host
+	pulsarHostURL = "pulsar://127.0.0.1:6651"
+	// the URL of the Pulsar broker inside the docker network
+	pulsarInternalURL = "pulsar://pulsar:6650"
+	// the namespace of the topics without a namespace in their names
+	pulsarNamespace = "public/default"
+	// the topic looked up by the health checker, it's not created by the lookup
+	pulsarHealthCheckTopic = "persistent://" + pulsarNamespace + "/ticdc-health-check"
+)
+
+// PulsarDockerEnv represents the docker-compose service generated from pulsarComposeConfig,
+// in which the changefeeds write canal-json messages to a standalone Pulsar, and the messages
+// are applied to the downstream TiDB by a consumer built into the framework.
+type PulsarDockerEnv struct {
+	dockerComposeOperator
+}
+
+// NewPulsarDockerEnv creates a new PulsarDockerEnv
+func NewPulsarDockerEnv(dockerComposeFile string) *PulsarDockerEnv {
+	healthChecker := func() error {
+		err := pingDatabases(upstreamDSN, downstreamDSN)
+		if err != nil {
+			return err
+		}
+
+		client, err := pulsar.NewClient(pulsar.ClientOptions{URL: pulsarHostURL})
+		if err != nil {
+			return errors.Annotate(err, "pulsar not ready")
+		}
+		defer client.Close()
+		// the lookup goes through the proxy to the broker
+		_, err = client.TopicPartitions(pulsarHealthCheckTopic)
+		return errors.Annotate(err, "pulsar not ready")
+	}
+
+	env := &PulsarDockerEnv{newDockerComposeOperator(dockerComposeFile, pulsarComposeConfig())}
+	env.controller = controllerContainerName
+	env.healthChecker = healthChecker
+	return env
+}
+
+// Reset implements Environment
+func (e *PulsarDockerEnv) Reset() {
+	e.TearDown()
+	e.Setup()
+}
+
+// RunTest implements Environment
+func (e *PulsarDockerEnv) RunTest(task Task) {
+	runTask(&e.dockerComposeOperator, e, task, func() error {
+		return retry.Run(time.Second, 120, e.healthChecker)
+	})
+}
+
+// RunTests implements Environment
+func (e *PulsarDockerEnv) RunTests(tasks []Task, parallelism int) []TaskResult {
+	return runTasks(&e.dockerComposeOperator, e, tasks, parallelism, func() error {
+		return retry.Run(time.Second, 120, e.healthChecker)
+	})
+}
+
+// SetListener implements Environment. Currently unfinished, will be used to monitor Pulsar output
+func (e *PulsarDockerEnv) SetListener(states interface{}, listener MqListener) {
+	// TODO
+}
+
+// ConsumePulsarTopic is like ConsumeTopic, but consumes the topic in Pulsar
+// from the earliest message.
+func (c *TaskContext) ConsumePulsarTopic(topic string, decoder MQDecoder) *TopicVerifier {
+	v := newTopicVerifier(topic, decoder)
+	c.startVerifier(v, func(ctx context.Context) error {
+		return consumePulsarTopic(ctx, pulsarHostURL, topic, func(msg pulsar.Message) error {
+			err := v.consume([]byte(msg.Key()), msg.Payload())
+			return errors.Annotatef(err, "message %v", msg.ID())
+		})
+	})
+	return v
+}
+
+func (c *canalJSONConsumer) runPulsar(ctx context.Context, conn *sql.Conn) error {
+	log.Info("canal-json consumer started", zap.String("topic", c.topic), zap.String("pulsar", c.pulsarURL))
+	return consumePulsarTopic(ctx, c.pulsarURL, c.topic, func(msg pulsar.Message) error {
+		return c.apply(ctx, conn, msg.Payload(), fmt.Sprintf("message %v", msg.ID()))
+	})
+}
+
+// consumePulsarTopic calls handle with the messages of the topic in order from the
+// earliest one, until ctx is done or handle fails. Every call has its own
+// subscription, so the consumers of a topic don't share the messages.
+func consumePulsarTopic(ctx context.Context, url string, topic string, handle func(msg pulsar.Message) error) error {
+	client, err := pulsar.NewClient(pulsar.ClientOptions{URL: url})
+	if err != nil {
+		return errors.AddStack(err)
+	}
+	defer client.Close()
+
+	var consumer pulsar.Consumer
+	// the broker may not be ready to serve the topic yet
+	err = retry.Run(time.Second, 60, func() error {
+		var err error
+		consumer, err = client.Subscribe(pulsar.ConsumerOptions{
+			Topic:                       topic,
+			SubscriptionName:            fmt.Sprintf("ticdc-integration-%d", time.Now().UnixNano()),
+			Type:                        pulsar.Exclusive,
+			SubscriptionInitialPosition: pulsar.SubscriptionPositionEarliest,
+		})
+		return err
+	})
+	if err != nil {
+		return errors.AddStack(err)
+	}
+	defer consumer.Close()
+
+	for {
+		msg, err := consumer.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return errors.Trace(ctx.Err())
+			}
+			return errors.AddStack(err)
+		}
+		err = handle(msg)
+		if err != nil {
+			return err
+		}
+		consumer.Ack(msg)
+	}
+}
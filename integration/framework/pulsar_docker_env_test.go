@@ -0,0 +1,38 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPulsarProfile(t *testing.T) {
+	task := &PulsarSingleTableTask{TableName: "test"}
+	task.SetDatabase("testdb_1")
+	profile := task.GetCDCProfile()
+	require.Equal(t, "pulsar://pulsar:6650/testdb_1_test?protocol=canal-json", profile.SinkURI)
+	require.Equal(t, []string{"testdb_1.*"}, profile.FilterRules)
+
+	profile.SinkURI += "&batch-max-delay=100ms"
+	require.Equal(t, "cli changefeed create --pd=http://upstream-pd:2379 "+
+		"--sink-uri='pulsar://pulsar:6650/testdb_1_test?protocol=canal-json&batch-max-delay=100ms' ", profile.String())
+}
+
+func TestShellQuote(t *testing.T) {
+	require.Equal(t, "mysql://root@downstream-mysql:3306/", shellQuote("mysql://root@downstream-mysql:3306/"))
+	require.Equal(t, "'a&b'", shellQuote("a&b"))
+	require.Equal(t, `'it'\''s'`, shellQuote("it's"))
+}
@@ -0,0 +1,67 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"github.com/pingcap/log"
+)
+
+// PulsarSingleTableTask provides a basic implementation for a Pulsar test case,
+// the changefeed writes canal-json messages to a topic in Pulsar.
+type PulsarSingleTableTask struct {
+	TableName string
+	database  string
+}
+
+// Name implements Task
+func (p *PulsarSingleTableTask) Name() string {
+	log.Warn("PulsarSingleTableTask should be embedded in another Task")
+	return "PulsarSingleTableTask-" + p.TableName
+}
+
+// GetCDCProfile implements Task
+func (p *PulsarSingleTableTask) GetCDCProfile() *CDCProfile {
+	return &CDCProfile{
+		PDUri:       "http://upstream-pd:2379",
+		SinkURI:     pulsarInternalURL + "/" + p.topic() + "?protocol=canal-json",
+		FilterRules: []string{p.db() + ".*"},
+	}
+}
+
+func (p *PulsarSingleTableTask) topic() string {
+	return p.db() + "_" + p.TableName
+}
+
+// SetDatabase implements IsolatedTask
+func (p *PulsarSingleTableTask) SetDatabase(name string) {
+	p.database = name
+}
+
+func (p *PulsarSingleTableTask) db() string {
+	if p.database == "" {
+		return defaultDatabase
+	}
+	return p.database
+}
+
+// Prepare implements Task
+func (p *PulsarSingleTableTask) Prepare(taskContext *TaskContext) error {
+	return prepareCanalJSONTask(taskContext, p.db(), newPulsarCanalJSONConsumer(p.topic()))
+}
+
+// Run implements Task
+func (p *PulsarSingleTableTask) Run(taskContext *TaskContext) error {
+	log.Warn("PulsarSingleTableTask has been run")
+	return nil
+}
@@ -0,0 +1,301 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	junitReportFileName   = "junit.xml"
+	summaryReportFileName = "summary.json"
+)
+
+// testReport collects the results of the tasks run in an environment
+type testReport struct {
+	mu        sync.Mutex
+	suite     string
+	dir       string
+	versions  string
+	startTime time.Time
+	results   []TaskResult
+}
+
+func newTestReport(suite string, dir string) *testReport {
+	return &testReport{
+		suite:     suite,
+		dir:       dir,
+		startTime: time.Now(),
+	}
+}
+
+func (r *testReport) record(results ...TaskResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.results = append(r.results, results...)
+}
+
+// write writes the JUnit XML report and the JSON summary of the results
+// recorded so far into the report directory.
+func (r *testReport) write() error {
+	err := os.MkdirAll(r.dir, 0755)
+	if err != nil {
+		return errors.AddStack(err)
+	}
+	for fileName, writeFn := range map[string]func(io.Writer) error{
+		junitReportFileName:   r.writeJUnit,
+		summaryReportFileName: r.writeSummary,
+	} {
+		f, err := os.Create(filepath.Join(r.dir, fileName))
+		if err != nil {
+			return errors.AddStack(err)
+		}
+		err = writeFn(f)
+		_ = f.Close()
+		if err != nil {
+			return errors.AddStack(err)
+		}
+	}
+	log.Info("Test reports written", zap.String("dir", r.dir))
+	return nil
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Time       string          `xml:"time,attr"`
+	Timestamp  string          `xml:"timestamp,attr"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Cases      []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+func (r *testReport) writeJUnit(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	suite := junitTestSuite{
+		Name:      r.suite,
+		Tests:     len(r.results),
+		Time:      junitSeconds(time.Since(r.startTime)),
+		Timestamp: r.startTime.Format(time.RFC3339),
+	}
+	if r.versions != "" {
+		suite.Properties = append(suite.Properties, junitProperty{Name: "versions", Value: r.versions})
+	}
+	for _, result := range r.results {
+		testCase := junitTestCase{
+			Name:      result.Name,
+			ClassName: "integration." + r.suite,
+			Time:      junitSeconds(result.Duration),
+			SystemOut: result.Log,
+		}
+		if result.Err != nil {
+			suite.Failures++
+			testCase.Failure = &junitFailure{
+				Message: result.Err.Error(),
+				Text:    fmt.Sprintf("%+v", result.Err),
+			}
+		}
+		suite.Cases = append(suite.Cases, testCase)
+	}
+
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return errors.AddStack(err)
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	return errors.AddStack(encoder.Encode(junitTestSuites{Suites: []junitTestSuite{suite}}))
+}
+
+type reportSummary struct {
+	Suite     string        `json:"suite"`
+	Versions  string        `json:"versions,omitempty"`
+	StartTime time.Time     `json:"start_time"`
+	Duration  float64       `json:"duration_seconds"`
+	Total     int           `json:"total"`
+	Passed    int           `json:"passed"`
+	Failed    int           `json:"failed"`
+	Tasks     []taskSummary `json:"tasks"`
+}
+
+type taskSummary struct {
+	Name     string  `json:"name"`
+	Database string  `json:"database"`
+	Passed   bool    `json:"passed"`
+	Duration float64 `json:"duration_seconds"`
+	Error    string  `json:"error,omitempty"`
+	// ArtifactsDir is the directory of the logs and the state of the cluster collected on failure
+	ArtifactsDir string `json:"artifacts_dir,omitempty"`
+	// Resources is the resource usage of the containers sampled while the task was running
+	Resources []ContainerResources `json:"resources,omitempty"`
+}
+
+func (r *testReport) writeSummary(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	summary := reportSummary{
+		Suite:     r.suite,
+		Versions:  r.versions,
+		StartTime: r.startTime,
+		Duration:  time.Since(r.startTime).Seconds(),
+		Total:     len(r.results),
+		Tasks:     make([]taskSummary, 0, len(r.results)),
+	}
+	for _, result := range r.results {
+		task := taskSummary{
+			Name:      result.Name,
+			Database:  result.Database,
+			Passed:    result.Err == nil,
+			Duration:  result.Duration.Seconds(),
+			Resources: result.Resources,
+		}
+		if result.Err != nil {
+			summary.Failed++
+			task.Error = result.Err.Error()
+			task.ArtifactsDir = result.ArtifactsDir
+		} else {
+			summary.Passed++
+		}
+		summary.Tasks = append(summary.Tasks, task)
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return errors.AddStack(encoder.Encode(summary))
+}
+
+// SetReportDir enables writing a JUnit XML report and a JSON summary of the
+// results of the tasks into dir when the environment is torn down, the logs
+// printed while a task is running are captured in the JUnit XML report.
+func (d *dockerComposeOperator) SetReportDir(dir string) {
+	suite := strings.TrimSuffix(filepath.Base(d.fileName), filepath.Ext(d.fileName))
+	d.report = newTestReport(suite, dir)
+	installLogCapture()
+}
+
+func (d *dockerComposeOperator) recordResults(results ...TaskResult) {
+	if d.report != nil {
+		d.report.record(results...)
+	}
+}
+
+func (d *dockerComposeOperator) writeReport() {
+	if d.report == nil {
+		return
+	}
+	d.report.mu.Lock()
+	d.report.versions = d.versionTag()
+	d.report.mu.Unlock()
+	err := d.report.write()
+	if err != nil {
+		log.Warn("Failed to write test reports", zap.Error(err))
+	}
+}
+
+// logCapture copies the logs into the buffers of the running tasks
+type logCapture struct {
+	mu      sync.Mutex
+	buffers map[*bytes.Buffer]struct{}
+}
+
+var (
+	globalLogCapture = &logCapture{buffers: make(map[*bytes.Buffer]struct{})}
+	installLogOnce   sync.Once
+)
+
+// installLogCapture tees the global logger to globalLogCapture. It replaces the
+// global logger, so it must be called before any task starts.
+func installLogCapture() {
+	installLogOnce.Do(func() {
+		cfg := &log.Config{Level: log.GetLevel().String()}
+		lg, props, err := log.InitLogger(cfg)
+		if err != nil {
+			log.Warn("Failed to capture the logs of tasks", zap.Error(err))
+			return
+		}
+		captureCore := log.NewTextCore(log.NewTextEncoder(cfg), zapcore.AddSync(globalLogCapture), props.Level)
+		lg = lg.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return zapcore.NewTee(core, captureCore)
+		}))
+		log.ReplaceGlobals(lg, props)
+	})
+}
+
+// start starts capturing the logs into a new buffer
+func (c *logCapture) start() *bytes.Buffer {
+	buf := new(bytes.Buffer)
+	c.mu.Lock()
+	c.buffers[buf] = struct{}{}
+	c.mu.Unlock()
+	return buf
+}
+
+// stop stops capturing the logs into buf and returns the captured logs
+func (c *logCapture) stop(buf *bytes.Buffer) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.buffers, buf)
+	return buf.String()
+}
+
+// Write implements io.Writer
+func (c *logCapture) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for buf := range c.buffers {
+		buf.Write(p)
+	}
+	return len(p), nil
+}
@@ -0,0 +1,84 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestReport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "integration-report")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	report := newTestReport("docker-compose-mysql", dir)
+	report.record(TaskResult{
+		Name:     "Simple",
+		Database: "testdb_0",
+		Duration: 1500 * time.Millisecond,
+		Log:      "some logs",
+	}, TaskResult{
+		Name:     "Broken",
+		Database: "testdb_1",
+		Duration: time.Second,
+		Err:      errors.New("table not consistent"),
+	})
+	require.NoError(t, report.write())
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, junitReportFileName))
+	require.NoError(t, err)
+	var suites junitTestSuites
+	require.NoError(t, xml.Unmarshal(data, &suites))
+	require.Len(t, suites.Suites, 1)
+	suite := suites.Suites[0]
+	require.Equal(t, "docker-compose-mysql", suite.Name)
+	require.Equal(t, 2, suite.Tests)
+	require.Equal(t, 1, suite.Failures)
+	require.Equal(t, "1.500", suite.Cases[0].Time)
+	require.Equal(t, "some logs", suite.Cases[0].SystemOut)
+	require.Nil(t, suite.Cases[0].Failure)
+	require.Equal(t, "table not consistent", suite.Cases[1].Failure.Message)
+
+	data, err = ioutil.ReadFile(filepath.Join(dir, summaryReportFileName))
+	require.NoError(t, err)
+	var summary reportSummary
+	require.NoError(t, json.Unmarshal(data, &summary))
+	require.Equal(t, 2, summary.Total)
+	require.Equal(t, 1, summary.Passed)
+	require.Equal(t, 1, summary.Failed)
+	require.True(t, summary.Tasks[0].Passed)
+	require.Equal(t, "testdb_1", summary.Tasks[1].Database)
+	require.Equal(t, "table not consistent", summary.Tasks[1].Error)
+}
+
+func TestLogCapture(t *testing.T) {
+	installLogCapture()
+	buf := globalLogCapture.start()
+	log.Info("captured message")
+	logs := globalLogCapture.stop(buf)
+	require.Contains(t, logs, "captured message")
+
+	log.Info("message after stop")
+	require.NotContains(t, buf.String(), "message after stop")
+}
@@ -0,0 +1,222 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"go.uber.org/zap"
+)
+
+// ContainerResources summarizes the CPU and memory usage of a container sampled
+// with `docker stats` while a task was running
+type ContainerResources struct {
+	Name           string  `json:"name"`
+	Samples        int     `json:"samples"`
+	AvgCPUPercent  float64 `json:"avg_cpu_percent"`
+	MaxCPUPercent  float64 `json:"max_cpu_percent"`
+	AvgMemoryBytes uint64  `json:"avg_memory_bytes"`
+	MaxMemoryBytes uint64  `json:"max_memory_bytes"`
+}
+
+// SetStatsInterval enables sampling the CPU and memory usage of all the containers
+// every interval while a task is running, the usage is summarized in the
+// TaskResult and the test reports. The usage sampled while tasks run in parallel
+// is included in the results of all of them.
+func (d *dockerComposeOperator) SetStatsInterval(interval time.Duration) {
+	d.statsInterval = interval
+}
+
+// statsSample is the usage of a container in the output of `docker stats`
+type statsSample struct {
+	name        string
+	cpuPercent  float64
+	memoryBytes uint64
+}
+
+// parseDockerStats parses the output of `docker stats --no-stream --format '{{json .}}'`
+func parseDockerStats(out []byte) ([]statsSample, error) {
+	var samples []statsSample
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var stats struct {
+			Name     string
+			CPUPerc  string
+			MemUsage string
+		}
+		err := json.Unmarshal(line, &stats)
+		if err != nil {
+			return nil, errors.Annotatef(err, "invalid docker stats: %s", line)
+		}
+		// the stats of a stopped container are "--"
+		if strings.HasPrefix(stats.CPUPerc, "--") {
+			continue
+		}
+		cpu, err := strconv.ParseFloat(strings.TrimSuffix(stats.CPUPerc, "%"), 64)
+		if err != nil {
+			return nil, errors.Annotatef(err, "invalid CPU usage of %s: %s", stats.Name, stats.CPUPerc)
+		}
+		// MemUsage is like "12.5MiB / 1.944GiB"
+		memory, err := parseByteSize(strings.TrimSpace(strings.Split(stats.MemUsage, "/")[0]))
+		if err != nil {
+			return nil, errors.Annotatef(err, "invalid memory usage of %s: %s", stats.Name, stats.MemUsage)
+		}
+		samples = append(samples, statsSample{name: stats.Name, cpuPercent: cpu, memoryBytes: memory})
+	}
+	return samples, errors.AddStack(scanner.Err())
+}
+
+var byteSizeUnits = []struct {
+	suffix string
+	bytes  float64
+}{
+	// the longer suffixes go first
+	{"KiB", 1 << 10}, {"MiB", 1 << 20}, {"GiB", 1 << 30}, {"TiB", 1 << 40},
+	{"kB", 1e3}, {"KB", 1e3}, {"MB", 1e6}, {"GB", 1e9}, {"TB", 1e12},
+	{"B", 1},
+}
+
+// parseByteSize parses the sizes printed by docker like "12.5MiB"
+func parseByteSize(s string) (uint64, error) {
+	for _, unit := range byteSizeUnits {
+		if strings.HasSuffix(s, unit.suffix) {
+			value, err := strconv.ParseFloat(strings.TrimSuffix(s, unit.suffix), 64)
+			if err != nil {
+				return 0, errors.AddStack(err)
+			}
+			return uint64(value * unit.bytes), nil
+		}
+	}
+	return 0, errors.Errorf("unknown unit of size %s", s)
+}
+
+// resourceSampler samples the usage of the containers in the background
+type resourceSampler struct {
+	d       *dockerComposeOperator
+	closeCh chan struct{}
+	wg      sync.WaitGroup
+
+	mu        sync.Mutex
+	resources map[string]*ContainerResources
+	// the sums of the samples of every container, to calculate the averages
+	cpuSums    map[string]float64
+	memorySums map[string]uint64
+}
+
+// startResourceSampler starts sampling the usage of the containers if the stats
+// interval is set, and returns nil otherwise.
+func (d *dockerComposeOperator) startResourceSampler() *resourceSampler {
+	if d.statsInterval <= 0 {
+		return nil
+	}
+	s := &resourceSampler{
+		d:          d,
+		closeCh:    make(chan struct{}),
+		resources:  make(map[string]*ContainerResources),
+		cpuSums:    make(map[string]float64),
+		memorySums: make(map[string]uint64),
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(d.statsInterval)
+		defer ticker.Stop()
+		for {
+			err := s.sample()
+			if err != nil {
+				log.Debug("failed to sample the resource usage", zap.Error(err))
+			}
+			select {
+			case <-s.closeCh:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return s
+}
+
+func (s *resourceSampler) sample() error {
+	// the containers started by ScaleCDC with `docker-compose run` are listed as well
+	out, err := runCmd(exec.Command("docker-compose", "-f", s.d.fileName, "ps", "-q"))
+	if err != nil {
+		return err
+	}
+	containers := strings.Fields(string(out))
+	if len(containers) == 0 {
+		return nil
+	}
+	args := append([]string{"stats", "--no-stream", "--format", "{{json .}}"}, containers...)
+	out, err = runCmd(exec.Command("docker", args...))
+	if err != nil {
+		return err
+	}
+	samples, err := parseDockerStats(out)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sample := range samples {
+		r, ok := s.resources[sample.name]
+		if !ok {
+			r = &ContainerResources{Name: sample.name}
+			s.resources[sample.name] = r
+		}
+		r.Samples++
+		if sample.cpuPercent > r.MaxCPUPercent {
+			r.MaxCPUPercent = sample.cpuPercent
+		}
+		if sample.memoryBytes > r.MaxMemoryBytes {
+			r.MaxMemoryBytes = sample.memoryBytes
+		}
+		s.cpuSums[sample.name] += sample.cpuPercent
+		s.memorySums[sample.name] += sample.memoryBytes
+		r.AvgCPUPercent = s.cpuSums[sample.name] / float64(r.Samples)
+		r.AvgMemoryBytes = s.memorySums[sample.name] / uint64(r.Samples)
+	}
+	return nil
+}
+
+// stop stops sampling and returns the usage of the containers sorted by their
+// names, it returns nil if s is nil.
+func (s *resourceSampler) stop() []ContainerResources {
+	if s == nil {
+		return nil
+	}
+	close(s.closeCh)
+	s.wg.Wait()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	resources := make([]ContainerResources, 0, len(s.resources))
+	for _, r := range s.resources {
+		resources = append(resources, *r)
+	}
+	sort.Slice(resources, func(i, j int) bool { return resources[i].Name < resources[j].Name })
+	return resources
+}
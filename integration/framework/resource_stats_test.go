@@ -0,0 +1,68 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDockerStats(t *testing.T) {
+	out := []byte(`{"BlockIO":"0B / 0B","CPUPerc":"12.50%","Container":"3f2a","ID":"3f2a","MemPerc":"1.23%","MemUsage":"24.5MiB / 1.944GiB","Name":"ticdc_capturer0_1","NetIO":"1kB / 2kB","PIDs":"20"}
+{"CPUPerc":"--","MemUsage":"-- / --","Name":"ticdc_capturer1_1"}
+
+{"CPUPerc":"0.00%","MemUsage":"512kB / 2GB","Name":"controller"}
+`)
+	samples, err := parseDockerStats(out)
+	require.NoError(t, err)
+	require.Equal(t, []statsSample{
+		{name: "ticdc_capturer0_1", cpuPercent: 12.5, memoryBytes: 24.5 * (1 << 20)},
+		{name: "controller", cpuPercent: 0, memoryBytes: 512000},
+	}, samples)
+
+	_, err = parseDockerStats([]byte(`{"CPUPerc":"1%","MemUsage":"1XB / 2GB","Name":"controller"}`))
+	require.Error(t, err)
+}
+
+func TestParseByteSize(t *testing.T) {
+	for s, expected := range map[string]uint64{
+		"0B":      0,
+		"100B":    100,
+		"1KiB":    1024,
+		"1.5GiB":  3 << 29,
+		"2MB":     2000000,
+		"1.944GB": 1944000000,
+	} {
+		size, err := parseByteSize(s)
+		require.NoError(t, err, s)
+		require.Equal(t, expected, size, s)
+	}
+	_, err := parseByteSize("12")
+	require.Error(t, err)
+}
+
+func TestReportResources(t *testing.T) {
+	var sampler *resourceSampler
+	require.Nil(t, sampler.stop())
+
+	report := newTestReport("docker-compose-mysql", "")
+	report.record(TaskResult{Name: "Simple", Resources: []ContainerResources{{
+		Name: "capturer0", Samples: 2, AvgCPUPercent: 10, MaxCPUPercent: 15, AvgMemoryBytes: 100, MaxMemoryBytes: 120,
+	}}})
+	buf := new(bytes.Buffer)
+	require.NoError(t, report.writeSummary(buf))
+	require.Contains(t, buf.String(), `"max_memory_bytes": 120`)
+}
@@ -0,0 +1,293 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultDatabase is the database used by a task running alone in an environment
+	defaultDatabase = "testdb"
+	// changefeedConfigDir is the directory in the controller container to write
+	// the changefeed configuration files to
+	changefeedConfigDir = "/tmp"
+)
+
+// IsolatedTask is a Task which only reads and writes its own database, and its
+// changefeed only replicates that database, so it can run in parallel with
+// other IsolatedTasks in the same environment.
+type IsolatedTask interface {
+	Task
+	// SetDatabase sets the database used by the task, it is called before GetCDCProfile.
+	SetDatabase(name string)
+}
+
+// TaskResult is the result of running a task
+type TaskResult struct {
+	Name     string
+	Database string
+	// Versions is the versions of the components the task ran with, see ComposeConfig.VersionTag
+	Versions string
+	Duration time.Duration
+	Err      error
+	// ArtifactsDir is the directory in which the artifacts are collected if the task failed
+	ArtifactsDir string
+	// Resources is the resource usage of the containers while the task was running,
+	// it's only sampled if the environment has a stats interval, see SetStatsInterval
+	Resources []ContainerResources
+	// Log is the log printed while the task was running, which includes the
+	// logs of the other tasks running in parallel. It's only captured if the
+	// environment writes test reports.
+	Log string
+}
+
+// runTask creates the changefeed of the task in the controller container of
+// the docker-compose service, then prepares and runs the task. It aborts the
+// test if any step fails.
+func runTask(d *dockerComposeOperator, env Environment, task Task, waitForReady func() error) {
+	if isolated, ok := task.(IsolatedTask); ok {
+		isolated.SetDatabase(defaultDatabase)
+	}
+	result := executeTask(d, env, task, defaultDatabase, waitForReady)
+	if result.Err != nil {
+		result.ArtifactsDir = d.collectArtifacts(result.Name)
+	}
+	d.recordResults(result)
+	if result.Err != nil {
+		err := d.DumpStdout()
+		if err != nil {
+			log.Warn("Failed to dump container logs", zap.Error(err))
+		}
+		d.TearDown()
+		log.Fatal("RunTest: task failed", zap.String("name", result.Name), zap.Error(result.Err))
+	}
+}
+
+// runTasks runs the IsolatedTasks with the given parallelism, each in its own
+// database, then runs the other tasks one by one, resetting the environment
+// between them. It returns the results in the order of the tasks.
+func runTasks(
+	d *dockerComposeOperator, env Environment, tasks []Task, parallelism int, waitForReady func() error,
+) []TaskResult {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	results := make([]TaskResult, len(tasks))
+	var (
+		wg        sync.WaitGroup
+		sem       = make(chan struct{}, parallelism)
+		exclusive []int
+	)
+	for i, task := range tasks {
+		isolated, ok := task.(IsolatedTask)
+		if !ok {
+			exclusive = append(exclusive, i)
+			continue
+		}
+		database := fmt.Sprintf("%s_%d", defaultDatabase, i)
+		isolated.SetDatabase(database)
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			results[i] = executeTask(d, env, tasks[i], database, waitForReady)
+			if results[i].Err != nil {
+				results[i].ArtifactsDir = d.collectArtifacts(results[i].Name)
+			}
+			<-sem
+		}(i)
+	}
+	wg.Wait()
+
+	// The isolated tasks don't touch the default database, so the first
+	// exclusive task doesn't need a reset.
+	for n, i := range exclusive {
+		if n > 0 {
+			env.Reset()
+		}
+		results[i] = executeTask(d, env, tasks[i], defaultDatabase, waitForReady)
+		if results[i].Err != nil {
+			// collect the artifacts before the environment is reset
+			results[i].ArtifactsDir = d.collectArtifacts(results[i].Name)
+		}
+	}
+
+	d.recordResults(results...)
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+			log.Warn("Task failed", zap.String("name", result.Name),
+				zap.Duration("duration", result.Duration), zap.Error(result.Err))
+		}
+	}
+	if failed > 0 {
+		err := d.DumpStdout()
+		if err != nil {
+			log.Warn("Failed to dump container logs", zap.Error(err))
+		}
+	}
+	log.Info("Finished running tasks", zap.Int("total", len(results)), zap.Int("failed", failed))
+	return results
+}
+
+// executeTask creates the changefeed of the task in the controller container
+// of the docker-compose service, then prepares and runs the task.
+func executeTask(
+	d *dockerComposeOperator, env Environment, task Task, database string, waitForReady func() error,
+) (result TaskResult) {
+	result = TaskResult{Name: task.Name(), Database: database, Versions: d.versionTag()}
+	startTime := time.Now()
+	logBuf := globalLogCapture.start()
+	sampler := d.startResourceSampler()
+	defer func() {
+		result.Duration = time.Since(startTime)
+		result.Log = globalLogCapture.stop(logBuf)
+		result.Resources = sampler.stop()
+	}()
+
+	if d.keepEnv {
+		// the data of the database may be left by a previous run
+		err := d.cleanupDatabase(database, false)
+		if err != nil {
+			result.Err = errors.Annotate(err, "cannot clean up the database")
+			return
+		}
+	}
+
+	profile := task.GetCDCProfile()
+	if len(profile.FilterRules) > 0 {
+		configPath, err := d.writeChangefeedConfig(database, profile.FilterRules)
+		if err != nil {
+			result.Err = errors.Annotate(err, "cannot write changefeed config")
+			return
+		}
+		profile.ConfigPath = configPath
+	}
+	cmdLine := "/cdc " + profile.String()
+	bytes, err := d.ExecInController(cmdLine)
+	if err != nil {
+		var stderr []byte
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			stderr = exitErr.Stderr
+		}
+		log.Warn("RunTest: cannot setup changefeed",
+			zap.String("name", result.Name),
+			zap.Error(err),
+			zap.ByteString("stdout", bytes),
+			zap.ByteString("stderr", stderr))
+		result.Err = errors.Annotate(err, "cannot setup changefeed")
+		return
+	}
+	changefeedID := parseChangefeedID(bytes)
+	if changefeedID == "" {
+		changefeedID = profile.ChangefeedID
+	}
+
+	upstream, err := sql.Open("mysql", upstreamDSN)
+	if err != nil {
+		result.Err = errors.Annotate(err, "cannot connect to upstream database")
+		return
+	}
+
+	_, err = upstream.Exec("set @@global.tidb_enable_clustered_index=0")
+	if err != nil {
+		log.Info("tidb_enable_clustered_index not supported.")
+	} else {
+		time.Sleep(2 * time.Second)
+	}
+
+	downstream, err := sql.Open("mysql", downstreamDSN)
+	if err != nil {
+		result.Err = errors.Annotate(err, "cannot connect to downstream database")
+		return
+	}
+
+	taskCtx := &TaskContext{
+		Upstream:     upstream,
+		Downstream:   downstream,
+		Database:     database,
+		changefeedID: changefeedID,
+		env:          env,
+		docker:       d,
+		waitForReady: waitForReady,
+		Ctx:          context.Background(),
+	}
+	defer func() {
+		_ = taskCtx.Upstream.Close()
+		_ = taskCtx.Downstream.Close()
+	}()
+
+	err = task.Prepare(taskCtx)
+	if err != nil {
+		taskCtx.cleanup()
+		result.Err = errors.Annotate(err, "task preparation failed")
+		return
+	}
+
+	log.Info("Start running task", zap.String("name", result.Name), zap.String("database", database))
+	err = task.Run(taskCtx)
+	taskCtx.cleanup()
+	if err != nil {
+		result.Err = err
+		return
+	}
+	log.Info("Finished running task", zap.String("name", result.Name), zap.Duration("duration", time.Since(startTime)))
+	return
+}
+
+// writeChangefeedConfig writes a changefeed configuration file replicating only
+// the tables matching the filter rules into the controller container, and
+// returns the path of the file.
+func (d *dockerComposeOperator) writeChangefeedConfig(name string, filterRules []string) (string, error) {
+	rules := make([]string, 0, len(filterRules))
+	for _, rule := range filterRules {
+		rules = append(rules, strconv.Quote(rule))
+	}
+	content := "[filter]\nrules = [" + strings.Join(rules, ", ") + "]\n"
+	path := changefeedConfigDir + "/changefeed-" + name + ".toml"
+	_, err := d.ExecInController(fmt.Sprintf("printf '%s' > %s", content, path))
+	if err != nil {
+		return "", errors.AddStack(err)
+	}
+	return path, nil
+}
+
+// pingDatabases returns an error if any of the databases is not ready
+func pingDatabases(dsns ...string) error {
+	for _, dsn := range dsns {
+		db, err := sql.Open("mysql", dsn)
+		if err != nil {
+			return errors.AddStack(err)
+		}
+		err = db.Ping()
+		_ = db.Close()
+		if err != nil {
+			return errors.Annotatef(err, "database %s not ready", dsn)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsolatedTaskDatabase(t *testing.T) {
+	avro := &AvroSingleTableTask{TableName: "test"}
+	profile := avro.GetCDCProfile()
+	require.Equal(t, "kafka://kafka:29092/testdb_test?protocol=avro", profile.SinkURI)
+	require.Equal(t, []string{"testdb.*"}, profile.FilterRules)
+
+	var task Task = avro
+	isolated, ok := task.(IsolatedTask)
+	require.True(t, ok)
+	isolated.SetDatabase("testdb_3")
+	profile = avro.GetCDCProfile()
+	require.Equal(t, "kafka://kafka:29092/testdb_3_test?protocol=avro", profile.SinkURI)
+	require.Equal(t, []string{"testdb_3.*"}, profile.FilterRules)
+
+	mysql := &MySQLSingleTableTask{TableName: "test"}
+	workload := NewWorkloadTask(mysql, DefaultWorkloadConfig(defaultDatabase, "test"))
+	workload.SetDatabase("testdb_1")
+	require.Equal(t, "testdb_1", workload.Config.Schema)
+	require.Equal(t, []string{"testdb_1.*"}, mysql.GetCDCProfile().FilterRules)
+
+	// the TLS wrapper keeps a task isolated only if the wrapped task is isolated
+	_, ok = wrapTLSTask(avro).(IsolatedTask)
+	require.True(t, ok)
+	_, ok = wrapTLSTask(&nonIsolatedTask{}).(IsolatedTask)
+	require.False(t, ok)
+}
+
+func TestCDCProfileConfigPath(t *testing.T) {
+	profile := &CDCProfile{
+		PDUri:       "http://upstream-pd:2379",
+		SinkURI:     mysqlDownstreamSinkURI,
+		FilterRules: []string{"testdb.*"},
+		ConfigPath:  "/tmp/changefeed-testdb.toml",
+	}
+	require.Equal(t, "cli changefeed create --pd=http://upstream-pd:2379 --sink-uri="+mysqlDownstreamSinkURI+" "+
+		"--config=/tmp/changefeed-testdb.toml ", profile.String())
+}
+
+type nonIsolatedTask struct {
+	MySQLSingleTableTask
+}
+
+// SetDatabase hides the method of the embedded task
+func (t *nonIsolatedTask) SetDatabase() {}
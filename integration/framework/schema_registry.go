@@ -0,0 +1,313 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pingcap/errors"
+)
+
+const (
+	// the address of the schema registry with TLS for the clients running on the host
+	tlsSchemaRegistryHostURL = "https://127.0.0.1:8081"
+	// the content type of the requests to the schema registry
+	schemaRegistryContentType = "application/vnd.schemaregistry.v1+json"
+)
+
+// SchemaRegistry is a client of the schema registry in the environment
+type SchemaRegistry struct {
+	url    string
+	client *http.Client
+}
+
+// RegistrySchema is a version of the schema registered under a subject
+type RegistrySchema struct {
+	Subject string `json:"subject"`
+	Version int    `json:"version"`
+	ID      int    `json:"id"`
+	Schema  string `json:"schema"`
+}
+
+// AvroField is a field of an Avro record schema
+type AvroField struct {
+	Name string          `json:"name"`
+	Type json.RawMessage `json:"type"`
+}
+
+// Fields returns the fields of the schema, which must be an Avro record
+func (s *RegistrySchema) Fields() ([]AvroField, error) {
+	var record struct {
+		Type   string      `json:"type"`
+		Fields []AvroField `json:"fields"`
+	}
+	err := json.Unmarshal([]byte(s.Schema), &record)
+	if err != nil {
+		return nil, errors.Annotatef(err, "invalid schema %s", s.Schema)
+	}
+	if record.Type != "record" {
+		return nil, errors.Errorf("schema of subject %s version %d is not a record", s.Subject, s.Version)
+	}
+	return record.Fields, nil
+}
+
+// AvroValueSubject returns the subject of the schemas of the values of the table,
+// which is registered by TiCDC.
+func AvroValueSubject(schema string, table string) string {
+	return schema + "_" + table + "-value"
+}
+
+// AvroKeySubject returns the subject of the schemas of the keys of the table,
+// which is registered by TiCDC.
+func AvroKeySubject(schema string, table string) string {
+	return schema + "_" + table + "-key"
+}
+
+// SchemaRegistry returns a client of the schema registry in the environment
+func (c *TaskContext) SchemaRegistry() (*SchemaRegistry, error) {
+	if c.docker == nil {
+		return &SchemaRegistry{url: schemaRegistryHostURL, client: http.DefaultClient}, nil
+	}
+	return c.docker.schemaRegistry()
+}
+
+func (d *dockerComposeOperator) schemaRegistry() (*SchemaRegistry, error) {
+	tlsConfig, err := d.tlsClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig == nil {
+		return &SchemaRegistry{url: schemaRegistryHostURL, client: http.DefaultClient}, nil
+	}
+	return &SchemaRegistry{
+		url:    tlsSchemaRegistryHostURL,
+		client: &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}},
+	}, nil
+}
+
+// tlsClientConfig returns the TLS configuration of the clients on the host with
+// the credentials generated for the environment, it returns nil if the environment
+// doesn't use TLS.
+func (d *dockerComposeOperator) tlsClientConfig() (*tls.Config, error) {
+	if !d.tls {
+		return nil, nil
+	}
+	dir := filepath.Join(filepath.Dir(d.fileName), tlsCredentialDir)
+	ca, err := ioutil.ReadFile(filepath.Join(dir, CAFileName))
+	if err != nil {
+		return nil, errors.AddStack(err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, errors.New("invalid CA certificate")
+	}
+	cert, err := tls.LoadX509KeyPair(filepath.Join(dir, ClientCertFileName), filepath.Join(dir, ClientKeyFileName))
+	if err != nil {
+		return nil, errors.AddStack(err)
+	}
+	return &tls.Config{RootCAs: pool, Certificates: []tls.Certificate{cert}}, nil
+}
+
+// get sends a GET request and decodes the JSON response into result, it
+// returns false if the resource is not found.
+func (r *SchemaRegistry) get(path string, result interface{}) (bool, error) {
+	return r.do(http.MethodGet, path, nil, result)
+}
+
+func (r *SchemaRegistry) do(method string, path string, body interface{}, result interface{}) (bool, error) {
+	var reqBody []byte
+	if body != nil {
+		var err error
+		reqBody, err = json.Marshal(body)
+		if err != nil {
+			return false, errors.AddStack(err)
+		}
+	}
+	req, err := http.NewRequest(method, r.url+path, bytes.NewReader(reqBody))
+	if err != nil {
+		return false, errors.AddStack(err)
+	}
+	req.Header.Set("Accept", schemaRegistryContentType)
+	if body != nil {
+		req.Header.Set("Content-Type", schemaRegistryContentType)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return false, errors.AddStack(err)
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return false, errors.AddStack(err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return false, errors.Errorf("schema registry returned status code %d for %s %s: %s",
+			resp.StatusCode, method, path, respBody)
+	}
+	err = json.Unmarshal(respBody, result)
+	if err != nil {
+		return false, errors.Annotatef(err, "invalid response of %s %s: %s", method, path, respBody)
+	}
+	return true, nil
+}
+
+// Subjects returns all the subjects in the registry
+func (r *SchemaRegistry) Subjects() ([]string, error) {
+	var subjects []string
+	_, err := r.get("/subjects", &subjects)
+	return subjects, err
+}
+
+// DeleteSubject deletes all the versions of the schemas registered under the
+// subject permanently, it does nothing if the subject doesn't exist.
+func (r *SchemaRegistry) DeleteSubject(subject string) error {
+	var versions []int
+	path := "/subjects/" + url.PathEscape(subject)
+	found, err := r.do(http.MethodDelete, path, nil, &versions)
+	if err != nil || !found {
+		return err
+	}
+	// the versions are soft deleted first, and then deleted permanently
+	_, err = r.do(http.MethodDelete, path+"?permanent=true", nil, &versions)
+	return err
+}
+
+// Versions returns the versions of the schemas registered under the subject,
+// it returns an empty slice if the subject doesn't exist.
+func (r *SchemaRegistry) Versions(subject string) ([]int, error) {
+	var versions []int
+	_, err := r.get("/subjects/"+url.PathEscape(subject)+"/versions", &versions)
+	return versions, err
+}
+
+// Schema returns the given version of the schema registered under the subject,
+// version 0 means the latest version.
+func (r *SchemaRegistry) Schema(subject string, version int) (*RegistrySchema, error) {
+	v := "latest"
+	if version > 0 {
+		v = strconv.Itoa(version)
+	}
+	schema := new(RegistrySchema)
+	found, err := r.get("/subjects/"+url.PathEscape(subject)+"/versions/"+v, schema)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, errors.Errorf("version %s of subject %s not found", v, subject)
+	}
+	return schema, nil
+}
+
+// Compatibility returns the compatibility level of the subject, which is the
+// global level if the subject doesn't have its own.
+func (r *SchemaRegistry) Compatibility(subject string) (string, error) {
+	var config struct {
+		CompatibilityLevel string `json:"compatibilityLevel"`
+	}
+	found, err := r.get("/config/"+url.PathEscape(subject), &config)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		_, err = r.get("/config", &config)
+		if err != nil {
+			return "", err
+		}
+	}
+	return config.CompatibilityLevel, nil
+}
+
+// IsCompatible tests whether the schema is compatible with the latest version
+// registered under the subject, according to the compatibility level of the subject.
+func (r *SchemaRegistry) IsCompatible(subject string, schema string) (bool, error) {
+	var result struct {
+		IsCompatible bool `json:"is_compatible"`
+	}
+	found, err := r.do(http.MethodPost, "/compatibility/subjects/"+url.PathEscape(subject)+"/versions/latest",
+		map[string]string{"schema": schema}, &result)
+	if err != nil {
+		return false, err
+	}
+	if !found {
+		return false, errors.Errorf("subject %s not found", subject)
+	}
+	return result.IsCompatible, nil
+}
+
+// RequireFields checks the latest schema registered under the subject has
+// exactly the given fields in order.
+func (r *SchemaRegistry) RequireFields(subject string, fields ...string) error {
+	schema, err := r.Schema(subject, 0)
+	if err != nil {
+		return err
+	}
+	actual, err := schema.Fields()
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(actual))
+	for _, field := range actual {
+		names = append(names, field.Name)
+	}
+	if strings.Join(names, ",") != strings.Join(fields, ",") {
+		return errors.Errorf("fields of version %d of subject %s are %v, expected %v",
+			schema.Version, subject, names, fields)
+	}
+	return nil
+}
+
+// RequireVersions waits until at least n versions of the schema have been
+// registered under the subject, and checks the compatibility level of the
+// subject is not NONE, so every version has been checked by the registry
+// against the previous ones.
+func (r *SchemaRegistry) RequireVersions(subject string, n int, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	var versions []int
+	for {
+		var err error
+		versions, err = r.Versions(subject)
+		if err != nil {
+			return err
+		}
+		if len(versions) >= n {
+			break
+		}
+		if time.Now().After(deadline) {
+			return errors.Errorf("subject %s has %d versions after %s, expected at least %d",
+				subject, len(versions), timeout, n)
+		}
+		time.Sleep(time.Second)
+	}
+	level, err := r.Compatibility(subject)
+	if err != nil {
+		return err
+	}
+	if level == "NONE" {
+		return errors.Errorf("compatibility of subject %s is not checked by the registry", subject)
+	}
+	return nil
+}
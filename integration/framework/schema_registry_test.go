@@ -0,0 +1,78 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaRegistry(t *testing.T) {
+	subject := AvroValueSubject("testdb", "test")
+	require.Equal(t, "testdb_test-value", subject)
+	v2 := `{"type":"record","name":"test","fields":[{"name":"id","type":"long"},` +
+		`{"name":"value","type":["null","long"],"default":null}]}`
+	responses := map[string]interface{}{
+		"GET /subjects": []string{subject, "testdb_test-key"},
+		"GET /subjects/testdb_test-value/versions":        []int{1, 2},
+		"GET /subjects/testdb_test-value/versions/latest": RegistrySchema{Subject: subject, Version: 2, ID: 3, Schema: v2},
+		"GET /config": map[string]string{"compatibilityLevel": "BACKWARD"},
+		"POST /compatibility/subjects/testdb_test-value/versions/latest": map[string]bool{"is_compatible": true},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp, ok := responses[r.Method+" "+r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error_code":40401,"message":"Subject not found."}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+	registry := &SchemaRegistry{url: server.URL, client: http.DefaultClient}
+
+	subjects, err := registry.Subjects()
+	require.NoError(t, err)
+	require.Equal(t, []string{subject, "testdb_test-key"}, subjects)
+	versions, err := registry.Versions(subject)
+	require.NoError(t, err)
+	require.Equal(t, []int{1, 2}, versions)
+	versions, err = registry.Versions("unknown")
+	require.NoError(t, err)
+	require.Empty(t, versions)
+
+	schema, err := registry.Schema(subject, 0)
+	require.NoError(t, err)
+	require.Equal(t, 2, schema.Version)
+	_, err = registry.Schema(subject, 1)
+	require.Error(t, err)
+
+	// the subject doesn't have its own compatibility level
+	level, err := registry.Compatibility(subject)
+	require.NoError(t, err)
+	require.Equal(t, "BACKWARD", level)
+	compatible, err := registry.IsCompatible(subject, v2)
+	require.NoError(t, err)
+	require.True(t, compatible)
+
+	require.NoError(t, registry.RequireFields(subject, "id", "value"))
+	require.Error(t, registry.RequireFields(subject, "id"))
+	require.NoError(t, registry.RequireVersions(subject, 2, time.Second))
+	require.Error(t, registry.RequireVersions(subject, 3, time.Millisecond))
+}
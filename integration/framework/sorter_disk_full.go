@@ -0,0 +1,298 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/pkg/quotes"
+	"github.com/pingcap/ticdc/pkg/retry"
+	"go.uber.org/zap"
+)
+
+const (
+	// sorterSortDir is the sort dir of the changefeeds in SorterDiskFullDockerEnv,
+	// a tmpfs of defaultSortDirSize is mounted at it in the captures
+	sorterSortDir      = "/tmp/cdc_sort"
+	defaultSortDirSize = "32m"
+)
+
+// SorterDiskFullDockerEnv represents the docker-compose service generated from
+// sorterDiskFullComposeConfig, which is like MySQLDockerEnv, but the changefeeds
+// sort the events in files, in a small tmpfs which is easily filled up.
+type SorterDiskFullDockerEnv struct {
+	dockerComposeOperator
+}
+
+// NewSorterDiskFullDockerEnv creates a new SorterDiskFullDockerEnv
+func NewSorterDiskFullDockerEnv(dockerComposeFile string) *SorterDiskFullDockerEnv {
+	healthChecker := func() error {
+		return pingDatabases(upstreamDSN, downstreamDSN)
+	}
+
+	env := &SorterDiskFullDockerEnv{newDockerComposeOperator(dockerComposeFile, sorterDiskFullComposeConfig())}
+	env.controller = controllerContainerName
+	env.healthChecker = healthChecker
+	return env
+}
+
+// Reset implements Environment
+func (e *SorterDiskFullDockerEnv) Reset() {
+	e.TearDown()
+	e.Setup()
+}
+
+// RunTest implements Environment
+func (e *SorterDiskFullDockerEnv) RunTest(task Task) {
+	runTask(&e.dockerComposeOperator, e, wrapProfileTask(task, fileSorterProfile), func() error {
+		return retry.Run(time.Second, 120, e.healthChecker)
+	})
+}
+
+// RunTests implements Environment
+func (e *SorterDiskFullDockerEnv) RunTests(tasks []Task, parallelism int) []TaskResult {
+	wrapped := make([]Task, len(tasks))
+	for i, task := range tasks {
+		wrapped[i] = wrapProfileTask(task, fileSorterProfile)
+	}
+	return runTasks(&e.dockerComposeOperator, e, wrapped, parallelism, func() error {
+		return retry.Run(time.Second, 120, e.healthChecker)
+	})
+}
+
+// SetListener implements Environment. The MySQL sink has no MQ output, so it does nothing.
+func (e *SorterDiskFullDockerEnv) SetListener(states interface{}, listener MqListener) {
+}
+
+// fileSorterProfile returns a copy of the profile which sorts the events in files in sorterSortDir
+func fileSorterProfile(profile *CDCProfile) *CDCProfile {
+	ret := *profile
+	ret.SortEngine = string(model.SortInFile)
+	ret.SortDir = sorterSortDir
+	return &ret
+}
+
+// SorterDiskFullConfig is the configuration of a SorterDiskFullTask
+type SorterDiskFullConfig struct {
+	// Table is the table the rows are written to
+	Table string
+	// Rows of RowSize bytes are written to the table, Rows * RowSize should be
+	// much larger than the size of the sort dir
+	Rows    int
+	RowSize int
+	// BatchSize is the number of rows written in a statement
+	BatchSize int
+	// Observe is how long to watch the changefeed and the captures after the rows are written
+	Observe time.Duration
+}
+
+// DefaultSorterDiskFullConfig returns a SorterDiskFullConfig writing 128MB to the table
+func DefaultSorterDiskFullConfig(table string) SorterDiskFullConfig {
+	return SorterDiskFullConfig{
+		Table:     table,
+		Rows:      2048,
+		RowSize:   64 << 10,
+		BatchSize: 16,
+		Observe:   time.Minute,
+	}
+}
+
+// SorterDiskFullTask writes more data than the sort dir of SorterDiskFullDockerEnv
+// can hold, and checks the changefeed degrades gracefully: it either keeps running,
+// stalled by backpressure or having replicated all the rows, or stops with an error,
+// while no capture crashes.
+type SorterDiskFullTask struct {
+	Task
+	Config SorterDiskFullConfig
+}
+
+// NewSorterDiskFullTask creates a SorterDiskFullTask, base provides the CDCProfile and prepares the databases
+func NewSorterDiskFullTask(base Task, config SorterDiskFullConfig) *SorterDiskFullTask {
+	return &SorterDiskFullTask{Task: base, Config: config}
+}
+
+// Name implements Task
+func (s *SorterDiskFullTask) Name() string {
+	return "Sorter-Disk-Full"
+}
+
+// SetDatabase implements IsolatedTask, the table is created in the database of
+// base if base is an IsolatedTask.
+func (s *SorterDiskFullTask) SetDatabase(name string) {
+	if isolated, ok := s.Task.(IsolatedTask); ok {
+		isolated.SetDatabase(name)
+	}
+}
+
+// Run implements Task
+func (s *SorterDiskFullTask) Run(taskContext *TaskContext) error {
+	restarts, err := taskContext.docker.captureRestarts()
+	if err != nil {
+		return err
+	}
+	// free the sort dir for the tasks run after this one
+	taskContext.addCleanup(func() {
+		err := taskContext.RemoveChangefeed()
+		if err == nil {
+			err = taskContext.docker.clearSortDir()
+		}
+		if err != nil {
+			log.Warn("failed to clean up the sort dir", zap.Error(err))
+		}
+	})
+	table := quotes.QuoteSchema(taskContext.Database, s.Config.Table)
+	_, err = taskContext.Upstream.ExecContext(taskContext.Ctx,
+		"create table "+table+" (id int primary key, v longblob)")
+	if err != nil {
+		return errors.AddStack(err)
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("(?, repeat('x', ?)), ", s.Config.BatchSize), ", ")
+	for i := 0; i < s.Config.Rows; i += s.Config.BatchSize {
+		args := make([]interface{}, 0, 2*s.Config.BatchSize)
+		for j := i; j < i+s.Config.BatchSize; j++ {
+			args = append(args, j, s.Config.RowSize)
+		}
+		_, err := taskContext.Upstream.ExecContext(taskContext.Ctx,
+			"insert into "+table+" values "+placeholders, args...)
+		if err != nil {
+			return errors.AddStack(err)
+		}
+	}
+	endTs, err := UpstreamTs(taskContext.Ctx, taskContext.Upstream)
+	if err != nil {
+		return err
+	}
+	log.Info("rows written to fill up the sort dir",
+		zap.Int("bytes", s.Config.Rows*s.Config.RowSize), zap.Uint64("endTs", endTs))
+
+	deadline := time.Now().Add(s.Config.Observe)
+	var status *ChangefeedStatus
+	for {
+		status, err = taskContext.QueryChangefeedStatus()
+		if err != nil {
+			return errors.Annotate(err, "the cluster doesn't respond when the sort dir is full")
+		}
+		err = taskContext.docker.checkCaptureRestarts(restarts)
+		if err != nil {
+			return err
+		}
+		if status.State != model.StateNormal || status.CheckpointTs >= endTs || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(2 * time.Second)
+	}
+
+	switch {
+	case status.State == model.StateNormal && status.CheckpointTs >= endTs:
+		log.Info("all the rows are replicated with the small sort dir")
+		return taskContext.TableConsistent(taskContext.Database, s.Config.Table).Wait().Check()
+	case status.State == model.StateNormal:
+		log.Info("the changefeed is stalled by the full sort dir",
+			zap.Uint64("checkpointTs", status.CheckpointTs), zap.Uint64("endTs", endTs))
+	case status.Error != nil:
+		log.Info("the changefeed stopped with an error when the sort dir is full",
+			zap.String("state", string(status.State)),
+			zap.String("code", status.Error.Code), zap.String("message", status.Error.Message))
+	default:
+		return errors.Errorf("the changefeed is %s without an error when the sort dir is full", status.State)
+	}
+	// the captures must survive the changefeed being stalled or stopped for a while
+	time.Sleep(10 * time.Second)
+	return taskContext.docker.checkCaptureRestarts(restarts)
+}
+
+// captureContainers returns the containers of the captures by their host names
+func (d *dockerComposeOperator) captureContainers() (map[string]string, error) {
+	captures, err := d.Captures()
+	if err != nil {
+		return nil, err
+	}
+	containers := make(map[string]string, len(captures))
+	for _, capture := range captures {
+		host := capture.AdvertiseAddr
+		if i := strings.LastIndex(host, ":"); i >= 0 {
+			host = host[:i]
+		}
+		// the containers started by ScaleCDC are named after their host names
+		containers[host] = host
+		if _, ok := d.scaledCaptures[host]; !ok {
+			containers[host], err = d.containerID(host)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return containers, nil
+}
+
+// captureRestarts returns the restart count of the container of every capture
+func (d *dockerComposeOperator) captureRestarts() (map[string]int, error) {
+	containers, err := d.captureContainers()
+	if err != nil {
+		return nil, err
+	}
+	restarts := make(map[string]int, len(containers))
+	for host, container := range containers {
+		out, err := runCmd(exec.Command("docker", "inspect", "-f", "{{.RestartCount}}", container))
+		if err != nil {
+			return nil, err
+		}
+		restarts[host], err = strconv.Atoi(strings.TrimSpace(string(out)))
+		if err != nil {
+			return nil, errors.Annotatef(err, "invalid restart count of %s: %s", host, out)
+		}
+	}
+	return restarts, nil
+}
+
+// clearSortDir removes the files left in the sort dir of every capture
+func (d *dockerComposeOperator) clearSortDir() error {
+	containers, err := d.captureContainers()
+	if err != nil {
+		return err
+	}
+	for _, container := range containers {
+		_, err := runCmd(exec.Command("docker", "exec", container, "sh", "-c", "rm -rf "+sorterSortDir+"/*"))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkCaptureRestarts returns an error if any capture has restarted or is gone
+// since the restart counts were returned by captureRestarts
+func (d *dockerComposeOperator) checkCaptureRestarts(before map[string]int) error {
+	after, err := d.captureRestarts()
+	if err != nil {
+		return err
+	}
+	for host, count := range before {
+		current, ok := after[host]
+		if !ok {
+			return errors.Errorf("capture %s is gone", host)
+		}
+		if current != count {
+			return errors.Errorf("capture %s crashed, it has restarted %d times", host, current-count)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSorterProfile(t *testing.T) {
+	base := &MySQLSingleTableTask{TableName: "sorter"}
+	task := wrapProfileTask(NewSorterDiskFullTask(base, DefaultSorterDiskFullConfig("sorter")), fileSorterProfile)
+	isolated, ok := task.(IsolatedTask)
+	require.True(t, ok)
+	isolated.SetDatabase("testdb_1")
+
+	profile := task.GetCDCProfile()
+	require.Equal(t, "file", profile.SortEngine)
+	require.Equal(t, []string{"testdb_1.*"}, profile.FilterRules)
+	require.Equal(t, "cli changefeed create --pd=http://upstream-pd:2379 --sink-uri="+mysqlDownstreamSinkURI+" "+
+		"--sort-engine=file --sort-dir=/tmp/cdc_sort ", profile.String())
+	require.Empty(t, base.GetCDCProfile().SortEngine)
+}
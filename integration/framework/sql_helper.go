@@ -35,6 +35,7 @@ import (
 type SQLHelper struct {
 	upstream   *sql.DB
 	downstream *sql.DB
+	database   string
 	ctx        context.Context
 }
 
@@ -53,7 +54,11 @@ func (h *SQLHelper) GetTable(tableName string) *Table {
 		return &Table{err: errors.AddStack(err)}
 	}
 
-	idxCol, err := getUniqueIndexColumn(h.ctx, db, "testdb", tableName)
+	database := h.database
+	if database == "" {
+		database = defaultDatabase
+	}
+	idxCol, err := getUniqueIndexColumn(h.ctx, db, database, tableName)
 	if err != nil {
 		return &Table{err: errors.AddStack(err)}
 	}
@@ -0,0 +1,146 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/ticdc/pkg/quotes"
+	"go.uber.org/zap"
+	"upper.io/db.v3/lib/sqlbuilder"
+)
+
+const tableConsistentTimeout = 120 * time.Second
+
+// tableComparator compares the content of a table in the upstream and the downstream
+type tableComparator struct {
+	upstream   *sql.DB
+	downstream *sql.DB
+	schema     string
+	table      string
+	diff       error
+}
+
+// CompareTable compares the content of the table in the upstream and the downstream,
+// it returns an error describing the first difference found.
+func (c *TaskContext) CompareTable(schema string, table string) error {
+	cmp := c.newTableComparator(schema, table)
+	_, err := cmp.poll(c.Ctx)
+	if err != nil {
+		return err
+	}
+	return cmp.Check()
+}
+
+// TableConsistent returns an Awaitable that waits until the table has the same
+// content in the upstream and the downstream.
+func (c *TaskContext) TableConsistent(schema string, table string) Awaitable {
+	return &basicAwaitable{
+		pollableAndCheckable: c.newTableComparator(schema, table),
+		timeout:              tableConsistentTimeout,
+	}
+}
+
+func (c *TaskContext) newTableComparator(schema string, table string) *tableComparator {
+	return &tableComparator{
+		upstream:   c.Upstream,
+		downstream: c.Downstream,
+		schema:     schema,
+		table:      table,
+	}
+}
+
+func (t *tableComparator) poll(ctx context.Context) (bool, error) {
+	db, err := sqlbuilder.New("mysql", t.upstream)
+	if err != nil {
+		return false, errors.AddStack(err)
+	}
+	orderBy, err := getUniqueIndexColumn(ctx, db, t.schema, t.table)
+	if err != nil {
+		return false, errors.AddStack(err)
+	}
+
+	upstreamRows, err := selectOrderedRows(ctx, t.upstream, t.schema, t.table, orderBy)
+	if err != nil {
+		return false, errors.AddStack(err)
+	}
+	downstreamRows, err := selectOrderedRows(ctx, t.downstream, t.schema, t.table, orderBy)
+	if err != nil {
+		if strings.Contains(err.Error(), "Error 1146") {
+			t.diff = errors.Errorf("table %s.%s does not exist in downstream", t.schema, t.table)
+			return false, nil
+		}
+		return false, errors.AddStack(err)
+	}
+
+	t.diff = diffRows(upstreamRows, downstreamRows)
+	if t.diff != nil {
+		log.Debug("table not consistent yet",
+			zap.String("schema", t.schema),
+			zap.String("table", t.table),
+			zap.Error(t.diff))
+		return false, nil
+	}
+	return true, nil
+}
+
+// Check implements Checkable
+func (t *tableComparator) Check() error {
+	if t.diff != nil {
+		log.Warn("Check failed", zap.String("schema", t.schema), zap.String("table", t.table), zap.Error(t.diff))
+	}
+	return t.diff
+}
+
+func selectOrderedRows(ctx context.Context, db *sql.DB, schema string, table string, orderBy []string) ([]map[string]interface{}, error) {
+	orderByQuoted := make([]string, len(orderBy))
+	for i := range orderBy {
+		orderByQuoted[i] = quotes.QuoteName(orderBy[i])
+	}
+	query := fmt.Sprintf("select * from %s order by %s",
+		quotes.QuoteSchema(schema, table), strings.Join(orderByQuoted, ","))
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ret := make([]map[string]interface{}, 0)
+	for rows.Next() {
+		m, err := rowsToMap(rows)
+		if err != nil {
+			return nil, errors.AddStack(err)
+		}
+		ret = append(ret, m)
+	}
+	return ret, errors.AddStack(rows.Err())
+}
+
+func diffRows(upstream []map[string]interface{}, downstream []map[string]interface{}) error {
+	for i := 0; i < len(upstream) && i < len(downstream); i++ {
+		if !compareMaps(upstream[i], downstream[i]) {
+			return errors.Errorf("row %d differs, upstream: %v, downstream: %v", i, upstream[i], downstream[i])
+		}
+	}
+	if len(upstream) != len(downstream) {
+		return errors.Errorf("row count differs, upstream: %d, downstream: %d", len(upstream), len(downstream))
+	}
+	return nil
+}
@@ -16,6 +16,7 @@ package framework
 import (
 	"context"
 	"database/sql"
+	"net/url"
 	"strings"
 
 	_ "github.com/go-sql-driver/mysql" // imported for side effects
@@ -33,11 +34,16 @@ type Task interface {
 
 // TaskContext is passed to the test case to provide basic utilities for testing
 type TaskContext struct {
-	Upstream     *sql.DB
-	Downstream   *sql.DB
+	Upstream   *sql.DB
+	Downstream *sql.DB
+	// Database is the database the task should create its tables in
+	Database     string
+	changefeedID string
 	env          Environment
+	docker       *dockerComposeOperator
 	waitForReady func() error
 	Ctx          context.Context
+	cleanups     []func()
 }
 
 // CDCProfile represents the command line arguments used to create the changefeed
@@ -45,6 +51,29 @@ type CDCProfile struct {
 	PDUri   string
 	SinkURI string
 	Opts    map[string]string
+	// ChangefeedID is generated by the cdc cli if it is empty
+	ChangefeedID string
+	// The paths of the credentials in the controller container used to connect to PD with TLS
+	CAPath   string
+	CertPath string
+	KeyPath  string
+	// FilterRules are the table filter rules of the changefeed, all tables are
+	// replicated if it is empty
+	FilterRules []string
+	// ConfigPath is the path of the changefeed configuration file in the
+	// controller container, it is set by the framework if FilterRules is not empty
+	ConfigPath string
+	// SortEngine is the sort engine of the changefeed, the default of the cdc cli is
+	// used if it's empty, and SortDir is the directory used by the file sort engine
+	SortEngine string
+	SortDir    string
+	// KerberosPrincipal is the principal in the form of user@REALM with which the
+	// Kafka sink authenticates with SASL/GSSAPI, SASL is not used if it's empty
+	KerberosPrincipal string
+	// The paths of the keytab of KerberosPrincipal and the Kerberos configuration
+	// in the controller and the capture containers
+	KerberosKeytabPath string
+	KerberosConfigPath string
 }
 
 // CreateDB creates a database in both the upstream and the downstream
@@ -68,11 +97,35 @@ func (c *TaskContext) CreateDB(name string) error {
 	return nil
 }
 
+// addCleanup registers a function to be called after the task has finished running
+func (c *TaskContext) addCleanup(f func()) {
+	c.cleanups = append(c.cleanups, f)
+}
+
+// cleanup calls the registered cleanup functions in the reverse order of registration
+func (c *TaskContext) cleanup() {
+	for i := len(c.cleanups) - 1; i >= 0; i-- {
+		c.cleanups[i]()
+	}
+	c.cleanups = nil
+}
+
+// Cluster returns the TiCDC cluster in the environment
+func (c *TaskContext) Cluster() CDCCluster {
+	return c.docker
+}
+
+// Chaos returns the operator to inject faults into the environment
+func (c *TaskContext) Chaos() ChaosOperator {
+	return c.docker
+}
+
 // SQLHelper returns an SQLHelper
 func (c *TaskContext) SQLHelper() *SQLHelper {
 	return &SQLHelper{
 		upstream:   c.Upstream,
 		downstream: c.Downstream,
+		database:   c.Database,
 		ctx:        c.Ctx,
 	}
 }
@@ -91,7 +144,28 @@ func (p *CDCProfile) String() string {
 		log.Fatal("SinkURI cannot be empty!")
 	}
 
-	builder.WriteString("--sink-uri=" + p.SinkURI + " ")
+	// the query of the sink URI may contain '&', which is special to the shell
+	builder.WriteString("--sink-uri=" + shellQuote(p.sinkURI()) + " ")
+
+	if p.ChangefeedID != "" {
+		builder.WriteString("--changefeed-id=" + p.ChangefeedID + " ")
+	}
+
+	if p.ConfigPath != "" {
+		builder.WriteString("--config=" + p.ConfigPath + " ")
+	}
+
+	if p.SortEngine != "" {
+		builder.WriteString("--sort-engine=" + p.SortEngine + " ")
+	}
+
+	if p.SortDir != "" {
+		builder.WriteString("--sort-dir=" + shellQuote(p.SortDir) + " ")
+	}
+
+	if p.CAPath != "" {
+		builder.WriteString("--ca=" + p.CAPath + " --cert=" + p.CertPath + " --key=" + p.KeyPath + " ")
+	}
 
 	if p.Opts == nil || len(p.Opts) == 0 {
 		return builder.String()
@@ -102,3 +176,63 @@ func (p *CDCProfile) String() string {
 	}
 	return builder.String()
 }
+
+// shellQuote quotes s with single quotes if it contains any character special to the shell
+func shellQuote(s string) string {
+	if !strings.ContainsAny(s, " \t\n&;|<>()$`\\\"'*?[#~") {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// sinkURI returns SinkURI with the SASL parameters of the Kafka sink if KerberosPrincipal is set
+func (p *CDCProfile) sinkURI() string {
+	if p.KerberosPrincipal == "" {
+		return p.SinkURI
+	}
+	sinkURI, err := url.Parse(p.SinkURI)
+	if err != nil {
+		log.Fatal("invalid sink URI", zap.String("sinkURI", p.SinkURI), zap.Error(err))
+	}
+	query := sinkURI.Query()
+	query.Set("sasl-mechanism", "gssapi")
+	user := p.KerberosPrincipal
+	if i := strings.LastIndex(user, "@"); i >= 0 {
+		query.Set("sasl-gssapi-realm", user[i+1:])
+		user = user[:i]
+	}
+	query.Set("sasl-gssapi-user", user)
+	query.Set("sasl-gssapi-keytab-path", p.KerberosKeytabPath)
+	query.Set("sasl-gssapi-kerberos-config-path", p.KerberosConfigPath)
+	sinkURI.RawQuery = query.Encode()
+	return sinkURI.String()
+}
+
+// profileTask rewrites the CDCProfile of a task
+type profileTask struct {
+	Task
+	rewrite func(profile *CDCProfile) *CDCProfile
+}
+
+// isolatedProfileTask is a profileTask wrapping an IsolatedTask
+type isolatedProfileTask struct {
+	profileTask
+}
+
+// SetDatabase implements IsolatedTask
+func (t *isolatedProfileTask) SetDatabase(name string) {
+	t.Task.(IsolatedTask).SetDatabase(name)
+}
+
+// wrapProfileTask wraps the task in a profileTask, keeping it an IsolatedTask if it is one
+func wrapProfileTask(task Task, rewrite func(profile *CDCProfile) *CDCProfile) Task {
+	if _, ok := task.(IsolatedTask); ok {
+		return &isolatedProfileTask{profileTask{Task: task, rewrite: rewrite}}
+	}
+	return &profileTask{Task: task, rewrite: rewrite}
+}
+
+// GetCDCProfile implements Task
+func (t *profileTask) GetCDCProfile() *CDCProfile {
+	return t.rewrite(t.Task.GetCDCProfile())
+}
@@ -0,0 +1,129 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pingcap/errors"
+)
+
+// The names of the files written by GenerateCertificates
+const (
+	CAFileName         = "ca.pem"
+	ServerCertFileName = "server.pem"
+	ServerKeyFileName  = "server-key.pem"
+	ClientCertFileName = "client.pem"
+	ClientKeyFileName  = "client-key.pem"
+
+	certValidity = 10 * 365 * 24 * time.Hour
+)
+
+// GenerateCertificates generates a self-signed CA, a server certificate valid for
+// hosts signed by the CA, and a client certificate signed by the CA, and writes
+// them to dir in PEM format. The server certificate can also be used by the
+// servers to connect to each other.
+func GenerateCertificates(dir string, hosts []string) error {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return errors.AddStack(err)
+	}
+
+	caKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return errors.AddStack(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ticdc-test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(certValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		return errors.AddStack(err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		return errors.AddStack(err)
+	}
+	err = writePEM(filepath.Join(dir, CAFileName), "CERTIFICATE", caDER)
+	if err != nil {
+		return err
+	}
+
+	serverTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "ticdc-test-server"},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			serverTemplate.IPAddresses = append(serverTemplate.IPAddresses, ip)
+		} else {
+			serverTemplate.DNSNames = append(serverTemplate.DNSNames, host)
+		}
+	}
+	err = generateSignedCert(dir, ServerCertFileName, ServerKeyFileName, serverTemplate, ca, caKey)
+	if err != nil {
+		return err
+	}
+
+	clientTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "ticdc-test-client"},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	return generateSignedCert(dir, ClientCertFileName, ClientKeyFileName, clientTemplate, ca, caKey)
+}
+
+func generateSignedCert(
+	dir string, certFile string, keyFile string, template *x509.Certificate, ca *x509.Certificate, caKey *rsa.PrivateKey,
+) error {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return errors.AddStack(err)
+	}
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(certValidity)
+	template.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		return errors.AddStack(err)
+	}
+	err = writePEM(filepath.Join(dir, certFile), "CERTIFICATE", der)
+	if err != nil {
+		return err
+	}
+	return writePEM(filepath.Join(dir, keyFile), "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key))
+}
+
+func writePEM(path string, blockType string, bytes []byte) error {
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: bytes})
+	// the containers may run as users other than the owner of the files
+	return errors.AddStack(ioutil.WriteFile(path, data, 0644))
+}
@@ -0,0 +1,58 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateCertificates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "certs")
+	require.NoError(t, err)
+
+	err = GenerateCertificates(dir, []string{"upstream-pd", "127.0.0.1"})
+	require.NoError(t, err)
+
+	caPEM, err := ioutil.ReadFile(filepath.Join(dir, CAFileName))
+	require.NoError(t, err)
+	pool := x509.NewCertPool()
+	require.True(t, pool.AppendCertsFromPEM(caPEM))
+
+	server, err := tls.LoadX509KeyPair(filepath.Join(dir, ServerCertFileName), filepath.Join(dir, ServerKeyFileName))
+	require.NoError(t, err)
+	serverCert, err := x509.ParseCertificate(server.Certificate[0])
+	require.NoError(t, err)
+	for _, host := range []string{"upstream-pd", "127.0.0.1"} {
+		_, err = serverCert.Verify(x509.VerifyOptions{DNSName: host, Roots: pool})
+		require.NoError(t, err, host)
+	}
+	_, err = serverCert.Verify(x509.VerifyOptions{DNSName: "kafka", Roots: pool})
+	require.Error(t, err)
+
+	client, err := tls.LoadX509KeyPair(filepath.Join(dir, ClientCertFileName), filepath.Join(dir, ClientKeyFileName))
+	require.NoError(t, err)
+	clientCert, err := x509.ParseCertificate(client.Certificate[0])
+	require.NoError(t, err)
+	_, err = clientCert.Verify(x509.VerifyOptions{
+		Roots:     pool,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	require.NoError(t, err)
+}
@@ -0,0 +1,171 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"net/url"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pingcap/log"
+	"github.com/pingcap/ticdc/pkg/retry"
+	"go.uber.org/zap"
+)
+
+const (
+	// the directory of the credentials relative to the docker-compose file
+	tlsCredentialDir = "docker/tls"
+
+	// the paths of the credentials in the containers
+	tlsContainerCAPath         = "/tls/" + CAFileName
+	tlsContainerClientCertPath = "/tls/" + ClientCertFileName
+	tlsContainerClientKeyPath  = "/tls/" + ClientKeyFileName
+
+	tlsCLIPDUri       = "https://upstream-pd:2379"
+	tlsKafkaAddr      = "kafka:29092"
+	tlsKeystoreImage  = "openjdk:8-jre-alpine"
+	tlsKeystoreScript = `set -e
+apk add --no-cache openssl > /dev/null
+cd /tls
+rm -f server.p12 kafka.server.keystore.jks kafka.server.truststore.jks
+openssl pkcs12 -export -in server.pem -inkey server-key.pem -name server -passout pass:ticdc-test -out server.p12
+keytool -importkeystore -noprompt -srckeystore server.p12 -srcstoretype PKCS12 -srcstorepass ticdc-test \
+	-destkeystore kafka.server.keystore.jks -deststorepass ticdc-test -destkeypass ticdc-test
+keytool -import -noprompt -trustcacerts -alias ca -file ca.pem -keystore kafka.server.truststore.jks -storepass ticdc-test
+echo ticdc-test > keystore_credentials
+chmod 644 *`
+)
+
+// tlsHosts are the host names of the services in the docker-compose file generated
+// from tlsComposeConfig, they are used if the docker-compose file is given by the user
+var tlsHosts = []string{
+	"localhost", "127.0.0.1",
+	"upstream-pd", "upstream-tikv0", "upstream-tikv1", "upstream-tikv2", "upstream-tidb",
+	"downstream-pd", "downstream-tikv0", "downstream-tikv1", "downstream-tikv2", "downstream-tidb",
+	"capturer0", "capturer1", "capturer2",
+	"kafka", "schema-registry", "kafka-connect-01",
+}
+
+// TLSDockerEnv represents the docker-compose service generated from tlsComposeConfig.
+// It's the same as AvroKafkaDockerEnv except that PD, TiKV, TiDB, TiCDC, Kafka and the
+// schema registry all use TLS, with the certificates generated when the environment is set up.
+// The tasks for AvroKafkaDockerEnv can run in it unchanged, their CDCProfiles are rewritten to use TLS.
+type TLSDockerEnv struct {
+	dockerComposeOperator
+}
+
+// NewTLSDockerEnv creates a new TLSDockerEnv
+func NewTLSDockerEnv(dockerComposeFile string) *TLSDockerEnv {
+	env := &TLSDockerEnv{newDockerComposeOperator(dockerComposeFile, tlsComposeConfig())}
+	env.controller = controllerContainerName
+	env.healthChecker = kafkaConnectHealthCheck
+	env.tls = true
+	return env
+}
+
+// Setup generates the credentials and brings up the docker-compose service. The
+// credentials are kept if the running services are reused, see keepEnvVar.
+func (e *TLSDockerEnv) Setup() {
+	if e.keepEnv && e.isUp() {
+		e.dockerComposeOperator.Setup()
+		return
+	}
+	dir := filepath.Join(filepath.Dir(e.fileName), tlsCredentialDir)
+	hosts := append([]string(nil), tlsHosts...)
+	if e.compose != nil {
+		hosts = append([]string{"localhost", "127.0.0.1"}, e.compose.Hosts()...)
+	}
+	for i := 0; i < maxScaledCaptures; i++ {
+		hosts = append(hosts, scaledCaptureName(i))
+	}
+	err := GenerateCertificates(dir, hosts)
+	if err != nil {
+		log.Fatal("Failed to generate certificates", zap.Error(err))
+	}
+	// Kafka and the schema registry need JKS key stores
+	runCmdHandleError(exec.Command("docker", "run", "--rm", "-v", dir+":/tls", tlsKeystoreImage, "sh", "-c", tlsKeystoreScript))
+	e.dockerComposeOperator.Setup()
+}
+
+// Reset implements Environment
+func (e *TLSDockerEnv) Reset() {
+	e.TearDown()
+	e.Setup()
+}
+
+// RunTest implements Environment
+func (e *TLSDockerEnv) RunTest(task Task) {
+	runTask(&e.dockerComposeOperator, e, wrapTLSTask(task), func() error {
+		return retry.Run(time.Second, 120, e.healthChecker)
+	})
+}
+
+// RunTests implements Environment
+func (e *TLSDockerEnv) RunTests(tasks []Task, parallelism int) []TaskResult {
+	wrapped := make([]Task, len(tasks))
+	for i, task := range tasks {
+		wrapped[i] = wrapTLSTask(task)
+	}
+	return runTasks(&e.dockerComposeOperator, e, wrapped, parallelism, func() error {
+		return retry.Run(time.Second, 120, e.healthChecker)
+	})
+}
+
+// SetListener implements Environment. Currently unfinished, will be used to monitor Kafka output
+func (e *TLSDockerEnv) SetListener(states interface{}, listener MqListener) {
+	// TODO
+}
+
+// wrapTLSTask wraps the task to rewrite its CDCProfile with tlsProfile
+func wrapTLSTask(task Task) Task {
+	return wrapProfileTask(task, tlsProfile)
+}
+
+// tlsProfile returns a copy of the profile which connects to PD, Kafka and the
+// schema registry with TLS.
+func tlsProfile(profile *CDCProfile) *CDCProfile {
+	ret := *profile
+	ret.PDUri = strings.Replace(ret.PDUri, "http://", "https://", 1)
+	ret.CAPath = tlsContainerCAPath
+	ret.CertPath = tlsContainerClientCertPath
+	ret.KeyPath = tlsContainerClientKeyPath
+
+	sinkURI, err := url.Parse(ret.SinkURI)
+	if err != nil {
+		log.Fatal("invalid sink URI", zap.String("sinkURI", ret.SinkURI), zap.Error(err))
+	}
+	if sinkURI.Scheme == "kafka" {
+		sinkURI.Scheme = "kafka+ssl"
+		sinkURI.Host = tlsKafkaAddr
+		query := sinkURI.Query()
+		query.Set("ca", tlsContainerCAPath)
+		query.Set("cert", tlsContainerClientCertPath)
+		query.Set("key", tlsContainerClientKeyPath)
+		sinkURI.RawQuery = query.Encode()
+		ret.SinkURI = sinkURI.String()
+	}
+
+	if len(ret.Opts) > 0 {
+		ret.Opts = make(map[string]string, len(profile.Opts))
+		for k, v := range profile.Opts {
+			if k == "registry" {
+				v = strings.Replace(v, "http://", "https://", 1)
+			}
+			ret.Opts[k] = v
+		}
+	}
+	return &ret
+}
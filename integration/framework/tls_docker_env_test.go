@@ -0,0 +1,36 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTLSProfile(t *testing.T) {
+	profile := (&AvroSingleTableTask{TableName: "test"}).GetCDCProfile()
+	tlsProfile := tlsProfile(profile)
+	require.Equal(t, "https://upstream-pd:2379", tlsProfile.PDUri)
+	require.Equal(t, "kafka+ssl://kafka:29092/testdb_test?"+
+		"ca=%2Ftls%2Fca.pem&cert=%2Ftls%2Fclient.pem&key=%2Ftls%2Fclient-key.pem&protocol=avro", tlsProfile.SinkURI)
+	require.Equal(t, map[string]string{"registry": "https://schema-registry:8081"}, tlsProfile.Opts)
+	require.Equal(t, "cli changefeed create --pd=https://upstream-pd:2379 --sink-uri='"+tlsProfile.SinkURI+"' "+
+		"--ca=/tls/ca.pem --cert=/tls/client.pem --key=/tls/client-key.pem --opts=\"registry=https://schema-registry:8081\" ",
+		tlsProfile.String())
+
+	// the original profile is not changed
+	require.Equal(t, "http://upstream-pd:2379", profile.PDUri)
+	require.Equal(t, map[string]string{"registry": "http://schema-registry:8081"}, profile.Opts)
+}
@@ -0,0 +1,178 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+
+	"github.com/pingcap/errors"
+)
+
+// the environment variables listing the versions of the components, separated by commas,
+// they override the lists in the matrix file, see VersionMatrix.OverrideFromEnv
+const (
+	tidbVersionsVar           = "TIDB_VERSIONS"
+	kafkaVersionsVar          = "KAFKA_VERSIONS"
+	schemaRegistryVersionsVar = "SCHEMA_REGISTRY_VERSIONS"
+	mysqlVersionsVar          = "MYSQL_VERSIONS"
+	pulsarVersionsVar         = "PULSAR_VERSIONS"
+)
+
+// VersionCombination is a combination of the versions of the components in an
+// environment, the versions which are empty are not changed.
+type VersionCombination struct {
+	TiDB           string
+	Kafka          string
+	SchemaRegistry string
+	MySQL          string
+	Pulsar         string
+}
+
+// Apply sets the versions of the combination in the config
+func (v VersionCombination) Apply(config *ComposeConfig) {
+	for _, field := range []struct {
+		version string
+		target  *string
+	}{
+		{v.TiDB, &config.TiDBVersion},
+		{v.Kafka, &config.KafkaVersion},
+		{v.SchemaRegistry, &config.SchemaRegistryVersion},
+		{v.MySQL, &config.MySQLVersion},
+		{v.Pulsar, &config.PulsarVersion},
+	} {
+		if field.version != "" {
+			*field.target = field.version
+		}
+	}
+}
+
+// IsEmpty returns whether the combination changes no version
+func (v VersionCombination) IsEmpty() bool {
+	return v == VersionCombination{}
+}
+
+// VersionMatrix lists the versions of every component to test, the environment
+// is tested with every combination of them. The defaults of the environment are
+// used for the components without versions.
+//
+// A matrix file is a JSON object like:
+//
+//	{"tidb": ["v4.0.9", "nightly"], "kafka": ["5.5.1", "7.0.1"]}
+type VersionMatrix struct {
+	TiDB           []string `json:"tidb"`
+	Kafka          []string `json:"kafka"`
+	SchemaRegistry []string `json:"schema-registry"`
+	MySQL          []string `json:"mysql"`
+	Pulsar         []string `json:"pulsar"`
+}
+
+// LoadVersionMatrix reads a VersionMatrix from the JSON file
+func LoadVersionMatrix(path string) (*VersionMatrix, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.AddStack(err)
+	}
+	defer f.Close()
+	matrix := new(VersionMatrix)
+	decoder := json.NewDecoder(f)
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(matrix)
+	if err != nil {
+		return nil, errors.Annotatef(err, "invalid version matrix file %s", path)
+	}
+	return matrix, nil
+}
+
+// OverrideFromEnv replaces the versions of the components with the versions
+// in the environment variables like TIDB_VERSIONS=v4.0.9,nightly
+func (m *VersionMatrix) OverrideFromEnv() {
+	for name, versions := range map[string]*[]string{
+		tidbVersionsVar:           &m.TiDB,
+		kafkaVersionsVar:          &m.Kafka,
+		schemaRegistryVersionsVar: &m.SchemaRegistry,
+		mysqlVersionsVar:          &m.MySQL,
+		pulsarVersionsVar:         &m.Pulsar,
+	} {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		*versions = nil
+		for _, version := range strings.Split(value, ",") {
+			if version = strings.TrimSpace(version); version != "" {
+				*versions = append(*versions, version)
+			}
+		}
+	}
+}
+
+// Combinations returns all the combinations of the versions, there is a single
+// empty combination if the matrix is empty.
+func (m *VersionMatrix) Combinations() []VersionCombination {
+	combinations := []VersionCombination{{}}
+	product := func(versions []string, set func(*VersionCombination, string)) {
+		if len(versions) == 0 {
+			return
+		}
+		next := make([]VersionCombination, 0, len(combinations)*len(versions))
+		for _, combination := range combinations {
+			for _, version := range versions {
+				c := combination
+				set(&c, version)
+				next = append(next, c)
+			}
+		}
+		combinations = next
+	}
+	product(m.TiDB, func(c *VersionCombination, v string) { c.TiDB = v })
+	product(m.Kafka, func(c *VersionCombination, v string) { c.Kafka = v })
+	product(m.SchemaRegistry, func(c *VersionCombination, v string) { c.SchemaRegistry = v })
+	product(m.MySQL, func(c *VersionCombination, v string) { c.MySQL = v })
+	product(m.Pulsar, func(c *VersionCombination, v string) { c.Pulsar = v })
+	return combinations
+}
+
+// VersionTag returns the versions of the components in the environment, like
+// "tidb=nightly,kafka=5.5.1", the results of the tasks are tagged with it.
+func (c *ComposeConfig) VersionTag() string {
+	tags := []string{"tidb=" + c.TiDBVersion}
+	if c.KafkaBrokers > 0 {
+		tags = append(tags, "kafka="+c.KafkaVersion)
+	}
+	if c.SchemaRegistry {
+		registryVersion := c.SchemaRegistryVersion
+		if registryVersion == "" {
+			registryVersion = c.KafkaVersion
+		}
+		tags = append(tags, "schema-registry="+registryVersion)
+	}
+	if c.Downstream == DownstreamMySQL {
+		tags = append(tags, "mysql="+c.MySQLVersion)
+	}
+	if c.Pulsar {
+		tags = append(tags, "pulsar="+c.PulsarVersion)
+	}
+	return strings.Join(tags, ",")
+}
+
+// versionTag returns the VersionTag of the environment, it's empty if the
+// docker-compose file is given by the user.
+func (d *dockerComposeOperator) versionTag() string {
+	if d.compose == nil {
+		return ""
+	}
+	return d.compose.VersionTag()
+}
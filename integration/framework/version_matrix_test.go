@@ -0,0 +1,98 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionMatrixCombinations(t *testing.T) {
+	require.Equal(t, []VersionCombination{{}}, new(VersionMatrix).Combinations())
+
+	matrix := &VersionMatrix{TiDB: []string{"v4.0.9", "nightly"}, Kafka: []string{"5.5.1", "7.0.1"}}
+	require.Equal(t, []VersionCombination{
+		{TiDB: "v4.0.9", Kafka: "5.5.1"},
+		{TiDB: "v4.0.9", Kafka: "7.0.1"},
+		{TiDB: "nightly", Kafka: "5.5.1"},
+		{TiDB: "nightly", Kafka: "7.0.1"},
+	}, matrix.Combinations())
+
+	config := avroComposeConfig()
+	matrix.Combinations()[1].Apply(config)
+	require.Equal(t, "tidb=v4.0.9,kafka=7.0.1,schema-registry=7.0.1", config.VersionTag())
+	data, err := config.Render()
+	require.NoError(t, err)
+	require.Contains(t, string(data), "image: confluentinc/cp-schema-registry:7.0.1")
+
+	VersionCombination{SchemaRegistry: "6.0.0", MySQL: "8.0"}.Apply(config)
+	require.Equal(t, "tidb=v4.0.9,kafka=7.0.1,schema-registry=6.0.0", config.VersionTag())
+	require.Equal(t, "tidb=nightly,mysql=5.7", mysqlComposeConfig().VersionTag())
+	require.Equal(t, "tidb=nightly,pulsar="+defaultPulsarVersion, pulsarComposeConfig().VersionTag())
+}
+
+func TestLoadVersionMatrix(t *testing.T) {
+	dir, err := ioutil.TempDir("", "version-matrix")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "matrix.json")
+	err = ioutil.WriteFile(path, []byte(`{"tidb": ["v4.0.9", "v5.0.0"], "kafka": ["5.5.1"]}`), 0644)
+	require.NoError(t, err)
+	matrix, err := LoadVersionMatrix(path)
+	require.NoError(t, err)
+	require.Equal(t, []string{"v4.0.9", "v5.0.0"}, matrix.TiDB)
+	require.Len(t, matrix.Combinations(), 2)
+
+	old, ok := os.LookupEnv(kafkaVersionsVar)
+	defer func() {
+		if ok {
+			os.Setenv(kafkaVersionsVar, old)
+		} else {
+			os.Unsetenv(kafkaVersionsVar)
+		}
+	}()
+	os.Setenv(kafkaVersionsVar, "5.5.1, 7.0.1,")
+	matrix.OverrideFromEnv()
+	require.Equal(t, []string{"5.5.1", "7.0.1"}, matrix.Kafka)
+	require.Equal(t, []string{"v4.0.9", "v5.0.0"}, matrix.TiDB)
+	require.Len(t, matrix.Combinations(), 4)
+
+	err = ioutil.WriteFile(path, []byte(`{"tikv": ["v4.0.9"]}`), 0644)
+	require.NoError(t, err)
+	_, err = LoadVersionMatrix(path)
+	require.Error(t, err)
+}
+
+func TestReportVersions(t *testing.T) {
+	report := newTestReport("docker-compose-avro", "")
+	report.versions = "tidb=v4.0.9,kafka=5.5.1"
+	report.record(TaskResult{Name: "Simple", Versions: report.versions})
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, report.writeJUnit(buf))
+	var suites junitTestSuites
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &suites))
+	require.Equal(t, []junitProperty{{Name: "versions", Value: "tidb=v4.0.9,kafka=5.5.1"}}, suites.Suites[0].Properties)
+
+	buf.Reset()
+	require.NoError(t, report.writeSummary(buf))
+	require.Contains(t, buf.String(), `"versions": "tidb=v4.0.9,kafka=5.5.1"`)
+}
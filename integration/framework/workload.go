@@ -0,0 +1,260 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/ticdc/pkg/quotes"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/time/rate"
+)
+
+// WorkloadConfig configures the DML workload run by RunWorkload
+type WorkloadConfig struct {
+	Schema string
+	// Table is created if it doesn't exist, with an integer primary key `id`,
+	// an integer column `value` and a varbinary column `payload`.
+	Table    string
+	Duration time.Duration
+	// TPS is the number of transactions per second of all workers, 0 means unlimited
+	TPS         int
+	Concurrency int
+	RowsPerTxn  int
+	// The weights of the kinds of DMLs
+	InsertWeight int
+	UpdateWeight int
+	DeleteWeight int
+	// RowSize is the size of the payload of every row in bytes
+	RowSize int
+	// KeySpace is the number of distinct primary keys
+	KeySpace int64
+	// HotKeys is the number of the hot keys, which are [0, HotKeys), and HotKeyRatio
+	// is the ratio of the DMLs on the hot keys.
+	HotKeys     int64
+	HotKeyRatio float64
+	Seed        int64
+}
+
+// DefaultWorkloadConfig returns a WorkloadConfig with a moderate insert-heavy workload
+func DefaultWorkloadConfig(schema string, table string) WorkloadConfig {
+	return WorkloadConfig{
+		Schema:       schema,
+		Table:        table,
+		Duration:     time.Minute,
+		TPS:          200,
+		Concurrency:  8,
+		RowsPerTxn:   4,
+		InsertWeight: 6,
+		UpdateWeight: 3,
+		DeleteWeight: 1,
+		RowSize:      128,
+		KeySpace:     100000,
+		HotKeys:      10,
+		HotKeyRatio:  0.1,
+		Seed:         time.Now().UnixNano(),
+	}
+}
+
+// WorkloadStats are the statistics of a finished workload
+type WorkloadStats struct {
+	Txns    int64
+	Inserts int64
+	Updates int64
+	Deletes int64
+	// Failed is the number of failed transactions, which are usually caused by conflicts
+	Failed int64
+}
+
+type dmlKind int
+
+const (
+	dmlInsert dmlKind = iota
+	dmlUpdate
+	dmlDelete
+)
+
+// RunWorkload runs the workload against db until the duration elapses or ctx is done
+func RunWorkload(ctx context.Context, db *sql.DB, cfg WorkloadConfig) (*WorkloadStats, error) {
+	if cfg.Concurrency <= 0 || cfg.RowsPerTxn <= 0 || cfg.KeySpace <= 0 ||
+		cfg.InsertWeight+cfg.UpdateWeight+cfg.DeleteWeight <= 0 {
+		return nil, errors.Errorf("invalid workload config %+v", cfg)
+	}
+	_, err := db.ExecContext(ctx, fmt.Sprintf(
+		"create table if not exists %s (id bigint primary key, value bigint, payload varbinary(%d))",
+		quotes.QuoteSchema(cfg.Schema, cfg.Table), cfg.RowSize+1))
+	if err != nil {
+		return nil, errors.AddStack(err)
+	}
+
+	limit := rate.Inf
+	if cfg.TPS > 0 {
+		limit = rate.Limit(cfg.TPS)
+	}
+	limiter := rate.NewLimiter(limit, cfg.Concurrency)
+	ctx, cancel := context.WithTimeout(ctx, cfg.Duration)
+	defer cancel()
+
+	log.Info("workload started", zap.Reflect("config", cfg))
+	stats := new(WorkloadStats)
+	errg, ctx := errgroup.WithContext(ctx)
+	for i := 0; i < cfg.Concurrency; i++ {
+		w := &workloadWorker{
+			db:    db,
+			cfg:   &cfg,
+			rand:  rand.New(rand.NewSource(cfg.Seed + int64(i))),
+			stats: stats,
+		}
+		errg.Go(func() error {
+			for {
+				if err := limiter.Wait(ctx); err != nil {
+					// the duration has elapsed
+					return nil
+				}
+				if err := w.runTxn(ctx); err != nil {
+					return err
+				}
+			}
+		})
+	}
+	err = errg.Wait()
+	log.Info("workload finished", zap.Reflect("stats", stats), zap.Error(err))
+	return stats, err
+}
+
+type workloadWorker struct {
+	db    *sql.DB
+	cfg   *WorkloadConfig
+	rand  *rand.Rand
+	stats *WorkloadStats
+}
+
+func (w *workloadWorker) nextKey() int64 {
+	if w.cfg.HotKeys > 0 && w.rand.Float64() < w.cfg.HotKeyRatio {
+		return w.rand.Int63n(w.cfg.HotKeys)
+	}
+	return w.rand.Int63n(w.cfg.KeySpace)
+}
+
+func (w *workloadWorker) nextKind() dmlKind {
+	n := w.rand.Intn(w.cfg.InsertWeight + w.cfg.UpdateWeight + w.cfg.DeleteWeight)
+	switch {
+	case n < w.cfg.InsertWeight:
+		return dmlInsert
+	case n < w.cfg.InsertWeight+w.cfg.UpdateWeight:
+		return dmlUpdate
+	default:
+		return dmlDelete
+	}
+}
+
+func (w *workloadWorker) runTxn(ctx context.Context) error {
+	table := quotes.QuoteSchema(w.cfg.Schema, w.cfg.Table)
+	tx, err := w.db.BeginTx(ctx, nil)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return errors.AddStack(err)
+	}
+	var inserts, updates, deletes int64
+	for i := 0; i < w.cfg.RowsPerTxn; i++ {
+		key := w.nextKey()
+		switch w.nextKind() {
+		case dmlInsert:
+			payload := make([]byte, w.cfg.RowSize)
+			w.rand.Read(payload)
+			_, err = tx.ExecContext(ctx, "replace into "+table+" (id, value, payload) values (?, ?, ?)",
+				key, w.rand.Int63(), payload)
+			inserts++
+		case dmlUpdate:
+			_, err = tx.ExecContext(ctx, "update "+table+" set value = value + 1 where id = ?", key)
+			updates++
+		case dmlDelete:
+			_, err = tx.ExecContext(ctx, "delete from "+table+" where id = ?", key)
+			deletes++
+		}
+		if err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = tx.Commit()
+	} else {
+		_ = tx.Rollback()
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		// conflicts are expected with hot keys, the transaction is simply dropped
+		log.Debug("workload transaction failed", zap.Error(err))
+		atomic.AddInt64(&w.stats.Failed, 1)
+		return nil
+	}
+	atomic.AddInt64(&w.stats.Txns, 1)
+	atomic.AddInt64(&w.stats.Inserts, inserts)
+	atomic.AddInt64(&w.stats.Updates, updates)
+	atomic.AddInt64(&w.stats.Deletes, deletes)
+	return nil
+}
+
+// WorkloadTask runs a workload in the environment prepared by the embedded Task, and
+// checks the table is replicated correctly after the workload finishes.
+type WorkloadTask struct {
+	Task
+	Config WorkloadConfig
+	// Stats are the statistics of the workload after the task has run
+	Stats *WorkloadStats
+}
+
+// NewWorkloadTask creates a WorkloadTask, base provides the CDCProfile and prepares the databases
+func NewWorkloadTask(base Task, config WorkloadConfig) *WorkloadTask {
+	return &WorkloadTask{Task: base, Config: config}
+}
+
+// Name implements Task
+func (w *WorkloadTask) Name() string {
+	return "Workload-" + w.Config.Table
+}
+
+// SetDatabase implements IsolatedTask, the workload runs in the database of base
+// if base is an IsolatedTask.
+func (w *WorkloadTask) SetDatabase(name string) {
+	if isolated, ok := w.Task.(IsolatedTask); ok {
+		isolated.SetDatabase(name)
+		w.Config.Schema = name
+	}
+}
+
+// Run implements Task
+func (w *WorkloadTask) Run(taskContext *TaskContext) error {
+	stats, err := RunWorkload(taskContext.Ctx, taskContext.Upstream, w.Config)
+	if err != nil {
+		return err
+	}
+	w.Stats = stats
+	if stats.Txns == 0 {
+		return errors.New("no transaction of the workload succeeded")
+	}
+	return taskContext.TableConsistent(w.Config.Schema, w.Config.Table).Wait().Check()
+}
@@ -0,0 +1,53 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkloadWorkerDistribution(t *testing.T) {
+	cfg := DefaultWorkloadConfig("testdb", "workload")
+	cfg.KeySpace = 1000
+	cfg.HotKeys = 10
+	cfg.HotKeyRatio = 0.5
+	w := &workloadWorker{cfg: &cfg, rand: rand.New(rand.NewSource(1))}
+
+	const n = 10000
+	var hot int
+	kinds := make(map[dmlKind]int)
+	for i := 0; i < n; i++ {
+		key := w.nextKey()
+		require.True(t, key >= 0 && key < cfg.KeySpace)
+		if key < cfg.HotKeys {
+			hot++
+		}
+		kinds[w.nextKind()]++
+	}
+	require.InDelta(t, 0.5, float64(hot)/n, 0.05)
+	require.InDelta(t, 0.6, float64(kinds[dmlInsert])/n, 0.05)
+	require.InDelta(t, 0.3, float64(kinds[dmlUpdate])/n, 0.05)
+	require.InDelta(t, 0.1, float64(kinds[dmlDelete])/n, 0.05)
+}
+
+func TestRunWorkloadInvalidConfig(t *testing.T) {
+	cfg := DefaultWorkloadConfig("testdb", "workload")
+	cfg.InsertWeight, cfg.UpdateWeight, cfg.DeleteWeight = 0, 0, 0
+	_, err := RunWorkload(context.Background(), nil, cfg)
+	require.Error(t, err)
+}
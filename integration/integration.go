@@ -15,33 +15,163 @@ package main
 
 import (
 	"flag"
+	"path/filepath"
+	"strings"
+
 	"github.com/pingcap/log"
 	"github.com/pingcap/ticdc/integration/framework"
+	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
 func main() {
 	dockerComposeFile := flag.String("docker-compose-file", "", "the path of the Docker-compose yml file")
+	envName := flag.String("env", "avro", "the environment to run the tests in, avro, tls, mysql, exotic, sorter, canal-json, kerberos, pulsar or multi-capture")
+	parallelism := flag.Int("parallelism", 1, "the number of tasks to run in parallel")
+	reportDir := flag.String("report-dir", "", "the directory to write the JUnit XML report and the JSON summary to")
+	artifactsDir := flag.String("artifacts-dir", "", "the directory to collect the logs and the state of the cluster in when a test case fails")
+	tidbVersion := flag.String("tidb-version", "", "the tag of the PD, TiKV and TiDB images, nightly by default")
+	kafkaVersion := flag.String("kafka-version", "", "the tag of the Confluent Platform images")
+	kafkaBrokers := flag.Int("kafka-brokers", 0, "the number of Kafka brokers in the environments with Kafka")
+	statsInterval := flag.Duration("stats-interval", 0, "the interval to sample the CPU and memory usage of the containers while a test case is running, 0 disables it")
+	versionMatrix := flag.String("version-matrix", "", "the JSON file listing the versions of the components to test every combination of")
+	generateCompose := flag.Bool("generate-compose", false, "write the docker-compose file of the environment and exit")
+	flag.Parse()
 
-	testCases := []framework.Task{
-		newSimpleCase(),
-		newDeleteCase(),
-		newManyTypesCase(),
-		newUnsignedCase(),
-		newCompositePKeyCase(),
-		newAlterCase(), // this case is slow, so put it last
+	log.SetLevel(zapcore.DebugLevel)
+	var (
+		newEnv    func() framework.Environment
+		testCases []framework.Task
+	)
+	switch *envName {
+	case "avro", "tls":
+		if *envName == "avro" {
+			newEnv = func() framework.Environment { return framework.NewAvroKafkaDockerEnv(*dockerComposeFile) }
+		} else {
+			newEnv = func() framework.Environment { return framework.NewTLSDockerEnv(*dockerComposeFile) }
+		}
+		testCases = []framework.Task{
+			newSimpleCase(),
+			newDeleteCase(),
+			newManyTypesCase(),
+			newUnsignedCase(),
+			newCompositePKeyCase(),
+			newAlterCase(), // this case is slow, so put it last
+		}
+	case "mysql":
+		newEnv = func() framework.Environment { return framework.NewMySQLDockerEnv(*dockerComposeFile) }
+		testCases = []framework.Task{
+			newMySQLSimpleCase(),
+			newMySQLWorkloadCase(),
+			newMySQLLatencyCase(),
+			newChangefeedLifecycleCase(),
+		}
+	case "sorter":
+		newEnv = func() framework.Environment { return framework.NewSorterDiskFullDockerEnv(*dockerComposeFile) }
+		testCases = []framework.Task{
+			newSorterDiskFullCase(),
+		}
+	case "exotic":
+		newEnv = func() framework.Environment { return framework.NewExoticSchemaDockerEnv(*dockerComposeFile) }
+		testCases = []framework.Task{
+			newExoticSchemaCase(),
+			newMySQLSimpleCase(),
+		}
+	case "canal-json", "kerberos":
+		if *envName == "canal-json" {
+			newEnv = func() framework.Environment { return framework.NewCanalJSONKafkaDockerEnv(*dockerComposeFile) }
+		} else {
+			newEnv = func() framework.Environment { return framework.NewKerberosDockerEnv(*dockerComposeFile) }
+		}
+		testCases = []framework.Task{
+			newCanalJSONSimpleCase(),
+			newCanalJSONLatencyCase(),
+		}
+	case "pulsar":
+		newEnv = func() framework.Environment { return framework.NewPulsarDockerEnv(*dockerComposeFile) }
+		testCases = []framework.Task{
+			newPulsarSimpleCase(),
+		}
+	case "multi-capture":
+		newEnv = func() framework.Environment { return framework.NewMultiCaptureDockerEnv(*dockerComposeFile) }
+		testCases = []framework.Task{
+			newMultiCaptureCase(),
+			newCaptureKillCase(),
+			newCaptureScaleCase(),
+			newDDLStormCase(),
+		}
+	default:
+		log.Fatal("unknown environment", zap.String("env", *envName))
+	}
+	matrix := new(framework.VersionMatrix)
+	if *versionMatrix != "" {
+		var err error
+		matrix, err = framework.LoadVersionMatrix(*versionMatrix)
+		if err != nil {
+			log.Fatal("failed to load the version matrix", zap.Error(err))
+		}
 	}
+	matrix.OverrideFromEnv()
+	if *tidbVersion != "" {
+		matrix.TiDB = []string{*tidbVersion}
+	}
+	if *kafkaVersion != "" {
+		matrix.Kafka = []string{*kafkaVersion}
+	}
+	combinations := matrix.Combinations()
 
-	log.SetLevel(zapcore.DebugLevel)
-	env := framework.NewAvroKafkaDockerEnv(*dockerComposeFile)
-	env.Setup()
+	failed := false
+	tested := make(map[string]bool)
+	for _, combination := range combinations {
+		env := newEnv()
+		var versions string
+		if !combination.IsEmpty() || *kafkaBrokers > 0 {
+			combination := combination
+			env.OverrideCompose(func(config *framework.ComposeConfig) {
+				combination.Apply(config)
+				if *kafkaBrokers > 0 && config.KafkaBrokers > 0 {
+					config.KafkaBrokers = *kafkaBrokers
+				}
+				versions = config.VersionTag()
+			})
+		}
+		// the combinations only differing in the components not in the environment are the same
+		if tested[versions] {
+			continue
+		}
+		tested[versions] = true
+		if *generateCompose {
+			env.GenerateComposeFile()
+			return
+		}
+		if *reportDir != "" {
+			dir := *reportDir
+			if len(combinations) > 1 {
+				dir = filepath.Join(dir, strings.NewReplacer(",", "_", "=", "-").Replace(versions))
+			}
+			env.SetReportDir(dir)
+		}
+		if *artifactsDir != "" {
+			env.SetArtifactsDir(*artifactsDir)
+		}
+		if *statsInterval > 0 {
+			env.SetStatsInterval(*statsInterval)
+		}
+		env.Setup()
+
+		results := env.RunTests(testCases, *parallelism)
 
-	for i := range testCases {
-		env.RunTest(testCases[i])
-		if i < len(testCases)-1 {
-			env.Reset()
+		env.TearDown()
+
+		for _, result := range results {
+			if result.Err != nil {
+				failed = true
+				log.Warn("Test case failed", zap.String("name", result.Name),
+					zap.String("versions", result.Versions), zap.Error(result.Err))
+			}
 		}
 	}
-
-	env.TearDown()
+	if failed {
+		log.Fatal("Some test cases failed")
+	}
 }
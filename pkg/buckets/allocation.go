@@ -0,0 +1,167 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buckets
+
+import (
+	"github.com/google/btree"
+)
+
+// AllocationPolicy decides how the total quota of a group is allocated to
+// its buckets.
+type AllocationPolicy int
+
+const (
+	// AllocationStatic gives every bucket the quota it asks for when created,
+	// and creating a bucket fails if the group doesn't have enough free quota.
+	AllocationStatic AllocationPolicy = iota
+	// AllocationFair distributes the total quota across the buckets with
+	// weighted max-min fairness, the quota a bucket asks for when created is
+	// its demand and its priority is its weight. Buckets with priority less
+	// than 1 have weight 1. The quotas are recomputed whenever a bucket joins
+	// or leaves the group or the priority of a bucket changes.
+	// A bucket may get no quota if there are too many buckets.
+	AllocationFair
+)
+
+func (p AllocationPolicy) String() string {
+	switch p {
+	case AllocationStatic:
+		return "static"
+	case AllocationFair:
+		return "fair"
+	}
+	return "unknown"
+}
+
+// groupResize is a pending change of the total quota of a nested group.
+type groupResize struct {
+	group *BucketGroup
+	quota int64
+}
+
+// SetAllocationPolicy sets the allocation policy of the group and reallocates
+// the quotas of the existing buckets.
+func (g *BucketGroup) SetAllocationPolicy(policy AllocationPolicy) {
+	g.mu.Lock()
+	g.allocationPolicy = policy
+	resizes := g.reallocateLocked()
+	g.mu.Unlock()
+	applyResizes(resizes)
+}
+
+// setTotalQuota changes the total quota of a nested group when the quota of
+// its backing bucket changes.
+func (g *BucketGroup) setTotalQuota(quota int64) {
+	g.mu.Lock()
+	g.totalQuota = quota
+	resizes := g.reallocateLocked()
+	g.updateMetricsLocked()
+	g.mu.Unlock()
+	applyResizes(resizes)
+	g.serveWaiters()
+}
+
+// applyResizes applies the changes of total quota to nested groups, it must
+// be called without holding the lock of the parent group.
+func applyResizes(resizes []groupResize) {
+	for _, r := range resizes {
+		r.group.setTotalQuota(r.quota)
+	}
+}
+
+func bucketWeight(b *Bucket) int64 {
+	if b.priority < 1 {
+		return 1
+	}
+	return b.priority
+}
+
+// weightedShare returns total*weight/totalWeight without overflow.
+func weightedShare(total, weight, totalWeight int64) int64 {
+	return total/totalWeight*weight + total%totalWeight*weight/totalWeight
+}
+
+// reallocateLocked recomputes the quotas of the buckets with weighted max-min
+// fairness if the policy is AllocationFair: in every round, the remaining
+// quota is divided among the unsatisfied buckets by weight, and the buckets
+// whose demand is within their share get their demand. Once no bucket can be
+// satisfied, the remaining quota is divided among the rest by weight.
+// It returns the nested groups whose total quota should change.
+// The caller must hold g.mu.
+func (g *BucketGroup) reallocateLocked() []groupResize {
+	if g.allocationPolicy != AllocationFair {
+		return nil
+	}
+	active := make([]*Bucket, 0, g.buckets.Len())
+	g.buckets.Ascend(func(i btree.Item) bool {
+		active = append(active, i.(*Bucket))
+		return true
+	})
+	quotas := make(map[*Bucket]int64, len(active))
+	remaining := g.totalQuota
+	for len(active) > 0 {
+		var totalWeight int64
+		for _, b := range active {
+			totalWeight += bucketWeight(b)
+		}
+		unsatisfied := active[:0:0]
+		var satisfied int64
+		for _, b := range active {
+			if b.demand <= weightedShare(remaining, bucketWeight(b), totalWeight) {
+				quotas[b] = b.demand
+				satisfied += b.demand
+			} else {
+				unsatisfied = append(unsatisfied, b)
+			}
+		}
+		if len(unsatisfied) == len(active) {
+			for _, b := range active {
+				quotas[b] = weightedShare(remaining, bucketWeight(b), totalWeight)
+			}
+			break
+		}
+		remaining -= satisfied
+		active = unsatisfied
+	}
+
+	var resizes []groupResize
+	for b, quota := range quotas {
+		if quota == b.quota {
+			continue
+		}
+		g.setQuotaLocked(b, quota)
+		for _, child := range g.children {
+			if child.parent == b {
+				resizes = append(resizes, groupResize{group: child, quota: quota})
+			}
+		}
+	}
+	g.updateMetricsLocked()
+	return resizes
+}
+
+// setQuotaLocked changes the quota of the bucket. A residency bucket keeps the
+// tokens in use, and a throughput bucket drops the tokens beyond its capacity.
+// The caller must hold g.mu.
+func (g *BucketGroup) setQuotaLocked(b *Bucket, quota int64) {
+	delta := quota - b.quota
+	g.allocatedQuota += delta
+	b.quota = quota
+	if b.mode == BucketModeResidency {
+		b.tokens += delta
+	} else if capacity := b.capacity(); b.tokens > capacity {
+		b.tokens = capacity
+	}
+	b.updateMetricsLocked()
+}
@@ -0,0 +1,392 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buckets
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/btree"
+	"github.com/pingcap/errors"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+)
+
+// BucketMode decides how the tokens of a bucket are replenished.
+type BucketMode int
+
+const (
+	// BucketModeThroughput buckets are refilled with `quota` tokens on every
+	// refill round of the group, which limits the rate of acquisitions,
+	// e.g. rows or bytes per second.
+	BucketModeThroughput BucketMode = iota
+	// BucketModeResidency buckets are never refilled, the tokens are only
+	// given back by Release when the acquired resource is freed, which limits
+	// the amount of resource held at the same time, e.g. memory.
+	BucketModeResidency
+)
+
+func (m BucketMode) String() string {
+	switch m {
+	case BucketModeThroughput:
+		return "throughput"
+	case BucketModeResidency:
+		return "residency"
+	}
+	return "unknown"
+}
+
+// Bucket is a token bucket belonging to a BucketGroup.
+// A throughput bucket is refilled with `quota` tokens on every refill round of
+// its group, and can accumulate at most `quota + burst` tokens. A residency
+// bucket holds at most `quota` tokens.
+// All the fields are protected by the mutex of the group.
+type Bucket struct {
+	group *BucketGroup
+	id    uint64
+	mode  BucketMode
+
+	priority int64
+	// boost is the temporary priority boost gained by aging, see SetAging
+	boost  int64
+	quota  int64
+	burst  int64
+	tokens int64
+	closed bool
+	// demand is the quota the bucket asks for, which equals to quota unless
+	// the group allocates quotas fairly, see AllocationFair
+	demand int64
+
+	// borrowed is the total number of tokens borrowed from sibling buckets
+	// and not repaid yet, debts records how many of them come from each lender.
+	borrowed int64
+	debts    map[*Bucket]int64
+
+	// metrics is nil unless the bucket is labeled by SetMetricLabels
+	metrics *bucketMetrics
+}
+
+// Less implements btree.Item. Buckets are ordered by effective priority from
+// high to low, and then by creation order.
+func (b *Bucket) Less(than btree.Item) bool {
+	other := than.(*Bucket)
+	if p1, p2 := b.effectivePriority(), other.effectivePriority(); p1 != p2 {
+		return p1 > p2
+	}
+	return b.id < other.id
+}
+
+func (b *Bucket) effectivePriority() int64 {
+	return b.priority + b.boost
+}
+
+// TryAcquire takes n tokens from the bucket without blocking.
+// If the bucket doesn't have enough tokens, it tries to borrow the shortfall
+// from its siblings, see BucketGroup.SetBorrowLimit.
+// If the group of the bucket is nested in another group, the tokens must be
+// acquired at every level of the hierarchy.
+// It returns false if there are not enough tokens, in which case nothing is taken.
+func (b *Bucket) TryAcquire(n int64) bool {
+	lent, ok := b.acquireLocal(n)
+	if !ok {
+		return false
+	}
+	if parent := b.group.parent; parent != nil && !parent.TryAcquire(n) {
+		b.rollbackLocal(n, lent)
+		return false
+	}
+	b.group.mu.Lock()
+	if b.metrics != nil {
+		b.metrics.acquire.Inc()
+	}
+	b.group.mu.Unlock()
+	return true
+}
+
+// ForceAcquire takes n tokens from the bucket at every level of the hierarchy
+// even if there are not enough tokens, leaving the bucket in debt until enough
+// tokens are released. It is used when blocking the caller may cause a deadlock.
+// The parent groups are charged even if the bucket is closed, since Release
+// always gives the tokens back to them.
+func (b *Bucket) ForceAcquire(n int64) {
+	b.group.mu.Lock()
+	if !b.closed {
+		b.tokens -= n
+		if b.metrics != nil {
+			b.metrics.acquire.Inc()
+			b.updateMetricsLocked()
+		}
+	}
+	b.group.mu.Unlock()
+	if parent := b.group.parent; parent != nil {
+		parent.ForceAcquire(n)
+	}
+}
+
+// refund gives back the tokens just taken by TryAcquire at every level,
+// without waking up any waiter.
+func (b *Bucket) refund(n int64) {
+	b.group.mu.Lock()
+	b.tokens += n
+	b.group.mu.Unlock()
+	if parent := b.group.parent; parent != nil {
+		parent.refund(n)
+	}
+}
+
+// Acquire takes n tokens from the bucket, blocking until the tokens are
+// available or ctx is done.
+// Blocked acquisitions in a group are served in the order configured by
+// BucketGroup.SetWaiterOrder, and a new acquisition never jumps ahead of the
+// blocked ones.
+func (b *Bucket) Acquire(ctx context.Context, n int64) error {
+	g := b.group
+	g.mu.Lock()
+	if b.closed {
+		g.mu.Unlock()
+		return cerror.ErrBucketClosed.GenWithStackByArgs()
+	}
+	if limit := b.capacity() + g.borrowLimit; n > limit {
+		g.mu.Unlock()
+		return cerror.ErrBucketAcquireTooLarge.GenWithStackByArgs(n, limit)
+	}
+	hasWaiters := g.waiters.Len() > 0
+	g.mu.Unlock()
+
+	if !hasWaiters && b.TryAcquire(n) {
+		return nil
+	}
+
+	w := g.enqueueWaiter(b, n)
+	startTime := time.Now()
+	defer func() {
+		g.mu.Lock()
+		if b.metrics != nil {
+			b.metrics.wait.Observe(time.Since(startTime).Seconds())
+		}
+		g.mu.Unlock()
+	}()
+	// tokens may have been added before the waiter is enqueued
+	g.serveWaiters()
+	select {
+	case <-w.done:
+		return errors.Trace(w.err)
+	case <-ctx.Done():
+	}
+
+	g.mu.Lock()
+	removed := g.waiters.Delete(w) != nil
+	g.setBoostLocked(b, 0)
+	g.updateMetricsLocked()
+	g.mu.Unlock()
+	if !removed {
+		// the waiter has been served concurrently
+		<-w.done
+		if w.err == nil {
+			b.Release(n)
+		}
+	}
+	return errors.Trace(ctx.Err())
+}
+
+// acquireLocal takes n tokens from the bucket without the parent groups, and
+// returns the tokens lent by the siblings for it, see rollbackLocal. The
+// acquisition is counted by the caller once it succeeds at every level.
+func (b *Bucket) acquireLocal(n int64) (map[*Bucket]int64, bool) {
+	b.group.mu.Lock()
+	defer b.group.mu.Unlock()
+	if b.closed {
+		return nil, false
+	}
+	var lent map[*Bucket]int64
+	if n > b.tokens {
+		var ok bool
+		if lent, ok = b.group.borrowLocked(b, n-b.tokens); !ok {
+			if b.metrics != nil {
+				b.metrics.throttle.Inc()
+			}
+			return nil, false
+		}
+	}
+	b.tokens -= n
+	b.updateMetricsLocked()
+	return lent, true
+}
+
+// rollbackLocal undoes acquireLocal when the tokens can't be acquired from the
+// parent groups, the tokens lent are given back to the siblings, so that
+// neither the lenders are drained nor the debts are left.
+func (b *Bucket) rollbackLocal(n int64, lent map[*Bucket]int64) {
+	b.group.mu.Lock()
+	defer b.group.mu.Unlock()
+	for lender, amount := range lent {
+		if !lender.closed {
+			lender.tokens += amount
+			lender.updateMetricsLocked()
+		}
+		if b.closed {
+			continue
+		}
+		b.borrowed -= amount
+		if b.debts[lender] -= amount; b.debts[lender] <= 0 {
+			delete(b.debts, lender)
+		}
+		n -= amount
+	}
+	if b.closed {
+		return
+	}
+	b.tokens += n
+	if b.metrics != nil {
+		b.metrics.throttle.Inc()
+		b.updateMetricsLocked()
+	}
+}
+
+// SetMetricLabels enables the metrics of the bucket with the given labels.
+func (b *Bucket) SetMetricLabels(changefeed, table string) {
+	b.group.mu.Lock()
+	defer b.group.mu.Unlock()
+	if b.closed {
+		return
+	}
+	if b.metrics != nil {
+		b.metrics.unregister()
+	}
+	b.metrics = newBucketMetrics(changefeed, table)
+	b.updateMetricsLocked()
+}
+
+// Borrowed returns the number of tokens borrowed from sibling buckets
+// which are not repaid yet.
+func (b *Bucket) Borrowed() int64 {
+	b.group.mu.Lock()
+	defer b.group.mu.Unlock()
+	return b.borrowed
+}
+
+// Release gives n tokens back to the bucket, e.g. when the acquired resource
+// is freed. The bucket never holds more than `quota + burst` tokens.
+// The tokens are also given back to the parent groups.
+func (b *Bucket) Release(n int64) {
+	b.group.mu.Lock()
+	if !b.closed {
+		if b.mode == BucketModeResidency && b.borrowed > 0 {
+			// a residency bucket is never refilled, so it repays its debts
+			// as soon as the tokens are given back
+			b.tokens += n
+			b.repayLocked()
+			b.addTokensLocked(0)
+		} else {
+			b.addTokensLocked(n)
+		}
+		b.updateMetricsLocked()
+	}
+	b.group.mu.Unlock()
+	b.group.serveWaiters()
+	if parent := b.group.parent; parent != nil {
+		parent.Release(n)
+	}
+}
+
+// Available returns the number of tokens which can be acquired right now.
+func (b *Bucket) Available() int64 {
+	b.group.mu.Lock()
+	defer b.group.mu.Unlock()
+	return b.tokens
+}
+
+// Mode returns the mode of the bucket.
+func (b *Bucket) Mode() BucketMode {
+	return b.mode
+}
+
+// Priority returns the priority of the bucket.
+func (b *Bucket) Priority() int64 {
+	b.group.mu.Lock()
+	defer b.group.mu.Unlock()
+	return b.priority
+}
+
+// SetPriority raises the priority of the bucket.
+func (b *Bucket) SetPriority(priority int64) error {
+	b.group.mu.Lock()
+	if b.closed {
+		b.group.mu.Unlock()
+		return cerror.ErrBucketClosed.GenWithStackByArgs()
+	}
+	resizes, err := b.group.adjustPriority(b, priority)
+	b.group.mu.Unlock()
+	applyResizes(resizes)
+	return err
+}
+
+// Quota returns the quota of the bucket.
+func (b *Bucket) Quota() int64 {
+	b.group.mu.Lock()
+	defer b.group.mu.Unlock()
+	return b.quota
+}
+
+// Close removes the bucket from its group and returns its quota to the group.
+func (b *Bucket) Close() {
+	b.group.mu.Lock()
+	if b.closed {
+		b.group.mu.Unlock()
+		return
+	}
+	resizes := b.group.returnQuota(b)
+	b.group.failWaitersLocked(b)
+	b.group.mu.Unlock()
+	applyResizes(resizes)
+	// the other buckets may have got more quota
+	b.group.serveWaiters()
+}
+
+func (b *Bucket) capacity() int64 {
+	return b.quota + b.burst
+}
+
+// repayLocked gives back the borrowed tokens to the lenders as far as
+// the bucket has tokens.
+func (b *Bucket) repayLocked() {
+	for lender, debt := range b.debts {
+		if lender.closed {
+			b.borrowed -= debt
+			delete(b.debts, lender)
+			continue
+		}
+		repay := debt
+		if repay > b.tokens {
+			repay = b.tokens
+		}
+		if repay <= 0 {
+			return
+		}
+		b.tokens -= repay
+		lender.addTokensLocked(repay)
+		b.borrowed -= repay
+		if repay == debt {
+			delete(b.debts, lender)
+		} else {
+			b.debts[lender] = debt - repay
+		}
+	}
+}
+
+func (b *Bucket) addTokensLocked(n int64) {
+	b.tokens += n
+	if capacity := b.capacity(); b.tokens > capacity {
+		b.tokens = capacity
+	}
+}
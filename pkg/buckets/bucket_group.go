@@ -0,0 +1,350 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buckets
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/btree"
+	"github.com/pingcap/errors"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+)
+
+// BucketGroup shares a total quota among a set of buckets.
+// The sum of the quotas of all buckets in a group never exceeds the total quota.
+//
+// Groups can be nested, e.g. a capture-level group contains a group per
+// changefeed which in turn contains a bucket per table. A nested group is
+// backed by a bucket in its parent group, and tokens acquired from a bucket
+// are acquired from all its ancestors as well.
+// Lock order: a child group is always locked before its parent, and never
+// while holding the lock of its parent.
+type BucketGroup struct {
+	mu             sync.Mutex
+	name           string
+	totalQuota     int64
+	allocatedQuota int64
+	refillInterval time.Duration
+	borrowLimit    int64
+	// allocationPolicy decides the quotas of buckets, see AllocationPolicy
+	allocationPolicy AllocationPolicy
+	nextID           uint64
+	// buckets is ordered by priority, see Bucket.Less
+	buckets *btree.BTree
+
+	// waiters holds the blocked acquisitions, see waiter.Less
+	waiters     *btree.BTree
+	waiterOrder WaiterOrder
+	waiterSeq   uint64
+	// a waiter gains agingStep priority every agingInterval it waits,
+	// aging is disabled if agingStep is 0.
+	agingStep     int64
+	agingInterval time.Duration
+	// serveMu serializes serveWaiters
+	serveMu sync.Mutex
+
+	// parent is the bucket backing this group in the parent group, it is nil
+	// for a top level group. parent never changes after the group is created.
+	parent   *Bucket
+	children []*BucketGroup
+}
+
+// NewBucketGroup creates a BucketGroup which refills its buckets every refillInterval.
+func NewBucketGroup(totalQuota int64, refillInterval time.Duration) *BucketGroup {
+	return &BucketGroup{
+		totalQuota:     totalQuota,
+		refillInterval: refillInterval,
+		buckets:        btree.New(16),
+		waiters:        btree.New(16),
+		waiterOrder:    WaiterOrderPriority,
+	}
+}
+
+// CreateBucket creates a throughput bucket in the group. The bucket is full
+// when created.
+func (g *BucketGroup) CreateBucket(priority, quota, burst int64) (*Bucket, error) {
+	return g.CreateBucketWithMode(BucketModeThroughput, priority, quota, burst)
+}
+
+// CreateBucketWithMode creates a bucket of the mode in the group. The bucket is
+// full when created. A residency bucket can't have burst.
+// If the group allocates quotas fairly, quota is the demand of the bucket, see
+// AllocationFair.
+func (g *BucketGroup) CreateBucketWithMode(mode BucketMode, priority, quota, burst int64) (*Bucket, error) {
+	if quota <= 0 || burst < 0 || (mode == BucketModeResidency && burst != 0) {
+		return nil, cerror.ErrBucketInvalidQuota.GenWithStackByArgs(quota, burst)
+	}
+	g.mu.Lock()
+	b := &Bucket{
+		group:    g,
+		id:       g.nextID,
+		mode:     mode,
+		priority: priority,
+		demand:   quota,
+		burst:    burst,
+	}
+	var resizes []groupResize
+	if g.allocationPolicy == AllocationFair {
+		g.buckets.ReplaceOrInsert(b)
+		resizes = g.reallocateLocked()
+	} else {
+		if g.allocatedQuota+quota > g.totalQuota {
+			g.mu.Unlock()
+			return nil, cerror.ErrBucketQuotaExceeded.GenWithStackByArgs(quota, g.totalQuota-g.allocatedQuota)
+		}
+		b.quota = quota
+		g.allocatedQuota += quota
+		g.buckets.ReplaceOrInsert(b)
+	}
+	b.tokens = b.quota
+	g.nextID++
+	g.updateMetricsLocked()
+	g.mu.Unlock()
+	applyResizes(resizes)
+	return b, nil
+}
+
+// CreateSubGroup creates a nested group backed by a new throughput bucket in g.
+// The total quota of the nested group is the quota of the backing bucket.
+// Refilling g refills the nested group as well.
+func (g *BucketGroup) CreateSubGroup(priority, quota, burst int64) (*BucketGroup, error) {
+	return g.CreateSubGroupWithMode(BucketModeThroughput, priority, quota, burst)
+}
+
+// CreateSubGroupWithMode creates a nested group backed by a new bucket of the
+// mode in g.
+func (g *BucketGroup) CreateSubGroupWithMode(mode BucketMode, priority, quota, burst int64) (*BucketGroup, error) {
+	b, err := g.CreateBucketWithMode(mode, priority, quota, burst)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	child := NewBucketGroup(quota, g.refillInterval)
+	child.parent = b
+	g.mu.Lock()
+	// the quota of b may have been reallocated, and the child is not visible
+	// to anyone else until it is appended
+	child.totalQuota = b.quota
+	g.children = append(g.children, child)
+	g.mu.Unlock()
+	return child, nil
+}
+
+// Close detaches a nested group from its parent and returns its quota to the
+// parent group. It is a no-op for a top level group.
+func (g *BucketGroup) Close() {
+	if g.parent == nil {
+		return
+	}
+	parent := g.parent.group
+	g.parent.Close()
+	g.mu.Lock()
+	if g.name != "" {
+		groupQuotaGauge.DeleteLabelValues(g.name)
+		groupAllocatedGauge.DeleteLabelValues(g.name)
+		groupWaitersGauge.DeleteLabelValues(g.name)
+	}
+	g.mu.Unlock()
+	parent.mu.Lock()
+	defer parent.mu.Unlock()
+	for i, child := range parent.children {
+		if child == g {
+			parent.children = append(parent.children[:i], parent.children[i+1:]...)
+			break
+		}
+	}
+}
+
+// SetName sets the name of the group, a named group exports its metrics
+// labeled by the name.
+func (g *BucketGroup) SetName(name string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.name = name
+	g.updateMetricsLocked()
+}
+
+// SetBorrowLimit sets the maximum number of tokens a bucket can borrow from
+// its siblings. Borrowing is disabled when the limit is 0, which is the default.
+func (g *BucketGroup) SetBorrowLimit(limit int64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.borrowLimit = limit
+}
+
+// Refill runs one refill round, throughput buckets with higher priority are
+// refilled first. A bucket repays its debts with the refilled tokens before
+// using them. Residency buckets are skipped.
+// Nested groups are refilled after g.
+func (g *BucketGroup) Refill() {
+	g.mu.Lock()
+	g.buckets.Ascend(func(i btree.Item) bool {
+		b := i.(*Bucket)
+		if b.mode == BucketModeResidency {
+			return true
+		}
+		b.addTokensLocked(b.quota)
+		if b.borrowed > 0 {
+			b.repayLocked()
+		}
+		b.updateMetricsLocked()
+		return true
+	})
+	g.ageWaitersLocked(time.Now())
+	children := make([]*BucketGroup, len(g.children))
+	copy(children, g.children)
+	g.mu.Unlock()
+
+	g.serveWaiters()
+	for _, child := range children {
+		child.Refill()
+	}
+}
+
+// Run refills the buckets periodically until ctx is done.
+func (g *BucketGroup) Run(ctx context.Context) error {
+	ticker := time.NewTicker(g.refillInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return errors.Trace(ctx.Err())
+		case <-ticker.C:
+			g.Refill()
+		}
+	}
+}
+
+// FreeQuota returns the quota which is not allocated to any bucket.
+func (g *BucketGroup) FreeQuota() int64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.totalQuota - g.allocatedQuota
+}
+
+// Len returns the number of buckets in the group.
+func (g *BucketGroup) Len() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.buckets.Len()
+}
+
+// Used returns the number of tokens taken from the buckets of the group and
+// not given back yet, the tokens accumulated beyond the quota are not counted.
+// It is meaningful only for residency buckets.
+func (g *BucketGroup) Used() int64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	var used int64
+	g.buckets.Ascend(func(i btree.Item) bool {
+		if b := i.(*Bucket); b.tokens < b.quota {
+			used += b.quota - b.tokens
+		}
+		return true
+	})
+	return used
+}
+
+// borrowLocked moves n tokens from the siblings of the borrower to it, and
+// returns how many tokens each sibling lends. Siblings with lower priority are
+// borrowed from first. Nothing is borrowed unless the whole amount can be
+// satisfied within the borrow limit, in which case false is returned.
+// The caller must hold g.mu.
+func (g *BucketGroup) borrowLocked(borrower *Bucket, n int64) (map[*Bucket]int64, bool) {
+	if borrower.borrowed+n > g.borrowLimit {
+		return nil, false
+	}
+	var spare int64
+	g.buckets.Ascend(func(i btree.Item) bool {
+		if b := i.(*Bucket); b != borrower {
+			spare += b.tokens
+		}
+		return spare < n
+	})
+	if spare < n {
+		return nil, false
+	}
+	if borrower.debts == nil {
+		borrower.debts = make(map[*Bucket]int64)
+	}
+	lent := make(map[*Bucket]int64)
+	remaining := n
+	g.buckets.Descend(func(i btree.Item) bool {
+		lender := i.(*Bucket)
+		if lender == borrower || lender.tokens == 0 {
+			return true
+		}
+		amount := lender.tokens
+		if amount > remaining {
+			amount = remaining
+		}
+		lender.tokens -= amount
+		lender.updateMetricsLocked()
+		borrower.debts[lender] += amount
+		lent[lender] = amount
+		remaining -= amount
+		return remaining > 0
+	})
+	borrower.tokens += n
+	borrower.borrowed += n
+	return lent, true
+}
+
+// returnQuota removes the bucket from the group and makes its quota
+// available to other buckets. It returns the nested groups to be resized,
+// see reallocateLocked. The caller must hold g.mu.
+func (g *BucketGroup) returnQuota(b *Bucket) []groupResize {
+	g.buckets.Delete(b)
+	g.allocatedQuota -= b.quota
+	b.closed = true
+	b.tokens = 0
+	if b.metrics != nil {
+		b.metrics.unregister()
+		b.metrics = nil
+	}
+	g.updateMetricsLocked()
+	return g.reallocateLocked()
+}
+
+// adjustPriority changes the priority of the bucket and keeps the buckets ordered.
+// The priority of a bucket can only be raised, lowering it is refused to avoid
+// a bucket from regressing behind buckets created after it.
+// It returns the nested groups to be resized, see reallocateLocked.
+// The caller must hold g.mu.
+func (g *BucketGroup) adjustPriority(b *Bucket, priority int64) ([]groupResize, error) {
+	if priority < b.priority {
+		return nil, cerror.ErrBucketPriorityRegression.GenWithStackByArgs(b.priority, priority)
+	}
+	if priority == b.priority {
+		return nil, nil
+	}
+	g.buckets.Delete(b)
+	b.priority = priority
+	g.buckets.ReplaceOrInsert(b)
+	return g.reallocateLocked(), nil
+}
+
+// setBoostLocked sets the temporary priority boost of the bucket. Unlike
+// adjustPriority, a boost can be revoked, which restores the bucket to its
+// original priority.
+// The caller must hold g.mu.
+func (g *BucketGroup) setBoostLocked(b *Bucket, boost int64) {
+	if b.boost == boost || b.closed {
+		return
+	}
+	g.buckets.Delete(b)
+	b.boost = boost
+	g.buckets.ReplaceOrInsert(b)
+}
@@ -0,0 +1,545 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buckets
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pingcap/check"
+	"github.com/pingcap/errors"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func Test(t *testing.T) {
+	check.TestingT(t)
+}
+
+type bucketSuite struct{}
+
+var _ = check.Suite(&bucketSuite{})
+
+func (s *bucketSuite) TestCreateBucket(c *check.C) {
+	g := NewBucketGroup(100, time.Second)
+	b1, err := g.CreateBucket(1, 60, 0)
+	c.Assert(err, check.IsNil)
+	_, err = g.CreateBucket(1, 50, 0)
+	c.Assert(cerror.ErrBucketQuotaExceeded.Equal(err), check.IsTrue)
+	_, err = g.CreateBucket(1, 0, 0)
+	c.Assert(cerror.ErrBucketInvalidQuota.Equal(err), check.IsTrue)
+	c.Assert(g.FreeQuota(), check.Equals, int64(40))
+
+	b1.Close()
+	c.Assert(g.FreeQuota(), check.Equals, int64(100))
+	c.Assert(g.Len(), check.Equals, 0)
+	c.Assert(b1.TryAcquire(1), check.IsFalse)
+	_, err = g.CreateBucket(1, 50, 0)
+	c.Assert(err, check.IsNil)
+}
+
+func (s *bucketSuite) TestAcquireRelease(c *check.C) {
+	g := NewBucketGroup(100, time.Second)
+	b, err := g.CreateBucket(1, 10, 5)
+	c.Assert(err, check.IsNil)
+	c.Assert(b.Available(), check.Equals, int64(10))
+
+	c.Assert(b.TryAcquire(8), check.IsTrue)
+	c.Assert(b.TryAcquire(3), check.IsFalse)
+	c.Assert(b.Available(), check.Equals, int64(2))
+	b.Release(3)
+	c.Assert(b.Available(), check.Equals, int64(5))
+
+	// refill accumulates tokens up to quota + burst
+	g.Refill()
+	c.Assert(b.Available(), check.Equals, int64(15))
+	g.Refill()
+	c.Assert(b.Available(), check.Equals, int64(15))
+	c.Assert(b.TryAcquire(15), check.IsTrue)
+	c.Assert(b.Available(), check.Equals, int64(0))
+
+	// the bucket is in debt after a forced acquisition
+	b.ForceAcquire(5)
+	c.Assert(b.Available(), check.Equals, int64(-5))
+	c.Assert(b.TryAcquire(1), check.IsFalse)
+	b.Release(6)
+	c.Assert(b.Available(), check.Equals, int64(1))
+}
+
+func (s *bucketSuite) TestPriority(c *check.C) {
+	g := NewBucketGroup(100, time.Second)
+	b1, err := g.CreateBucket(1, 10, 0)
+	c.Assert(err, check.IsNil)
+	b2, err := g.CreateBucket(2, 10, 0)
+	c.Assert(err, check.IsNil)
+	c.Assert(g.buckets.Min().(*Bucket), check.Equals, b2)
+
+	c.Assert(b1.SetPriority(3), check.IsNil)
+	c.Assert(g.buckets.Min().(*Bucket), check.Equals, b1)
+	err = b1.SetPriority(0)
+	c.Assert(cerror.ErrBucketPriorityRegression.Equal(err), check.IsTrue)
+	c.Assert(b1.Priority(), check.Equals, int64(3))
+}
+
+func (s *bucketSuite) TestConcurrentAcquire(c *check.C) {
+	g := NewBucketGroup(1000, time.Millisecond)
+	b, err := g.CreateBucket(1, 1000, 0)
+	c.Assert(err, check.IsNil)
+
+	// without refill, exactly quota tokens can be acquired in total
+	var acquired int64
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if b.TryAcquire(1) {
+					atomic.AddInt64(&acquired, 1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	c.Assert(acquired, check.Equals, int64(1000))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err = g.Run(ctx)
+	c.Assert(errors.Cause(err), check.Equals, context.DeadlineExceeded)
+	c.Assert(b.Available(), check.Equals, int64(1000))
+}
+
+func (s *bucketSuite) TestBorrow(c *check.C) {
+	g := NewBucketGroup(100, time.Second)
+	hot, err := g.CreateBucket(2, 10, 0)
+	c.Assert(err, check.IsNil)
+	idle1, err := g.CreateBucket(1, 10, 0)
+	c.Assert(err, check.IsNil)
+	idle2, err := g.CreateBucket(0, 10, 0)
+	c.Assert(err, check.IsNil)
+
+	// borrowing is disabled by default
+	c.Assert(hot.TryAcquire(15), check.IsFalse)
+
+	g.SetBorrowLimit(15)
+	c.Assert(hot.TryAcquire(15), check.IsTrue)
+	c.Assert(hot.Borrowed(), check.Equals, int64(5))
+	// the sibling with the lowest priority lends first
+	c.Assert(idle2.Available(), check.Equals, int64(5))
+	c.Assert(idle1.Available(), check.Equals, int64(10))
+
+	// exceeds the borrow limit
+	c.Assert(hot.TryAcquire(11), check.IsFalse)
+	c.Assert(hot.TryAcquire(10), check.IsTrue)
+	c.Assert(hot.Borrowed(), check.Equals, int64(15))
+	c.Assert(idle2.Available(), check.Equals, int64(0))
+	c.Assert(idle1.Available(), check.Equals, int64(5))
+
+	// debts are repaid on refill
+	g.Refill()
+	c.Assert(hot.Borrowed(), check.Equals, int64(5))
+	c.Assert(hot.Available(), check.Equals, int64(0))
+	g.Refill()
+	c.Assert(hot.Borrowed(), check.Equals, int64(0))
+	c.Assert(hot.Available(), check.Equals, int64(5))
+	c.Assert(idle1.Available(), check.Equals, int64(10))
+	c.Assert(idle2.Available(), check.Equals, int64(10))
+}
+
+func (s *bucketSuite) TestBorrowRollback(c *check.C) {
+	node := NewBucketGroup(100, time.Second)
+	cf, err := node.CreateSubGroup(1, 20, 0)
+	c.Assert(err, check.IsNil)
+	cf.SetBorrowLimit(10)
+	hot, err := cf.CreateBucket(1, 10, 0)
+	c.Assert(err, check.IsNil)
+	idle, err := cf.CreateBucket(0, 10, 0)
+	c.Assert(err, check.IsNil)
+	hot.SetMetricLabels("test-cf", "test.hot")
+	defer hot.Close()
+
+	// the changefeed level quota is exhausted
+	cf.parent.tokens = 5
+	c.Assert(hot.TryAcquire(15), check.IsFalse)
+	// the tokens borrowed from the sibling are given back
+	c.Assert(hot.Available(), check.Equals, int64(10))
+	c.Assert(hot.Borrowed(), check.Equals, int64(0))
+	c.Assert(hot.debts, check.HasLen, 0)
+	c.Assert(idle.Available(), check.Equals, int64(10))
+	c.Assert(cf.parent.Available(), check.Equals, int64(5))
+	c.Assert(testutil.ToFloat64(hot.metrics.acquire), check.Equals, float64(0))
+	c.Assert(testutil.ToFloat64(hot.metrics.throttle), check.Equals, float64(1))
+
+	cf.parent.tokens = 20
+	c.Assert(hot.TryAcquire(15), check.IsTrue)
+	c.Assert(hot.Borrowed(), check.Equals, int64(5))
+	c.Assert(idle.Available(), check.Equals, int64(5))
+	c.Assert(testutil.ToFloat64(hot.metrics.acquire), check.Equals, float64(1))
+}
+
+func (s *bucketSuite) TestHierarchy(c *check.C) {
+	node := NewBucketGroup(100, time.Second)
+	cf1, err := node.CreateSubGroup(1, 20, 0)
+	c.Assert(err, check.IsNil)
+	cf2, err := node.CreateSubGroup(1, 50, 0)
+	c.Assert(err, check.IsNil)
+	_, err = node.CreateSubGroup(1, 50, 0)
+	c.Assert(cerror.ErrBucketQuotaExceeded.Equal(err), check.IsTrue)
+
+	t1, err := cf1.CreateBucket(1, 15, 0)
+	c.Assert(err, check.IsNil)
+	t2, err := cf1.CreateBucket(1, 5, 0)
+	c.Assert(err, check.IsNil)
+	_, err = cf1.CreateBucket(1, 1, 0)
+	c.Assert(cerror.ErrBucketQuotaExceeded.Equal(err), check.IsTrue)
+	t3, err := cf2.CreateBucket(1, 50, 0)
+	c.Assert(err, check.IsNil)
+
+	c.Assert(t1.TryAcquire(15), check.IsTrue)
+	c.Assert(t2.TryAcquire(5), check.IsTrue)
+	c.Assert(t3.TryAcquire(50), check.IsTrue)
+	c.Assert(cf1.parent.Available(), check.Equals, int64(0))
+
+	// the changefeed level quota is exhausted, even if the table has tokens
+	t1.Release(10)
+	c.Assert(cf1.parent.Available(), check.Equals, int64(10))
+	c.Assert(t1.TryAcquire(5), check.IsTrue)
+	cf1.parent.tokens = 0
+	c.Assert(t1.TryAcquire(5), check.IsFalse)
+	// a failed acquisition at the upper level is rolled back
+	c.Assert(t1.Available(), check.Equals, int64(5))
+
+	// refilling the node level refills all levels
+	node.Refill()
+	c.Assert(cf1.parent.Available(), check.Equals, int64(20))
+	c.Assert(t2.Available(), check.Equals, int64(5))
+	c.Assert(t3.Available(), check.Equals, int64(50))
+
+	cf2.Close()
+	c.Assert(node.FreeQuota(), check.Equals, int64(80))
+	c.Assert(node.children, check.HasLen, 1)
+	c.Assert(t3.TryAcquire(1), check.IsFalse)
+}
+
+func (s *bucketSuite) TestBlockingAcquire(c *check.C) {
+	ctx := context.Background()
+	g := NewBucketGroup(100, time.Second)
+	b, err := g.CreateBucket(1, 10, 0)
+	c.Assert(err, check.IsNil)
+
+	c.Assert(b.Acquire(ctx, 10), check.IsNil)
+	err = b.Acquire(ctx, 11)
+	c.Assert(cerror.ErrBucketAcquireTooLarge.Equal(err), check.IsTrue)
+
+	cctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	err = b.Acquire(cctx, 1)
+	c.Assert(errors.Cause(err), check.Equals, context.DeadlineExceeded)
+	c.Assert(g.Waiters(), check.Equals, 0)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- b.Acquire(ctx, 5)
+	}()
+	for g.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	b.Release(5)
+	c.Assert(<-done, check.IsNil)
+	c.Assert(b.Available(), check.Equals, int64(0))
+
+	go func() {
+		done <- b.Acquire(ctx, 5)
+	}()
+	for g.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	b.Close()
+	c.Assert(cerror.ErrBucketClosed.Equal(<-done), check.IsTrue)
+}
+
+func (s *bucketSuite) TestWaiterOrder(c *check.C) {
+	ctx := context.Background()
+	for _, order := range []WaiterOrder{WaiterOrderPriority, WaiterOrderFIFO} {
+		node := NewBucketGroup(100, time.Second)
+		cf, err := node.CreateSubGroup(1, 10, 0)
+		c.Assert(err, check.IsNil)
+		cf.SetWaiterOrder(order)
+		low, err := cf.CreateBucket(1, 5, 0)
+		c.Assert(err, check.IsNil)
+		high, err := cf.CreateBucket(2, 5, 0)
+		c.Assert(err, check.IsNil)
+		// both tables contend for the quota of the changefeed
+		c.Assert(cf.parent.TryAcquire(10), check.IsTrue)
+
+		served := make(chan string, 2)
+		go func() {
+			c.Check(low.Acquire(ctx, 5), check.IsNil)
+			served <- "low"
+		}()
+		for cf.Waiters() != 1 {
+			time.Sleep(time.Millisecond)
+		}
+		go func() {
+			c.Check(high.Acquire(ctx, 5), check.IsNil)
+			served <- "high"
+		}()
+		for cf.Waiters() != 2 {
+			time.Sleep(time.Millisecond)
+		}
+
+		// only one of them can be served
+		cf.parent.Release(5)
+		first := <-served
+		if order == WaiterOrderPriority {
+			c.Assert(first, check.Equals, "high")
+		} else {
+			c.Assert(first, check.Equals, "low")
+		}
+		cf.parent.Release(5)
+		<-served
+	}
+}
+
+func (s *bucketSuite) TestMetrics(c *check.C) {
+	g := NewBucketGroup(100, time.Second)
+	g.SetName("test-metrics")
+	b, err := g.CreateBucket(1, 10, 0)
+	c.Assert(err, check.IsNil)
+	b.SetMetricLabels("test-cf", "test.t")
+
+	c.Assert(b.TryAcquire(4), check.IsTrue)
+	c.Assert(b.TryAcquire(7), check.IsFalse)
+	c.Assert(testutil.ToFloat64(b.metrics.available), check.Equals, float64(6))
+	c.Assert(testutil.ToFloat64(b.metrics.acquire), check.Equals, float64(1))
+	c.Assert(testutil.ToFloat64(b.metrics.throttle), check.Equals, float64(1))
+	c.Assert(testutil.ToFloat64(groupAllocatedGauge.WithLabelValues("test-metrics")), check.Equals, float64(10))
+
+	b.Close()
+	c.Assert(b.metrics, check.IsNil)
+	c.Assert(testutil.ToFloat64(groupAllocatedGauge.WithLabelValues("test-metrics")), check.Equals, float64(0))
+}
+
+func (s *bucketSuite) TestAging(c *check.C) {
+	ctx := context.Background()
+	node := NewBucketGroup(100, time.Second)
+	cf, err := node.CreateSubGroup(1, 20, 0)
+	c.Assert(err, check.IsNil)
+	cf.SetAging(1, time.Second)
+	low, err := cf.CreateBucket(1, 10, 0)
+	c.Assert(err, check.IsNil)
+	high, err := cf.CreateBucket(5, 10, 0)
+	c.Assert(err, check.IsNil)
+	c.Assert(cf.parent.TryAcquire(20), check.IsTrue)
+
+	served := make(chan string, 2)
+	go func() {
+		c.Check(low.Acquire(ctx, 5), check.IsNil)
+		served <- "low"
+	}()
+	for cf.Waiters() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	// the low priority waiter has waited long enough to overtake
+	cf.mu.Lock()
+	cf.ageWaitersLocked(time.Now().Add(10 * time.Second))
+	cf.mu.Unlock()
+	c.Assert(low.effectivePriority() > high.effectivePriority(), check.IsTrue)
+
+	go func() {
+		c.Check(high.Acquire(ctx, 5), check.IsNil)
+		served <- "high"
+	}()
+	for cf.Waiters() != 2 {
+		time.Sleep(time.Millisecond)
+	}
+	cf.parent.Release(5)
+	c.Assert(<-served, check.Equals, "low")
+	// the boost is revoked once served
+	c.Assert(low.effectivePriority(), check.Equals, int64(1))
+	c.Assert(low.Priority(), check.Equals, int64(1))
+	cf.parent.Release(5)
+	c.Assert(<-served, check.Equals, "high")
+}
+
+func (s *bucketSuite) TestBucketMode(c *check.C) {
+	g := NewBucketGroup(100, time.Second)
+	throughput, err := g.CreateBucket(1, 10, 5)
+	c.Assert(err, check.IsNil)
+	c.Assert(throughput.Mode(), check.Equals, BucketModeThroughput)
+	_, err = g.CreateBucketWithMode(BucketModeResidency, 1, 10, 5)
+	c.Assert(cerror.ErrBucketInvalidQuota.Equal(err), check.IsTrue)
+	residency, err := g.CreateBucketWithMode(BucketModeResidency, 1, 10, 0)
+	c.Assert(err, check.IsNil)
+	c.Assert(residency.Mode(), check.Equals, BucketModeResidency)
+
+	// only throughput buckets are refilled
+	c.Assert(throughput.TryAcquire(10), check.IsTrue)
+	c.Assert(residency.TryAcquire(10), check.IsTrue)
+	g.Refill()
+	c.Assert(throughput.Available(), check.Equals, int64(10))
+	c.Assert(residency.Available(), check.Equals, int64(0))
+	c.Assert(g.Used(), check.Equals, int64(10))
+	residency.Release(10)
+	c.Assert(residency.Available(), check.Equals, int64(10))
+	c.Assert(g.Used(), check.Equals, int64(0))
+}
+
+func (s *bucketSuite) TestResidencyRepay(c *check.C) {
+	g := NewBucketGroup(100, time.Second)
+	g.SetBorrowLimit(10)
+	lender, err := g.CreateBucketWithMode(BucketModeResidency, 1, 10, 0)
+	c.Assert(err, check.IsNil)
+	borrower, err := g.CreateBucketWithMode(BucketModeResidency, 2, 10, 0)
+	c.Assert(err, check.IsNil)
+
+	c.Assert(borrower.TryAcquire(15), check.IsTrue)
+	c.Assert(borrower.Borrowed(), check.Equals, int64(5))
+	c.Assert(lender.Available(), check.Equals, int64(5))
+	// the debts are repaid as soon as the tokens are released
+	borrower.Release(15)
+	c.Assert(borrower.Borrowed(), check.Equals, int64(0))
+	c.Assert(borrower.Available(), check.Equals, int64(10))
+	c.Assert(lender.Available(), check.Equals, int64(10))
+}
+
+func (s *bucketSuite) TestForceAcquireClosed(c *check.C) {
+	node := NewBucketGroup(100, time.Second)
+	cf, err := node.CreateSubGroupWithMode(BucketModeResidency, 0, 50, 0)
+	c.Assert(err, check.IsNil)
+	held, err := cf.CreateBucketWithMode(BucketModeResidency, 0, 20, 0)
+	c.Assert(err, check.IsNil)
+	closed, err := cf.CreateBucketWithMode(BucketModeResidency, 0, 20, 0)
+	c.Assert(err, check.IsNil)
+
+	c.Assert(held.TryAcquire(10), check.IsTrue)
+	c.Assert(closed.TryAcquire(10), check.IsTrue)
+	c.Assert(cf.parent.Available(), check.Equals, int64(30))
+	closed.Close()
+	// the parent is charged by a closed bucket, since the tokens are given
+	// back to it by Release
+	closed.ForceAcquire(5)
+	c.Assert(cf.parent.Available(), check.Equals, int64(25))
+	closed.Release(15)
+	c.Assert(cf.parent.Available(), check.Equals, int64(40))
+	held.Release(10)
+	c.Assert(cf.parent.Available(), check.Equals, int64(50))
+}
+
+func (s *bucketSuite) TestSnapshot(c *check.C) {
+	ctx := context.Background()
+	g := NewBucketGroup(100, time.Second)
+	g.SetName("test-snapshot")
+	defer groupQuotaGauge.DeleteLabelValues("test-snapshot")
+	b1, err := g.CreateBucket(1, 10, 5)
+	c.Assert(err, check.IsNil)
+	b1.SetMetricLabels("test-cf", "test-table")
+	defer b1.Close()
+	sub, err := g.CreateSubGroupWithMode(BucketModeResidency, 2, 20, 0)
+	c.Assert(err, check.IsNil)
+	b2, err := sub.CreateBucketWithMode(BucketModeResidency, 0, 20, 0)
+	c.Assert(err, check.IsNil)
+
+	g.Refill()
+	c.Assert(b2.TryAcquire(20), check.IsTrue)
+	done := make(chan struct{})
+	go func() {
+		c.Check(b2.Acquire(ctx, 5), check.IsNil)
+		close(done)
+	}()
+	for sub.Waiters() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	snap := g.Snapshot()
+	c.Assert(snap.Name, check.Equals, "test-snapshot")
+	c.Assert(snap.TotalQuota, check.Equals, int64(100))
+	c.Assert(snap.AllocatedQuota, check.Equals, int64(30))
+	c.Assert(snap.ParentBucketID, check.IsNil)
+	c.Assert(snap.Buckets, check.HasLen, 2)
+	// the backing bucket of the nested group has higher priority
+	c.Assert(snap.Buckets[0].Mode, check.Equals, "residency")
+	c.Assert(snap.Buckets[0].Available, check.Equals, int64(0))
+	c.Assert(snap.Buckets[1], check.DeepEquals, BucketSnapshot{
+		ID: b1.id, Mode: "throughput", Changefeed: "test-cf", Table: "test-table",
+		Priority: 1, Quota: 10, Demand: 10, Burst: 5, BurstUsed: 5, Available: 15,
+	})
+	c.Assert(snap.Groups, check.HasLen, 1)
+	c.Assert(*snap.Groups[0].ParentBucketID, check.Equals, snap.Buckets[0].ID)
+	c.Assert(snap.Groups[0].Waiters, check.Equals, 1)
+	c.Assert(snap.Groups[0].Buckets[0].Waiters, check.Equals, 1)
+
+	b2.Release(20)
+	<-done
+}
+
+func (s *bucketSuite) TestFairAllocation(c *check.C) {
+	g := NewBucketGroup(100, time.Second)
+	g.SetAllocationPolicy(AllocationFair)
+	b1, err := g.CreateBucket(1, 80, 0)
+	c.Assert(err, check.IsNil)
+	c.Assert(b1.Quota(), check.Equals, int64(80))
+	c.Assert(b1.Available(), check.Equals, int64(80))
+
+	// the demand of b2 can be satisfied, and b1 gets the rest
+	b2, err := g.CreateBucket(1, 10, 0)
+	c.Assert(err, check.IsNil)
+	c.Assert(b1.Quota(), check.Equals, int64(80))
+	c.Assert(b2.Quota(), check.Equals, int64(10))
+
+	// b1 and b3 are not satisfied and share the remaining quota by weight
+	b3, err := g.CreateBucket(3, 100, 0)
+	c.Assert(err, check.IsNil)
+	c.Assert(b2.Quota(), check.Equals, int64(10))
+	c.Assert(b1.Quota(), check.Equals, int64(22))
+	c.Assert(b3.Quota(), check.Equals, int64(67))
+	c.Assert(g.FreeQuota(), check.Equals, int64(1))
+	c.Assert(b1.Available(), check.Equals, int64(22))
+
+	c.Assert(b1.SetPriority(6), check.IsNil)
+	c.Assert(b1.Quota(), check.Equals, int64(60))
+	c.Assert(b3.Quota(), check.Equals, int64(30))
+
+	// the quota is reallocated when a bucket leaves
+	b1.Close()
+	c.Assert(b2.Quota(), check.Equals, int64(10))
+	c.Assert(b3.Quota(), check.Equals, int64(90))
+}
+
+func (s *bucketSuite) TestFairAllocationResizeSubGroup(c *check.C) {
+	g := NewBucketGroup(100, time.Second)
+	g.SetAllocationPolicy(AllocationFair)
+	sub, err := g.CreateSubGroupWithMode(BucketModeResidency, 1, 100, 0)
+	c.Assert(err, check.IsNil)
+	c.Assert(sub.FreeQuota(), check.Equals, int64(100))
+	sub.SetAllocationPolicy(AllocationFair)
+	b, err := sub.CreateBucketWithMode(BucketModeResidency, 1, 100, 0)
+	c.Assert(err, check.IsNil)
+	c.Assert(b.TryAcquire(30), check.IsTrue)
+
+	other, err := g.CreateBucketWithMode(BucketModeResidency, 1, 100, 0)
+	c.Assert(err, check.IsNil)
+	c.Assert(other.Quota(), check.Equals, int64(50))
+	c.Assert(b.Quota(), check.Equals, int64(50))
+	// the tokens in use are kept
+	c.Assert(b.Available(), check.Equals, int64(20))
+
+	other.Close()
+	c.Assert(b.Quota(), check.Equals, int64(100))
+	c.Assert(b.Available(), check.Equals, int64(70))
+}
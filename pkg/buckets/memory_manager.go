@@ -0,0 +1,217 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buckets
+
+import (
+	"context"
+	"io/ioutil"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/ticdc/pkg/notify"
+	"go.uber.org/zap"
+)
+
+// The subsystems sharing the memory of a capture.
+const (
+	MemorySubsystemSorter = "sorter"
+)
+
+// memoryShares is the percentage of the total memory budget allocated to each
+// subsystem, and the priority of the subsystem in the global group. Only the
+// subsystems acquiring their memory from the manager have a share, otherwise
+// the share is never used while the others are limited.
+var memoryShares = []struct {
+	subsystem string
+	percent   int64
+	priority  int64
+}{
+	{MemorySubsystemSorter, 100, 0},
+}
+
+// MemoryPressure is the level of the memory usage of a capture.
+type MemoryPressure int
+
+const (
+	// MemoryPressureLow means the memory usage is below 80% of the budget.
+	MemoryPressureLow MemoryPressure = iota
+	// MemoryPressureHigh means the memory usage is at least 80% of the budget,
+	// components should stop buffering more data than necessary.
+	MemoryPressureHigh
+	// MemoryPressureCritical means the memory usage is at least 95% of the
+	// budget, components should release as much memory as possible.
+	MemoryPressureCritical
+)
+
+func (p MemoryPressure) String() string {
+	switch p {
+	case MemoryPressureLow:
+		return "low"
+	case MemoryPressureHigh:
+		return "high"
+	case MemoryPressureCritical:
+		return "critical"
+	}
+	return "unknown"
+}
+
+const (
+	memoryPressureCheckInterval = time.Second
+
+	cgroupV1MemoryLimitPath = "/sys/fs/cgroup/memory/memory.limit_in_bytes"
+	cgroupV2MemoryLimitPath = "/sys/fs/cgroup/memory.max"
+)
+
+// GlobalMemoryManager owns the memory budget of a capture. The budget is split
+// into a nested group for each subsystem, the buckets in which should be
+// residency buckets, see BucketModeResidency.
+type GlobalMemoryManager struct {
+	root   *BucketGroup
+	total  int64
+	groups map[string]*BucketGroup
+
+	mu       sync.Mutex
+	pressure MemoryPressure
+	notifier notify.Notifier
+}
+
+// NewGlobalMemoryManager creates a GlobalMemoryManager with the total budget
+// in bytes.
+func NewGlobalMemoryManager(total int64) (*GlobalMemoryManager, error) {
+	m := &GlobalMemoryManager{
+		root:   NewBucketGroup(total, time.Second),
+		total:  total,
+		groups: make(map[string]*BucketGroup, len(memoryShares)),
+	}
+	m.root.SetName("memory")
+	for _, share := range memoryShares {
+		g, err := m.root.CreateSubGroupWithMode(BucketModeResidency, share.priority, total*share.percent/100, 0)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		g.SetName("memory-" + share.subsystem)
+		m.groups[share.subsystem] = g
+	}
+	return m, nil
+}
+
+// Group returns the group of the subsystem, or nil if the subsystem is unknown.
+func (m *GlobalMemoryManager) Group(subsystem string) *BucketGroup {
+	return m.groups[subsystem]
+}
+
+// Total returns the total memory budget.
+func (m *GlobalMemoryManager) Total() int64 {
+	return m.total
+}
+
+// Used returns the memory acquired from all the subsystems.
+func (m *GlobalMemoryManager) Used() int64 {
+	return m.root.Used()
+}
+
+// UsedBySubsystem returns the memory acquired from each subsystem.
+func (m *GlobalMemoryManager) UsedBySubsystem() map[string]int64 {
+	used := make(map[string]int64, len(m.groups))
+	for subsystem, g := range m.groups {
+		used[subsystem] = g.Used()
+	}
+	return used
+}
+
+// Snapshot returns the state of the memory quota of all the subsystems.
+func (m *GlobalMemoryManager) Snapshot() GroupSnapshot {
+	return m.root.Snapshot()
+}
+
+// Pressure returns the current memory pressure.
+func (m *GlobalMemoryManager) Pressure() MemoryPressure {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.pressure
+}
+
+// Subscribe returns a receiver which is notified whenever the memory pressure
+// changes, the subscriber should call Pressure to get the new level.
+func (m *GlobalMemoryManager) Subscribe() *notify.Receiver {
+	return m.notifier.NewReceiver(0)
+}
+
+// Run checks the memory pressure periodically until ctx is done.
+func (m *GlobalMemoryManager) Run(ctx context.Context) error {
+	defer m.notifier.Close()
+	ticker := time.NewTicker(memoryPressureCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return errors.Trace(ctx.Err())
+		case <-ticker.C:
+			m.checkPressure()
+		}
+	}
+}
+
+func (m *GlobalMemoryManager) checkPressure() {
+	used := m.Used()
+	pressure := MemoryPressureLow
+	switch {
+	case used*100 >= m.total*95:
+		pressure = MemoryPressureCritical
+	case used*100 >= m.total*80:
+		pressure = MemoryPressureHigh
+	}
+	m.mu.Lock()
+	changed := pressure != m.pressure
+	m.pressure = pressure
+	m.mu.Unlock()
+	if changed {
+		log.Info("memory pressure changed",
+			zap.Stringer("pressure", pressure),
+			zap.Int64("used", used),
+			zap.Int64("total", m.total))
+		m.notifier.Notify()
+	}
+}
+
+// MemoryLimitFromCgroup returns the memory limit of the cgroup the process
+// belongs to, it returns false if there is no limit.
+func MemoryLimitFromCgroup() (int64, bool) {
+	for _, path := range []string{cgroupV2MemoryLimitPath, cgroupV1MemoryLimitPath} {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		return parseCgroupMemoryLimit(string(data))
+	}
+	return 0, false
+}
+
+func parseCgroupMemoryLimit(s string) (int64, bool) {
+	s = strings.TrimSpace(s)
+	if s == "max" {
+		return 0, false
+	}
+	limit, err := strconv.ParseUint(s, 10, 64)
+	// cgroup v1 reports a huge number rounded to the page size if unlimited
+	if err != nil || limit == 0 || limit >= math.MaxInt64/2 {
+		return 0, false
+	}
+	return int64(limit), true
+}
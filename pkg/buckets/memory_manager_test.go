@@ -0,0 +1,82 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buckets
+
+import (
+	"time"
+
+	"github.com/pingcap/check"
+)
+
+type memoryManagerSuite struct{}
+
+var _ = check.Suite(&memoryManagerSuite{})
+
+func (s *memoryManagerSuite) TestSubsystemGroups(c *check.C) {
+	m, err := NewGlobalMemoryManager(1000)
+	c.Assert(err, check.IsNil)
+	c.Assert(m.Group(MemorySubsystemSorter).FreeQuota(), check.Equals, int64(1000))
+	c.Assert(m.Group("unknown"), check.IsNil)
+
+	b, err := m.Group(MemorySubsystemSorter).CreateBucketWithMode(BucketModeResidency, 0, 1000, 0)
+	c.Assert(err, check.IsNil)
+	c.Assert(b.TryAcquire(500), check.IsTrue)
+	c.Assert(m.Used(), check.Equals, int64(500))
+	c.Assert(m.UsedBySubsystem(), check.DeepEquals, map[string]int64{
+		MemorySubsystemSorter: 500,
+	})
+	b.Release(500)
+	c.Assert(m.Used(), check.Equals, int64(0))
+}
+
+func (s *memoryManagerSuite) TestPressure(c *check.C) {
+	m, err := NewGlobalMemoryManager(1000)
+	c.Assert(err, check.IsNil)
+	b, err := m.Group(MemorySubsystemSorter).CreateBucketWithMode(BucketModeResidency, 0, 1000, 0)
+	c.Assert(err, check.IsNil)
+	receiver := m.Subscribe()
+	defer receiver.Stop()
+
+	c.Assert(b.TryAcquire(500), check.IsTrue)
+	m.checkPressure()
+	c.Assert(m.Pressure(), check.Equals, MemoryPressureLow)
+
+	b.ForceAcquire(400)
+	m.checkPressure()
+	c.Assert(m.Pressure(), check.Equals, MemoryPressureHigh)
+	select {
+	case <-receiver.C:
+	case <-time.After(time.Second):
+		c.Fatal("memory pressure change is not notified")
+	}
+
+	b.ForceAcquire(100)
+	m.checkPressure()
+	c.Assert(m.Pressure(), check.Equals, MemoryPressureCritical)
+	b.Release(1000)
+	m.checkPressure()
+	c.Assert(m.Pressure(), check.Equals, MemoryPressureLow)
+}
+
+func (s *memoryManagerSuite) TestParseCgroupMemoryLimit(c *check.C) {
+	limit, ok := parseCgroupMemoryLimit("1073741824\n")
+	c.Assert(ok, check.IsTrue)
+	c.Assert(limit, check.Equals, int64(1073741824))
+	_, ok = parseCgroupMemoryLimit("max\n")
+	c.Assert(ok, check.IsFalse)
+	_, ok = parseCgroupMemoryLimit("9223372036854771712\n")
+	c.Assert(ok, check.IsFalse)
+	_, ok = parseCgroupMemoryLimit("invalid")
+	c.Assert(ok, check.IsFalse)
+}
@@ -0,0 +1,155 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buckets
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	bucketQuotaGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "ticdc",
+			Subsystem: "bucket",
+			Name:      "quota",
+			Help:      "The quota of a bucket",
+		}, []string{"changefeed", "table"})
+	bucketAvailableGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "ticdc",
+			Subsystem: "bucket",
+			Name:      "available",
+			Help:      "The number of tokens available in a bucket",
+		}, []string{"changefeed", "table"})
+	bucketBorrowedGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "ticdc",
+			Subsystem: "bucket",
+			Name:      "borrowed",
+			Help:      "The number of tokens a bucket borrowed from its siblings",
+		}, []string{"changefeed", "table"})
+	bucketAcquireCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "ticdc",
+			Subsystem: "bucket",
+			Name:      "acquire_count",
+			Help:      "The number of successful acquisitions of a bucket",
+		}, []string{"changefeed", "table"})
+	bucketThrottleCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "ticdc",
+			Subsystem: "bucket",
+			Name:      "throttle_count",
+			Help:      "The number of acquisitions of a bucket which failed or had to wait for tokens",
+		}, []string{"changefeed", "table"})
+	bucketWaitDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "ticdc",
+			Subsystem: "bucket",
+			Name:      "wait_duration",
+			Help:      "Bucketed histogram of the time (s) blocked acquisitions waited for tokens.",
+			Buckets:   prometheus.ExponentialBuckets(0.0001, 2, 18),
+		}, []string{"changefeed", "table"})
+	groupQuotaGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "ticdc",
+			Subsystem: "bucket_group",
+			Name:      "quota",
+			Help:      "The total quota of a bucket group",
+		}, []string{"group"})
+	groupAllocatedGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "ticdc",
+			Subsystem: "bucket_group",
+			Name:      "allocated",
+			Help:      "The quota of a bucket group allocated to its buckets",
+		}, []string{"group"})
+	groupWaitersGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "ticdc",
+			Subsystem: "bucket_group",
+			Name:      "waiters",
+			Help:      "The number of blocked acquisitions in a bucket group",
+		}, []string{"group"})
+)
+
+// InitMetrics registers all metrics in this file
+func InitMetrics(registry *prometheus.Registry) {
+	registry.MustRegister(bucketQuotaGauge)
+	registry.MustRegister(bucketAvailableGauge)
+	registry.MustRegister(bucketBorrowedGauge)
+	registry.MustRegister(bucketAcquireCounter)
+	registry.MustRegister(bucketThrottleCounter)
+	registry.MustRegister(bucketWaitDuration)
+	registry.MustRegister(groupQuotaGauge)
+	registry.MustRegister(groupAllocatedGauge)
+	registry.MustRegister(groupWaitersGauge)
+}
+
+// bucketMetrics caches the metrics of a labeled bucket.
+type bucketMetrics struct {
+	changefeed string
+	table      string
+
+	quota     prometheus.Gauge
+	available prometheus.Gauge
+	borrowed  prometheus.Gauge
+	acquire   prometheus.Counter
+	throttle  prometheus.Counter
+	wait      prometheus.Observer
+}
+
+func newBucketMetrics(changefeed, table string) *bucketMetrics {
+	return &bucketMetrics{
+		changefeed: changefeed,
+		table:      table,
+		quota:      bucketQuotaGauge.WithLabelValues(changefeed, table),
+		available:  bucketAvailableGauge.WithLabelValues(changefeed, table),
+		borrowed:   bucketBorrowedGauge.WithLabelValues(changefeed, table),
+		acquire:    bucketAcquireCounter.WithLabelValues(changefeed, table),
+		throttle:   bucketThrottleCounter.WithLabelValues(changefeed, table),
+		wait:       bucketWaitDuration.WithLabelValues(changefeed, table),
+	}
+}
+
+func (m *bucketMetrics) unregister() {
+	bucketQuotaGauge.DeleteLabelValues(m.changefeed, m.table)
+	bucketAvailableGauge.DeleteLabelValues(m.changefeed, m.table)
+	bucketBorrowedGauge.DeleteLabelValues(m.changefeed, m.table)
+	bucketAcquireCounter.DeleteLabelValues(m.changefeed, m.table)
+	bucketThrottleCounter.DeleteLabelValues(m.changefeed, m.table)
+	bucketWaitDuration.DeleteLabelValues(m.changefeed, m.table)
+}
+
+// updateMetricsLocked refreshes the gauges of the bucket.
+// The caller must hold the lock of the group.
+func (b *Bucket) updateMetricsLocked() {
+	if b.metrics == nil {
+		return
+	}
+	b.metrics.quota.Set(float64(b.quota))
+	b.metrics.available.Set(float64(b.tokens))
+	b.metrics.borrowed.Set(float64(b.borrowed))
+}
+
+// updateMetricsLocked refreshes the gauges of the group.
+// The caller must hold g.mu.
+func (g *BucketGroup) updateMetricsLocked() {
+	if g.name == "" {
+		return
+	}
+	groupQuotaGauge.WithLabelValues(g.name).Set(float64(g.totalQuota))
+	groupAllocatedGauge.WithLabelValues(g.name).Set(float64(g.allocatedQuota))
+	groupWaitersGauge.WithLabelValues(g.name).Set(float64(g.waiters.Len()))
+}
@@ -0,0 +1,108 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buckets
+
+import (
+	"github.com/google/btree"
+)
+
+// BucketSnapshot is the state of a bucket at some point in time.
+type BucketSnapshot struct {
+	ID         uint64 `json:"id"`
+	Mode       string `json:"mode"`
+	Changefeed string `json:"changefeed,omitempty"`
+	Table      string `json:"table,omitempty"`
+	Priority   int64  `json:"priority"`
+	Boost      int64  `json:"boost"`
+	Quota      int64  `json:"quota"`
+	Demand     int64  `json:"demand"`
+	Burst      int64  `json:"burst"`
+	// BurstUsed is the number of tokens accumulated beyond the quota
+	BurstUsed int64 `json:"burst_used"`
+	Available int64 `json:"available"`
+	Borrowed  int64 `json:"borrowed"`
+	Waiters   int   `json:"waiters"`
+}
+
+// GroupSnapshot is the state of a group and all its buckets and nested groups
+// at some point in time.
+type GroupSnapshot struct {
+	Name           string `json:"name,omitempty"`
+	TotalQuota     int64  `json:"total_quota"`
+	AllocatedQuota int64  `json:"allocated_quota"`
+	BorrowLimit    int64  `json:"borrow_limit"`
+	Allocation     string `json:"allocation"`
+	Waiters        int    `json:"waiters"`
+	// ParentBucketID is the id of the bucket backing a nested group in its
+	// parent group
+	ParentBucketID *uint64          `json:"parent_bucket_id,omitempty"`
+	Buckets        []BucketSnapshot `json:"buckets"`
+	Groups         []GroupSnapshot  `json:"groups,omitempty"`
+}
+
+// Snapshot returns the state of the group, the buckets are ordered by priority.
+// The nested groups are not captured atomically with g.
+func (g *BucketGroup) Snapshot() GroupSnapshot {
+	g.mu.Lock()
+	snap := GroupSnapshot{
+		Name:           g.name,
+		TotalQuota:     g.totalQuota,
+		AllocatedQuota: g.allocatedQuota,
+		BorrowLimit:    g.borrowLimit,
+		Allocation:     g.allocationPolicy.String(),
+		Waiters:        g.waiters.Len(),
+		Buckets:        make([]BucketSnapshot, 0, g.buckets.Len()),
+	}
+	if g.parent != nil {
+		id := g.parent.id
+		snap.ParentBucketID = &id
+	}
+	waiters := make(map[*Bucket]int)
+	g.waiters.Ascend(func(i btree.Item) bool {
+		waiters[i.(*waiter).bucket]++
+		return true
+	})
+	g.buckets.Ascend(func(i btree.Item) bool {
+		b := i.(*Bucket)
+		bs := BucketSnapshot{
+			ID:        b.id,
+			Mode:      b.mode.String(),
+			Priority:  b.priority,
+			Boost:     b.boost,
+			Quota:     b.quota,
+			Demand:    b.demand,
+			Burst:     b.burst,
+			Available: b.tokens,
+			Borrowed:  b.borrowed,
+			Waiters:   waiters[b],
+		}
+		if b.tokens > b.quota {
+			bs.BurstUsed = b.tokens - b.quota
+		}
+		if b.metrics != nil {
+			bs.Changefeed = b.metrics.changefeed
+			bs.Table = b.metrics.table
+		}
+		snap.Buckets = append(snap.Buckets, bs)
+		return true
+	})
+	children := make([]*BucketGroup, len(g.children))
+	copy(children, g.children)
+	g.mu.Unlock()
+
+	for _, child := range children {
+		snap.Groups = append(snap.Groups, child.Snapshot())
+	}
+	return snap
+}
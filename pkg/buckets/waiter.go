@@ -0,0 +1,189 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buckets
+
+import (
+	"time"
+
+	"github.com/google/btree"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+)
+
+// WaiterOrder is the order in which blocked acquisitions of a group are served.
+type WaiterOrder int
+
+const (
+	// WaiterOrderPriority serves the waiters of buckets with higher priority
+	// first, and waiters with the same priority in FIFO order.
+	WaiterOrderPriority WaiterOrder = iota
+	// WaiterOrderFIFO serves the waiters in FIFO order regardless of priority.
+	WaiterOrderFIFO
+)
+
+// waiter is a blocked acquisition.
+type waiter struct {
+	bucket *Bucket
+	n      int64
+	// basePriority is the priority of the bucket when the waiter is enqueued,
+	// and priority is basePriority plus the boost gained by aging.
+	// Both of them are always 0 in FIFO order.
+	basePriority int64
+	priority     int64
+	seq          uint64
+	enqueueTime  time.Time
+	done         chan struct{}
+	err          error
+}
+
+// Less implements btree.Item.
+func (w *waiter) Less(than btree.Item) bool {
+	other := than.(*waiter)
+	if w.priority != other.priority {
+		return w.priority > other.priority
+	}
+	return w.seq < other.seq
+}
+
+// SetWaiterOrder sets the order in which blocked acquisitions are served.
+// It only affects the acquisitions blocked after it is called.
+func (g *BucketGroup) SetWaiterOrder(order WaiterOrder) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.waiterOrder = order
+}
+
+// SetAging enables priority aging. A blocked acquisition gains `step` priority
+// for every `interval` it waits, so that buckets with low priority are not
+// starved by buckets with high priority. Once served, the bucket is restored
+// to its original priority. Aging takes effect on every refill round.
+func (g *BucketGroup) SetAging(step int64, interval time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.agingStep = step
+	g.agingInterval = interval
+}
+
+// Waiters returns the number of blocked acquisitions in the group.
+func (g *BucketGroup) Waiters() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.waiters.Len()
+}
+
+func (g *BucketGroup) enqueueWaiter(b *Bucket, n int64) *waiter {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	w := &waiter{
+		bucket:      b,
+		n:           n,
+		seq:         g.waiterSeq,
+		enqueueTime: time.Now(),
+		done:        make(chan struct{}),
+	}
+	if g.waiterOrder == WaiterOrderPriority {
+		w.basePriority = b.priority
+		w.priority = w.basePriority
+	}
+	g.waiterSeq++
+	g.waiters.ReplaceOrInsert(w)
+	if b.metrics != nil {
+		b.metrics.throttle.Inc()
+	}
+	g.updateMetricsLocked()
+	return w
+}
+
+// serveWaiters grants tokens to the blocked acquisitions in order. It stops at
+// the first waiter which can't be satisfied, so that a large acquisition
+// is not starved by the smaller ones behind it.
+// The waiters of the nested groups are served afterwards, since they may be
+// blocked on the quota of g.
+func (g *BucketGroup) serveWaiters() {
+	g.serveMu.Lock()
+	for {
+		g.mu.Lock()
+		item := g.waiters.Min()
+		g.mu.Unlock()
+		if item == nil {
+			break
+		}
+		w := item.(*waiter)
+		if !w.bucket.TryAcquire(w.n) {
+			break
+		}
+		g.mu.Lock()
+		removed := g.waiters.Delete(w) != nil
+		g.setBoostLocked(w.bucket, 0)
+		g.updateMetricsLocked()
+		g.mu.Unlock()
+		if !removed {
+			// the waiter has been cancelled concurrently
+			w.bucket.refund(w.n)
+			continue
+		}
+		close(w.done)
+	}
+	g.mu.Lock()
+	children := make([]*BucketGroup, len(g.children))
+	copy(children, g.children)
+	g.mu.Unlock()
+	g.serveMu.Unlock()
+
+	for _, child := range children {
+		child.serveWaiters()
+	}
+}
+
+// ageWaitersLocked boosts the priority of the waiters according to the time
+// they have waited. The caller must hold g.mu.
+func (g *BucketGroup) ageWaitersLocked(now time.Time) {
+	if g.agingStep == 0 || g.agingInterval <= 0 || g.waiters.Len() == 0 {
+		return
+	}
+	var aged []*waiter
+	g.waiters.Ascend(func(i btree.Item) bool {
+		w := i.(*waiter)
+		boost := int64(now.Sub(w.enqueueTime)/g.agingInterval) * g.agingStep
+		if g.waiterOrder == WaiterOrderPriority && w.basePriority+boost != w.priority {
+			aged = append(aged, w)
+		}
+		return true
+	})
+	for _, w := range aged {
+		g.waiters.Delete(w)
+		boost := int64(now.Sub(w.enqueueTime)/g.agingInterval) * g.agingStep
+		w.priority = w.basePriority + boost
+		g.waiters.ReplaceOrInsert(w)
+		if boost > w.bucket.boost {
+			g.setBoostLocked(w.bucket, boost)
+		}
+	}
+}
+
+// failWaitersLocked wakes up all the waiters of a closed bucket.
+// The caller must hold g.mu.
+func (g *BucketGroup) failWaitersLocked(b *Bucket) {
+	var toFail []*waiter
+	g.waiters.Ascend(func(i btree.Item) bool {
+		if w := i.(*waiter); w.bucket == b {
+			toFail = append(toFail, w)
+		}
+		return true
+	})
+	for _, w := range toFail {
+		g.waiters.Delete(w)
+		w.err = cerror.ErrBucketClosed.GenWithStackByArgs()
+		close(w.done)
+	}
+}
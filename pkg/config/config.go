@@ -43,6 +43,11 @@ var defaultReplicaConfig = &ReplicaConfig{
 		Tp:          "table-number",
 		PollingTime: -1,
 	},
+	Retry: &RetryConfig{
+		MaxRestarts:   0,
+		HistoryWindow: 600,
+		Backoff:       []int{1, 5, 10, 30, 60, 120},
+	},
 }
 
 // ReplicaConfig represents some addition replication config for a changefeed
@@ -56,6 +61,13 @@ type replicaConfig struct {
 	Sink           *SinkConfig      `toml:"sink" json:"sink"`
 	Cyclic         *CyclicConfig    `toml:"cyclic-replication" json:"cyclic-replication"`
 	Scheduler      *SchedulerConfig `toml:"scheduler" json:"scheduler"`
+	Retry          *RetryConfig     `toml:"retry" json:"retry"`
+	// TimeZone is the time zone that the temporal columns are converted to,
+	// the time zone of the capture is used if it's empty.
+	TimeZone string `toml:"time-zone" json:"time-zone,omitempty"`
+	// FeatureGates overrides the states of the experimental features for the
+	// changefeed, see FeatureGates.
+	FeatureGates FeatureGates `toml:"feature-gates" json:"feature-gates,omitempty"`
 }
 
 // Marshal returns the json marshal format of a ReplicationConfig
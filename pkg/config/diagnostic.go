@@ -0,0 +1,88 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+)
+
+// DiagnosticLevel is the severity of a diagnostic
+type DiagnosticLevel string
+
+// All the diagnostic levels
+const (
+	// DiagnosticError is a config which can't work
+	DiagnosticError DiagnosticLevel = "error"
+	// DiagnosticWarning is a config which works but is likely a mistake
+	DiagnosticWarning DiagnosticLevel = "warning"
+)
+
+// Diagnostic is a problem of a config found by the validation
+type Diagnostic struct {
+	Level DiagnosticLevel `json:"level"`
+	// Field is the path of the config, e.g. sink.dispatchers
+	Field   string `json:"field"`
+	Message string `json:"message"`
+	// Hint tells the user how to fix the problem, it may be empty
+	Hint string `json:"hint,omitempty"`
+}
+
+func (d *Diagnostic) String() string {
+	s := fmt.Sprintf("[%s] %s: %s", d.Level, d.Field, d.Message)
+	if d.Hint != "" {
+		s += ", " + d.Hint
+	}
+	return s
+}
+
+// Diagnostics are the problems of a config
+type Diagnostics []*Diagnostic
+
+// Errorf appends an error of the field
+func (d *Diagnostics) Errorf(field, hint, format string, args ...interface{}) {
+	*d = append(*d, &Diagnostic{Level: DiagnosticError, Field: field, Message: fmt.Sprintf(format, args...), Hint: hint})
+}
+
+// Warnf appends a warning of the field
+func (d *Diagnostics) Warnf(field, hint, format string, args ...interface{}) {
+	*d = append(*d, &Diagnostic{Level: DiagnosticWarning, Field: field, Message: fmt.Sprintf(format, args...), Hint: hint})
+}
+
+// Filter returns the diagnostics of the level
+func (d Diagnostics) Filter(level DiagnosticLevel) Diagnostics {
+	var filtered Diagnostics
+	for _, diag := range d {
+		if diag.Level == level {
+			filtered = append(filtered, diag)
+		}
+	}
+	return filtered
+}
+
+// Err returns ErrInvalidConfig with all the errors of the diagnostics, it
+// returns nil if there are warnings only.
+func (d Diagnostics) Err() error {
+	errs := d.Filter(DiagnosticError)
+	if len(errs) == 0 {
+		return nil
+	}
+	msgs := make([]string, 0, len(errs))
+	for _, diag := range errs {
+		msgs = append(msgs, diag.String())
+	}
+	return cerror.ErrInvalidConfig.GenWithStackByArgs(strings.Join(msgs, "; "))
+}
@@ -0,0 +1,124 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+)
+
+// Feature is an experimental capability gated by the feature gates
+type Feature string
+
+// All the gated features
+const (
+	// FeatureFileSorter gates the file sort engine
+	FeatureFileSorter Feature = "file-sorter"
+	// FeatureAvroProtocol gates the avro protocol of the mq sinks
+	FeatureAvroProtocol Feature = "avro-protocol"
+	// FeatureMaxwellProtocol gates the maxwell protocol of the mq sinks
+	FeatureMaxwellProtocol Feature = "maxwell-protocol"
+	// FeatureCanalJSONProtocol gates the canal-json protocol of the mq sinks
+	FeatureCanalJSONProtocol Feature = "canal-json-protocol"
+)
+
+// defaultFeatures are the states of the features if they're not set. A new
+// feature is disabled by default until it's ready to be rolled out, and it's
+// removed from the gates once it's no longer experimental.
+var defaultFeatures = map[Feature]bool{
+	FeatureFileSorter:        true,
+	FeatureAvroProtocol:      true,
+	FeatureMaxwellProtocol:   true,
+	FeatureCanalJSONProtocol: true,
+}
+
+// Features returns all the gated features in order
+func Features() []Feature {
+	features := make([]Feature, 0, len(defaultFeatures))
+	for f := range defaultFeatures {
+		features = append(features, f)
+	}
+	sort.Slice(features, func(i, j int) bool { return features[i] < features[j] })
+	return features
+}
+
+// FeatureGates overrides the states of the features, the features not in it
+// are in their default states. The gates of a capture apply to all the
+// changefeeds it runs, and the gates of a changefeed apply to itself only, so
+// a feature is enabled for a changefeed only if both enable it.
+type FeatureGates map[Feature]bool
+
+// ParseFeatureGates parses the feature gates in the `feature=bool,...` format
+func ParseFeatureGates(s string) (FeatureGates, error) {
+	gates := make(FeatureGates)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		kv := strings.SplitN(item, "=", 2)
+		if len(kv) != 2 {
+			return nil, cerror.ErrInvalidFeatureGates.GenWithStackByArgs(item)
+		}
+		enabled, err := strconv.ParseBool(strings.TrimSpace(kv[1]))
+		if err != nil {
+			return nil, cerror.ErrInvalidFeatureGates.GenWithStackByArgs(item)
+		}
+		gates[Feature(strings.TrimSpace(kv[0]))] = enabled
+	}
+	if err := gates.Validate(); err != nil {
+		return nil, err
+	}
+	return gates, nil
+}
+
+// Validate checks all the features of the gates are known
+func (g FeatureGates) Validate() error {
+	for f := range g {
+		if _, ok := defaultFeatures[f]; !ok {
+			return cerror.ErrUnknownFeature.GenWithStackByArgs(f)
+		}
+	}
+	return nil
+}
+
+// Enabled returns whether the feature is enabled by the gates
+func (g FeatureGates) Enabled(f Feature) bool {
+	if enabled, ok := g[f]; ok {
+		return enabled
+	}
+	return defaultFeatures[f]
+}
+
+// Effective returns the states of all the features by the gates
+func (g FeatureGates) Effective() FeatureGates {
+	effective := make(FeatureGates, len(defaultFeatures))
+	for f := range defaultFeatures {
+		effective[f] = g.Enabled(f)
+	}
+	return effective
+}
+
+// String returns the gates in the `feature=bool,...` format
+func (g FeatureGates) String() string {
+	items := make([]string, 0, len(g))
+	for f, enabled := range g {
+		items = append(items, string(f)+"="+strconv.FormatBool(enabled))
+	}
+	sort.Strings(items)
+	return strings.Join(items, ",")
+}
@@ -24,4 +24,29 @@ type FilterConfig struct {
 	*filter.MySQLReplicationRules
 	IgnoreTxnStartTs []uint64           `toml:"ignore-txn-start-ts" json:"ignore-txn-start-ts"`
 	DDLAllowlist     []model.ActionType `toml:"ddl-allow-list" json:"ddl-allow-list"`
+	EventFilters     []*EventFilterRule `toml:"event-filters" json:"event-filters"`
+	RowFilters       []*RowFilterRule   `toml:"row-filters" json:"row-filters"`
+}
+
+// EventType is the type of a DML event
+type EventType string
+
+// DML event types
+const (
+	EventInsert EventType = "insert"
+	EventUpdate EventType = "update"
+	EventDelete EventType = "delete"
+)
+
+// EventFilterRule represents the DML event types ignored for the tables
+type EventFilterRule struct {
+	Matcher     []string    `toml:"matcher" json:"matcher"`
+	IgnoreEvent []EventType `toml:"ignore-event" json:"ignore-event"`
+}
+
+// RowFilterRule represents the rows replicated for the tables, which match the
+// SQL expression of the column values, e.g. "status != 'archived'".
+type RowFilterRule struct {
+	Matcher []string `toml:"matcher" json:"matcher"`
+	Expr    string   `toml:"expr" json:"expr"`
 }
@@ -0,0 +1,95 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"time"
+
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+)
+
+// RetryConfig is the policy the owner restarts a changefeed with after it
+// meets an error
+type RetryConfig struct {
+	// MaxRestarts is the max number of the automatic restarts in the history
+	// window, the changefeed is failed once it's exceeded, 0 is unlimited
+	MaxRestarts int `toml:"max-restarts" json:"max-restarts"`
+	// HistoryWindow is the seconds the errors are kept in the error history
+	HistoryWindow int `toml:"history-window" json:"history-window"`
+	// Backoff is the seconds waited before the restart after the n-th error
+	// in the error history, the last one is used for the subsequent errors
+	Backoff []int `toml:"backoff" json:"backoff"`
+	// AlwaysRetry are the RFC codes of the errors which are retried even if
+	// they're considered unretryable
+	AlwaysRetry []string `toml:"always-retry" json:"always-retry"`
+	// NeverRetry are the RFC codes of the errors which fail the changefeed
+	// at once
+	NeverRetry []string `toml:"never-retry" json:"never-retry"`
+}
+
+// Validate checks the retry policy
+func (c *RetryConfig) Validate() error {
+	if c.MaxRestarts < 0 {
+		return cerror.ErrRetryConfigInvalid.GenWithStackByArgs("max-restarts must not be negative")
+	}
+	if c.HistoryWindow <= 0 {
+		return cerror.ErrRetryConfigInvalid.GenWithStackByArgs("history-window must be positive")
+	}
+	for _, backoff := range c.Backoff {
+		if backoff < 0 {
+			return cerror.ErrRetryConfigInvalid.GenWithStackByArgs("backoff must not be negative")
+		}
+	}
+	for _, code := range c.AlwaysRetry {
+		for _, never := range c.NeverRetry {
+			if code == never {
+				return cerror.ErrRetryConfigInvalid.GenWithStackByArgs(code + " is both always and never retried")
+			}
+		}
+	}
+	return nil
+}
+
+// GetHistoryWindow returns the duration the errors are kept in the error history
+func (c *RetryConfig) GetHistoryWindow() time.Duration {
+	return time.Duration(c.HistoryWindow) * time.Second
+}
+
+// GetBackoff returns the duration waited before the restart after the n-th
+// error, n starts from 1.
+func (c *RetryConfig) GetBackoff(n int) time.Duration {
+	if n <= 0 || len(c.Backoff) == 0 {
+		return 0
+	}
+	if n > len(c.Backoff) {
+		n = len(c.Backoff)
+	}
+	return time.Duration(c.Backoff[n-1]) * time.Second
+}
+
+// ShouldRetry returns whether the error of the RFC code is retried, retryable
+// is the default classification of the error.
+func (c *RetryConfig) ShouldRetry(code string, retryable bool) bool {
+	for _, never := range c.NeverRetry {
+		if code == never {
+			return false
+		}
+	}
+	for _, always := range c.AlwaysRetry {
+		if code == always {
+			return true
+		}
+	}
+	return retryable
+}
@@ -17,6 +17,19 @@ package config
 type SinkConfig struct {
 	DispatchRules []*DispatchRule `toml:"dispatchers" json:"dispatchers"`
 	Protocol      string          `toml:"protocol" json:"protocol"`
+	RouteRules    []*RouteRule    `toml:"route-rules" json:"route-rules"`
+	// ColumnTransforms are applied to the rows before they're encoded by any sink
+	ColumnTransforms []*ColumnTransformRule `toml:"column-transforms" json:"column-transforms"`
+	// Throughput limits the rows emitted to the sink, it's unlimited if nil
+	Throughput *ThroughputConfig `toml:"throughput" json:"throughput,omitempty"`
+}
+
+// ThroughputConfig is the max throughput of the sink of a changefeed in each
+// capture, so that a changefeed catching up doesn't saturate the downstream
+// shared with the other changefeeds. A limit of 0 is unlimited.
+type ThroughputConfig struct {
+	RowsPerSecond  int64 `toml:"rows-per-second" json:"rows-per-second"`
+	BytesPerSecond int64 `toml:"bytes-per-second" json:"bytes-per-second"`
 }
 
 // DispatchRule represents partition rule for a table
@@ -24,3 +37,39 @@ type DispatchRule struct {
 	Matcher    []string `toml:"matcher" json:"matcher"`
 	Dispatcher string   `toml:"dispatcher" json:"dispatcher"`
 }
+
+// RouteRule represents the downstream schema and table of the upstream tables,
+// the empty target schema or table keeps the upstream name. Multiple upstream
+// tables can be routed to the same downstream table to merge the shards.
+type RouteRule struct {
+	Matcher      []string `toml:"matcher" json:"matcher"`
+	TargetSchema string   `toml:"target-schema" json:"target-schema"`
+	TargetTable  string   `toml:"target-table" json:"target-table"`
+}
+
+// TransformType is the type of a column transformation
+type TransformType string
+
+// Column transformation types
+const (
+	// TransformHash replaces the value with the SHA-256 of it
+	TransformHash TransformType = "hash"
+	// TransformRedact replaces every character of the value with '*'
+	TransformRedact TransformType = "redact"
+	// TransformTruncate keeps the leading characters of the value
+	TransformTruncate TransformType = "truncate"
+	// TransformConstant replaces the value with a constant
+	TransformConstant TransformType = "constant"
+)
+
+// ColumnTransformRule represents the transformation of the column values for
+// the tables, the NULL values are kept.
+type ColumnTransformRule struct {
+	Matcher []string      `toml:"matcher" json:"matcher"`
+	Columns []string      `toml:"columns" json:"columns"`
+	Type    TransformType `toml:"type" json:"type"`
+	// Length is the number of the characters kept by truncate
+	Length int `toml:"length" json:"length"`
+	// Value is the constant of constant
+	Value string `toml:"value" json:"value"`
+}
@@ -0,0 +1,86 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pingcap/ticdc/pkg/util"
+)
+
+// MinSortDirAvailable is the min bytes available in the file system of the
+// sort dir recommended, the file sorters of the tables spill into it while
+// the changefeed is catching up.
+const MinSortDirAvailable = 10 * 1024 * 1024 * 1024
+
+// SorterConfig is the sorter of the tables of a changefeed
+type SorterConfig struct {
+	// Engine is memory or file
+	Engine string
+	// Dir is the directory the file sorter spills into
+	Dir string
+}
+
+// Validate checks the sorter config. The sort dir is checked on the local host
+// if local is set, which should be done by the captures running the sorters.
+func (c *SorterConfig) Validate(local bool) Diagnostics {
+	var diags Diagnostics
+	switch c.Engine {
+	case "memory":
+		return diags
+	case "file":
+	default:
+		diags.Errorf("sort-engine", "use memory or file", "unknown sort engine %s", c.Engine)
+		return diags
+	}
+	if c.Dir == "" {
+		diags.Errorf("sort-dir", "set the directory the file sorter spills into", "the sort dir of the file sorter is empty")
+		return diags
+	}
+	if !local {
+		return diags
+	}
+	// the sort dir is created on demand, the capacity of its nearest existing
+	// ancestor is checked instead
+	dir := c.Dir
+	st, err := os.Stat(dir)
+	for os.IsNotExist(err) && filepath.Dir(dir) != dir {
+		dir = filepath.Dir(dir)
+		st, err = os.Stat(dir)
+	}
+	if err != nil {
+		diags.Errorf("sort-dir", "", "%s", err)
+		return diags
+	}
+	if !st.IsDir() {
+		diags.Errorf("sort-dir", "use a directory", "%s is not a directory", dir)
+		return diags
+	}
+	if dir == c.Dir {
+		if err := util.IsDirWritable(dir); err != nil {
+			diags.Errorf("sort-dir", "grant the write permission to the user of the capture", "%s", err)
+			return diags
+		}
+	}
+	available, err := util.GetDiskAvailableSpace(dir)
+	if err != nil {
+		diags.Warnf("sort-dir", "", "the available space is unknown: %s", err)
+	} else if available < MinSortDirAvailable {
+		diags.Warnf("sort-dir", fmt.Sprintf("use a directory with at least %d bytes available", MinSortDirAvailable),
+			"only %d bytes are available, the file sorter may exhaust them while catching up", available)
+	}
+	return diags
+}
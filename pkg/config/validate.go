@@ -0,0 +1,132 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/ticdc/pkg/util"
+)
+
+// maxMounterWorkerNum is the max number of the mounter workers recommended,
+// more workers take more memory without speeding up the mounting.
+const maxMounterWorkerNum = 256
+
+var (
+	// sinkProtocols are the protocols of the mq sinks
+	sinkProtocols = []string{"default", "canal", "avro", "maxwell", "canal-json"}
+	// dispatchers are the dispatchers of the rows to the partitions
+	dispatchers = []string{"default", "rowid", "ts", "table", "index-value"}
+)
+
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// ProtocolRequiresOldValue returns whether the messages of the protocol need
+// the old values of the rows.
+func ProtocolRequiresOldValue(protocol string) bool {
+	protocol = strings.ToLower(protocol)
+	return protocol == "canal" || protocol == "canal-json"
+}
+
+// Validate checks the replica config, including the constraints across its
+// fields, and returns the problems found.
+func (c *ReplicaConfig) Validate() Diagnostics {
+	var diags Diagnostics
+	if c.Mounter != nil {
+		switch n := c.Mounter.WorkerNum; {
+		case n <= 0:
+			diags.Errorf("mounter.worker-num", "set it to a positive number, e.g. 16", "%d workers can't mount any row", n)
+		case n > maxMounterWorkerNum:
+			diags.Warnf("mounter.worker-num", fmt.Sprintf("set it to at most %d", maxMounterWorkerNum),
+				"%d workers take more memory without speeding up the mounting", n)
+		}
+	}
+	if c.Retry != nil {
+		if err := c.Retry.Validate(); err != nil {
+			diags.Errorf("retry", "", "%s", err)
+		}
+	}
+	if err := c.FeatureGates.Validate(); err != nil {
+		diags.Errorf("feature-gates", "the features are "+featureNames(), "%s", err)
+	}
+	if c.TimeZone != "" {
+		if _, err := util.GetTimezone(c.TimeZone); err != nil {
+			diags.Errorf("time-zone", "use a name of the IANA time zone database, e.g. Asia/Shanghai", "%s", err)
+		}
+	}
+	if c.Cyclic.IsEnabled() && c.Cyclic.ReplicaID == 0 {
+		diags.Errorf("cyclic-replication.replica-id", "set a nonzero replica ID unique among the clusters",
+			"the replica ID of cyclic replication is 0")
+	}
+	c.validateSink(&diags)
+	return diags
+}
+
+func (c *ReplicaConfig) validateSink(diags *Diagnostics) {
+	if c.Sink == nil {
+		return
+	}
+	if c.Sink.Protocol != "" && !contains(sinkProtocols, c.Sink.Protocol) {
+		diags.Errorf("sink.protocol", "use one of "+strings.Join(sinkProtocols, ", "), "unknown protocol %s", c.Sink.Protocol)
+	}
+	oldValue := c.EnableOldValue || ProtocolRequiresOldValue(c.Sink.Protocol)
+	for i, rule := range c.Sink.DispatchRules {
+		field := fmt.Sprintf("sink.dispatchers[%d]", i)
+		if len(rule.Matcher) == 0 {
+			diags.Errorf(field+".matcher", "set the table filter rules of the tables dispatched", "no table is matched")
+		}
+		switch d := strings.ToLower(rule.Dispatcher); {
+		case !contains(dispatchers, d):
+			diags.Errorf(field+".dispatcher", "use one of "+strings.Join(dispatchers, ", "), "unknown dispatcher %s", rule.Dispatcher)
+		case (d == "rowid" || d == "index-value") && oldValue:
+			diags.Warnf(field+".dispatcher", "use the default or the table dispatcher",
+				"the rows of the same key may be out of order with old value, which is enabled or required by the protocol")
+		}
+	}
+	if t := c.Sink.Throughput; t != nil && (t.RowsPerSecond < 0 || t.BytesPerSecond < 0) {
+		diags.Errorf("sink.throughput", "set it to 0 for unlimited", "the limit %d rows/s, %d bytes/s is negative",
+			t.RowsPerSecond, t.BytesPerSecond)
+	}
+	for i, rule := range c.Sink.ColumnTransforms {
+		field := fmt.Sprintf("sink.column-transforms[%d]", i)
+		if len(rule.Columns) == 0 {
+			diags.Warnf(field+".columns", "set the columns transformed", "no column is transformed")
+		}
+		switch rule.Type {
+		case TransformHash, TransformRedact, TransformConstant:
+		case TransformTruncate:
+			if rule.Length <= 0 {
+				diags.Errorf(field+".length", "set the number of the characters kept", "the length %d of truncate is not positive", rule.Length)
+			}
+		default:
+			diags.Errorf(field+".type", "use one of hash, redact, truncate, constant", "unknown transform type %s", rule.Type)
+		}
+	}
+}
+
+func featureNames() string {
+	names := make([]string, 0, len(defaultFeatures))
+	for _, f := range Features() {
+		names = append(names, string(f))
+	}
+	return strings.Join(names, ", ")
+}
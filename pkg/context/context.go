@@ -0,0 +1,141 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package context contains the utilities of the contexts passed through the
+// pipelines, which are the sorters, the sinks and the queues between them.
+//
+// A context may be in an async section, where the caller runs in an event
+// loop and must not block, so the blocking operations fail with ErrWouldBlock
+// instead of waiting. A context may also carry the stage it's bounded by, so
+// the timeout of the stage can be told from the cancellation of its parent.
+package context
+
+import (
+	"context"
+	"time"
+
+	"github.com/pingcap/errors"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+	"github.com/pingcap/ticdc/pkg/util"
+	"go.uber.org/zap"
+)
+
+type ctxKey string
+
+const (
+	ctxKeyAsync = ctxKey("async")
+	ctxKeyStage = ctxKey("stage")
+)
+
+// WithAsync returns a context in an async section, the blocking operations
+// under it return ErrWouldBlock instead of waiting.
+func WithAsync(ctx context.Context) context.Context {
+	return context.WithValue(ctx, ctxKeyAsync, true)
+}
+
+// WithSync returns a context in a sync section, which is used by the
+// operations that must wait even if they're called in an async section, e.g.
+// flushing a sink.
+func WithSync(ctx context.Context) context.Context {
+	if !IsAsync(ctx) {
+		return ctx
+	}
+	return context.WithValue(ctx, ctxKeyAsync, false)
+}
+
+// IsAsync returns whether the context is in an async section.
+func IsAsync(ctx context.Context) bool {
+	async, ok := ctx.Value(ctxKeyAsync).(bool)
+	return ok && async
+}
+
+// Wait blocks until ch is readable or ctx is done. In an async section it
+// returns ErrWouldBlock at once if ch is not readable.
+func Wait(ctx context.Context, ch <-chan struct{}) error {
+	if IsAsync(ctx) {
+		select {
+		case <-ch:
+			return nil
+		default:
+			return cerror.ErrWouldBlock.GenWithStackByArgs()
+		}
+	}
+	select {
+	case <-ctx.Done():
+		return errors.Trace(ctx.Err())
+	case <-ch:
+		return nil
+	}
+}
+
+type stage struct {
+	name     string
+	timeout  time.Duration
+	deadline time.Time
+}
+
+// WithStageTimeout derives a context bounded by the timeout of a stage, whose
+// deadline is the earlier one of the parent's and the stage's.
+func WithStageTimeout(ctx context.Context, name string, timeout time.Duration) (context.Context, context.CancelFunc) {
+	deadline := time.Now().Add(timeout)
+	ctx = context.WithValue(ctx, ctxKeyStage, &stage{name: name, timeout: timeout, deadline: deadline})
+	return context.WithDeadline(ctx, deadline)
+}
+
+// StageErr returns ErrStageTimeout if err is caused by the timeout of the
+// innermost stage of ctx, otherwise err is returned as it is.
+func StageErr(ctx context.Context, err error) error {
+	if errors.Cause(err) != context.DeadlineExceeded {
+		return err
+	}
+	s, ok := ctx.Value(ctxKeyStage).(*stage)
+	if !ok || time.Now().Before(s.deadline) {
+		return err
+	}
+	return cerror.ErrStageTimeout.GenWithStackByArgs(s.name, s.timeout)
+}
+
+// detached carries the values of its parent, but neither its deadline nor its
+// cancellation.
+type detached struct {
+	context.Context
+}
+
+func (detached) Deadline() (time.Time, bool) { return time.Time{}, false }
+
+func (detached) Done() <-chan struct{} { return nil }
+
+func (detached) Err() error { return nil }
+
+// Detach returns a context with the values of ctx which is never canceled,
+// it's used by the work which must be done after ctx is canceled, e.g.
+// recording an action which has been applied.
+func Detach(ctx context.Context) context.Context {
+	return detached{ctx}
+}
+
+// ZapFields returns the log fields of the capture, the changefeed and the
+// table the context is scoped to, the unset ones are omitted.
+func ZapFields(ctx context.Context) []zap.Field {
+	var fields []zap.Field
+	if addr := util.CaptureAddrFromCtx(ctx); addr != "" {
+		fields = append(fields, zap.String("capture", addr))
+	}
+	if changefeedID := util.ChangefeedIDFromCtx(ctx); changefeedID != "" {
+		fields = append(fields, zap.String("changefeed", changefeedID))
+	}
+	if tableID, tableName := util.TableIDFromCtx(ctx); tableName != "" || tableID != 0 {
+		fields = append(fields, zap.Int64("tableID", tableID), zap.String("table", tableName))
+	}
+	return fields
+}
@@ -0,0 +1,112 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pingcap/check"
+	"github.com/pingcap/errors"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+	"github.com/pingcap/ticdc/pkg/util"
+)
+
+func Test(t *testing.T) {
+	check.TestingT(t)
+}
+
+type contextSuite struct{}
+
+var _ = check.Suite(&contextSuite{})
+
+func (s *contextSuite) TestAsyncSection(c *check.C) {
+	ctx := context.Background()
+	c.Assert(IsAsync(ctx), check.IsFalse)
+	c.Assert(WithSync(ctx), check.Equals, ctx)
+	asyncCtx := WithAsync(ctx)
+	c.Assert(IsAsync(asyncCtx), check.IsTrue)
+	c.Assert(IsAsync(WithSync(asyncCtx)), check.IsFalse)
+	c.Assert(IsAsync(WithAsync(WithSync(asyncCtx))), check.IsTrue)
+
+	ch := make(chan struct{}, 1)
+	err := Wait(asyncCtx, ch)
+	c.Assert(cerror.ErrWouldBlock.Equal(err), check.IsTrue)
+	ch <- struct{}{}
+	c.Assert(Wait(asyncCtx, ch), check.IsNil)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		ch <- struct{}{}
+	}()
+	c.Assert(Wait(WithSync(asyncCtx), ch), check.IsNil)
+
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	err = Wait(cctx, ch)
+	c.Assert(errors.Cause(err), check.Equals, context.Canceled)
+}
+
+func (s *contextSuite) TestStageTimeout(c *check.C) {
+	ctx := context.Background()
+	stageCtx, cancel := WithStageTimeout(ctx, "flush", 10*time.Millisecond)
+	defer cancel()
+	<-stageCtx.Done()
+	err := StageErr(stageCtx, errors.Trace(stageCtx.Err()))
+	c.Assert(cerror.ErrStageTimeout.Equal(err), check.IsTrue)
+	c.Assert(err, check.ErrorMatches, ".*stage flush timed out after 10ms.*")
+
+	// the deadline of the parent is earlier than the stage's
+	parentCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	stageCtx, cancel = WithStageTimeout(parentCtx, "flush", time.Hour)
+	defer cancel()
+	<-stageCtx.Done()
+	err = StageErr(stageCtx, stageCtx.Err())
+	c.Assert(err, check.Equals, context.DeadlineExceeded)
+
+	// the other errors are returned as they are
+	err = errors.New("test")
+	c.Assert(StageErr(stageCtx, err), check.Equals, err)
+}
+
+func (s *contextSuite) TestDetach(c *check.C) {
+	ctx, cancel := context.WithCancel(util.PutChangefeedIDInCtx(context.Background(), "test-cf"))
+	cancel()
+	detached := Detach(ctx)
+	c.Assert(detached.Err(), check.IsNil)
+	_, ok := detached.Deadline()
+	c.Assert(ok, check.IsFalse)
+	c.Assert(util.ChangefeedIDFromCtx(detached), check.Equals, "test-cf")
+
+	cctx, cancel := context.WithTimeout(detached, 10*time.Millisecond)
+	defer cancel()
+	<-cctx.Done()
+	c.Assert(cctx.Err(), check.Equals, context.DeadlineExceeded)
+}
+
+func (s *contextSuite) TestZapFields(c *check.C) {
+	ctx := context.Background()
+	c.Assert(ZapFields(ctx), check.HasLen, 0)
+	ctx = util.PutCaptureAddrInCtx(ctx, "127.0.0.1:8300")
+	ctx = util.PutChangefeedIDInCtx(ctx, "test-cf")
+	c.Assert(ZapFields(ctx), check.HasLen, 2)
+	ctx = util.PutTableInfoInCtx(ctx, 1, "test.t")
+	fields := ZapFields(ctx)
+	c.Assert(fields, check.HasLen, 4)
+	c.Assert(fields[1].String, check.Equals, "test-cf")
+	c.Assert(fields[2].Integer, check.Equals, int64(1))
+	c.Assert(fields[3].String, check.Equals, "test.t")
+}
@@ -0,0 +1,294 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diagnostics
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/ticdc/pkg/buckets"
+	"go.uber.org/zap"
+)
+
+const (
+	heapProfilerCheckInterval = time.Second
+
+	// the RSS is under pressure once it reaches 90% of the memory limit
+	defaultPressureRatio = 0.9
+	// a profile is captured once the pressure lasts for 30s
+	defaultSustainDuration = 30 * time.Second
+	// at most one profile is captured every 10 minutes
+	defaultProfileInterval = 10 * time.Minute
+	// the profiles other than the latest 5 ones are removed
+	defaultMaxProfiles = 5
+
+	heapProfilePrefix  = "heap-"
+	heapProfileSuffix  = ".pprof"
+	memoryReportSuffix = ".json"
+	profileTimeFormat  = "20060102-150405"
+)
+
+// MemoryReport is the memory usage of the process when a heap profile is
+// captured, it's saved along with the profile.
+type MemoryReport struct {
+	Time  time.Time `json:"time"`
+	RSS   int64     `json:"rss"`
+	Limit int64     `json:"limit"`
+	// the statistics of the go runtime, see runtime.MemStats
+	HeapAlloc  uint64 `json:"heap_alloc"`
+	HeapInuse  uint64 `json:"heap_inuse"`
+	HeapSys    uint64 `json:"heap_sys"`
+	StackInuse uint64 `json:"stack_inuse"`
+	Sys        uint64 `json:"sys"`
+	NumGC      uint32 `json:"num_gc"`
+	// Components is the memory acquired by each subsystem, e.g. the sorter,
+	// the sink buffers and the kv client buffers.
+	Components map[string]int64 `json:"components,omitempty"`
+	// Buckets is the state of the memory quota of all the subsystems
+	Buckets *buckets.GroupSnapshot `json:"buckets,omitempty"`
+}
+
+// HeapProfiler watches the RSS of the process against the memory limit, and
+// captures a heap profile and a MemoryReport into the diagnostics directory
+// once the RSS stays close to the limit, or at once when the memory manager
+// reports the critical pressure, for the post-mortem analysis of the memory
+// issues.
+type HeapProfiler struct {
+	dir           string
+	limit         int64
+	memoryManager *buckets.GlobalMemoryManager
+
+	pressureRatio   float64
+	sustainDuration time.Duration
+	profileInterval time.Duration
+	maxProfiles     int
+
+	readRSS func() (int64, error)
+
+	pressureSince time.Time
+	lastProfile   time.Time
+}
+
+// NewHeapProfiler creates a HeapProfiler saving the profiles into dir, the
+// memoryManager is used to report the memory usage of the subsystems, which
+// can be nil.
+func NewHeapProfiler(dir string, limit int64, memoryManager *buckets.GlobalMemoryManager) *HeapProfiler {
+	return &HeapProfiler{
+		dir:             dir,
+		limit:           limit,
+		memoryManager:   memoryManager,
+		pressureRatio:   defaultPressureRatio,
+		sustainDuration: defaultSustainDuration,
+		profileInterval: defaultProfileInterval,
+		maxProfiles:     defaultMaxProfiles,
+		readRSS:         readProcessRSS,
+	}
+}
+
+// Run checks the RSS periodically until ctx is done.
+func (p *HeapProfiler) Run(ctx context.Context) error {
+	if err := os.MkdirAll(p.dir, 0755); err != nil {
+		return errors.Trace(err)
+	}
+	ticker := time.NewTicker(heapProfilerCheckInterval)
+	defer ticker.Stop()
+	// pressureCh stays nil without a memory manager
+	var pressureCh <-chan struct{}
+	if p.memoryManager != nil {
+		receiver := p.memoryManager.Subscribe()
+		defer receiver.Stop()
+		pressureCh = receiver.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return errors.Trace(ctx.Err())
+		case now := <-ticker.C:
+			p.check(now)
+		case <-pressureCh:
+			p.checkPressure(time.Now())
+		}
+	}
+}
+
+// checkPressure captures a profile without waiting for the RSS pressure to
+// last if the memory manager reports the critical pressure, the buffers are
+// about to exceed the budget and the process may be killed soon.
+func (p *HeapProfiler) checkPressure(now time.Time) {
+	if p.memoryManager.Pressure() != buckets.MemoryPressureCritical {
+		return
+	}
+	rss, err := p.readRSS()
+	if err != nil {
+		log.Warn("read the RSS of the process failed", zap.Error(err))
+		return
+	}
+	p.profile(now, rss)
+}
+
+// check captures a profile if the pressure is sustained, the errors are
+// logged only since profiling is best effort.
+func (p *HeapProfiler) check(now time.Time) {
+	rss, err := p.readRSS()
+	if err != nil {
+		log.Warn("read the RSS of the process failed", zap.Error(err))
+		return
+	}
+	if float64(rss) < float64(p.limit)*p.pressureRatio {
+		p.pressureSince = time.Time{}
+		return
+	}
+	if p.pressureSince.IsZero() {
+		p.pressureSince = now
+	}
+	if now.Sub(p.pressureSince) < p.sustainDuration {
+		return
+	}
+	p.profile(now, rss)
+}
+
+// profile captures a profile unless one is captured in the profile interval.
+func (p *HeapProfiler) profile(now time.Time, rss int64) {
+	if !p.lastProfile.IsZero() && now.Sub(p.lastProfile) < p.profileInterval {
+		return
+	}
+	p.lastProfile = now
+	if err := p.capture(now, rss); err != nil {
+		log.Warn("capture the heap profile failed", zap.String("dir", p.dir), zap.Error(err))
+		return
+	}
+	if err := p.removeStaleProfiles(); err != nil {
+		log.Warn("remove the stale heap profiles failed", zap.String("dir", p.dir), zap.Error(err))
+	}
+}
+
+func (p *HeapProfiler) capture(now time.Time, rss int64) error {
+	name := heapProfilePrefix + now.Format(profileTimeFormat)
+	f, err := os.Create(filepath.Join(p.dir, name+heapProfileSuffix))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	err = pprof.Lookup("heap").WriteTo(f, 0)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	report := p.report(now, rss)
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(p.dir, name+memoryReportSuffix), data, 0644); err != nil {
+		return errors.Trace(err)
+	}
+	log.Warn("the memory usage is close to the limit, a heap profile is captured",
+		zap.String("profile", filepath.Join(p.dir, name+heapProfileSuffix)),
+		zap.Int64("rss", rss), zap.Int64("limit", p.limit),
+		zap.Reflect("components", report.Components))
+	return nil
+}
+
+func (p *HeapProfiler) report(now time.Time, rss int64) *MemoryReport {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	report := &MemoryReport{
+		Time:       now,
+		RSS:        rss,
+		Limit:      p.limit,
+		HeapAlloc:  stats.HeapAlloc,
+		HeapInuse:  stats.HeapInuse,
+		HeapSys:    stats.HeapSys,
+		StackInuse: stats.StackInuse,
+		Sys:        stats.Sys,
+		NumGC:      stats.NumGC,
+	}
+	if p.memoryManager != nil {
+		report.Components = p.memoryManager.UsedBySubsystem()
+		snap := p.memoryManager.Snapshot()
+		report.Buckets = &snap
+	}
+	return report
+}
+
+// removeStaleProfiles keeps the latest maxProfiles profiles and their reports.
+func (p *HeapProfiler) removeStaleProfiles() error {
+	files, err := ioutil.ReadDir(p.dir)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	var names []string
+	for _, file := range files {
+		name := file.Name()
+		if strings.HasPrefix(name, heapProfilePrefix) && strings.HasSuffix(name, heapProfileSuffix) {
+			names = append(names, strings.TrimSuffix(name, heapProfileSuffix))
+		}
+	}
+	if len(names) <= p.maxProfiles {
+		return nil
+	}
+	// the names are ordered by the time they are captured
+	sort.Strings(names)
+	for _, name := range names[:len(names)-p.maxProfiles] {
+		for _, suffix := range []string{heapProfileSuffix, memoryReportSuffix} {
+			err := os.Remove(filepath.Join(p.dir, name+suffix))
+			if err != nil && !os.IsNotExist(err) {
+				return errors.Trace(err)
+			}
+		}
+	}
+	return nil
+}
+
+// readProcessRSS reads the resident set size of the process from procfs, the
+// memory obtained from the OS by the go runtime is used if procfs is absent.
+func readProcessRSS() (int64, error) {
+	data, err := ioutil.ReadFile("/proc/self/statm")
+	if os.IsNotExist(err) {
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+		return int64(stats.Sys), nil
+	}
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	return parseStatmRSS(string(data), int64(os.Getpagesize()))
+}
+
+// parseStatmRSS parses the content of /proc/[pid]/statm, whose second field is
+// the number of the resident pages.
+func parseStatmRSS(s string, pageSize int64) (int64, error) {
+	fields := strings.Fields(s)
+	if len(fields) < 2 {
+		return 0, errors.Errorf("invalid statm %q", s)
+	}
+	pages, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return 0, errors.Annotatef(err, "invalid statm %q", s)
+	}
+	return pages * pageSize, nil
+}
@@ -0,0 +1,139 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diagnostics
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/pingcap/check"
+	"github.com/pingcap/ticdc/pkg/buckets"
+)
+
+func Test(t *testing.T) {
+	check.TestingT(t)
+}
+
+type heapProfilerSuite struct{}
+
+var _ = check.Suite(&heapProfilerSuite{})
+
+func (s *heapProfilerSuite) TestCaptureUnderSustainedPressure(c *check.C) {
+	dir := c.MkDir()
+	m, err := buckets.NewGlobalMemoryManager(1000)
+	c.Assert(err, check.IsNil)
+	b, err := m.Group(buckets.MemorySubsystemSorter).CreateBucketWithMode(buckets.BucketModeResidency, 0, 1000, 0)
+	c.Assert(err, check.IsNil)
+	c.Assert(b.TryAcquire(100), check.IsTrue)
+
+	p := NewHeapProfiler(dir, 1000, m)
+	p.maxProfiles = 2
+	rss := int64(950)
+	p.readRSS = func() (int64, error) { return rss, nil }
+	profiles := func() []string {
+		names, err := filepath.Glob(filepath.Join(dir, heapProfilePrefix+"*"))
+		c.Assert(err, check.IsNil)
+		sort.Strings(names)
+		return names
+	}
+
+	start := time.Date(2020, 10, 1, 12, 0, 0, 0, time.Local)
+	p.check(start)
+	p.check(start.Add(10 * time.Second))
+	c.Assert(profiles(), check.HasLen, 0)
+
+	// the pressure is interrupted
+	rss = 800
+	p.check(start.Add(20 * time.Second))
+	rss = 950
+	p.check(start.Add(40 * time.Second))
+	c.Assert(profiles(), check.HasLen, 0)
+
+	p.check(start.Add(70 * time.Second))
+	names := profiles()
+	c.Assert(names, check.DeepEquals, []string{
+		filepath.Join(dir, "heap-20201001-120110.json"),
+		filepath.Join(dir, "heap-20201001-120110.pprof"),
+	})
+	data, err := ioutil.ReadFile(names[0])
+	c.Assert(err, check.IsNil)
+	report := new(MemoryReport)
+	c.Assert(json.Unmarshal(data, report), check.IsNil)
+	c.Assert(report.RSS, check.Equals, int64(950))
+	c.Assert(report.Limit, check.Equals, int64(1000))
+	c.Assert(report.Components[buckets.MemorySubsystemSorter], check.Equals, int64(100))
+	c.Assert(report.Buckets, check.NotNil)
+
+	// the profiles are captured at most once every profile interval
+	p.check(start.Add(80 * time.Second))
+	c.Assert(profiles(), check.HasLen, 2)
+
+	// the stale profiles are removed
+	p.check(start.Add(70*time.Second + defaultProfileInterval))
+	p.check(start.Add(70*time.Second + 2*defaultProfileInterval))
+	c.Assert(profiles(), check.DeepEquals, []string{
+		filepath.Join(dir, "heap-20201001-121110.json"),
+		filepath.Join(dir, "heap-20201001-121110.pprof"),
+		filepath.Join(dir, "heap-20201001-122110.json"),
+		filepath.Join(dir, "heap-20201001-122110.pprof"),
+	})
+}
+
+func (s *heapProfilerSuite) TestCaptureUnderCriticalPressure(c *check.C) {
+	dir := c.MkDir()
+	m, err := buckets.NewGlobalMemoryManager(1000)
+	c.Assert(err, check.IsNil)
+	b, err := m.Group(buckets.MemorySubsystemSorter).CreateBucketWithMode(buckets.BucketModeResidency, 0, 1000, 0)
+	c.Assert(err, check.IsNil)
+
+	p := NewHeapProfiler(dir, 1000, m)
+	// the RSS is far from the limit, the profile is captured by the pressure
+	p.readRSS = func() (int64, error) { return 100, nil }
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = m.Run(ctx) }()
+	go func() { _ = p.Run(ctx) }()
+
+	b.ForceAcquire(1000)
+	for i := 0; ; i++ {
+		names, err := filepath.Glob(filepath.Join(dir, heapProfilePrefix+"*"+heapProfileSuffix))
+		c.Assert(err, check.IsNil)
+		if len(names) == 1 {
+			break
+		}
+		if i > 50 {
+			c.Fatal("no profile is captured under the critical pressure")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func (s *heapProfilerSuite) TestParseStatmRSS(c *check.C) {
+	rss, err := parseStatmRSS("2000 300 100 10 0 500 0\n", 4096)
+	c.Assert(err, check.IsNil)
+	c.Assert(rss, check.Equals, int64(300*4096))
+	_, err = parseStatmRSS("2000", 4096)
+	c.Assert(err, check.NotNil)
+	_, err = parseStatmRSS("2000 x", 4096)
+	c.Assert(err, check.NotNil)
+
+	rss, err = readProcessRSS()
+	c.Assert(err, check.IsNil)
+	c.Assert(rss, check.Greater, int64(0))
+}
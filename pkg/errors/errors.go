@@ -36,6 +36,7 @@ var (
 	ErrGetTiKVRPCContext       = errors.Normalize("get tikv grpc context failed", errors.RFCCodeText("CDC:ErrGetTiKVRPCContext"))
 	ErrPendingRegionCancel     = errors.Normalize("pending region cancelled due to stream disconnecting", errors.RFCCodeText("CDC:ErrPendingRegionCancel"))
 	ErrEventFeedAborted        = errors.Normalize("single event feed aborted", errors.RFCCodeText("CDC:ErrEventFeedAborted"))
+	ErrStoreBreakerOpen        = errors.Normalize("circuit breaker of store %s is open", errors.RFCCodeText("CDC:ErrStoreBreakerOpen"))
 	ErrUnknownKVEventType      = errors.Normalize("unknown kv event type: %v, entry: %v", errors.RFCCodeText("CDC:ErrUnknownKVEventType"))
 	ErrNoPendingRegion         = errors.Normalize("received event regionID %v, requestID %v from %v,"+
 		" but neither pending region nor running region was found", errors.RFCCodeText("CDC:ErrNoPendingRegion"))
@@ -55,9 +56,17 @@ var (
 	ErrNewStore               = errors.Normalize("new store faile", errors.RFCCodeText("CDC:ErrNewStore"))
 
 	// rule related errors
-	ErrEncodeFailed      = errors.Normalize("encode failed: %s", errors.RFCCodeText("CDC:ErrEncodeFailed"))
-	ErrDecodeFailed      = errors.Normalize("decode failed: %s", errors.RFCCodeText("CDC:ErrDecodeFailed"))
-	ErrFilterRuleInvalid = errors.Normalize("filter rule is invalid", errors.RFCCodeText("CDC:ErrFilterRuleInvalid"))
+	ErrEncodeFailed           = errors.Normalize("encode failed: %s", errors.RFCCodeText("CDC:ErrEncodeFailed"))
+	ErrDecodeFailed           = errors.Normalize("decode failed: %s", errors.RFCCodeText("CDC:ErrDecodeFailed"))
+	ErrFilterRuleInvalid      = errors.Normalize("filter rule is invalid", errors.RFCCodeText("CDC:ErrFilterRuleInvalid"))
+	ErrEvalRowFilter          = errors.Normalize("evaluate the row filter failed", errors.RFCCodeText("CDC:ErrEvalRowFilter"))
+	ErrRouteRuleInvalid       = errors.Normalize("route rule is invalid", errors.RFCCodeText("CDC:ErrRouteRuleInvalid"))
+	ErrRouteDDL               = errors.Normalize("route the DDL failed", errors.RFCCodeText("CDC:ErrRouteDDL"))
+	ErrColumnTransformInvalid = errors.Normalize("column transform rule is invalid", errors.RFCCodeText("CDC:ErrColumnTransformInvalid"))
+	ErrTransformColumn        = errors.Normalize("transform the column failed", errors.RFCCodeText("CDC:ErrTransformColumn"))
+	ErrSinkThroughputInvalid  = errors.Normalize("invalid sink throughput limit %d rows/s, %d bytes/s", errors.RFCCodeText("CDC:ErrSinkThroughputInvalid"))
+	ErrRetryConfigInvalid     = errors.Normalize("invalid retry policy: %s", errors.RFCCodeText("CDC:ErrRetryConfigInvalid"))
+	ErrInvalidUpstream        = errors.Normalize("invalid upstream: %s", errors.RFCCodeText("CDC:ErrInvalidUpstream"))
 
 	// internal errors
 	ErrAdminStopProcessor = errors.Normalize("stop processor by admin command", errors.RFCCodeText("CDC:ErrAdminStopProcessor"))
@@ -98,6 +107,10 @@ var (
 	ErrAvroEncodeFailed          = errors.Normalize("encode to avro native data", errors.RFCCodeText("CDC:ErrAvroEncodeFailed"))
 	ErrAvroEncodeToBinary        = errors.Normalize("encode to binray from native", errors.RFCCodeText("CDC:ErrAvroEncodeToBinary"))
 	ErrAvroSchemaAPIError        = errors.Normalize("schema manager API error", errors.RFCCodeText("CDC:ErrAvroSchemaAPIError"))
+	ErrAvroSchemaNotFound        = errors.Normalize("schema %s not found in the schema registry", errors.RFCCodeText("CDC:ErrAvroSchemaNotFound"))
+	ErrAvroInvalidSchemaID       = errors.Normalize("invalid schema ID %d returned from the schema registry", errors.RFCCodeText("CDC:ErrAvroInvalidSchemaID"))
+	ErrPulsarInvalidOption       = errors.Normalize("pulsar option invalid", errors.RFCCodeText("CDC:ErrPulsarInvalidOption"))
+	ErrMQCodecInvalidConfig      = errors.Normalize("MQ codec config invalid", errors.RFCCodeText("CDC:ErrMQCodecInvalidConfig"))
 	ErrMaxwellEncodeFailed       = errors.Normalize("maxwell encode failed", errors.RFCCodeText("CDC:ErrMaxwellEncodeFailed"))
 	ErrMaxwellDecodeFailed       = errors.Normalize("maxwell decode failed", errors.RFCCodeText("CDC:ErrMaxwellDecodeFailed"))
 	ErrMaxwellInvalidData        = errors.Normalize("maxwell invalid data", errors.RFCCodeText("CDC:ErrMaxwellInvalidData"))
@@ -107,12 +120,30 @@ var (
 
 	// utilities related errors
 	ErrToTLSConfigFailed         = errors.Normalize("generate tls config failed", errors.RFCCodeText("CDC:ErrToTLSConfigFailed"))
+	ErrCredentialKeyNotSet       = errors.Normalize("the credential key is not set, the changefeed credential can't be encrypted or decrypted", errors.RFCCodeText("CDC:ErrCredentialKeyNotSet"))
+	ErrInvalidCredentialKey      = errors.Normalize("invalid credential key, a hex-encoded 256-bit key is expected", errors.RFCCodeText("CDC:ErrInvalidCredentialKey"))
+	ErrDecryptCredential         = errors.Normalize("failed to decrypt the changefeed credential, please check the credential key", errors.RFCCodeText("CDC:ErrDecryptCredential"))
+	ErrChangefeedSecretNotFound  = errors.Normalize("the secret %s of the changefeed %s is not provided", errors.RFCCodeText("CDC:ErrChangefeedSecretNotFound"))
+	ErrInvalidChangefeedExport   = errors.Normalize("invalid changefeed export", errors.RFCCodeText("CDC:ErrInvalidChangefeedExport"))
+	ErrStartTsBeforeGC           = errors.Normalize("start ts %d is earlier than the GC safepoint %d", errors.RFCCodeText("CDC:ErrStartTsBeforeGC"))
+	ErrInvalidResumeTs           = errors.Normalize("can't resume the changefeed from %d, %s", errors.RFCCodeText("CDC:ErrInvalidResumeTs"))
+	ErrInvalidChangefeedManifest = errors.Normalize("invalid changefeed manifest, %d of the %d changefeeds are invalid", errors.RFCCodeText("CDC:ErrInvalidChangefeedManifest"))
 	ErrCheckClusterVersionFromPD = errors.Normalize("failed to request PD", errors.RFCCodeText("CDC:ErrCheckClusterVersionFromPD"))
 	ErrNewSemVersion             = errors.Normalize("create sem version", errors.RFCCodeText("CDC:ErrNewSemVersion"))
 	ErrCheckDirWritable          = errors.Normalize("check dir writable failed", errors.RFCCodeText("CDC:ErrCheckDirWritable"))
 	ErrLoadTimezone              = errors.Normalize("load timezone", errors.RFCCodeText("CDC:ErrLoadTimezone"))
 	ErrURLFormatInvalid          = errors.Normalize("url format is invalid", errors.RFCCodeText("CDC:ErrURLFormatInvalid"))
 	ErrIntersectNoOverlap        = errors.Normalize("span doesn't overlap: %+v vs %+v", errors.RFCCodeText("CDC:ErrIntersectNoOverlap"))
+	ErrQueueClosed               = errors.Normalize("queue is closed", errors.RFCCodeText("CDC:ErrQueueClosed"))
+	ErrReceiverStopped           = errors.Normalize("the notify receiver is stopped", errors.RFCCodeText("CDC:ErrReceiverStopped"))
+	ErrWouldBlock                = errors.Normalize("the operation would block in an async section", errors.RFCCodeText("CDC:ErrWouldBlock"))
+	ErrStageTimeout              = errors.Normalize("stage %s timed out after %s", errors.RFCCodeText("CDC:ErrStageTimeout"))
+	ErrInvalidFeatureGates       = errors.Normalize("invalid feature gate %s, it should be in the `feature=bool` format", errors.RFCCodeText("CDC:ErrInvalidFeatureGates"))
+	ErrUnknownFeature            = errors.Normalize("unknown feature %s", errors.RFCCodeText("CDC:ErrUnknownFeature"))
+	ErrFeatureDisabled           = errors.Normalize("feature %s required by the changefeed is disabled %s", errors.RFCCodeText("CDC:ErrFeatureDisabled"))
+	ErrInvalidConfig             = errors.Normalize("invalid config: %s", errors.RFCCodeText("CDC:ErrInvalidConfig"))
+	ErrDiskQueueInvalidConfig    = errors.Normalize("disk queue config invalid: %s", errors.RFCCodeText("CDC:ErrDiskQueueInvalidConfig"))
+	ErrDiskQueueInvalidItem      = errors.Normalize("disk queue item type %v mismatches %v", errors.RFCCodeText("CDC:ErrDiskQueueInvalidItem"))
 
 	// encode/decode, data format and data integrity errors
 	ErrInvalidRecordKey      = errors.Normalize("invalid record key - %q", errors.RFCCodeText("CDC:ErrInvalidRecordKey"))
@@ -127,6 +158,7 @@ var (
 	ErrUnmarshalFailed       = errors.Normalize("unmarshal failed", errors.RFCCodeText("CDC:ErrUnmarshalFailed"))
 	ErrInvalidChangefeedID   = errors.Normalize(`bad changefeed id, please match the pattern "^[a-zA-Z0-9]+(\-[a-zA-Z0-9]+)*$", eg, "simple-changefeed-task"`, errors.RFCCodeText("CDC:ErrInvalidChangefeedID"))
 	ErrInvalidEtcdKey        = errors.Normalize("invalid key: %s", errors.RFCCodeText("CDC:ErrInvalidEtcdKey"))
+	ErrConfigNotReloadable   = errors.Normalize("%s can't be updated while the changefeed is running, please pause the changefeed first", errors.RFCCodeText("CDC:ErrConfigNotReloadable"))
 
 	// schema storage errors
 	ErrSchemaStorageUnresolved = errors.Normalize("can not found schema snapshot, the specified ts(%d) is more than resolvedTs(%d)", errors.RFCCodeText("CDC:ErrSchemaStorageUnresolved"))
@@ -147,6 +179,13 @@ var (
 	ErrFileSorterDecode      = errors.Normalize("decode failed", errors.RFCCodeText("CDC:ErrFileSorterDecode"))
 	ErrFileSorterInvalidData = errors.Normalize("invalid data", errors.RFCCodeText("CDC:ErrFileSorterInvalidData"))
 
+	// buckets related errors
+	ErrBucketInvalidQuota       = errors.Normalize("invalid bucket quota %d, burst %d", errors.RFCCodeText("CDC:ErrBucketInvalidQuota"))
+	ErrBucketQuotaExceeded      = errors.Normalize("bucket group quota exceeded, required %d, free %d", errors.RFCCodeText("CDC:ErrBucketQuotaExceeded"))
+	ErrBucketClosed             = errors.Normalize("bucket is closed", errors.RFCCodeText("CDC:ErrBucketClosed"))
+	ErrBucketPriorityRegression = errors.Normalize("bucket priority can not regress from %d to %d", errors.RFCCodeText("CDC:ErrBucketPriorityRegression"))
+	ErrBucketAcquireTooLarge    = errors.Normalize("acquire %d tokens exceeds the bucket limit %d", errors.RFCCodeText("CDC:ErrBucketAcquireTooLarge"))
+
 	// server related errors
 	ErrCaptureSuicide             = errors.Normalize("capture suicide", errors.RFCCodeText("CDC:ErrCaptureSuicide"))
 	ErrNewCaptureFailed           = errors.Normalize("new capture failed", errors.RFCCodeText("CDC:ErrNewCaptureFailed"))
@@ -163,11 +202,17 @@ var (
 	ErrServeHTTP                  = errors.Normalize("serve http error", errors.RFCCodeText("CDC:ErrServeHTTP"))
 	ErrCaptureCampaignOwner       = errors.Normalize("campaign owner failed", errors.RFCCodeText("CDC:ErrCaptureCampaignOwner"))
 	ErrCaptureResignOwner         = errors.Normalize("resign owner failed", errors.RFCCodeText("CDC:ErrCaptureResignOwner"))
+	ErrInvalidOwnerPreference     = errors.Normalize("invalid owner preference %s, it must be one of preferred, normal and never", errors.RFCCodeText("CDC:ErrInvalidOwnerPreference"))
 	ErrWaitHandleOperationTimeout = errors.Normalize("waiting processor to handle the operation finished timeout", errors.RFCCodeText("CDC:ErrWaitHandleOperationTimeout"))
 	ErrSupportPostOnly            = errors.Normalize("this api supports POST method only", errors.RFCCodeText("ErrSupportPostOnly"))
 	ErrAPIInvalidParam            = errors.Normalize("invalid api parameter", errors.RFCCodeText("CDC:ErrAPIInvalidParam"))
 	ErrInternalServerError        = errors.Normalize("internal server error", errors.RFCCodeText("CDC:ErrInternalServerError"))
+	ErrAPIOwnerNotFound           = errors.Normalize("owner not found", errors.RFCCodeText("CDC:ErrAPIOwnerNotFound"))
+	ErrAPIForwardFailed           = errors.Normalize("failed to forward the request to the owner %s", errors.RFCCodeText("CDC:ErrAPIForwardFailed"))
+	ErrAPIMethodNotAllowed        = errors.Normalize("method %s is not allowed", errors.RFCCodeText("CDC:ErrAPIMethodNotAllowed"))
+	ErrAPIRouteNotFound           = errors.Normalize("api route %s not found", errors.RFCCodeText("CDC:ErrAPIRouteNotFound"))
 	ErrOwnerSortDir               = errors.Normalize("owner sort dir", errors.RFCCodeText("CDC:ErrOwnerSortDir"))
+	ErrOwnerUnknown               = errors.Normalize("owner running unknown error", errors.RFCCodeText("CDC:ErrOwnerUnknown"))
 	ErrOwnerChangefeedNotFound    = errors.Normalize("changefeed %s not found in owner cache", errors.RFCCodeText("CDC:ErrOwnerChangefeedNotFound"))
 	ErrChangefeedAbnormalState    = errors.Normalize("changefeed in abnormal state: %s, replication status: %+v", errors.RFCCodeText("CDC:ErrChangefeedAbnormalState"))
 	ErrInvalidAdminJobType        = errors.Normalize("invalid admin job type: %d", errors.RFCCodeText("CDC:ErrInvalidAdminJobType"))
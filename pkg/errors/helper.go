@@ -27,3 +27,65 @@ func WrapError(rfcError *errors.Error, err error) error {
 	}
 	return rfcError.Wrap(err).GenWithStackByCause()
 }
+
+// RFCCode returns the RFC code of the first `*errors.Error` in the cause chain
+// of err.
+func RFCCode(err error) (errors.RFCErrorCode, bool) {
+	type causer interface {
+		Cause() error
+	}
+	for err != nil {
+		if terr, ok := err.(*errors.Error); ok {
+			return terr.RFCCode(), true
+		}
+		cause, ok := err.(causer)
+		if !ok {
+			break
+		}
+		err = cause.Cause()
+	}
+	return "", false
+}
+
+// unretryableErrors are the errors which can't be recovered by restarting the
+// changefeed, the config or the environment must be fixed by the user first.
+var unretryableErrors = map[errors.RFCErrorCode]struct{}{
+	ErrVersionIncompatible.RFCCode():      {},
+	ErrInvalidChangefeedID.RFCCode():      {},
+	ErrSinkURIInvalid.RFCCode():           {},
+	ErrFilterRuleInvalid.RFCCode():        {},
+	ErrRouteRuleInvalid.RFCCode():         {},
+	ErrColumnTransformInvalid.RFCCode():   {},
+	ErrKafkaInvalidConfig.RFCCode():       {},
+	ErrKafkaInvalidPartitionNum.RFCCode(): {},
+	ErrKafkaInvalidClientID.RFCCode():     {},
+	ErrKafkaInvalidVersion.RFCCode():      {},
+	ErrPulsarInvalidOption.RFCCode():      {},
+	ErrMQCodecInvalidConfig.RFCCode():     {},
+	ErrMySQLInvalidConfig.RFCCode():       {},
+	ErrAvroUnknownType.RFCCode():          {},
+	ErrCredentialKeyNotSet.RFCCode():      {},
+	ErrInvalidCredentialKey.RFCCode():     {},
+	ErrDecryptCredential.RFCCode():        {},
+	ErrUnknownSortEngine.RFCCode():        {},
+	ErrSchemaStorageGCed.RFCCode():        {},
+	ErrRetryConfigInvalid.RFCCode():       {},
+	ErrInvalidUpstream.RFCCode():          {},
+}
+
+// IsRetryableError returns true if the error may be recovered by restarting
+// the changefeed, the errors without RFC codes are considered retryable.
+func IsRetryableError(err error) bool {
+	code, ok := RFCCode(err)
+	if !ok {
+		return true
+	}
+	return IsRetryableErrorCode(code)
+}
+
+// IsRetryableErrorCode returns true if the error of the RFC code may be
+// recovered by restarting the changefeed.
+func IsRetryableErrorCode(code errors.RFCErrorCode) bool {
+	_, ok := unretryableErrors[code]
+	return !ok
+}
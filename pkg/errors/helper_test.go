@@ -51,3 +51,41 @@ func (s *helperSuite) TestWrapError(c *check.C) {
 		}
 	}
 }
+
+func (s *helperSuite) TestRFCCode(c *check.C) {
+	testCases := []struct {
+		err      error
+		expected errors.RFCErrorCode
+		ok       bool
+	}{
+		{nil, "", false},
+		{errors.New("test"), "", false},
+		{ErrAPIInvalidParam.GenWithStackByArgs(), "CDC:ErrAPIInvalidParam", true},
+		{errors.Annotate(ErrChangeFeedNotExists.GenWithStackByArgs("cf"), "test"), "CDC:ErrChangeFeedNotExists", true},
+		{WrapError(ErrPDEtcdAPIError, errors.New("test")), "CDC:ErrPDEtcdAPIError", true},
+	}
+	for _, tc := range testCases {
+		code, ok := RFCCode(tc.err)
+		c.Assert(ok, check.Equals, tc.ok)
+		c.Assert(code, check.Equals, tc.expected)
+	}
+}
+
+func (s *helperSuite) TestIsRetryableError(c *check.C) {
+	testCases := []struct {
+		err       error
+		retryable bool
+	}{
+		{errors.New("test"), true},
+		{ErrKafkaSendMessage.GenWithStackByArgs(), true},
+		{WrapError(ErrAvroSchemaAPIError, errors.New("test")), true},
+		{ErrSinkURIInvalid.GenWithStackByArgs(), false},
+		{errors.Annotate(WrapError(ErrKafkaInvalidConfig, errors.New("test")), "test"), false},
+		{errors.Trace(ErrFilterRuleInvalid.GenWithStackByArgs()), false},
+	}
+	for _, tc := range testCases {
+		c.Assert(IsRetryableError(tc.err), check.Equals, tc.retryable, check.Commentf("%s", tc.err))
+	}
+	c.Assert(IsRetryableErrorCode(ErrMySQLInvalidConfig.RFCCode()), check.IsFalse)
+	c.Assert(IsRetryableErrorCode(ErrMySQLConnectionError.RFCCode()), check.IsTrue)
+}
@@ -0,0 +1,378 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pingcap/parser"
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/opcode"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+	"github.com/pingcap/tidb/types"
+	driver "github.com/pingcap/tidb/types/parser_driver"
+	"github.com/pingcap/tidb/util/stringutil"
+)
+
+// rowExpr is the expression of a row filter, which is a subset of the SQL
+// expressions: the comparisons, AND, OR, XOR, NOT, IN, BETWEEN, LIKE and
+// IS NULL of the columns and the constants. The strings are compared in
+// binary, and a string is converted to a number if it's compared with one.
+type rowExpr struct {
+	text string
+	node ast.ExprNode
+}
+
+func parseRowExpr(text string) (*rowExpr, error) {
+	stmt, err := parser.New().ParseOneStmt("SELECT * FROM t WHERE "+text, "", "")
+	if err != nil {
+		return nil, cerror.ErrFilterRuleInvalid.GenWithStack("invalid row filter expression %s: %s", text, err)
+	}
+	sel, ok := stmt.(*ast.SelectStmt)
+	if !ok || sel.Where == nil || sel.GroupBy != nil || sel.Having != nil || len(sel.WindowSpecs) != 0 ||
+		sel.OrderBy != nil || sel.Limit != nil || sel.LockTp != ast.SelectLockNone || sel.SelectIntoOpt != nil {
+		return nil, cerror.ErrFilterRuleInvalid.GenWithStack("invalid row filter expression %s", text)
+	}
+	if err := checkRowExpr(sel.Where); err != nil {
+		return nil, cerror.ErrFilterRuleInvalid.GenWithStack("invalid row filter expression %s: %s", text, err)
+	}
+	return &rowExpr{text: text, node: sel.Where}, nil
+}
+
+// checkRowExpr checks whether the expression can be evaluated by evalRowExpr
+func checkRowExpr(node ast.ExprNode) error {
+	var children []ast.ExprNode
+	switch n := node.(type) {
+	case *driver.ValueExpr, *ast.ColumnNameExpr:
+	case *ast.ParenthesesExpr:
+		children = []ast.ExprNode{n.Expr}
+	case *ast.UnaryOperationExpr:
+		switch n.Op {
+		case opcode.Not, opcode.Minus, opcode.Plus:
+		default:
+			return fmt.Errorf("unsupported operator %s", n.Op)
+		}
+		children = []ast.ExprNode{n.V}
+	case *ast.BinaryOperationExpr:
+		switch n.Op {
+		case opcode.LogicAnd, opcode.LogicOr, opcode.LogicXor,
+			opcode.EQ, opcode.NE, opcode.LT, opcode.LE, opcode.GT, opcode.GE, opcode.NullEQ:
+		default:
+			return fmt.Errorf("unsupported operator %s", n.Op)
+		}
+		children = []ast.ExprNode{n.L, n.R}
+	case *ast.IsNullExpr:
+		children = []ast.ExprNode{n.Expr}
+	case *ast.PatternInExpr:
+		if n.Sel != nil {
+			return fmt.Errorf("unsupported subquery")
+		}
+		children = append([]ast.ExprNode{n.Expr}, n.List...)
+	case *ast.BetweenExpr:
+		children = []ast.ExprNode{n.Expr, n.Left, n.Right}
+	case *ast.PatternLikeExpr:
+		children = []ast.ExprNode{n.Expr, n.Pattern}
+	default:
+		return fmt.Errorf("unsupported expression %T", node)
+	}
+	for _, child := range children {
+		if err := checkRowExpr(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// match returns whether the expression is true for the column values, which
+// are keyed by the lower case column names.
+func (e *rowExpr) match(columns map[string]interface{}) (bool, error) {
+	v, err := evalRowExpr(e.node, columns)
+	if err != nil {
+		return false, cerror.ErrEvalRowFilter.GenWithStack("evaluate the row filter %s failed: %s", e.text, err)
+	}
+	truth, isNull := truthValue(v)
+	return truth && !isNull, nil
+}
+
+// evalRowExpr evaluates the expression, the value is nil for NULL, and the
+// booleans are 1 or 0 of int64.
+func evalRowExpr(node ast.ExprNode, columns map[string]interface{}) (interface{}, error) {
+	switch n := node.(type) {
+	case *driver.ValueExpr:
+		return normalizeValue(n.GetValue()), nil
+	case *ast.ColumnNameExpr:
+		v, ok := columns[n.Name.Name.L]
+		if !ok {
+			return nil, fmt.Errorf("column %s doesn't exist", n.Name.Name.O)
+		}
+		return normalizeValue(v), nil
+	case *ast.ParenthesesExpr:
+		return evalRowExpr(n.Expr, columns)
+	case *ast.UnaryOperationExpr:
+		v, err := evalRowExpr(n.V, columns)
+		if err != nil || v == nil {
+			return nil, err
+		}
+		switch n.Op {
+		case opcode.Not:
+			truth, _ := truthValue(v)
+			return boolValue(!truth), nil
+		case opcode.Minus:
+			switch x := v.(type) {
+			case int64:
+				return -x, nil
+			case uint64:
+				return -float64(x), nil
+			}
+			return -toFloat64(v), nil
+		}
+		return v, nil
+	case *ast.BinaryOperationExpr:
+		l, err := evalRowExpr(n.L, columns)
+		if err != nil {
+			return nil, err
+		}
+		r, err := evalRowExpr(n.R, columns)
+		if err != nil {
+			return nil, err
+		}
+		return evalBinaryOperation(n.Op, l, r), nil
+	case *ast.IsNullExpr:
+		v, err := evalRowExpr(n.Expr, columns)
+		if err != nil {
+			return nil, err
+		}
+		return boolValue((v == nil) != n.Not), nil
+	case *ast.PatternInExpr:
+		v, err := evalRowExpr(n.Expr, columns)
+		if err != nil || v == nil {
+			return nil, err
+		}
+		hasNull := false
+		for _, item := range n.List {
+			iv, err := evalRowExpr(item, columns)
+			if err != nil {
+				return nil, err
+			}
+			if iv == nil {
+				hasNull = true
+			} else if compareValues(v, iv) == 0 {
+				return boolValue(!n.Not), nil
+			}
+		}
+		if hasNull {
+			return nil, nil
+		}
+		return boolValue(n.Not), nil
+	case *ast.BetweenExpr:
+		v, err := evalRowExpr(n.Expr, columns)
+		if err != nil {
+			return nil, err
+		}
+		left, err := evalRowExpr(n.Left, columns)
+		if err != nil {
+			return nil, err
+		}
+		right, err := evalRowExpr(n.Right, columns)
+		if err != nil {
+			return nil, err
+		}
+		between := evalBinaryOperation(opcode.LogicAnd,
+			evalBinaryOperation(opcode.GE, v, left), evalBinaryOperation(opcode.LE, v, right))
+		if n.Not {
+			return evalBinaryOperation(opcode.LogicXor, between, int64(1)), nil
+		}
+		return between, nil
+	case *ast.PatternLikeExpr:
+		v, err := evalRowExpr(n.Expr, columns)
+		if err != nil {
+			return nil, err
+		}
+		pattern, err := evalRowExpr(n.Pattern, columns)
+		if err != nil || v == nil || pattern == nil {
+			return nil, err
+		}
+		patChars, patTypes := stringutil.CompilePattern(toString(pattern), n.Escape)
+		return boolValue(stringutil.DoMatch(toString(v), patChars, patTypes) != n.Not), nil
+	}
+	return nil, fmt.Errorf("unsupported expression %T", node)
+}
+
+func evalBinaryOperation(op opcode.Op, l, r interface{}) interface{} {
+	switch op {
+	case opcode.LogicAnd, opcode.LogicOr, opcode.LogicXor:
+		lt, lNull := truthValue(l)
+		rt, rNull := truthValue(r)
+		switch {
+		case op == opcode.LogicAnd && ((!lt && !lNull) || (!rt && !rNull)):
+			return boolValue(false)
+		case op == opcode.LogicOr && ((lt && !lNull) || (rt && !rNull)):
+			return boolValue(true)
+		case lNull || rNull:
+			return nil
+		case op == opcode.LogicXor:
+			return boolValue(lt != rt)
+		}
+		return boolValue(lt)
+	case opcode.NullEQ:
+		if l == nil || r == nil {
+			return boolValue(l == nil && r == nil)
+		}
+		return boolValue(compareValues(l, r) == 0)
+	}
+	if l == nil || r == nil {
+		return nil
+	}
+	c := compareValues(l, r)
+	switch op {
+	case opcode.EQ:
+		return boolValue(c == 0)
+	case opcode.NE:
+		return boolValue(c != 0)
+	case opcode.LT:
+		return boolValue(c < 0)
+	case opcode.LE:
+		return boolValue(c <= 0)
+	case opcode.GT:
+		return boolValue(c > 0)
+	case opcode.GE:
+		return boolValue(c >= 0)
+	}
+	return nil
+}
+
+// normalizeValue converts the value to nil, int64, uint64, float64 or string
+func normalizeValue(v interface{}) interface{} {
+	switch x := v.(type) {
+	case nil, int64, uint64, float64, string:
+		return x
+	case []byte:
+		return string(x)
+	case int:
+		return int64(x)
+	case float32:
+		return float64(x)
+	case *types.MyDecimal:
+		f, err := x.ToFloat64()
+		if err != nil {
+			return x.String()
+		}
+		return f
+	case fmt.Stringer:
+		return x.String()
+	}
+	return fmt.Sprint(v)
+}
+
+// compareValues compares the non-NULL normalized values
+func compareValues(a, b interface{}) int {
+	switch x := a.(type) {
+	case string:
+		if y, ok := b.(string); ok {
+			return strings.Compare(x, y)
+		}
+	case int64:
+		switch y := b.(type) {
+		case int64:
+			return compareInt64(x, y)
+		case uint64:
+			if x < 0 {
+				return -1
+			}
+			return compareUint64(uint64(x), y)
+		}
+	case uint64:
+		switch y := b.(type) {
+		case uint64:
+			return compareUint64(x, y)
+		case int64:
+			if y < 0 {
+				return 1
+			}
+			return compareUint64(x, uint64(y))
+		}
+	}
+	x, y := toFloat64(a), toFloat64(b)
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+func compareInt64(x, y int64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+func compareUint64(x, y uint64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+// toFloat64 converts the normalized value to a number, a string which isn't
+// a number is 0.
+func toFloat64(v interface{}) float64 {
+	switch x := v.(type) {
+	case int64:
+		return float64(x)
+	case uint64:
+		return float64(x)
+	case float64:
+		return x
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(x), 64)
+		if err != nil {
+			return 0
+		}
+		return f
+	}
+	return 0
+}
+
+func toString(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
+
+// truthValue returns whether the value is true, or NULL
+func truthValue(v interface{}) (truth bool, isNull bool) {
+	if v == nil {
+		return false, true
+	}
+	return toFloat64(v) != 0, false
+}
+
+func boolValue(b bool) interface{} {
+	if b {
+		return int64(1)
+	}
+	return int64(0)
+}
@@ -0,0 +1,95 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import (
+	"github.com/pingcap/check"
+)
+
+type exprSuite struct{}
+
+var _ = check.Suite(&exprSuite{})
+
+func (s *exprSuite) TestMatch(c *check.C) {
+	columns := map[string]interface{}{
+		"id":     int64(42),
+		"uid":    uint64(18446744073709551615),
+		"price":  "12.50",
+		"status": []byte("archived"),
+		"name":   "Alice",
+		"score":  float64(3.5),
+		"note":   nil,
+	}
+	testCases := []struct {
+		expr    string
+		matched bool
+	}{
+		{"id = 42", true},
+		{"ID = 42", true},
+		{"id <> 42", false},
+		{"id > 40 and id < 50", true},
+		{"id >= 43 or score > 3", true},
+		{"id > 40 xor score > 3", false},
+		{"not (id = 42)", false},
+		{"-id < 0", true},
+		{"uid > id", true},
+		{"uid > 18446744073709551614", true},
+		{"price > 12", true},
+		{"price = 12.5", true},
+		{"score between 3 and 4", true},
+		{"score not between 3 and 4", false},
+		{"status != 'archived'", false},
+		{"status in ('active', 'archived')", true},
+		{"status not in ('active', 'archived')", false},
+		{"name like 'Al%'", true},
+		{"name like 'al%'", false},
+		{"name not like '_lice'", false},
+		{"note is null", true},
+		{"note is not null", false},
+		{"note = 1", false},
+		{"not (note = 1)", false},
+		{"note <=> null", true},
+		{"note = 1 or id = 42", true},
+		{"id in (1, null)", false},
+		{"not (id in (1, null))", false},
+	}
+	for _, tc := range testCases {
+		expr, err := parseRowExpr(tc.expr)
+		c.Assert(err, check.IsNil, check.Commentf("%s", tc.expr))
+		matched, err := expr.match(columns)
+		c.Assert(err, check.IsNil, check.Commentf("%s", tc.expr))
+		c.Assert(matched, check.Equals, tc.matched, check.Commentf("%s", tc.expr))
+	}
+
+	expr, err := parseRowExpr("unknown = 1")
+	c.Assert(err, check.IsNil)
+	_, err = expr.match(columns)
+	c.Assert(err, check.ErrorMatches, ".*column unknown doesn't exist.*")
+}
+
+func (s *exprSuite) TestParseInvalidExpr(c *check.C) {
+	for _, expr := range []string{
+		"",
+		"id =",
+		"id = 1 order by id",
+		"id = 1 limit 1",
+		"id + 1 > 2",
+		"lower(name) = 'alice'",
+		"id in (select id from t)",
+		"id = 1; drop table t",
+	} {
+		_, err := parseRowExpr(expr)
+		c.Assert(err, check.ErrorMatches, ".*invalid row filter expression.*", check.Commentf("%s", expr))
+	}
+}
@@ -14,7 +14,10 @@
 package filter
 
 import (
+	"strings"
+
 	"github.com/pingcap/parser/model"
+	cdcmodel "github.com/pingcap/ticdc/cdc/model"
 	"github.com/pingcap/ticdc/pkg/config"
 	"github.com/pingcap/ticdc/pkg/cyclic/mark"
 	cerror "github.com/pingcap/ticdc/pkg/errors"
@@ -27,9 +30,23 @@ type Filter struct {
 	filter           filterV2.Filter
 	ignoreTxnStartTs []uint64
 	ddlAllowlist     []model.ActionType
+	eventFilters     []*eventFilter
+	rowFilters       []*rowFilter
 	isCyclicEnabled  bool
 }
 
+// eventFilter ignores the DML events of the types for the matched tables
+type eventFilter struct {
+	filter      filterV2.Filter
+	ignoreEvent map[config.EventType]struct{}
+}
+
+// rowFilter only replicates the rows matching the expression for the matched tables
+type rowFilter struct {
+	filter filterV2.Filter
+	expr   *rowExpr
+}
+
 // NewFilter creates a filter
 func NewFilter(cfg *config.ReplicaConfig) (*Filter, error) {
 	var f filterV2.Filter
@@ -49,14 +66,88 @@ func NewFilter(cfg *config.ReplicaConfig) (*Filter, error) {
 	if !cfg.CaseSensitive {
 		f = filterV2.CaseInsensitive(f)
 	}
+	eventFilters, err := newEventFilters(cfg)
+	if err != nil {
+		return nil, err
+	}
+	rowFilters, err := newRowFilters(cfg)
+	if err != nil {
+		return nil, err
+	}
 	return &Filter{
 		filter:           f,
 		ignoreTxnStartTs: cfg.Filter.IgnoreTxnStartTs,
 		ddlAllowlist:     cfg.Filter.DDLAllowlist,
+		eventFilters:     eventFilters,
+		rowFilters:       rowFilters,
 		isCyclicEnabled:  cfg.Cyclic.IsEnabled(),
 	}, nil
 }
 
+func newEventFilters(cfg *config.ReplicaConfig) ([]*eventFilter, error) {
+	eventFilters := make([]*eventFilter, 0, len(cfg.Filter.EventFilters))
+	for _, rule := range cfg.Filter.EventFilters {
+		f, err := filterV2.Parse(rule.Matcher)
+		if err != nil {
+			return nil, cerror.WrapError(cerror.ErrFilterRuleInvalid, err)
+		}
+		if !cfg.CaseSensitive {
+			f = filterV2.CaseInsensitive(f)
+		}
+		ignoreEvent := make(map[config.EventType]struct{}, len(rule.IgnoreEvent))
+		for _, tp := range rule.IgnoreEvent {
+			switch tp {
+			case config.EventInsert, config.EventUpdate, config.EventDelete:
+				ignoreEvent[tp] = struct{}{}
+			default:
+				return nil, cerror.ErrFilterRuleInvalid.GenWithStack(
+					"invalid event type %s, insert, update or delete is expected", tp)
+			}
+		}
+		eventFilters = append(eventFilters, &eventFilter{filter: f, ignoreEvent: ignoreEvent})
+	}
+	return eventFilters, nil
+}
+
+func newRowFilters(cfg *config.ReplicaConfig) ([]*rowFilter, error) {
+	rowFilters := make([]*rowFilter, 0, len(cfg.Filter.RowFilters))
+	for _, rule := range cfg.Filter.RowFilters {
+		f, err := filterV2.Parse(rule.Matcher)
+		if err != nil {
+			return nil, cerror.WrapError(cerror.ErrFilterRuleInvalid, err)
+		}
+		if !cfg.CaseSensitive {
+			f = filterV2.CaseInsensitive(f)
+		}
+		expr, err := parseRowExpr(rule.Expr)
+		if err != nil {
+			return nil, err
+		}
+		rowFilters = append(rowFilters, &rowFilter{filter: f, expr: expr})
+	}
+	return rowFilters, nil
+}
+
+// RequireOldValue returns whether the event filters or the row filters of the
+// config require the old values of the rows, without which the updates are the
+// same as the inserts, and the deletes only have the handle columns.
+func RequireOldValue(cfg *config.ReplicaConfig) bool {
+	if cfg.Filter == nil {
+		return false
+	}
+	if len(cfg.Filter.RowFilters) != 0 {
+		return true
+	}
+	for _, rule := range cfg.Filter.EventFilters {
+		for _, tp := range rule.IgnoreEvent {
+			if tp == config.EventInsert || tp == config.EventUpdate {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (f *Filter) shouldIgnoreStartTs(ts uint64) bool {
 	for _, ignoreTs := range f.ignoreTxnStartTs {
 		if ignoreTs == ts {
@@ -80,9 +171,80 @@ func (f *Filter) ShouldIgnoreTable(db, tbl string) bool {
 }
 
 // ShouldIgnoreDMLEvent removes DMLs that's not wanted by this change feed.
-// CDC only supports filtering by database/table now.
-func (f *Filter) ShouldIgnoreDMLEvent(ts uint64, schema, table string) bool {
-	return f.shouldIgnoreStartTs(ts) || f.ShouldIgnoreTable(schema, table)
+// The DMLs can be filtered by database/table and the event type.
+func (f *Filter) ShouldIgnoreDMLEvent(ts uint64, eventType config.EventType, schema, table string) bool {
+	return f.shouldIgnoreStartTs(ts) || f.ShouldIgnoreTable(schema, table) ||
+		f.shouldIgnoreEventType(eventType, schema, table)
+}
+
+func (f *Filter) shouldIgnoreEventType(eventType config.EventType, schema, table string) bool {
+	for _, ef := range f.eventFilters {
+		if _, ok := ef.ignoreEvent[eventType]; ok && ef.filter.MatchTable(schema, table) {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterRow applies the row filters to the row changed event. It returns nil if
+// the row should be ignored. An update is converted to an insert if only the
+// new values match the row filters, or a delete if only the old values match.
+func (f *Filter) FilterRow(row *cdcmodel.RowChangedEvent) (*cdcmodel.RowChangedEvent, error) {
+	if len(f.rowFilters) == 0 || row.Table == nil {
+		return row, nil
+	}
+	var exprs []*rowExpr
+	for _, rf := range f.rowFilters {
+		if rf.filter.MatchTable(row.Table.Schema, row.Table.Table) {
+			exprs = append(exprs, rf.expr)
+		}
+	}
+	if len(exprs) == 0 {
+		return row, nil
+	}
+	newMatched, err := matchRowExprs(exprs, row.Columns)
+	if err != nil {
+		return nil, err
+	}
+	oldMatched, err := matchRowExprs(exprs, row.PreColumns)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case !newMatched && !oldMatched:
+		return nil, nil
+	case len(row.Columns) != 0 && len(row.PreColumns) != 0 && newMatched != oldMatched:
+		converted := *row
+		if newMatched {
+			converted.PreColumns = nil
+		} else {
+			converted.Columns = nil
+		}
+		return &converted, nil
+	}
+	return row, nil
+}
+
+// matchRowExprs returns whether all the expressions match the columns, it
+// returns false if there are no columns.
+func matchRowExprs(exprs []*rowExpr, cols []*cdcmodel.Column) (bool, error) {
+	if len(cols) == 0 {
+		return false, nil
+	}
+	values := make(map[string]interface{}, len(cols))
+	for _, col := range cols {
+		if col == nil {
+			continue
+		}
+		values[strings.ToLower(col.Name)] = col.Value
+	}
+	for _, expr := range exprs {
+		matched, err := expr.match(values)
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+	return true, nil
 }
 
 // ShouldIgnoreDDLEvent removes DDLs that's not wanted by this change feed.
@@ -16,6 +16,7 @@ package filter
 import (
 	"testing"
 
+	cdcmodel "github.com/pingcap/ticdc/cdc/model"
 	"github.com/pingcap/ticdc/pkg/config"
 
 	"github.com/pingcap/check"
@@ -110,12 +111,113 @@ func (s *filterSuite) TestShouldIgnoreTxn(c *check.C) {
 		})
 		c.Assert(err, check.IsNil)
 		for _, tc := range ftc.cases {
-			c.Assert(filter.ShouldIgnoreDMLEvent(tc.ts, tc.schema, tc.table), check.Equals, tc.ignore)
+			c.Assert(filter.ShouldIgnoreDMLEvent(tc.ts, config.EventInsert, tc.schema, tc.table), check.Equals, tc.ignore)
 			c.Assert(filter.ShouldIgnoreDDLEvent(tc.ts, model.ActionCreateTable, tc.schema, tc.table), check.Equals, tc.ignore)
 		}
 	}
 }
 
+func (s *filterSuite) TestShouldIgnoreEventType(c *check.C) {
+	cfg := &config.ReplicaConfig{
+		Filter: &config.FilterConfig{
+			Rules: []string{"*.*"},
+			EventFilters: []*config.EventFilterRule{
+				{Matcher: []string{"test.*"}, IgnoreEvent: []config.EventType{config.EventDelete}},
+				{Matcher: []string{"test.log", "archive.*"}, IgnoreEvent: []config.EventType{config.EventUpdate, config.EventDelete}},
+			},
+		},
+	}
+	filter, err := NewFilter(cfg)
+	c.Assert(err, check.IsNil)
+	testCases := []struct {
+		eventType     config.EventType
+		schema, table string
+		ignore        bool
+	}{
+		{config.EventInsert, "test", "t1", false},
+		{config.EventUpdate, "test", "t1", false},
+		{config.EventDelete, "test", "t1", true},
+		{config.EventInsert, "test", "log", false},
+		{config.EventUpdate, "test", "log", true},
+		{config.EventUpdate, "archive", "t1", true},
+		{config.EventDelete, "other", "t1", false},
+	}
+	for _, tc := range testCases {
+		c.Assert(filter.ShouldIgnoreDMLEvent(1, tc.eventType, tc.schema, tc.table), check.Equals, tc.ignore,
+			check.Commentf("%s %s.%s", tc.eventType, tc.schema, tc.table))
+	}
+	c.Assert(RequireOldValue(cfg), check.IsTrue)
+	cfg.Filter.EventFilters = cfg.Filter.EventFilters[:1]
+	c.Assert(RequireOldValue(cfg), check.IsFalse)
+
+	cfg.Filter.EventFilters[0].IgnoreEvent = []config.EventType{"truncate"}
+	_, err = NewFilter(cfg)
+	c.Assert(err, check.ErrorMatches, ".*invalid event type truncate.*")
+}
+
+func (s *filterSuite) TestFilterRow(c *check.C) {
+	cfg := &config.ReplicaConfig{
+		Filter: &config.FilterConfig{
+			Rules: []string{"*.*"},
+			RowFilters: []*config.RowFilterRule{
+				{Matcher: []string{"test.*"}, Expr: "status != 'archived'"},
+				{Matcher: []string{"test.order"}, Expr: "amount > 100"},
+			},
+		},
+	}
+	filter, err := NewFilter(cfg)
+	c.Assert(err, check.IsNil)
+	newColumns := func(status string, amount int64) []*cdcmodel.Column {
+		return []*cdcmodel.Column{
+			{Name: "Status", Value: []byte(status)},
+			nil,
+			{Name: "amount", Value: amount},
+		}
+	}
+	table := &cdcmodel.TableName{Schema: "test", Table: "order"}
+
+	// insert
+	row := &cdcmodel.RowChangedEvent{Table: table, Columns: newColumns("active", 200)}
+	filtered, err := filter.FilterRow(row)
+	c.Assert(err, check.IsNil)
+	c.Assert(filtered, check.Equals, row)
+	row = &cdcmodel.RowChangedEvent{Table: table, Columns: newColumns("active", 50)}
+	filtered, err = filter.FilterRow(row)
+	c.Assert(err, check.IsNil)
+	c.Assert(filtered, check.IsNil)
+	// delete
+	row = &cdcmodel.RowChangedEvent{Table: table, PreColumns: newColumns("archived", 200)}
+	filtered, err = filter.FilterRow(row)
+	c.Assert(err, check.IsNil)
+	c.Assert(filtered, check.IsNil)
+	// the update is converted to an insert if only the new values match
+	row = &cdcmodel.RowChangedEvent{Table: table, PreColumns: newColumns("active", 50), Columns: newColumns("active", 200)}
+	filtered, err = filter.FilterRow(row)
+	c.Assert(err, check.IsNil)
+	c.Assert(filtered.PreColumns, check.IsNil)
+	c.Assert(filtered.Columns, check.DeepEquals, row.Columns)
+	c.Assert(row.PreColumns, check.NotNil)
+	// the update is converted to a delete if only the old values match
+	row = &cdcmodel.RowChangedEvent{Table: table, PreColumns: newColumns("active", 200), Columns: newColumns("archived", 200)}
+	filtered, err = filter.FilterRow(row)
+	c.Assert(err, check.IsNil)
+	c.Assert(filtered.PreColumns, check.DeepEquals, row.PreColumns)
+	c.Assert(filtered.Columns, check.IsNil)
+	// only the row filters of the matched tables are applied
+	row = &cdcmodel.RowChangedEvent{Table: &cdcmodel.TableName{Schema: "test", Table: "user"}, Columns: newColumns("active", 50)}
+	filtered, err = filter.FilterRow(row)
+	c.Assert(err, check.IsNil)
+	c.Assert(filtered, check.Equals, row)
+	row = &cdcmodel.RowChangedEvent{Table: &cdcmodel.TableName{Schema: "other", Table: "order"}, Columns: newColumns("archived", 50)}
+	filtered, err = filter.FilterRow(row)
+	c.Assert(err, check.IsNil)
+	c.Assert(filtered, check.Equals, row)
+
+	cfg.Filter.RowFilters[1].Expr = "sleep(1)"
+	_, err = NewFilter(cfg)
+	c.Assert(err, check.ErrorMatches, ".*invalid row filter expression sleep\\(1\\).*")
+}
+
 func (s *filterSuite) TestShouldDiscardDDL(c *check.C) {
 	config := &config.ReplicaConfig{
 		Filter: &config.FilterConfig{
@@ -48,6 +48,8 @@ type Config struct {
 	FileMaxDays int `toml:"max-days" json:"max-days"`
 	// Maximum number of old log files to retain.
 	FileMaxBackups int `toml:"max-backups" json:"max-backups"`
+	// Redact mode of the user data in the logs, off, hash or elide.
+	Redact string `toml:"redact" json:"redact"`
 }
 
 // Adjust adjusts config
@@ -83,6 +85,9 @@ func SetLogLevel(level string) error {
 
 // InitLogger initializes logger
 func InitLogger(cfg *Config) error {
+	if err := SetRedactMode(RedactMode(cfg.Redact)); err != nil {
+		return err
+	}
 	pclogConfig := &log.Config{
 		Level: cfg.Level,
 		File: log.FileLogConfig{
@@ -0,0 +1,146 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logutil
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/pingcap/errors"
+	"go.uber.org/zap"
+)
+
+// RedactMode is the mode of redacting the user data, e.g. the keys, the
+// values, the rows and the queries, in the logs.
+type RedactMode string
+
+const (
+	// RedactModeOff logs the user data verbatim
+	RedactModeOff RedactMode = "off"
+	// RedactModeHash replaces the user data with its hash, so that the same
+	// data can still be correlated across the logs.
+	RedactModeHash RedactMode = "hash"
+	// RedactModeElide replaces the user data with "?"
+	RedactModeElide RedactMode = "elide"
+)
+
+// redactMode is the global redact mode, which is a RedactMode
+var redactMode atomic.Value
+
+func init() {
+	redactMode.Store(RedactModeOff)
+}
+
+// SetRedactMode sets the global redact mode, the empty mode is off.
+func SetRedactMode(mode RedactMode) error {
+	switch mode {
+	case "":
+		mode = RedactModeOff
+	case RedactModeOff, RedactModeHash, RedactModeElide:
+	default:
+		return errors.Errorf("invalid log redact mode %s, off, hash or elide is expected", mode)
+	}
+	redactMode.Store(mode)
+	return nil
+}
+
+// GetRedactMode returns the global redact mode
+func GetRedactMode() RedactMode {
+	return redactMode.Load().(RedactMode)
+}
+
+// ZapRedactBinary is zap.Binary with the value redacted
+func ZapRedactBinary(key string, value []byte) zap.Field {
+	if GetRedactMode() == RedactModeOff {
+		return zap.Binary(key, value)
+	}
+	return zap.Stringer(key, redactedValue{value})
+}
+
+// ZapRedactByteString is zap.ByteString with the value redacted
+func ZapRedactByteString(key string, value []byte) zap.Field {
+	if GetRedactMode() == RedactModeOff {
+		return zap.ByteString(key, value)
+	}
+	return zap.Stringer(key, redactedValue{value})
+}
+
+// ZapRedactString is zap.String with the value redacted
+func ZapRedactString(key string, value string) zap.Field {
+	if GetRedactMode() == RedactModeOff {
+		return zap.String(key, value)
+	}
+	return zap.Stringer(key, redactedValue{value})
+}
+
+// ZapRedactStrings is zap.Strings with every value redacted
+func ZapRedactStrings(key string, values []string) zap.Field {
+	if GetRedactMode() == RedactModeOff {
+		return zap.Strings(key, values)
+	}
+	return zap.Stringer(key, redactedValue{values})
+}
+
+// ZapRedactStringer is zap.Stringer with the value redacted, e.g. the spans
+// whose keys are user data.
+func ZapRedactStringer(key string, value fmt.Stringer) zap.Field {
+	if GetRedactMode() == RedactModeOff {
+		return zap.Stringer(key, value)
+	}
+	return zap.Stringer(key, redactedValue{value})
+}
+
+// ZapRedactReflect is zap.Reflect with the value redacted, the value is
+// formatted by fmt before it's hashed.
+func ZapRedactReflect(key string, value interface{}) zap.Field {
+	if GetRedactMode() == RedactModeOff {
+		return zap.Reflect(key, value)
+	}
+	return zap.Stringer(key, redactedValue{value})
+}
+
+// redactedValue redacts the value lazily, only when the log is written.
+type redactedValue struct {
+	value interface{}
+}
+
+func (v redactedValue) String() string {
+	if GetRedactMode() == RedactModeElide {
+		return "?"
+	}
+	switch value := v.value.(type) {
+	case []byte:
+		return redactHash(value)
+	case string:
+		return redactHash([]byte(value))
+	case []string:
+		hashes := make([]string, 0, len(value))
+		for _, s := range value {
+			hashes = append(hashes, redactHash([]byte(s)))
+		}
+		return "[" + strings.Join(hashes, ",") + "]"
+	case fmt.Stringer:
+		return redactHash([]byte(value.String()))
+	default:
+		return redactHash([]byte(fmt.Sprintf("%+v", value)))
+	}
+}
+
+func redactHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "hash:" + hex.EncodeToString(sum[:8])
+}
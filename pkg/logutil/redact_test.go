@@ -0,0 +1,64 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logutil
+
+import (
+	"github.com/pingcap/check"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type testStringer string
+
+func (s testStringer) String() string { return string(s) }
+
+func encodeField(field zap.Field) interface{} {
+	enc := zapcore.NewMapObjectEncoder()
+	field.AddTo(enc)
+	return enc.Fields[field.Key]
+}
+
+func (s *logSuite) TestRedact(c *check.C) {
+	defer SetRedactMode(RedactModeOff) //nolint:errcheck
+
+	c.Assert(GetRedactMode(), check.Equals, RedactModeOff)
+	c.Assert(ZapRedactByteString("key", []byte("abc")), check.DeepEquals, zap.ByteString("key", []byte("abc")))
+	c.Assert(ZapRedactString("query", "drop table t"), check.DeepEquals, zap.String("query", "drop table t"))
+	c.Assert(encodeField(ZapRedactStringer("span", testStringer("abc"))), check.Equals, "abc")
+
+	err := SetRedactMode(RedactModeHash)
+	c.Assert(err, check.IsNil)
+	hashed := encodeField(ZapRedactBinary("key", []byte("abc")))
+	c.Assert(hashed, check.Equals, "hash:ba7816bf8f01cfea")
+	// the same data is hashed to the same value
+	c.Assert(encodeField(ZapRedactString("key", "abc")), check.Equals, hashed)
+	c.Assert(encodeField(ZapRedactByteString("key", []byte("abc"))), check.Equals, hashed)
+	c.Assert(encodeField(ZapRedactStringer("span", testStringer("abc"))), check.Equals, hashed)
+	c.Assert(encodeField(ZapRedactStrings("sqls", []string{"abc", "abc"})), check.Equals,
+		"["+hashed.(string)+","+hashed.(string)+"]")
+	c.Assert(encodeField(ZapRedactReflect("row", struct{ A int }{1})), check.Matches, "hash:[0-9a-f]{16}")
+
+	err = SetRedactMode(RedactModeElide)
+	c.Assert(err, check.IsNil)
+	c.Assert(encodeField(ZapRedactBinary("key", []byte("abc"))), check.Equals, "?")
+	c.Assert(encodeField(ZapRedactReflect("row", struct{ A int }{1})), check.Equals, "?")
+	c.Assert(encodeField(ZapRedactStringer("span", testStringer("abc"))), check.Equals, "?")
+
+	err = SetRedactMode("")
+	c.Assert(err, check.IsNil)
+	c.Assert(GetRedactMode(), check.Equals, RedactModeOff)
+	err = SetRedactMode("mask")
+	c.Assert(err, check.ErrorMatches, ".*invalid log redact mode mask.*")
+	c.Assert(GetRedactMode(), check.Equals, RedactModeOff)
+}
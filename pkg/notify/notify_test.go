@@ -19,6 +19,8 @@ import (
 	"time"
 
 	"github.com/pingcap/check"
+	"github.com/pingcap/errors"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
 )
 
 func Test(t *testing.T) {
@@ -95,3 +97,71 @@ func (s *notifySuite) TestContinusStop(c *check.C) {
 	}
 	<-ctx.Done()
 }
+
+func (s *notifySuite) TestValueNotifierPolicy(c *check.C) {
+	ctx := context.Background()
+	notifier := NewValueNotifier(PolicyQueue)
+	queue := notifier.NewReceiver()
+	defer queue.Stop()
+	latest := NewValueNotifier(PolicyLatest).NewReceiver()
+	defer latest.Stop()
+	for i := 1; i <= 3; i++ {
+		notifier.Notify(uint64(i))
+		latest.notifier.Notify(uint64(i))
+	}
+	for i := 1; i <= 3; i++ {
+		v, err := queue.Recv(ctx)
+		c.Assert(err, check.IsNil)
+		c.Assert(v, check.Equals, uint64(i))
+	}
+	v, err := latest.Recv(ctx)
+	c.Assert(err, check.IsNil)
+	c.Assert(v, check.Equals, uint64(3))
+	_, ok := latest.TryRecv()
+	c.Assert(ok, check.IsFalse)
+
+	// the receivers created later don't get the earlier payloads
+	late := notifier.NewReceiver()
+	defer late.Stop()
+	_, ok = late.TryRecv()
+	c.Assert(ok, check.IsFalse)
+}
+
+func (s *notifySuite) TestValueReceiverRecv(c *check.C) {
+	notifier := NewValueNotifier(PolicyQueue)
+	r := notifier.NewReceiver()
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		notifier.Notify("done")
+	}()
+	v, err := r.Recv(context.Background())
+	c.Assert(err, check.IsNil)
+	c.Assert(v, check.Equals, "done")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = r.Recv(ctx)
+	c.Assert(errors.Cause(err), check.Equals, context.DeadlineExceeded)
+
+	// the payloads are received before the receiver reports it's stopped
+	notifier.Notify("last")
+	notifier.Close()
+	v, err = r.Recv(context.Background())
+	c.Assert(err, check.IsNil)
+	c.Assert(v, check.Equals, "last")
+	_, err = r.Recv(context.Background())
+	c.Assert(cerror.ErrReceiverStopped.Equal(err), check.IsTrue)
+	c.Assert(notifier.receivers, check.HasLen, 0)
+
+	// a receiver of the closed notifier is stopped
+	_, err = notifier.NewReceiver().Recv(context.Background())
+	c.Assert(cerror.ErrReceiverStopped.Equal(err), check.IsTrue)
+
+	notifier = NewValueNotifier(PolicyLatest)
+	r = notifier.NewReceiver()
+	r.Stop()
+	notifier.Notify(1)
+	_, err = r.Recv(context.Background())
+	c.Assert(cerror.ErrReceiverStopped.Equal(err), check.IsTrue)
+	c.Assert(notifier.receivers, check.HasLen, 0)
+}
@@ -0,0 +1,174 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pingcap/errors"
+	cdcContext "github.com/pingcap/ticdc/pkg/context"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+)
+
+// Policy is how the payloads not received yet by a receiver are coalesced
+type Policy int
+
+const (
+	// PolicyLatest keeps the latest payload only, the earlier ones are dropped
+	PolicyLatest Policy = iota
+	// PolicyQueue keeps all the payloads in the order they're notified
+	PolicyQueue
+)
+
+// ValueNotifier delivers the payloads to its receivers, every receiver gets
+// the payloads notified after it's created, coalesced by the policy. Unlike
+// Notifier, the payloads are never lost, so the receivers don't need to poll.
+type ValueNotifier struct {
+	policy Policy
+
+	mu        sync.RWMutex
+	receivers map[*ValueReceiver]struct{}
+	closed    bool
+}
+
+// NewValueNotifier creates a ValueNotifier with the coalescing policy
+func NewValueNotifier(policy Policy) *ValueNotifier {
+	return &ValueNotifier{
+		policy:    policy,
+		receivers: make(map[*ValueReceiver]struct{}),
+	}
+}
+
+// Notify delivers the payload to all the receivers, it never blocks.
+func (n *ValueNotifier) Notify(v interface{}) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	for r := range n.receivers {
+		r.push(v)
+	}
+}
+
+// NewReceiver creates a receiver of the payloads, which must be stopped once
+// it's not used. The receiver of a closed notifier is stopped.
+func (n *ValueNotifier) NewReceiver() *ValueReceiver {
+	r := &ValueReceiver{
+		notifier: n,
+		signal:   make(chan struct{}, 1),
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.closed {
+		r.stopped = true
+		return r
+	}
+	n.receivers[r] = struct{}{}
+	return r
+}
+
+// Close stops all the receivers, the payloads not received yet can still be
+// received.
+func (n *ValueNotifier) Close() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.closed = true
+	for r := range n.receivers {
+		r.stop()
+		delete(n.receivers, r)
+	}
+}
+
+// ValueReceiver receives the payloads of a ValueNotifier
+type ValueReceiver struct {
+	notifier *ValueNotifier
+	// signal is notified once a payload is pushed or the receiver is stopped
+	signal chan struct{}
+
+	mu      sync.Mutex
+	values  []interface{}
+	stopped bool
+}
+
+func (r *ValueReceiver) push(v interface{}) {
+	r.mu.Lock()
+	if r.stopped {
+		r.mu.Unlock()
+		return
+	}
+	if r.notifier.policy == PolicyLatest && len(r.values) > 0 {
+		r.values[0] = v
+	} else {
+		r.values = append(r.values, v)
+	}
+	r.mu.Unlock()
+	r.wakeup()
+}
+
+func (r *ValueReceiver) wakeup() {
+	select {
+	case r.signal <- struct{}{}:
+	default:
+	}
+}
+
+// TryRecv returns the earliest payload not received, ok is false if there is
+// no such payload.
+func (r *ValueReceiver) TryRecv() (v interface{}, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.values) == 0 {
+		return nil, false
+	}
+	v = r.values[0]
+	r.values[0] = nil
+	r.values = r.values[1:]
+	return v, true
+}
+
+// Recv blocks until a payload is received. It returns ErrReceiverStopped once
+// all the payloads are received after the receiver is stopped, or the error of
+// ctx if it's canceled. In an async section it returns ErrWouldBlock instead of
+// blocking.
+func (r *ValueReceiver) Recv(ctx context.Context) (interface{}, error) {
+	for {
+		if v, ok := r.TryRecv(); ok {
+			return v, nil
+		}
+		r.mu.Lock()
+		stopped := r.stopped
+		r.mu.Unlock()
+		if stopped {
+			return nil, cerror.ErrReceiverStopped.GenWithStackByArgs()
+		}
+		if err := cdcContext.Wait(ctx, r.signal); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+}
+
+// Stop stops the receiver, no payload is delivered to it afterwards.
+func (r *ValueReceiver) Stop() {
+	n := r.notifier
+	n.mu.Lock()
+	delete(n.receivers, r)
+	n.mu.Unlock()
+	r.stop()
+}
+
+func (r *ValueReceiver) stop() {
+	r.mu.Lock()
+	r.stopped = true
+	r.mu.Unlock()
+	r.wakeup()
+}
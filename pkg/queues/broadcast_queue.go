@@ -0,0 +1,182 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package queues
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pingcap/errors"
+	cdcContext "github.com/pingcap/ticdc/pkg/context"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+)
+
+// BroadcastQueue delivers every sent value to all the registered receivers.
+// Values are stored once in a shared ring buffer and each receiver keeps its
+// own cursor, so a receiver can lag at most `size` values behind the sender.
+// Send blocks when the slowest receiver is `size` values behind.
+type BroadcastQueue struct {
+	mu        sync.Mutex
+	buf       []interface{}
+	head      uint64
+	nextID    int
+	receivers map[int]*BroadcastReceiver
+	closed    bool
+	// changed is closed and replaced whenever the state of the queue changes,
+	// waking up all blocked senders and receivers.
+	changed chan struct{}
+}
+
+// BroadcastReceiver reads values from a BroadcastQueue.
+type BroadcastReceiver struct {
+	q      *BroadcastQueue
+	id     int
+	cursor uint64
+}
+
+// NewBroadcastQueue creates a BroadcastQueue in which each receiver can buffer
+// at most `size` values.
+func NewBroadcastQueue(size int) *BroadcastQueue {
+	if size <= 0 {
+		size = 1
+	}
+	return &BroadcastQueue{
+		buf:       make([]interface{}, size),
+		receivers: make(map[int]*BroadcastReceiver),
+		changed:   make(chan struct{}),
+	}
+}
+
+// NewReceiver registers a receiver which receives all the values sent after
+// it is registered.
+func (q *BroadcastQueue) NewReceiver() *BroadcastReceiver {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	r := &BroadcastReceiver{
+		q:      q,
+		id:     q.nextID,
+		cursor: q.head,
+	}
+	q.nextID++
+	q.receivers[r.id] = r
+	return r
+}
+
+// Send delivers v to all the registered receivers, blocking until every
+// receiver has room or ctx is done. If there is no receiver, v is dropped.
+// In an async section it returns ErrWouldBlock instead of blocking.
+func (q *BroadcastQueue) Send(ctx context.Context, v interface{}) error {
+	for {
+		q.mu.Lock()
+		if q.closed {
+			q.mu.Unlock()
+			return cerror.ErrQueueClosed.GenWithStackByArgs()
+		}
+		if q.head-q.minCursorLocked() < uint64(len(q.buf)) {
+			q.buf[q.head%uint64(len(q.buf))] = v
+			q.head++
+			q.notifyLocked()
+			q.mu.Unlock()
+			return nil
+		}
+		changed := q.changed
+		q.mu.Unlock()
+
+		if err := cdcContext.Wait(ctx, changed); err != nil {
+			return errors.Trace(err)
+		}
+	}
+}
+
+// Close closes the queue. Receivers can still drain the values sent before
+// Close, after that they get ErrQueueClosed.
+func (q *BroadcastQueue) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	q.closed = true
+	q.notifyLocked()
+}
+
+func (q *BroadcastQueue) minCursorLocked() uint64 {
+	min := q.head
+	for _, r := range q.receivers {
+		if r.cursor < min {
+			min = r.cursor
+		}
+	}
+	return min
+}
+
+func (q *BroadcastQueue) notifyLocked() {
+	close(q.changed)
+	q.changed = make(chan struct{})
+}
+
+// Receive returns the next value for this receiver, blocking until one is
+// available or ctx is done. In an async section it returns ErrWouldBlock
+// instead of blocking.
+func (r *BroadcastReceiver) Receive(ctx context.Context) (interface{}, error) {
+	q := r.q
+	for {
+		q.mu.Lock()
+		if _, ok := q.receivers[r.id]; !ok {
+			q.mu.Unlock()
+			return nil, cerror.ErrQueueClosed.GenWithStackByArgs()
+		}
+		if r.cursor < q.head {
+			idx := r.cursor % uint64(len(q.buf))
+			v := q.buf[idx]
+			r.cursor++
+			if r.cursor == q.minCursorLocked() {
+				// nobody else needs this slot any more, release the reference
+				q.buf[idx] = nil
+			}
+			q.notifyLocked()
+			q.mu.Unlock()
+			return v, nil
+		}
+		if q.closed {
+			q.mu.Unlock()
+			return nil, cerror.ErrQueueClosed.GenWithStackByArgs()
+		}
+		changed := q.changed
+		q.mu.Unlock()
+
+		if err := cdcContext.Wait(ctx, changed); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+}
+
+// Len returns the number of values buffered for this receiver.
+func (r *BroadcastReceiver) Len() int {
+	r.q.mu.Lock()
+	defer r.q.mu.Unlock()
+	return int(r.q.head - r.cursor)
+}
+
+// Stop unregisters the receiver, so that it no longer holds back the sender.
+func (r *BroadcastReceiver) Stop() {
+	q := r.q
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.receivers[r.id]; !ok {
+		return
+	}
+	delete(q.receivers, r.id)
+	q.notifyLocked()
+}
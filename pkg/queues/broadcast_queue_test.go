@@ -0,0 +1,89 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package queues
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pingcap/check"
+	"github.com/pingcap/errors"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+)
+
+type broadcastQueueSuite struct{}
+
+var _ = check.Suite(&broadcastQueueSuite{})
+
+func (s *broadcastQueueSuite) TestBroadcast(c *check.C) {
+	ctx := context.Background()
+	q := NewBroadcastQueue(8)
+	const receiverNum = 4
+	const total = 1000
+
+	var wg sync.WaitGroup
+	for i := 0; i < receiverNum; i++ {
+		r := q.NewReceiver()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for expected := 0; ; expected++ {
+				v, err := r.Receive(ctx)
+				if err != nil {
+					c.Assert(cerror.ErrQueueClosed.Equal(err), check.IsTrue)
+					c.Assert(expected, check.Equals, total)
+					return
+				}
+				c.Assert(v, check.Equals, expected)
+			}
+		}()
+	}
+	for i := 0; i < total; i++ {
+		c.Assert(q.Send(ctx, i), check.IsNil)
+	}
+	q.Close()
+	wg.Wait()
+}
+
+func (s *broadcastQueueSuite) TestSlowReceiver(c *check.C) {
+	ctx := context.Background()
+	q := NewBroadcastQueue(2)
+	fast := q.NewReceiver()
+	slow := q.NewReceiver()
+
+	c.Assert(q.Send(ctx, 1), check.IsNil)
+	c.Assert(q.Send(ctx, 2), check.IsNil)
+	v, err := fast.Receive(ctx)
+	c.Assert(err, check.IsNil)
+	c.Assert(v, check.Equals, 1)
+
+	// the slow receiver holds back the sender
+	cctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	err = q.Send(cctx, 3)
+	c.Assert(errors.Cause(err), check.Equals, context.DeadlineExceeded)
+	c.Assert(slow.Len(), check.Equals, 2)
+
+	// stopping the slow receiver unblocks the sender
+	slow.Stop()
+	c.Assert(q.Send(ctx, 3), check.IsNil)
+	c.Assert(fast.Len(), check.Equals, 2)
+	_, err = slow.Receive(ctx)
+	c.Assert(cerror.ErrQueueClosed.Equal(err), check.IsTrue)
+
+	// a new receiver only sees values sent after it is registered
+	late := q.NewReceiver()
+	c.Assert(late.Len(), check.Equals, 0)
+}
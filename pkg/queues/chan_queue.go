@@ -0,0 +1,211 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package queues
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+
+	"github.com/pingcap/errors"
+	cdcContext "github.com/pingcap/ticdc/pkg/context"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+)
+
+// ChanQueue is a bounded FIFO queue backed by a channel.
+// It is safe for concurrent use by multiple producers and consumers.
+type ChanQueue struct {
+	ch        chan interface{}
+	closeOnce sync.Once
+
+	// waiters are signaled after each Push and on Close, they are the
+	// callers of Select waiting on the queue.
+	mu      sync.Mutex
+	waiters map[chan struct{}]struct{}
+}
+
+// NewChanQueue creates a ChanQueue which can buffer at most `size` elements.
+func NewChanQueue(size int) *ChanQueue {
+	return &ChanQueue{
+		ch: make(chan interface{}, size),
+	}
+}
+
+// Push appends v to the queue, blocking until there is room or ctx is done.
+// In an async section it returns ErrWouldBlock if there is no room.
+// Pushing to a closed queue panics, as sending on a closed channel does.
+func (q *ChanQueue) Push(ctx context.Context, v interface{}) error {
+	if cdcContext.IsAsync(ctx) {
+		select {
+		case q.ch <- v:
+			q.notifyWaiters()
+			return nil
+		default:
+			return cerror.ErrWouldBlock.GenWithStackByArgs()
+		}
+	}
+	select {
+	case <-ctx.Done():
+		return errors.Trace(ctx.Err())
+	case q.ch <- v:
+	}
+	q.notifyWaiters()
+	return nil
+}
+
+// Pop removes and returns the head of the queue, blocking until an element
+// is available or ctx is done. It returns ErrQueueClosed once the queue has
+// been closed and drained. In an async section it returns ErrWouldBlock if
+// the queue is empty.
+func (q *ChanQueue) Pop(ctx context.Context) (interface{}, error) {
+	if cdcContext.IsAsync(ctx) {
+		select {
+		case v, ok := <-q.ch:
+			if !ok {
+				return nil, cerror.ErrQueueClosed.GenWithStackByArgs()
+			}
+			return v, nil
+		default:
+			return nil, cerror.ErrWouldBlock.GenWithStackByArgs()
+		}
+	}
+	select {
+	case <-ctx.Done():
+		return nil, errors.Trace(ctx.Err())
+	case v, ok := <-q.ch:
+		if !ok {
+			return nil, cerror.ErrQueueClosed.GenWithStackByArgs()
+		}
+		return v, nil
+	}
+}
+
+// TryPop returns the head of the queue without blocking.
+// The second return value is false if the queue is empty or closed.
+func (q *ChanQueue) TryPop() (interface{}, bool) {
+	select {
+	case v, ok := <-q.ch:
+		return v, ok
+	default:
+		return nil, false
+	}
+}
+
+// Len returns the number of buffered elements.
+func (q *ChanQueue) Len() int {
+	return len(q.ch)
+}
+
+// Cap returns the capacity of the queue.
+func (q *ChanQueue) Cap() int {
+	return cap(q.ch)
+}
+
+// Close closes the queue. Buffered elements can still be popped after Close.
+func (q *ChanQueue) Close() {
+	q.closeOnce.Do(func() {
+		close(q.ch)
+		q.notifyWaiters()
+	})
+}
+
+// addWaiter registers ch to be signaled once an element is pushed or the
+// queue is closed.
+func (q *ChanQueue) addWaiter(ch chan struct{}) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.waiters == nil {
+		q.waiters = make(map[chan struct{}]struct{})
+	}
+	q.waiters[ch] = struct{}{}
+}
+
+func (q *ChanQueue) removeWaiter(ch chan struct{}) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.waiters, ch)
+}
+
+// notifyWaiters signals the waiters non-blockingly
+func (q *ChanQueue) notifyWaiters() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for ch := range q.waiters {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Select waits on all the given queues and returns the first available value
+// together with the index of the queue it came from. No element is taken from
+// a queue unless it is returned.
+// A closed and drained queue is skipped; if every queue is closed,
+// ErrQueueClosed is returned. In an async section ErrWouldBlock is returned
+// if no queue is ready.
+func Select(ctx context.Context, qs ...*ChanQueue) (interface{}, int, error) {
+	async := cdcContext.IsAsync(ctx)
+	var notify chan struct{}
+	if !async {
+		// the waiter is registered before the queues are checked, so that a
+		// push after the check is never missed.
+		notify = make(chan struct{}, 1)
+		for _, q := range qs {
+			q.addWaiter(notify)
+		}
+		defer func() {
+			for _, q := range qs {
+				q.removeWaiter(notify)
+			}
+		}()
+	}
+	closed := make([]bool, len(qs))
+	remaining := len(qs)
+	// start from a random queue so that a busy queue can't starve the others
+	start := 0
+	if len(qs) > 1 {
+		start = rand.Intn(len(qs))
+	}
+	for remaining > 0 {
+		for i := range qs {
+			idx := (start + i) % len(qs)
+			if closed[idx] {
+				continue
+			}
+			select {
+			case v, ok := <-qs[idx].ch:
+				if !ok {
+					closed[idx] = true
+					remaining--
+					continue
+				}
+				return v, idx, nil
+			default:
+			}
+		}
+		if remaining == 0 {
+			break
+		}
+		if async {
+			return nil, -1, cerror.ErrWouldBlock.GenWithStackByArgs()
+		}
+		select {
+		case <-ctx.Done():
+			return nil, -1, errors.Trace(ctx.Err())
+		case <-notify:
+		}
+	}
+	return nil, -1, cerror.ErrQueueClosed.GenWithStackByArgs()
+}
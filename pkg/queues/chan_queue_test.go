@@ -0,0 +1,156 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package queues
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pingcap/check"
+	"github.com/pingcap/errors"
+	cdcContext "github.com/pingcap/ticdc/pkg/context"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+)
+
+func Test(t *testing.T) {
+	check.TestingT(t)
+}
+
+type chanQueueSuite struct{}
+
+var _ = check.Suite(&chanQueueSuite{})
+
+func (s *chanQueueSuite) TestPushPop(c *check.C) {
+	ctx := context.Background()
+	q := NewChanQueue(2)
+	c.Assert(q.Push(ctx, 1), check.IsNil)
+	c.Assert(q.Push(ctx, 2), check.IsNil)
+	c.Assert(q.Len(), check.Equals, 2)
+
+	cctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	err := q.Push(cctx, 3)
+	c.Assert(errors.Cause(err), check.Equals, context.DeadlineExceeded)
+
+	v, err := q.Pop(ctx)
+	c.Assert(err, check.IsNil)
+	c.Assert(v, check.Equals, 1)
+	v, ok := q.TryPop()
+	c.Assert(ok, check.IsTrue)
+	c.Assert(v, check.Equals, 2)
+	_, ok = q.TryPop()
+	c.Assert(ok, check.IsFalse)
+
+	q.Close()
+	_, err = q.Pop(ctx)
+	c.Assert(cerror.ErrQueueClosed.Equal(err), check.IsTrue)
+}
+
+func (s *chanQueueSuite) TestSelect(c *check.C) {
+	ctx := context.Background()
+	q1 := NewChanQueue(1)
+	q2 := NewChanQueue(1)
+	c.Assert(q2.Push(ctx, "b"), check.IsNil)
+
+	v, idx, err := Select(ctx, q1, q2)
+	c.Assert(err, check.IsNil)
+	c.Assert(v, check.Equals, "b")
+	c.Assert(idx, check.Equals, 1)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		_ = q1.Push(ctx, "a")
+	}()
+	v, idx, err = Select(ctx, q1, q2)
+	c.Assert(err, check.IsNil)
+	c.Assert(v, check.Equals, "a")
+	c.Assert(idx, check.Equals, 0)
+
+	// closed queues are skipped
+	q1.Close()
+	c.Assert(q2.Push(ctx, "c"), check.IsNil)
+	v, idx, err = Select(ctx, q1, q2)
+	c.Assert(err, check.IsNil)
+	c.Assert(v, check.Equals, "c")
+	c.Assert(idx, check.Equals, 1)
+
+	cctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, _, err = Select(cctx, q1, q2)
+	c.Assert(errors.Cause(err), check.Equals, context.DeadlineExceeded)
+
+	q2.Close()
+	_, _, err = Select(ctx, q1, q2)
+	c.Assert(cerror.ErrQueueClosed.Equal(err), check.IsTrue)
+}
+
+func (s *chanQueueSuite) TestSelectTakesOnlyReturned(c *check.C) {
+	ctx := context.Background()
+	qs := []*ChanQueue{NewChanQueue(1), NewChanQueue(1), NewChanQueue(1)}
+	for i, q := range qs {
+		c.Assert(q.Push(ctx, i), check.IsNil)
+	}
+	v, idx, err := Select(ctx, qs...)
+	c.Assert(err, check.IsNil)
+	c.Assert(v, check.Equals, idx)
+	// the other queues keep their elements
+	for i, q := range qs {
+		if i == idx {
+			c.Assert(q.Len(), check.Equals, 0)
+		} else {
+			c.Assert(q.Len(), check.Equals, 1)
+		}
+	}
+
+	// the element pushed is kept if the select is canceled
+	cctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	q := NewChanQueue(1)
+	_, _, err = Select(cctx, q)
+	c.Assert(errors.Cause(err), check.Equals, context.DeadlineExceeded)
+	c.Assert(q.Push(ctx, 1), check.IsNil)
+	c.Assert(q.Len(), check.Equals, 1)
+	c.Assert(q.waiters, check.HasLen, 0)
+}
+
+func (s *chanQueueSuite) TestAsync(c *check.C) {
+	ctx := cdcContext.WithAsync(context.Background())
+	q := NewChanQueue(1)
+	_, err := q.Pop(ctx)
+	c.Assert(cerror.ErrWouldBlock.Equal(err), check.IsTrue)
+	c.Assert(q.Push(ctx, 1), check.IsNil)
+	err = q.Push(ctx, 2)
+	c.Assert(cerror.ErrWouldBlock.Equal(err), check.IsTrue)
+
+	q.Close()
+	v, err := q.Pop(ctx)
+	c.Assert(err, check.IsNil)
+	c.Assert(v, check.Equals, 1)
+	_, err = q.Pop(ctx)
+	c.Assert(cerror.ErrQueueClosed.Equal(err), check.IsTrue)
+
+	q = NewChanQueue(1)
+	_, _, err = Select(ctx, q)
+	c.Assert(cerror.ErrWouldBlock.Equal(err), check.IsTrue)
+	c.Assert(q.Push(ctx, 1), check.IsNil)
+	v, idx, err := Select(ctx, q)
+	c.Assert(err, check.IsNil)
+	c.Assert(v, check.Equals, 1)
+	c.Assert(idx, check.Equals, 0)
+
+	q.Close()
+	_, _, err = Select(ctx, q)
+	c.Assert(cerror.ErrQueueClosed.Equal(err), check.IsTrue)
+}
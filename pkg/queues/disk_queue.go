@@ -0,0 +1,308 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package queues
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	cdcContext "github.com/pingcap/ticdc/pkg/context"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+	"github.com/pingcap/ticdc/pkg/spill"
+	"go.uber.org/zap"
+)
+
+const defaultSegmentSizeLimit = 64 * 1024 * 1024 // 64MB per segment file at most
+
+// diskSegment is a spill file holding a contiguous range of the queue.
+type diskSegment struct {
+	path      string
+	remaining int
+	rfile     *os.File
+	reader    *bufio.Reader
+}
+
+// DiskQueue is an unbounded FIFO queue which keeps at most `memLimit` elements
+// in memory and spills the overflow to files under `dir`.
+//
+// Elements are spilled in the same format as the file sorter, see package
+// spill. Since the decoded type can't be inferred from the encoded data,
+// `newItem` must return a pointer to a fresh value, and only the elements of
+// that pointer type can be pushed, so that an element popped from the disk has
+// the same type as the one pushed.
+type DiskQueue struct {
+	dir      string
+	prefix   string
+	memLimit int
+	newItem  func() interface{}
+	itemType reflect.Type
+
+	mu sync.Mutex
+	// mem holds the oldest elements, all of them are older than
+	// the elements on disk.
+	mem       []interface{}
+	segments  []*diskSegment
+	wfile     *os.File
+	writer    *bufio.Writer
+	wsize     int
+	diskCount int
+	segmentID int
+	closed    bool
+
+	// notEmpty is signaled non-blockingly after each Push
+	notEmpty chan struct{}
+	encoder  spill.Encoder
+	decoder  spill.Decoder
+}
+
+// NewDiskQueue creates a DiskQueue. Spill files are named after `prefix`
+// so that several queues can share the same directory.
+func NewDiskQueue(dir, prefix string, memLimit int, newItem func() interface{}) (*DiskQueue, error) {
+	if memLimit <= 0 {
+		return nil, cerror.ErrDiskQueueInvalidConfig.GenWithStackByArgs("memory limit must be positive")
+	}
+	itemType := reflect.TypeOf(newItem())
+	if itemType == nil || itemType.Kind() != reflect.Ptr {
+		return nil, cerror.ErrDiskQueueInvalidConfig.GenWithStackByArgs("newItem must return a pointer")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, cerror.WrapError(cerror.ErrFileSorterOpenFile, err)
+	}
+	return &DiskQueue{
+		dir:      dir,
+		prefix:   prefix,
+		memLimit: memLimit,
+		newItem:  newItem,
+		itemType: itemType,
+		mem:      make([]interface{}, 0, memLimit),
+		notEmpty: make(chan struct{}, 1),
+	}, nil
+}
+
+// Push appends v to the queue, it never blocks on a full in-memory window.
+// v must have the type returned by `newItem`.
+func (q *DiskQueue) Push(v interface{}) error {
+	if t := reflect.TypeOf(v); t != q.itemType {
+		return cerror.ErrDiskQueueInvalidItem.GenWithStackByArgs(t, q.itemType)
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return cerror.ErrQueueClosed.GenWithStackByArgs()
+	}
+	if q.diskCount == 0 && len(q.mem) < q.memLimit {
+		q.mem = append(q.mem, v)
+	} else if err := q.spill(v); err != nil {
+		return errors.Trace(err)
+	}
+	select {
+	case q.notEmpty <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Pop removes and returns the head of the queue, blocking until an element
+// is available or ctx is done. In an async section it returns ErrWouldBlock
+// if the queue is empty.
+func (q *DiskQueue) Pop(ctx context.Context) (interface{}, error) {
+	for {
+		v, ok, err := q.TryPop()
+		if err != nil || ok {
+			return v, err
+		}
+		if err := cdcContext.Wait(ctx, q.notEmpty); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+}
+
+// TryPop returns the head of the queue without blocking.
+// The second return value is false if the queue is empty.
+func (q *DiskQueue) TryPop() (interface{}, bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return nil, false, cerror.ErrQueueClosed.GenWithStackByArgs()
+	}
+	// refill the memory before popping the last element in it, so that the
+	// head is kept if the loading fails. The head doesn't count against the
+	// memory limit since it's leaving.
+	if len(q.mem) <= 1 && q.diskCount > 0 {
+		if err := q.load(); err != nil {
+			return nil, false, errors.Trace(err)
+		}
+	}
+	if len(q.mem) == 0 {
+		return nil, false, nil
+	}
+	v := q.mem[0]
+	q.mem[0] = nil
+	q.mem = q.mem[1:]
+	return v, true, nil
+}
+
+// Len returns the total number of elements, both in memory and on disk.
+func (q *DiskQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.mem) + q.diskCount
+}
+
+// DiskLen returns the number of elements spilled to disk.
+func (q *DiskQueue) DiskLen() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.diskCount
+}
+
+// Close drops all queued elements and removes the spill files.
+func (q *DiskQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return nil
+	}
+	q.closed = true
+	q.mem = nil
+	return errors.Trace(q.removeAllSegments())
+}
+
+func (q *DiskQueue) spill(v interface{}) error {
+	if q.writer == nil || q.wsize >= defaultSegmentSizeLimit {
+		if err := q.rotate(); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	n, err := q.encoder.Encode(q.writer, v)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	q.wsize += n
+	q.segments[len(q.segments)-1].remaining++
+	q.diskCount++
+	return nil
+}
+
+// rotate closes the current write segment and opens a new one.
+func (q *DiskQueue) rotate() error {
+	if err := q.closeWriter(); err != nil {
+		return errors.Trace(err)
+	}
+	path := filepath.Join(q.dir, fmt.Sprintf("%s-%d.spill", q.prefix, q.segmentID))
+	q.segmentID++
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return cerror.WrapError(cerror.ErrFileSorterOpenFile, err)
+	}
+	q.wfile = f
+	q.writer = bufio.NewWriter(f)
+	q.wsize = 0
+	q.segments = append(q.segments, &diskSegment{path: path})
+	return nil
+}
+
+func (q *DiskQueue) closeWriter() error {
+	if q.writer == nil {
+		return nil
+	}
+	if err := q.writer.Flush(); err != nil {
+		return cerror.WrapError(cerror.ErrFileSorterWriteFile, err)
+	}
+	if err := q.wfile.Close(); err != nil {
+		return cerror.WrapError(cerror.ErrFileSorterWriteFile, err)
+	}
+	q.writer = nil
+	q.wfile = nil
+	return nil
+}
+
+// load moves elements from disk into memory until there are memLimit elements
+// besides the head.
+func (q *DiskQueue) load() error {
+	// make sure all spilled data is visible to the readers
+	if q.writer != nil {
+		if err := q.writer.Flush(); err != nil {
+			return cerror.WrapError(cerror.ErrFileSorterWriteFile, err)
+		}
+	}
+	for len(q.mem) <= q.memLimit && q.diskCount > 0 {
+		seg := q.segments[0]
+		if seg.reader == nil {
+			f, err := os.Open(seg.path)
+			if err != nil {
+				return cerror.WrapError(cerror.ErrFileSorterOpenFile, err)
+			}
+			seg.rfile = f
+			seg.reader = bufio.NewReader(f)
+		}
+		v, err := q.readOne(seg.reader)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		q.mem = append(q.mem, v)
+		seg.remaining--
+		q.diskCount--
+		if seg.remaining == 0 && len(q.segments) > 1 {
+			q.segments = q.segments[1:]
+			q.removeSegment(seg)
+		}
+	}
+	if q.diskCount == 0 {
+		// all spilled data has been consumed, start over with a fresh segment
+		// next time to keep the disk usage bounded.
+		return errors.Trace(q.removeAllSegments())
+	}
+	return nil
+}
+
+func (q *DiskQueue) readOne(rd *bufio.Reader) (interface{}, error) {
+	v := q.newItem()
+	if err := q.decoder.Decode(rd, v); err != nil {
+		if err == io.EOF {
+			return nil, cerror.ErrFileSorterInvalidData.GenWithStack("spill file %s ends early", q.segments[0].path)
+		}
+		return nil, errors.Trace(err)
+	}
+	return v, nil
+}
+
+func (q *DiskQueue) removeSegment(seg *diskSegment) {
+	if seg.rfile != nil {
+		if err := seg.rfile.Close(); err != nil {
+			log.Warn("close spill file failed", zap.String("path", seg.path), zap.Error(err))
+		}
+	}
+	if err := os.Remove(seg.path); err != nil && !os.IsNotExist(err) {
+		log.Warn("remove spill file failed", zap.String("path", seg.path), zap.Error(err))
+	}
+}
+
+func (q *DiskQueue) removeAllSegments() error {
+	err := q.closeWriter()
+	for _, seg := range q.segments {
+		q.removeSegment(seg)
+	}
+	q.segments = nil
+	q.diskCount = 0
+	return err
+}
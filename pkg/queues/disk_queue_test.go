@@ -0,0 +1,129 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package queues
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pingcap/check"
+	"github.com/pingcap/errors"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+)
+
+type diskQueueSuite struct{}
+
+var _ = check.Suite(&diskQueueSuite{})
+
+type testItem struct {
+	ID   int
+	Data string
+}
+
+func newTestItem() interface{} {
+	return new(testItem)
+}
+
+func (s *diskQueueSuite) TestSpillAndOrder(c *check.C) {
+	dir := c.MkDir()
+	q, err := NewDiskQueue(dir, "test", 4, newTestItem)
+	c.Assert(err, check.IsNil)
+	defer q.Close()
+
+	const total = 100
+	for i := 0; i < total; i++ {
+		c.Assert(q.Push(&testItem{ID: i, Data: "data"}), check.IsNil)
+	}
+	c.Assert(q.Len(), check.Equals, total)
+	c.Assert(q.DiskLen(), check.Equals, total-4)
+	files, err := ioutil.ReadDir(dir)
+	c.Assert(err, check.IsNil)
+	c.Assert(files, check.HasLen, 1)
+
+	ctx := context.Background()
+	for i := 0; i < total/2; i++ {
+		v, err := q.Pop(ctx)
+		c.Assert(err, check.IsNil)
+		c.Assert(v.(*testItem).ID, check.Equals, i)
+	}
+	// interleave pushes with pops, the order must be kept
+	for i := total; i < total+10; i++ {
+		c.Assert(q.Push(&testItem{ID: i}), check.IsNil)
+	}
+	for i := total / 2; i < total+10; i++ {
+		v, ok, err := q.TryPop()
+		c.Assert(err, check.IsNil)
+		c.Assert(ok, check.IsTrue)
+		c.Assert(v.(*testItem).ID, check.Equals, i)
+	}
+	c.Assert(q.Len(), check.Equals, 0)
+	files, err = ioutil.ReadDir(dir)
+	c.Assert(err, check.IsNil)
+	c.Assert(files, check.HasLen, 0)
+}
+
+func (s *diskQueueSuite) TestBlockingPop(c *check.C) {
+	q, err := NewDiskQueue(c.MkDir(), "test", 1, newTestItem)
+	c.Assert(err, check.IsNil)
+	defer q.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = q.Pop(ctx)
+	c.Assert(errors.Cause(err), check.Equals, context.DeadlineExceeded)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		_ = q.Push(&testItem{ID: 1})
+	}()
+	v, err := q.Pop(context.Background())
+	c.Assert(err, check.IsNil)
+	c.Assert(v.(*testItem).ID, check.Equals, 1)
+}
+
+func (s *diskQueueSuite) TestItemType(c *check.C) {
+	_, err := NewDiskQueue(c.MkDir(), "test", 1, func() interface{} { return testItem{} })
+	c.Assert(cerror.ErrDiskQueueInvalidConfig.Equal(err), check.IsTrue)
+
+	q, err := NewDiskQueue(c.MkDir(), "test", 1, newTestItem)
+	c.Assert(err, check.IsNil)
+	defer q.Close()
+	// the value would be decoded into a pointer once it's spilled
+	err = q.Push(testItem{ID: 1})
+	c.Assert(cerror.ErrDiskQueueInvalidItem.Equal(err), check.IsTrue)
+	c.Assert(q.Len(), check.Equals, 0)
+}
+
+func (s *diskQueueSuite) TestLoadFailureKeepsHead(c *check.C) {
+	dir := c.MkDir()
+	q, err := NewDiskQueue(dir, "test", 1, newTestItem)
+	c.Assert(err, check.IsNil)
+	defer q.Close()
+
+	c.Assert(q.Push(&testItem{ID: 1}), check.IsNil)
+	c.Assert(q.Push(&testItem{ID: 2}), check.IsNil)
+	c.Assert(q.DiskLen(), check.Equals, 1)
+	files, err := ioutil.ReadDir(dir)
+	c.Assert(err, check.IsNil)
+	c.Assert(files, check.HasLen, 1)
+	c.Assert(os.Remove(filepath.Join(dir, files[0].Name())), check.IsNil)
+
+	_, ok, err := q.TryPop()
+	c.Assert(err, check.NotNil)
+	c.Assert(ok, check.IsFalse)
+	c.Assert(q.Len(), check.Equals, 2)
+}
@@ -0,0 +1,189 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package security
+
+import (
+	"context"
+	"crypto/tls"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// certRotation notifies the long-lived connections to be re-established with
+// the rotated certificates.
+var certRotation = struct {
+	sync.Mutex
+	// generation is increased every time the certificates are reloaded
+	generation uint64
+	rotated    chan struct{}
+}{
+	rotated: make(chan struct{}),
+}
+
+// ReloadCertificates makes the certificates reloaded from the disk for the new
+// TLS handshakes, and notifies the connections dialed with the old ones. It's
+// called when SIGHUP is received or the certificate files are changed.
+func ReloadCertificates() {
+	certRotation.Lock()
+	defer certRotation.Unlock()
+	certRotation.generation++
+	close(certRotation.rotated)
+	certRotation.rotated = make(chan struct{})
+	log.Info("certificates are rotated", zap.Uint64("generation", certRotation.generation))
+}
+
+// CertificatesRotated returns a channel which is closed when the certificates
+// are reloaded next time.
+func CertificatesRotated() <-chan struct{} {
+	certRotation.Lock()
+	defer certRotation.Unlock()
+	return certRotation.rotated
+}
+
+func certGeneration() uint64 {
+	certRotation.Lock()
+	defer certRotation.Unlock()
+	return certRotation.generation
+}
+
+// fileStamp tells whether a file is changed
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func stampFile(path string) (fileStamp, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}, errors.Trace(err)
+	}
+	return fileStamp{modTime: info.ModTime(), size: info.Size()}, nil
+}
+
+// keyPairLoader loads the key pair for the TLS handshakes, the key pair is
+// reloaded if the files are changed or the certificates are rotated.
+type keyPairLoader struct {
+	certPath string
+	keyPath  string
+
+	mu         sync.Mutex
+	cert       *tls.Certificate
+	certStamp  fileStamp
+	keyStamp   fileStamp
+	generation uint64
+}
+
+var keyPairLoaders = struct {
+	sync.Mutex
+	loaders map[[2]string]*keyPairLoader
+}{
+	loaders: make(map[[2]string]*keyPairLoader),
+}
+
+// getKeyPairLoader returns the loader shared by the TLS configs with the paths
+func getKeyPairLoader(certPath, keyPath string) *keyPairLoader {
+	keyPairLoaders.Lock()
+	defer keyPairLoaders.Unlock()
+	key := [2]string{certPath, keyPath}
+	loader, ok := keyPairLoaders.loaders[key]
+	if !ok {
+		loader = &keyPairLoader{certPath: certPath, keyPath: keyPath}
+		keyPairLoaders.loaders[key] = loader
+	}
+	return loader
+}
+
+// get returns the up to date key pair. If the files can't be loaded, which may
+// happen when they're being replaced, the key pair loaded last time is used.
+func (l *keyPairLoader) get() (*tls.Certificate, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	generation := certGeneration()
+	certStamp, err := stampFile(l.certPath)
+	if err == nil {
+		var keyStamp fileStamp
+		keyStamp, err = stampFile(l.keyPath)
+		if err == nil && l.cert != nil && generation == l.generation &&
+			certStamp == l.certStamp && keyStamp == l.keyStamp {
+			return l.cert, nil
+		}
+		if err == nil {
+			var cert tls.Certificate
+			cert, err = tls.LoadX509KeyPair(l.certPath, l.keyPath)
+			if err == nil {
+				if l.cert != nil {
+					log.Info("certificate is reloaded", zap.String("cert", l.certPath))
+				}
+				l.cert = &cert
+				l.certStamp = certStamp
+				l.keyStamp = keyStamp
+				l.generation = generation
+				return l.cert, nil
+			}
+		}
+	}
+	if l.cert != nil {
+		log.Warn("failed to reload certificate, use the old one",
+			zap.String("cert", l.certPath), zap.Error(err))
+		return l.cert, nil
+	}
+	return nil, cerror.WrapError(cerror.ErrToTLSConfigFailed, err)
+}
+
+// WatchCertificates checks the files of the credential every interval, and
+// reloads the certificates once any of them is changed, until ctx is done.
+func (s *Credential) WatchCertificates(ctx context.Context, interval time.Duration) error {
+	paths := []string{s.CAPath, s.CertPath, s.KeyPath}
+	stamps := make([]fileStamp, len(paths))
+	for i, path := range paths {
+		if path != "" {
+			// a missing file is treated as changed once it appears
+			stamps[i], _ = stampFile(path)
+		}
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		changed := false
+		for i, path := range paths {
+			if path == "" {
+				continue
+			}
+			stamp, err := stampFile(path)
+			if err != nil {
+				log.Warn("failed to check certificate file", zap.String("path", path), zap.Error(err))
+				continue
+			}
+			if stamp != stamps[i] {
+				log.Info("certificate file is changed", zap.String("path", path))
+				stamps[i] = stamp
+				changed = true
+			}
+		}
+		if changed {
+			ReloadCertificates()
+		}
+	}
+}
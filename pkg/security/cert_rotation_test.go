@@ -0,0 +1,155 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package security
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pingcap/check"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func Test(t *testing.T) { check.TestingT(t) }
+
+type certRotationSuite struct{}
+
+var _ = check.Suite(&certRotationSuite{})
+
+// writeKeyPair writes a self-signed key pair with the serial number, which is
+// also used as the CA.
+func writeKeyPair(c *check.C, dir string, serial int64) (caPath, certPath, keyPath string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	c.Assert(err, check.IsNil)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: "ticdc"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	c.Assert(err, check.IsNil)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	c.Assert(err, check.IsNil)
+
+	certPath = filepath.Join(dir, "cert.pem")
+	keyPath = filepath.Join(dir, "key.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	c.Assert(ioutil.WriteFile(certPath, certPEM, 0644), check.IsNil)
+	c.Assert(ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600), check.IsNil)
+	return certPath, certPath, keyPath
+}
+
+func (s *certRotationSuite) TestKeyPairReload(c *check.C) {
+	dir := c.MkDir()
+	caPath, certPath, keyPath := writeKeyPair(c, dir, 1)
+	credential := &Credential{CAPath: caPath, CertPath: certPath, KeyPath: keyPath}
+	cfg, err := credential.ToTLSConfig()
+	c.Assert(err, check.IsNil)
+	c.Assert(cfg.Certificates, check.HasLen, 0)
+
+	cert1, err := cfg.GetClientCertificate(nil)
+	c.Assert(err, check.IsNil)
+	cert, err := cfg.GetCertificate(nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(cert, check.Equals, cert1)
+
+	// the key pair is reloaded once the files are changed
+	writeKeyPair(c, dir, 2)
+	later := time.Now().Add(time.Minute)
+	c.Assert(os.Chtimes(certPath, later, later), check.IsNil)
+	cert2, err := cfg.GetClientCertificate(nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(cert2.Certificate[0], check.Not(check.DeepEquals), cert1.Certificate[0])
+
+	// the old key pair is used if the files can't be loaded
+	c.Assert(ioutil.WriteFile(keyPath, []byte("broken"), 0600), check.IsNil)
+	ReloadCertificates()
+	cert, err = cfg.GetClientCertificate(nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(cert, check.Equals, cert2)
+}
+
+func (s *certRotationSuite) TestWatchCertificates(c *check.C) {
+	dir := c.MkDir()
+	caPath, certPath, keyPath := writeKeyPair(c, dir, 1)
+	credential := &Credential{CAPath: caPath, CertPath: certPath, KeyPath: keyPath}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rotated := CertificatesRotated()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- credential.WatchCertificates(ctx, 10*time.Millisecond)
+	}()
+
+	select {
+	case <-rotated:
+		c.Fatal("certificates are rotated without changes")
+	case <-time.After(50 * time.Millisecond):
+	}
+	later := time.Now().Add(time.Minute)
+	c.Assert(os.Chtimes(keyPath, later, later), check.IsNil)
+	select {
+	case <-rotated:
+	case <-time.After(5 * time.Second):
+		c.Fatal("certificates are not rotated after the files are changed")
+	}
+	cancel()
+	c.Assert(<-errCh, check.Equals, context.Canceled)
+}
+
+func (s *certRotationSuite) TestPDClientDialer(c *check.C) {
+	dir := c.MkDir()
+	caPath, certPath, keyPath := writeKeyPair(c, dir, 1)
+	credential := &Credential{CAPath: caPath, CertPath: certPath, KeyPath: keyPath}
+	serverCfg, err := credential.ToTLSConfig()
+	c.Assert(err, check.IsNil)
+	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(serverCfg)))
+	grpc_health_v1.RegisterHealthServer(server, health.NewServer())
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, check.IsNil)
+	go func() { _ = server.Serve(ln) }()
+	defer server.Stop()
+
+	// the pd client dials with an insecure transport, the TLS handshake is
+	// done by the dialer.
+	_, dialOption := credential.PDClientOptions()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, ln.Addr().String(), grpc.WithInsecure(), dialOption, grpc.WithBlock())
+	c.Assert(err, check.IsNil)
+	defer conn.Close()
+	resp, err := grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	c.Assert(err, check.IsNil)
+	c.Assert(resp.Status, check.Equals, grpc_health_v1.HealthCheckResponse_SERVING)
+}
@@ -14,7 +14,11 @@
 package security
 
 import (
+	"context"
 	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"time"
 
 	cerror "github.com/pingcap/ticdc/pkg/errors"
 	"github.com/pingcap/tidb-tools/pkg/utils"
@@ -29,11 +33,17 @@ type Credential struct {
 	CertPath      string   `toml:"cert-path" json:"cert-path"`
 	KeyPath       string   `toml:"key-path" json:"key-path"`
 	CertAllowedCN []string `toml:"cert-allowed-cn" json:"cert-allowed-cn"`
+
+	// CA, Cert and Key are the PEM contents used instead of the files, e.g.
+	// the ones of a changefeed credential, see SinkCredential.
+	CA   []byte `toml:"-" json:"-"`
+	Cert []byte `toml:"-" json:"-"`
+	Key  []byte `toml:"-" json:"-"`
 }
 
 // IsTLSEnabled checks whether TLS is enabled or not.
 func (s *Credential) IsTLSEnabled() bool {
-	return len(s.CAPath) != 0
+	return len(s.CAPath) != 0 || len(s.CA) != 0
 }
 
 // PDSecurityOption creates a new pd SecurityOption from Security
@@ -45,6 +55,43 @@ func (s *Credential) PDSecurityOption() pd.SecurityOption {
 	}
 }
 
+// PDClientOptions returns the security option and the gRPC dial option of a
+// long-lived pd client. The pd client loads the certificates once for each PD
+// member and keeps the connections forever, so the TLS handshakes are done by
+// the dialer instead, with the certificates reloaded once they're rotated.
+func (s *Credential) PDClientOptions() (pd.SecurityOption, grpc.DialOption) {
+	if !s.IsTLSEnabled() {
+		return pd.SecurityOption{}, grpc.WithInsecure()
+	}
+	return pd.SecurityOption{}, grpc.WithContextDialer(s.dialTLS)
+}
+
+func (s *Credential) dialTLS(ctx context.Context, addr string) (net.Conn, error) {
+	tlsCfg, err := s.ToTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, cerror.WrapError(cerror.ErrToTLSConfigFailed, err)
+	}
+	tlsCfg.ServerName = host
+	rawConn, err := (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	conn := tls.Client(rawConn, tlsCfg)
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	if err := conn.Handshake(); err != nil {
+		rawConn.Close()
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
 // ToGRPCDialOption constructs a gRPC dial option.
 func (s *Credential) ToGRPCDialOption() (grpc.DialOption, error) {
 	tlsCfg, err := s.ToTLSConfig()
@@ -54,15 +101,64 @@ func (s *Credential) ToGRPCDialOption() (grpc.DialOption, error) {
 	return grpc.WithTransportCredentials(credentials.NewTLS(tlsCfg)), nil
 }
 
-// ToTLSConfig generates tls's config from *Security
+// ToTLSConfig generates tls's config from *Security, the key pair is reloaded
+// for the TLS handshakes once it's rotated.
 func (s *Credential) ToTLSConfig() (*tls.Config, error) {
+	if len(s.CA) != 0 {
+		return s.pemToTLSConfig()
+	}
 	cfg, err := utils.ToTLSConfig(s.CAPath, s.CertPath, s.KeyPath)
-	return cfg, cerror.WrapError(cerror.ErrToTLSConfigFailed, err)
+	if err != nil {
+		return nil, cerror.WrapError(cerror.ErrToTLSConfigFailed, err)
+	}
+	s.reloadKeyPair(cfg)
+	return cfg, nil
 }
 
 // ToTLSConfigWithVerify generates tls's config from *Security and requires
 // verifing remote cert common name.
 func (s *Credential) ToTLSConfigWithVerify() (*tls.Config, error) {
 	cfg, err := utils.ToTLSConfigWithVerify(s.CAPath, s.CertPath, s.KeyPath, s.CertAllowedCN)
-	return cfg, cerror.WrapError(cerror.ErrToTLSConfigFailed, err)
+	if err != nil {
+		return nil, cerror.WrapError(cerror.ErrToTLSConfigFailed, err)
+	}
+	s.reloadKeyPair(cfg)
+	return cfg, nil
+}
+
+// pemToTLSConfig generates tls's config from the PEM contents
+func (s *Credential) pemToTLSConfig() (*tls.Config, error) {
+	certPool := x509.NewCertPool()
+	if !certPool.AppendCertsFromPEM(s.CA) {
+		return nil, cerror.ErrToTLSConfigFailed.GenWithStack("failed to append ca certs")
+	}
+	cfg := &tls.Config{
+		RootCAs:    certPool,
+		ClientCAs:  certPool,
+		NextProtos: []string{"h2", "http/1.1"},
+	}
+	if len(s.Cert) != 0 && len(s.Key) != 0 {
+		cert, err := tls.X509KeyPair(s.Cert, s.Key)
+		if err != nil {
+			return nil, cerror.WrapError(cerror.ErrToTLSConfigFailed, err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// reloadKeyPair makes the tls config get the key pair from the loader, which
+// reloads it once it's rotated.
+func (s *Credential) reloadKeyPair(cfg *tls.Config) {
+	if cfg == nil || len(cfg.Certificates) == 0 {
+		return
+	}
+	loader := getKeyPairLoader(s.CertPath, s.KeyPath)
+	cfg.Certificates = nil
+	cfg.GetCertificate = func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+		return loader.get()
+	}
+	cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		return loader.get()
+	}
 }
@@ -0,0 +1,167 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package security
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"github.com/pingcap/errors"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+)
+
+// SinkCredential is the credential of a changefeed to connect to the sink and
+// the schema registry, which takes precedence over the certificates and the
+// SASL secrets in the sink URI. It holds the PEM contents instead of the file
+// paths, so that it can be stored in etcd and used by all the captures.
+type SinkCredential struct {
+	CA   string `json:"ca,omitempty"`
+	Cert string `json:"cert,omitempty"`
+	Key  string `json:"key,omitempty"`
+	// SASLUser and SASLPassword are the secrets of the SASL authentication
+	SASLUser     string `json:"sasl-user,omitempty"`
+	SASLPassword string `json:"sasl-password,omitempty"`
+}
+
+// IsTLSEnabled checks whether the credential has a CA or not
+func (c *SinkCredential) IsTLSEnabled() bool {
+	return c != nil && len(c.CA) != 0
+}
+
+// IsSASLEnabled checks whether the credential has the SASL secrets or not
+func (c *SinkCredential) IsSASLEnabled() bool {
+	return c != nil && (len(c.SASLUser) != 0 || len(c.SASLPassword) != 0)
+}
+
+// TLSCredential returns the Credential of the PEM contents, or nil if TLS
+// isn't enabled.
+func (c *SinkCredential) TLSCredential() *Credential {
+	if !c.IsTLSEnabled() {
+		return nil
+	}
+	return &Credential{
+		CA:   []byte(c.CA),
+		Cert: []byte(c.Cert),
+		Key:  []byte(c.Key),
+	}
+}
+
+// Validate checks whether the PEM contents can be loaded
+func (c *SinkCredential) Validate() error {
+	if c == nil {
+		return nil
+	}
+	if !c.IsTLSEnabled() {
+		if len(c.Cert) != 0 || len(c.Key) != 0 {
+			return cerror.ErrToTLSConfigFailed.GenWithStack("the CA of the certificate is required")
+		}
+		return nil
+	}
+	_, err := c.TLSCredential().ToTLSConfig()
+	return err
+}
+
+// credentialKey is the key to encrypt the changefeed credentials stored in
+// etcd, it must be the same in all the captures and the cli.
+var credentialKey struct {
+	sync.RWMutex
+	key []byte
+}
+
+// LoadCredentialKey reads the hex-encoded 256-bit key from the file
+func LoadCredentialKey(path string) ([]byte, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, cerror.WrapError(cerror.ErrInvalidCredentialKey, err)
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(content)))
+	if err != nil {
+		return nil, cerror.WrapError(cerror.ErrInvalidCredentialKey, err)
+	}
+	if len(key) != 32 {
+		return nil, cerror.ErrInvalidCredentialKey.GenWithStackByArgs()
+	}
+	return key, nil
+}
+
+// SetCredentialKey sets the key to encrypt the changefeed credentials
+func SetCredentialKey(key []byte) {
+	credentialKey.Lock()
+	defer credentialKey.Unlock()
+	credentialKey.key = key
+}
+
+func newCredentialCipher() (cipher.AEAD, error) {
+	credentialKey.RLock()
+	key := credentialKey.key
+	credentialKey.RUnlock()
+	if len(key) == 0 {
+		return nil, cerror.ErrCredentialKeyNotSet.GenWithStackByArgs()
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, cerror.WrapError(cerror.ErrInvalidCredentialKey, err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, cerror.WrapError(cerror.ErrInvalidCredentialKey, err)
+	}
+	return aead, nil
+}
+
+// Encrypt encrypts the credential with the credential key by AES-GCM, the
+// nonce is prepended to the ciphertext.
+func (c *SinkCredential) Encrypt() ([]byte, error) {
+	aead, err := newCredentialCipher()
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := json.Marshal(c)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// DecryptSinkCredential decrypts the credential encrypted by Encrypt
+func DecryptSinkCredential(data []byte) (*SinkCredential, error) {
+	aead, err := newCredentialCipher()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, cerror.ErrDecryptCredential.GenWithStackByArgs()
+	}
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, cerror.WrapError(cerror.ErrDecryptCredential, err)
+	}
+	credential := &SinkCredential{}
+	if err := json.Unmarshal(plaintext, credential); err != nil {
+		return nil, cerror.WrapError(cerror.ErrDecryptCredential, err)
+	}
+	return credential, nil
+}
@@ -0,0 +1,87 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package security
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/pingcap/check"
+)
+
+type sinkCredentialSuite struct{}
+
+var _ = check.Suite(&sinkCredentialSuite{})
+
+func (s *sinkCredentialSuite) TestTLSCredential(c *check.C) {
+	dir := c.MkDir()
+	caPath, certPath, keyPath := writeKeyPair(c, dir, 1)
+	read := func(path string) string {
+		content, err := ioutil.ReadFile(path)
+		c.Assert(err, check.IsNil)
+		return string(content)
+	}
+	credential := &SinkCredential{CA: read(caPath), Cert: read(certPath), Key: read(keyPath)}
+	c.Assert(credential.Validate(), check.IsNil)
+	c.Assert(credential.IsSASLEnabled(), check.IsFalse)
+	tlsCredential := credential.TLSCredential()
+	c.Assert(tlsCredential.IsTLSEnabled(), check.IsTrue)
+	cfg, err := tlsCredential.ToTLSConfig()
+	c.Assert(err, check.IsNil)
+	c.Assert(cfg.Certificates, check.HasLen, 1)
+	c.Assert(cfg.RootCAs, check.NotNil)
+
+	credential.Key = "broken"
+	c.Assert(credential.Validate(), check.ErrorMatches, ".*ErrToTLSConfigFailed.*")
+	credential.CA = ""
+	c.Assert(credential.Validate(), check.ErrorMatches, ".*CA of the certificate is required.*")
+	var nilCredential *SinkCredential
+	c.Assert(nilCredential.Validate(), check.IsNil)
+	c.Assert(nilCredential.TLSCredential(), check.IsNil)
+}
+
+func (s *sinkCredentialSuite) TestEncrypt(c *check.C) {
+	defer SetCredentialKey(nil)
+	credential := &SinkCredential{SASLUser: "ticdc", SASLPassword: "secret"}
+	SetCredentialKey(nil)
+	_, err := credential.Encrypt()
+	c.Assert(err, check.ErrorMatches, ".*credential key is not set.*")
+
+	dir := c.MkDir()
+	keyPath := filepath.Join(dir, "credential.key")
+	c.Assert(ioutil.WriteFile(keyPath, []byte(hex.EncodeToString(bytes.Repeat([]byte{1}, 32))+"\n"), 0600), check.IsNil)
+	key, err := LoadCredentialKey(keyPath)
+	c.Assert(err, check.IsNil)
+	SetCredentialKey(key)
+	data, err := credential.Encrypt()
+	c.Assert(err, check.IsNil)
+	c.Assert(bytes.Contains(data, []byte("secret")), check.IsFalse)
+	decrypted, err := DecryptSinkCredential(data)
+	c.Assert(err, check.IsNil)
+	c.Assert(decrypted, check.DeepEquals, credential)
+
+	// the credential can't be decrypted by another key, or if it's corrupted
+	SetCredentialKey(bytes.Repeat([]byte{2}, 32))
+	_, err = DecryptSinkCredential(data)
+	c.Assert(err, check.ErrorMatches, ".*ErrDecryptCredential.*")
+	SetCredentialKey(key)
+	_, err = DecryptSinkCredential(data[:4])
+	c.Assert(err, check.ErrorMatches, ".*ErrDecryptCredential.*")
+
+	c.Assert(ioutil.WriteFile(keyPath, []byte("0102"), 0600), check.IsNil)
+	_, err = LoadCredentialKey(keyPath)
+	c.Assert(err, check.ErrorMatches, ".*invalid credential key.*")
+}
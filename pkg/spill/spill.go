@@ -0,0 +1,83 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package spill contains the encoding of the values spilled to the files by
+// the file sorters and the disk queues. A value is encoded with msgpack and
+// framed by an 8-byte big-endian length.
+package spill
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+const lenSize = 8
+
+// Encoder encodes the values into frames, it's not safe for concurrent use.
+type Encoder struct {
+	buf bytes.Buffer
+}
+
+// Encode writes the frame of v to w, and returns the number of bytes written
+func (e *Encoder) Encode(w io.Writer, v interface{}) (int, error) {
+	e.buf.Reset()
+	if err := msgpack.NewEncoder(&e.buf).Encode(v); err != nil {
+		return 0, cerror.WrapError(cerror.ErrFileSorterEncode, err)
+	}
+	var dataLen [lenSize]byte
+	binary.BigEndian.PutUint64(dataLen[:], uint64(e.buf.Len()))
+	if _, err := w.Write(dataLen[:]); err != nil {
+		return 0, cerror.WrapError(cerror.ErrFileSorterWriteFile, err)
+	}
+	if _, err := w.Write(e.buf.Bytes()); err != nil {
+		return 0, cerror.WrapError(cerror.ErrFileSorterWriteFile, err)
+	}
+	return lenSize + e.buf.Len(), nil
+}
+
+// Decoder decodes the values from frames, it's not safe for concurrent use.
+type Decoder struct {
+	buf bytes.Reader
+}
+
+// Decode reads the next frame from rd into v, which must be a pointer. It
+// returns io.EOF as it is if rd ends right before a frame.
+func (d *Decoder) Decode(rd io.Reader, v interface{}) error {
+	var byteLen [lenSize]byte
+	if _, err := io.ReadFull(rd, byteLen[:]); err != nil {
+		switch err {
+		case io.EOF:
+			return io.EOF
+		case io.ErrUnexpectedEOF:
+			return cerror.ErrFileSorterInvalidData.GenWithStack("truncated length %v", byteLen)
+		}
+		return cerror.WrapError(cerror.ErrFileSorterReadFile, err)
+	}
+	dataLen := binary.BigEndian.Uint64(byteLen[:])
+	data := make([]byte, dataLen)
+	if n, err := io.ReadFull(rd, data); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return cerror.ErrFileSorterInvalidData.GenWithStack("truncated data, read %d of %d bytes", n, dataLen)
+		}
+		return cerror.WrapError(cerror.ErrFileSorterReadFile, err)
+	}
+	d.buf.Reset(data)
+	if err := msgpack.NewDecoder(&d.buf).Decode(v); err != nil {
+		return cerror.WrapError(cerror.ErrFileSorterDecode, err)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spill
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/pingcap/check"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+)
+
+func Test(t *testing.T) {
+	check.TestingT(t)
+}
+
+type spillSuite struct{}
+
+var _ = check.Suite(&spillSuite{})
+
+type testValue struct {
+	ID   int
+	Data string
+}
+
+func (s *spillSuite) TestRoundTrip(c *check.C) {
+	var buf bytes.Buffer
+	var enc Encoder
+	total := 0
+	for i := 0; i < 3; i++ {
+		n, err := enc.Encode(&buf, &testValue{ID: i, Data: "data"})
+		c.Assert(err, check.IsNil)
+		total += n
+	}
+	c.Assert(buf.Len(), check.Equals, total)
+
+	var dec Decoder
+	for i := 0; i < 3; i++ {
+		v := new(testValue)
+		c.Assert(dec.Decode(&buf, v), check.IsNil)
+		c.Assert(*v, check.DeepEquals, testValue{ID: i, Data: "data"})
+	}
+	c.Assert(dec.Decode(&buf, new(testValue)), check.Equals, io.EOF)
+}
+
+func (s *spillSuite) TestTruncated(c *check.C) {
+	var buf bytes.Buffer
+	var enc Encoder
+	n, err := enc.Encode(&buf, &testValue{ID: 1})
+	c.Assert(err, check.IsNil)
+
+	var dec Decoder
+	for _, size := range []int{lenSize - 1, n - 1} {
+		err := dec.Decode(bytes.NewReader(buf.Bytes()[:size]), new(testValue))
+		c.Assert(cerror.ErrFileSorterInvalidData.Equal(err), check.IsTrue)
+	}
+}
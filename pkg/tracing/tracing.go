@@ -0,0 +1,231 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/jaeger"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	instrumentationName = "github.com/pingcap/ticdc/pkg/tracing"
+	shutdownTimeout     = 5 * time.Second
+)
+
+// The stages of the replication pipeline traced by an EventTrace
+const (
+	StagePuller  = "puller"
+	StageSorter  = "sorter"
+	StageMounter = "mounter"
+	StageSink    = "sink"
+)
+
+// Config is the config of tracing the row changes
+type Config struct {
+	// SampleRate is the ratio of the row changes traced, 0 disables tracing
+	SampleRate float64
+	// Endpoint is the address the spans are exported to, the default address
+	// of the exporter is used if it's empty.
+	Endpoint string
+}
+
+// Tags are the tags of a span. The callers only depend on the types of this
+// package, so that the exporter can be replaced without touching them.
+type Tags map[string]interface{}
+
+// sampleRate is the bits of the float64 sample rate
+var sampleRate uint64
+
+// Init sets the global tracer provider by the config, the returned closer
+// flushes the reported spans. The spans are exported to a jaeger agent through
+// OpenTelemetry. The row changes are sampled before the spans are created, so
+// the provider records all the spans it creates.
+func Init(serviceName string, cfg Config) (io.Closer, error) {
+	if cfg.SampleRate < 0 || cfg.SampleRate > 1 {
+		return nil, cerror.ErrInvalidServerOption.GenWithStack(
+			"invalid tracing sample rate %v, it must be in [0, 1]", cfg.SampleRate)
+	}
+	if cfg.SampleRate == 0 {
+		atomic.StoreUint64(&sampleRate, 0)
+		return nopCloser{}, nil
+	}
+	var agentOpts []jaeger.AgentEndpointOption
+	if cfg.Endpoint != "" {
+		host, port, err := net.SplitHostPort(cfg.Endpoint)
+		if err != nil {
+			return nil, cerror.WrapError(cerror.ErrInvalidServerOption, err)
+		}
+		agentOpts = append(agentOpts, jaeger.WithAgentHost(host), jaeger.WithAgentPort(port))
+	}
+	exporter, err := jaeger.New(jaeger.WithAgentEndpoint(agentOpts...))
+	if err != nil {
+		return nil, cerror.WrapError(cerror.ErrInvalidServerOption, err)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL, semconv.ServiceName(serviceName))),
+	)
+	otel.SetTracerProvider(provider)
+	atomic.StoreUint64(&sampleRate, math.Float64bits(cfg.SampleRate))
+	return providerCloser{provider: provider}, nil
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
+
+type providerCloser struct {
+	provider *sdktrace.TracerProvider
+}
+
+func (c providerCloser) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return c.provider.Shutdown(ctx)
+}
+
+func sampled() bool {
+	rate := math.Float64frombits(atomic.LoadUint64(&sampleRate))
+	return rate > 0 && rand.Float64() < rate
+}
+
+// EventTrace traces a row change across the stages of the replication
+// pipeline, the root span covers the whole lifecycle of the row change and
+// every stage is a child span of it. A nil EventTrace is a no-op, so that the
+// row changes not sampled only pay for a nil check.
+type EventTrace struct {
+	// ctx carries the root span, the stages are started from it
+	ctx  context.Context
+	root trace.Span
+	// the stages may run concurrently, e.g. the mounter and the sorter
+	mu       sync.Mutex
+	stages   map[string]trace.Span
+	finished bool
+}
+
+// StartEventTrace starts tracing a row change if it's sampled, otherwise it
+// returns nil. The tags are set on the root span.
+func StartEventTrace(tags Tags) *EventTrace {
+	if !sampled() {
+		return nil
+	}
+	attrs := make([]attribute.KeyValue, 0, len(tags))
+	for k, v := range tags {
+		attrs = append(attrs, toAttribute(k, v))
+	}
+	// the tracer is fetched from the global provider every time, since the
+	// provider may be replaced after Init
+	ctx, root := otel.Tracer(instrumentationName).Start(
+		context.Background(), "row-change", trace.WithAttributes(attrs...))
+	return &EventTrace{
+		ctx:    ctx,
+		root:   root,
+		stages: make(map[string]trace.Span),
+	}
+}
+
+func toAttribute(key string, value interface{}) attribute.KeyValue {
+	switch v := value.(type) {
+	case string:
+		return attribute.String(key, v)
+	case bool:
+		return attribute.Bool(key, v)
+	case int:
+		return attribute.Int(key, v)
+	case int64:
+		return attribute.Int64(key, v)
+	case uint64:
+		// the ts and ids fit in int64
+		return attribute.Int64(key, int64(v))
+	case float64:
+		return attribute.Float64(key, v)
+	default:
+		return attribute.String(key, fmt.Sprint(v))
+	}
+}
+
+// SetTag sets a tag on the root span
+func (t *EventTrace) SetTag(key string, value interface{}) {
+	if t == nil {
+		return
+	}
+	t.root.SetAttributes(toAttribute(key, value))
+}
+
+// StartStage starts the span of a stage
+func (t *EventTrace) StartStage(stage string) {
+	if t == nil {
+		return
+	}
+	_, span := otel.Tracer(instrumentationName).Start(t.ctx, stage)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.finished {
+		return
+	}
+	t.stages[stage] = span
+}
+
+// FinishStage finishes the span of a stage if it's started
+func (t *EventTrace) FinishStage(stage string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	span, ok := t.stages[stage]
+	delete(t.stages, stage)
+	t.mu.Unlock()
+	if ok {
+		span.End()
+	}
+}
+
+// Finish finishes the root span and the stages not finished, it's a no-op if
+// the trace is already finished.
+func (t *EventTrace) Finish() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	if t.finished {
+		t.mu.Unlock()
+		return
+	}
+	t.finished = true
+	stages := t.stages
+	t.stages = nil
+	t.mu.Unlock()
+	for _, span := range stages {
+		span.End()
+	}
+	t.root.End()
+}
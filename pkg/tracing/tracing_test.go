@@ -0,0 +1,82 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"math"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pingcap/check"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func Test(t *testing.T) { check.TestingT(t) }
+
+type tracingSuite struct{}
+
+var _ = check.Suite(&tracingSuite{})
+
+func (s *tracingSuite) TestEventTrace(c *check.C) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(trace.NewNoopTracerProvider())
+	defer atomic.StoreUint64(&sampleRate, 0)
+
+	// the row changes aren't traced if tracing is disabled
+	closer, err := Init("ticdc", Config{})
+	c.Assert(err, check.IsNil)
+	c.Assert(closer.Close(), check.IsNil)
+	trace := StartEventTrace(nil)
+	c.Assert(trace, check.IsNil)
+	trace.SetTag("commit-ts", 1)
+	trace.StartStage(StagePuller)
+	trace.FinishStage(StagePuller)
+	trace.Finish()
+	c.Assert(recorder.Ended(), check.HasLen, 0)
+
+	atomic.StoreUint64(&sampleRate, math.Float64bits(1))
+	trace = StartEventTrace(Tags{"changefeed": "test-cf"})
+	c.Assert(trace, check.NotNil)
+	trace.SetTag("commit-ts", 1)
+	trace.StartStage(StagePuller)
+	trace.FinishStage(StagePuller)
+	trace.StartStage(StageSorter)
+	trace.StartStage(StageMounter)
+	trace.FinishStage(StageMounter)
+	trace.Finish()
+	// the stages are ignored once the trace is finished
+	trace.StartStage(StageSink)
+	trace.Finish()
+
+	spans := recorder.Ended()
+	c.Assert(spans, check.HasLen, 4)
+	root := spans[3]
+	c.Assert(root.Name(), check.Equals, "row-change")
+	c.Assert(root.Attributes(), check.DeepEquals, []attribute.KeyValue{
+		attribute.String("changefeed", "test-cf"),
+		attribute.Int("commit-ts", 1),
+	})
+	for i, stage := range []string{StagePuller, StageMounter, StageSorter} {
+		c.Assert(spans[i].Name(), check.Equals, stage)
+		c.Assert(spans[i].Parent().SpanID(), check.Equals, root.SpanContext().SpanID())
+	}
+
+	_, err = Init("ticdc", Config{SampleRate: 2})
+	c.Assert(err, check.ErrorMatches, ".*invalid tracing sample rate 2.*")
+}
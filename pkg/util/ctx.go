@@ -48,6 +48,19 @@ func PutTimezoneInCtx(ctx context.Context, timezone *time.Location) context.Cont
 	return context.WithValue(ctx, ctxKeyTimezone, timezone)
 }
 
+// PutChangefeedTimezoneInCtx returns a new child context with the timezone of a
+// changefeed, the timezone in ctx is kept if the changefeed doesn't specify one.
+func PutChangefeedTimezoneInCtx(ctx context.Context, name string) (context.Context, error) {
+	if name == "" {
+		return ctx, nil
+	}
+	tz, err := GetTimezone(name)
+	if err != nil {
+		return nil, err
+	}
+	return PutTimezoneInCtx(ctx, tz), nil
+}
+
 type tableinfo struct {
 	id   int64
 	name string
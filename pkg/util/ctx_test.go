@@ -15,6 +15,7 @@ package util
 
 import (
 	"context"
+	"time"
 
 	"github.com/pingcap/check"
 )
@@ -44,3 +45,15 @@ func (s *ctxValueSuite) TestChangefeedIDNotSet(c *check.C) {
 	ctx := context.WithValue(context.Background(), ctxKeyChangefeedID, 1321)
 	c.Assert(ChangefeedIDFromCtx(ctx), check.Equals, "")
 }
+
+func (s *ctxValueSuite) TestPutChangefeedTimezone(c *check.C) {
+	ctx := PutTimezoneInCtx(context.Background(), time.UTC)
+	cfCtx, err := PutChangefeedTimezoneInCtx(ctx, "")
+	c.Assert(err, check.IsNil)
+	c.Assert(TimezoneFromCtx(cfCtx), check.Equals, time.UTC)
+	cfCtx, err = PutChangefeedTimezoneInCtx(ctx, "Asia/Shanghai")
+	c.Assert(err, check.IsNil)
+	c.Assert(TimezoneFromCtx(cfCtx).String(), check.Equals, "Asia/Shanghai")
+	_, err = PutChangefeedTimezoneInCtx(ctx, "Mars/Olympus")
+	c.Assert(err, check.ErrorMatches, ".*ErrLoadTimezone.*")
+}
@@ -17,6 +17,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"syscall"
 
 	"github.com/pingcap/errors"
 	cerror "github.com/pingcap/ticdc/pkg/errors"
@@ -43,3 +44,13 @@ func IsDirWritable(dir string) error {
 	}
 	return cerror.WrapError(cerror.ErrCheckDirWritable, os.Remove(f))
 }
+
+// GetDiskAvailableSpace returns the bytes available to the user of the file
+// system the path is in.
+func GetDiskAvailableSpace(path string) (uint64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, errors.Trace(err)
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), nil
+}
@@ -0,0 +1,64 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// PanicError is a panic recovered from a goroutine, which is returned as an
+// error so that it fails the work the goroutine belongs to, e.g. a table,
+// instead of crashing the process.
+type PanicError struct {
+	Value interface{}
+	Stack string
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// CallSafe calls f and returns its panic as a PanicError
+func CallSafe(f func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: string(debug.Stack())}
+		}
+	}()
+	return f()
+}
+
+// SafeGroup is an errgroup.Group whose goroutines return their panics as
+// PanicErrors, which cancel the group like the other errors.
+type SafeGroup struct {
+	*errgroup.Group
+}
+
+// SafeGroupWithContext returns a SafeGroup and the context derived from ctx,
+// which is canceled once a goroutine of the group fails or panics.
+func SafeGroupWithContext(ctx context.Context) (*SafeGroup, context.Context) {
+	g, ctx := errgroup.WithContext(ctx)
+	return &SafeGroup{Group: g}, ctx
+}
+
+// Go runs f in a new goroutine, see errgroup.Group.Go
+func (g *SafeGroup) Go(f func() error) {
+	g.Group.Go(func() error {
+		return CallSafe(f)
+	})
+}
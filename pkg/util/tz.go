@@ -16,6 +16,7 @@ package util
 import (
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -63,3 +64,38 @@ func GetLocalTimezone() (*time.Location, error) {
 	}
 	return getTimezoneFromZonefile(str)
 }
+
+// GetMySQLTimezone returns the timezone specified by the time_zone variable of
+// MySQL, which is either a name or an offset from UTC like "+08:00".
+func GetMySQLTimezone(name string) (*time.Location, error) {
+	if len(name) > 0 && (name[0] == '+' || name[0] == '-') {
+		parts := strings.Split(name[1:], ":")
+		if len(parts) == 2 {
+			hours, err1 := strconv.Atoi(parts[0])
+			minutes, err2 := strconv.Atoi(parts[1])
+			if err1 == nil && err2 == nil && hours <= 14 && minutes < 60 {
+				offset := (hours*60 + minutes) * 60
+				if name[0] == '-' {
+					offset = -offset
+				}
+				return time.FixedZone(name, offset), nil
+			}
+		}
+		return nil, cerror.ErrLoadTimezone.GenWithStack("invalid time zone offset %s", name)
+	}
+	return GetTimezone(name)
+}
+
+// IsSameTimezone returns whether the two timezones have the same offsets from
+// UTC in both the winter and the summer of the year.
+func IsSameTimezone(a, b *time.Location, year int) bool {
+	for _, month := range []time.Month{time.January, time.July} {
+		t := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+		_, offsetA := t.In(a).Zone()
+		_, offsetB := t.In(b).Zone()
+		if offsetA != offsetB {
+			return false
+		}
+	}
+	return true
+}
@@ -14,6 +14,8 @@
 package util
 
 import (
+	"time"
+
 	"github.com/pingcap/check"
 )
 
@@ -45,3 +47,40 @@ func (s *tzSuite) TestGetTimezoneFromZonefile(c *check.C) {
 		}
 	}
 }
+
+func (s *tzSuite) TestGetMySQLTimezone(c *check.C) {
+	testCases := []struct {
+		name   string
+		hasErr bool
+		offset int
+	}{
+		{name: "+08:00", offset: 8 * 3600},
+		{name: "-05:30", offset: -(5*3600 + 30*60)},
+		{name: "UTC", offset: 0},
+		{name: "Asia/Shanghai", offset: 8 * 3600},
+		{name: "+8", hasErr: true},
+		{name: "+15:00", hasErr: true},
+		{name: "Mars/Olympus", hasErr: true},
+	}
+	for _, tc := range testCases {
+		loc, err := GetMySQLTimezone(tc.name)
+		if tc.hasErr {
+			c.Assert(err, check.NotNil, check.Commentf("%s", tc.name))
+			continue
+		}
+		c.Assert(err, check.IsNil, check.Commentf("%s", tc.name))
+		_, offset := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC).In(loc).Zone()
+		c.Assert(offset, check.Equals, tc.offset, check.Commentf("%s", tc.name))
+	}
+}
+
+func (s *tzSuite) TestIsSameTimezone(c *check.C) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	c.Assert(err, check.IsNil)
+	newYork, err := time.LoadLocation("America/New_York")
+	c.Assert(err, check.IsNil)
+	c.Assert(IsSameTimezone(shanghai, time.FixedZone("+08:00", 8*3600), 2020), check.IsTrue)
+	c.Assert(IsSameTimezone(shanghai, time.UTC, 2020), check.IsFalse)
+	// the offset of New York is different in the summer
+	c.Assert(IsSameTimezone(newYork, time.FixedZone("-05:00", -5*3600), 2020), check.IsFalse)
+}
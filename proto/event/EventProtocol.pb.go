@@ -0,0 +1,3350 @@
+// Code generated by protoc-gen-gogo. DO NOT EDIT.
+// source: EventProtocol.proto
+
+package event
+
+import (
+	encoding_binary "encoding/binary"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	io "io"
+	math "math"
+	math_bits "math/bits"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type OpType int32
+
+const (
+	OpType_UNKNOWN  OpType = 0
+	OpType_PUT      OpType = 1
+	OpType_DELETE   OpType = 2
+	OpType_RESOLVED OpType = 3
+)
+
+var OpType_name = map[int32]string{
+	0: "UNKNOWN",
+	1: "PUT",
+	2: "DELETE",
+	3: "RESOLVED",
+}
+
+var OpType_value = map[string]int32{
+	"UNKNOWN":  0,
+	"PUT":      1,
+	"DELETE":   2,
+	"RESOLVED": 3,
+}
+
+func (x OpType) String() string {
+	return proto.EnumName(OpType_name, int32(x))
+}
+
+func (OpType) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_e631055c9793d5f8, []int{0}
+}
+
+type TableName struct {
+	Schema               string   `protobuf:"bytes,1,opt,name=schema,proto3" json:"schema,omitempty"`
+	Table                string   `protobuf:"bytes,2,opt,name=table,proto3" json:"table,omitempty"`
+	TableId              int64    `protobuf:"varint,3,opt,name=table_id,json=tableId,proto3" json:"table_id,omitempty"`
+	IsPartition          bool     `protobuf:"varint,4,opt,name=is_partition,json=isPartition,proto3" json:"is_partition,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TableName) Reset()         { *m = TableName{} }
+func (m *TableName) String() string { return proto.CompactTextString(m) }
+func (*TableName) ProtoMessage()    {}
+func (*TableName) Descriptor() ([]byte, []int) {
+	return fileDescriptor_e631055c9793d5f8, []int{0}
+}
+func (m *TableName) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *TableName) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_TableName.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *TableName) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TableName.Merge(m, src)
+}
+func (m *TableName) XXX_Size() int {
+	return m.Size()
+}
+func (m *TableName) XXX_DiscardUnknown() {
+	xxx_messageInfo_TableName.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TableName proto.InternalMessageInfo
+
+func (m *TableName) GetSchema() string {
+	if m != nil {
+		return m.Schema
+	}
+	return ""
+}
+
+func (m *TableName) GetTable() string {
+	if m != nil {
+		return m.Table
+	}
+	return ""
+}
+
+func (m *TableName) GetTableId() int64 {
+	if m != nil {
+		return m.TableId
+	}
+	return 0
+}
+
+func (m *TableName) GetIsPartition() bool {
+	if m != nil {
+		return m.IsPartition
+	}
+	return false
+}
+
+type Column struct {
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Type uint32 `protobuf:"varint,2,opt,name=type,proto3" json:"type,omitempty"`
+	Flag uint64 `protobuf:"varint,3,opt,name=flag,proto3" json:"flag,omitempty"`
+	// Types that are valid to be assigned to Value:
+	//	*Column_IntValue
+	//	*Column_UintValue
+	//	*Column_DoubleValue
+	//	*Column_FloatValue
+	//	*Column_StringValue
+	//	*Column_BytesValue
+	Value                isColumn_Value `protobuf_oneof:"value"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *Column) Reset()         { *m = Column{} }
+func (m *Column) String() string { return proto.CompactTextString(m) }
+func (*Column) ProtoMessage()    {}
+func (*Column) Descriptor() ([]byte, []int) {
+	return fileDescriptor_e631055c9793d5f8, []int{1}
+}
+func (m *Column) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Column) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Column.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Column) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Column.Merge(m, src)
+}
+func (m *Column) XXX_Size() int {
+	return m.Size()
+}
+func (m *Column) XXX_DiscardUnknown() {
+	xxx_messageInfo_Column.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Column proto.InternalMessageInfo
+
+type isColumn_Value interface {
+	isColumn_Value()
+	MarshalTo([]byte) (int, error)
+	Size() int
+}
+
+type Column_IntValue struct {
+	IntValue int64 `protobuf:"varint,4,opt,name=int_value,json=intValue,proto3,oneof" json:"int_value,omitempty"`
+}
+type Column_UintValue struct {
+	UintValue uint64 `protobuf:"varint,5,opt,name=uint_value,json=uintValue,proto3,oneof" json:"uint_value,omitempty"`
+}
+type Column_DoubleValue struct {
+	DoubleValue float64 `protobuf:"fixed64,6,opt,name=double_value,json=doubleValue,proto3,oneof" json:"double_value,omitempty"`
+}
+type Column_FloatValue struct {
+	FloatValue float32 `protobuf:"fixed32,7,opt,name=float_value,json=floatValue,proto3,oneof" json:"float_value,omitempty"`
+}
+type Column_StringValue struct {
+	StringValue string `protobuf:"bytes,8,opt,name=string_value,json=stringValue,proto3,oneof" json:"string_value,omitempty"`
+}
+type Column_BytesValue struct {
+	BytesValue []byte `protobuf:"bytes,9,opt,name=bytes_value,json=bytesValue,proto3,oneof" json:"bytes_value,omitempty"`
+}
+
+func (*Column_IntValue) isColumn_Value()    {}
+func (*Column_UintValue) isColumn_Value()   {}
+func (*Column_DoubleValue) isColumn_Value() {}
+func (*Column_FloatValue) isColumn_Value()  {}
+func (*Column_StringValue) isColumn_Value() {}
+func (*Column_BytesValue) isColumn_Value()  {}
+
+func (m *Column) GetValue() isColumn_Value {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+func (m *Column) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Column) GetType() uint32 {
+	if m != nil {
+		return m.Type
+	}
+	return 0
+}
+
+func (m *Column) GetFlag() uint64 {
+	if m != nil {
+		return m.Flag
+	}
+	return 0
+}
+
+func (m *Column) GetIntValue() int64 {
+	if x, ok := m.GetValue().(*Column_IntValue); ok {
+		return x.IntValue
+	}
+	return 0
+}
+
+func (m *Column) GetUintValue() uint64 {
+	if x, ok := m.GetValue().(*Column_UintValue); ok {
+		return x.UintValue
+	}
+	return 0
+}
+
+func (m *Column) GetDoubleValue() float64 {
+	if x, ok := m.GetValue().(*Column_DoubleValue); ok {
+		return x.DoubleValue
+	}
+	return 0
+}
+
+func (m *Column) GetFloatValue() float32 {
+	if x, ok := m.GetValue().(*Column_FloatValue); ok {
+		return x.FloatValue
+	}
+	return 0
+}
+
+func (m *Column) GetStringValue() string {
+	if x, ok := m.GetValue().(*Column_StringValue); ok {
+		return x.StringValue
+	}
+	return ""
+}
+
+func (m *Column) GetBytesValue() []byte {
+	if x, ok := m.GetValue().(*Column_BytesValue); ok {
+		return x.BytesValue
+	}
+	return nil
+}
+
+// XXX_OneofWrappers is for the internal use of the proto package.
+func (*Column) XXX_OneofWrappers() []interface{} {
+	return []interface{}{
+		(*Column_IntValue)(nil),
+		(*Column_UintValue)(nil),
+		(*Column_DoubleValue)(nil),
+		(*Column_FloatValue)(nil),
+		(*Column_StringValue)(nil),
+		(*Column_BytesValue)(nil),
+	}
+}
+
+type IndexColumns struct {
+	Offsets              []int32  `protobuf:"varint,1,rep,packed,name=offsets,proto3" json:"offsets,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *IndexColumns) Reset()         { *m = IndexColumns{} }
+func (m *IndexColumns) String() string { return proto.CompactTextString(m) }
+func (*IndexColumns) ProtoMessage()    {}
+func (*IndexColumns) Descriptor() ([]byte, []int) {
+	return fileDescriptor_e631055c9793d5f8, []int{2}
+}
+func (m *IndexColumns) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *IndexColumns) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_IndexColumns.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *IndexColumns) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_IndexColumns.Merge(m, src)
+}
+func (m *IndexColumns) XXX_Size() int {
+	return m.Size()
+}
+func (m *IndexColumns) XXX_DiscardUnknown() {
+	xxx_messageInfo_IndexColumns.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_IndexColumns proto.InternalMessageInfo
+
+func (m *IndexColumns) GetOffsets() []int32 {
+	if m != nil {
+		return m.Offsets
+	}
+	return nil
+}
+
+type RowChangedEvent struct {
+	StartTs              uint64          `protobuf:"varint,1,opt,name=start_ts,json=startTs,proto3" json:"start_ts,omitempty"`
+	CommitTs             uint64          `protobuf:"varint,2,opt,name=commit_ts,json=commitTs,proto3" json:"commit_ts,omitempty"`
+	RowId                int64           `protobuf:"varint,3,opt,name=row_id,json=rowId,proto3" json:"row_id,omitempty"`
+	Table                *TableName      `protobuf:"bytes,4,opt,name=table,proto3" json:"table,omitempty"`
+	TableInfoVersion     uint64          `protobuf:"varint,5,opt,name=table_info_version,json=tableInfoVersion,proto3" json:"table_info_version,omitempty"`
+	Columns              []*Column       `protobuf:"bytes,6,rep,name=columns,proto3" json:"columns,omitempty"`
+	PreColumns           []*Column       `protobuf:"bytes,7,rep,name=pre_columns,json=preColumns,proto3" json:"pre_columns,omitempty"`
+	IndexColumns         []*IndexColumns `protobuf:"bytes,8,rep,name=index_columns,json=indexColumns,proto3" json:"index_columns,omitempty"`
+	ApproximateSize      int64           `protobuf:"varint,9,opt,name=approximate_size,json=approximateSize,proto3" json:"approximate_size,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *RowChangedEvent) Reset()         { *m = RowChangedEvent{} }
+func (m *RowChangedEvent) String() string { return proto.CompactTextString(m) }
+func (*RowChangedEvent) ProtoMessage()    {}
+func (*RowChangedEvent) Descriptor() ([]byte, []int) {
+	return fileDescriptor_e631055c9793d5f8, []int{3}
+}
+func (m *RowChangedEvent) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *RowChangedEvent) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_RowChangedEvent.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *RowChangedEvent) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RowChangedEvent.Merge(m, src)
+}
+func (m *RowChangedEvent) XXX_Size() int {
+	return m.Size()
+}
+func (m *RowChangedEvent) XXX_DiscardUnknown() {
+	xxx_messageInfo_RowChangedEvent.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RowChangedEvent proto.InternalMessageInfo
+
+func (m *RowChangedEvent) GetStartTs() uint64 {
+	if m != nil {
+		return m.StartTs
+	}
+	return 0
+}
+
+func (m *RowChangedEvent) GetCommitTs() uint64 {
+	if m != nil {
+		return m.CommitTs
+	}
+	return 0
+}
+
+func (m *RowChangedEvent) GetRowId() int64 {
+	if m != nil {
+		return m.RowId
+	}
+	return 0
+}
+
+func (m *RowChangedEvent) GetTable() *TableName {
+	if m != nil {
+		return m.Table
+	}
+	return nil
+}
+
+func (m *RowChangedEvent) GetTableInfoVersion() uint64 {
+	if m != nil {
+		return m.TableInfoVersion
+	}
+	return 0
+}
+
+func (m *RowChangedEvent) GetColumns() []*Column {
+	if m != nil {
+		return m.Columns
+	}
+	return nil
+}
+
+func (m *RowChangedEvent) GetPreColumns() []*Column {
+	if m != nil {
+		return m.PreColumns
+	}
+	return nil
+}
+
+func (m *RowChangedEvent) GetIndexColumns() []*IndexColumns {
+	if m != nil {
+		return m.IndexColumns
+	}
+	return nil
+}
+
+func (m *RowChangedEvent) GetApproximateSize() int64 {
+	if m != nil {
+		return m.ApproximateSize
+	}
+	return 0
+}
+
+type ColumnInfo struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Type                 uint32   `protobuf:"varint,2,opt,name=type,proto3" json:"type,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ColumnInfo) Reset()         { *m = ColumnInfo{} }
+func (m *ColumnInfo) String() string { return proto.CompactTextString(m) }
+func (*ColumnInfo) ProtoMessage()    {}
+func (*ColumnInfo) Descriptor() ([]byte, []int) {
+	return fileDescriptor_e631055c9793d5f8, []int{4}
+}
+func (m *ColumnInfo) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ColumnInfo) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ColumnInfo.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ColumnInfo) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ColumnInfo.Merge(m, src)
+}
+func (m *ColumnInfo) XXX_Size() int {
+	return m.Size()
+}
+func (m *ColumnInfo) XXX_DiscardUnknown() {
+	xxx_messageInfo_ColumnInfo.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ColumnInfo proto.InternalMessageInfo
+
+func (m *ColumnInfo) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *ColumnInfo) GetType() uint32 {
+	if m != nil {
+		return m.Type
+	}
+	return 0
+}
+
+type SimpleTableInfo struct {
+	Schema               string        `protobuf:"bytes,1,opt,name=schema,proto3" json:"schema,omitempty"`
+	Table                string        `protobuf:"bytes,2,opt,name=table,proto3" json:"table,omitempty"`
+	TableId              int64         `protobuf:"varint,3,opt,name=table_id,json=tableId,proto3" json:"table_id,omitempty"`
+	ColumnInfo           []*ColumnInfo `protobuf:"bytes,4,rep,name=column_info,json=columnInfo,proto3" json:"column_info,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *SimpleTableInfo) Reset()         { *m = SimpleTableInfo{} }
+func (m *SimpleTableInfo) String() string { return proto.CompactTextString(m) }
+func (*SimpleTableInfo) ProtoMessage()    {}
+func (*SimpleTableInfo) Descriptor() ([]byte, []int) {
+	return fileDescriptor_e631055c9793d5f8, []int{5}
+}
+func (m *SimpleTableInfo) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *SimpleTableInfo) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_SimpleTableInfo.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *SimpleTableInfo) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SimpleTableInfo.Merge(m, src)
+}
+func (m *SimpleTableInfo) XXX_Size() int {
+	return m.Size()
+}
+func (m *SimpleTableInfo) XXX_DiscardUnknown() {
+	xxx_messageInfo_SimpleTableInfo.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SimpleTableInfo proto.InternalMessageInfo
+
+func (m *SimpleTableInfo) GetSchema() string {
+	if m != nil {
+		return m.Schema
+	}
+	return ""
+}
+
+func (m *SimpleTableInfo) GetTable() string {
+	if m != nil {
+		return m.Table
+	}
+	return ""
+}
+
+func (m *SimpleTableInfo) GetTableId() int64 {
+	if m != nil {
+		return m.TableId
+	}
+	return 0
+}
+
+func (m *SimpleTableInfo) GetColumnInfo() []*ColumnInfo {
+	if m != nil {
+		return m.ColumnInfo
+	}
+	return nil
+}
+
+type DDLEvent struct {
+	StartTs              uint64           `protobuf:"varint,1,opt,name=start_ts,json=startTs,proto3" json:"start_ts,omitempty"`
+	CommitTs             uint64           `protobuf:"varint,2,opt,name=commit_ts,json=commitTs,proto3" json:"commit_ts,omitempty"`
+	TableInfo            *SimpleTableInfo `protobuf:"bytes,3,opt,name=table_info,json=tableInfo,proto3" json:"table_info,omitempty"`
+	PreTableInfo         *SimpleTableInfo `protobuf:"bytes,4,opt,name=pre_table_info,json=preTableInfo,proto3" json:"pre_table_info,omitempty"`
+	Query                string           `protobuf:"bytes,5,opt,name=query,proto3" json:"query,omitempty"`
+	Type                 int32            `protobuf:"varint,6,opt,name=type,proto3" json:"type,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
+}
+
+func (m *DDLEvent) Reset()         { *m = DDLEvent{} }
+func (m *DDLEvent) String() string { return proto.CompactTextString(m) }
+func (*DDLEvent) ProtoMessage()    {}
+func (*DDLEvent) Descriptor() ([]byte, []int) {
+	return fileDescriptor_e631055c9793d5f8, []int{6}
+}
+func (m *DDLEvent) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *DDLEvent) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_DDLEvent.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *DDLEvent) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DDLEvent.Merge(m, src)
+}
+func (m *DDLEvent) XXX_Size() int {
+	return m.Size()
+}
+func (m *DDLEvent) XXX_DiscardUnknown() {
+	xxx_messageInfo_DDLEvent.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DDLEvent proto.InternalMessageInfo
+
+func (m *DDLEvent) GetStartTs() uint64 {
+	if m != nil {
+		return m.StartTs
+	}
+	return 0
+}
+
+func (m *DDLEvent) GetCommitTs() uint64 {
+	if m != nil {
+		return m.CommitTs
+	}
+	return 0
+}
+
+func (m *DDLEvent) GetTableInfo() *SimpleTableInfo {
+	if m != nil {
+		return m.TableInfo
+	}
+	return nil
+}
+
+func (m *DDLEvent) GetPreTableInfo() *SimpleTableInfo {
+	if m != nil {
+		return m.PreTableInfo
+	}
+	return nil
+}
+
+func (m *DDLEvent) GetQuery() string {
+	if m != nil {
+		return m.Query
+	}
+	return ""
+}
+
+func (m *DDLEvent) GetType() int32 {
+	if m != nil {
+		return m.Type
+	}
+	return 0
+}
+
+type RawKVEntry struct {
+	OpType               OpType   `protobuf:"varint,1,opt,name=op_type,json=opType,proto3,enum=ticdc.event.OpType" json:"op_type,omitempty"`
+	Key                  []byte   `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Value                []byte   `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	OldValue             []byte   `protobuf:"bytes,4,opt,name=old_value,json=oldValue,proto3" json:"old_value,omitempty"`
+	StartTs              uint64   `protobuf:"varint,5,opt,name=start_ts,json=startTs,proto3" json:"start_ts,omitempty"`
+	Crts                 uint64   `protobuf:"varint,6,opt,name=crts,proto3" json:"crts,omitempty"`
+	RegionId             uint64   `protobuf:"varint,7,opt,name=region_id,json=regionId,proto3" json:"region_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RawKVEntry) Reset()         { *m = RawKVEntry{} }
+func (m *RawKVEntry) String() string { return proto.CompactTextString(m) }
+func (*RawKVEntry) ProtoMessage()    {}
+func (*RawKVEntry) Descriptor() ([]byte, []int) {
+	return fileDescriptor_e631055c9793d5f8, []int{7}
+}
+func (m *RawKVEntry) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *RawKVEntry) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_RawKVEntry.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *RawKVEntry) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RawKVEntry.Merge(m, src)
+}
+func (m *RawKVEntry) XXX_Size() int {
+	return m.Size()
+}
+func (m *RawKVEntry) XXX_DiscardUnknown() {
+	xxx_messageInfo_RawKVEntry.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RawKVEntry proto.InternalMessageInfo
+
+func (m *RawKVEntry) GetOpType() OpType {
+	if m != nil {
+		return m.OpType
+	}
+	return OpType_UNKNOWN
+}
+
+func (m *RawKVEntry) GetKey() []byte {
+	if m != nil {
+		return m.Key
+	}
+	return nil
+}
+
+func (m *RawKVEntry) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+func (m *RawKVEntry) GetOldValue() []byte {
+	if m != nil {
+		return m.OldValue
+	}
+	return nil
+}
+
+func (m *RawKVEntry) GetStartTs() uint64 {
+	if m != nil {
+		return m.StartTs
+	}
+	return 0
+}
+
+func (m *RawKVEntry) GetCrts() uint64 {
+	if m != nil {
+		return m.Crts
+	}
+	return 0
+}
+
+func (m *RawKVEntry) GetRegionId() uint64 {
+	if m != nil {
+		return m.RegionId
+	}
+	return 0
+}
+
+func init() {
+	proto.RegisterEnum("ticdc.event.OpType", OpType_name, OpType_value)
+	proto.RegisterType((*TableName)(nil), "ticdc.event.TableName")
+	proto.RegisterType((*Column)(nil), "ticdc.event.Column")
+	proto.RegisterType((*IndexColumns)(nil), "ticdc.event.IndexColumns")
+	proto.RegisterType((*RowChangedEvent)(nil), "ticdc.event.RowChangedEvent")
+	proto.RegisterType((*ColumnInfo)(nil), "ticdc.event.ColumnInfo")
+	proto.RegisterType((*SimpleTableInfo)(nil), "ticdc.event.SimpleTableInfo")
+	proto.RegisterType((*DDLEvent)(nil), "ticdc.event.DDLEvent")
+	proto.RegisterType((*RawKVEntry)(nil), "ticdc.event.RawKVEntry")
+}
+
+func init() { proto.RegisterFile("EventProtocol.proto", fileDescriptor_e631055c9793d5f8) }
+
+var fileDescriptor_e631055c9793d5f8 = []byte{
+	// 803 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x55, 0xcd, 0x6e, 0xdb, 0x46,
+	0x10, 0xd6, 0x8a, 0xe2, 0xdf, 0x90, 0x89, 0x85, 0x4d, 0x9a, 0x30, 0x68, 0xeb, 0x32, 0xea, 0x85,
+	0x2d, 0x5c, 0x1f, 0xdc, 0x1c, 0x02, 0x14, 0xe8, 0xc1, 0xb1, 0x00, 0x1b, 0x31, 0x6c, 0x63, 0xad,
+	0xa8, 0x40, 0x2f, 0x04, 0x4d, 0xae, 0x94, 0x45, 0x49, 0x2e, 0x4b, 0xae, 0xac, 0x28, 0xcf, 0xd0,
+	0x63, 0x0f, 0xbd, 0xf4, 0x7d, 0x0a, 0xf4, 0xd2, 0x47, 0x28, 0xdc, 0x73, 0xdf, 0xa1, 0xd8, 0x5d,
+	0x91, 0x92, 0x82, 0x16, 0x08, 0xd0, 0x9c, 0x34, 0xf3, 0xed, 0xcc, 0x2c, 0xbf, 0x6f, 0x3e, 0x52,
+	0xf0, 0x60, 0x7c, 0x4b, 0x4b, 0x71, 0x55, 0x73, 0xc1, 0x53, 0x9e, 0x1f, 0x56, 0x32, 0xc0, 0x9e,
+	0x60, 0x69, 0x96, 0x1e, 0x52, 0x79, 0x34, 0x5a, 0x82, 0x3b, 0x49, 0x6e, 0x72, 0x7a, 0x91, 0x14,
+	0x14, 0x3f, 0x02, 0xab, 0x49, 0x5f, 0xd3, 0x22, 0x09, 0x50, 0x88, 0x22, 0x97, 0xac, 0x33, 0xfc,
+	0x10, 0x4c, 0x21, 0x8b, 0x82, 0xbe, 0x82, 0x75, 0x82, 0x9f, 0x80, 0xa3, 0x82, 0x98, 0x65, 0x81,
+	0x11, 0xa2, 0xc8, 0x20, 0xb6, 0xca, 0xcf, 0x32, 0xfc, 0x14, 0x7c, 0xd6, 0xc4, 0x55, 0x52, 0x0b,
+	0x26, 0x18, 0x2f, 0x83, 0x41, 0x88, 0x22, 0x87, 0x78, 0xac, 0xb9, 0x6a, 0xa1, 0xd1, 0xaf, 0x7d,
+	0xb0, 0x5e, 0xf0, 0x7c, 0x51, 0x94, 0x18, 0xc3, 0xa0, 0x4c, 0x0a, 0xba, 0xbe, 0x54, 0xc5, 0x12,
+	0x13, 0xab, 0x4a, 0xdf, 0x78, 0x8f, 0xa8, 0x58, 0x62, 0xb3, 0x3c, 0x99, 0xab, 0xcb, 0x06, 0x44,
+	0xc5, 0xf8, 0x53, 0x70, 0x59, 0x29, 0xe2, 0xdb, 0x24, 0x5f, 0x50, 0x75, 0x8d, 0x71, 0xda, 0x23,
+	0x0e, 0x2b, 0xc5, 0x54, 0x22, 0xf8, 0x33, 0x80, 0xc5, 0xe6, 0xdc, 0x94, 0x8d, 0xa7, 0x3d, 0xe2,
+	0x2e, 0xba, 0x82, 0xcf, 0xc1, 0xcf, 0xf8, 0x42, 0xb2, 0xd0, 0x25, 0x56, 0x88, 0x22, 0x74, 0xda,
+	0x23, 0x9e, 0x46, 0x75, 0xd1, 0x53, 0xf0, 0x66, 0x39, 0x4f, 0xda, 0x31, 0x76, 0x88, 0xa2, 0xfe,
+	0x69, 0x8f, 0x80, 0x02, 0xbb, 0x39, 0x8d, 0xa8, 0x59, 0x39, 0x5f, 0xd7, 0x38, 0x92, 0x8b, 0x9c,
+	0xa3, 0xd1, 0x6e, 0xce, 0xcd, 0x4a, 0xd0, 0x66, 0x5d, 0xe3, 0x86, 0x28, 0xf2, 0xe5, 0x1c, 0x05,
+	0xaa, 0x92, 0x63, 0x1b, 0x4c, 0x75, 0x38, 0x8a, 0xc0, 0x3f, 0x2b, 0x33, 0xfa, 0x46, 0x6b, 0xd4,
+	0xe0, 0x00, 0x6c, 0x3e, 0x9b, 0x35, 0x54, 0x34, 0x01, 0x0a, 0x8d, 0xc8, 0x24, 0x6d, 0x3a, 0xfa,
+	0xc9, 0x80, 0x3d, 0xc2, 0x97, 0x2f, 0x5e, 0x27, 0xe5, 0x9c, 0x66, 0x6a, 0xe3, 0x72, 0x37, 0x8d,
+	0x48, 0x6a, 0x11, 0xab, 0x72, 0x29, 0x97, 0xad, 0xf2, 0x49, 0x83, 0x3f, 0x06, 0x37, 0xe5, 0x45,
+	0xc1, 0xd4, 0x59, 0x5f, 0x9d, 0x39, 0x1a, 0x98, 0x34, 0xf8, 0x23, 0xb0, 0x6a, 0xbe, 0xdc, 0x6c,
+	0xd4, 0xac, 0xf9, 0xf2, 0x2c, 0xc3, 0x07, 0xad, 0x01, 0xa4, 0xc2, 0xde, 0xd1, 0xa3, 0xc3, 0x2d,
+	0x0b, 0x1d, 0x76, 0xfe, 0x69, 0x8d, 0x71, 0x00, 0x78, 0x6d, 0x8c, 0x72, 0xc6, 0xe3, 0x5b, 0x5a,
+	0x37, 0xd2, 0x03, 0x4a, 0x7c, 0x32, 0xd4, 0x16, 0x29, 0x67, 0x7c, 0xaa, 0x71, 0xfc, 0x15, 0xd8,
+	0xa9, 0xe6, 0x18, 0x58, 0xa1, 0x11, 0x79, 0x47, 0x0f, 0x76, 0xa6, 0x6b, 0xfe, 0xa4, 0xad, 0xc1,
+	0xcf, 0xc0, 0xab, 0x6a, 0x1a, 0xb7, 0x2d, 0xf6, 0x7f, 0xb7, 0x40, 0x55, 0xd3, 0x56, 0xbd, 0x6f,
+	0xe1, 0x1e, 0x93, 0x6a, 0x76, 0x7d, 0x8e, 0xea, 0x7b, 0xb2, 0xd3, 0xb7, 0xad, 0x37, 0xf1, 0xd9,
+	0x56, 0x86, 0xbf, 0x80, 0x61, 0x52, 0x55, 0x35, 0x7f, 0xc3, 0x8a, 0x44, 0xd0, 0xb8, 0x61, 0x6f,
+	0xf5, 0xfa, 0x0c, 0xb2, 0xb7, 0x85, 0x5f, 0xb3, 0xb7, 0x74, 0xf4, 0x0c, 0x40, 0x77, 0x49, 0x92,
+	0xef, 0xeb, 0xed, 0xd1, 0xcf, 0x08, 0xf6, 0xae, 0x59, 0x51, 0xe5, 0x74, 0xd2, 0x0a, 0xf4, 0xe1,
+	0x5e, 0xc7, 0xe7, 0xe0, 0x69, 0xde, 0x6a, 0x23, 0xc1, 0x40, 0x71, 0x7f, 0xfc, 0x2f, 0x9a, 0xc9,
+	0x6b, 0x09, 0xa4, 0x5d, 0x3c, 0xfa, 0x1b, 0x81, 0x73, 0x72, 0x72, 0xfe, 0xff, 0x4c, 0xf5, 0x0d,
+	0xc0, 0xc6, 0x0f, 0xea, 0xd9, 0xbc, 0xa3, 0x4f, 0x76, 0x6e, 0x7f, 0x87, 0x39, 0x71, 0x3b, 0x97,
+	0xe0, 0x63, 0xb8, 0x2f, 0xf7, 0xbd, 0x35, 0x60, 0xf0, 0x1e, 0x03, 0xfc, 0xaa, 0xde, 0x64, 0x52,
+	0xb0, 0x1f, 0x17, 0xb4, 0x5e, 0x29, 0x0f, 0xba, 0x44, 0x27, 0xdd, 0x1a, 0xe4, 0x2b, 0x6f, 0xae,
+	0xd7, 0xf0, 0x3b, 0x02, 0x20, 0xc9, 0xf2, 0xe5, 0x74, 0x5c, 0x8a, 0x7a, 0x85, 0x0f, 0xc0, 0xe6,
+	0x55, 0xac, 0xaa, 0x24, 0xe1, 0xfb, 0xef, 0x18, 0xed, 0xb2, 0x9a, 0xac, 0x2a, 0x4a, 0x2c, 0xae,
+	0x7e, 0xf1, 0x10, 0x8c, 0x1f, 0xe8, 0x4a, 0xd1, 0xf7, 0x89, 0x0c, 0xf1, 0xc3, 0xf5, 0xdb, 0xac,
+	0x48, 0xfb, 0x44, 0x27, 0x52, 0x2c, 0x9e, 0x67, 0x5b, 0xdf, 0x2c, 0x9f, 0x38, 0x3c, 0xcf, 0xf4,
+	0x37, 0x62, 0x5b, 0x64, 0x73, 0x57, 0x64, 0x0c, 0x83, 0xb4, 0x16, 0x8d, 0x7a, 0xe0, 0x01, 0x51,
+	0xb1, 0x9c, 0x55, 0xd3, 0x39, 0xe3, 0xa5, 0x5c, 0xbb, 0xad, 0x85, 0xd7, 0xc0, 0x59, 0xf6, 0xe5,
+	0x73, 0xb0, 0xf4, 0x23, 0x62, 0x0f, 0xec, 0x57, 0x17, 0x2f, 0x2f, 0x2e, 0xbf, 0xbb, 0x18, 0xf6,
+	0xb0, 0x0d, 0xc6, 0xd5, 0xab, 0xc9, 0x10, 0x61, 0x00, 0xeb, 0x64, 0x7c, 0x3e, 0x9e, 0x8c, 0x87,
+	0x7d, 0xec, 0x83, 0x43, 0xc6, 0xd7, 0x97, 0xe7, 0xd3, 0xf1, 0xc9, 0xd0, 0x38, 0x7e, 0xfc, 0xdb,
+	0xdd, 0x3e, 0xfa, 0xe3, 0x6e, 0x1f, 0xfd, 0x79, 0xb7, 0x8f, 0x7e, 0xf9, 0x6b, 0xbf, 0xf7, 0xbd,
+	0xa9, 0x28, 0xdf, 0x58, 0xea, 0x3f, 0xe4, 0xeb, 0x7f, 0x06, 0x00, 0xe6, 0xb9, 0xe3, 0x81, 0x5a,
+	0x06, 0x00, 0x00,
+}
+
+func (m *TableName) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *TableName) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *TableName) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.IsPartition {
+		i--
+		if m.IsPartition {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.TableId != 0 {
+		i = encodeVarintEventProtocol(dAtA, i, uint64(m.TableId))
+		i--
+		dAtA[i] = 0x18
+	}
+	if len(m.Table) > 0 {
+		i -= len(m.Table)
+		copy(dAtA[i:], m.Table)
+		i = encodeVarintEventProtocol(dAtA, i, uint64(len(m.Table)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Schema) > 0 {
+		i -= len(m.Schema)
+		copy(dAtA[i:], m.Schema)
+		i = encodeVarintEventProtocol(dAtA, i, uint64(len(m.Schema)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *Column) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Column) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Column) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.Value != nil {
+		{
+			size := m.Value.Size()
+			i -= size
+			if _, err := m.Value.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+		}
+	}
+	if m.Flag != 0 {
+		i = encodeVarintEventProtocol(dAtA, i, uint64(m.Flag))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.Type != 0 {
+		i = encodeVarintEventProtocol(dAtA, i, uint64(m.Type))
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.Name) > 0 {
+		i -= len(m.Name)
+		copy(dAtA[i:], m.Name)
+		i = encodeVarintEventProtocol(dAtA, i, uint64(len(m.Name)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *Column_IntValue) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Column_IntValue) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	i = encodeVarintEventProtocol(dAtA, i, uint64(m.IntValue))
+	i--
+	dAtA[i] = 0x20
+	return len(dAtA) - i, nil
+}
+func (m *Column_UintValue) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Column_UintValue) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	i = encodeVarintEventProtocol(dAtA, i, uint64(m.UintValue))
+	i--
+	dAtA[i] = 0x28
+	return len(dAtA) - i, nil
+}
+func (m *Column_DoubleValue) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Column_DoubleValue) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	i -= 8
+	encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.DoubleValue))))
+	i--
+	dAtA[i] = 0x31
+	return len(dAtA) - i, nil
+}
+func (m *Column_FloatValue) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Column_FloatValue) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	i -= 4
+	encoding_binary.LittleEndian.PutUint32(dAtA[i:], uint32(math.Float32bits(float32(m.FloatValue))))
+	i--
+	dAtA[i] = 0x3d
+	return len(dAtA) - i, nil
+}
+func (m *Column_StringValue) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Column_StringValue) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	i -= len(m.StringValue)
+	copy(dAtA[i:], m.StringValue)
+	i = encodeVarintEventProtocol(dAtA, i, uint64(len(m.StringValue)))
+	i--
+	dAtA[i] = 0x42
+	return len(dAtA) - i, nil
+}
+func (m *Column_BytesValue) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Column_BytesValue) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	if m.BytesValue != nil {
+		i -= len(m.BytesValue)
+		copy(dAtA[i:], m.BytesValue)
+		i = encodeVarintEventProtocol(dAtA, i, uint64(len(m.BytesValue)))
+		i--
+		dAtA[i] = 0x4a
+	}
+	return len(dAtA) - i, nil
+}
+func (m *IndexColumns) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *IndexColumns) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *IndexColumns) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if len(m.Offsets) > 0 {
+		dAtA2 := make([]byte, len(m.Offsets)*10)
+		var j1 int
+		for _, num1 := range m.Offsets {
+			num := uint64(num1)
+			for num >= 1<<7 {
+				dAtA2[j1] = uint8(uint64(num)&0x7f | 0x80)
+				num >>= 7
+				j1++
+			}
+			dAtA2[j1] = uint8(num)
+			j1++
+		}
+		i -= j1
+		copy(dAtA[i:], dAtA2[:j1])
+		i = encodeVarintEventProtocol(dAtA, i, uint64(j1))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *RowChangedEvent) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *RowChangedEvent) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *RowChangedEvent) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.ApproximateSize != 0 {
+		i = encodeVarintEventProtocol(dAtA, i, uint64(m.ApproximateSize))
+		i--
+		dAtA[i] = 0x48
+	}
+	if len(m.IndexColumns) > 0 {
+		for iNdEx := len(m.IndexColumns) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.IndexColumns[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintEventProtocol(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x42
+		}
+	}
+	if len(m.PreColumns) > 0 {
+		for iNdEx := len(m.PreColumns) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.PreColumns[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintEventProtocol(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x3a
+		}
+	}
+	if len(m.Columns) > 0 {
+		for iNdEx := len(m.Columns) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Columns[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintEventProtocol(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x32
+		}
+	}
+	if m.TableInfoVersion != 0 {
+		i = encodeVarintEventProtocol(dAtA, i, uint64(m.TableInfoVersion))
+		i--
+		dAtA[i] = 0x28
+	}
+	if m.Table != nil {
+		{
+			size, err := m.Table.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintEventProtocol(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x22
+	}
+	if m.RowId != 0 {
+		i = encodeVarintEventProtocol(dAtA, i, uint64(m.RowId))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.CommitTs != 0 {
+		i = encodeVarintEventProtocol(dAtA, i, uint64(m.CommitTs))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.StartTs != 0 {
+		i = encodeVarintEventProtocol(dAtA, i, uint64(m.StartTs))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ColumnInfo) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ColumnInfo) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ColumnInfo) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.Type != 0 {
+		i = encodeVarintEventProtocol(dAtA, i, uint64(m.Type))
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.Name) > 0 {
+		i -= len(m.Name)
+		copy(dAtA[i:], m.Name)
+		i = encodeVarintEventProtocol(dAtA, i, uint64(len(m.Name)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *SimpleTableInfo) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *SimpleTableInfo) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *SimpleTableInfo) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if len(m.ColumnInfo) > 0 {
+		for iNdEx := len(m.ColumnInfo) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.ColumnInfo[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintEventProtocol(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x22
+		}
+	}
+	if m.TableId != 0 {
+		i = encodeVarintEventProtocol(dAtA, i, uint64(m.TableId))
+		i--
+		dAtA[i] = 0x18
+	}
+	if len(m.Table) > 0 {
+		i -= len(m.Table)
+		copy(dAtA[i:], m.Table)
+		i = encodeVarintEventProtocol(dAtA, i, uint64(len(m.Table)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Schema) > 0 {
+		i -= len(m.Schema)
+		copy(dAtA[i:], m.Schema)
+		i = encodeVarintEventProtocol(dAtA, i, uint64(len(m.Schema)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *DDLEvent) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *DDLEvent) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *DDLEvent) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.Type != 0 {
+		i = encodeVarintEventProtocol(dAtA, i, uint64(m.Type))
+		i--
+		dAtA[i] = 0x30
+	}
+	if len(m.Query) > 0 {
+		i -= len(m.Query)
+		copy(dAtA[i:], m.Query)
+		i = encodeVarintEventProtocol(dAtA, i, uint64(len(m.Query)))
+		i--
+		dAtA[i] = 0x2a
+	}
+	if m.PreTableInfo != nil {
+		{
+			size, err := m.PreTableInfo.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintEventProtocol(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x22
+	}
+	if m.TableInfo != nil {
+		{
+			size, err := m.TableInfo.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintEventProtocol(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	if m.CommitTs != 0 {
+		i = encodeVarintEventProtocol(dAtA, i, uint64(m.CommitTs))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.StartTs != 0 {
+		i = encodeVarintEventProtocol(dAtA, i, uint64(m.StartTs))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *RawKVEntry) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *RawKVEntry) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *RawKVEntry) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.RegionId != 0 {
+		i = encodeVarintEventProtocol(dAtA, i, uint64(m.RegionId))
+		i--
+		dAtA[i] = 0x38
+	}
+	if m.Crts != 0 {
+		i = encodeVarintEventProtocol(dAtA, i, uint64(m.Crts))
+		i--
+		dAtA[i] = 0x30
+	}
+	if m.StartTs != 0 {
+		i = encodeVarintEventProtocol(dAtA, i, uint64(m.StartTs))
+		i--
+		dAtA[i] = 0x28
+	}
+	if len(m.OldValue) > 0 {
+		i -= len(m.OldValue)
+		copy(dAtA[i:], m.OldValue)
+		i = encodeVarintEventProtocol(dAtA, i, uint64(len(m.OldValue)))
+		i--
+		dAtA[i] = 0x22
+	}
+	if len(m.Value) > 0 {
+		i -= len(m.Value)
+		copy(dAtA[i:], m.Value)
+		i = encodeVarintEventProtocol(dAtA, i, uint64(len(m.Value)))
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.Key) > 0 {
+		i -= len(m.Key)
+		copy(dAtA[i:], m.Key)
+		i = encodeVarintEventProtocol(dAtA, i, uint64(len(m.Key)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.OpType != 0 {
+		i = encodeVarintEventProtocol(dAtA, i, uint64(m.OpType))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func encodeVarintEventProtocol(dAtA []byte, offset int, v uint64) int {
+	offset -= sovEventProtocol(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func (m *TableName) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Schema)
+	if l > 0 {
+		n += 1 + l + sovEventProtocol(uint64(l))
+	}
+	l = len(m.Table)
+	if l > 0 {
+		n += 1 + l + sovEventProtocol(uint64(l))
+	}
+	if m.TableId != 0 {
+		n += 1 + sovEventProtocol(uint64(m.TableId))
+	}
+	if m.IsPartition {
+		n += 2
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *Column) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Name)
+	if l > 0 {
+		n += 1 + l + sovEventProtocol(uint64(l))
+	}
+	if m.Type != 0 {
+		n += 1 + sovEventProtocol(uint64(m.Type))
+	}
+	if m.Flag != 0 {
+		n += 1 + sovEventProtocol(uint64(m.Flag))
+	}
+	if m.Value != nil {
+		n += m.Value.Size()
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *Column_IntValue) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	n += 1 + sovEventProtocol(uint64(m.IntValue))
+	return n
+}
+func (m *Column_UintValue) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	n += 1 + sovEventProtocol(uint64(m.UintValue))
+	return n
+}
+func (m *Column_DoubleValue) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	n += 9
+	return n
+}
+func (m *Column_FloatValue) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	n += 5
+	return n
+}
+func (m *Column_StringValue) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.StringValue)
+	n += 1 + l + sovEventProtocol(uint64(l))
+	return n
+}
+func (m *Column_BytesValue) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.BytesValue != nil {
+		l = len(m.BytesValue)
+		n += 1 + l + sovEventProtocol(uint64(l))
+	}
+	return n
+}
+func (m *IndexColumns) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Offsets) > 0 {
+		l = 0
+		for _, e := range m.Offsets {
+			l += sovEventProtocol(uint64(e))
+		}
+		n += 1 + sovEventProtocol(uint64(l)) + l
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *RowChangedEvent) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.StartTs != 0 {
+		n += 1 + sovEventProtocol(uint64(m.StartTs))
+	}
+	if m.CommitTs != 0 {
+		n += 1 + sovEventProtocol(uint64(m.CommitTs))
+	}
+	if m.RowId != 0 {
+		n += 1 + sovEventProtocol(uint64(m.RowId))
+	}
+	if m.Table != nil {
+		l = m.Table.Size()
+		n += 1 + l + sovEventProtocol(uint64(l))
+	}
+	if m.TableInfoVersion != 0 {
+		n += 1 + sovEventProtocol(uint64(m.TableInfoVersion))
+	}
+	if len(m.Columns) > 0 {
+		for _, e := range m.Columns {
+			l = e.Size()
+			n += 1 + l + sovEventProtocol(uint64(l))
+		}
+	}
+	if len(m.PreColumns) > 0 {
+		for _, e := range m.PreColumns {
+			l = e.Size()
+			n += 1 + l + sovEventProtocol(uint64(l))
+		}
+	}
+	if len(m.IndexColumns) > 0 {
+		for _, e := range m.IndexColumns {
+			l = e.Size()
+			n += 1 + l + sovEventProtocol(uint64(l))
+		}
+	}
+	if m.ApproximateSize != 0 {
+		n += 1 + sovEventProtocol(uint64(m.ApproximateSize))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *ColumnInfo) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Name)
+	if l > 0 {
+		n += 1 + l + sovEventProtocol(uint64(l))
+	}
+	if m.Type != 0 {
+		n += 1 + sovEventProtocol(uint64(m.Type))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *SimpleTableInfo) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Schema)
+	if l > 0 {
+		n += 1 + l + sovEventProtocol(uint64(l))
+	}
+	l = len(m.Table)
+	if l > 0 {
+		n += 1 + l + sovEventProtocol(uint64(l))
+	}
+	if m.TableId != 0 {
+		n += 1 + sovEventProtocol(uint64(m.TableId))
+	}
+	if len(m.ColumnInfo) > 0 {
+		for _, e := range m.ColumnInfo {
+			l = e.Size()
+			n += 1 + l + sovEventProtocol(uint64(l))
+		}
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *DDLEvent) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.StartTs != 0 {
+		n += 1 + sovEventProtocol(uint64(m.StartTs))
+	}
+	if m.CommitTs != 0 {
+		n += 1 + sovEventProtocol(uint64(m.CommitTs))
+	}
+	if m.TableInfo != nil {
+		l = m.TableInfo.Size()
+		n += 1 + l + sovEventProtocol(uint64(l))
+	}
+	if m.PreTableInfo != nil {
+		l = m.PreTableInfo.Size()
+		n += 1 + l + sovEventProtocol(uint64(l))
+	}
+	l = len(m.Query)
+	if l > 0 {
+		n += 1 + l + sovEventProtocol(uint64(l))
+	}
+	if m.Type != 0 {
+		n += 1 + sovEventProtocol(uint64(m.Type))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *RawKVEntry) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.OpType != 0 {
+		n += 1 + sovEventProtocol(uint64(m.OpType))
+	}
+	l = len(m.Key)
+	if l > 0 {
+		n += 1 + l + sovEventProtocol(uint64(l))
+	}
+	l = len(m.Value)
+	if l > 0 {
+		n += 1 + l + sovEventProtocol(uint64(l))
+	}
+	l = len(m.OldValue)
+	if l > 0 {
+		n += 1 + l + sovEventProtocol(uint64(l))
+	}
+	if m.StartTs != 0 {
+		n += 1 + sovEventProtocol(uint64(m.StartTs))
+	}
+	if m.Crts != 0 {
+		n += 1 + sovEventProtocol(uint64(m.Crts))
+	}
+	if m.RegionId != 0 {
+		n += 1 + sovEventProtocol(uint64(m.RegionId))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func sovEventProtocol(x uint64) (n int) {
+	return (math_bits.Len64(x|1) + 6) / 7
+}
+func sozEventProtocol(x uint64) (n int) {
+	return sovEventProtocol(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (m *TableName) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowEventProtocol
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: TableName: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: TableName: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Schema", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowEventProtocol
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Schema = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Table", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowEventProtocol
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Table = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TableId", wireType)
+			}
+			m.TableId = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowEventProtocol
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.TableId |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field IsPartition", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowEventProtocol
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.IsPartition = bool(v != 0)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipEventProtocol(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Column) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowEventProtocol
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Column: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Column: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Name", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowEventProtocol
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Name = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Type", wireType)
+			}
+			m.Type = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowEventProtocol
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Type |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Flag", wireType)
+			}
+			m.Flag = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowEventProtocol
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Flag |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field IntValue", wireType)
+			}
+			var v int64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowEventProtocol
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Value = &Column_IntValue{v}
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field UintValue", wireType)
+			}
+			var v uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowEventProtocol
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Value = &Column_UintValue{v}
+		case 6:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DoubleValue", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.Value = &Column_DoubleValue{float64(math.Float64frombits(v))}
+		case 7:
+			if wireType != 5 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FloatValue", wireType)
+			}
+			var v uint32
+			if (iNdEx + 4) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint32(encoding_binary.LittleEndian.Uint32(dAtA[iNdEx:]))
+			iNdEx += 4
+			m.Value = &Column_FloatValue{float32(math.Float32frombits(v))}
+		case 8:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StringValue", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowEventProtocol
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Value = &Column_StringValue{string(dAtA[iNdEx:postIndex])}
+			iNdEx = postIndex
+		case 9:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BytesValue", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowEventProtocol
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := make([]byte, postIndex-iNdEx)
+			copy(v, dAtA[iNdEx:postIndex])
+			m.Value = &Column_BytesValue{v}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipEventProtocol(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *IndexColumns) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowEventProtocol
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: IndexColumns: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: IndexColumns: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType == 0 {
+				var v int32
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowEventProtocol
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					v |= int32(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				m.Offsets = append(m.Offsets, v)
+			} else if wireType == 2 {
+				var packedLen int
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowEventProtocol
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					packedLen |= int(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				if packedLen < 0 {
+					return ErrInvalidLengthEventProtocol
+				}
+				postIndex := iNdEx + packedLen
+				if postIndex < 0 {
+					return ErrInvalidLengthEventProtocol
+				}
+				if postIndex > l {
+					return io.ErrUnexpectedEOF
+				}
+				var elementCount int
+				var count int
+				for _, integer := range dAtA[iNdEx:postIndex] {
+					if integer < 128 {
+						count++
+					}
+				}
+				elementCount = count
+				if elementCount != 0 && len(m.Offsets) == 0 {
+					m.Offsets = make([]int32, 0, elementCount)
+				}
+				for iNdEx < postIndex {
+					var v int32
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowEventProtocol
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						v |= int32(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					m.Offsets = append(m.Offsets, v)
+				}
+			} else {
+				return fmt.Errorf("proto: wrong wireType = %d for field Offsets", wireType)
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipEventProtocol(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *RowChangedEvent) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowEventProtocol
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: RowChangedEvent: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: RowChangedEvent: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StartTs", wireType)
+			}
+			m.StartTs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowEventProtocol
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.StartTs |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CommitTs", wireType)
+			}
+			m.CommitTs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowEventProtocol
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.CommitTs |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RowId", wireType)
+			}
+			m.RowId = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowEventProtocol
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.RowId |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Table", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowEventProtocol
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Table == nil {
+				m.Table = &TableName{}
+			}
+			if err := m.Table.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TableInfoVersion", wireType)
+			}
+			m.TableInfoVersion = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowEventProtocol
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.TableInfoVersion |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Columns", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowEventProtocol
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Columns = append(m.Columns, &Column{})
+			if err := m.Columns[len(m.Columns)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PreColumns", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowEventProtocol
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.PreColumns = append(m.PreColumns, &Column{})
+			if err := m.PreColumns[len(m.PreColumns)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 8:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field IndexColumns", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowEventProtocol
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.IndexColumns = append(m.IndexColumns, &IndexColumns{})
+			if err := m.IndexColumns[len(m.IndexColumns)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 9:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ApproximateSize", wireType)
+			}
+			m.ApproximateSize = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowEventProtocol
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ApproximateSize |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipEventProtocol(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ColumnInfo) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowEventProtocol
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ColumnInfo: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ColumnInfo: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Name", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowEventProtocol
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Name = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Type", wireType)
+			}
+			m.Type = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowEventProtocol
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Type |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipEventProtocol(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *SimpleTableInfo) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowEventProtocol
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SimpleTableInfo: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SimpleTableInfo: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Schema", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowEventProtocol
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Schema = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Table", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowEventProtocol
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Table = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TableId", wireType)
+			}
+			m.TableId = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowEventProtocol
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.TableId |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ColumnInfo", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowEventProtocol
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ColumnInfo = append(m.ColumnInfo, &ColumnInfo{})
+			if err := m.ColumnInfo[len(m.ColumnInfo)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipEventProtocol(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *DDLEvent) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowEventProtocol
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: DDLEvent: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: DDLEvent: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StartTs", wireType)
+			}
+			m.StartTs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowEventProtocol
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.StartTs |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CommitTs", wireType)
+			}
+			m.CommitTs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowEventProtocol
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.CommitTs |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TableInfo", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowEventProtocol
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.TableInfo == nil {
+				m.TableInfo = &SimpleTableInfo{}
+			}
+			if err := m.TableInfo.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PreTableInfo", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowEventProtocol
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.PreTableInfo == nil {
+				m.PreTableInfo = &SimpleTableInfo{}
+			}
+			if err := m.PreTableInfo.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Query", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowEventProtocol
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Query = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Type", wireType)
+			}
+			m.Type = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowEventProtocol
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Type |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipEventProtocol(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *RawKVEntry) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowEventProtocol
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: RawKVEntry: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: RawKVEntry: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field OpType", wireType)
+			}
+			m.OpType = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowEventProtocol
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.OpType |= OpType(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Key", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowEventProtocol
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Key = append(m.Key[:0], dAtA[iNdEx:postIndex]...)
+			if m.Key == nil {
+				m.Key = []byte{}
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Value", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowEventProtocol
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Value = append(m.Value[:0], dAtA[iNdEx:postIndex]...)
+			if m.Value == nil {
+				m.Value = []byte{}
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field OldValue", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowEventProtocol
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.OldValue = append(m.OldValue[:0], dAtA[iNdEx:postIndex]...)
+			if m.OldValue == nil {
+				m.OldValue = []byte{}
+			}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StartTs", wireType)
+			}
+			m.StartTs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowEventProtocol
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.StartTs |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Crts", wireType)
+			}
+			m.Crts = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowEventProtocol
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Crts |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RegionId", wireType)
+			}
+			m.RegionId = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowEventProtocol
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.RegionId |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipEventProtocol(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthEventProtocol
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func skipEventProtocol(dAtA []byte) (n int, err error) {
+	l := len(dAtA)
+	iNdEx := 0
+	depth := 0
+	for iNdEx < l {
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return 0, ErrIntOverflowEventProtocol
+			}
+			if iNdEx >= l {
+				return 0, io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		wireType := int(wire & 0x7)
+		switch wireType {
+		case 0:
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, ErrIntOverflowEventProtocol
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				iNdEx++
+				if dAtA[iNdEx-1] < 0x80 {
+					break
+				}
+			}
+		case 1:
+			iNdEx += 8
+		case 2:
+			var length int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, ErrIntOverflowEventProtocol
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				length |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if length < 0 {
+				return 0, ErrInvalidLengthEventProtocol
+			}
+			iNdEx += length
+		case 3:
+			depth++
+		case 4:
+			if depth == 0 {
+				return 0, ErrUnexpectedEndOfGroupEventProtocol
+			}
+			depth--
+		case 5:
+			iNdEx += 4
+		default:
+			return 0, fmt.Errorf("proto: illegal wireType %d", wireType)
+		}
+		if iNdEx < 0 {
+			return 0, ErrInvalidLengthEventProtocol
+		}
+		if depth == 0 {
+			return iNdEx, nil
+		}
+	}
+	return 0, io.ErrUnexpectedEOF
+}
+
+var (
+	ErrInvalidLengthEventProtocol        = fmt.Errorf("proto: negative length found during unmarshaling")
+	ErrIntOverflowEventProtocol          = fmt.Errorf("proto: integer overflow")
+	ErrUnexpectedEndOfGroupEventProtocol = fmt.Errorf("proto: unexpected end of group")
+)
@@ -7,3 +7,9 @@ echo "generate canal protocol code..."
 
 protoc --gofast_out=./canal EntryProtocol.proto
 protoc --gofast_out=./canal CanalProtocol.proto
+
+echo "generate event protocol code..."
+
+[ ! -d ./event ] && mkdir ./event
+
+protoc --gofast_out=./event EventProtocol.proto
@@ -44,6 +44,8 @@ sub_init() {
 }
 
 sub_up() {
+  # the docker-compose file is generated by the integration framework
+  (cd ../integration && go run . -env=avro -generate-compose)
   sudo docker-compose -f ../docker-compose-avro.yml up --detach
 }
 